@@ -0,0 +1,37 @@
+// Command pgas-gen generates a provider adapter skeleton from a JSON spec.
+//
+// Typical use from a new provider directory:
+//
+//	//go:generate go run pgas/cmd/pgas-gen -spec spec.json -out .
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"pgas/pkg/codegen"
+)
+
+func main() {
+	specPath := flag.String("spec", "spec.json", "path to the adapter spec")
+	outDir := flag.String("out", ".", "directory to write the generated files to")
+	overwrite := flag.Bool("overwrite", false, "replace files that already exist")
+	flag.Parse()
+
+	spec, err := codegen.LoadSpec(*specPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	written, err := codegen.WriteFiles(spec, *outDir, *overwrite)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, path := range written {
+		fmt.Println("wrote", path)
+	}
+}
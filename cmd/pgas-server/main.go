@@ -0,0 +1,170 @@
+// Command pgas-server serves the payment processor over HTTP, and its
+// Prometheus metrics at /metrics.
+//
+//	export PGAS_API_SECRETS=...
+//	pgas-server -addr :8080 -config pgas.json -in-doubt in-doubt.json
+//
+// The API only takes requests signed with one of the comma separated
+// secrets of PGAS_API_SECRETS, see pkg/replay. -insecure serves it without
+// authentication, for local development only.
+//
+// On SIGINT or SIGTERM it stops accepting connections, lets requests in
+// flight finish within the shutdown timeout and writes the report of
+// payments left in doubt.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	apihttp "pgas/pkg/api/http"
+	"pgas/pkg/config"
+	"pgas/pkg/dashboard"
+	"pgas/pkg/metrics"
+	"pgas/pkg/problem"
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/ach"
+	"pgas/pkg/providers/amex"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/upi"
+	"pgas/pkg/providers/visa"
+	"pgas/pkg/replay"
+)
+
+// how far the timestamp of a signed request may be from the server clock
+const requestSkew = 5 * time.Minute
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	configPath := flag.String("config", "", "deployment configuration, see pgas config check")
+	shutdownTimeout := flag.Duration("shutdown-timeout", 30*time.Second, "time given to requests in flight on shutdown")
+	inDoubtPath := flag.String("in-doubt", "", "file the in-doubt report is written to on shutdown, stderr when empty")
+	insecure := flag.Bool("insecure", false, "serve the API without authentication, for local development only")
+	flag.Parse()
+
+	if err := run(*addr, *configPath, *shutdownTimeout, *inDoubtPath, *insecure); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(addr, configPath string, shutdownTimeout time.Duration, inDoubtPath string, insecure bool) error {
+	guard, err := newGuard(os.Getenv("PGAS_API_SECRETS"), insecure)
+	if err != nil {
+		return err
+	}
+
+	collector := metrics.NewCollector()
+	opts := []processor.Option{processor.WithLogger(slog.Default()), processor.WithMetrics(collector)}
+	if configPath != "" {
+		file, err := config.Load(configPath)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, file.Options()...)
+	}
+
+	paymentProcessor := processor.NewPaymentProcessor([]providers.Provider{
+		visa.GetNewVisaPaymentProvider(),
+		mastercard.GetNewMasterCardPaymentProvider(),
+		amex.GetNewAmexPaymentProvider(),
+		upi.GetNewUPIPaymentProvider(),
+		ach.GetNewACHPaymentProvider(),
+	}, opts...)
+
+	api := http.NewServeMux()
+	api.Handle("/v1/", apihttp.NewHandler(paymentProcessor))
+	api.Handle("/v1/dashboard", dashboard.Handler(&dashboard.Embedded{Transactions: paymentProcessor.Transactions()}))
+
+	var handler http.Handler = api
+	if guard != nil {
+		handler = guard.Middleware(api)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", handler)
+	mux.Handle("/metrics", collector)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           problem.Correlate(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe() }()
+	fmt.Fprintf(os.Stderr, "pgas-server listening on %s\n", addr)
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// stop taking requests first, then wait for the gateway calls of the
+	// requests that did not finish in time
+	serverErr := server.Shutdown(shutdownCtx)
+	if errors.Is(serverErr, context.DeadlineExceeded) {
+		serverErr = fmt.Errorf("requests still in flight after %s", shutdownTimeout)
+	}
+	report, err := paymentProcessor.Shutdown(shutdownCtx)
+	if err := writeReport(report, inDoubtPath); err != nil {
+		return err
+	}
+	if len(report.Payments) > 0 {
+		fmt.Fprintf(os.Stderr, "%d payments in doubt\n", len(report.Payments))
+	}
+	return errors.Join(serverErr, err)
+}
+
+// newGuard authenticates the API with the comma separated secrets; it
+// returns nil only when the API is explicitly served insecure
+func newGuard(secrets string, insecure bool) (*replay.Guard, error) {
+	var keys [][]byte
+	for _, secret := range strings.Split(secrets, ",") {
+		if secret = strings.TrimSpace(secret); secret != "" {
+			keys = append(keys, []byte(secret))
+		}
+	}
+
+	switch {
+	case len(keys) > 0:
+		return replay.NewGuard(requestSkew, replay.NewMemoryNonces(), keys...), nil
+	case insecure:
+		fmt.Fprintln(os.Stderr, "pgas-server: serving the API WITHOUT authentication (-insecure)")
+		return nil, nil
+	}
+	return nil, errors.New("PGAS_API_SECRETS is required to authenticate API requests; use -insecure for local development")
+}
+
+func writeReport(report processor.InDoubtReport, path string) error {
+	if path == "" {
+		return report.WriteJSON(os.Stderr)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
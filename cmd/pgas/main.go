@@ -0,0 +1,249 @@
+// Command pgas is the operator CLI.
+//
+//	pgas top -url http://localhost:8080/v1/dashboard
+//	pgas top -demo
+//	pgas config check -file pgas.json -probe
+//	pgas seed -count 5000 -out history.json
+//	pgas fixtures convert -provider visa -in recorded.json -out testdata/fixtures
+//	pgas in-doubt -in transactions.json -out in-doubt.json
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"pgas/pkg/config"
+	"pgas/pkg/dashboard"
+	"pgas/pkg/fixtures"
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/ach"
+	"pgas/pkg/providers/amex"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/upi"
+	"pgas/pkg/providers/visa"
+	"pgas/pkg/seed"
+	"pgas/pkg/store"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "top":
+		err = top(os.Args[2:])
+	case "config":
+		if len(os.Args) < 3 || os.Args[2] != "check" {
+			usage()
+			os.Exit(2)
+		}
+		err = configCheck(os.Args[3:])
+	case "seed":
+		err = seedHistory(os.Args[2:])
+	case "fixtures":
+		if len(os.Args) < 3 || os.Args[2] != "convert" {
+			usage()
+			os.Exit(2)
+		}
+		err = convertFixtures(os.Args[3:])
+	case "in-doubt":
+		err = inDoubt(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: pgas <command> [flags]")
+	fmt.Fprintln(os.Stderr, "")
+	fmt.Fprintln(os.Stderr, "commands:")
+	fmt.Fprintln(os.Stderr, "  top               live per-provider dashboard of a running server")
+	fmt.Fprintln(os.Stderr, "  config check      validate a deployment configuration")
+	fmt.Fprintln(os.Stderr, "  seed              generate synthetic payment history")
+	fmt.Fprintln(os.Stderr, "  fixtures convert  turn recorded map-based responses into golden fixtures")
+	fmt.Fprintln(os.Stderr, "  in-doubt          report unresolved payments of exported transactions")
+}
+
+func top(args []string) error {
+	flags := flag.NewFlagSet("top", flag.ExitOnError)
+	url := flags.String("url", "http://localhost:8080/v1/dashboard", "dashboard endpoint of the server")
+	interval := flags.Duration("interval", 2*time.Second, "refresh interval")
+	demo := flags.Bool("demo", false, "show synthetic live traffic instead of a server")
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	remote := dashboard.NewHTTPSource(*url)
+	if secret := os.Getenv("PGAS_API_SECRET"); secret != "" {
+		remote.Secret = []byte(secret)
+	}
+	var source dashboard.Source = remote
+	if *demo {
+		transactions := store.NewMemoryTransactions(store.MemoryOptions{MaxEntries: 100000})
+		go seed.Live(ctx, transactions, 20, seed.Options{Seed: uint64(time.Now().UnixNano())})
+		source = &dashboard.Embedded{Transactions: transactions}
+	}
+
+	if err := dashboard.Run(ctx, os.Stdout, source, *interval); err != context.Canceled {
+		return err
+	}
+	return nil
+}
+
+func configCheck(args []string) error {
+	flags := flag.NewFlagSet("config check", flag.ExitOnError)
+	path := flags.String("file", "pgas.json", "configuration file")
+	probe := flags.Bool("probe", false, "contact every endpoint")
+	flags.Parse(args)
+
+	file, err := config.Load(*path)
+	if err != nil {
+		return err
+	}
+
+	report := config.Check(context.Background(), file, config.CheckOptions{Probe: *probe})
+	for _, finding := range report.Findings {
+		fmt.Println(finding)
+	}
+	if !report.OK() {
+		return fmt.Errorf("%s: configuration check failed", *path)
+	}
+	fmt.Printf("%s: ok\n", *path)
+	return nil
+}
+
+func seedHistory(args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	count := flags.Int("count", 1000, "payments to generate")
+	days := flags.Int("days", 30, "days of history ending now")
+	seedValue := flags.Uint64("seed", 1, "random seed, the same seed gives the same history")
+	out := flags.String("out", "", "output file, stdout when empty")
+	flags.Parse(args)
+
+	now := time.Now()
+	history := seed.Generate(seed.Options{
+		Count: *count,
+		Start: now.AddDate(0, 0, -*days),
+		End:   now,
+		Seed:  *seedValue,
+	})
+
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = os.Stdout.Write(append(data, '\n'))
+		return err
+	}
+	return os.WriteFile(*out, data, 0o600)
+}
+
+func convertFixtures(args []string) error {
+	flags := flag.NewFlagSet("fixtures convert", flag.ExitOnError)
+	name := flags.String("provider", "", "provider the responses were recorded from")
+	in := flags.String("in", "", "JSON array of {name, kind, response} entries")
+	out := flags.String("out", ".", "fixture directory")
+	flags.Parse(args)
+
+	var provider providers.Provider
+	switch *name {
+	case "ach":
+		provider = ach.GetNewACHPaymentProvider()
+	case "amex":
+		provider = amex.GetNewAmexPaymentProvider()
+	case "mastercard":
+		provider = mastercard.GetNewMasterCardPaymentProvider()
+	case "upi":
+		provider = upi.GetNewUPIPaymentProvider()
+	case "visa":
+		provider = visa.GetNewVisaPaymentProvider()
+	default:
+		return fmt.Errorf("unknown provider %q", *name)
+	}
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return err
+	}
+	var recorded []fixtures.Fixture
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		return fmt.Errorf("%s: %v", *in, err)
+	}
+
+	conversions, err := fixtures.ConvertAll(provider, recorded)
+	if err != nil {
+		return err
+	}
+	for _, conversion := range conversions {
+		path, err := fixtures.Write(*out, conversion.Fixture)
+		if err != nil {
+			return err
+		}
+		fmt.Println(path)
+		if len(conversion.Dropped) > 0 {
+			fmt.Printf("  dropped fields: %v\n", conversion.Dropped)
+		}
+	}
+	return nil
+}
+
+// inDoubt reports the PENDING and UNKNOWN payments of a transaction export,
+// for recovery when the processor cannot be started
+func inDoubt(args []string) error {
+	flags := flag.NewFlagSet("in-doubt", flag.ExitOnError)
+	in := flags.String("in", "", "exported transactions, as written by seed")
+	out := flags.String("out", "", "output file, stdout when empty")
+	flags.Parse(args)
+
+	data, err := os.ReadFile(*in)
+	if err != nil {
+		return err
+	}
+	var history seed.History
+	if err := json.Unmarshal(data, &history); err != nil {
+		return fmt.Errorf("%s: %w", *in, err)
+	}
+
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	for _, tx := range history.Transactions {
+		transactions.Save(tx)
+	}
+	payments, err := processor.InDoubtTransactions(transactions)
+	if err != nil {
+		return err
+	}
+	report := processor.InDoubtReport{Reason: processor.ReportRecovery, GeneratedAt: time.Now(), Payments: payments}
+	if report.Payments == nil {
+		report.Payments = []processor.InDoubtPayment{}
+	}
+
+	if *out == "" {
+		return report.WriteJSON(os.Stdout)
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteJSON(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
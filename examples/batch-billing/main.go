@@ -0,0 +1,218 @@
+// Command batch-billing is an example billing job: it charges a period's
+// subscription renewals as merchant-initiated payments on the cards stored
+// at sign-up, streaming results from ProcessBatch as they complete.
+//
+//	go run ./examples/batch-billing -period 2026-10 \
+//	  -subscriptions examples/batch-billing/subscriptions.csv \
+//	  -signups examples/batch-billing/signups.json
+//
+// The idempotency key of each renewal is its subscription and period, so a
+// job that is rerun after a crash does not charge anyone twice. Sign-ups
+// are the stored transactions of the customer-initiated payments that
+// stored the cards, renewals must reference them.
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/amex"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/visa"
+	"pgas/pkg/store"
+)
+
+// Subscription is one row of the subscriptions file
+type Subscription struct {
+	ID                  string
+	Amount              float64
+	Currency            string
+	CardNumber          string
+	ExpiryMonth         string
+	ExpiryYear          string
+	SignupTransactionID string
+}
+
+// Summary is the outcome of a billing run
+type Summary struct {
+	Period   string         `json:"period"`
+	Charged  int            `json:"charged"`
+	Amount   float64        `json:"amount"`
+	Failed   int            `json:"failed"`
+	Failures map[string]int `json:"failures,omitempty"` // by reason, or error code without one
+	// Retry lists the subscriptions whose failure may succeed on a later run
+	Retry []string `json:"retry,omitempty"`
+}
+
+// readSubscriptions reads the CSV file, the first row is the header
+func readSubscriptions(r io.Reader) ([]Subscription, error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var subscriptions []Subscription
+	for i, row := range rows {
+		if i == 0 {
+			continue
+		}
+		if len(row) != 7 {
+			return nil, fmt.Errorf("line %d: expected 7 columns, got %d", i+1, len(row))
+		}
+		amount, err := strconv.ParseFloat(row[1], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: amount: %v", i+1, err)
+		}
+		subscriptions = append(subscriptions, Subscription{
+			ID:                  row[0],
+			Amount:              amount,
+			Currency:            row[2],
+			CardNumber:          row[3],
+			ExpiryMonth:         row[4],
+			ExpiryYear:          row[5],
+			SignupTransactionID: row[6],
+		})
+	}
+	return subscriptions, nil
+}
+
+// readSignups loads the sign-up payments into a transaction store, where
+// the processor checks the renewals' prior transactions
+func readSignups(r io.Reader) (store.Transactions, error) {
+	var signups []store.Transaction
+	if err := json.NewDecoder(r).Decode(&signups); err != nil {
+		return nil, err
+	}
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	for _, tx := range signups {
+		if err := transactions.Save(tx); err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
+
+// renewal is the payment of a subscription for a period
+func renewal(subscription Subscription, period string) providers.PaymentRequest {
+	return providers.PaymentRequest{
+		IdempotencyKey:        "renewal-" + subscription.ID + "-" + period,
+		Amount:                subscription.Amount,
+		Currency:              subscription.Currency,
+		CardNumber:            subscription.CardNumber,
+		ExpiryMonth:           subscription.ExpiryMonth,
+		ExpiryYear:            subscription.ExpiryYear,
+		InitiatedBy:           providers.InitiatedByMerchant,
+		StoredCredentialUsage: providers.StoredCredentialSubsequent,
+		PriorTransactionID:    subscription.SignupTransactionID,
+		Metadata:              map[string]string{"subscription_id": subscription.ID, "period": period},
+		Tags:                  []string{"renewal"},
+	}
+}
+
+// bill charges every subscription with up to concurrency payments in flight
+func bill(ctx context.Context, payments *processor.PaymentProcessor, subscriptions []Subscription, period string, concurrency int) Summary {
+	requests := make([]providers.PaymentRequest, len(subscriptions))
+	for i, subscription := range subscriptions {
+		requests[i] = renewal(subscription, period)
+	}
+
+	summary := Summary{Period: period, Failures: make(map[string]int)}
+	for result := range payments.ProcessBatch(ctx, requests, concurrency) {
+		subscription := subscriptions[result.Index]
+		if result.Error == nil && result.Response.Status == providers.StatusApproved {
+			summary.Charged++
+			summary.Amount += result.Response.Amount
+			continue
+		}
+
+		summary.Failed++
+		failure, retry := failureOf(result)
+		summary.Failures[failure]++
+		if retry {
+			summary.Retry = append(summary.Retry, subscription.ID)
+		}
+		log.Printf("%s: %s", subscription.ID, failure)
+	}
+	sort.Strings(summary.Retry)
+	return summary
+}
+
+// failureOf names why a renewal was not charged and whether a later run may
+// succeed; the issuer's advice wins over the error's retryability
+func failureOf(result processor.BatchResult) (string, bool) {
+	if result.Error == nil {
+		return "status " + result.Response.Status, false
+	}
+	failure := result.Error.Reason
+	if failure == "" {
+		failure = result.Error.ErrorCode
+	}
+	switch result.Error.Advice {
+	case providers.AdviceDoNotRetry, providers.AdviceUpdateCard:
+		return failure, false
+	}
+	return failure, result.Error.Retryable || result.Error.Reason == providers.ReasonInsufficientFunds
+}
+
+func main() {
+	period := flag.String("period", time.Now().Format("2006-01"), "billing period, part of the idempotency keys")
+	subscriptionsPath := flag.String("subscriptions", "subscriptions.csv", "subscriptions to renew")
+	signupsPath := flag.String("signups", "signups.json", "stored transactions of the sign-up payments")
+	concurrency := flag.Int("concurrency", 8, "payments in flight")
+	flag.Parse()
+
+	summary, err := run(*subscriptionsPath, *signupsPath, *period, *concurrency)
+	if err != nil {
+		log.Fatal(err)
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(summary)
+}
+
+func run(subscriptionsPath, signupsPath, period string, concurrency int) (Summary, error) {
+	subscriptionsFile, err := os.Open(subscriptionsPath)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer subscriptionsFile.Close()
+	subscriptions, err := readSubscriptions(subscriptionsFile)
+	if err != nil {
+		return Summary{}, fmt.Errorf("%s: %v", subscriptionsPath, err)
+	}
+
+	signupsFile, err := os.Open(signupsPath)
+	if err != nil {
+		return Summary{}, err
+	}
+	defer signupsFile.Close()
+	transactions, err := readSignups(signupsFile)
+	if err != nil {
+		return Summary{}, fmt.Errorf("%s: %v", signupsPath, err)
+	}
+
+	payments := processor.NewPaymentProcessor([]providers.Provider{
+		visa.GetNewVisaPaymentProvider(),
+		mastercard.GetNewMasterCardPaymentProvider(),
+		amex.GetNewAmexPaymentProvider(),
+	},
+		processor.WithTransactionStore(transactions),
+		processor.WithDefaultTimeout(15*time.Second),
+	)
+
+	// a deadline for the whole run, renewals not started by then are
+	// reported as BATCH_CANCELLED and picked up by the next run
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+	return bill(ctx, payments, subscriptions, period, concurrency), nil
+}
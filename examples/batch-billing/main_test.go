@@ -0,0 +1,24 @@
+package main
+
+import (
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestBill(t *testing.T) {
+	summary, err := run("subscriptions.csv", "signups.json", "2026-10", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Charged != 3 || summary.Failed != 2 {
+		t.Fatalf("Expected 3 renewals charged and 2 failed, got %+v", summary)
+	}
+	if summary.Failures[providers.ReasonInsufficientFunds] != 1 || summary.Failures["INVALID_REQUEST"] != 1 {
+		t.Errorf("Expected an insufficient funds decline and an unknown sign-up, got %+v", summary.Failures)
+	}
+	if len(summary.Retry) != 1 || summary.Retry[0] != "sub_003" {
+		t.Errorf("Expected the insufficient funds decline to be retried, got %v", summary.Retry)
+	}
+}
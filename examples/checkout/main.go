@@ -0,0 +1,138 @@
+// Command checkout is an example checkout service embedding pgas as a
+// library: the shop's own HTTP API takes an order and a card, and the
+// payment processor runs in the same process.
+//
+//	go run ./examples/checkout -addr :8081
+//	curl -d '{"order_id": "1001", "amount": 25, "currency": "USD",
+//	  "card_number": "4111111111111111", "expiry_month": "12",
+//	  "expiry_year": "2030", "cvv": "123"}' localhost:8081/checkout
+//
+// The sandbox test cards of pkg/sandbox trigger fixed outcomes, e.g.
+// 4000000000009995 is declined for insufficient funds.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/amex"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/visa"
+	"pgas/pkg/redact"
+)
+
+// Order is what the shop's front end posts to /checkout
+type Order struct {
+	OrderID     string  `json:"order_id"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	CardNumber  string  `json:"card_number"`
+	ExpiryMonth string  `json:"expiry_month"`
+	ExpiryYear  string  `json:"expiry_year"`
+	CVV         string  `json:"cvv"`
+}
+
+// Receipt is the shop's answer, declines carry the processor's code and
+// reason so the front end can ask for another card
+type Receipt struct {
+	OrderID       string            `json:"order_id"`
+	Paid          bool              `json:"paid"`
+	TransactionID string            `json:"transaction_id,omitempty"`
+	Card          redact.MaskedCard `json:"card,omitempty"`
+	ErrorCode     string            `json:"error_code,omitempty"`
+	Reason        string            `json:"reason,omitempty"`
+	Message       string            `json:"message,omitempty"`
+}
+
+// Checkout charges orders through an embedded payment processor
+type Checkout struct {
+	Payments *processor.PaymentProcessor
+}
+
+// NewCheckout sets up the processor the way a shop would: its card
+// providers picked by BIN, a timeout per payment, one retry of errors the
+// gateway marks as safe to replay and an audit log
+func NewCheckout(log audit.Log) *Checkout {
+	payments := processor.NewPaymentProcessor([]providers.Provider{
+		visa.GetNewVisaPaymentProvider(),
+		mastercard.GetNewMasterCardPaymentProvider(),
+		amex.GetNewAmexPaymentProvider(),
+	},
+		processor.WithDefaultTimeout(10*time.Second),
+		processor.WithRetryPolicy(processor.RetryPolicy{
+			MaxAttempts: 2,
+			Backoff:     200 * time.Millisecond,
+			Retryable:   func(paymentError *providers.PaymentError) bool { return paymentError.Retryable },
+		}),
+		processor.WithAuditLog(log),
+	)
+	return &Checkout{Payments: payments}
+}
+
+// Pay charges an order once: the order id is the idempotency key, so a
+// customer double-clicking pay gets the first outcome back
+func (c *Checkout) Pay(ctx context.Context, order Order) Receipt {
+	response, paymentError := c.Payments.ProcessPayment(ctx, providers.PaymentRequest{
+		IdempotencyKey: "order-" + order.OrderID,
+		Amount:         order.Amount,
+		Currency:       order.Currency,
+		CardNumber:     order.CardNumber,
+		ExpiryMonth:    order.ExpiryMonth,
+		ExpiryYear:     order.ExpiryYear,
+		CVV:            order.CVV,
+		OrderData:      map[string]string{"order_id": order.OrderID},
+	})
+
+	receipt := Receipt{OrderID: order.OrderID}
+	if paymentError != nil {
+		receipt.ErrorCode = paymentError.ErrorCode
+		receipt.Reason = paymentError.Reason
+		receipt.Message = paymentError.ErrorMessage
+		return receipt
+	}
+	receipt.Paid = response.Status == providers.StatusApproved
+	receipt.TransactionID = response.TransactionID
+	receipt.Card = response.Card
+	return receipt
+}
+
+func (c *Checkout) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	var order Order
+	if err := json.NewDecoder(r.Body).Decode(&order); err != nil || order.OrderID == "" {
+		http.Error(w, "an order with an order_id is required", http.StatusBadRequest)
+		return
+	}
+
+	receipt := c.Pay(r.Context(), order)
+	status := http.StatusOK
+	if !receipt.Paid {
+		status = http.StatusPaymentRequired
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(receipt)
+}
+
+func main() {
+	addr := flag.String("addr", ":8081", "listen address")
+	flag.Parse()
+
+	mux := http.NewServeMux()
+	mux.Handle("/checkout", NewCheckout(audit.NewMemoryLog()))
+
+	log.Printf("checkout listening on %s", *addr)
+	server := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	log.Fatal(server.ListenAndServe())
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/providers"
+)
+
+func checkout(t *testing.T, handler http.Handler, order string) (int, Receipt) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/checkout", strings.NewReader(order)))
+
+	var receipt Receipt
+	if err := json.Unmarshal(recorder.Body.Bytes(), &receipt); err != nil {
+		t.Fatalf("Expected a receipt, got %d %s", recorder.Code, recorder.Body)
+	}
+	return recorder.Code, receipt
+}
+
+func TestCheckout(t *testing.T) {
+	handler := NewCheckout(audit.NewMemoryLog())
+
+	code, receipt := checkout(t, handler, `{"order_id": "1001", "amount": 25, "currency": "USD", "card_number": "4111111111111111", "expiry_month": "12", "expiry_year": "2030", "cvv": "123"}`)
+	if code != http.StatusOK || !receipt.Paid || receipt.Card.String() != "411111******1111" {
+		t.Errorf("Expected the sandbox card to be approved, got %d %+v", code, receipt)
+	}
+
+	code, receipt = checkout(t, handler, `{"order_id": "1002", "amount": 25, "currency": "USD", "card_number": "4000000000009995", "expiry_month": "12", "expiry_year": "2030", "cvv": "123"}`)
+	if code != http.StatusPaymentRequired || receipt.Paid || receipt.Reason != providers.ReasonInsufficientFunds {
+		t.Errorf("Expected the sandbox card to be declined, got %d %+v", code, receipt)
+	}
+}
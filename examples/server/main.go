@@ -0,0 +1,124 @@
+// Command server is an example standalone deployment: the payment
+// processor behind pgas's REST API, configured from a deployment file that
+// is checked before the server starts.
+//
+//	export VISA_API_KEY=... MASTERCARD_API_KEY=... AMEX_API_KEY=...
+//	go run ./examples/server -config examples/server/pgas.json
+//
+// Only the providers the file names are registered. Clients then use
+// POST /v1/payments, GET /v1/payments/{id}, GET /v1/payments/{id}/timeline
+// and POST /v1/refunds.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	apihttp "pgas/pkg/api/http"
+	"pgas/pkg/audit"
+	"pgas/pkg/config"
+	"pgas/pkg/events"
+	"pgas/pkg/problem"
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/amex"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/visa"
+)
+
+// builtin are the providers a deployment file may name
+var builtin = map[string]func() providers.Provider{
+	"visa":       func() providers.Provider { return visa.GetNewVisaPaymentProvider() },
+	"mastercard": func() providers.Provider { return mastercard.GetNewMasterCardPaymentProvider() },
+	"amex":       func() providers.Provider { return amex.GetNewAmexPaymentProvider() },
+}
+
+// newProcessor registers the file's providers and applies its settings. The
+// in-memory audit log and event history make GetTimeline complete.
+func newProcessor(file config.File) (*processor.PaymentProcessor, error) {
+	var registered []providers.Provider
+	for _, provider := range file.Providers {
+		newProvider, ok := builtin[provider.Name]
+		if !ok {
+			return nil, fmt.Errorf("unknown provider '%s'", provider.Name)
+		}
+		registered = append(registered, newProvider())
+	}
+
+	opts := append(file.Options(),
+		processor.WithAuditLog(audit.NewMemoryLog()),
+		processor.WithEventPublisher(events.NewMemoryPublisher()),
+	)
+	return processor.NewPaymentProcessor(registered, opts...), nil
+}
+
+// newHandler serves the REST API with correlation ids on every error
+func newHandler(payments *processor.PaymentProcessor) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", apihttp.NewHandler(payments))
+	return problem.Correlate(mux)
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "listen address")
+	configPath := flag.String("config", "pgas.json", "deployment configuration")
+	flag.Parse()
+
+	if err := run(*addr, *configPath); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(addr, configPath string) error {
+	file, err := config.Load(configPath)
+	if err != nil {
+		return err
+	}
+	// refuse to start on a configuration pgas config check would reject
+	report := config.Check(context.Background(), file, config.CheckOptions{})
+	for _, finding := range report.Findings {
+		log.Println(finding)
+	}
+	if !report.OK() {
+		return errors.New("configuration has errors")
+	}
+
+	payments, err := newProcessor(file)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{Addr: addr, Handler: newHandler(payments), ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	served := make(chan error, 1)
+	go func() { served <- server.ListenAndServe() }()
+	log.Printf("listening on %s", addr)
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	// payments whose gateway call did not finish need reconciliation
+	inDoubt, err := payments.Shutdown(shutdownCtx)
+	if len(inDoubt.Payments) > 0 {
+		inDoubt.WriteJSON(os.Stderr)
+	}
+	return err
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pgas/pkg/config"
+)
+
+func TestDeploymentFile(t *testing.T) {
+	file, err := config.Load("pgas.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	getenv := func(key string) string { return "set" }
+	if report := config.Check(context.Background(), file, config.CheckOptions{Getenv: getenv}); !report.OK() {
+		t.Fatalf("Expected the example configuration to pass the check, got %+v", report.Findings)
+	}
+
+	payments, err := newProcessor(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(newHandler(payments))
+	defer server.Close()
+
+	response, err := http.Post(server.URL+"/v1/payments", "application/json", strings.NewReader(
+		`{"amount": 25, "currency": "USD", "card_number": "4111111111111111", "expiry_month": "12", "expiry_year": "2030", "cvv": "123"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		t.Errorf("Expected the sandbox card to be approved, got %d", response.StatusCode)
+	}
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"pgas/pkg/processor"
 	"pgas/pkg/providers"
+	"pgas/pkg/providers/ach"
+	"pgas/pkg/providers/amex"
 	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/upi"
 	"pgas/pkg/providers/visa"
+	"time"
 )
 
 func main() {
@@ -13,9 +18,12 @@ func main() {
 	// Initialize payment providers
 	mastercardProvider := mastercard.GetNewMasterCardPaymentProvider()
 	visaProvider := visa.GetNewVisaPaymentProvider()
+	amexProvider := amex.GetNewAmexPaymentProvider()
+	upiProvider := upi.GetNewUPIPaymentProvider()
+	achProvider := ach.GetNewACHPaymentProvider()
 
 	// Initialize the payment processor
-	paymentProcessor := processor.NewPaymentProcessor([]providers.Provider{mastercardProvider, visaProvider})
+	paymentProcessor := processor.NewPaymentProcessor([]providers.Provider{mastercardProvider, visaProvider, amexProvider, upiProvider, achProvider})
 
 	// Example payment request
 	paymentRequests := providers.PaymentRequest{
@@ -24,11 +32,14 @@ func main() {
 		Currency:    "USD",
 		CardNumber:  "4111111111111111",
 		ExpiryMonth: "12",
-		ExpiryYear:  "2025",
+		ExpiryYear:  "2030",
 		CVV:         "123",
 	}
 
-	res, err := paymentProcessor.ProcessPayment(paymentRequests)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	res, err := paymentProcessor.ProcessPayment(ctx, paymentRequests)
 	if err != nil {
 		fmt.Printf("payment failed: %v", err)
 	}
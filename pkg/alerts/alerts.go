@@ -0,0 +1,333 @@
+// Package alerts evaluates alert rules on payment metrics and publishes
+// firing and resolved alerts as events, so teams without an observability
+// stack still get paged.
+package alerts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/lock"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+type Kind string
+
+const (
+	// KindSuccessRateBelow fires when the success rate stays below Threshold
+	// (0..1) for at least For
+	KindSuccessRateBelow Kind = "success_rate_below"
+	// KindDeclineSpike fires when the share of declines with Reason in Window
+	// exceeds Threshold times its share in the preceding Baseline period
+	KindDeclineSpike Kind = "decline_spike"
+	// KindLatencyP99Above fires when the p99 gateway latency in Window is
+	// above Threshold milliseconds
+	KindLatencyP99Above Kind = "latency_p99_above"
+)
+
+// minimum baseline decline share, keeps a quiet baseline from turning every
+// single decline into a spike
+const minBaselineShare = 0.01
+
+// Rule is one alert condition
+type Rule struct {
+	Name        string        `json:"name"`
+	Kind        Kind          `json:"kind"`
+	Provider    string        `json:"provider,omitempty"` // empty watches all providers
+	Reason      string        `json:"reason,omitempty"`   // decline reason for KindDeclineSpike
+	Threshold   float64       `json:"threshold"`
+	Window      time.Duration `json:"window"`
+	For         time.Duration `json:"for,omitempty"`      // how long the condition must hold
+	Baseline    time.Duration `json:"baseline,omitempty"` // comparison period for spikes
+	MinAttempts int           `json:"min_attempts,omitempty"`
+}
+
+func (r Rule) Validate() error {
+	if r.Name == "" {
+		return errors.New("rule name is required")
+	}
+	if r.Window <= 0 {
+		return fmt.Errorf("rule '%s': window must be positive", r.Name)
+	}
+
+	switch r.Kind {
+	case KindSuccessRateBelow:
+		if r.Threshold <= 0 || r.Threshold > 1 {
+			return fmt.Errorf("rule '%s': success rate threshold must be within (0, 1]", r.Name)
+		}
+	case KindDeclineSpike:
+		if r.Reason == "" || r.Baseline <= 0 || r.Threshold <= 1 {
+			return fmt.Errorf("rule '%s': decline spikes need a reason, a baseline and a factor above 1", r.Name)
+		}
+	case KindLatencyP99Above:
+		if r.Threshold <= 0 {
+			return fmt.Errorf("rule '%s': latency threshold must be positive", r.Name)
+		}
+	default:
+		return fmt.Errorf("rule '%s': unknown kind '%s'", r.Name, r.Kind)
+	}
+	return nil
+}
+
+type State string
+
+const (
+	StateFiring   State = "firing"
+	StateResolved State = "resolved"
+)
+
+// Alert is a state change of a rule
+type Alert struct {
+	Rule    string    `json:"rule"`
+	State   State     `json:"state"`
+	Value   float64   `json:"value"`
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+}
+
+type ruleState struct {
+	breachedSince time.Time
+	firing        bool
+}
+
+type Engine struct {
+	transactions store.Transactions
+	publisher    events.Publisher
+	rules        []Rule
+	now          func() time.Time
+
+	// Locker makes Start evaluate on one instance of a cluster only, so
+	// alerts are not published once per instance
+	Locker lock.Locker
+
+	mu     sync.Mutex
+	states map[string]*ruleState
+}
+
+func NewEngine(transactions store.Transactions, publisher events.Publisher, rules ...Rule) (*Engine, error) {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Engine{
+		transactions: transactions,
+		publisher:    publisher,
+		rules:        rules,
+		now:          time.Now,
+		states:       make(map[string]*ruleState),
+	}, nil
+}
+
+// Evaluate checks every rule once and publishes the alerts whose state changed
+func (e *Engine) Evaluate(ctx context.Context) ([]Alert, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.now()
+	var alerts []Alert
+
+	for _, rule := range e.rules {
+		value, breached, err := e.measure(rule, now)
+		if err != nil {
+			return alerts, err
+		}
+
+		state, ok := e.states[rule.Name]
+		if !ok {
+			state = &ruleState{}
+			e.states[rule.Name] = state
+		}
+
+		if !breached {
+			state.breachedSince = time.Time{}
+			if state.firing {
+				state.firing = false
+				alerts = append(alerts, Alert{Rule: rule.Name, State: StateResolved, Value: value, Time: now,
+					Message: fmt.Sprintf("%s recovered: %s", rule.Name, describe(rule, value))})
+			}
+			continue
+		}
+
+		if state.breachedSince.IsZero() {
+			state.breachedSince = now
+		}
+		if !state.firing && now.Sub(state.breachedSince) >= rule.For {
+			state.firing = true
+			alerts = append(alerts, Alert{Rule: rule.Name, State: StateFiring, Value: value, Time: now,
+				Message: fmt.Sprintf("%s: %s", rule.Name, describe(rule, value))})
+		}
+	}
+
+	for _, alert := range alerts {
+		e.publish(ctx, alert)
+	}
+	return alerts, nil
+}
+
+// Start evaluates the rules every interval until ctx is done. With a
+// Locker, only the elected instance evaluates; another instance takes over
+// within a few intervals when it stops, starting from a clean rule state.
+func (e *Engine) Start(ctx context.Context, interval time.Duration) {
+	if e.Locker != nil {
+		go lock.Lead(ctx, e.Locker, "alerts", 3*interval, func(ctx context.Context) {
+			e.mu.Lock()
+			e.states = make(map[string]*ruleState)
+			e.mu.Unlock()
+			e.run(ctx, interval)
+		})
+		return
+	}
+	go e.run(ctx, interval)
+}
+
+func (e *Engine) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			e.Evaluate(ctx)
+		}
+	}
+}
+
+// Firing lists the names of the rules currently firing
+func (e *Engine) Firing() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var names []string
+	for name, state := range e.states {
+		if state.firing {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (e *Engine) measure(rule Rule, now time.Time) (float64, bool, error) {
+	current, err := e.transactions.Query(store.TransactionFilter{Provider: rule.Provider, Since: now.Add(-rule.Window)})
+	if err != nil {
+		return 0, false, err
+	}
+	current = decided(current)
+	if len(current) == 0 || len(current) < rule.MinAttempts {
+		return 0, false, nil
+	}
+
+	switch rule.Kind {
+	case KindSuccessRateBelow:
+		approved := 0
+		for _, tx := range current {
+			if providers.IsSuccessStatus(tx.Status) {
+				approved++
+			}
+		}
+		rate := float64(approved) / float64(len(current))
+		return rate, rate < rule.Threshold, nil
+
+	case KindDeclineSpike:
+		baseline, err := e.transactions.Query(store.TransactionFilter{
+			Provider: rule.Provider,
+			Since:    now.Add(-rule.Window - rule.Baseline),
+			Until:    now.Add(-rule.Window),
+		})
+		if err != nil {
+			return 0, false, err
+		}
+
+		share := reasonShare(current, rule.Reason)
+		baselineShare := math.Max(reasonShare(decided(baseline), rule.Reason), minBaselineShare)
+		factor := share / baselineShare
+		return factor, factor > rule.Threshold, nil
+
+	case KindLatencyP99Above:
+		p99 := percentile(current, 0.99)
+		return p99, p99 > rule.Threshold, nil
+	}
+
+	return 0, false, nil
+}
+
+func (e *Engine) publish(ctx context.Context, alert Alert) {
+	if e.publisher == nil {
+		return
+	}
+
+	eventType := events.TypeAlertFiring
+	if alert.State == StateResolved {
+		eventType = events.TypeAlertResolved
+	}
+
+	e.publisher.Publish(ctx, events.Event{
+		Type:    eventType,
+		Version: events.SchemaVersion,
+		Time:    alert.Time,
+		Data:    events.Encode(events.AlertData{Rule: alert.Rule, Value: alert.Value, Message: alert.Message}),
+	})
+}
+
+// decided drops payments whose outcome is not known yet
+func decided(transactions []store.Transaction) []store.Transaction {
+	kept := transactions[:0:0]
+	for _, tx := range transactions {
+		if tx.Status != providers.StatusDeferred && tx.Status != providers.StatusUnknown {
+			kept = append(kept, tx)
+		}
+	}
+	return kept
+}
+
+func reasonShare(transactions []store.Transaction, reason string) float64 {
+	if len(transactions) == 0 {
+		return 0
+	}
+
+	matching := 0
+	for _, tx := range transactions {
+		if tx.Reason == reason {
+			matching++
+		}
+	}
+	return float64(matching) / float64(len(transactions))
+}
+
+// percentile uses the nearest-rank method on gateway latencies
+func percentile(transactions []store.Transaction, p float64) float64 {
+	latencies := make([]int64, len(transactions))
+	for i, tx := range transactions {
+		latencies[i] = tx.LatencyMs
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+
+	rank := int(math.Ceil(p*float64(len(latencies)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return float64(latencies[rank])
+}
+
+func describe(rule Rule, value float64) string {
+	switch rule.Kind {
+	case KindSuccessRateBelow:
+		return fmt.Sprintf("success rate %.1f%% (threshold %.1f%%)", value*100, rule.Threshold*100)
+	case KindDeclineSpike:
+		return fmt.Sprintf("'%s' declines at %.1fx baseline (threshold %.1fx)", rule.Reason, value, rule.Threshold)
+	case KindLatencyP99Above:
+		return fmt.Sprintf("p99 latency %.0fms (threshold %.0fms)", value, rule.Threshold)
+	}
+	return ""
+}
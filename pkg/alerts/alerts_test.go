@@ -0,0 +1,141 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/lock"
+	"pgas/pkg/store"
+)
+
+type fixture struct {
+	transactions *store.MemoryTransactions
+	now          time.Time
+	next         int
+}
+
+func (f *fixture) add(age time.Duration, status, reason string, latencyMs int64) {
+	f.next++
+	f.transactions.Save(store.Transaction{
+		ID:        fmt.Sprintf("tx-%d", f.next),
+		Provider:  "visa",
+		Status:    status,
+		Reason:    reason,
+		LatencyMs: latencyMs,
+		CreatedAt: f.now.Add(-age),
+	})
+}
+
+func newFixture() *fixture {
+	return &fixture{transactions: store.NewMemoryTransactions(store.MemoryOptions{}), now: time.Now()}
+}
+
+func TestEngine_SuccessRateBelowFor(t *testing.T) {
+	f := newFixture()
+	publisher := events.NewMemoryPublisher()
+	engine, err := NewEngine(f.transactions, publisher, Rule{
+		Name:      "visa-success",
+		Kind:      KindSuccessRateBelow,
+		Provider:  "visa",
+		Threshold: 0.8,
+		Window:    10 * time.Minute,
+		For:       5 * time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("Expected valid rule, got error: %v", err)
+	}
+	engine.now = func() time.Time { return f.now }
+
+	f.add(time.Minute, "APPROVED", "", 100)
+	f.add(time.Minute, "DECLINED", "card_declined", 100)
+
+	if alerts, _ := engine.Evaluate(context.Background()); len(alerts) != 0 {
+		t.Fatalf("Expected no alert before the condition held long enough, got %+v", alerts)
+	}
+
+	f.now = f.now.Add(5 * time.Minute)
+	alerts, _ := engine.Evaluate(context.Background())
+	if len(alerts) != 1 || alerts[0].State != StateFiring || alerts[0].Value != 0.5 {
+		t.Fatalf("Expected firing alert at 50%%, got %+v", alerts)
+	}
+
+	if alerts, _ := engine.Evaluate(context.Background()); len(alerts) != 0 {
+		t.Errorf("Expected firing alert not to repeat, got %+v", alerts)
+	}
+
+	for i := 0; i < 10; i++ {
+		f.add(0, "APPROVED", "", 100)
+	}
+	alerts, _ = engine.Evaluate(context.Background())
+	if len(alerts) != 1 || alerts[0].State != StateResolved {
+		t.Fatalf("Expected resolved alert, got %+v", alerts)
+	}
+
+	published := publisher.Events()
+	if len(published) != 2 || published[0].Type != events.TypeAlertFiring || published[1].Type != events.TypeAlertResolved {
+		t.Errorf("Expected firing and resolved events, got %+v", published)
+	}
+}
+
+func TestEngine_DeclineSpikeAndLatency(t *testing.T) {
+	f := newFixture()
+	engine, _ := NewEngine(f.transactions, nil,
+		Rule{Name: "fraud-spike", Kind: KindDeclineSpike, Reason: "suspected_fraud", Threshold: 3, Window: 10 * time.Minute, Baseline: time.Hour},
+		Rule{Name: "slow", Kind: KindLatencyP99Above, Threshold: 1000, Window: 10 * time.Minute},
+	)
+	engine.now = func() time.Time { return f.now }
+
+	// baseline: 1 fraud decline in 20 payments, now 2 in 4
+	for i := 0; i < 19; i++ {
+		f.add(30*time.Minute, "APPROVED", "", 100)
+	}
+	f.add(30*time.Minute, "DECLINED", "suspected_fraud", 100)
+	f.add(time.Minute, "APPROVED", "", 200)
+	f.add(time.Minute, "APPROVED", "", 300)
+	f.add(time.Minute, "DECLINED", "suspected_fraud", 250)
+	f.add(time.Minute, "DECLINED", "suspected_fraud", 1500)
+
+	engine.Evaluate(context.Background())
+
+	firing := engine.Firing()
+	if len(firing) != 2 || firing[0] != "fraud-spike" || firing[1] != "slow" {
+		t.Errorf("Expected both rules to fire, got %v", firing)
+	}
+}
+
+func TestRule_Validate(t *testing.T) {
+	if _, err := NewEngine(nil, nil, Rule{Name: "bad", Kind: KindDeclineSpike, Window: time.Minute, Threshold: 2}); err == nil {
+		t.Error("Expected decline spike without reason and baseline to be rejected")
+	}
+
+	if _, err := NewEngine(nil, nil, Rule{Name: "bad", Kind: "p50", Window: time.Minute}); err == nil {
+		t.Error("Expected unknown kind to be rejected")
+	}
+}
+
+func TestEngine_StartWithLocker(t *testing.T) {
+	f := newFixture()
+	f.add(time.Minute, "DECLINED", "card_declined", 100)
+
+	publisher := events.NewMemoryPublisher()
+	locker := lock.NewMemoryLocker()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// two instances sharing the store and the locker
+	for i := 0; i < 2; i++ {
+		engine, _ := NewEngine(f.transactions, publisher, Rule{
+			Name: "success", Kind: KindSuccessRateBelow, Threshold: 0.8, Window: 10 * time.Minute,
+		})
+		engine.Locker = locker
+		engine.Start(ctx, 10*time.Millisecond)
+	}
+	<-ctx.Done()
+
+	if published := publisher.Events(); len(published) != 1 {
+		t.Errorf("Expected the alert to fire once across instances, got %d events", len(published))
+	}
+}
@@ -0,0 +1,83 @@
+// Package api is the stable contract of pgas for third parties: the payment
+// types, the provider interface and its optional capabilities, stores, event
+// publishers and hooks. It only depends on the small leaf packages that
+// define these contracts, never on the processor, so a provider or store
+// implementation can import it without pulling in the processor dependency
+// tree. The names below are aliases, values can be passed to the processor
+// and its options unchanged.
+//
+// The implementation packages are still under pkg/, not internal/, so
+// existing importers keep building. Only this package is covered by the
+// compatibility promise.
+package api
+
+import (
+	"pgas/pkg/audit"
+	"pgas/pkg/compress"
+	"pgas/pkg/events"
+	"pgas/pkg/fraud"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+	"pgas/pkg/threeds"
+)
+
+// payments
+type (
+	PaymentRequest  = providers.PaymentRequest
+	PaymentResponse = providers.PaymentResponse
+	PaymentError    = providers.PaymentError
+	Overrides       = providers.Overrides
+	Authentication  = providers.Authentication
+	Challenge       = providers.Challenge
+	RefundRequest   = providers.RefundRequest
+	RefundResponse  = providers.RefundResponse
+	RefundReason    = providers.RefundReason
+	CreditRequest   = providers.CreditRequest
+	CreditResponse  = providers.CreditResponse
+	Quote           = providers.Quote
+	InstallmentPlan = providers.InstallmentPlan
+)
+
+// providers and their optional capabilities, detected with type assertions
+type (
+	Provider           = providers.Provider
+	Quoter             = providers.Quoter
+	Refunder           = providers.Refunder
+	Creditor           = providers.Creditor
+	InstallmentPlanner = providers.InstallmentPlanner
+)
+
+// stores
+type (
+	Transaction       = store.Transaction
+	TransactionFilter = store.TransactionFilter
+	StatusChange      = store.StatusChange
+	TransactionStore  = store.Transactions
+	IdempotencyStore  = store.Idempotency
+	IdempotencyRecord = store.IdempotencyRecord
+	Blob              = store.Blob
+	BlobStore         = store.Blobs
+	Compressor        = compress.Compressor
+)
+
+// events and audit
+type (
+	Event          = events.Event
+	EventPublisher = events.Publisher
+	AuditEntry     = audit.Entry
+	AuditLog       = audit.Log
+)
+
+// hooks
+type (
+	Authenticator = threeds.Authenticator
+	// FraudProvider scores payments with an external fraud service
+	FraudProvider   = fraud.Provider
+	FraudRequest    = fraud.Request
+	FraudAssessment = fraud.Assessment
+	// OverrideAuthorizer accepts or rejects the credentials of a request
+	// carrying overrides; returning nil authorizes all overrides on the request
+	OverrideAuthorizer func(overrides Overrides) error
+	// RetryableFunc decides whether a failed gateway attempt may be replayed
+	RetryableFunc func(paymentError *PaymentError) bool
+)
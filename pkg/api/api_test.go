@@ -0,0 +1,46 @@
+package api
+
+import (
+	"go/build"
+	"strings"
+	"testing"
+)
+
+// the contract packages must stay free of the processor and its wiring
+func TestDependencies(t *testing.T) {
+	allowed := map[string]bool{
+		"pgas/pkg/api":       true,
+		"pgas/pkg/audit":     true,
+		"pgas/pkg/codec":     true,
+		"pgas/pkg/compress":  true,
+		"pgas/pkg/events":    true,
+		"pgas/pkg/fraud":     true,
+		"pgas/pkg/problem":   true,
+		"pgas/pkg/providers": true,
+		"pgas/pkg/redact":    true,
+		"pgas/pkg/store":     true,
+		"pgas/pkg/threeds":   true,
+	}
+
+	seen := make(map[string]bool)
+	var walk func(path string)
+	walk = func(path string) {
+		if seen[path] || !strings.HasPrefix(path, "pgas/") {
+			return
+		}
+		seen[path] = true
+		if !allowed[path] {
+			t.Errorf("pgas/pkg/api depends on %s", path)
+			return
+		}
+
+		pkg, err := build.Import(path, ".", 0)
+		if err != nil {
+			t.Fatalf("importing %s: %v", path, err)
+		}
+		for _, imported := range pkg.Imports {
+			walk(imported)
+		}
+	}
+	walk("pgas/pkg/api")
+}
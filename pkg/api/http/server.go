@@ -0,0 +1,178 @@
+// Package http serves a payment processor as a REST service: payments,
+// refunds and payment lookups as JSON, every error as a problem.Problem.
+// Import it under another name, e.g.
+//
+//	apihttp "pgas/pkg/api/http"
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pgas/pkg/problem"
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/schema"
+	"pgas/pkg/store"
+)
+
+// routes served by Handler
+const (
+	PathPayments = "/v1/payments"
+	PathRefunds  = "/v1/refunds"
+)
+
+// HeaderIdempotencyKey sets the idempotency key of payments whose body has
+// none
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// Handler serves the processor's payments API:
+//
+//	POST /v1/payments       process a providers.PaymentRequest
+//	GET  /v1/payments/{id}  the stored store.Transaction
+//	GET  /v1/payments/{id}/timeline
+//	                        the payment's processor.TimelineEntry list
+//	POST /v1/refunds        refund with a providers.RefundRequest
+//
+// Bodies are validated with schema.Decode before they reach the processor.
+// Wrap the handler in problem.Correlate to give errors correlation ids.
+type Handler struct {
+	Processor *processor.PaymentProcessor
+	// Validator checks request bodies, schema.Tags when nil
+	Validator schema.Validator
+}
+
+func NewHandler(paymentProcessor *processor.PaymentProcessor) *Handler {
+	return &Handler{Processor: paymentProcessor}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, timeline := paymentPath(r.URL.Path)
+	switch {
+	case r.URL.Path == PathPayments:
+		if allow(w, r, http.MethodPost) {
+			h.processPayment(w, r)
+		}
+	case id != "" && timeline:
+		if allow(w, r, http.MethodGet) {
+			h.getTimeline(w, r, id)
+		}
+	case id != "":
+		if allow(w, r, http.MethodGet) {
+			h.getPayment(w, r, id)
+		}
+	case r.URL.Path == PathRefunds:
+		if allow(w, r, http.MethodPost) {
+			h.refund(w, r)
+		}
+	default:
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CategoryNotFound, "", "no such endpoint"))
+	}
+}
+
+func (h *Handler) processPayment(w http.ResponseWriter, r *http.Request) {
+	var request providers.PaymentRequest
+	if p := schema.Decode(r, &request, h.validator()); p != nil {
+		problem.Write(w, r, p)
+		return
+	}
+	if request.IdempotencyKey == "" {
+		request.IdempotencyKey = r.Header.Get(HeaderIdempotencyKey)
+	}
+
+	response, paymentError := h.Processor.ProcessPayment(r.Context(), request)
+	if paymentError != nil {
+		problem.Write(w, r, problem.FromPaymentError(paymentError))
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) getPayment(w http.ResponseWriter, r *http.Request, id string) {
+	transactions := h.Processor.Transactions()
+	if transactions == nil {
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CategoryNotFound, "PAYMENT_NOT_FOUND", "payments are not stored by this server"))
+		return
+	}
+
+	tx, err := transactions.Get(id)
+	if errors.Is(err, store.ErrTransactionNotFound) {
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CategoryNotFound, "PAYMENT_NOT_FOUND", "payment '"+id+"' not found"))
+		return
+	}
+	if err != nil {
+		problem.Write(w, r, problem.New(http.StatusInternalServerError, problem.CategoryInternal, "", err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, tx)
+}
+
+func (h *Handler) getTimeline(w http.ResponseWriter, r *http.Request, id string) {
+	timeline, err := h.Processor.GetTimeline(id)
+	if errors.Is(err, store.ErrTransactionNotFound) {
+		problem.Write(w, r, problem.New(http.StatusNotFound, problem.CategoryNotFound, "PAYMENT_NOT_FOUND", "payment '"+id+"' not found"))
+		return
+	}
+	if err != nil {
+		problem.Write(w, r, problem.New(http.StatusInternalServerError, problem.CategoryInternal, "", err.Error()))
+		return
+	}
+	writeJSON(w, http.StatusOK, timeline)
+}
+
+func (h *Handler) refund(w http.ResponseWriter, r *http.Request) {
+	var request providers.RefundRequest
+	if p := schema.Decode(r, &request, h.validator()); p != nil {
+		problem.Write(w, r, p)
+		return
+	}
+
+	response, paymentError := h.Processor.RefundPayment(r.Context(), request)
+	if paymentError != nil {
+		problem.Write(w, r, problem.FromPaymentError(paymentError))
+		return
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) validator() schema.Validator {
+	if h.Validator != nil {
+		return h.Validator
+	}
+	return schema.Tags
+}
+
+// paymentPath returns the payment id of /v1/payments/{id} and
+// /v1/payments/{id}/timeline paths, empty for other paths
+func paymentPath(path string) (id string, timeline bool) {
+	rest, ok := strings.CutPrefix(path, PathPayments+"/")
+	if !ok {
+		return "", false
+	}
+	if before, ok := strings.CutSuffix(rest, "/timeline"); ok {
+		rest, timeline = before, true
+	}
+	if strings.Contains(rest, "/") {
+		return "", false
+	}
+	return rest, timeline
+}
+
+// allow answers requests of other methods with 405 and reports whether the
+// request may be served
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	problem.Write(w, r, problem.New(http.StatusMethodNotAllowed, problem.CategoryMethodNotAllowed, "", "use "+method))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
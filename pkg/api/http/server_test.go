@@ -0,0 +1,125 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pgas/pkg/problem"
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// testProvider approves amounts up to 1000 and declines the rest
+type testProvider struct{}
+
+func (testProvider) GetName() string { return "test" }
+
+func (testProvider) ValidateRequest(request providers.PaymentRequest) error { return nil }
+
+func (testProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	if request.Amount > 1000 {
+		return nil, &providers.PaymentError{ErrorCode: "INSUFFICIENT_FUNDS", ErrorMessage: "insufficient funds", Reason: providers.ReasonInsufficientFunds}
+	}
+	return &providers.PaymentResponse{Success: true, TransactionID: "tx-" + request.IdempotencyKey, Status: providers.StatusApproved, Amount: request.Amount, Currency: request.Currency}, nil
+}
+
+func (testProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	return response.(*providers.PaymentResponse), nil
+}
+
+func (testProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	return response.(*providers.PaymentError), nil
+}
+
+func (testProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	return nil, providers.ErrRefundNotSupported
+}
+
+func (testProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return nil, providers.ErrStatusQueryNotSupported
+}
+
+func serve(t *testing.T, handler http.Handler, method, path, body string, header http.Header) (*httptest.ResponseRecorder, problem.Problem) {
+	t.Helper()
+	request := httptest.NewRequest(method, path, strings.NewReader(body))
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	var p problem.Problem
+	if recorder.Header().Get("Content-Type") == problem.ContentType {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return recorder, p
+}
+
+const payment = `{"mode": "test", "amount": %s, "currency": "USD", "card_number": "4111111111111111", "expiry_month": "12", "expiry_year": "2030", "cvv": "123"}`
+
+func body(amount string) string {
+	return strings.Replace(payment, "%s", amount, 1)
+}
+
+func TestHandler_Payments(t *testing.T) {
+	paymentProcessor := processor.NewPaymentProcessor([]providers.Provider{testProvider{}})
+	handler := NewHandler(paymentProcessor)
+
+	recorder, _ := serve(t, handler, http.MethodPost, PathPayments, body("25"), http.Header{HeaderIdempotencyKey: {"key-1"}})
+	var response providers.PaymentResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); recorder.Code != http.StatusOK || err != nil || response.GatewayReference != "tx-key-1" {
+		t.Fatalf("Expected the payment approved with the header's idempotency key, got %d %s", recorder.Code, recorder.Body)
+	}
+
+	recorder, _ = serve(t, handler, http.MethodGet, PathPayments+"/"+response.TransactionID, "", nil)
+	var tx store.Transaction
+	if err := json.Unmarshal(recorder.Body.Bytes(), &tx); recorder.Code != http.StatusOK || err != nil || tx.Amount != 25 || tx.Status != providers.StatusApproved {
+		t.Errorf("Expected the stored payment, got %d %s", recorder.Code, recorder.Body)
+	}
+
+	if recorder, p := serve(t, handler, http.MethodGet, PathPayments+"/missing", "", nil); recorder.Code != http.StatusNotFound || p.Code != "PAYMENT_NOT_FOUND" {
+		t.Errorf("Expected PAYMENT_NOT_FOUND, got %d %+v", recorder.Code, p)
+	}
+
+	recorder, _ = serve(t, handler, http.MethodGet, PathPayments+"/"+response.TransactionID+"/timeline", "", nil)
+	var timeline []processor.TimelineEntry
+	if err := json.Unmarshal(recorder.Body.Bytes(), &timeline); recorder.Code != http.StatusOK || err != nil || len(timeline) == 0 || timeline[len(timeline)-1].Status != providers.StatusApproved {
+		t.Errorf("Expected the payment's timeline, got %d %s", recorder.Code, recorder.Body)
+	}
+	if recorder, p := serve(t, handler, http.MethodGet, PathPayments+"/missing/timeline", "", nil); recorder.Code != http.StatusNotFound || p.Code != "PAYMENT_NOT_FOUND" {
+		t.Errorf("Expected PAYMENT_NOT_FOUND for the timeline, got %d %+v", recorder.Code, p)
+	}
+
+	if recorder, p := serve(t, handler, http.MethodPost, PathPayments, body("2000"), nil); recorder.Code != http.StatusPaymentRequired || p.Code != "INSUFFICIENT_FUNDS" || p.Type != problem.TypeURI(providers.ReasonInsufficientFunds) {
+		t.Errorf("Expected the decline as a 402 problem, got %d %+v", recorder.Code, p)
+	}
+}
+
+func TestHandler_RejectsRequests(t *testing.T) {
+	handler := NewHandler(processor.NewPaymentProcessor([]providers.Provider{testProvider{}}))
+
+	if recorder, p := serve(t, handler, http.MethodPost, PathPayments, body("-1"), nil); recorder.Code != http.StatusUnprocessableEntity || len(p.InvalidParams) != 1 || p.InvalidParams[0].Name != "amount" {
+		t.Errorf("Expected the amount rejected by validation, got %d %+v", recorder.Code, p)
+	}
+	if recorder, p := serve(t, handler, http.MethodPost, PathPayments, `{"amount": "ten"}`, nil); recorder.Code != http.StatusBadRequest || p.Code != "MALFORMED_REQUEST" {
+		t.Errorf("Expected a malformed request, got %d %+v", recorder.Code, p)
+	}
+	if recorder, _ := serve(t, handler, http.MethodGet, PathRefunds, "", nil); recorder.Code != http.StatusMethodNotAllowed || recorder.Header().Get("Allow") != http.MethodPost {
+		t.Errorf("Expected 405 allowing POST, got %d", recorder.Code)
+	}
+	if recorder, _ := serve(t, handler, http.MethodGet, PathPayments+"/a/b", "", nil); recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected unknown paths to be 404, got %d", recorder.Code)
+	}
+
+	recorder, p := serve(t, handler, http.MethodPost, PathRefunds, `{"mode": "test", "transaction_id": "tx-1", "amount": 5, "currency": "USD", "reason": "duplicate"}`, nil)
+	if recorder.Code != http.StatusUnprocessableEntity || p.Code != "REFUND_NOT_SUPPORTED" {
+		t.Errorf("Expected REFUND_NOT_SUPPORTED, got %d %+v", recorder.Code, p)
+	}
+}
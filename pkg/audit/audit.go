@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"sync"
+	"time"
+)
+
+// Entry is a single auditable action taken by or on behalf of an actor
+type Entry struct {
+	Time      time.Time         `json:"time"`
+	Actor     string            `json:"actor"`
+	Action    string            `json:"action"`
+	Reference string            `json:"reference,omitempty"` // transaction or payment the action applies to
+	Details   map[string]string `json:"details,omitempty"`
+}
+
+// Log receives audit entries; implementations must be safe for concurrent use
+type Log interface {
+	Record(entry Entry)
+}
+
+// Reader is implemented by logs whose entries can be read back, such as
+// MemoryLog
+type Reader interface {
+	Entries() []Entry
+}
+
+// MemoryLog keeps audit entries in memory, mainly for tests and embedded use
+type MemoryLog struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+func NewMemoryLog() *MemoryLog {
+	return &MemoryLog{}
+}
+
+func (l *MemoryLog) Record(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+}
+
+// Entries returns a copy of all recorded entries in order
+func (l *MemoryLog) Entries() []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Entry(nil), l.entries...)
+}
+
+// Filter returns the entries matching the action
+func (l *MemoryLog) Filter(action string) []Entry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var matched []Entry
+	for _, entry := range l.entries {
+		if entry.Action == action {
+			matched = append(matched, entry)
+		}
+	}
+	return matched
+}
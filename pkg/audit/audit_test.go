@@ -0,0 +1,23 @@
+package audit
+
+import "testing"
+
+func TestMemoryLog(t *testing.T) {
+	log := NewMemoryLog()
+
+	log.Record(Entry{Actor: "ops", Action: "override.force_provider"})
+	log.Record(Entry{Actor: "ops", Action: "override.disable_retries"})
+
+	entries := log.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	if entries[0].Time.IsZero() {
+		t.Error("Expected entry time to be set")
+	}
+
+	if matched := log.Filter("override.disable_retries"); len(matched) != 1 {
+		t.Errorf("Expected 1 filtered entry, got %d", len(matched))
+	}
+}
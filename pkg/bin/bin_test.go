@@ -0,0 +1,53 @@
+package bin
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	testCases := []struct {
+		cardNumber string
+		network    string
+	}{
+		{"4111111111111111", Visa},
+		{"4111 1111 1111 1111", Visa},
+		{"5555555555554444", Mastercard},
+		{"5105-1051-0510-5100", Mastercard},
+		{"2221000000000009", Mastercard},
+		{"2720990000000007", Mastercard},
+		{"378282246310005", Amex},
+		{"341111111111111", Amex},
+		{"6011111111111117", Discover},
+		{"6445644564456445", Discover},
+		{"3530111333300000", JCB},
+		{"6200000000000005", UnionPay},
+		{"30569309025904", Diners},
+		{"36227206271667", Diners},
+		{"2720999999999996", Mastercard},
+		{"2721000000000005", ""}, // just past the 2-series range
+		{"2220990000000000", ""},
+		{"5600000000000000", ""},
+		{"9", ""},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		network, ok := Detect(tc.cardNumber)
+		if network != tc.network || ok != (tc.network != "") {
+			t.Errorf("%q: expected %q, got %q (%v)", tc.cardNumber, tc.network, network, ok)
+		}
+	}
+}
+
+func TestTable_MostSpecificRangeWins(t *testing.T) {
+	table := NewTable(DefaultRanges...)
+	table.Add(Range{Low: "411111", High: "411111", Network: "cobrand"})
+
+	if network, _ := table.Detect("4111111111111111"); network != "cobrand" {
+		t.Errorf("Expected the six-digit range to win, got %s", network)
+	}
+	if network, _ := table.Detect("4000000000000002"); network != Visa {
+		t.Errorf("Expected other Visa cards unchanged, got %s", network)
+	}
+	if network, _ := Detect("4111111111111111"); network != Visa {
+		t.Errorf("Expected the default table unchanged, got %s", network)
+	}
+}
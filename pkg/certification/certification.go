@@ -0,0 +1,149 @@
+// Package certification holds the edge cases every card provider's
+// ValidateRequest is expected to agree on: Luhn and length of card numbers
+// per network, expiry dates around the current month, card security codes,
+// amount boundaries and 3-D Secure data. Custom providers run the vectors in
+// their tests to reject and accept exactly what the built-in providers do.
+package certification
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"pgas/pkg/bin"
+	"pgas/pkg/providers"
+)
+
+// vector categories, the unit Options.Skip works on
+const (
+	CategoryLuhn           = "luhn"
+	CategoryExpiry         = "expiry"
+	CategoryCVV            = "cvv"
+	CategoryAmount         = "amount"
+	CategoryAuthentication = "3ds"
+)
+
+// Vector is one edge case. Apply turns the valid base request of a network
+// into the case; Valid is whether ValidateRequest must accept the result.
+type Vector struct {
+	Name     string
+	Category string
+	// Network limits the vector to cards of one network, empty for vectors
+	// every network shares
+	Network string
+	Apply   func(request *providers.PaymentRequest, now time.Time)
+	Valid   bool
+}
+
+// Options tunes a certification run
+type Options struct {
+	// Now is the time expiry vectors are relative to, the current time when
+	// zero
+	Now time.Time
+	// Skip lists categories the provider does not validate
+	Skip []string
+}
+
+// Mismatch is a vector the provider judged differently than expected
+type Mismatch struct {
+	Vector Vector
+	Err    error // what ValidateRequest returned, nil if it accepted the case
+}
+
+func (m Mismatch) Error() string {
+	if m.Vector.Valid {
+		return fmt.Sprintf("%s/%s: expected the request to be valid, got: %v", m.Vector.Category, m.Vector.Name, m.Err)
+	}
+	return fmt.Sprintf("%s/%s: expected the request to be rejected, it was accepted", m.Vector.Category, m.Vector.Name)
+}
+
+// test cards of each network that pass every check, the base of all vectors
+var testCards = map[string]string{
+	bin.Visa:       "4111111111111111",
+	bin.Mastercard: "5555555555554444",
+	bin.Amex:       "378282246310005",
+	bin.Discover:   "6011111111111117",
+	bin.JCB:        "3530111333300000",
+	bin.UnionPay:   "6200000000000005",
+	bin.Diners:     "30569309025904",
+}
+
+// Request returns a request for a network that every vector starts from:
+// its test card expiring next year, a security code of the network's
+// length and 100 USD. It reports false for networks without a test card.
+func Request(network string, now time.Time) (providers.PaymentRequest, bool) {
+	cardNumber, ok := testCards[network]
+	if !ok {
+		return providers.PaymentRequest{}, false
+	}
+
+	cvv := "123"
+	if network == bin.Amex {
+		cvv = "1234"
+	}
+
+	return providers.PaymentRequest{
+		Amount:      100,
+		Currency:    "USD",
+		CardNumber:  cardNumber,
+		ExpiryMonth: fmt.Sprintf("%02d", int(now.Month())),
+		ExpiryYear:  fmt.Sprintf("%04d", now.Year()+1),
+		CVV:         cvv,
+	}, true
+}
+
+// Check validates every vector of a network that is not skipped and
+// returns the ones the provider got wrong
+func Check(provider providers.Provider, network string, options Options) ([]Mismatch, error) {
+	var mismatches []Mismatch
+	err := each(network, options, func(vector Vector, request providers.PaymentRequest) {
+		err := provider.ValidateRequest(request)
+		if (err == nil) != vector.Valid {
+			mismatches = append(mismatches, Mismatch{Vector: vector, Err: err})
+		}
+	})
+	return mismatches, err
+}
+
+// Run validates every vector of a network that is not skipped as a subtest
+func Run(t *testing.T, provider providers.Provider, network string, options Options) {
+	t.Helper()
+
+	err := each(network, options, func(vector Vector, request providers.PaymentRequest) {
+		t.Run(vector.Category+"/"+vector.Name, func(t *testing.T) {
+			err := provider.ValidateRequest(request)
+			if (err == nil) != vector.Valid {
+				t.Error(Mismatch{Vector: vector, Err: err})
+			}
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// each builds the request of every vector that applies
+func each(network string, options Options, fn func(Vector, providers.PaymentRequest)) error {
+	now := options.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if _, ok := testCards[network]; !ok {
+		return fmt.Errorf("certification: no test card for network '%s'", network)
+	}
+
+	skipped := make(map[string]bool, len(options.Skip))
+	for _, category := range options.Skip {
+		skipped[category] = true
+	}
+
+	for _, vector := range Vectors {
+		if skipped[vector.Category] || (vector.Network != "" && vector.Network != network) {
+			continue
+		}
+		request, _ := Request(network, now)
+		vector.Apply(&request, now)
+		fn(vector, request)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package certification
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/bin"
+	"pgas/pkg/providers"
+	"pgas/pkg/validation"
+)
+
+// permissive accepts every request, as a provider without local checks does
+type permissive struct {
+	providers.Provider
+}
+
+func (permissive) ValidateRequest(request providers.PaymentRequest) error {
+	return nil
+}
+
+func TestRequest_ValidForEveryNetwork(t *testing.T) {
+	now := time.Date(2026, time.March, 31, 12, 0, 0, 0, time.UTC)
+	for network := range testCards {
+		request, ok := Request(network, now)
+		if !ok {
+			t.Fatalf("%s: expected a base request", network)
+		}
+		if err := validation.ValidateCardNumber(request.CardNumber); err != nil {
+			t.Errorf("%s: test card is invalid: %v", network, err)
+		}
+		if detected, _ := bin.Detect(request.CardNumber); detected != network {
+			t.Errorf("%s: test card belongs to %q", network, detected)
+		}
+		if request.ExpiryMonth != "03" || request.ExpiryYear != "2027" {
+			t.Errorf("%s: expected expiry 03/2027, got %s/%s", network, request.ExpiryMonth, request.ExpiryYear)
+		}
+	}
+
+	if _, ok := Request("maestro", now); ok {
+		t.Error("Expected no base request for a network without test card")
+	}
+}
+
+func TestVectors_LuhnCardsMatchTheirNetwork(t *testing.T) {
+	for _, vector := range Vectors {
+		if vector.Category != CategoryLuhn || vector.Network == "" {
+			continue
+		}
+		request, _ := Request(vector.Network, time.Now())
+		vector.Apply(&request, time.Now())
+
+		if vector.Valid != (validation.ValidateCardNumber(request.CardNumber) == nil) {
+			t.Errorf("%s: card %s does not match Valid %v", vector.Name, request.CardNumber, vector.Valid)
+		}
+		if detected, _ := bin.Detect(request.CardNumber); detected != vector.Network {
+			t.Errorf("%s: card %s belongs to %q", vector.Name, request.CardNumber, detected)
+		}
+	}
+}
+
+func TestVectors_ExpiryRelativeToNow(t *testing.T) {
+	now := time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)
+	expected := map[string]string{
+		"expires this month":      "01/2026",
+		"expires next month":      "02/2026",
+		"expires in twenty years": "01/2046",
+		"expired last month":      "12/2025",
+		"expired last year":       "01/2025",
+	}
+
+	for _, vector := range Vectors {
+		want, ok := expected[vector.Name]
+		if !ok {
+			continue
+		}
+		request, _ := Request(bin.Visa, now)
+		vector.Apply(&request, now)
+		if got := request.ExpiryMonth + "/" + request.ExpiryYear; got != want {
+			t.Errorf("%s: expected %s, got %s", vector.Name, want, got)
+		}
+	}
+}
+
+func TestCheck_ReportsAcceptedInvalidRequests(t *testing.T) {
+	mismatches, err := Check(permissive{}, bin.Amex, Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	invalid := 0
+	for _, vector := range Vectors {
+		if !vector.Valid && (vector.Network == "" || vector.Network == bin.Amex) {
+			invalid++
+		}
+	}
+	if len(mismatches) != invalid {
+		t.Fatalf("Expected %d mismatches, got %d", invalid, len(mismatches))
+	}
+	for _, mismatch := range mismatches {
+		if mismatch.Vector.Network == bin.Visa {
+			t.Errorf("Vector %q of another network was run", mismatch.Vector.Name)
+		}
+		if !strings.Contains(mismatch.Error(), "expected the request to be rejected") {
+			t.Errorf("Unexpected message: %s", mismatch.Error())
+		}
+	}
+}
+
+func TestCheck_SkipsCategories(t *testing.T) {
+	mismatches, err := Check(permissive{}, bin.Visa, Options{Skip: []string{CategoryLuhn, CategoryExpiry, CategoryCVV, CategoryAmount}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, mismatch := range mismatches {
+		if mismatch.Vector.Category != CategoryAuthentication {
+			t.Errorf("Skipped vector %s/%s was run", mismatch.Vector.Category, mismatch.Vector.Name)
+		}
+	}
+}
+
+func TestCheck_UnknownNetwork(t *testing.T) {
+	if _, err := Check(permissive{}, "maestro", Options{}); err == nil {
+		t.Fatal("Expected error for a network without test card")
+	}
+}
+
+func TestMismatch_Error(t *testing.T) {
+	mismatch := Mismatch{Vector: Vector{Name: "visa 13 digits", Category: CategoryLuhn, Valid: true}, Err: errors.New("card number is too short")}
+	if got := mismatch.Error(); got != "luhn/visa 13 digits: expected the request to be valid, got: card number is too short" {
+		t.Errorf("Unexpected message: %s", got)
+	}
+}
@@ -0,0 +1,184 @@
+package certification
+
+import (
+	"fmt"
+	"time"
+
+	"pgas/pkg/bin"
+	"pgas/pkg/providers"
+)
+
+// a CAVV of 20 bytes, base64 encoded
+const cryptogram = "AAABBEg0VhI0VniQEjRWAAAAAAA="
+
+// Vectors are the edge cases of the built-in providers
+var Vectors = concat(luhnVectors, expiryVectors, cvvVectors, amountVectors, authenticationVectors())
+
+var luhnVectors = []Vector{
+	{Name: "visa 16 digits", Category: CategoryLuhn, Network: bin.Visa, Apply: cardNumber("4012888888881881"), Valid: true},
+	{Name: "visa 13 digits", Category: CategoryLuhn, Network: bin.Visa, Apply: cardNumber("4222222222222"), Valid: true},
+	{Name: "visa 19 digits", Category: CategoryLuhn, Network: bin.Visa, Apply: cardNumber("4000000000000000006"), Valid: true},
+	{Name: "visa check digit off by one", Category: CategoryLuhn, Network: bin.Visa, Apply: cardNumber("4111111111111112"), Valid: false},
+	{Name: "visa 15 digits", Category: CategoryLuhn, Network: bin.Visa, Apply: cardNumber("411111111111116"), Valid: false},
+	{Name: "mastercard 5-series", Category: CategoryLuhn, Network: bin.Mastercard, Apply: cardNumber("5105105105105100"), Valid: true},
+	{Name: "mastercard lowest 2-series", Category: CategoryLuhn, Network: bin.Mastercard, Apply: cardNumber("2223003122003222"), Valid: true},
+	{Name: "mastercard highest 2-series", Category: CategoryLuhn, Network: bin.Mastercard, Apply: cardNumber("2720999999999996"), Valid: true},
+	{Name: "mastercard check digit off by one", Category: CategoryLuhn, Network: bin.Mastercard, Apply: cardNumber("5555555555554445"), Valid: false},
+	{Name: "mastercard transposed digits", Category: CategoryLuhn, Network: bin.Mastercard, Apply: cardNumber("5555555555545444"), Valid: false},
+	{Name: "amex 37 prefix", Category: CategoryLuhn, Network: bin.Amex, Apply: cardNumber("378282246310005"), Valid: true},
+	{Name: "amex 34 prefix", Category: CategoryLuhn, Network: bin.Amex, Apply: cardNumber("340000000000009"), Valid: true},
+	{Name: "amex check digit off by one", Category: CategoryLuhn, Network: bin.Amex, Apply: cardNumber("378282246310006"), Valid: false},
+	{Name: "amex 16 digits", Category: CategoryLuhn, Network: bin.Amex, Apply: cardNumber("3782822463100005"), Valid: false},
+	{Name: "discover", Category: CategoryLuhn, Network: bin.Discover, Apply: cardNumber("6011000990139424"), Valid: true},
+	{Name: "discover check digit off by one", Category: CategoryLuhn, Network: bin.Discover, Apply: cardNumber("6011000990139425"), Valid: false},
+	{Name: "jcb", Category: CategoryLuhn, Network: bin.JCB, Apply: cardNumber("3566002020360505"), Valid: true},
+	{Name: "diners 14 digits", Category: CategoryLuhn, Network: bin.Diners, Apply: cardNumber("36227206271667"), Valid: true},
+	{Name: "missing card number", Category: CategoryLuhn, Apply: cardNumber(""), Valid: false},
+	{Name: "spaces between digit groups", Category: CategoryLuhn, Apply: func(request *providers.PaymentRequest, now time.Time) {
+		request.CardNumber = request.CardNumber[:4] + " " + request.CardNumber[4:]
+	}, Valid: false},
+	{Name: "letters", Category: CategoryLuhn, Apply: func(request *providers.PaymentRequest, now time.Time) {
+		request.CardNumber = request.CardNumber[:len(request.CardNumber)-1] + "x"
+	}, Valid: false},
+}
+
+// expiry dates are relative to the current month, a card is valid through
+// the last day of its expiry month
+var expiryVectors = []Vector{
+	{Name: "expires this month", Category: CategoryExpiry, Apply: expiresIn(0), Valid: true},
+	{Name: "expires next month", Category: CategoryExpiry, Apply: expiresIn(1), Valid: true},
+	{Name: "expires in twenty years", Category: CategoryExpiry, Apply: expiresIn(240), Valid: true},
+	{Name: "expired last month", Category: CategoryExpiry, Apply: expiresIn(-1), Valid: false},
+	{Name: "expired last year", Category: CategoryExpiry, Apply: expiresIn(-12), Valid: false},
+	{Name: "month 00", Category: CategoryExpiry, Apply: expiryMonth("00"), Valid: false},
+	{Name: "month 13", Category: CategoryExpiry, Apply: expiryMonth("13"), Valid: false},
+	{Name: "month 99", Category: CategoryExpiry, Apply: expiryMonth("99"), Valid: false},
+	{Name: "missing month", Category: CategoryExpiry, Apply: expiryMonth(""), Valid: false},
+	{Name: "missing year", Category: CategoryExpiry, Apply: func(request *providers.PaymentRequest, now time.Time) {
+		request.ExpiryYear = ""
+	}, Valid: false},
+	{Name: "year with letters", Category: CategoryExpiry, Apply: func(request *providers.PaymentRequest, now time.Time) {
+		request.ExpiryYear = "20ab"
+	}, Valid: false},
+}
+
+// the base request carries a code of the network's length, 4 digits for
+// amex and 3 for the others
+var cvvVectors = []Vector{
+	{Name: "network length", Category: CategoryCVV, Apply: func(request *providers.PaymentRequest, now time.Time) {}, Valid: true},
+	{Name: "amex 3 digits", Category: CategoryCVV, Network: bin.Amex, Apply: cvv("123"), Valid: false},
+	{Name: "2 digits", Category: CategoryCVV, Apply: cvv("12"), Valid: false},
+	{Name: "5 digits", Category: CategoryCVV, Apply: cvv("12345"), Valid: false},
+	{Name: "letters", Category: CategoryCVV, Apply: func(request *providers.PaymentRequest, now time.Time) {
+		request.CVV = request.CVV[:len(request.CVV)-1] + "a"
+	}, Valid: false},
+	{Name: "spaces", Category: CategoryCVV, Apply: func(request *providers.PaymentRequest, now time.Time) {
+		request.CVV = " " + request.CVV[1:]
+	}, Valid: false},
+	{Name: "missing", Category: CategoryCVV, Apply: cvv(""), Valid: false},
+}
+
+// payments must be positive and at most 1,000,000 in any currency
+var amountVectors = []Vector{
+	{Name: "smallest USD amount", Category: CategoryAmount, Apply: amount(0.01, "USD"), Valid: true},
+	{Name: "largest USD amount", Category: CategoryAmount, Apply: amount(1000000, "USD"), Valid: true},
+	{Name: "USD above the limit", Category: CategoryAmount, Apply: amount(1000000.01, "USD"), Valid: false},
+	{Name: "smallest EUR amount", Category: CategoryAmount, Apply: amount(0.01, "EUR"), Valid: true},
+	{Name: "largest EUR amount", Category: CategoryAmount, Apply: amount(1000000, "EUR"), Valid: true},
+	{Name: "smallest JPY amount", Category: CategoryAmount, Apply: amount(1, "JPY"), Valid: true},
+	{Name: "largest JPY amount", Category: CategoryAmount, Apply: amount(1000000, "JPY"), Valid: true},
+	{Name: "JPY above the limit", Category: CategoryAmount, Apply: amount(1000001, "JPY"), Valid: false},
+	{Name: "zero", Category: CategoryAmount, Apply: amount(0, "USD"), Valid: false},
+	{Name: "negative", Category: CategoryAmount, Apply: amount(-1, "USD"), Valid: false},
+	{Name: "missing currency", Category: CategoryAmount, Apply: amount(100, ""), Valid: false},
+}
+
+// ECI values of the networks with 3-D Secure vectors
+var eciValues = []struct {
+	network         string
+	authenticated   string
+	attempted       string
+	unauthenticated string
+	foreign         string // an ECI of another network
+}{
+	{network: bin.Visa, authenticated: "05", attempted: "06", unauthenticated: "07", foreign: "02"},
+	{network: bin.Mastercard, authenticated: "02", attempted: "01", unauthenticated: "00", foreign: "05"},
+	{network: bin.Amex, authenticated: "05", attempted: "06", unauthenticated: "07", foreign: "02"},
+}
+
+func authenticationVectors() []Vector {
+	vectors := []Vector{
+		{Name: "not authenticated", Category: CategoryAuthentication, Apply: func(request *providers.PaymentRequest, now time.Time) {}, Valid: true},
+		{Name: "cryptogram without ECI", Category: CategoryAuthentication, Apply: authentication("", cryptogram), Valid: false},
+		{Name: "DS transaction id without ECI", Category: CategoryAuthentication, Apply: func(request *providers.PaymentRequest, now time.Time) {
+			request.DSTransactionID = "f25084f0-5b16-4c0a-ae5d-b24808a95e4b"
+		}, Valid: false},
+	}
+
+	for _, eci := range eciValues {
+		vectors = append(vectors,
+			Vector{Name: eci.network + " fully authenticated", Category: CategoryAuthentication, Network: eci.network, Apply: authentication(eci.authenticated, cryptogram), Valid: true},
+			Vector{Name: eci.network + " attempted", Category: CategoryAuthentication, Network: eci.network, Apply: authentication(eci.attempted, cryptogram), Valid: true},
+			Vector{Name: eci.network + " unauthenticated ECI", Category: CategoryAuthentication, Network: eci.network, Apply: authentication(eci.unauthenticated, ""), Valid: true},
+			Vector{Name: eci.network + " authenticated without cryptogram", Category: CategoryAuthentication, Network: eci.network, Apply: authentication(eci.authenticated, ""), Valid: false},
+			Vector{Name: eci.network + " ECI of another network", Category: CategoryAuthentication, Network: eci.network, Apply: authentication(eci.foreign, cryptogram), Valid: false},
+			Vector{Name: eci.network + " hex cryptogram", Category: CategoryAuthentication, Network: eci.network, Apply: authentication(eci.authenticated, "0001020304050607080910111213141516171819"), Valid: true},
+			Vector{Name: eci.network + " short cryptogram", Category: CategoryAuthentication, Network: eci.network, Apply: authentication(eci.authenticated, "AAABBEg0VhI0VniQ"), Valid: false},
+			Vector{Name: eci.network + " DS transaction id not a UUID", Category: CategoryAuthentication, Network: eci.network, Apply: func(request *providers.PaymentRequest, now time.Time) {
+				authentication(eci.authenticated, cryptogram)(request, now)
+				request.DSTransactionID = "not-a-uuid"
+			}, Valid: false},
+		)
+	}
+	return vectors
+}
+
+func concat(groups ...[]Vector) []Vector {
+	var vectors []Vector
+	for _, group := range groups {
+		vectors = append(vectors, group...)
+	}
+	return vectors
+}
+
+func cardNumber(number string) func(*providers.PaymentRequest, time.Time) {
+	return func(request *providers.PaymentRequest, now time.Time) {
+		request.CardNumber = number
+	}
+}
+
+// expiresIn sets the expiry to the month the given number of months away
+// from the current one
+func expiresIn(months int) func(*providers.PaymentRequest, time.Time) {
+	return func(request *providers.PaymentRequest, now time.Time) {
+		expiry := time.Date(now.Year(), now.Month()+time.Month(months), 1, 0, 0, 0, 0, now.Location())
+		request.ExpiryMonth = fmt.Sprintf("%02d", int(expiry.Month()))
+		request.ExpiryYear = fmt.Sprintf("%04d", expiry.Year())
+	}
+}
+
+func expiryMonth(month string) func(*providers.PaymentRequest, time.Time) {
+	return func(request *providers.PaymentRequest, now time.Time) {
+		request.ExpiryMonth = month
+	}
+}
+
+func cvv(code string) func(*providers.PaymentRequest, time.Time) {
+	return func(request *providers.PaymentRequest, now time.Time) {
+		request.CVV = code
+	}
+}
+
+func amount(value float64, currency string) func(*providers.PaymentRequest, time.Time) {
+	return func(request *providers.PaymentRequest, now time.Time) {
+		request.Amount = value
+		request.Currency = currency
+	}
+}
+
+func authentication(eci, cavv string) func(*providers.PaymentRequest, time.Time) {
+	return func(request *providers.PaymentRequest, now time.Time) {
+		request.ECI = eci
+		request.Cryptogram = cavv
+	}
+}
@@ -0,0 +1,130 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// Point is a location in the payment pipeline where faults can be injected
+type Point string
+
+const (
+	BeforeProvider  Point = "before_provider"  // before the gateway call is made
+	AfterProvider   Point = "after_provider"   // after the gateway answered, before parsing
+	WebhookDelivery Point = "webhook_delivery" // when a notification is handed to its handler
+)
+
+type Fault string
+
+const (
+	FaultDelay     Fault = "delay"     // sleep before continuing
+	FaultDrop      Fault = "drop"      // lose the response, the caller never sees the outcome
+	FaultFail      Fault = "fail"      // fail the step outright
+	FaultDuplicate Fault = "duplicate" // deliver the same event twice
+)
+
+var (
+	ErrDropped         = errors.New("chaos: response dropped")
+	ErrInjectedFailure = errors.New("chaos: injected failure")
+)
+
+// Rule injects a fault at a pipeline point with the given probability
+type Rule struct {
+	Point       Point
+	Fault       Fault
+	Probability float64
+	Delay       time.Duration // used by FaultDelay
+}
+
+// Injector decides which faults fire. A nil *Injector never injects, so
+// callers can invoke it unconditionally.
+type Injector struct {
+	mu       sync.Mutex
+	rules    []Rule
+	random   *rand.Rand
+	injected map[Fault]int
+}
+
+// New creates an injector; seed makes runs reproducible
+func New(seed uint64, rules ...Rule) *Injector {
+	return &Injector{
+		rules:    rules,
+		random:   rand.New(rand.NewPCG(seed, seed)),
+		injected: make(map[Fault]int),
+	}
+}
+
+func (i *Injector) fires(rule Rule) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.random.Float64() >= rule.Probability {
+		return false
+	}
+
+	i.injected[rule.Fault]++
+	return true
+}
+
+// Inject applies the delay, drop and fail rules configured for the point
+func (i *Injector) Inject(ctx context.Context, point Point) error {
+	if i == nil {
+		return nil
+	}
+
+	for _, rule := range i.rules {
+		if rule.Point != point || rule.Fault == FaultDuplicate || !i.fires(rule) {
+			continue
+		}
+
+		switch rule.Fault {
+		case FaultDelay:
+			select {
+			case <-time.After(rule.Delay):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case FaultDrop:
+			return ErrDropped
+		case FaultFail:
+			return ErrInjectedFailure
+		}
+	}
+
+	return nil
+}
+
+// Deliveries returns how many times an event at the point should be delivered
+func (i *Injector) Deliveries(point Point) int {
+	if i == nil {
+		return 1
+	}
+
+	deliveries := 1
+	for _, rule := range i.rules {
+		if rule.Point == point && rule.Fault == FaultDuplicate && i.fires(rule) {
+			deliveries++
+		}
+	}
+
+	return deliveries
+}
+
+// Stats returns how many times each fault has been injected
+func (i *Injector) Stats() map[Fault]int {
+	if i == nil {
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	stats := make(map[Fault]int, len(i.injected))
+	for fault, count := range i.injected {
+		stats[fault] = count
+	}
+	return stats
+}
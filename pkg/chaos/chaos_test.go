@@ -0,0 +1,66 @@
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInjector_NilIsNoop(t *testing.T) {
+	var injector *Injector
+
+	if err := injector.Inject(context.Background(), BeforeProvider); err != nil {
+		t.Errorf("Expected nil injector to never fail, got: %v", err)
+	}
+
+	if n := injector.Deliveries(WebhookDelivery); n != 1 {
+		t.Errorf("Expected 1 delivery, got %d", n)
+	}
+}
+
+func TestInjector_Faults(t *testing.T) {
+	injector := New(1,
+		Rule{Point: BeforeProvider, Fault: FaultFail, Probability: 1},
+		Rule{Point: AfterProvider, Fault: FaultDrop, Probability: 1},
+		Rule{Point: WebhookDelivery, Fault: FaultDuplicate, Probability: 1},
+	)
+
+	if err := injector.Inject(context.Background(), BeforeProvider); !errors.Is(err, ErrInjectedFailure) {
+		t.Errorf("Expected injected failure, got: %v", err)
+	}
+
+	if err := injector.Inject(context.Background(), AfterProvider); !errors.Is(err, ErrDropped) {
+		t.Errorf("Expected dropped response, got: %v", err)
+	}
+
+	if n := injector.Deliveries(WebhookDelivery); n != 2 {
+		t.Errorf("Expected duplicate delivery, got %d deliveries", n)
+	}
+
+	stats := injector.Stats()
+	if stats[FaultFail] != 1 || stats[FaultDrop] != 1 || stats[FaultDuplicate] != 1 {
+		t.Errorf("Unexpected injection stats: %v", stats)
+	}
+}
+
+func TestInjector_DelayHonorsContext(t *testing.T) {
+	injector := New(1, Rule{Point: BeforeProvider, Fault: FaultDelay, Probability: 1, Delay: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := injector.Inject(ctx, BeforeProvider); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded, got: %v", err)
+	}
+}
+
+func TestInjector_ZeroProbabilityNeverFires(t *testing.T) {
+	injector := New(1, Rule{Point: BeforeProvider, Fault: FaultFail, Probability: 0})
+
+	for i := 0; i < 100; i++ {
+		if err := injector.Inject(context.Background(), BeforeProvider); err != nil {
+			t.Fatalf("Expected no injected fault, got: %v", err)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Codec serializes persisted records and emitted events. JSON, gob and
+// MessagePack are built in; protobuf codecs can be plugged in with Register.
+type Codec interface {
+	Name() string
+	ContentType() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string        { return "json" }
+func (jsonCodec) ContentType() string { return "application/json" }
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string        { return "gob" }
+func (gobCodec) ContentType() string { return "application/x-gob" }
+
+func (gobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+var (
+	JSON    Codec = jsonCodec{}
+	Gob     Codec = gobCodec{}
+	Msgpack Codec = msgpackCodec{}
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Codec{
+		JSON.Name():    JSON,
+		Gob.Name():     Gob,
+		Msgpack.Name(): Msgpack,
+	}
+)
+
+// Register makes a codec available by name, replacing any codec with the same name
+func Register(c Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[c.Name()] = c
+}
+
+// Lookup returns the registered codec with the given name
+func Lookup(name string) (Codec, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	c, ok := registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec: '%s'", name)
+	}
+	return c, nil
+}
+
+// Envelope tags encoded data with the codec that produced it, so records
+// written with one codec stay readable after the default changes
+type Envelope struct {
+	Codec string `json:"codec"`
+	Data  []byte `json:"data"`
+}
+
+// Encode serializes v with the codec into an envelope
+func Encode(c Codec, v interface{}) (Envelope, error) {
+	data, err := c.Marshal(v)
+	if err != nil {
+		return Envelope{}, err
+	}
+	return Envelope{Codec: c.Name(), Data: data}, nil
+}
+
+// Decode deserializes the envelope with the codec it was written with
+func Decode(envelope Envelope, v interface{}) error {
+	c, err := Lookup(envelope.Codec)
+	if err != nil {
+		return err
+	}
+	return c.Unmarshal(envelope.Data, v)
+}
@@ -0,0 +1,114 @@
+package codec
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	original := providers.PaymentResponse{
+		Success:       true,
+		TransactionID: "TX1234567890",
+		Status:        "APPROVED",
+		Amount:        100.50,
+		Currency:      "USD",
+	}
+
+	for _, c := range []Codec{JSON, Gob, Msgpack} {
+		t.Run(c.Name(), func(t *testing.T) {
+			envelope, err := Encode(c, original)
+			if err != nil {
+				t.Fatalf("Expected encoding to succeed, got error: %v", err)
+			}
+
+			if envelope.Codec != c.Name() {
+				t.Errorf("Expected envelope codec %s, got %s", c.Name(), envelope.Codec)
+			}
+
+			var decoded providers.PaymentResponse
+			if err := Decode(envelope, &decoded); err != nil {
+				t.Fatalf("Expected decoding to succeed, got error: %v", err)
+			}
+
+			if !reflect.DeepEqual(decoded, original) {
+				t.Errorf("Expected %+v, got %+v", original, decoded)
+			}
+		})
+	}
+}
+
+// customCodec names itself per instance so tests register codecs nobody
+// registered before, also when run with -count
+type customCodec struct {
+	jsonCodec
+	name string
+}
+
+func (c customCodec) Name() string { return c.name }
+
+func TestRegisterAndLookup(t *testing.T) {
+	name := fmt.Sprintf("custom-%d", time.Now().UnixNano())
+	if _, err := Lookup(name); err == nil {
+		t.Fatal("Expected error for unregistered codec")
+	}
+
+	Register(customCodec{name: name})
+
+	c, err := Lookup(name)
+	if err != nil {
+		t.Fatalf("Expected registered codec, got error: %v", err)
+	}
+	if c.Name() != name {
+		t.Errorf("Expected codec '%s', got %s", name, c.Name())
+	}
+
+	if err := Decode(Envelope{Codec: "protobuf"}, &struct{}{}); err == nil {
+		t.Error("Expected error decoding with unknown codec")
+	}
+}
+
+func TestMsgpack_Compact(t *testing.T) {
+	record := map[string]interface{}{
+		"amount":   100.5,
+		"attempts": 3,
+		"big":      int64(1) << 60,
+		"negative": -40000,
+		"tags":     []string{"a", strings.Repeat("b", 300)},
+		"none":     nil,
+		"ok":       true,
+	}
+
+	encoded, err := Msgpack.Marshal(record)
+	if err != nil {
+		t.Fatalf("Expected encoding to succeed, got error: %v", err)
+	}
+	plain, _ := JSON.Marshal(record)
+	if len(encoded) >= len(plain) {
+		t.Errorf("Expected msgpack to be smaller than JSON, got %d and %d bytes", len(encoded), len(plain))
+	}
+
+	var decoded struct {
+		Amount   float64  `json:"amount"`
+		Attempts int      `json:"attempts"`
+		Big      int64    `json:"big"`
+		Negative int      `json:"negative"`
+		Tags     []string `json:"tags"`
+		OK       bool     `json:"ok"`
+	}
+	if err := Msgpack.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("Expected decoding to succeed, got error: %v", err)
+	}
+	if decoded.Amount != 100.5 || decoded.Attempts != 3 || decoded.Big != 1<<60 || decoded.Negative != -40000 ||
+		len(decoded.Tags) != 2 || len(decoded.Tags[1]) != 300 || !decoded.OK {
+		t.Errorf("Unexpected round trip %+v", decoded)
+	}
+
+	if err := Msgpack.Unmarshal(encoded[:len(encoded)-1], &decoded); err == nil {
+		t.Error("Expected truncated data to fail")
+	}
+}
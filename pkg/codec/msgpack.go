@@ -0,0 +1,324 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// msgpackCodec writes MessagePack. Values go through their JSON form first,
+// so json tags and custom marshalers apply exactly as with the JSON codec
+// and the result is the same document, only smaller.
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string        { return "msgpack" }
+func (msgpackCodec) ContentType() string { return "application/msgpack" }
+
+func (msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var tree interface{}
+	if err := decoder.Decode(&tree); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeMsgpack(&buf, tree); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	reader := &msgpackReader{data: data}
+	tree, err := reader.value()
+	if err != nil {
+		return err
+	}
+	if reader.pos != len(data) {
+		return errors.New("msgpack: trailing data")
+	}
+
+	encoded, err := json.Marshal(tree)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(encoded, v)
+}
+
+func writeMsgpack(buf *bytes.Buffer, value interface{}) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if value {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case json.Number:
+		if n, err := value.Int64(); err == nil {
+			writeMsgpackInt(buf, n)
+			return nil
+		}
+		f, err := value.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	case string:
+		writeMsgpackHeader(buf, len(value), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(value)
+	case []interface{}:
+		writeMsgpackHeader(buf, len(value), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range value {
+			if err := writeMsgpack(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		writeMsgpackHeader(buf, len(value), 0x80, 16, 0, 0xde, 0xdf)
+		for _, key := range keys {
+			writeMsgpack(buf, key)
+			if err := writeMsgpack(buf, value[key]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: cannot encode %T", value)
+	}
+	return nil
+}
+
+// writeMsgpackHeader writes the type and length of a string, array or map:
+// the fix format below fixLimit, then the 8 (if the type has one), 16 and
+// 32 bit formats
+func writeMsgpackHeader(buf *bytes.Buffer, n int, fix byte, fixLimit int, format8, format16, format32 byte) {
+	switch {
+	case n < fixLimit:
+		buf.WriteByte(fix | byte(n))
+	case format8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(format8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(format16)
+		binary.Write(buf, binary.BigEndian, uint16(n))
+	default:
+		buf.WriteByte(format32)
+		binary.Write(buf, binary.BigEndian, uint32(n))
+	}
+}
+
+func writeMsgpackInt(buf *bytes.Buffer, n int64) {
+	switch {
+	case n >= 0 && n <= 127:
+		buf.WriteByte(byte(n))
+	case n < 0 && n >= -32:
+		buf.WriteByte(byte(int8(n)))
+	case n >= math.MinInt8 && n <= math.MaxInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(int8(n)))
+	case n >= math.MinInt16 && n <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		binary.Write(buf, binary.BigEndian, int16(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		binary.Write(buf, binary.BigEndian, int32(n))
+	default:
+		buf.WriteByte(0xd3)
+		binary.Write(buf, binary.BigEndian, n)
+	}
+}
+
+var errMsgpackShort = errors.New("msgpack: unexpected end of data")
+
+// msgpackReader decodes MessagePack into the values encoding/json produces,
+// with json.Number for numbers so integers keep their precision
+type msgpackReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *msgpackReader) next(n int) ([]byte, error) {
+	if n < 0 || len(r.data)-r.pos < n {
+		return nil, errMsgpackShort
+	}
+	b := r.data[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+// length reads a big-endian length or integer of size bytes
+func (r *msgpackReader) length(size int) (int, error) {
+	b, err := r.next(size)
+	if err != nil {
+		return 0, err
+	}
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	if n > uint64(len(r.data)) {
+		return 0, errMsgpackShort
+	}
+	return int(n), nil
+}
+
+func (r *msgpackReader) value() (interface{}, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format := b[0]; {
+	case format <= 0x7f:
+		return json.Number(strconv.Itoa(int(format))), nil
+	case format >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(format)))), nil
+	case format&0xf0 == 0x80:
+		return r.mapOf(int(format & 0x0f))
+	case format&0xf0 == 0x90:
+		return r.arrayOf(int(format & 0x0f))
+	case format&0xe0 == 0xa0:
+		return r.stringOf(int(format & 0x1f))
+	}
+
+	switch format := b[0]; format {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := r.length(1 << (format - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		data, err := r.next(n)
+		return append([]byte(nil), data...), err
+	case 0xca:
+		data, err := r.next(4)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackFloat(float64(math.Float32frombits(binary.BigEndian.Uint32(data))))
+	case 0xcb:
+		data, err := r.next(8)
+		if err != nil {
+			return nil, err
+		}
+		return msgpackFloat(math.Float64frombits(binary.BigEndian.Uint64(data)))
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		data, err := r.next(1 << (format - 0xcc))
+		if err != nil {
+			return nil, err
+		}
+		var n uint64
+		for _, c := range data {
+			n = n<<8 | uint64(c)
+		}
+		return json.Number(strconv.FormatUint(n, 10)), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (format - 0xd0)
+		data, err := r.next(size)
+		if err != nil {
+			return nil, err
+		}
+		var n uint64
+		for _, c := range data {
+			n = n<<8 | uint64(c)
+		}
+		// sign-extend from the encoded width
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(n<<shift)>>shift, 10)), nil
+	case 0xd9, 0xda, 0xdb:
+		n, err := r.length(1 << (format - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return r.stringOf(n)
+	case 0xdc, 0xdd:
+		n, err := r.length(2 << (format - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return r.arrayOf(n)
+	case 0xde, 0xdf:
+		n, err := r.length(2 << (format - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return r.mapOf(n)
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported format 0x%x", format)
+	}
+}
+
+func (r *msgpackReader) stringOf(n int) (interface{}, error) {
+	data, err := r.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (r *msgpackReader) arrayOf(n int) (interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, errMsgpackShort
+	}
+	items := make([]interface{}, n)
+	for i := range items {
+		item, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
+func (r *msgpackReader) mapOf(n int) (interface{}, error) {
+	if n > len(r.data)-r.pos {
+		return nil, errMsgpackShort
+	}
+	values := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := r.value()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(string)
+		if !ok {
+			return nil, fmt.Errorf("msgpack: map key %v is not a string", key)
+		}
+		if values[name], err = r.value(); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func msgpackFloat(f float64) (interface{}, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("msgpack: %v has no JSON form", f)
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, 64)), nil
+}
@@ -0,0 +1,75 @@
+package codegen
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadSpec(t *testing.T) {
+	spec, err := LoadSpec("testdata/acme.json")
+	if err != nil {
+		t.Fatalf("Expected spec to load, got error: %v", err)
+	}
+
+	if spec.Package != "acmepay" || spec.TypeName != "AcmePay" {
+		t.Errorf("Unexpected derived names: package=%s type=%s", spec.Package, spec.TypeName)
+	}
+}
+
+func TestSpec_Validation(t *testing.T) {
+	spec := Spec{Name: "Bad-Name"}
+	if _, err := Generate(spec); err == nil {
+		t.Error("Expected error for invalid provider name")
+	}
+
+	spec = Spec{Name: "acme"}
+	if _, err := Generate(spec); err == nil || !strings.Contains(err.Error(), "success.amount") {
+		t.Errorf("Expected missing mapping error, got: %v", err)
+	}
+}
+
+func TestGenerate_CompilesAndPassesConformance(t *testing.T) {
+	spec, err := LoadSpec("testdata/acme.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := Generate(spec)
+	if err != nil {
+		t.Fatalf("Expected generation to succeed, got error: %v", err)
+	}
+
+	for _, name := range []string{"types.go", "provider.go", "provider_test.go"} {
+		if len(files[name]) == 0 {
+			t.Errorf("Expected %s to be generated", name)
+		}
+	}
+
+	if !strings.Contains(string(files["types.go"]), `Code: "A91", Reason: "issuer_unavailable"`) {
+		t.Error("Expected error code table in types.go")
+	}
+
+	if testing.Short() {
+		t.Skip("skipping compile check in short mode")
+	}
+
+	// generate inside the module so the skeleton can import pgas packages
+	dir, err := os.MkdirTemp("..", "codegen-test-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := WriteFiles(spec, dir, false); err != nil {
+		t.Fatalf("Expected files to be written, got error: %v", err)
+	}
+
+	cmd := exec.Command("go", "test", "./"+filepath.Base(dir))
+	cmd.Dir = ".."
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("Expected generated provider tests to pass, got: %v\n%s", err, output)
+	}
+}
@@ -0,0 +1,358 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Generate renders the provider skeleton files for the spec, keyed by file name
+func Generate(spec Spec) (map[string][]byte, error) {
+	if err := spec.normalize(); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte, len(templates))
+	for name, tmpl := range templates {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, spec); err != nil {
+			return nil, fmt.Errorf("rendering %s: %v", name, err)
+		}
+
+		formatted, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("formatting %s: %v", name, err)
+		}
+		files[name] = formatted
+	}
+
+	return files, nil
+}
+
+// WriteFiles generates the skeleton into dir. Existing files are only
+// replaced when overwrite is set, so hand edits are not lost on regeneration.
+func WriteFiles(spec Spec, dir string, overwrite bool) ([]string, error) {
+	files, err := Generate(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var written []string
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil && !overwrite {
+			continue
+		}
+		if err := os.WriteFile(path, files[name], 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+
+	return written, nil
+}
+
+var funcs = template.FuncMap{
+	"quote":    func(s string) string { return fmt.Sprintf("%q", s) },
+	"exported": exportedName,
+	"literal":  nestedLiteral,
+}
+
+// nestedLiteral renders a map literal from alternating dotted paths and Go
+// expressions, merging paths that share a parent object
+func nestedLiteral(pairs ...string) string {
+	type node struct {
+		expr     string
+		keys     []string
+		children map[string]*node
+	}
+	root := &node{children: map[string]*node{}}
+
+	for i := 0; i+1 < len(pairs); i += 2 {
+		current := root
+		for _, part := range strings.Split(pairs[i], ".") {
+			child, ok := current.children[part]
+			if !ok {
+				child = &node{children: map[string]*node{}}
+				current.children[part] = child
+				current.keys = append(current.keys, part)
+			}
+			current = child
+		}
+		current.expr = pairs[i+1]
+	}
+
+	var render func(n *node) string
+	render = func(n *node) string {
+		if len(n.children) == 0 {
+			return n.expr
+		}
+		entries := make([]string, 0, len(n.keys))
+		for _, key := range n.keys {
+			entries = append(entries, fmt.Sprintf("%q: %s", key, render(n.children[key])))
+		}
+		return "map[string]interface{}{" + strings.Join(entries, ", ") + "}"
+	}
+
+	return render(root)
+}
+
+var templates = map[string]*template.Template{
+	"types.go":         template.Must(template.New("types.go").Funcs(funcs).Parse(typesTemplate)),
+	"provider.go":      template.Must(template.New("provider.go").Funcs(funcs).Parse(providerTemplate)),
+	"provider_test.go": template.Must(template.New("provider_test.go").Funcs(funcs).Parse(testTemplate)),
+}
+
+const header = `// Code generated by pgas-gen from the {{.Name}} adapter spec; edit freely, regenerate with -overwrite only when starting over.
+`
+
+const typesTemplate = header + `
+package {{.Package}}
+
+import "pgas/pkg/providers"
+
+// gateway endpoints
+const (
+{{- range $name, $url := .Endpoints}}
+	endpoint{{exported $name}} = {{quote $url}}
+{{- end}}
+)
+
+// error code table from the adapter spec, see providers.LookupErrorCode
+func init() {
+	providers.RegisterErrorCodes({{quote .Name}},
+{{- range .ErrorCodes}}
+		providers.ErrorCodeInfo{Code: {{quote .Code}}, Reason: {{quote .Reason}}, Description: {{quote .Description}}, Retryable: {{.Retryable}}},
+{{- end}}
+	)
+}
+`
+
+const providerTemplate = header + `
+package {{.Package}}
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"pgas/pkg/providers"
+)
+
+type {{.TypeName}}PaymentProvider struct {
+	Name string
+}
+
+func GetNew{{.TypeName}}PaymentProvider() *{{.TypeName}}PaymentProvider {
+	return &{{.TypeName}}PaymentProvider{Name: {{quote .Name}}}
+}
+
+func (p *{{.TypeName}}PaymentProvider) GetName() string {
+	return p.Name
+}
+
+func (p *{{.TypeName}}PaymentProvider) ValidateRequest(request providers.PaymentRequest) error {
+	if request.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+
+	if request.Currency == "" {
+		return errors.New("currency is required")
+	}
+
+	if request.CardNumber == "" {
+		return errors.New("card number is required")
+	}
+
+	// TODO: add {{.Name}} specific validation rules
+
+	return nil
+}
+
+func (p *{{.TypeName}}PaymentProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	// TODO: call the {{.Name}} gateway; the simulated response below follows the spec field mapping
+	return {{literal .Success.TransactionID "\"TODO-TRANSACTION-ID\"" .Success.Status "\"APPROVED\"" .Success.Amount "strconv.FormatFloat(request.Amount, 'f', -1, 64)" .Success.Currency "request.Currency"}}, nil
+}
+
+func (p *{{.TypeName}}PaymentProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	data, ok := response.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected map[string]interface{}, got %T", response)
+	}
+
+	transactionID, err := stringField(data, {{quote .Success.TransactionID}})
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := stringField(data, {{quote .Success.Status}})
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := stringField(data, {{quote .Success.Currency}})
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := amountField(data, {{quote .Success.Amount}})
+	if err != nil {
+		return nil, err
+	}
+
+	return &providers.PaymentResponse{
+		Success:       true,
+		TransactionID: transactionID,
+		Status:        status,
+		Amount:        amount,
+		Currency:      currency,
+	}, nil
+}
+
+func (p *{{.TypeName}}PaymentProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	data, ok := response.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected map[string]interface{}, got %T", response)
+	}
+
+	code, err := stringField(data, {{quote .Error.Code}})
+	if err != nil {
+		return nil, err
+	}
+
+	message, _ := stringField(data, {{quote .Error.Message}})
+
+	return providers.NewCatalogError(p.Name, code, message), nil
+}
+
+func (p *{{.TypeName}}PaymentProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	// TODO: call the {{.Name}} refund endpoint
+	return nil, providers.ErrRefundNotSupported
+}
+
+func (p *{{.TypeName}}PaymentProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	// TODO: call the {{.Name}} payment lookup endpoint
+	return nil, providers.ErrStatusQueryNotSupported
+}
+
+// lookup resolves a dotted field path in a decoded response
+func lookup(data map[string]interface{}, path string) (interface{}, bool) {
+	var current interface{} = data
+	for _, part := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func stringField(data map[string]interface{}, path string) (string, error) {
+	value, ok := lookup(data, path)
+	if !ok {
+		return "", fmt.Errorf("missing field '%s'", path)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("expected '%s' field to be a string, got %T", path, value)
+	}
+	return s, nil
+}
+
+func amountField(data map[string]interface{}, path string) (float64, error) {
+	value, ok := lookup(data, path)
+	if !ok {
+		return 0, fmt.Errorf("missing field '%s'", path)
+	}
+	switch amount := value.(type) {
+	case float64:
+		return amount, nil
+	case string:
+		return strconv.ParseFloat(amount, 64)
+	}
+	return 0, fmt.Errorf("expected '%s' field to be a number or string, got %T", path, value)
+}
+`
+
+const testTemplate = header + `
+package {{.Package}}
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestGetNew{{.TypeName}}PaymentProvider(t *testing.T) {
+	provider := GetNew{{.TypeName}}PaymentProvider()
+	if provider.GetName() != {{quote .Name}} {
+		t.Errorf("Expected provider name '{{.Name}}', got: %s", provider.GetName())
+	}
+}
+
+func Test{{.TypeName}}Provider_Conformance(t *testing.T) {
+	provider := GetNew{{.TypeName}}PaymentProvider()
+
+	request := providers.PaymentRequest{
+		Mode:        {{quote .Name}},
+		Amount:      100.00,
+		Currency:    "USD",
+		CardNumber:  "4111111111111111",
+		ExpiryMonth: "12",
+		ExpiryYear:  "2030",
+		CVV:         "123",
+	}
+
+	if err := provider.ValidateRequest(request); err != nil {
+		t.Fatalf("Expected valid request, got error: %v", err)
+	}
+
+	response, providerError := provider.ProcessPayment(context.Background(), request)
+	if providerError != nil {
+		t.Fatalf("Expected successful payment, got: %v", providerError)
+	}
+
+	parsed, err := provider.ParseSuccessResponse(response)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	if !parsed.Success || parsed.Amount != request.Amount || parsed.Currency != request.Currency {
+		t.Errorf("Unexpected normalized response: %+v", parsed)
+	}
+}
+
+func Test{{.TypeName}}Provider_ParseErrorResponse(t *testing.T) {
+	provider := GetNew{{.TypeName}}PaymentProvider()
+
+	parsed, err := provider.ParseErrorResponse({{literal .Error.Code "\"E001\"" .Error.Message "\"declined\""}})
+	if err != nil {
+		t.Fatalf("Expected successful error parsing, got error: %v", err)
+	}
+
+	if parsed.Success || parsed.ErrorCode != "E001" {
+		t.Errorf("Unexpected normalized error: %+v", parsed)
+	}
+}
+`
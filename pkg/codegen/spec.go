@@ -0,0 +1,117 @@
+package codegen
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Spec describes a gateway adapter to generate
+type Spec struct {
+	Name       string            `json:"name"`      // provider mode, e.g. "acme"
+	Package    string            `json:"package"`   // defaults to Name
+	TypeName   string            `json:"type_name"` // e.g. "Acme" for AcmePaymentProvider
+	Endpoints  map[string]string `json:"endpoints"`
+	Success    SuccessMapping    `json:"success"`
+	Error      ErrorMapping      `json:"error"`
+	ErrorCodes []ErrorCode       `json:"error_codes"`
+}
+
+// SuccessMapping maps normalized response fields to dotted wire field paths
+type SuccessMapping struct {
+	TransactionID string `json:"transaction_id"`
+	Status        string `json:"status"`
+	Amount        string `json:"amount"`
+	Currency      string `json:"currency"`
+}
+
+// ErrorMapping maps normalized error fields to dotted wire field paths
+type ErrorMapping struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorCode is one row of the gateway's error code table
+type ErrorCode struct {
+	Code        string `json:"code"`
+	Reason      string `json:"reason"`
+	Description string `json:"description"`
+	Retryable   bool   `json:"retryable"`
+}
+
+var identifierPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// LoadSpec reads and validates a JSON spec file
+func LoadSpec(path string) (Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Spec{}, err
+	}
+
+	var spec Spec
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return Spec{}, fmt.Errorf("invalid spec %s: %v", path, err)
+	}
+
+	if err := spec.normalize(); err != nil {
+		return Spec{}, fmt.Errorf("invalid spec %s: %v", path, err)
+	}
+	return spec, nil
+}
+
+func (s *Spec) normalize() error {
+	if !identifierPattern.MatchString(s.Name) {
+		return fmt.Errorf("name %q must be a lowercase identifier", s.Name)
+	}
+	if s.Package == "" {
+		s.Package = strings.ReplaceAll(s.Name, "_", "")
+	}
+	if s.TypeName == "" {
+		s.TypeName = exportedName(s.Name)
+	}
+
+	required := map[string]string{
+		"success.transaction_id": s.Success.TransactionID,
+		"success.status":         s.Success.Status,
+		"success.amount":         s.Success.Amount,
+		"success.currency":       s.Success.Currency,
+		"error.code":             s.Error.Code,
+		"error.message":          s.Error.Message,
+	}
+	var missing []string
+	for field, value := range required {
+		if value == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return errors.New("missing field mappings: " + strings.Join(missing, ", "))
+	}
+
+	seen := make(map[string]bool)
+	for _, code := range s.ErrorCodes {
+		if code.Code == "" || seen[code.Code] {
+			return fmt.Errorf("error codes must be unique and non-empty, got %q", code.Code)
+		}
+		seen[code.Code] = true
+	}
+
+	return nil
+}
+
+func exportedName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
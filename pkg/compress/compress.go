@@ -0,0 +1,89 @@
+// Package compress provides the codecs used to shrink stored blobs such as
+// raw provider responses and certification transcripts. Each stored record
+// names its encoding, so the codec can change without rewriting old records.
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// Compressor compresses blobs. Encoding is stored with every record and must
+// stay stable for as long as such records exist. Codecs outside the standard
+// library, e.g. zstd, are plugged in by implementing this interface.
+type Compressor interface {
+	Encoding() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+// None stores data as is, its encoding is empty like that of records
+// written before compression existed
+var None Compressor = identity{}
+
+type identity struct{}
+
+func (identity) Encoding() string                       { return "" }
+func (identity) Compress(data []byte) ([]byte, error)   { return data, nil }
+func (identity) Decompress(data []byte) ([]byte, error) { return data, nil }
+
+// Gzip compresses with compress/gzip at Level, zero uses the default level
+type Gzip struct {
+	Level int
+}
+
+func (g Gzip) Encoding() string {
+	return "gzip"
+}
+
+func (g Gzip) Compress(data []byte) ([]byte, error) {
+	level := g.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (g Gzip) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// Registry finds the codec for a stored encoding
+type Registry map[string]Compressor
+
+// NewRegistry knows None, Gzip and the given compressors
+func NewRegistry(compressors ...Compressor) Registry {
+	registry := Registry{None.Encoding(): None, "gzip": Gzip{}}
+	for _, compressor := range compressors {
+		registry[compressor.Encoding()] = compressor
+	}
+	return registry
+}
+
+// Decompress decodes data stored with encoding
+func (r Registry) Decompress(encoding string, data []byte) ([]byte, error) {
+	compressor, ok := r[encoding]
+	if !ok {
+		return nil, fmt.Errorf("unknown encoding '%s'", encoding)
+	}
+	return compressor.Decompress(data)
+}
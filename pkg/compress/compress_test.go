@@ -0,0 +1,55 @@
+package compress
+
+import (
+	"bytes"
+	"testing"
+)
+
+// reverse is a toy codec standing in for codecs like zstd
+type reverse struct{}
+
+func (reverse) Encoding() string { return "reverse" }
+
+func (reverse) Compress(data []byte) ([]byte, error) {
+	reversed := make([]byte, len(data))
+	for i, b := range data {
+		reversed[len(data)-1-i] = b
+	}
+	return reversed, nil
+}
+
+func (r reverse) Decompress(data []byte) ([]byte, error) { return r.Compress(data) }
+
+func TestGzip_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"status":"SUCCESS","amount":"100.00"}`), 50)
+
+	compressed, err := Gzip{}.Compress(data)
+	if err != nil {
+		t.Fatalf("Expected compression to succeed, got %v", err)
+	}
+	if len(compressed) >= len(data) {
+		t.Errorf("Expected repetitive data to shrink, got %d bytes from %d", len(compressed), len(data))
+	}
+
+	decompressed, err := NewRegistry().Decompress("gzip", compressed)
+	if err != nil || !bytes.Equal(decompressed, data) {
+		t.Errorf("Expected original data back, got %q, %v", decompressed, err)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	registry := NewRegistry(reverse{})
+
+	if data, err := registry.Decompress("", []byte("plain")); err != nil || string(data) != "plain" {
+		t.Errorf("Expected uncompressed records to be returned as is, got %q, %v", data, err)
+	}
+	if data, err := registry.Decompress("reverse", []byte("nialp")); err != nil || string(data) != "plain" {
+		t.Errorf("Expected plugged-in codec to decode, got %q, %v", data, err)
+	}
+	if _, err := registry.Decompress("zstd", []byte("x")); err == nil {
+		t.Error("Expected error for an unknown encoding")
+	}
+	if _, err := registry.Decompress("gzip", []byte("not gzip")); err == nil {
+		t.Error("Expected error for corrupt data")
+	}
+}
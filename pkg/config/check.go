@@ -0,0 +1,323 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // the deployment must not go ahead
+	SeverityWarning Severity = "warning" // likely a mistake
+)
+
+// Finding is one problem in a configuration
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path"` // e.g. providers[0].url
+	Message  string   `json:"message"`
+}
+
+func (f Finding) String() string {
+	return string(f.Severity) + ": " + f.Path + ": " + f.Message
+}
+
+// Report lists the findings of a check
+type Report struct {
+	Findings []Finding `json:"findings"`
+}
+
+// OK reports whether the configuration has no errors; warnings are allowed
+func (r Report) OK() bool {
+	for _, finding := range r.Findings {
+		if finding.Severity == SeverityError {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Report) add(severity Severity, path, format string, args ...interface{}) {
+	r.Findings = append(r.Findings, Finding{Severity: severity, Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+type CheckOptions struct {
+	// Probe contacts every endpoint to catch unreachable ones
+	Probe        bool
+	ProbeTimeout time.Duration // defaults to 5s
+	Client       *http.Client
+	// Getenv looks up credentials, defaults to os.Getenv
+	Getenv func(key string) string
+}
+
+// Check validates a configuration: credentials are present, endpoints are
+// well formed (and reachable when probing), routing rules reference known
+// providers without conflicting, and limits make sense together.
+func Check(ctx context.Context, file File, opts CheckOptions) Report {
+	if opts.Getenv == nil {
+		opts.Getenv = os.Getenv
+	}
+	if opts.ProbeTimeout <= 0 {
+		opts.ProbeTimeout = 5 * time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: opts.ProbeTimeout}
+	}
+
+	var report Report
+	checkProviders(ctx, &report, file, opts)
+	checkRouting(&report, file)
+	checkLimits(&report, file)
+
+	if file.WebhookURL != "" {
+		checkEndpoint(ctx, &report, "webhook_url", Endpoint{URL: file.WebhookURL}, opts)
+	}
+	if file.ThreeDS != nil {
+		checkEndpoint(ctx, &report, "threeds", *file.ThreeDS, opts)
+	}
+	return report
+}
+
+func checkProviders(ctx context.Context, report *Report, file File, opts CheckOptions) {
+	if len(file.Providers) == 0 {
+		report.add(SeverityError, "providers", "at least one provider is required")
+	}
+
+	seen := make(map[string]bool)
+	for i, provider := range file.Providers {
+		path := index("providers", i)
+		if provider.Name == "" {
+			report.add(SeverityError, path+".name", "provider name is required")
+		} else if seen[provider.Name] {
+			report.add(SeverityError, path+".name", "duplicate provider '%s'", provider.Name)
+		}
+		seen[provider.Name] = true
+
+		if provider.MaxAmount < 0 {
+			report.add(SeverityError, path+".max_amount", "must not be negative")
+		}
+		if provider.Timeout < 0 {
+			report.add(SeverityError, path+".timeout", "must not be negative")
+		}
+		if file.Limits.MaxAmount > 0 && provider.MaxAmount > file.Limits.MaxAmount {
+			report.add(SeverityWarning, path+".max_amount", "%.2f is above the processor limit %.2f and can never be reached",
+				provider.MaxAmount, file.Limits.MaxAmount)
+		}
+
+		checkEndpoint(ctx, report, path, provider.Endpoint, opts)
+		checkMerchantAccounts(report, path, provider.MerchantAccounts)
+	}
+}
+
+// checkMerchantAccounts requires named accounts with a merchant id and
+// flags accounts an earlier one always takes payments from, and local
+// accounts without a cross-border account for the other currencies
+func checkMerchantAccounts(report *Report, path string, accounts []providers.MerchantAccount) {
+	seen := make(map[string]bool)
+	local, crossBorder := false, false
+	for i, account := range accounts {
+		local = local || (account.SettlementCurrency != "" && !account.CrossBorder)
+		crossBorder = crossBorder || account.CrossBorder
+		accountPath := index(path+".merchant_accounts", i)
+		if err := account.Validate(); err != nil {
+			report.add(SeverityError, accountPath, "%v", err)
+		}
+		if account.Name != "" && seen[account.Name] {
+			report.add(SeverityError, accountPath+".name", "duplicate merchant account '%s'", account.Name)
+		}
+		seen[account.Name] = true
+
+		if account.SettlementCurrency != "" && len(account.SettlementCurrency) != 3 {
+			report.add(SeverityError, accountPath+".settlement_currency", "'%s' is not an ISO 4217 currency code", account.SettlementCurrency)
+		} else if !account.CrossBorder && account.SettlementCurrency != "" && !matchesList(account.Currencies, account.SettlementCurrency) {
+			report.add(SeverityWarning, accountPath+".currencies", "never used, a local account only takes payments in its settlement currency %s", account.SettlementCurrency)
+		}
+
+		for _, earlier := range accounts[:i] {
+			if coversAccount(earlier, account) {
+				report.add(SeverityWarning, accountPath, "never used, merchant account '%s' is tried first and takes all its payments", earlier.Name)
+				break
+			}
+		}
+	}
+	if local && !crossBorder {
+		report.add(SeverityWarning, path+".merchant_accounts", "no cross-border account, payments in currencies without a local account are rejected")
+	}
+}
+
+// coversAccount reports whether every payment account takes is taken by
+// earlier. Cross-border accounts are tried after all others, so only cover
+// each other.
+func coversAccount(earlier, account providers.MerchantAccount) bool {
+	if earlier.CrossBorder != account.CrossBorder {
+		return false
+	}
+	return coversList(currencies(earlier), currencies(account)) &&
+		coversList(earlier.Countries, account.Countries) &&
+		coversList(earlier.BusinessLines, account.BusinessLines)
+}
+
+// currencies are the currencies an account takes, those of a local account
+// are limited to its settlement currency
+func currencies(account providers.MerchantAccount) []string {
+	if account.CrossBorder || account.SettlementCurrency == "" {
+		return account.Currencies
+	}
+	return []string{account.SettlementCurrency}
+}
+
+func matchesList(values []string, value string) bool {
+	return len(values) == 0 || slices.Contains(values, value)
+}
+
+func coversList(earlier, values []string) bool {
+	if len(earlier) == 0 {
+		return true
+	}
+	if len(values) == 0 {
+		return false
+	}
+	for _, value := range values {
+		if !slices.Contains(earlier, value) {
+			return false
+		}
+	}
+	return true
+}
+
+func checkEndpoint(ctx context.Context, report *Report, path string, endpoint Endpoint, opts CheckOptions) {
+	for _, name := range endpoint.Credentials {
+		if opts.Getenv(name) == "" {
+			report.add(SeverityError, path+".credentials", "environment variable %s is not set", name)
+		}
+	}
+
+	if endpoint.URL == "" {
+		report.add(SeverityError, path+".url", "endpoint url is required")
+		return
+	}
+	parsed, err := url.Parse(endpoint.URL)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "https" && parsed.Scheme != "http") {
+		report.add(SeverityError, path+".url", "'%s' is not a valid http(s) url", endpoint.URL)
+		return
+	}
+	if parsed.Scheme == "http" && parsed.Hostname() != "localhost" && parsed.Hostname() != "127.0.0.1" {
+		report.add(SeverityWarning, path+".url", "'%s' is not using https", endpoint.URL)
+	}
+
+	if opts.Probe {
+		probe(ctx, report, path, endpoint.URL, opts)
+	}
+}
+
+// probe only checks the endpoint answers at all; any HTTP status counts
+// since many gateways reject unauthenticated requests
+func probe(ctx context.Context, report *Report, path, endpoint string, opts CheckOptions) {
+	ctx, cancel := context.WithTimeout(ctx, opts.ProbeTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, endpoint, nil)
+	if err != nil {
+		report.add(SeverityError, path+".url", "cannot probe: %v", err)
+		return
+	}
+	response, err := opts.Client.Do(request)
+	if err != nil {
+		report.add(SeverityError, path+".url", "unreachable: %v", err)
+		return
+	}
+	response.Body.Close()
+}
+
+func checkRouting(report *Report, file File) {
+	known := make(map[string]bool)
+	for _, provider := range file.Providers {
+		known[provider.Name] = true
+	}
+
+	for i, rule := range file.Routing {
+		path := index("routing", i)
+		if !known[rule.Provider] {
+			report.add(SeverityError, path+".provider", "unknown provider '%s'", rule.Provider)
+		}
+
+		for j, earlier := range file.Routing[:i] {
+			if !covers(earlier, rule) {
+				continue
+			}
+			switch {
+			case earlier.Currency == rule.Currency && earlier.BINPrefix == rule.BINPrefix && earlier.Provider != rule.Provider:
+				report.add(SeverityError, path, "conflicts with %s, same match routed to '%s'", describeRule(j, earlier), earlier.Provider)
+			default:
+				report.add(SeverityWarning, path, "never matches, %s is tried first and covers it", describeRule(j, earlier))
+			}
+			break
+		}
+	}
+}
+
+// covers reports whether every payment matching rule also matches earlier
+func covers(earlier, rule RoutingRule) bool {
+	return (earlier.Currency == "" || earlier.Currency == rule.Currency) &&
+		strings.HasPrefix(rule.BINPrefix, earlier.BINPrefix)
+}
+
+func describeRule(i int, rule RoutingRule) string {
+	if rule.Name != "" {
+		return "rule '" + rule.Name + "'"
+	}
+	return index("routing", i)
+}
+
+func checkLimits(report *Report, file File) {
+	limits := file.Limits
+	switch {
+	case limits.MinAmount < 0:
+		report.add(SeverityError, "limits.min_amount", "must not be negative")
+	case limits.MaxAmount <= 0:
+		report.add(SeverityError, "limits.max_amount", "must be positive")
+	case limits.MaxAmount <= limits.MinAmount:
+		report.add(SeverityError, "limits.max_amount", "must be above min_amount")
+	}
+
+	if limits.DefaultTimeout <= 0 {
+		report.add(SeverityError, "limits.default_timeout", "must be positive")
+	} else if time.Duration(limits.DefaultTimeout) > 2*time.Minute {
+		report.add(SeverityWarning, "limits.default_timeout", "%s will hold connections for very long", time.Duration(limits.DefaultTimeout))
+	}
+	if limits.RetryAttempts < 0 || limits.RetryAttempts > 5 {
+		report.add(SeverityError, "limits.retry_attempts", "must be between 0 and 5")
+	}
+	if limits.RetryAttempts > 1 && limits.RetryBackoff <= 0 {
+		report.add(SeverityWarning, "limits.retry_backoff", "retries without backoff hammer a struggling provider")
+	}
+
+	if forward := file.Forward; forward != nil {
+		if forward.QueuePath == "" {
+			report.add(SeverityWarning, "forward.queue_path", "queued payments are lost on restart without a queue file")
+		}
+		if forward.MaxAmount <= 0 {
+			report.add(SeverityError, "forward.max_amount", "must be positive")
+		} else if forward.MaxAmount > limits.MaxAmount {
+			report.add(SeverityWarning, "forward.max_amount", "defers payments above the processor limit")
+		}
+		if forward.MaxAge <= 0 {
+			report.add(SeverityError, "forward.max_age", "must be positive")
+		}
+	}
+
+	if budget := file.Budget; budget != nil {
+		if budget.Validation < 0 || budget.Fraud < 0 || budget.Validation+budget.Fraud >= 1 {
+			report.add(SeverityError, "budget", "shares must not be negative and leave room for the gateway call")
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+const validConfig = `{
+	"providers": [
+		{"name": "visa", "url": "https://visa.example.com", "credentials": ["VISA_API_KEY"], "max_amount": 50000,
+			"merchant_accounts": [
+				{"name": "eu-travel", "merchant_id": "4445551", "terminal_id": "T01", "currencies": ["EUR"], "business_lines": ["travel"]},
+				{"name": "eu", "merchant_id": "4445552", "terminal_id": "T01", "currencies": ["EUR"]},
+				{"name": "default", "merchant_id": "4445550"}
+			]},
+		{"name": "mastercard", "url": "https://mastercard.example.com", "timeout": "10s"}
+	],
+	"routing": [
+		{"name": "eu-mastercard", "currency": "EUR", "bin_prefix": "5", "provider": "mastercard"},
+		{"name": "default", "provider": "visa"}
+	],
+	"limits": {"max_amount": 100000, "default_timeout": "30s", "retry_attempts": 2, "retry_backoff": "200ms"},
+	"forward": {"queue_path": "/var/lib/pgas/forward.json", "max_amount": 100, "max_age": "24h"}
+}`
+
+func env(values map[string]string) func(string) string {
+	return func(key string) string { return values[key] }
+}
+
+func TestCheck_Valid(t *testing.T) {
+	file, err := Parse([]byte(validConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Check(context.Background(), file, CheckOptions{Getenv: env(map[string]string{"VISA_API_KEY": "secret"})})
+	if !report.OK() || len(report.Findings) != 0 {
+		t.Errorf("Expected a clean report, got %v", report.Findings)
+	}
+
+	if time.Duration(file.Limits.DefaultTimeout) != 30*time.Second || len(file.Options()) != 5 {
+		t.Errorf("Expected durations and options to be read, got %+v", file.Limits)
+	}
+}
+
+func TestCheck_Findings(t *testing.T) {
+	file, _ := Parse([]byte(validConfig))
+	file.Providers[1].URL = "http://mastercard.example.com"
+	file.Providers[1].Timeout = Duration(-time.Second)
+	file.Routing = append([]RoutingRule{{Name: "all-euro", Currency: "EUR", Provider: "visa"}}, file.Routing...)
+	file.Routing = append(file.Routing,
+		RoutingRule{Name: "dup", Currency: "EUR", Provider: "mastercard"},
+		RoutingRule{Name: "ghost", Currency: "USD", Provider: "amex"})
+	file.Limits.MaxAmount = 10000
+	file.Budget = &Budget{Validation: 0.5, Fraud: 0.5}
+	file.Providers[0].MerchantAccounts = append(file.Providers[0].MerchantAccounts,
+		providers.MerchantAccount{Name: "eu", MerchantID: "4445553"},
+		providers.MerchantAccount{Name: "us", Currencies: []string{"USD"}})
+	file.Providers[1].MerchantAccounts = []providers.MerchantAccount{
+		{Name: "uk", MerchantID: "5550001", SettlementCurrency: "GBP"},
+		{Name: "eu", MerchantID: "5550002", SettlementCurrency: "EURO"},
+		{Name: "ch", MerchantID: "5550003", SettlementCurrency: "CHF", Currencies: []string{"EUR"}},
+	}
+
+	report := Check(context.Background(), file, CheckOptions{Getenv: env(nil)})
+	if report.OK() {
+		t.Fatal("Expected the report to fail")
+	}
+
+	expected := []string{
+		"error: providers[0].credentials: environment variable VISA_API_KEY is not set",
+		"warning: providers[0].max_amount: 50000.00 is above the processor limit",
+		"error: providers[1].timeout: must not be negative",
+		"warning: providers[1].url: 'http://mastercard.example.com' is not using https",
+		"warning: routing[1]: never matches, rule 'all-euro'",
+		"error: routing[3]: conflicts with rule 'all-euro'",
+		"error: routing[4].provider: unknown provider 'amex'",
+		"error: budget: shares must not be negative",
+		"error: providers[0].merchant_accounts[3].name: duplicate merchant account 'eu'",
+		"warning: providers[0].merchant_accounts[3]: never used, merchant account 'default'",
+		"error: providers[0].merchant_accounts[4]: merchant account 'us' has no merchant id",
+		"error: providers[1].merchant_accounts[1].settlement_currency: 'EURO' is not an ISO 4217 currency code",
+		"warning: providers[1].merchant_accounts[2].currencies: never used",
+		"warning: providers[1].merchant_accounts: no cross-border account",
+	}
+	for _, want := range expected {
+		found := false
+		for _, finding := range report.Findings {
+			found = found || strings.HasPrefix(finding.String(), want)
+		}
+		if !found {
+			t.Errorf("Expected finding %q, got:\n%v", want, report.Findings)
+		}
+	}
+}
+
+func TestCheck_Probe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	file := File{
+		Providers: []Provider{
+			{Name: "up", Endpoint: Endpoint{URL: server.URL}},
+			{Name: "down", Endpoint: Endpoint{URL: closed.URL}},
+		},
+		Limits: Limits{MaxAmount: 100, DefaultTimeout: Duration(time.Second)},
+	}
+
+	report := Check(context.Background(), file, CheckOptions{Probe: true, ProbeTimeout: time.Second})
+	if len(report.Findings) != 1 || !strings.HasPrefix(report.Findings[0].String(), "error: providers[1].url: unreachable") {
+		t.Errorf("Expected only the closed endpoint to be unreachable, got %v", report.Findings)
+	}
+}
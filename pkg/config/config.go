@@ -0,0 +1,153 @@
+// Package config reads the deployment configuration of a pgas server and
+// validates it before rollout, see Check.
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+)
+
+// Duration reads durations written as "30s" or "2m" in JSON
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %s", data)
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// File is the deployment configuration
+type File struct {
+	Providers []Provider    `json:"providers"`
+	Routing   []RoutingRule `json:"routing,omitempty"`
+	Limits    Limits        `json:"limits"`
+	Forward   *Forward      `json:"forward,omitempty"`
+	Budget    *Budget       `json:"budget,omitempty"`
+	// endpoints of optional integrations
+	WebhookURL string    `json:"webhook_url,omitempty"`
+	ThreeDS    *Endpoint `json:"threeds,omitempty"`
+}
+
+// Endpoint is a remote service and the environment variables holding its
+// credentials; secrets themselves never live in the file
+type Endpoint struct {
+	URL         string   `json:"url"`
+	Credentials []string `json:"credentials,omitempty"` // environment variable names
+}
+
+type Provider struct {
+	Name string `json:"name"`
+	Endpoint
+	MaxAmount  float64  `json:"max_amount,omitempty"`
+	Currencies []string `json:"currencies,omitempty"` // empty accepts all
+	Timeout    Duration `json:"timeout,omitempty"`    // replaces limits.default_timeout
+	// MerchantAccounts are the MIDs and TIDs of the connection, the first
+	// one taking a payment is used
+	MerchantAccounts []providers.MerchantAccount `json:"merchant_accounts,omitempty"`
+}
+
+// RoutingRule sends matching payments to Provider; rules are tried in order
+type RoutingRule struct {
+	Name      string `json:"name"`
+	Currency  string `json:"currency,omitempty"`
+	BINPrefix string `json:"bin_prefix,omitempty"`
+	Provider  string `json:"provider"`
+}
+
+type Limits struct {
+	MinAmount      float64  `json:"min_amount,omitempty"`
+	MaxAmount      float64  `json:"max_amount"`
+	DefaultTimeout Duration `json:"default_timeout"`
+	RetryAttempts  int      `json:"retry_attempts,omitempty"`
+	RetryBackoff   Duration `json:"retry_backoff,omitempty"`
+}
+
+type Forward struct {
+	QueuePath string   `json:"queue_path"`
+	MaxAmount float64  `json:"max_amount"`
+	MaxAge    Duration `json:"max_age"`
+}
+
+type Budget struct {
+	Validation float64 `json:"validation"`
+	Fraud      float64 `json:"fraud"`
+}
+
+// Parse reads a configuration file's contents
+func Parse(data []byte) (File, error) {
+	var file File
+	if err := json.Unmarshal(data, &file); err != nil {
+		return File{}, err
+	}
+	return file, nil
+}
+
+// Load reads a configuration file
+func Load(path string) (File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return File{}, err
+	}
+
+	file, err := Parse(data)
+	if err != nil {
+		return File{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return file, nil
+}
+
+// Options turns the processor settings of the file into processor options.
+// The file should pass Check first.
+func (f File) Options() []processor.Option {
+	var opts []processor.Option
+	if f.Limits.DefaultTimeout > 0 {
+		opts = append(opts, processor.WithDefaultTimeout(time.Duration(f.Limits.DefaultTimeout)))
+	}
+	for _, provider := range f.Providers {
+		if provider.Timeout > 0 {
+			opts = append(opts, processor.WithProviderTimeout(provider.Name, time.Duration(provider.Timeout)))
+		}
+		if len(provider.MerchantAccounts) > 0 {
+			opts = append(opts, processor.WithMerchantAccounts(provider.Name, provider.MerchantAccounts...))
+		}
+	}
+	if f.Limits.RetryAttempts > 0 {
+		opts = append(opts, processor.WithRetryPolicy(processor.RetryPolicy{
+			MaxAttempts: f.Limits.RetryAttempts,
+			Backoff:     time.Duration(f.Limits.RetryBackoff),
+			// only errors the provider catalog marks as safe to replay
+			Retryable: func(paymentError *providers.PaymentError) bool { return paymentError.Retryable },
+		}))
+	}
+	if len(f.Routing) > 0 {
+		opts = append(opts, processor.WithRouter(f.Router()))
+	}
+	if f.Budget != nil {
+		opts = append(opts, processor.WithBudgetShares(processor.BudgetShares{
+			Validation: f.Budget.Validation,
+			Fraud:      f.Budget.Fraud,
+		}))
+	}
+	return opts
+}
+
+func index(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
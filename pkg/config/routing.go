@@ -0,0 +1,50 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+)
+
+// rulesRouter applies the declarative routing rules, first match wins
+type rulesRouter []RoutingRule
+
+// Router returns a processor.Router for the file's routing rules. Payments no
+// rule matches stay on the provider they name.
+func (f File) Router() processor.Router {
+	return rulesRouter(f.Routing)
+}
+
+func (r rulesRouter) Route(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, error) {
+	chosen, _, err := r.Explain(ctx, req, candidates)
+	return chosen, err
+}
+
+// Explain implements processor.RouteExplainer, naming the matched rule
+func (r rulesRouter) Explain(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, []string, error) {
+	for i, rule := range r {
+		if (rule.Currency != "" && rule.Currency != req.Currency) || !strings.HasPrefix(req.CardNumber, rule.BINPrefix) {
+			continue
+		}
+
+		matched := []string{ruleName(i, rule)}
+		for _, candidate := range candidates {
+			if candidate.GetName() == rule.Provider {
+				return candidate, matched, nil
+			}
+		}
+		return nil, matched, fmt.Errorf("%s routes to unregistered provider '%s'", describeRule(i, rule), rule.Provider)
+	}
+	return nil, nil, nil
+}
+
+// ruleName is the rule's name, or its position for unnamed rules
+func ruleName(i int, rule RoutingRule) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return index("routing", i)
+}
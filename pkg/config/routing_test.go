@@ -0,0 +1,63 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/visa"
+)
+
+func TestRouter(t *testing.T) {
+	file, err := Parse([]byte(validConfig))
+	if err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+	candidates := []providers.Provider{mastercard.GetNewMasterCardPaymentProvider(), visa.GetNewVisaPaymentProvider()}
+
+	tests := []struct {
+		name     string
+		request  providers.PaymentRequest
+		provider string
+	}{
+		{"euro mastercard", providers.PaymentRequest{Currency: "EUR", CardNumber: "5555555555554444"}, "mastercard"},
+		{"dollar mastercard", providers.PaymentRequest{Currency: "USD", CardNumber: "5555555555554444"}, "visa"},
+		{"euro visa", providers.PaymentRequest{Currency: "EUR", CardNumber: "4111111111111111"}, "visa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chosen, err := file.Router().Route(context.Background(), tt.request, candidates)
+			if err != nil || chosen == nil || chosen.GetName() != tt.provider {
+				t.Errorf("Expected %s, got %v (%v)", tt.provider, chosen, err)
+			}
+		})
+	}
+
+	if _, err := file.Router().Route(context.Background(), providers.PaymentRequest{Currency: "USD"}, candidates[:1]); err == nil {
+		t.Error("Expected a rule naming an unregistered provider to fail")
+	}
+
+	if chosen, err := (File{}).Router().Route(context.Background(), providers.PaymentRequest{}, candidates); chosen != nil || err != nil {
+		t.Errorf("Expected no decision without rules, got %v (%v)", chosen, err)
+	}
+}
+
+func TestRouter_Explain(t *testing.T) {
+	file, err := Parse([]byte(validConfig))
+	if err != nil {
+		t.Fatalf("Expected valid config, got: %v", err)
+	}
+	candidates := []providers.Provider{mastercard.GetNewMasterCardPaymentProvider(), visa.GetNewVisaPaymentProvider()}
+
+	explainer, ok := file.Router().(processor.RouteExplainer)
+	if !ok {
+		t.Fatal("Expected the rules router to explain its decisions")
+	}
+	chosen, rules, err := explainer.Explain(context.Background(), providers.PaymentRequest{Currency: "EUR", CardNumber: "5555555555554444"}, candidates)
+	if err != nil || chosen.GetName() != "mastercard" || len(rules) != 1 || rules[0] != "eu-mastercard" {
+		t.Errorf("Expected the eu-mastercard rule, got %v %v (%v)", chosen, rules, err)
+	}
+}
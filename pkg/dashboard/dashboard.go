@@ -0,0 +1,131 @@
+// Package dashboard collects the live operational view shown by `pgas top`:
+// per-provider throughput, success rate, latency and breaker state plus the
+// store-and-forward queue depth.
+package dashboard
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"pgas/pkg/forward"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// Snapshot is the state of the gateway over the last window
+type Snapshot struct {
+	Time       time.Time       `json:"time"`
+	Window     time.Duration   `json:"window"`
+	Providers  []ProviderStats `json:"providers"`
+	QueueDepth int             `json:"queue_depth"`
+}
+
+type ProviderStats struct {
+	Provider     string  `json:"provider"`
+	Attempts     int     `json:"attempts"`
+	TPS          float64 `json:"tps"`
+	SuccessRate  float64 `json:"success_rate"` // 0..1 over decided payments
+	LatencyP50Ms int64   `json:"latency_p50_ms"`
+	LatencyP99Ms int64   `json:"latency_p99_ms"`
+	Breaker      string  `json:"breaker,omitempty"`
+}
+
+// Source produces snapshots, either in process or from a running server
+type Source interface {
+	Snapshot(ctx context.Context) (Snapshot, error)
+}
+
+// Embedded computes snapshots from the processor's own stores
+type Embedded struct {
+	Transactions store.Transactions
+	Queue        *forward.Queue // optional forward queue
+	// Breakers reports the circuit breaker state per provider, optional
+	Breakers func() map[string]string
+	Window   time.Duration // defaults to one minute
+
+	now func() time.Time
+}
+
+func (e *Embedded) Snapshot(ctx context.Context) (Snapshot, error) {
+	now := time.Now()
+	if e.now != nil {
+		now = e.now()
+	}
+	window := e.Window
+	if window <= 0 {
+		window = time.Minute
+	}
+
+	snapshot := Snapshot{Time: now, Window: window}
+	if e.Queue != nil {
+		snapshot.QueueDepth = e.Queue.Len()
+	}
+
+	transactions, err := e.Transactions.Query(store.TransactionFilter{Since: now.Add(-window)})
+	if err != nil {
+		return snapshot, err
+	}
+
+	byProvider := make(map[string][]store.Transaction)
+	for _, tx := range transactions {
+		byProvider[tx.Provider] = append(byProvider[tx.Provider], tx)
+	}
+
+	var breakers map[string]string
+	if e.Breakers != nil {
+		breakers = e.Breakers()
+		for provider := range breakers {
+			if _, ok := byProvider[provider]; !ok {
+				byProvider[provider] = nil
+			}
+		}
+	}
+
+	for provider, txs := range byProvider {
+		stats := ProviderStats{
+			Provider: provider,
+			Attempts: len(txs),
+			TPS:      float64(len(txs)) / window.Seconds(),
+			Breaker:  breakers[provider],
+		}
+
+		decided, approved := 0, 0
+		latencies := make([]int64, 0, len(txs))
+		for _, tx := range txs {
+			latencies = append(latencies, tx.LatencyMs)
+			switch {
+			case tx.Status == providers.StatusDeferred, tx.Status == providers.StatusUnknown:
+			case providers.IsSuccessStatus(tx.Status):
+				decided++
+				approved++
+			default:
+				decided++
+			}
+		}
+		if decided > 0 {
+			stats.SuccessRate = float64(approved) / float64(decided)
+		}
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		stats.LatencyP50Ms = percentile(latencies, 0.50)
+		stats.LatencyP99Ms = percentile(latencies, 0.99)
+
+		snapshot.Providers = append(snapshot.Providers, stats)
+	}
+
+	sort.Slice(snapshot.Providers, func(i, j int) bool { return snapshot.Providers[i].Provider < snapshot.Providers[j].Provider })
+	return snapshot, nil
+}
+
+// percentile uses the nearest-rank method on sorted values
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
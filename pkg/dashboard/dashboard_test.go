@@ -0,0 +1,94 @@
+package dashboard
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/forward"
+	"pgas/pkg/providers"
+	"pgas/pkg/replay"
+	"pgas/pkg/store"
+)
+
+func testSource(t *testing.T) *Embedded {
+	t.Helper()
+
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	statuses := []string{providers.StatusApproved, providers.StatusApproved, providers.StatusApproved, providers.StatusDeclined, providers.StatusDeferred}
+	for i, status := range statuses {
+		transactions.Save(store.Transaction{ID: "v" + string(rune('a'+i)), Provider: "visa", Status: status,
+			LatencyMs: int64(100 * (i + 1)), CreatedAt: now.Add(-time.Duration(i) * time.Second)})
+	}
+	// outside the window
+	transactions.Save(store.Transaction{ID: "old", Provider: "visa", Status: providers.StatusDeclined, CreatedAt: now.Add(-time.Hour)})
+
+	queue, _ := forward.NewQueue("", nil)
+	queue.Enqueue(providers.PaymentRequest{Mode: "visa", Amount: 5}, now)
+
+	return &Embedded{
+		Transactions: transactions,
+		Queue:        queue,
+		Breakers:     func() map[string]string { return map[string]string{"visa": "closed", "mastercard": "open"} },
+		Window:       10 * time.Second,
+		now:          func() time.Time { return now },
+	}
+}
+
+func TestEmbedded_Snapshot(t *testing.T) {
+	snapshot, err := testSource(t).Snapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if snapshot.QueueDepth != 1 || len(snapshot.Providers) != 2 {
+		t.Fatalf("Expected two providers and one queued payment, got %+v", snapshot)
+	}
+
+	mastercard, visa := snapshot.Providers[0], snapshot.Providers[1]
+	if mastercard.Provider != "mastercard" || mastercard.Attempts != 0 || mastercard.Breaker != "open" {
+		t.Errorf("Expected idle mastercard with open breaker, got %+v", mastercard)
+	}
+	if visa.Attempts != 5 || visa.TPS != 0.5 || visa.SuccessRate != 0.75 {
+		t.Errorf("Expected 5 visa payments at 0.5 TPS and 75%% success, got %+v", visa)
+	}
+	if visa.LatencyP50Ms != 300 || visa.LatencyP99Ms != 500 {
+		t.Errorf("Expected p50 300ms and p99 500ms, got %d and %d", visa.LatencyP50Ms, visa.LatencyP99Ms)
+	}
+}
+
+func TestHTTPSource_RoundTrip(t *testing.T) {
+	server := httptest.NewServer(Handler(testSource(t)))
+	defer server.Close()
+
+	snapshot, err := NewHTTPSource(server.URL).Snapshot(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Providers) != 2 || snapshot.Providers[1].Attempts != 5 {
+		t.Errorf("Expected snapshot to survive the round trip, got %+v", snapshot)
+	}
+
+	guarded := httptest.NewServer(replay.NewGuard(time.Minute, replay.NewMemoryNonces(), []byte("secret")).Middleware(Handler(testSource(t))))
+	defer guarded.Close()
+	if _, err := NewHTTPSource(guarded.URL).Snapshot(context.Background()); err == nil {
+		t.Error("Expected an unsigned request to be rejected")
+	}
+	signed := NewHTTPSource(guarded.URL)
+	signed.Secret = []byte("secret")
+	if _, err := signed.Snapshot(context.Background()); err != nil {
+		t.Errorf("Expected a signed request to pass, got %v", err)
+	}
+
+	var frame bytes.Buffer
+	Render(&frame, snapshot)
+	for _, want := range []string{"forward queue 1", "visa", "75.0%", "open"} {
+		if !strings.Contains(frame.String(), want) {
+			t.Errorf("Expected frame to contain %q:\n%s", want, frame.String())
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"pgas/pkg/problem"
+	"pgas/pkg/replay"
+)
+
+// Handler serves the source's snapshots as JSON for remote dashboards
+func Handler(source Source) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.Write(w, r, problem.New(http.StatusMethodNotAllowed, problem.CategoryMethodNotAllowed, "", "use GET"))
+			return
+		}
+
+		snapshot, err := source.Snapshot(r.Context())
+		if err != nil {
+			problem.Write(w, r, problem.New(http.StatusInternalServerError, problem.CategoryInternal, "", err.Error()))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(snapshot)
+	})
+}
+
+// HTTPSource reads snapshots from a server exposing Handler
+type HTTPSource struct {
+	URL    string
+	Client *http.Client
+	// Secret signs the requests for servers behind a replay.Guard, optional
+	Secret []byte
+}
+
+func NewHTTPSource(url string) *HTTPSource {
+	return &HTTPSource{URL: url, Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+func (s *HTTPSource) Snapshot(ctx context.Context) (Snapshot, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if s.Secret != nil {
+		replay.SignRequest(request, s.Secret, nil)
+	}
+
+	response, err := s.Client.Do(request)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return Snapshot{}, fmt.Errorf("dashboard endpoint returned %s", response.Status)
+	}
+
+	var snapshot Snapshot
+	if err := json.NewDecoder(response.Body).Decode(&snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("invalid dashboard snapshot: %w", err)
+	}
+	return snapshot, nil
+}
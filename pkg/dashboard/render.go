@@ -0,0 +1,60 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// clears the terminal and moves the cursor home
+const clearScreen = "\033[H\033[2J"
+
+// Render writes one frame of the dashboard
+func Render(w io.Writer, snapshot Snapshot) {
+	fmt.Fprint(w, clearScreen)
+	fmt.Fprintf(w, "pgas top  %s  window %s  forward queue %d\n\n",
+		snapshot.Time.Format("15:04:05"), snapshot.Window, snapshot.QueueDepth)
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "PROVIDER\tTPS\tSUCCESS\tP50\tP99\tBREAKER\t")
+	for _, provider := range snapshot.Providers {
+		breaker := provider.Breaker
+		if breaker == "" {
+			breaker = "-"
+		}
+		success := "-"
+		if provider.Attempts > 0 {
+			success = fmt.Sprintf("%.1f%%", provider.SuccessRate*100)
+		}
+		fmt.Fprintf(table, "%s\t%.2f\t%s\t%dms\t%dms\t%s\t\n",
+			provider.Provider, provider.TPS, success, provider.LatencyP50Ms, provider.LatencyP99Ms, breaker)
+	}
+	table.Flush()
+
+	if len(snapshot.Providers) == 0 {
+		fmt.Fprintln(w, "no payments in window")
+	}
+}
+
+// Run redraws the dashboard every interval until ctx is done
+func Run(ctx context.Context, w io.Writer, source Source, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		snapshot, err := source.Snapshot(ctx)
+		if err != nil {
+			fmt.Fprintf(w, "%s%s  %v\n", clearScreen, time.Now().Format("15:04:05"), err)
+		} else {
+			Render(w, snapshot)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Package emv turns card-present input, magnetic stripe track 2 and EMV
+// chip tag data, into the normalized card fields of a payment request so POS
+// integrations can hand raw reader output to pgas.
+package emv
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"pgas/pkg/providers"
+)
+
+// Source tells where card data was read from
+type Source string
+
+const (
+	SourceTrack2 Source = "track2" // magnetic stripe
+	SourceChip   Source = "chip"   // EMV tag data
+)
+
+var (
+	ErrInvalidTrack2     = errors.New("invalid track 2 data")
+	ErrInvalidTLV        = errors.New("invalid EMV tag data")
+	ErrMissingPAN        = errors.New("card data contains no PAN")
+	ErrInvalidService    = errors.New("invalid service code")
+	ErrChipRequired      = errors.New("chip card must be read through the chip")
+	ErrNotForPurchases   = errors.New("service code restricts the card to ATM use")
+	ErrInvalidExpiryData = errors.New("invalid expiry in card data")
+)
+
+// CardData holds the card fields read at the terminal
+type CardData struct {
+	PAN            string `json:"-"`
+	ExpiryMonth    string `json:"expiry_month"`
+	ExpiryYear     string `json:"expiry_year"` // four digits
+	ServiceCode    string `json:"service_code,omitempty"`
+	CardholderName string `json:"cardholder_name,omitempty"`
+	Source         Source `json:"source"`
+}
+
+// CheckOptions tune the service code checks
+type CheckOptions struct {
+	// AllowFallback accepts swiped chip cards, e.g. after a failed chip read
+	AllowFallback bool
+}
+
+// ParseTrack2 reads track 2 data as delivered by card readers, with or
+// without the ';' start and '?' end sentinels: PAN, separator ('=' or 'D'),
+// expiry YYMM, service code and discretionary data.
+func ParseTrack2(track string) (CardData, error) {
+	track = strings.TrimPrefix(strings.TrimSpace(track), ";")
+	if i := strings.IndexByte(track, '?'); i >= 0 {
+		track = track[:i] // drops the end sentinel and the LRC after it
+	}
+	// track 2 equivalent data from chips is padded to full bytes
+	track = strings.TrimRight(strings.ToUpper(track), "F")
+
+	separator := strings.IndexAny(track, "=D")
+	if separator < 0 {
+		return CardData{}, ErrInvalidTrack2
+	}
+
+	pan, rest := track[:separator], track[separator+1:]
+	if !isDigits(pan) || len(pan) < 12 || len(pan) > 19 {
+		return CardData{}, ErrInvalidTrack2
+	}
+
+	if len(rest) < 7 || !isDigits(rest[:7]) {
+		return CardData{}, ErrInvalidTrack2
+	}
+
+	card := CardData{PAN: pan, ServiceCode: rest[4:7], Source: SourceTrack2}
+	if err := card.setExpiry(rest[:4]); err != nil {
+		return CardData{}, err
+	}
+	return card, nil
+}
+
+// EMV tags read by ParseEMV
+const (
+	TagPAN            = "5A"
+	TagExpiry         = "5F24"
+	TagTrack2         = "57"
+	TagServiceCode    = "5F30"
+	TagCardholderName = "5F20"
+)
+
+// ParseEMV reads BER-TLV encoded chip data, given as hex, preferring the
+// dedicated PAN and expiry tags and falling back to track 2 equivalent data
+func ParseEMV(hexData string) (CardData, error) {
+	data, err := hex.DecodeString(strings.ReplaceAll(hexData, " ", ""))
+	if err != nil {
+		return CardData{}, ErrInvalidTLV
+	}
+
+	tags, err := ParseTLV(data)
+	if err != nil {
+		return CardData{}, err
+	}
+
+	card := CardData{Source: SourceChip}
+	if track2, ok := tags[TagTrack2]; ok {
+		parsed, err := ParseTrack2(strings.ToUpper(hex.EncodeToString(track2)))
+		if err != nil {
+			return CardData{}, err
+		}
+		card = parsed
+		card.Source = SourceChip
+	}
+
+	if pan, ok := tags[TagPAN]; ok {
+		card.PAN = strings.TrimRight(strings.ToUpper(hex.EncodeToString(pan)), "F")
+	}
+
+	if expiry, ok := tags[TagExpiry]; ok {
+		digits := hex.EncodeToString(expiry)
+		if len(digits) < 4 {
+			return CardData{}, ErrInvalidExpiryData
+		}
+		if err := card.setExpiry(digits[:4]); err != nil {
+			return CardData{}, err
+		}
+	}
+
+	if code, ok := tags[TagServiceCode]; ok {
+		// n3 packed into two bytes with a leading zero nibble
+		card.ServiceCode = strings.TrimPrefix(hex.EncodeToString(code), "0")
+	}
+
+	if name, ok := tags[TagCardholderName]; ok {
+		card.CardholderName = strings.TrimSpace(string(name))
+	}
+
+	if card.PAN == "" {
+		return CardData{}, ErrMissingPAN
+	}
+	if !isDigits(card.PAN) {
+		return CardData{}, ErrInvalidTLV
+	}
+	if card.ExpiryMonth == "" {
+		return CardData{}, ErrInvalidExpiryData
+	}
+	return card, nil
+}
+
+// ParseTLV decodes BER-TLV data into a map of upper-case hex tags to values.
+// Constructed tags (templates such as 70 or 77) are descended into.
+func ParseTLV(data []byte) (map[string][]byte, error) {
+	tags := make(map[string][]byte)
+	if err := parseTLV(data, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func parseTLV(data []byte, tags map[string][]byte) error {
+	for i := 0; i < len(data); {
+		// 00 and FF are padding between objects
+		if data[i] == 0x00 || data[i] == 0xFF {
+			i++
+			continue
+		}
+
+		start := i
+		constructed := data[i]&0x20 != 0
+		if data[i]&0x1F == 0x1F {
+			for i++; i < len(data) && data[i]&0x80 != 0; i++ {
+			}
+		}
+		i++
+		if i > len(data) {
+			return ErrInvalidTLV
+		}
+		tag := strings.ToUpper(hex.EncodeToString(data[start:i]))
+
+		if i >= len(data) {
+			return ErrInvalidTLV
+		}
+		length := int(data[i])
+		i++
+		if length&0x80 != 0 {
+			octets := length & 0x7F
+			if octets == 0 || octets > 3 || i+octets > len(data) {
+				return ErrInvalidTLV
+			}
+			length = 0
+			for _, b := range data[i : i+octets] {
+				length = length<<8 | int(b)
+			}
+			i += octets
+		}
+
+		if i+length > len(data) {
+			return ErrInvalidTLV
+		}
+		value := data[i : i+length]
+		i += length
+
+		if constructed {
+			if err := parseTLV(value, tags); err != nil {
+				return err
+			}
+			continue
+		}
+		tags[tag] = value
+	}
+	return nil
+}
+
+// Check applies the service code rules: the code must be well formed, chip
+// cards must not be swiped unless fallback is allowed, and ATM-only cards
+// cannot pay for goods or services
+func (c CardData) Check(opts CheckOptions) error {
+	if c.ServiceCode == "" {
+		// chip data often omits the service code; the chip enforces it
+		if c.Source == SourceChip {
+			return nil
+		}
+		return ErrInvalidService
+	}
+
+	if len(c.ServiceCode) != 3 || !isDigits(c.ServiceCode) {
+		return ErrInvalidService
+	}
+
+	switch c.ServiceCode[0] {
+	case '1', '2', '5', '6', '7', '9':
+	default:
+		return ErrInvalidService
+	}
+
+	chipCard := c.ServiceCode[0] == '2' || c.ServiceCode[0] == '6'
+	if chipCard && c.Source == SourceTrack2 && !opts.AllowFallback {
+		return ErrChipRequired
+	}
+
+	if c.ServiceCode[2] == '3' {
+		return ErrNotForPurchases
+	}
+	return nil
+}
+
+// Apply copies the card fields into a payment request
+func (c CardData) Apply(request providers.PaymentRequest) providers.PaymentRequest {
+	request.CardNumber = c.PAN
+	request.ExpiryMonth = c.ExpiryMonth
+	request.ExpiryYear = c.ExpiryYear
+	return request
+}
+
+func (c *CardData) setExpiry(yymm string) error {
+	if !isDigits(yymm) || len(yymm) != 4 {
+		return ErrInvalidExpiryData
+	}
+
+	month := yymm[2:]
+	if month < "01" || month > "12" {
+		return fmt.Errorf("%w: month %s", ErrInvalidExpiryData, month)
+	}
+
+	c.ExpiryMonth = month
+	c.ExpiryYear = "20" + yymm[:2]
+	return nil
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package emv
+
+import (
+	"errors"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestParseTrack2(t *testing.T) {
+	card, err := ParseTrack2(";4761739001010010=30122011143804400000?5")
+	if err != nil {
+		t.Fatalf("Expected valid track 2, got error: %v", err)
+	}
+
+	if card.PAN != "4761739001010010" || card.ExpiryMonth != "12" || card.ExpiryYear != "2030" || card.ServiceCode != "201" {
+		t.Errorf("Unexpected card data: %+v", card)
+	}
+
+	if err := card.Check(CheckOptions{}); !errors.Is(err, ErrChipRequired) {
+		t.Errorf("Expected swiped chip card to be rejected, got %v", err)
+	}
+
+	if err := card.Check(CheckOptions{AllowFallback: true}); err != nil {
+		t.Errorf("Expected fallback to be accepted, got %v", err)
+	}
+
+	request := card.Apply(providers.PaymentRequest{Mode: "visa", Amount: 10})
+	if request.CardNumber != card.PAN || request.ExpiryYear != "2030" || request.Mode != "visa" {
+		t.Errorf("Expected card fields copied into request, got %+v", request)
+	}
+}
+
+func TestParseTrack2_Invalid(t *testing.T) {
+	for _, track := range []string{
+		"",
+		"4761739001010010",
+		"4761739001010010=3012",
+		"47617390ABCD0010=30122011143804400000",
+		"4761739001010010=30132011143804400000",
+	} {
+		if _, err := ParseTrack2(track); err == nil {
+			t.Errorf("Expected %q to be rejected", track)
+		}
+	}
+}
+
+func TestServiceCodeChecks(t *testing.T) {
+	cases := map[string]error{
+		"101": nil,
+		"120": nil,
+		"103": ErrNotForPurchases,
+		"401": ErrInvalidService,
+		"1x1": ErrInvalidService,
+	}
+
+	for code, expected := range cases {
+		card := CardData{PAN: "5413330089010434", ServiceCode: code, Source: SourceTrack2}
+		if err := card.Check(CheckOptions{}); !errors.Is(err, expected) {
+			t.Errorf("Service code %s: expected %v, got %v", code, expected, err)
+		}
+	}
+}
+
+func TestParseEMV(t *testing.T) {
+	// 70 template holding PAN (5A), expiry (5F24), service code (5F30) and
+	// cardholder name (5F20)
+	data := "7022" +
+		"5A085413330089010434" +
+		"5F2403301231" +
+		"5F30020201" +
+		"5F200A4A414E4520444F452020"
+
+	card, err := ParseEMV(data)
+	if err != nil {
+		t.Fatalf("Expected valid EMV data, got error: %v", err)
+	}
+
+	if card.PAN != "5413330089010434" || card.ExpiryMonth != "12" || card.ExpiryYear != "2030" {
+		t.Errorf("Unexpected card data: %+v", card)
+	}
+
+	if card.ServiceCode != "201" || card.CardholderName != "JANE DOE" || card.Source != SourceChip {
+		t.Errorf("Unexpected card details: %+v", card)
+	}
+
+	if err := card.Check(CheckOptions{}); err != nil {
+		t.Errorf("Expected chip read chip card to pass, got %v", err)
+	}
+}
+
+func TestParseEMV_Track2Equivalent(t *testing.T) {
+	card, err := ParseEMV("57134761739001010010D30122011143804400000F")
+	if err != nil {
+		t.Fatalf("Expected track 2 equivalent data, got error: %v", err)
+	}
+
+	if card.PAN != "4761739001010010" || card.ExpiryMonth != "12" || card.Source != SourceChip {
+		t.Errorf("Unexpected card data: %+v", card)
+	}
+
+	if _, err := ParseEMV("5A0854"); !errors.Is(err, ErrInvalidTLV) {
+		t.Errorf("Expected truncated TLV to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,288 @@
+package escrow
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	StateHeld     State = "HELD"     // funds captured and waiting for a decision
+	StateReleased State = "RELEASED" // fully paid out to the seller
+	StateReturned State = "RETURNED" // fully refunded to the buyer
+	StateSettled  State = "SETTLED"  // closed with a mix of releases and returns
+	StateExpired  State = "EXPIRED"  // closed by the expiry policy
+)
+
+// ExpiryAction decides what happens to the remaining balance of an expired hold
+type ExpiryAction string
+
+const (
+	ExpireRelease ExpiryAction = "release"
+	ExpireReturn  ExpiryAction = "return"
+)
+
+var (
+	ErrHoldNotFound           = errors.New("hold not found")
+	ErrHoldClosed             = errors.New("hold is no longer open")
+	ErrInvalidAmount          = errors.New("amount must be greater than 0")
+	ErrAmountExceedsRemaining = errors.New("amount exceeds remaining held balance")
+	ErrHoldBusy               = errors.New("hold has a transfer in progress")
+)
+
+// Hold tracks captured funds held in escrow for a marketplace order
+type Hold struct {
+	ID            string    `json:"id"`
+	TransactionID string    `json:"transaction_id"`
+	Amount        float64   `json:"amount"`
+	Currency      string    `json:"currency"`
+	Released      float64   `json:"released"`
+	Returned      float64   `json:"returned"`
+	State         State     `json:"state"`
+	CreatedAt     time.Time `json:"created_at"`
+	ExpiresAt     time.Time `json:"expires_at,omitempty"`
+
+	moving bool // a payout or refund handler is running for the hold
+}
+
+// Remaining returns the balance still held
+func (h Hold) Remaining() float64 {
+	return roundAmount(h.Amount - h.Released - h.Returned)
+}
+
+// Payouter pays held funds out to the seller
+type Payouter interface {
+	Payout(ctx context.Context, hold Hold, amount float64) error
+}
+
+// Refunder returns held funds to the buyer
+type Refunder interface {
+	Refund(ctx context.Context, hold Hold, amount float64) error
+}
+
+type Config struct {
+	Payouts      Payouter
+	Refunds      Refunder
+	HoldDuration time.Duration // zero means holds never expire
+	OnExpiry     ExpiryAction  // defaults to returning funds to the buyer
+	Now          func() time.Time
+}
+
+type Manager struct {
+	mu    sync.Mutex
+	holds map[string]*Hold
+	cfg   Config
+}
+
+func NewManager(cfg Config) *Manager {
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
+	if cfg.OnExpiry == "" {
+		cfg.OnExpiry = ExpireReturn
+	}
+
+	return &Manager{
+		holds: make(map[string]*Hold),
+		cfg:   cfg,
+	}
+}
+
+// Hold places captured funds of a transaction into escrow
+func (m *Manager) Hold(transactionID string, amount float64, currency string) (Hold, error) {
+	if amount <= 0 {
+		return Hold{}, ErrInvalidAmount
+	}
+	if transactionID == "" {
+		return Hold{}, errors.New("transaction id is required")
+	}
+	if currency == "" {
+		return Hold{}, errors.New("currency is required")
+	}
+
+	now := m.cfg.Now()
+	hold := &Hold{
+		ID:            newHoldID(),
+		TransactionID: transactionID,
+		Amount:        roundAmount(amount),
+		Currency:      currency,
+		State:         StateHeld,
+		CreatedAt:     now,
+	}
+	if m.cfg.HoldDuration > 0 {
+		hold.ExpiresAt = now.Add(m.cfg.HoldDuration)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.holds[hold.ID] = hold
+
+	return *hold, nil
+}
+
+// Get returns the current state of a hold
+func (m *Manager) Get(holdID string) (Hold, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hold, ok := m.holds[holdID]
+	if !ok {
+		return Hold{}, ErrHoldNotFound
+	}
+	return *hold, nil
+}
+
+// Release pays amount of the held funds out to the seller
+func (m *Manager) Release(ctx context.Context, holdID string, amount float64) (Hold, error) {
+	return m.move(ctx, holdID, amount, ExpireRelease, false)
+}
+
+// Return refunds amount of the held funds to the buyer
+func (m *Manager) Return(ctx context.Context, holdID string, amount float64) (Hold, error) {
+	return m.move(ctx, holdID, amount, ExpireReturn, false)
+}
+
+// move transfers funds out of an open hold, all that remains when expire
+// closes it. The payout or refund handler runs without m.mu; the hold is
+// marked moving meanwhile, so other transfers of it fail with ErrHoldBusy
+// instead of spending the same balance.
+func (m *Manager) move(ctx context.Context, holdID string, amount float64, action ExpiryAction, expire bool) (Hold, error) {
+	m.mu.Lock()
+	hold, ok := m.holds[holdID]
+	if !ok {
+		m.mu.Unlock()
+		return Hold{}, ErrHoldNotFound
+	}
+	held, err := m.begin(hold, &amount, action, expire)
+	m.mu.Unlock()
+	if err != nil {
+		return held, err
+	}
+
+	if action == ExpireRelease {
+		err = m.cfg.Payouts.Payout(ctx, held, amount)
+	} else {
+		err = m.cfg.Refunds.Refund(ctx, held, amount)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	hold.moving = false
+	if err != nil {
+		return *hold, err
+	}
+
+	if action == ExpireRelease {
+		hold.Released = roundAmount(hold.Released + amount)
+	} else {
+		hold.Returned = roundAmount(hold.Returned + amount)
+	}
+
+	if hold.Remaining() == 0 {
+		switch {
+		case expire:
+			hold.State = StateExpired
+		case hold.Returned == 0:
+			hold.State = StateReleased
+		case hold.Released == 0:
+			hold.State = StateReturned
+		default:
+			hold.State = StateSettled
+		}
+	}
+
+	return *hold, nil
+}
+
+// begin checks a transfer and marks the hold moving; callers hold m.mu
+func (m *Manager) begin(hold *Hold, amount *float64, action ExpiryAction, expire bool) (Hold, error) {
+	if hold.State != StateHeld {
+		return *hold, ErrHoldClosed
+	}
+	if hold.moving {
+		return *hold, ErrHoldBusy
+	}
+
+	if expire {
+		*amount = hold.Remaining()
+	}
+	*amount = roundAmount(*amount)
+	if *amount <= 0 {
+		return *hold, ErrInvalidAmount
+	}
+	if *amount > hold.Remaining() {
+		return *hold, ErrAmountExceedsRemaining
+	}
+
+	if action == ExpireRelease && m.cfg.Payouts == nil {
+		return *hold, errors.New("no payout handler configured")
+	}
+	if action == ExpireReturn && m.cfg.Refunds == nil {
+		return *hold, errors.New("no refund handler configured")
+	}
+
+	hold.moving = true
+	return *hold, nil
+}
+
+// ExpireDue applies the expiry policy to every open hold past its expiry
+// and returns the holds that were closed. Holds with a transfer in progress
+// are left for the next run.
+func (m *Manager) ExpireDue(ctx context.Context) ([]Hold, error) {
+	m.mu.Lock()
+	now := m.cfg.Now()
+	var due []string
+	for id, hold := range m.holds {
+		if hold.State == StateHeld && !hold.moving && !hold.ExpiresAt.IsZero() && !now.Before(hold.ExpiresAt) {
+			due = append(due, id)
+		}
+	}
+	m.mu.Unlock()
+
+	var expired []Hold
+	var errs []error
+	for _, id := range due {
+		hold, err := m.move(ctx, id, 0, m.cfg.OnExpiry, true)
+		switch {
+		case errors.Is(err, ErrHoldBusy) || errors.Is(err, ErrHoldClosed):
+			// settled concurrently since it was found due
+		case err != nil:
+			errs = append(errs, err)
+		default:
+			expired = append(expired, hold)
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// Balances returns the total open held amount per currency
+func (m *Manager) Balances() map[string]float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	balances := make(map[string]float64)
+	for _, hold := range m.holds {
+		if hold.State == StateHeld {
+			balances[hold.Currency] = roundAmount(balances[hold.Currency] + hold.Remaining())
+		}
+	}
+	return balances
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func newHoldID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "hold_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,156 @@
+package escrow
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type recordingHandler struct {
+	payouts []float64
+	refunds []float64
+}
+
+func (h *recordingHandler) Payout(ctx context.Context, hold Hold, amount float64) error {
+	h.payouts = append(h.payouts, amount)
+	return nil
+}
+
+func (h *recordingHandler) Refund(ctx context.Context, hold Hold, amount float64) error {
+	h.refunds = append(h.refunds, amount)
+	return nil
+}
+
+func TestManager_PartialReleaseAndReturn(t *testing.T) {
+	handler := &recordingHandler{}
+	manager := NewManager(Config{Payouts: handler, Refunds: handler})
+	ctx := context.Background()
+
+	hold, err := manager.Hold("TX1", 100.00, "USD")
+	if err != nil {
+		t.Fatalf("Expected hold to be created, got error: %v", err)
+	}
+
+	if hold, err = manager.Release(ctx, hold.ID, 70.00); err != nil {
+		t.Fatalf("Expected release to succeed, got error: %v", err)
+	}
+
+	if hold.Remaining() != 30.00 || hold.State != StateHeld {
+		t.Errorf("Expected 30.00 remaining in HELD, got %.2f in %s", hold.Remaining(), hold.State)
+	}
+
+	if balances := manager.Balances(); balances["USD"] != 30.00 {
+		t.Errorf("Expected USD balance 30.00, got %v", balances)
+	}
+
+	if _, err := manager.Return(ctx, hold.ID, 50.00); !errors.Is(err, ErrAmountExceedsRemaining) {
+		t.Errorf("Expected ErrAmountExceedsRemaining, got: %v", err)
+	}
+
+	if hold, err = manager.Return(ctx, hold.ID, 30.00); err != nil {
+		t.Fatalf("Expected return to succeed, got error: %v", err)
+	}
+
+	if hold.State != StateSettled {
+		t.Errorf("Expected state SETTLED, got %s", hold.State)
+	}
+
+	if _, err := manager.Release(ctx, hold.ID, 1); !errors.Is(err, ErrHoldClosed) {
+		t.Errorf("Expected ErrHoldClosed, got: %v", err)
+	}
+
+	if len(handler.payouts) != 1 || len(handler.refunds) != 1 {
+		t.Errorf("Expected 1 payout and 1 refund, got %v and %v", handler.payouts, handler.refunds)
+	}
+}
+
+func TestManager_ExpireDue(t *testing.T) {
+	handler := &recordingHandler{}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	manager := NewManager(Config{
+		Payouts:      handler,
+		Refunds:      handler,
+		HoldDuration: 24 * time.Hour,
+		OnExpiry:     ExpireRelease,
+		Now:          func() time.Time { return now },
+	})
+
+	hold, _ := manager.Hold("TX1", 40.00, "EUR")
+
+	expired, err := manager.ExpireDue(context.Background())
+	if err != nil || len(expired) != 0 {
+		t.Fatalf("Expected no expired holds yet, got %v (%v)", expired, err)
+	}
+
+	now = now.Add(25 * time.Hour)
+
+	expired, err = manager.ExpireDue(context.Background())
+	if err != nil || len(expired) != 1 {
+		t.Fatalf("Expected 1 expired hold, got %v (%v)", expired, err)
+	}
+
+	hold, _ = manager.Get(hold.ID)
+	if hold.State != StateExpired || hold.Released != 40.00 {
+		t.Errorf("Expected hold released on expiry, got %+v", hold)
+	}
+}
+
+func TestManager_InvalidHold(t *testing.T) {
+	manager := NewManager(Config{})
+
+	if _, err := manager.Hold("TX1", 0, "USD"); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("Expected ErrInvalidAmount, got: %v", err)
+	}
+
+	if _, err := manager.Get("missing"); !errors.Is(err, ErrHoldNotFound) {
+		t.Errorf("Expected ErrHoldNotFound, got: %v", err)
+	}
+}
+
+// blockingHandler pays out once release is closed
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) Payout(ctx context.Context, hold Hold, amount float64) error {
+	h.started <- struct{}{}
+	<-h.release
+	return nil
+}
+
+func TestManager_HandlerRunsUnlocked(t *testing.T) {
+	handler := &blockingHandler{started: make(chan struct{}), release: make(chan struct{})}
+	manager := NewManager(Config{Payouts: handler})
+	ctx := context.Background()
+
+	hold, _ := manager.Hold("TX1", 50.00, "USD")
+	other, _ := manager.Hold("TX2", 10.00, "USD")
+
+	done := make(chan error)
+	go func() {
+		_, err := manager.Release(ctx, hold.ID, 50.00)
+		done <- err
+	}()
+	<-handler.started
+
+	// the manager stays usable while the payout is in flight
+	if _, err := manager.Get(other.ID); err != nil {
+		t.Fatalf("Expected other holds to be readable, got error: %v", err)
+	}
+	if _, err := manager.Release(ctx, hold.ID, 10.00); !errors.Is(err, ErrHoldBusy) {
+		t.Errorf("Expected ErrHoldBusy for a second transfer, got: %v", err)
+	}
+
+	close(handler.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected release to succeed, got error: %v", err)
+	}
+
+	hold, _ = manager.Get(hold.ID)
+	if hold.State != StateReleased || hold.Released != 50.00 {
+		t.Errorf("Expected hold released after the payout, got %+v", hold)
+	}
+}
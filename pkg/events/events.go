@@ -0,0 +1,71 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// event types emitted by the processor
+const (
+	TypePaymentStatusUnknown = "payment.status_unknown"
+	TypePaymentDeferred      = "payment.deferred"
+	TypePaymentForwarded     = "payment.forwarded"
+	TypeForwardExpired       = "payment.forward_expired"
+	TypePaymentExpired       = "payment.expired"
+	TypePaymentSettled       = "payment.settled"
+	TypePaymentReturned      = "payment.returned"
+	TypeComplianceHold       = "payment.compliance_hold"
+	TypeGatewayNotification  = "payment.gateway_notification"
+	TypeFraudDecision        = "payment.fraud_decision"
+	TypeAlertFiring          = "alert.firing"
+	TypeAlertResolved        = "alert.resolved"
+	TypeProviderDrift        = "provider.schema_drift"
+	TypeProviderPromoted     = "provider.canary_promoted"
+)
+
+// Event is a notification about something that happened to a payment. The
+// fields of Data depend on the type, see the data structs in schema.go.
+type Event struct {
+	Type          string            `json:"type"`
+	Version       int               `json:"version"` // SchemaVersion the event follows
+	Time          time.Time         `json:"time"`
+	Provider      string            `json:"provider,omitempty"`
+	TransactionID string            `json:"transaction_id,omitempty"`
+	Data          map[string]string `json:"data,omitempty"`
+}
+
+// Publisher delivers events to downstream consumers
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// History is implemented by publishers that keep what they published, such
+// as MemoryPublisher
+type History interface {
+	Events() []Event
+}
+
+// MemoryPublisher keeps published events in memory, mainly for tests
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns a copy of the published events in order
+func (p *MemoryPublisher) Events() []Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Event(nil), p.events...)
+}
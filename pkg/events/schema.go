@@ -0,0 +1,272 @@
+package events
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the version of the event envelope and of the data of
+// every event type. Within a version fields are only added; removing,
+// renaming or retyping a field is a breaking change and bumps the version.
+// Events published before versioning carry version 0.
+const SchemaVersion = 1
+
+// The data of each event type. Data travels as a map of strings: lists are
+// comma separated, numbers decimal and durations in time.Duration notation.
+// Encode builds the map from these structs and Event.Decode reads it back.
+
+// PaymentStatusUnknownData is the data of payment.status_unknown
+type PaymentStatusUnknownData struct {
+	RawStatus string `json:"raw_status"` // status as the provider sent it
+}
+
+// PaymentDeferredData is the data of payment.deferred
+type PaymentDeferredData struct {
+	ErrorCode string `json:"error_code"` // failure that deferred the payment
+}
+
+// PaymentForwardData is the data of payment.forwarded and
+// payment.forward_expired; the transaction id is set once a provider answered
+type PaymentForwardData struct {
+	EntryID   string `json:"entry_id"`             // id of the queued payment
+	Status    string `json:"status,omitempty"`     // status the provider answered
+	ErrorCode string `json:"error_code,omitempty"` // failure of the last attempt
+}
+
+// PaymentExpiredData is the data of payment.expired
+type PaymentExpiredData struct {
+	PreviousStatus string        `json:"previous_status"`
+	Expiry         time.Duration `json:"expiry"`                  // age at which the payment expired
+	ReleaseError   string        `json:"release_error,omitempty"` // why releasing the funds failed
+}
+
+// PaymentSettlementData is the data of payment.settled and payment.returned
+type PaymentSettlementData struct {
+	PreviousStatus string `json:"previous_status"`
+	Status         string `json:"status"`
+	ReturnCode     string `json:"return_code,omitempty"` // network return code of returned payments
+	Reason         string `json:"reason,omitempty"`      // normalized reason of returned payments
+}
+
+// ComplianceHoldData is the data of payment.compliance_hold
+type ComplianceHoldData struct {
+	Decision      string   `json:"decision"` // flag or block
+	Reason        string   `json:"reason"`
+	Matches       []string `json:"matches,omitempty"` // list entries the payment matched
+	SubMerchantID string   `json:"sub_merchant_id,omitempty"`
+}
+
+// GatewayNotificationData is the data of payment.gateway_notification, a
+// webhook of the provider applied to the payment
+type GatewayNotificationData struct {
+	EventID    string  `json:"event_id"` // the gateway's notification id
+	Event      string  `json:"event"`    // approved, declined, captured, refunded or chargeback
+	Status     string  `json:"status"`   // status of the payment afterwards
+	Amount     float64 `json:"amount,omitempty"`
+	Currency   string  `json:"currency,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+	Key        string  `json:"key"`        // correlation key that found the payment
+	Confidence float64 `json:"confidence"` // confidence of the correlation
+}
+
+// FraudDecisionData is the data of payment.fraud_decision, published for
+// payments the fraud provider's assessment sent to review or denied
+type FraudDecisionData struct {
+	Decision      string   `json:"decision"` // review or deny
+	Score         float64  `json:"score"`
+	AssessmentID  string   `json:"assessment_id,omitempty"` // the fraud service's reference
+	Reasons       []string `json:"reasons,omitempty"`
+	Amount        float64  `json:"amount"`
+	Currency      string   `json:"currency"`
+	SubMerchantID string   `json:"sub_merchant_id,omitempty"`
+}
+
+// AlertData is the data of alert.firing and alert.resolved
+type AlertData struct {
+	Rule    string  `json:"rule"`
+	Value   float64 `json:"value"` // measured value that crossed the threshold
+	Message string  `json:"message"`
+}
+
+// ProviderDriftData is the data of provider.schema_drift
+type ProviderDriftData struct {
+	Fields []string `json:"fields"` // response fields the decoder did not expect
+}
+
+// ProviderPromotedData is the data of provider.canary_promoted
+type ProviderPromotedData struct {
+	Attempts int `json:"attempts"` // canary payments before the promotion
+	Errors   int `json:"errors"`   // gateway failures among them
+}
+
+// dataTypes maps every event type to the struct of its data
+var dataTypes = map[string]interface{}{
+	TypePaymentStatusUnknown: PaymentStatusUnknownData{},
+	TypePaymentDeferred:      PaymentDeferredData{},
+	TypePaymentForwarded:     PaymentForwardData{},
+	TypeForwardExpired:       PaymentForwardData{},
+	TypePaymentExpired:       PaymentExpiredData{},
+	TypePaymentSettled:       PaymentSettlementData{},
+	TypePaymentReturned:      PaymentSettlementData{},
+	TypeComplianceHold:       ComplianceHoldData{},
+	TypeGatewayNotification:  GatewayNotificationData{},
+	TypeFraudDecision:        FraudDecisionData{},
+	TypeAlertFiring:          AlertData{},
+	TypeAlertResolved:        AlertData{},
+	TypeProviderDrift:        ProviderDriftData{},
+	TypeProviderPromoted:     ProviderPromotedData{},
+}
+
+// Schema describes the events of a schema version, for consumers that
+// check their expectations against it
+type Schema struct {
+	Version  int                `json:"version"`
+	Envelope []Field            `json:"envelope"`
+	Types    map[string][]Field `json:"types"` // data fields per event type
+}
+
+// Field is a field of the envelope or of the data of an event type. Type is
+// one of string, integer, number, boolean, time, duration, list or object.
+type Field struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Optional bool   `json:"optional,omitempty"`
+}
+
+// Describe returns the schema of SchemaVersion
+func Describe() Schema {
+	schema := Schema{
+		Version:  SchemaVersion,
+		Envelope: fieldsOf(reflect.TypeOf(Event{})),
+		Types:    make(map[string][]Field, len(dataTypes)),
+	}
+	for eventType, data := range dataTypes {
+		schema.Types[eventType] = fieldsOf(reflect.TypeOf(data))
+	}
+	return schema
+}
+
+func fieldsOf(structType reflect.Type) []Field {
+	var fields []Field
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		name, optional := jsonTag(field)
+		fields = append(fields, Field{Name: name, Type: fieldType(field.Type), Optional: optional})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return fields
+}
+
+func jsonTag(field reflect.StructField) (string, bool) {
+	name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		name = field.Name
+	}
+	return name, options == "omitempty"
+}
+
+func fieldType(t reflect.Type) string {
+	switch {
+	case t == reflect.TypeOf(time.Duration(0)):
+		return "duration"
+	case t == reflect.TypeOf(time.Time{}):
+		return "time"
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Int, reflect.Int64:
+		return "integer"
+	case reflect.Float64:
+		return "number"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Slice:
+		return "list"
+	}
+	return "object"
+}
+
+// Encode turns event data, one of the data structs of this package, into
+// the map of an Event. Optional fields are left out when empty.
+func Encode(data interface{}) map[string]string {
+	value := reflect.ValueOf(data)
+	encoded := make(map[string]string, value.NumField())
+
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		name, optional := jsonTag(value.Type().Field(i))
+		if optional && field.IsZero() {
+			continue
+		}
+
+		switch field := field.Interface().(type) {
+		case string:
+			encoded[name] = field
+		case time.Duration:
+			encoded[name] = field.String()
+		case int:
+			encoded[name] = strconv.Itoa(field)
+		case float64:
+			encoded[name] = strconv.FormatFloat(field, 'f', 4, 64)
+		case bool:
+			encoded[name] = strconv.FormatBool(field)
+		case []string:
+			encoded[name] = strings.Join(field, ",")
+		default:
+			panic(fmt.Sprintf("events: unsupported data field %s of type %T", name, field))
+		}
+	}
+	return encoded
+}
+
+// Decode reads the event's data into data, a pointer to the data struct of
+// its type. Keys the struct does not know are ignored, so consumers keep
+// working when fields are added.
+func (e Event) Decode(data interface{}) error {
+	value := reflect.ValueOf(data).Elem()
+
+	for i := 0; i < value.NumField(); i++ {
+		name, _ := jsonTag(value.Type().Field(i))
+		raw, ok := e.Data[name]
+		if !ok {
+			continue
+		}
+
+		field := value.Field(i)
+		var err error
+		switch field.Interface().(type) {
+		case string:
+			field.SetString(raw)
+		case time.Duration:
+			var duration time.Duration
+			duration, err = time.ParseDuration(raw)
+			field.SetInt(int64(duration))
+		case int:
+			var number int64
+			number, err = strconv.ParseInt(raw, 10, 64)
+			field.SetInt(number)
+		case float64:
+			var number float64
+			number, err = strconv.ParseFloat(raw, 64)
+			field.SetFloat(number)
+		case bool:
+			var flag bool
+			flag, err = strconv.ParseBool(raw)
+			field.SetBool(flag)
+		case []string:
+			if raw != "" {
+				field.Set(reflect.ValueOf(strings.Split(raw, ",")))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("event %s: field %s: %w", e.Type, name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSchema_Compatible fails when a field recorded for the current schema
+// version is removed or retyped. Adding fields is compatible; a breaking
+// change bumps SchemaVersion and records the new schema in
+// testdata/schema_v<version>.json.
+func TestSchema_Compatible(t *testing.T) {
+	path := fmt.Sprintf("testdata/schema_v%d.json", SchemaVersion)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("No recorded schema of version %d: %v", SchemaVersion, err)
+	}
+	var recorded Schema
+	if err := json.Unmarshal(raw, &recorded); err != nil {
+		t.Fatalf("Malformed %s: %v", path, err)
+	}
+
+	current := Describe()
+	compareFields(t, "envelope", recorded.Envelope, current.Envelope)
+	for eventType, fields := range recorded.Types {
+		if _, ok := current.Types[eventType]; !ok {
+			t.Errorf("Event type %s was removed", eventType)
+			continue
+		}
+		compareFields(t, eventType, fields, current.Types[eventType])
+	}
+}
+
+func compareFields(t *testing.T, owner string, recorded, current []Field) {
+	t.Helper()
+
+	byName := make(map[string]Field, len(current))
+	for _, field := range current {
+		byName[field.Name] = field
+	}
+	for _, field := range recorded {
+		now, ok := byName[field.Name]
+		switch {
+		case !ok:
+			t.Errorf("%s: field %s was removed", owner, field.Name)
+		case now.Type != field.Type:
+			t.Errorf("%s: field %s changed type from %s to %s", owner, field.Name, field.Type, now.Type)
+		case now.Optional && !field.Optional:
+			t.Errorf("%s: required field %s became optional", owner, field.Name)
+		}
+	}
+}
+
+func TestSchema_DescribesEveryType(t *testing.T) {
+	types := []string{
+		TypePaymentStatusUnknown, TypePaymentDeferred, TypePaymentForwarded, TypeForwardExpired,
+		TypePaymentExpired, TypePaymentSettled, TypePaymentReturned, TypeComplianceHold,
+		TypeGatewayNotification, TypeFraudDecision, TypeAlertFiring, TypeAlertResolved, TypeProviderDrift, TypeProviderPromoted,
+	}
+
+	schema := Describe()
+	for _, eventType := range types {
+		if len(schema.Types[eventType]) == 0 {
+			t.Errorf("No data fields described for %s", eventType)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	data := PaymentExpiredData{PreviousStatus: "PENDING", Expiry: 90 * time.Minute}
+
+	encoded := Encode(data)
+	if encoded["expiry"] != "1h30m0s" || encoded["previous_status"] != "PENDING" {
+		t.Fatalf("Unexpected encoding %v", encoded)
+	}
+	if _, ok := encoded["release_error"]; ok {
+		t.Error("Expected the empty optional field to be left out")
+	}
+
+	var decoded PaymentExpiredData
+	if err := (Event{Type: TypePaymentExpired, Data: encoded}).Decode(&decoded); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if decoded != data {
+		t.Errorf("Expected %+v, got %+v", data, decoded)
+	}
+
+	var hold ComplianceHoldData
+	event := Event{Data: Encode(ComplianceHoldData{Decision: "block", Matches: []string{"issuer_country:IR", "name:x"}})}
+	if err := event.Decode(&hold); err != nil || !reflect.DeepEqual(hold.Matches, []string{"issuer_country:IR", "name:x"}) {
+		t.Errorf("Unexpected matches %v (%v)", hold.Matches, err)
+	}
+
+	var promoted ProviderPromotedData
+	if err := (Event{Data: map[string]string{"attempts": "many"}}).Decode(&promoted); err == nil {
+		t.Error("Expected error decoding a malformed number")
+	}
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pgas/pkg/problem"
+)
+
+// headers of signed callbacks
+const (
+	HeaderTimestamp = "X-PGAS-Timestamp" // unix seconds, part of the signed payload
+	HeaderSignature = "X-PGAS-Signature" // "v1=" and the hex HMAC-SHA256
+)
+
+const signatureVersion = "v1="
+
+// largest callback body the verifying middleware reads
+const maxCallbackBody = 1 << 20
+
+var (
+	ErrSignatureMissing = errors.New("callback signature or timestamp is missing")
+	ErrSignatureInvalid = errors.New("callback signature does not match")
+	ErrSignatureExpired = errors.New("callback timestamp is outside the accepted window")
+)
+
+// Sign returns the signature header value of body sent at timestamp. The MAC
+// covers the timestamp so a captured callback cannot be replayed later.
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	return signatureVersion + hex.EncodeToString(mac(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+func mac(secret []byte, timestamp string, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(timestamp))
+	h.Write([]byte("."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// Verifier authenticates callbacks on the merchant side. Several secrets are
+// accepted so a merchant's secret can be rotated without dropping callbacks.
+type Verifier struct {
+	Secrets [][]byte
+	// Tolerance is how far the callback timestamp may be from the local
+	// clock, either way
+	Tolerance time.Duration
+	Now       func() time.Time
+}
+
+func NewVerifier(tolerance time.Duration, secrets ...[]byte) *Verifier {
+	return &Verifier{Secrets: secrets, Tolerance: tolerance, Now: time.Now}
+}
+
+// Verify checks the signature and timestamp headers against body
+func (v *Verifier) Verify(header http.Header, body []byte) error {
+	timestamp, signature := header.Get(HeaderTimestamp), header.Get(HeaderSignature)
+	if timestamp == "" || !strings.HasPrefix(signature, signatureVersion) {
+		return ErrSignatureMissing
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrSignatureMissing
+	}
+	sent, now := time.Unix(seconds, 0), v.Now()
+	if sent.Before(now.Add(-v.Tolerance)) || sent.After(now.Add(v.Tolerance)) {
+		return ErrSignatureExpired
+	}
+
+	given, err := hex.DecodeString(strings.TrimPrefix(signature, signatureVersion))
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	for _, secret := range v.Secrets {
+		if hmac.Equal(given, mac(secret, timestamp, body)) {
+			return nil
+		}
+	}
+	return ErrSignatureInvalid
+}
+
+// Middleware rejects callbacks failing Verify with 401 before they reach
+// next, which reads the verified body as usual
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBody))
+		if err == nil {
+			err = v.Verify(r.Header, body)
+		}
+		if err != nil {
+			problem.Write(w, r, problem.New(http.StatusUnauthorized, problem.CategoryUnauthorized, "INVALID_SIGNATURE", err.Error()))
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignedWebhookPublisher(t *testing.T) {
+	secret := []byte("merchant-secret")
+	received := make(chan string, 1)
+	verifier := NewVerifier(5*time.Minute, []byte("old-secret"), secret)
+	server := httptest.NewServer(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	})))
+	defer server.Close()
+
+	publisher := NewSignedWebhookPublisher(server.URL, secret)
+	if err := publisher.Publish(context.Background(), Event{Type: TypeAlertFiring}); err != nil {
+		t.Fatalf("Expected signed delivery to be accepted, got error: %v", err)
+	}
+	if body := <-received; body == "" {
+		t.Error("Expected the verified body to reach the handler")
+	}
+
+	publisher.Secret = []byte("wrong-secret")
+	if err := publisher.Publish(context.Background(), Event{Type: TypeAlertFiring}); err == nil {
+		t.Error("Expected a delivery signed with another secret to be rejected")
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	secret := []byte("merchant-secret")
+	now := time.Unix(1700000000, 0)
+	verifier := NewVerifier(5*time.Minute, secret)
+	verifier.Now = func() time.Time { return now }
+
+	signed := func(sent time.Time, body string) http.Header {
+		header := http.Header{}
+		header.Set(HeaderTimestamp, strconv.FormatInt(sent.Unix(), 10))
+		header.Set(HeaderSignature, Sign(secret, sent, []byte(body)))
+		return header
+	}
+
+	if err := verifier.Verify(signed(now, `{"type":"x"}`), []byte(`{"type":"x"}`)); err != nil {
+		t.Errorf("Expected valid signature, got: %v", err)
+	}
+
+	cases := map[string]struct {
+		header http.Header
+		body   string
+		err    error
+	}{
+		"tampered body": {signed(now, `{"type":"x"}`), `{"type":"y"}`, ErrSignatureInvalid},
+		"expired":       {signed(now.Add(-10*time.Minute), "{}"), "{}", ErrSignatureExpired},
+		"unsigned":      {http.Header{}, "{}", ErrSignatureMissing},
+	}
+	for name, c := range cases {
+		if err := verifier.Verify(c.header, []byte(c.body)); err != c.err {
+			t.Errorf("%s: expected %v, got %v", name, c.err, err)
+		}
+	}
+
+	// moving the timestamp forward breaks the signature
+	header := signed(now.Add(-10*time.Minute), "{}")
+	header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	if err := verifier.Verify(header, []byte("{}")); err != ErrSignatureInvalid {
+		t.Errorf("Expected re-dated callback to be rejected, got %v", err)
+	}
+}
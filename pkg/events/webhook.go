@@ -0,0 +1,89 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pgas/pkg/codec"
+)
+
+// WebhookPublisher POSTs every event to a URL
+type WebhookPublisher struct {
+	URL    string
+	Client *http.Client // defaults to http.DefaultClient
+	// Codec encodes the deliveries and names their Content-Type, defaults
+	// to codec.JSON; codec.Msgpack cuts the bandwidth of busy endpoints
+	Codec codec.Codec
+	// Secret signs every delivery, see Sign. Each merchant endpoint gets
+	// its own publisher and secret; nil sends unsigned callbacks.
+	Secret []byte
+	now    func() time.Time
+}
+
+func NewWebhookPublisher(url string) *WebhookPublisher {
+	return &WebhookPublisher{URL: url, now: time.Now}
+}
+
+// NewSignedWebhookPublisher signs deliveries with the merchant's secret so
+// the merchant can authenticate them with a Verifier
+func NewSignedWebhookPublisher(url string, secret []byte) *WebhookPublisher {
+	return &WebhookPublisher{URL: url, Secret: secret, now: time.Now}
+}
+
+func (w *WebhookPublisher) Publish(ctx context.Context, event Event) error {
+	encoder := w.Codec
+	if encoder == nil {
+		encoder = codec.JSON
+	}
+	body, err := encoder.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", encoder.ContentType())
+	if len(w.Secret) > 0 {
+		now := time.Now()
+		if w.now != nil {
+			now = w.now()
+		}
+		request.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+		request.Header.Set(HeaderSignature, Sign(w.Secret, now, body))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s answered %s", w.URL, response.Status)
+	}
+	return nil
+}
+
+// Multi fans an event out to several publishers, returning the first error
+type Multi []Publisher
+
+func (m Multi) Publish(ctx context.Context, event Event) error {
+	var firstErr error
+	for _, publisher := range m {
+		if err := publisher.Publish(ctx, event); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pgas/pkg/codec"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("Content-Type")
+	}))
+	defer server.Close()
+
+	publisher := NewWebhookPublisher(server.URL)
+	if err := publisher.Publish(context.Background(), Event{Type: TypeAlertFiring}); err != nil {
+		t.Fatalf("Expected webhook delivery, got error: %v", err)
+	}
+
+	if contentType := <-received; contentType != "application/json" {
+		t.Errorf("Expected JSON webhook, got %s", contentType)
+	}
+}
+
+func TestWebhookPublisher_Codec(t *testing.T) {
+	var delivered Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/msgpack" {
+			t.Errorf("Expected msgpack webhook, got %s", r.Header.Get("Content-Type"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		if err := codec.Msgpack.Unmarshal(body, &delivered); err != nil {
+			t.Errorf("Expected msgpack body, got error: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	publisher := NewWebhookPublisher(server.URL)
+	publisher.Codec = codec.Msgpack
+	event := Event{Type: TypePaymentSettled, TransactionID: "tx-1", Data: map[string]string{"status": "SETTLED"}}
+	if err := publisher.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Expected webhook delivery, got error: %v", err)
+	}
+
+	if delivered.Type != event.Type || delivered.TransactionID != "tx-1" || delivered.Data["status"] != "SETTLED" {
+		t.Errorf("Expected the event to arrive intact, got %+v", delivered)
+	}
+}
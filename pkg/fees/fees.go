@@ -0,0 +1,175 @@
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Input describes a prospective transaction
+type Input struct {
+	Brand    string  `json:"brand"`  // e.g. "visa", "mastercard"
+	Region   string  `json:"region"` // e.g. "domestic", "intra_regional", "inter_regional"
+	MCC      string  `json:"mcc"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// Rate is a percentage of the amount plus a fixed per-transaction fee
+type Rate struct {
+	Percent float64 `json:"percent"`
+	Fixed   float64 `json:"fixed"`
+}
+
+func (r Rate) apply(amount float64) float64 {
+	return amount*r.Percent/100 + r.Fixed
+}
+
+// Rule prices a fee component; empty match fields act as wildcards and the
+// most specific matching rule wins
+type Rule struct {
+	Brand  string `json:"brand,omitempty"`
+	Region string `json:"region,omitempty"`
+	MCC    string `json:"mcc,omitempty"`
+	Rate   Rate   `json:"rate"`
+}
+
+func (r Rule) matches(in Input) (bool, int) {
+	specificity := 0
+	for _, field := range []struct{ rule, input string }{
+		{r.Brand, in.Brand},
+		{r.Region, in.Region},
+		{r.MCC, in.MCC},
+	} {
+		if field.rule == "" {
+			continue
+		}
+		if field.rule != field.input {
+			return false, 0
+		}
+		specificity++
+	}
+	return true, specificity
+}
+
+// Table holds the configurable fee schedules
+type Table struct {
+	Interchange []Rule          `json:"interchange"`
+	Scheme      []Rule          `json:"scheme"`
+	Gateway     map[string]Rate `json:"gateway"` // per route (provider name)
+}
+
+// Estimate is the expected cost of a transaction on one route
+type Estimate struct {
+	Route         string  `json:"route"`
+	Interchange   float64 `json:"interchange"`
+	Scheme        float64 `json:"scheme"`
+	Gateway       float64 `json:"gateway"`
+	Total         float64 `json:"total"`
+	EffectiveRate float64 `json:"effective_rate"` // total as a percentage of the amount
+}
+
+type Calculator struct {
+	table Table
+}
+
+func NewCalculator(table Table) (*Calculator, error) {
+	if len(table.Interchange) == 0 {
+		return nil, errors.New("interchange table must not be empty")
+	}
+	if len(table.Gateway) == 0 {
+		return nil, errors.New("gateway fee table must not be empty")
+	}
+	return &Calculator{table: table}, nil
+}
+
+// Estimate computes interchange, scheme and gateway fees for a route
+func (c *Calculator) Estimate(route string, in Input) (Estimate, error) {
+	if in.Amount <= 0 {
+		return Estimate{}, errors.New("amount must be greater than 0")
+	}
+
+	gatewayRate, ok := c.table.Gateway[route]
+	if !ok {
+		return Estimate{}, fmt.Errorf("no gateway fees configured for route: '%s'", route)
+	}
+
+	interchangeRate, ok := lookup(c.table.Interchange, in)
+	if !ok {
+		return Estimate{}, fmt.Errorf("no interchange rate for brand '%s' in region '%s'", in.Brand, in.Region)
+	}
+
+	// scheme fees are optional, a missing rule means none are charged
+	schemeRate, _ := lookup(c.table.Scheme, in)
+
+	estimate := Estimate{
+		Route:       route,
+		Interchange: roundAmount(interchangeRate.apply(in.Amount)),
+		Scheme:      roundAmount(schemeRate.apply(in.Amount)),
+		Gateway:     roundAmount(gatewayRate.apply(in.Amount)),
+	}
+	estimate.Total = roundAmount(estimate.Interchange + estimate.Scheme + estimate.Gateway)
+	estimate.EffectiveRate = math.Round(estimate.Total/in.Amount*10000) / 100
+
+	return estimate, nil
+}
+
+// Compare estimates every route and returns them cheapest first; routes that
+// cannot be priced are skipped
+func (c *Calculator) Compare(in Input, routes ...string) []Estimate {
+	estimates := make([]Estimate, 0, len(routes))
+	for _, route := range routes {
+		if estimate, err := c.Estimate(route, in); err == nil {
+			estimates = append(estimates, estimate)
+		}
+	}
+
+	sort.SliceStable(estimates, func(i, j int) bool {
+		return estimates[i].Total < estimates[j].Total
+	})
+	return estimates
+}
+
+func lookup(rules []Rule, in Input) (Rate, bool) {
+	best := -1
+	var rate Rate
+	for _, rule := range rules {
+		if ok, specificity := rule.matches(in); ok && specificity > best {
+			best = specificity
+			rate = rule.Rate
+		}
+	}
+	return rate, best >= 0
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// DefaultTable returns illustrative rates for the built-in providers; real
+// deployments should load their acquirer's fee schedule instead
+func DefaultTable() Table {
+	return Table{
+		Interchange: []Rule{
+			{Brand: "visa", Rate: Rate{Percent: 1.80, Fixed: 0.10}},
+			{Brand: "visa", Region: "domestic", Rate: Rate{Percent: 1.51, Fixed: 0.10}},
+			{Brand: "visa", MCC: "5411", Region: "domestic", Rate: Rate{Percent: 1.22, Fixed: 0.05}},
+			{Brand: "mastercard", Rate: Rate{Percent: 1.90, Fixed: 0.10}},
+			{Brand: "mastercard", Region: "domestic", Rate: Rate{Percent: 1.58, Fixed: 0.10}},
+			{Brand: "amex", Rate: Rate{Percent: 2.50, Fixed: 0.10}},
+		},
+		Scheme: []Rule{
+			{Brand: "visa", Rate: Rate{Percent: 0.14}},
+			{Brand: "visa", Region: "inter_regional", Rate: Rate{Percent: 0.45}},
+			{Brand: "mastercard", Rate: Rate{Percent: 0.13}},
+			{Brand: "mastercard", Region: "inter_regional", Rate: Rate{Percent: 0.60}},
+			{Brand: "amex", Rate: Rate{Percent: 0.15}},
+		},
+		Gateway: map[string]Rate{
+			"visa":       {Percent: 0.25, Fixed: 0.05},
+			"mastercard": {Percent: 0.20, Fixed: 0.08},
+			"amex":       {Percent: 0.30, Fixed: 0.05},
+		},
+	}
+}
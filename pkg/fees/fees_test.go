@@ -0,0 +1,73 @@
+package fees
+
+import "testing"
+
+func TestCalculator_Estimate(t *testing.T) {
+	calculator, err := NewCalculator(DefaultTable())
+	if err != nil {
+		t.Fatalf("Expected calculator to be created, got error: %v", err)
+	}
+
+	estimate, err := calculator.Estimate("visa", Input{Brand: "visa", Region: "domestic", MCC: "5411", Amount: 100, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("Expected estimate, got error: %v", err)
+	}
+
+	// most specific interchange rule (grocery) applies: 1.22% + 0.05
+	if estimate.Interchange != 1.27 {
+		t.Errorf("Expected interchange 1.27, got %.2f", estimate.Interchange)
+	}
+
+	if estimate.Scheme != 0.14 || estimate.Gateway != 0.30 || estimate.Total != 1.71 {
+		t.Errorf("Unexpected estimate: %+v", estimate)
+	}
+
+	if estimate.EffectiveRate != 1.71 {
+		t.Errorf("Expected effective rate 1.71, got %.2f", estimate.EffectiveRate)
+	}
+}
+
+func TestCalculator_Errors(t *testing.T) {
+	calculator, _ := NewCalculator(DefaultTable())
+
+	testCases := []struct {
+		name  string
+		route string
+		input Input
+	}{
+		{"zero amount", "visa", Input{Brand: "visa", Amount: 0}},
+		{"unknown route", "paypal", Input{Brand: "visa", Amount: 10}},
+		{"unknown brand", "visa", Input{Brand: "discover", Amount: 10}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := calculator.Estimate(tc.route, tc.input); err == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+
+	if _, err := NewCalculator(Table{}); err == nil {
+		t.Error("Expected error for empty table")
+	}
+}
+
+func TestCalculator_Compare(t *testing.T) {
+	calculator, _ := NewCalculator(Table{
+		Interchange: []Rule{{Rate: Rate{Percent: 1}}},
+		Gateway: map[string]Rate{
+			"expensive": {Percent: 1},
+			"cheap":     {Percent: 0.1},
+		},
+	})
+
+	estimates := calculator.Compare(Input{Brand: "visa", Amount: 100}, "expensive", "cheap", "missing")
+	if len(estimates) != 2 {
+		t.Fatalf("Expected 2 estimates, got %d", len(estimates))
+	}
+
+	if estimates[0].Route != "cheap" {
+		t.Errorf("Expected cheapest route first, got %s", estimates[0].Route)
+	}
+}
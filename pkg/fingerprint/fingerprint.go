@@ -0,0 +1,201 @@
+package fingerprint
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Algorithm computes a keyed digest of card data
+type Algorithm interface {
+	Name() string
+	Sum(key []byte, data []byte) []byte
+}
+
+type hmacAlgorithm struct {
+	name string
+	new  func() hash.Hash
+}
+
+func (a hmacAlgorithm) Name() string {
+	return a.name
+}
+
+func (a hmacAlgorithm) Sum(key []byte, data []byte) []byte {
+	mac := hmac.New(a.new, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+var (
+	HMACSHA256 Algorithm = hmacAlgorithm{name: "hmac-sha256", new: sha256.New}
+	HMACSHA512 Algorithm = hmacAlgorithm{name: "hmac-sha512", new: sha512.New}
+)
+
+// Key is a versioned secret used for fingerprinting
+type Key struct {
+	Version int
+	Secret  []byte
+}
+
+// operator supplied fingerprinting configuration
+type Config struct {
+	Algorithm     Algorithm // defaults to HMACSHA256
+	Keys          []Key
+	ActiveVersion int // key version used for new fingerprints
+}
+
+// Fingerprint is a keyed, non-reversible card identifier carrying the
+// metadata needed to recompute it after a key rotation
+type Fingerprint struct {
+	Algorithm string `json:"algorithm"`
+	Version   int    `json:"version"`
+	Value     string `json:"value"`
+}
+
+// String renders the fingerprint as "<algorithm>:<version>:<hex digest>"
+func (f Fingerprint) String() string {
+	return f.Algorithm + ":" + strconv.Itoa(f.Version) + ":" + f.Value
+}
+
+// Parse reads a fingerprint previously produced by Fingerprint.String
+func Parse(s string) (Fingerprint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return Fingerprint{}, fmt.Errorf("invalid fingerprint format: %q", s)
+	}
+
+	version, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Fingerprint{}, fmt.Errorf("invalid fingerprint version: %q", parts[1])
+	}
+
+	return Fingerprint{Algorithm: parts[0], Version: version, Value: parts[2]}, nil
+}
+
+type Fingerprinter struct {
+	algorithm Algorithm
+	keys      map[int][]byte
+	active    int
+}
+
+func NewFingerprinter(cfg Config) (*Fingerprinter, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("at least one fingerprint key is required")
+	}
+
+	algorithm := cfg.Algorithm
+	if algorithm == nil {
+		algorithm = HMACSHA256
+	}
+
+	keys := make(map[int][]byte, len(cfg.Keys))
+	for _, key := range cfg.Keys {
+		if len(key.Secret) < 16 {
+			return nil, fmt.Errorf("fingerprint key version %d must be at least 16 bytes", key.Version)
+		}
+		if _, exists := keys[key.Version]; exists {
+			return nil, fmt.Errorf("duplicate fingerprint key version %d", key.Version)
+		}
+		keys[key.Version] = key.Secret
+	}
+
+	if _, ok := keys[cfg.ActiveVersion]; !ok {
+		return nil, fmt.Errorf("active fingerprint key version %d is not configured", cfg.ActiveVersion)
+	}
+
+	return &Fingerprinter{
+		algorithm: algorithm,
+		keys:      keys,
+		active:    cfg.ActiveVersion,
+	}, nil
+}
+
+// ActiveVersion returns the key version used for new fingerprints
+func (f *Fingerprinter) ActiveVersion() int {
+	return f.active
+}
+
+// Fingerprint computes the fingerprint of a card number with the active key
+func (f *Fingerprinter) Fingerprint(cardNumber string) (Fingerprint, error) {
+	return f.fingerprintWith(cardNumber, f.active)
+}
+
+func (f *Fingerprinter) fingerprintWith(cardNumber string, version int) (Fingerprint, error) {
+	key, ok := f.keys[version]
+	if !ok {
+		return Fingerprint{}, fmt.Errorf("fingerprint key version %d is not configured", version)
+	}
+
+	pan := normalizePAN(cardNumber)
+	if pan == "" {
+		return Fingerprint{}, errors.New("card number is required")
+	}
+
+	return Fingerprint{
+		Algorithm: f.algorithm.Name(),
+		Version:   version,
+		Value:     hex.EncodeToString(f.algorithm.Sum(key, []byte(pan))),
+	}, nil
+}
+
+// Matches reports whether the card number produces the given fingerprint,
+// using whichever key version the fingerprint was created with
+func (f *Fingerprinter) Matches(cardNumber string, fp Fingerprint) bool {
+	if fp.Algorithm != f.algorithm.Name() {
+		return false
+	}
+
+	computed, err := f.fingerprintWith(cardNumber, fp.Version)
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(computed.Value), []byte(fp.Value)) == 1
+}
+
+// Candidates computes the card's fingerprint under every configured key,
+// active key first, so lookups find records that were not rotated yet
+func (f *Fingerprinter) Candidates(cardNumber string) ([]Fingerprint, error) {
+	versions := make([]int, 0, len(f.keys))
+	for version := range f.keys {
+		if version != f.active {
+			versions = append(versions, version)
+		}
+	}
+	sort.Ints(versions)
+
+	candidates := make([]Fingerprint, 0, len(f.keys))
+	for _, version := range append([]int{f.active}, versions...) {
+		fp, err := f.fingerprintWith(cardNumber, version)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, fp)
+	}
+	return candidates, nil
+}
+
+// IsStale reports whether the fingerprint was produced by a non-active key
+// or a different algorithm and should be re-fingerprinted
+func (f *Fingerprinter) IsStale(fp Fingerprint) bool {
+	return fp.Version != f.active || fp.Algorithm != f.algorithm.Name()
+}
+
+func normalizePAN(cardNumber string) string {
+	var b strings.Builder
+	for _, r := range cardNumber {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
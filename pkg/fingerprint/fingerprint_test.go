@@ -0,0 +1,140 @@
+package fingerprint
+
+import (
+	"errors"
+	"testing"
+)
+
+func newTestFingerprinter(t *testing.T, active int) *Fingerprinter {
+	t.Helper()
+
+	f, err := NewFingerprinter(Config{
+		Keys: []Key{
+			{Version: 1, Secret: []byte("0123456789abcdef-v1")},
+			{Version: 2, Secret: []byte("0123456789abcdef-v2")},
+		},
+		ActiveVersion: active,
+	})
+	if err != nil {
+		t.Fatalf("Expected fingerprinter to be created, got error: %v", err)
+	}
+
+	return f
+}
+
+func TestNewFingerprinter_InvalidConfig(t *testing.T) {
+	testCases := []struct {
+		name string
+		cfg  Config
+	}{
+		{"no keys", Config{}},
+		{"short key", Config{Keys: []Key{{Version: 1, Secret: []byte("short")}}, ActiveVersion: 1}},
+		{"missing active key", Config{Keys: []Key{{Version: 1, Secret: []byte("0123456789abcdef")}}, ActiveVersion: 2}},
+		{"duplicate version", Config{Keys: []Key{
+			{Version: 1, Secret: []byte("0123456789abcdef")},
+			{Version: 1, Secret: []byte("fedcba9876543210")},
+		}, ActiveVersion: 1}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewFingerprinter(tc.cfg); err == nil {
+				t.Error("Expected error for invalid config")
+			}
+		})
+	}
+}
+
+func TestFingerprint_DeterministicAndNormalized(t *testing.T) {
+	f := newTestFingerprinter(t, 1)
+
+	a, err := f.Fingerprint("4111111111111111")
+	if err != nil {
+		t.Fatalf("Expected fingerprint, got error: %v", err)
+	}
+
+	b, _ := f.Fingerprint("4111 1111 1111 1111")
+	if a != b {
+		t.Errorf("Expected equal fingerprints, got %s and %s", a, b)
+	}
+
+	if a.Algorithm != "hmac-sha256" || a.Version != 1 {
+		t.Errorf("Unexpected fingerprint metadata: %+v", a)
+	}
+
+	parsed, err := Parse(a.String())
+	if err != nil || parsed != a {
+		t.Errorf("Expected round trip of %s, got %+v (%v)", a, parsed, err)
+	}
+}
+
+func TestFingerprint_MatchesAcrossRotation(t *testing.T) {
+	old := newTestFingerprinter(t, 1)
+	fp, _ := old.Fingerprint("4111111111111111")
+
+	rotated := newTestFingerprinter(t, 2)
+	if !rotated.Matches("4111111111111111", fp) {
+		t.Error("Expected old fingerprint to remain matchable after rotation")
+	}
+
+	if rotated.Matches("5555555555554444", fp) {
+		t.Error("Expected different card not to match")
+	}
+
+	if !rotated.IsStale(fp) {
+		t.Error("Expected old fingerprint to be stale")
+	}
+
+	fresh, changed, matched := rotated.Refresh("4111111111111111", fp)
+	if !matched || !changed || fresh.Version != 2 {
+		t.Errorf("Expected refreshed v2 fingerprint, got %+v changed=%v matched=%v", fresh, changed, matched)
+	}
+}
+
+func TestFingerprint_Candidates(t *testing.T) {
+	old := newTestFingerprinter(t, 1)
+	fp, _ := old.Fingerprint("4111111111111111")
+
+	candidates, err := newTestFingerprinter(t, 2).Candidates("4111111111111111")
+	if err != nil || len(candidates) != 2 {
+		t.Fatalf("Expected one candidate per key, got %v (%v)", candidates, err)
+	}
+	if candidates[0].Version != 2 || candidates[1] != fp {
+		t.Errorf("Expected active key first and the old fingerprint second, got %v", candidates)
+	}
+}
+
+func TestRotate(t *testing.T) {
+	old := newTestFingerprinter(t, 1)
+	fp1, _ := old.Fingerprint("4111111111111111")
+	fp2, _ := old.Fingerprint("5555555555554444")
+
+	f := newTestFingerprinter(t, 2)
+	current, _ := f.Fingerprint("378282246310005")
+
+	cards := map[string]string{"a": "4111111111111111"}
+	source := func(id string) (string, error) {
+		if pan, ok := cards[id]; ok {
+			return pan, nil
+		}
+		return "", errors.New("not in vault")
+	}
+
+	records, report := f.Rotate([]Record{
+		{ID: "a", Fingerprint: fp1},
+		{ID: "b", Fingerprint: fp2},
+		{ID: "c", Fingerprint: current},
+	}, source)
+
+	if report.Rotated != 1 || report.Current != 1 || len(report.Failed) != 1 {
+		t.Errorf("Unexpected rotation report: %+v", report)
+	}
+
+	if records[0].Fingerprint.Version != 2 {
+		t.Errorf("Expected record a to be rotated, got version %d", records[0].Fingerprint.Version)
+	}
+
+	if records[1].Fingerprint != fp2 {
+		t.Error("Expected failed record to keep its old fingerprint")
+	}
+}
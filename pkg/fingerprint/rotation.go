@@ -0,0 +1,72 @@
+package fingerprint
+
+// Record is a stored item identified by a card fingerprint
+type Record struct {
+	ID          string
+	Fingerprint Fingerprint
+}
+
+// CardSource resolves the card number behind a record (e.g. from a vault)
+// so it can be re-fingerprinted with the active key
+type CardSource func(recordID string) (string, error)
+
+// RotationReport summarizes a re-fingerprinting run
+type RotationReport struct {
+	Rotated int
+	Current int
+	Failed  map[string]error
+}
+
+// Rotate re-fingerprints every stale record with the active key. Records that
+// cannot be resolved keep their old fingerprint, which stays matchable through
+// its version metadata as long as the old key remains configured.
+func (f *Fingerprinter) Rotate(records []Record, source CardSource) ([]Record, RotationReport) {
+	report := RotationReport{Failed: make(map[string]error)}
+	rotated := make([]Record, len(records))
+
+	for i, record := range records {
+		rotated[i] = record
+
+		if !f.IsStale(record.Fingerprint) {
+			report.Current++
+			continue
+		}
+
+		cardNumber, err := source(record.ID)
+		if err != nil {
+			report.Failed[record.ID] = err
+			continue
+		}
+
+		fp, err := f.Fingerprint(cardNumber)
+		if err != nil {
+			report.Failed[record.ID] = err
+			continue
+		}
+
+		rotated[i].Fingerprint = fp
+		report.Rotated++
+	}
+
+	return rotated, report
+}
+
+// Refresh returns an up-to-date fingerprint for a card seen again, allowing
+// lazy rotation: if the stored fingerprint matches but is stale, the new one is
+// returned with changed set to true
+func (f *Fingerprinter) Refresh(cardNumber string, stored Fingerprint) (fp Fingerprint, changed bool, matched bool) {
+	if !f.Matches(cardNumber, stored) {
+		return stored, false, false
+	}
+
+	if !f.IsStale(stored) {
+		return stored, false, true
+	}
+
+	fresh, err := f.Fingerprint(cardNumber)
+	if err != nil {
+		return stored, false, true
+	}
+
+	return fresh, true, true
+}
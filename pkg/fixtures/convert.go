@@ -0,0 +1,93 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"pgas/pkg/providers"
+)
+
+// Conversion is a legacy map-based response turned into a golden fixture
+type Conversion struct {
+	Fixture Fixture `json:"fixture"`
+	// Dropped lists legacy fields the wire struct has no place for, as
+	// dotted paths; they are not part of the fixture
+	Dropped []string `json:"dropped,omitempty"`
+}
+
+// Convert decodes a recorded response, typically the map[string]interface{}
+// returned by a provider simulator, into the provider's typed wire struct and
+// records what the current parsers make of it as the expectation. The
+// expectations describe today's behavior, review them before committing.
+func Convert(provider providers.Provider, name, kind string, legacy interface{}) (Conversion, error) {
+	wireFormat, ok := provider.(providers.WireFormat)
+	if !ok {
+		return Conversion{}, fmt.Errorf("provider %q has no typed wire format", provider.GetName())
+	}
+
+	var wire interface{}
+	switch kind {
+	case KindSuccess:
+		wire = wireFormat.NewWireResponse()
+	case KindError:
+		wire = wireFormat.NewWireError()
+	default:
+		return Conversion{}, fmt.Errorf("fixture %s has unknown kind %q", name, kind)
+	}
+
+	var conversion Conversion
+	decoding := providers.Decoding{Mode: providers.DecodeWarn, OnDrift: func(drift providers.Drift) {
+		conversion.Dropped = drift.Fields
+	}}
+	if err := decoding.Decode(provider.GetName(), legacy, wire); err != nil {
+		return Conversion{}, fmt.Errorf("fixture %s: %v", name, err)
+	}
+
+	fixture := Fixture{Name: name, Provider: provider.GetName(), Kind: kind, Response: wire}
+	response, paymentError, err := Replay(provider, fixture)
+	if err != nil {
+		return Conversion{}, fmt.Errorf("fixture %s: parse failed: %v", name, err)
+	}
+	fixture.ExpectedReply = response
+	fixture.ExpectedError = paymentError
+
+	conversion.Fixture = fixture
+	return conversion, nil
+}
+
+// ConvertAll converts fixtures recorded without expectations, such as a
+// file of {"name", "kind", "response"} entries
+func ConvertAll(provider providers.Provider, legacy []Fixture) ([]Conversion, error) {
+	conversions := make([]Conversion, 0, len(legacy))
+	for i, recorded := range legacy {
+		if recorded.Name == "" {
+			recorded.Name = fmt.Sprintf("%s_%s_%d", provider.GetName(), recorded.Kind, i+1)
+		}
+		if recorded.Provider != "" && recorded.Provider != provider.GetName() {
+			return nil, fmt.Errorf("fixture %s is for provider %q, got %q", recorded.Name, recorded.Provider, provider.GetName())
+		}
+
+		conversion, err := Convert(provider, recorded.Name, recorded.Kind, recorded.Response)
+		if err != nil {
+			return nil, err
+		}
+		conversions = append(conversions, conversion)
+	}
+	return conversions, nil
+}
+
+// Write stores a fixture as <dir>/<name>.json, in the format Load reads
+func Write(dir string, fixture Fixture) (string, error) {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, fixture.Name+".json")
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
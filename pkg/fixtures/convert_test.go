@@ -0,0 +1,82 @@
+package fixtures
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/visa"
+)
+
+func TestConvert_SimulatorResponses(t *testing.T) {
+	provider := visa.GetNewVisaPaymentProvider()
+	request := providers.PaymentRequest{Mode: "visa", Amount: 10, Currency: "USD"}
+
+	request.CardNumber = "4111111111111111"
+	success, _ := provider.ProcessPayment(context.Background(), request)
+	request.CardNumber = "4000000000009995"
+	_, failure := provider.ProcessPayment(context.Background(), request)
+
+	conversions, err := ConvertAll(provider, []Fixture{
+		{Name: "approved", Kind: KindSuccess, Response: success},
+		{Kind: KindError, Response: failure},
+	})
+	if err != nil {
+		t.Fatalf("Expected conversion, got error: %v", err)
+	}
+
+	approved := conversions[0].Fixture
+	if _, ok := approved.Response.(*visa.PaymentResponse); !ok {
+		t.Errorf("Expected the typed visa wire struct, got %T", approved.Response)
+	}
+	if approved.ExpectedReply == nil || approved.ExpectedReply.Status != providers.StatusApproved {
+		t.Errorf("Expected an approved expectation, got %+v", approved.ExpectedReply)
+	}
+
+	declined := conversions[1].Fixture
+	if declined.Name != "visa_error_2" || declined.ExpectedError == nil || declined.ExpectedError.ErrorCode != "EE000051" {
+		t.Errorf("Expected a named insufficient funds fixture, got %+v", declined)
+	}
+
+	// written fixtures pass their own check
+	dir := t.TempDir()
+	for _, conversion := range conversions {
+		if _, err := Write(dir, conversion.Fixture); err != nil {
+			t.Fatal(err)
+		}
+	}
+	Run(t, provider, dir)
+}
+
+func TestConvert_ReportsDroppedFields(t *testing.T) {
+	legacy := map[string]interface{}{
+		"error_code": "MC0001",
+		"message":    "Insufficient funds",
+		"trace":      map[string]interface{}{"id": "abc"},
+	}
+
+	conversion, err := Convert(mastercard.GetNewMasterCardPaymentProvider(), "legacy", KindError, legacy)
+	if err != nil {
+		t.Fatalf("Expected conversion, got error: %v", err)
+	}
+	if len(conversion.Dropped) != 1 || conversion.Dropped[0] != "trace" {
+		t.Errorf("Expected the unknown field to be reported, got %v", conversion.Dropped)
+	}
+	if wire := conversion.Fixture.Response.(*mastercard.PaymentError); wire.ErrorCode != "MC0001" {
+		t.Errorf("Expected the error code to survive, got %+v", wire)
+	}
+}
+
+func TestConvert_Rejects(t *testing.T) {
+	provider := visa.GetNewVisaPaymentProvider()
+	if _, err := Convert(provider, "bad", "maybe", map[string]interface{}{}); err == nil {
+		t.Error("Expected an unknown kind to fail")
+	}
+	if _, err := Convert(provider, "bad", KindSuccess, map[string]interface{}{"payment_id": 7}); err == nil {
+		t.Error("Expected a mistyped field to fail")
+	}
+	if _, err := ConvertAll(provider, []Fixture{{Provider: "mastercard", Kind: KindSuccess}}); err == nil {
+		t.Error("Expected fixtures of another provider to fail")
+	}
+}
@@ -0,0 +1,180 @@
+package fixtures
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+const (
+	KindSuccess = "success"
+	KindError   = "error"
+)
+
+// Fixture is a recorded provider response together with the normalized
+// result it is expected to parse into
+type Fixture struct {
+	Name          string                     `json:"name"`
+	Provider      string                     `json:"provider"`
+	Kind          string                     `json:"kind"`
+	Response      interface{}                `json:"response"`
+	ExpectedReply *providers.PaymentResponse `json:"expected_response,omitempty"`
+	ExpectedError *providers.PaymentError    `json:"expected_error,omitempty"`
+}
+
+// Load reads a single golden fixture file
+func Load(path string) (Fixture, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Fixture{}, err
+	}
+
+	var fixture Fixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		return Fixture{}, fmt.Errorf("invalid fixture %s: %v", path, err)
+	}
+
+	if fixture.Name == "" {
+		fixture.Name = filepath.Base(path)
+	}
+
+	if fixture.Kind != KindSuccess && fixture.Kind != KindError {
+		return Fixture{}, fmt.Errorf("fixture %s has unknown kind %q", path, fixture.Kind)
+	}
+
+	return fixture, nil
+}
+
+// LoadDir reads every *.json fixture in a directory, sorted by file name
+func LoadDir(dir string) ([]Fixture, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	fixtures := make([]Fixture, 0, len(paths))
+	for _, path := range paths {
+		fixture, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		fixtures = append(fixtures, fixture)
+	}
+
+	return fixtures, nil
+}
+
+// Replay feeds the recorded response through the provider's parse pipeline
+func Replay(provider providers.Provider, fixture Fixture) (*providers.PaymentResponse, *providers.PaymentError, error) {
+	if fixture.Kind == KindError {
+		parsed, err := provider.ParseErrorResponse(fixture.Response)
+		return nil, parsed, err
+	}
+
+	parsed, err := provider.ParseSuccessResponse(fixture.Response)
+	return parsed, nil, err
+}
+
+// Check replays the fixture and compares the outcome with its expectations
+func Check(provider providers.Provider, fixture Fixture) error {
+	if fixture.Provider != "" && fixture.Provider != provider.GetName() {
+		return fmt.Errorf("fixture is for provider %q, got %q", fixture.Provider, provider.GetName())
+	}
+
+	response, paymentError, err := Replay(provider, fixture)
+	if err != nil {
+		return fmt.Errorf("parse failed: %v", err)
+	}
+
+	if fixture.Kind == KindError {
+		return compareError(fixture.ExpectedError, paymentError)
+	}
+
+	return compareResponse(fixture.ExpectedReply, response)
+}
+
+// TB is the part of testing.TB that Run reports through, so the package
+// does not import testing outside of tests
+type TB interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+	Fatalf(format string, args ...interface{})
+}
+
+// Run checks every fixture in dir, reporting each mismatch under the
+// fixture's name
+func Run(t TB, provider providers.Provider, dir string) {
+	t.Helper()
+
+	fixtures, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	if len(fixtures) == 0 {
+		t.Fatalf("No fixtures found in %s", dir)
+	}
+
+	for _, fixture := range fixtures {
+		if err := Check(provider, fixture); err != nil {
+			t.Errorf("%s: %v", fixture.Name, err)
+		}
+	}
+}
+
+func compareResponse(expected, actual *providers.PaymentResponse) error {
+	if expected == nil {
+		return nil
+	}
+
+	if actual == nil {
+		return fmt.Errorf("expected response, got nil")
+	}
+
+	if expected.Success != actual.Success ||
+		expected.TransactionID != actual.TransactionID ||
+		expected.Status != actual.Status ||
+		(expected.RawStatus != "" && expected.RawStatus != actual.RawStatus) ||
+		(expected.ResponseVersion != "" && expected.ResponseVersion != actual.ResponseVersion) ||
+		expected.Amount != actual.Amount ||
+		expected.Currency != actual.Currency {
+		return fmt.Errorf("expected response %+v, got %+v", *expected, *actual)
+	}
+
+	if expected.Date != nil && (actual.Date == nil || !expected.Date.Equal(*actual.Date)) {
+		return fmt.Errorf("expected date %v, got %v", expected.Date.Format(time.RFC3339), actual.Date)
+	}
+
+	return nil
+}
+
+func compareError(expected, actual *providers.PaymentError) error {
+	if expected == nil {
+		return nil
+	}
+
+	if actual == nil {
+		return fmt.Errorf("expected error, got nil")
+	}
+
+	// timings and the wrapped error are not part of the normalized result;
+	// like the raw status of responses, the version is only checked when
+	// the fixture records one
+	if expected.Success != actual.Success ||
+		expected.ErrorCode != actual.ErrorCode ||
+		expected.Reason != actual.Reason ||
+		expected.ErrorMessage != actual.ErrorMessage ||
+		expected.Retryable != actual.Retryable ||
+		expected.Advice != actual.Advice ||
+		(expected.ResponseVersion != "" && expected.ResponseVersion != actual.ResponseVersion) {
+		return fmt.Errorf("expected error %+v, got %+v", *expected, *actual)
+	}
+
+	return nil
+}
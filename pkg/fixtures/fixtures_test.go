@@ -0,0 +1,70 @@
+package fixtures
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/visa"
+)
+
+func TestLoad_UnknownKind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(path, []byte(`{"name":"bad","kind":"maybe","response":{}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load(path); err == nil {
+		t.Fatal("Expected error for unknown fixture kind")
+	}
+}
+
+func TestCheck_DetectsDrift(t *testing.T) {
+	fixture := Fixture{
+		Name:     "drifted",
+		Provider: "visa",
+		Kind:     KindSuccess,
+		Response: map[string]interface{}{
+			"payment_id": "PAY-1",
+			"status":     "SUCCESS", // gateway renamed "state" to "status"
+			"value": map[string]interface{}{
+				"amount":        "10.00",
+				"currency_code": "USD",
+			},
+		},
+	}
+	fixture.ExpectedReply = &providers.PaymentResponse{
+		Success:       true,
+		TransactionID: "PAY-1",
+		Status:        "SUCCESS",
+		Amount:        10,
+		Currency:      "USD",
+	}
+
+	if err := Check(visa.GetNewVisaPaymentProvider(), fixture); err == nil {
+		t.Error("Expected drifted status field to be detected")
+	}
+
+	fixture.Provider = "mastercard"
+	if err := Check(visa.GetNewVisaPaymentProvider(), fixture); err == nil {
+		t.Error("Expected provider mismatch error")
+	}
+}
+
+func TestCompareError_NormalizedFields(t *testing.T) {
+	expected := &providers.PaymentError{ErrorCode: "CARD_DECLINED", Reason: providers.ReasonCardDeclined, ErrorMessage: "declined"}
+	actual := *expected
+	actual.Timings = &providers.Timings{}
+	actual.Err = errors.New("gateway said no")
+
+	if err := compareError(expected, &actual); err != nil {
+		t.Errorf("Expected timings and the wrapped error to be ignored, got %v", err)
+	}
+
+	actual.ErrorMessage = "declined by issuer"
+	if err := compareError(expected, &actual); err == nil {
+		t.Error("Expected a changed message to be detected")
+	}
+}
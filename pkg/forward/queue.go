@@ -0,0 +1,199 @@
+// Package forward implements the store-and-forward queue used to accept
+// small payments while every provider is unreachable and submit them once
+// connectivity returns.
+package forward
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+var (
+	ErrEntryNotFound = errors.New("forward entry not found")
+	ErrKeyRequired   = errors.New("forward queue file needs an encryption key")
+)
+
+// Entry is a payment accepted locally and waiting to be submitted
+type Entry struct {
+	ID       string                   `json:"id"`
+	Request  providers.PaymentRequest `json:"request"`
+	QueuedAt time.Time                `json:"queued_at"`
+	Attempts int                      `json:"attempts"`
+	LastTry  *time.Time               `json:"last_try,omitempty"`
+}
+
+// Queue keeps deferred payments in order. With a path every change is
+// written through to disk so queued payments survive restarts. The file is
+// sealed with AES-GCM under the queue's key and never holds the CVV: it is
+// only kept in memory, so entries reloaded after a restart are submitted
+// without it and providers requiring it decline them.
+type Queue struct {
+	mu      sync.Mutex
+	path    string
+	aead    cipher.AEAD
+	entries map[string]Entry
+}
+
+// NewQueue opens the queue stored at path, an empty path keeps it in memory.
+// key is the 16, 24 or 32 byte AES key of the file and required with a path.
+func NewQueue(path string, key []byte) (*Queue, error) {
+	q := &Queue{path: path, entries: make(map[string]Entry)}
+	if path == "" {
+		return q, nil
+	}
+	if len(key) == 0 {
+		return nil, ErrKeyRequired
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	if q.aead, err = cipher.NewGCM(block); err != nil {
+		return nil, err
+	}
+
+	sealed, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	size := q.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, errors.New("forward queue file is truncated")
+	}
+	data, err := q.aead.Open(nil, sealed[:size], sealed[size:], nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		q.entries[entry.ID] = entry
+	}
+	return q, nil
+}
+
+// Enqueue stores a request and returns its entry
+func (q *Queue) Enqueue(request providers.PaymentRequest, now time.Time) (Entry, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry := Entry{ID: newEntryID(), Request: request, QueuedAt: now}
+	q.entries[entry.ID] = entry
+	if err := q.persist(); err != nil {
+		delete(q.entries, entry.ID)
+		return Entry{}, err
+	}
+	return entry, nil
+}
+
+// Pending lists queued entries, oldest first
+func (q *Queue) Pending() []Entry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entries := make([]Entry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].QueuedAt.Before(entries[j].QueuedAt) })
+	return entries
+}
+
+// Len returns the queue depth
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// MarkAttempt records a failed submission of an entry
+func (q *Queue) MarkAttempt(id string, now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	entry, ok := q.entries[id]
+	if !ok {
+		return ErrEntryNotFound
+	}
+	entry.Attempts++
+	entry.LastTry = &now
+	q.entries[id] = entry
+	return q.persist()
+}
+
+// Remove drops an entry once it was submitted or force-declined
+func (q *Queue) Remove(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.entries[id]; !ok {
+		return ErrEntryNotFound
+	}
+	delete(q.entries, id)
+	return q.persist()
+}
+
+// persist rewrites the queue file atomically, callers hold the lock
+func (q *Queue) persist() error {
+	if q.path == "" {
+		return nil
+	}
+
+	entries := make([]Entry, 0, len(q.entries))
+	for _, entry := range q.entries {
+		entry.Request.CVV = ""
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].QueuedAt.Before(entries[j].QueuedAt) })
+
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, q.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data = q.aead.Seal(nonce, nonce, data, nil)
+
+	tmp, err := os.CreateTemp(filepath.Dir(q.path), filepath.Base(q.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), q.path)
+}
+
+func newEntryID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "saf_" + hex.EncodeToString(b)
+}
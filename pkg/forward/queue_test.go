@@ -0,0 +1,96 @@
+package forward
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+func TestQueue_PersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forward.json")
+
+	queue, err := NewQueue(path, testKey)
+	if err != nil {
+		t.Fatalf("Expected new queue, got error: %v", err)
+	}
+
+	now := time.Now()
+	first, err := queue.Enqueue(providers.PaymentRequest{Mode: "visa", Amount: 10}, now)
+	if err != nil {
+		t.Fatalf("Expected enqueue to succeed, got error: %v", err)
+	}
+	second, _ := queue.Enqueue(providers.PaymentRequest{Mode: "visa", Amount: 20}, now.Add(time.Second))
+
+	if err := queue.MarkAttempt(first.ID, now); err != nil {
+		t.Fatalf("Expected attempt to be recorded, got error: %v", err)
+	}
+
+	reopened, err := NewQueue(path, testKey)
+	if err != nil {
+		t.Fatalf("Expected queue to reopen, got error: %v", err)
+	}
+
+	pending := reopened.Pending()
+	if len(pending) != 2 || pending[0].ID != first.ID || pending[1].ID != second.ID {
+		t.Fatalf("Expected both entries oldest first, got %+v", pending)
+	}
+
+	if pending[0].Attempts != 1 || pending[0].Request.Amount != 10 {
+		t.Errorf("Expected entry state to persist, got %+v", pending[0])
+	}
+
+	if err := reopened.Remove(first.ID); err != nil {
+		t.Fatalf("Expected remove to succeed, got error: %v", err)
+	}
+
+	if err := reopened.Remove(first.ID); err != ErrEntryNotFound {
+		t.Errorf("Expected ErrEntryNotFound, got %v", err)
+	}
+}
+
+func TestQueue_FileHoldsNoCardData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forward.json")
+
+	if _, err := NewQueue(path, nil); !errors.Is(err, ErrKeyRequired) {
+		t.Fatalf("Expected ErrKeyRequired without a key, got %v", err)
+	}
+
+	queue, _ := NewQueue(path, testKey)
+	request := providers.PaymentRequest{Mode: "visa", Amount: 10, CardNumber: "4111111111111111", CVV: "737"}
+	entry, err := queue.Enqueue(request, time.Now())
+	if err != nil {
+		t.Fatalf("Expected enqueue to succeed, got error: %v", err)
+	}
+
+	if queue.Pending()[0].Request.CVV != "737" {
+		t.Errorf("Expected the CVV to be kept in memory, got %+v", queue.Pending()[0].Request)
+	}
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("4111111111111111")) || bytes.Contains(data, []byte("card_number")) {
+		t.Errorf("Expected the queue file to be encrypted, got %q", data)
+	}
+
+	if _, err := NewQueue(path, bytes.Repeat([]byte{8}, 32)); err == nil {
+		t.Errorf("Expected the queue to refuse the wrong key")
+	}
+
+	reopened, err := NewQueue(path, testKey)
+	if err != nil {
+		t.Fatalf("Expected queue to reopen, got error: %v", err)
+	}
+	pending := reopened.Pending()
+	if len(pending) != 1 || pending[0].ID != entry.ID || pending[0].Request.CardNumber != request.CardNumber {
+		t.Fatalf("Expected the entry to round-trip, got %+v", pending)
+	}
+	if pending[0].Request.CVV != "" {
+		t.Errorf("Expected the CVV not to be persisted, got %q", pending[0].Request.CVV)
+	}
+}
@@ -0,0 +1,98 @@
+// Package fraud abstracts external fraud scoring services, such as Sift or
+// Forter, so merchants can plug their scores into the processor. The
+// processor asks the configured Provider before a payment reaches the
+// gateway and decides from its score and recommendation whether the payment
+// is allowed, allowed for review, or denied with FRAUD_DENIED.
+package fraud
+
+import (
+	"context"
+	"strings"
+
+	"pgas/pkg/providers"
+)
+
+type Decision string
+
+const (
+	Allow  Decision = "allow"  // the payment goes ahead
+	Review Decision = "review" // the payment goes ahead and is marked for manual review
+	Deny   Decision = "deny"   // the payment fails and is never sent to the gateway
+)
+
+// severity orders decisions from the most lenient
+var severity = map[Decision]int{Allow: 0, Review: 1, Deny: 2}
+
+// Valid reports whether d is a known decision
+func (d Decision) Valid() bool {
+	_, ok := severity[d]
+	return ok
+}
+
+// Stricter returns the stricter of two decisions; an empty or unknown b
+// never wins over a
+func Stricter(a, b Decision) Decision {
+	if severity[b] > severity[a] {
+		return b
+	}
+	return a
+}
+
+// Request is what a fraud service gets to see of a payment. Card numbers
+// are not part of it, only the BIN, the last four digits and the card's
+// fingerprint when the processor has a fingerprinter.
+type Request struct {
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency"`
+	Provider        string  `json:"provider,omitempty"`
+	Method          string  `json:"method,omitempty"`
+	BIN             string  `json:"bin,omitempty"`
+	Last4           string  `json:"last4,omitempty"`
+	Fingerprint     string  `json:"fingerprint,omitempty"`
+	MerchantCountry string  `json:"merchant_country,omitempty"`
+	IssuerCountry   string  `json:"issuer_country,omitempty"`
+	SubMerchantID   string  `json:"sub_merchant_id,omitempty"`
+	OrderID         string  `json:"order_id,omitempty"`
+	InitiatedBy     string  `json:"initiated_by,omitempty"`
+	// Metadata is the merchant's metadata of the payment, where signals
+	// such as the customer's email or IP address are passed on
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// NewRequest builds a fraud request from a payment request
+func NewRequest(paymentRequest providers.PaymentRequest) Request {
+	request := Request{
+		Amount:          paymentRequest.Amount,
+		Currency:        paymentRequest.Currency,
+		Provider:        paymentRequest.Mode,
+		Method:          paymentRequest.Method,
+		MerchantCountry: strings.ToUpper(paymentRequest.MerchantCountry),
+		IssuerCountry:   strings.ToUpper(paymentRequest.IssuerCountry),
+		SubMerchantID:   paymentRequest.SubMerchantID,
+		OrderID:         paymentRequest.OrderData["order_id"],
+		InitiatedBy:     paymentRequest.InitiatedBy,
+		Metadata:        paymentRequest.Metadata,
+	}
+	if len(paymentRequest.CardNumber) >= 10 {
+		request.BIN = paymentRequest.CardNumber[:6]
+		request.Last4 = paymentRequest.CardNumber[len(paymentRequest.CardNumber)-4:]
+	}
+	return request
+}
+
+// Assessment is a fraud service's view of a payment
+type Assessment struct {
+	// ID is the service's reference of the assessment, for disputes and
+	// feedback
+	ID string `json:"id,omitempty"`
+	// Score is the risk from 0, safe, to 1, certainly fraudulent
+	Score float64 `json:"score"`
+	// Decision is the service's recommendation, empty when it only scores
+	Decision Decision `json:"decision,omitempty"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// Provider scores payments for fraud
+type Provider interface {
+	Assess(ctx context.Context, request Request) (*Assessment, error)
+}
@@ -0,0 +1,82 @@
+package fraud
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestNewRequest(t *testing.T) {
+	request := NewRequest(providers.PaymentRequest{
+		Mode:          "visa",
+		Amount:        500,
+		Currency:      "EUR",
+		CardNumber:    "4111111111111111",
+		IssuerCountry: "fr",
+		OrderData:     map[string]string{"order_id": "o-1"},
+		Metadata:      map[string]string{"email": "a@example.com"},
+	})
+
+	if request.BIN != "411111" || request.Last4 != "1111" || request.IssuerCountry != "FR" || request.OrderID != "o-1" || request.Metadata["email"] != "a@example.com" {
+		t.Errorf("Unexpected fraud request: %+v", request)
+	}
+}
+
+func TestStricter(t *testing.T) {
+	cases := []struct{ a, b, want Decision }{
+		{Allow, Review, Review},
+		{Deny, Review, Deny},
+		{Review, "", Review},
+		{Allow, "maybe", Allow},
+	}
+	for _, tc := range cases {
+		if got := Stricter(tc.a, tc.b); got != tc.want {
+			t.Errorf("Stricter(%q, %q) = %q, want %q", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestHTTPProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/score" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		var request Request
+		json.NewDecoder(r.Body).Decode(&request)
+
+		switch {
+		case request.Amount > 1000:
+			json.NewEncoder(w).Encode(Assessment{ID: "a-1", Score: 0.9, Decision: Review, Reasons: []string{"large amount"}})
+		case request.Amount > 100:
+			json.NewEncoder(w).Encode(Assessment{Score: 1.5})
+		default:
+			json.NewEncoder(w).Encode(Assessment{Score: 0.1, Decision: "maybe"})
+		}
+	}))
+	defer server.Close()
+
+	provider := NewHTTPProvider(server.URL+"/", "key")
+	assessment, err := provider.Assess(context.Background(), Request{Amount: 5000})
+	if err != nil {
+		t.Fatalf("Expected the assessment to succeed, got error: %v", err)
+	}
+	if assessment.ID != "a-1" || assessment.Score != 0.9 || assessment.Decision != Review || len(assessment.Reasons) != 1 {
+		t.Errorf("Unexpected assessment: %+v", assessment)
+	}
+
+	if _, err := provider.Assess(context.Background(), Request{Amount: 500}); err == nil {
+		t.Error("Expected scores outside [0, 1] to be rejected")
+	}
+	if _, err := provider.Assess(context.Background(), Request{Amount: 50}); err == nil {
+		t.Error("Expected unknown decisions to be rejected")
+	}
+	if _, err := NewHTTPProvider(server.URL, "wrong").Assess(context.Background(), Request{}); err == nil {
+		t.Error("Expected error responses to fail the assessment")
+	}
+}
@@ -0,0 +1,67 @@
+package fraud
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HTTPProvider is the reference Provider for fraud services exposing a
+// JSON scoring API: the Request is POSTed to BaseURL + "/score" and the
+// service answers with an Assessment. Services with their own formats are
+// adapted by implementing Provider.
+type HTTPProvider struct {
+	BaseURL string
+	APIKey  string       // sent as a bearer token when set
+	Client  *http.Client // defaults to http.DefaultClient
+}
+
+func NewHTTPProvider(baseURL, apiKey string) *HTTPProvider {
+	return &HTTPProvider{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: apiKey}
+}
+
+func (s *HTTPProvider) Assess(ctx context.Context, request Request) (*Assessment, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, s.BaseURL+"/score", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fraud service answered %s", response.Status)
+	}
+
+	var assessment Assessment
+	if err := json.NewDecoder(response.Body).Decode(&assessment); err != nil {
+		return nil, fmt.Errorf("invalid fraud response: %v", err)
+	}
+	if assessment.Score < 0 || assessment.Score > 1 {
+		return nil, fmt.Errorf("fraud response score %v is outside [0, 1]", assessment.Score)
+	}
+	if assessment.Decision != "" && !assessment.Decision.Valid() {
+		return nil, fmt.Errorf("fraud response has unknown decision '%s'", assessment.Decision)
+	}
+	return &assessment, nil
+}
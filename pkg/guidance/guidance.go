@@ -0,0 +1,141 @@
+// Package guidance maps normalized decline reasons to the message and action
+// hint a checkout shows the customer, per locale. Built-in messages ship for
+// English, Spanish, French and German; merchants replace or add entries so
+// every checkout displays the same reviewed wording. Fraud related reasons
+// are worded as plain declines, customers must not learn that a payment was
+// flagged.
+package guidance
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"pgas/pkg/providers"
+)
+
+// DefaultLocale is used when neither the requested locale nor its language
+// has a message
+const DefaultLocale = "en"
+
+// action hints telling the checkout what to offer the customer
+const (
+	ActionRetry          = "retry"            // try the same card again right away
+	ActionRetryLater     = "retry_later"      // try the same card again in a few minutes
+	ActionUseAnotherCard = "use_another_card" // pay with a different card or method
+	ActionUpdateCard     = "update_card"      // correct the card details
+	ActionAuthenticate   = "authenticate"     // complete the bank's verification
+	ActionContactBank    = "contact_bank"     // the cardholder must call their bank
+)
+
+// Entry is the customer facing text of a reason in one locale
+type Entry struct {
+	Message string `json:"message"`
+	Action  string `json:"action"`
+}
+
+// Guidance is what a checkout shows for a decline
+type Guidance struct {
+	Reason  string `json:"reason"`
+	Locale  string `json:"locale"` // locale of the message, a fallback when the requested one has none
+	Message string `json:"message"`
+	Action  string `json:"action"`
+}
+
+//go:embed messages/*.json
+var messageFiles embed.FS
+
+// Catalog holds the entries per locale and reason. It is safe for
+// concurrent use.
+type Catalog struct {
+	mu      sync.RWMutex
+	entries map[string]map[string]Entry // locale -> reason -> entry
+}
+
+// Default returns a catalog of the built-in messages
+func Default() *Catalog {
+	catalog := &Catalog{entries: make(map[string]map[string]Entry)}
+
+	files, _ := messageFiles.ReadDir("messages")
+	for _, file := range files {
+		data, err := messageFiles.ReadFile(path.Join("messages", file.Name()))
+		if err != nil {
+			panic("guidance: reading embedded messages: " + err.Error())
+		}
+
+		var entries map[string]Entry
+		if err := json.Unmarshal(data, &entries); err != nil {
+			panic("guidance: invalid embedded messages in " + file.Name() + ": " + err.Error())
+		}
+		catalog.entries[strings.TrimSuffix(file.Name(), ".json")] = entries
+	}
+	return catalog
+}
+
+// Parse reads merchant entries keyed by locale and reason, e.g.
+// {"en": {"insufficient_funds": {"message": "...", "action": "use_another_card"}}},
+// on top of the built-in messages
+func Parse(data []byte) (*Catalog, error) {
+	var overrides map[string]map[string]Entry
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("guidance: %v", err)
+	}
+
+	catalog := Default()
+	for locale, entries := range overrides {
+		for reason, entry := range entries {
+			if entry.Message == "" {
+				return nil, fmt.Errorf("guidance: %s message of %s is empty", locale, reason)
+			}
+			catalog.Set(locale, reason, entry)
+		}
+	}
+	return catalog, nil
+}
+
+// Set adds or replaces the entry of a reason in a locale
+func (c *Catalog) Set(locale, reason string, entry Entry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	locale = normalizeLocale(locale)
+	if c.entries[locale] == nil {
+		c.entries[locale] = make(map[string]Entry)
+	}
+	c.entries[locale][reason] = entry
+}
+
+// Lookup returns the guidance for a reason in the closest locale: the
+// locale itself, e.g. fr-ca, then its language, fr, then DefaultLocale.
+// Reasons without an entry in any of them get the entry of
+// providers.ReasonUnknown.
+func (c *Catalog) Lookup(reason, locale string) Guidance {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, key := range []string{reason, providers.ReasonUnknown} {
+		for _, candidate := range fallbacks(normalizeLocale(locale)) {
+			if entry, ok := c.entries[candidate][key]; ok {
+				return Guidance{Reason: reason, Locale: candidate, Message: entry.Message, Action: entry.Action}
+			}
+		}
+	}
+	return Guidance{Reason: reason, Locale: DefaultLocale}
+}
+
+// fallbacks lists the locales tried for a locale, closest first
+func fallbacks(locale string) []string {
+	candidates := []string{locale}
+	if language, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, language)
+	}
+	return append(candidates, DefaultLocale)
+}
+
+// normalizeLocale turns en_US and EN-us into en-us
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
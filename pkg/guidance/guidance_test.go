@@ -0,0 +1,93 @@
+package guidance
+
+import (
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+var reasons = []string{
+	providers.ReasonCardDeclined, providers.ReasonDoNotHonor, providers.ReasonInsufficientFunds,
+	providers.ReasonExpiredCard, providers.ReasonInvalidCard, providers.ReasonSuspectedFraud,
+	providers.ReasonIssuerUnavailable, providers.ReasonProcessingError,
+	providers.ReasonAuthenticationRequired, providers.ReasonUnknown,
+}
+
+func TestDefault_CoversEveryReason(t *testing.T) {
+	catalog := Default()
+
+	for _, locale := range []string{"en", "es", "fr", "de"} {
+		for _, reason := range reasons {
+			if _, ok := catalog.entries[locale][reason]; !ok {
+				t.Errorf("No %s message for %s", locale, reason)
+			}
+		}
+	}
+}
+
+func TestDefault_FraudLooksLikeADecline(t *testing.T) {
+	catalog := Default()
+
+	for _, locale := range []string{"en", "es", "fr", "de"} {
+		fraud := catalog.Lookup(providers.ReasonSuspectedFraud, locale)
+		declined := catalog.Lookup(providers.ReasonCardDeclined, locale)
+		if fraud.Message != declined.Message || fraud.Action != declined.Action {
+			t.Errorf("Expected suspected fraud to read as a plain decline in %s, got %+v", locale, fraud)
+		}
+	}
+}
+
+func TestLookup_LocaleFallback(t *testing.T) {
+	catalog := Default()
+
+	tests := []struct {
+		locale, expected string
+	}{
+		{"fr", "fr"},
+		{"fr_CA", "fr"},
+		{"DE-at", "de"},
+		{"ja-JP", "en"},
+		{"", "en"},
+	}
+	for _, test := range tests {
+		guidance := catalog.Lookup(providers.ReasonInsufficientFunds, test.locale)
+		if guidance.Locale != test.expected || guidance.Message == "" {
+			t.Errorf("Expected %s guidance for %q, got %+v", test.expected, test.locale, guidance)
+		}
+	}
+}
+
+func TestLookup_UnknownReason(t *testing.T) {
+	guidance := Default().Lookup("velocity_exceeded", "es")
+
+	if guidance.Reason != "velocity_exceeded" || guidance.Locale != "es" || guidance.Action != ActionUseAnotherCard {
+		t.Errorf("Expected the Spanish unknown entry, got %+v", guidance)
+	}
+}
+
+func TestParse_MerchantWording(t *testing.T) {
+	catalog, err := Parse([]byte(`{
+		"en": {"insufficient_funds": {"message": "Not enough funds on this card.", "action": "contact_bank"}},
+		"fr-CA": {"expired_card": {"message": "Carte expirée.", "action": "update_card"}}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	if guidance := catalog.Lookup(providers.ReasonInsufficientFunds, "en-GB"); guidance.Message != "Not enough funds on this card." || guidance.Action != ActionContactBank {
+		t.Errorf("Expected the merchant wording, got %+v", guidance)
+	}
+	if guidance := catalog.Lookup(providers.ReasonExpiredCard, "fr_ca"); guidance.Locale != "fr-ca" || guidance.Message != "Carte expirée." {
+		t.Errorf("Expected the regional wording, got %+v", guidance)
+	}
+	if guidance := catalog.Lookup(providers.ReasonExpiredCard, "fr"); guidance.Locale != "fr" || guidance.Message == "Carte expirée." {
+		t.Errorf("Expected the built-in French wording, got %+v", guidance)
+	}
+
+	if _, err := Parse([]byte(`{"en": {"expired_card": {"action": "update_card"}}}`)); err == nil {
+		t.Error("Expected error for an empty message")
+	}
+	if _, err := Parse([]byte(`[]`)); err == nil {
+		t.Error("Expected error for malformed entries")
+	}
+}
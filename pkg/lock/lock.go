@@ -0,0 +1,109 @@
+// Package lock coordinates jobs across pgas instances. A Locker hands out
+// named, expiring locks; RunOnce runs a job on whichever instance gets the
+// lock and Lead keeps one instance in charge of a long running loop.
+package lock
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrLocked is returned by Acquire while another holder has the lock
+	ErrLocked = errors.New("lock is held by another instance")
+	// ErrLost is returned when a lock expired or was taken over before it
+	// was refreshed or released
+	ErrLost = errors.New("lock was lost")
+)
+
+// Locker hands out named locks shared by all instances using the same
+// backend
+type Locker interface {
+	// Acquire takes the lock for ttl without waiting, failing with
+	// ErrLocked when it is held elsewhere
+	Acquire(ctx context.Context, name string, ttl time.Duration) (Lock, error)
+}
+
+// Lock is a held lock
+type Lock interface {
+	// Refresh extends the lock to ttl from now, ErrLost when it expired
+	Refresh(ctx context.Context, ttl time.Duration) error
+	// Release gives the lock up, ErrLost when it was no longer held
+	Release(ctx context.Context) error
+}
+
+// MemoryLocker locks within a single process, for tests and single
+// instance deployments
+type MemoryLocker struct {
+	mu   sync.Mutex
+	held map[string]memoryEntry
+	now  func() time.Time
+}
+
+type memoryEntry struct {
+	token   string
+	expires time.Time
+}
+
+func NewMemoryLocker() *MemoryLocker {
+	return &MemoryLocker{held: make(map[string]memoryEntry), now: time.Now}
+}
+
+func (m *MemoryLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (Lock, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if entry, ok := m.held[name]; ok && now.Before(entry.expires) {
+		return nil, ErrLocked
+	}
+
+	token := newToken()
+	m.held[name] = memoryEntry{token: token, expires: now.Add(ttl)}
+	return &memoryLock{locker: m, name: name, token: token}, nil
+}
+
+type memoryLock struct {
+	locker *MemoryLocker
+	name   string
+	token  string
+}
+
+func (l *memoryLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	m := l.locker
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	entry, ok := m.held[l.name]
+	if !ok || entry.token != l.token || !now.Before(entry.expires) {
+		return ErrLost
+	}
+	m.held[l.name] = memoryEntry{token: l.token, expires: now.Add(ttl)}
+	return nil
+}
+
+func (l *memoryLock) Release(ctx context.Context) error {
+	m := l.locker
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.held[l.name]
+	if !ok || entry.token != l.token || !m.now().Before(entry.expires) {
+		return ErrLost
+	}
+	delete(m.held, l.name)
+	return nil
+}
+
+// newToken identifies one holder, so a holder whose lock expired cannot
+// release or refresh the next holder's lock
+func newToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
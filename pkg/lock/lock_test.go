@@ -0,0 +1,120 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryLocker(t *testing.T) {
+	locker := NewMemoryLocker()
+	now := time.Now()
+	locker.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	first, err := locker.Acquire(ctx, "capture", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected lock, got %v", err)
+	}
+	if _, err := locker.Acquire(ctx, "capture", time.Minute); err != ErrLocked {
+		t.Errorf("Expected ErrLocked while held, got %v", err)
+	}
+	if _, err := locker.Acquire(ctx, "reconcile", time.Minute); err != nil {
+		t.Errorf("Expected other names to be independent, got %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	second, err := locker.Acquire(ctx, "capture", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected expired lock to be taken over, got %v", err)
+	}
+	if err := first.Refresh(ctx, time.Minute); err != ErrLost {
+		t.Errorf("Expected the expired holder to have lost the lock, got %v", err)
+	}
+	if err := first.Release(ctx); err != ErrLost {
+		t.Errorf("Expected the expired holder not to release the new lock, got %v", err)
+	}
+	if err := second.Release(ctx); err != nil {
+		t.Errorf("Expected release, got %v", err)
+	}
+}
+
+func TestRunOnce(t *testing.T) {
+	locker := NewMemoryLocker()
+	ctx := context.Background()
+
+	var runs atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			RunOnce(ctx, locker, "subscriptions", time.Second, func(ctx context.Context) error {
+				runs.Add(1)
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			})
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if runs.Load() != 1 {
+		t.Errorf("Expected the job to run once across instances, got %d runs", runs.Load())
+	}
+
+	ran, err := RunOnce(ctx, locker, "subscriptions", time.Second, func(ctx context.Context) error { return nil })
+	if !ran || err != nil {
+		t.Errorf("Expected the lock to be released after the job, got ran %v, %v", ran, err)
+	}
+}
+
+func TestRunOnce_LostLock(t *testing.T) {
+	locker := NewMemoryLocker()
+	ttl := 30 * time.Millisecond
+
+	ran, err := RunOnce(context.Background(), locker, "reconcile", ttl, func(ctx context.Context) error {
+		// another instance takes over as if this one had stalled
+		locker.mu.Lock()
+		locker.held["reconcile"] = memoryEntry{token: "other", expires: time.Now().Add(time.Minute)}
+		locker.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+			return nil
+		}
+	})
+	if !ran || !errors.Is(err, ErrLost) {
+		t.Errorf("Expected the job to be cancelled with ErrLost, got ran %v, %v", ran, err)
+	}
+}
+
+func TestLead(t *testing.T) {
+	locker := NewMemoryLocker()
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var leaders atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Lead(ctx, locker, "forward", 20*time.Millisecond, func(ctx context.Context) {
+				if leaders.Add(1) > 1 {
+					t.Error("Expected a single leader at a time")
+				}
+				<-ctx.Done()
+				leaders.Add(-1)
+			})
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,71 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"time"
+)
+
+// PostgresLocker uses PostgreSQL session advisory locks. Each held lock pins
+// one connection of DB; the lock ends with that session, so it has no ttl
+// and Refresh checks that the session is still alive. DB is opened by the
+// caller with the PostgreSQL driver of their choice.
+type PostgresLocker struct {
+	DB *sql.DB
+}
+
+func NewPostgresLocker(db *sql.DB) *PostgresLocker {
+	return &PostgresLocker{DB: db}
+}
+
+func (p *PostgresLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (Lock, error) {
+	conn, err := p.DB.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key := advisoryKey(name)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !acquired {
+		conn.Close()
+		return nil, ErrLocked
+	}
+	return &postgresLock{conn: conn, key: key}, nil
+}
+
+type postgresLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+func (l *postgresLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	if err := l.conn.PingContext(ctx); err != nil {
+		return ErrLost
+	}
+	return nil
+}
+
+func (l *postgresLock) Release(ctx context.Context) error {
+	defer l.conn.Close()
+
+	var released bool
+	if err := l.conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", l.key).Scan(&released); err != nil {
+		return err
+	}
+	if !released {
+		return ErrLost
+	}
+	return nil
+}
+
+// advisoryKey maps lock names onto the bigint key space of advisory locks
+func advisoryKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("pgas:" + name))
+	return int64(h.Sum64())
+}
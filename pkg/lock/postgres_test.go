@@ -0,0 +1,138 @@
+package lock
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePostgres implements advisory locks per connection like a server would
+type fakePostgres struct {
+	mu   sync.Mutex
+	held map[int64]*fakeSession
+}
+
+type fakeSession struct {
+	server *fakePostgres
+	killed bool
+}
+
+func (f *fakePostgres) Open(name string) (driver.Conn, error) {
+	return &fakeSession{server: f}, nil
+}
+
+func (s *fakeSession) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *fakeSession) Begin() (driver.Tx, error) {
+	return nil, errors.New("not supported")
+}
+
+// Close ends the session and with it its advisory locks
+func (s *fakeSession) Close() error {
+	s.server.mu.Lock()
+	defer s.server.mu.Unlock()
+	for key, holder := range s.server.held {
+		if holder == s {
+			delete(s.server.held, key)
+		}
+	}
+	return nil
+}
+
+func (s *fakeSession) Ping(ctx context.Context) error {
+	if s.killed {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (s *fakeSession) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	f := s.server
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := args[0].Value.(int64)
+	holder, held := f.held[key]
+	switch {
+	case strings.Contains(query, "pg_try_advisory_lock"):
+		if held && holder != s {
+			return &boolRows{value: false}, nil
+		}
+		f.held[key] = s
+		return &boolRows{value: true}, nil
+	case strings.Contains(query, "pg_advisory_unlock"):
+		if !held || holder != s {
+			return &boolRows{value: false}, nil
+		}
+		delete(f.held, key)
+		return &boolRows{value: true}, nil
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+type boolRows struct {
+	value bool
+	done  bool
+}
+
+func (r *boolRows) Columns() []string { return []string{"result"} }
+func (r *boolRows) Close() error      { return nil }
+
+func (r *boolRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.value
+	return nil
+}
+
+var fakePostgresServer = &fakePostgres{held: make(map[int64]*fakeSession)}
+
+func init() {
+	sql.Register("fakepostgres", fakePostgresServer)
+}
+
+func TestPostgresLocker(t *testing.T) {
+	db, err := sql.Open("fakepostgres", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	locker := NewPostgresLocker(db)
+	ctx := context.Background()
+
+	held, err := locker.Acquire(ctx, "reconcile", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected lock, got %v", err)
+	}
+	if _, err := locker.Acquire(ctx, "reconcile", time.Minute); err != ErrLocked {
+		t.Errorf("Expected ErrLocked while held by another session, got %v", err)
+	}
+	if err := held.Refresh(ctx, time.Minute); err != nil {
+		t.Errorf("Expected refresh of a live session, got %v", err)
+	}
+	if err := held.Release(ctx); err != nil {
+		t.Fatalf("Expected release, got %v", err)
+	}
+
+	again, err := locker.Acquire(ctx, "reconcile", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected lock after release, got %v", err)
+	}
+	fakePostgresServer.mu.Lock()
+	fakePostgresServer.held[advisoryKey("reconcile")].killed = true
+	fakePostgresServer.mu.Unlock()
+	if err := again.Refresh(ctx, time.Minute); err != ErrLost {
+		t.Errorf("Expected a dead session to lose the lock, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package lock
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"pgas/pkg/redis"
+)
+
+// scripts only touching the lock while it still carries the holder's token
+const (
+	refreshScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+	releaseScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+)
+
+// RedisLocker keeps locks as expiring Redis keys set with SET NX PX. It is
+// safe against a single Redis primary; locks may be granted twice during a
+// failover to a replica that missed the write.
+type RedisLocker struct {
+	Client *redis.Client
+	Prefix string // prepended to lock names, defaults to "pgas:lock:"
+}
+
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{Client: client, Prefix: "pgas:lock:"}
+}
+
+func (r *RedisLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (Lock, error) {
+	key, token := r.Prefix+name, newToken()
+	reply, err := r.Client.Do(ctx, "SET", key, token, "NX", "PX", milliseconds(ttl))
+	if err != nil {
+		return nil, err
+	}
+	if reply == nil {
+		return nil, ErrLocked
+	}
+	return &redisLock{client: r.Client, key: key, token: token}, nil
+}
+
+type redisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+func (l *redisLock) Refresh(ctx context.Context, ttl time.Duration) error {
+	return l.eval(ctx, refreshScript, milliseconds(ttl))
+}
+
+func (l *redisLock) Release(ctx context.Context) error {
+	return l.eval(ctx, releaseScript)
+}
+
+func (l *redisLock) eval(ctx context.Context, script string, args ...string) error {
+	reply, err := l.client.Do(ctx, append([]string{"EVAL", script, "1", l.key, l.token}, args...)...)
+	if err != nil {
+		return err
+	}
+	if reply != int64(1) {
+		return ErrLost
+	}
+	return nil
+}
+
+func milliseconds(ttl time.Duration) string {
+	return strconv.FormatInt(ttl.Milliseconds(), 10)
+}
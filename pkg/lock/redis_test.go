@@ -0,0 +1,127 @@
+package lock
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pgas/pkg/redis"
+)
+
+// fakeRedis understands the commands RedisLocker sends
+type fakeRedis struct {
+	mu      sync.Mutex
+	values  map[string]string
+	expires map[string]time.Time
+}
+
+func startFakeRedis(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	fake := &fakeRedis{values: make(map[string]string), expires: make(map[string]time.Time)}
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go fake.serve(c)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (f *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	reader := bufio.NewReader(c)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		count, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		args := make([]string, count)
+		for i := range args {
+			reader.ReadString('\n')
+			line, _ := reader.ReadString('\n')
+			args[i] = strings.TrimSuffix(line, "\r\n")
+		}
+		fmt.Fprint(c, f.do(args))
+	}
+}
+
+func (f *fakeRedis) do(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	live := func(key string) (string, bool) {
+		value, ok := f.values[key]
+		if ok && time.Now().After(f.expires[key]) {
+			return "", false
+		}
+		return value, ok
+	}
+
+	switch args[0] {
+	case "SET": // key value NX PX ms
+		if _, ok := live(args[1]); ok {
+			return "$-1\r\n"
+		}
+		ms, _ := strconv.Atoi(args[5])
+		f.values[args[1]], f.expires[args[1]] = args[2], time.Now().Add(time.Duration(ms)*time.Millisecond)
+		return "+OK\r\n"
+	case "EVAL": // script 1 key token [ms]
+		if value, ok := live(args[3]); !ok || value != args[4] {
+			return ":0\r\n"
+		}
+		if args[1] == releaseScript {
+			delete(f.values, args[3])
+		} else {
+			ms, _ := strconv.Atoi(args[5])
+			f.expires[args[3]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return ":1\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestRedisLocker(t *testing.T) {
+	client := redis.NewClient(startFakeRedis(t))
+	defer client.Close()
+	locker := NewRedisLocker(client)
+	ctx := context.Background()
+
+	held, err := locker.Acquire(ctx, "capture", 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Expected lock, got %v", err)
+	}
+	if _, err := locker.Acquire(ctx, "capture", time.Minute); err != ErrLocked {
+		t.Errorf("Expected ErrLocked while held, got %v", err)
+	}
+	if err := held.Refresh(ctx, 50*time.Millisecond); err != nil {
+		t.Errorf("Expected refresh, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	next, err := locker.Acquire(ctx, "capture", time.Minute)
+	if err != nil {
+		t.Fatalf("Expected expired lock to be taken over, got %v", err)
+	}
+	if err := held.Release(ctx); err != ErrLost {
+		t.Errorf("Expected the old holder not to release the new lock, got %v", err)
+	}
+	if err := next.Release(ctx); err != nil {
+		t.Errorf("Expected release, got %v", err)
+	}
+}
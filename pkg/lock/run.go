@@ -0,0 +1,87 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RunOnce runs job if this instance gets the lock, so a job scheduled on
+// every instance runs once per schedule cluster-wide. The lock is refreshed
+// while the job runs; the job's context is cancelled if it is lost. ran is
+// false when another instance holds the lock.
+func RunOnce(ctx context.Context, locker Locker, name string, ttl time.Duration, job func(ctx context.Context) error) (ran bool, err error) {
+	held, err := locker.Acquire(ctx, name, ttl)
+	if errors.Is(err, ErrLocked) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	lost := keepAlive(jobCtx, held, ttl, cancel)
+	err = job(jobCtx)
+	cancel()
+
+	if <-lost {
+		return true, errors.Join(err, ErrLost)
+	}
+	// released with a fresh context, ctx may be what ended the job
+	releaseCtx, cancelRelease := context.WithTimeout(context.Background(), ttl)
+	defer cancelRelease()
+	held.Release(releaseCtx)
+	return true, err
+}
+
+// Lead keeps this instance competing for leadership of name until ctx is
+// done. Whenever it becomes leader, lead runs with a context that ends when
+// leadership is lost; it should return once that context is done. Other
+// instances retry every ttl/2.
+func Lead(ctx context.Context, locker Locker, name string, ttl time.Duration, lead func(ctx context.Context)) {
+	ticker := time.NewTicker(ttl / 2)
+	defer ticker.Stop()
+
+	for {
+		RunOnce(ctx, locker, name, ttl, func(ctx context.Context) error {
+			lead(ctx)
+			return nil
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// keepAlive refreshes the lock every ttl/3 until ctx is done. The returned
+// channel yields whether the lock was lost, after which cancel was called.
+func keepAlive(ctx context.Context, held Lock, ttl time.Duration, cancel context.CancelFunc) <-chan bool {
+	lost := make(chan bool, 1)
+	go func() {
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				lost <- false
+				return
+			case <-ticker.C:
+				refreshCtx, cancelRefresh := context.WithTimeout(ctx, ttl/3)
+				err := held.Refresh(refreshCtx, ttl)
+				cancelRefresh()
+				if errors.Is(err, ErrLost) {
+					cancel()
+					lost <- true
+					return
+				}
+			}
+		}
+	}()
+	return lost
+}
@@ -0,0 +1,208 @@
+package merchant
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+var (
+	ErrMerchantNotFound    = errors.New("merchant not found")
+	ErrSubMerchantNotFound = errors.New("sub-merchant not found")
+	ErrSubMerchantInactive = errors.New("sub-merchant is not active")
+)
+
+type PayoutFrequency string
+
+const (
+	PayoutDaily  PayoutFrequency = "daily"
+	PayoutWeekly PayoutFrequency = "weekly"
+)
+
+// PayoutSchedule controls when settled funds are paid out and how much is
+// withheld as a rolling reserve
+type PayoutSchedule struct {
+	Frequency      PayoutFrequency `json:"frequency"`
+	WeeklyAnchor   time.Weekday    `json:"weekly_anchor,omitempty"` // payout day for weekly schedules
+	DelayDays      int             `json:"delay_days"`              // days funds wait after capture
+	ReservePercent float64         `json:"reserve_percent"`         // share of each payout withheld
+	ReserveDays    int             `json:"reserve_days"`            // days a reserve is held before release
+}
+
+func (s PayoutSchedule) Validate() error {
+	if s.Frequency != PayoutDaily && s.Frequency != PayoutWeekly {
+		return fmt.Errorf("unsupported payout frequency: '%s'", s.Frequency)
+	}
+	if s.DelayDays < 0 || s.ReserveDays < 0 {
+		return errors.New("payout delay and reserve days must not be negative")
+	}
+	if s.ReservePercent < 0 || s.ReservePercent > 100 {
+		return errors.New("reserve percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// Merchant is a platform (payment facilitator) account
+type Merchant struct {
+	ID             string          `json:"id"`
+	Name           string          `json:"name"`
+	PayoutSchedule *PayoutSchedule `json:"payout_schedule,omitempty"`
+	// SupportContact is shown to cardholders of this merchant's charges and
+	// those of sub-merchants without their own
+	SupportContact *providers.SupportContact `json:"support_contact,omitempty"`
+	// PartialApproval is what to do when issuers approve less than the
+	// requested amount of this merchant's charges and those of
+	// sub-merchants without their own, see providers.PartialApprovalAccept
+	PartialApproval string `json:"partial_approval,omitempty"`
+}
+
+// FeeSplit is the platform's cut of each charge made for a sub-merchant
+type FeeSplit struct {
+	Percent float64 `json:"percent"` // e.g. 2.5 for 2.5%
+	Fixed   float64 `json:"fixed"`   // flat fee per charge, in charge currency
+}
+
+// SubMerchant is a seller of record onboarded under a platform merchant
+type SubMerchant struct {
+	ID         string   `json:"id"`
+	PlatformID string   `json:"platform_id"`
+	Name       string   `json:"name"`
+	Descriptor string   `json:"descriptor"` // statement descriptor shown to cardholders, may be a template
+	FeeSplit   FeeSplit `json:"fee_split"`
+	Active     bool     `json:"active"`
+	Country    string   `json:"country,omitempty"` // ISO 3166-1 alpha-2, used for data residency
+
+	PayoutSchedule  *PayoutSchedule           `json:"payout_schedule,omitempty"`
+	SupportContact  *providers.SupportContact `json:"support_contact,omitempty"`
+	PartialApproval string                    `json:"partial_approval,omitempty"` // empty uses the platform's
+}
+
+// Registry holds platform merchants and their sub-merchants
+type Registry struct {
+	mu           sync.RWMutex
+	merchants    map[string]Merchant
+	subMerchants map[string]SubMerchant
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		merchants:    make(map[string]Merchant),
+		subMerchants: make(map[string]SubMerchant),
+	}
+}
+
+func (r *Registry) AddMerchant(m Merchant) error {
+	if m.ID == "" {
+		return errors.New("merchant id is required")
+	}
+	if m.PayoutSchedule != nil {
+		if err := m.PayoutSchedule.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := providers.ValidateSupportContact(m.SupportContact, providers.ContactRules{}); err != nil {
+		return err
+	}
+	if err := providers.ValidatePartialApproval(m.PartialApproval); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.merchants[m.ID] = m
+	return nil
+}
+
+func (r *Registry) Merchant(id string) (Merchant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.merchants[id]
+	if !ok {
+		return Merchant{}, ErrMerchantNotFound
+	}
+	return m, nil
+}
+
+// AddSubMerchant onboards a sub-merchant under an existing platform merchant
+func (r *Registry) AddSubMerchant(s SubMerchant) error {
+	if s.ID == "" {
+		return errors.New("sub-merchant id is required")
+	}
+	if err := validateDescriptor(s.Descriptor); err != nil {
+		return err
+	}
+	if s.FeeSplit.Percent < 0 || s.FeeSplit.Percent > 100 || s.FeeSplit.Fixed < 0 {
+		return errors.New("fee split must be between 0 and 100 percent with a non-negative fixed fee")
+	}
+	if s.PayoutSchedule != nil {
+		if err := s.PayoutSchedule.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := providers.ValidateSupportContact(s.SupportContact, providers.ContactRules{}); err != nil {
+		return err
+	}
+	if err := providers.ValidatePartialApproval(s.PartialApproval); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.merchants[s.PlatformID]; !ok {
+		return ErrMerchantNotFound
+	}
+	r.subMerchants[s.ID] = s
+	return nil
+}
+
+func (r *Registry) SubMerchant(id string) (SubMerchant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, ok := r.subMerchants[id]
+	if !ok {
+		return SubMerchant{}, ErrSubMerchantNotFound
+	}
+	return s, nil
+}
+
+// SubMerchants lists the sub-merchants of a platform merchant
+func (r *Registry) SubMerchants(platformID string) []SubMerchant {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var subMerchants []SubMerchant
+	for _, s := range r.subMerchants {
+		if s.PlatformID == platformID {
+			subMerchants = append(subMerchants, s)
+		}
+	}
+	return subMerchants
+}
+
+// ResolveActive returns the sub-merchant if it exists and can take charges
+func (r *Registry) ResolveActive(id string) (SubMerchant, error) {
+	s, err := r.SubMerchant(id)
+	if err != nil {
+		return SubMerchant{}, err
+	}
+	if !s.Active {
+		return SubMerchant{}, ErrSubMerchantInactive
+	}
+	return s, nil
+}
+
+// validateDescriptor checks plain descriptors fully; templates can only be
+// checked for their syntax until they are rendered with order data
+func validateDescriptor(descriptor string) error {
+	placeholders, err := providers.DescriptorPlaceholders(descriptor)
+	if err != nil || len(placeholders) > 0 {
+		return err
+	}
+	return providers.ValidateDescriptor(descriptor)
+}
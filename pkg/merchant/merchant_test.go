@@ -0,0 +1,63 @@
+package merchant
+
+import (
+	"errors"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestRegistry_SubMerchants(t *testing.T) {
+	registry := NewRegistry()
+
+	if err := registry.AddSubMerchant(SubMerchant{ID: "sub_1", PlatformID: "plat_1"}); !errors.Is(err, ErrMerchantNotFound) {
+		t.Errorf("Expected ErrMerchantNotFound for unknown platform, got: %v", err)
+	}
+
+	registry.AddMerchant(Merchant{ID: "plat_1", Name: "Marketplace"})
+
+	if err := registry.AddSubMerchant(SubMerchant{ID: "sub_1", PlatformID: "plat_1", Descriptor: "SHOP*ACME", Active: true}); err != nil {
+		t.Fatalf("Expected sub-merchant to be added, got error: %v", err)
+	}
+	registry.AddSubMerchant(SubMerchant{ID: "sub_2", PlatformID: "plat_1", Active: false})
+
+	if err := registry.AddSubMerchant(SubMerchant{ID: "sub_3", PlatformID: "plat_1", Descriptor: "THIS DESCRIPTOR IS WAY TOO LONG"}); err == nil {
+		t.Error("Expected error for descriptor longer than 22 characters")
+	}
+	if err := registry.AddSubMerchant(SubMerchant{ID: "sub_5", PlatformID: "plat_1", Descriptor: "SHOP*{order_id"}); err == nil {
+		t.Error("Expected error for malformed descriptor template")
+	}
+
+	if err := registry.AddSubMerchant(SubMerchant{ID: "sub_4", PlatformID: "plat_1", FeeSplit: FeeSplit{Percent: 120}}); err == nil {
+		t.Error("Expected error for fee split above 100 percent")
+	}
+
+	if subMerchants := registry.SubMerchants("plat_1"); len(subMerchants) != 2 {
+		t.Errorf("Expected 2 sub-merchants, got %d", len(subMerchants))
+	}
+
+	if _, err := registry.ResolveActive("sub_1"); err != nil {
+		t.Errorf("Expected active sub-merchant, got error: %v", err)
+	}
+
+	if _, err := registry.ResolveActive("sub_2"); !errors.Is(err, ErrSubMerchantInactive) {
+		t.Errorf("Expected ErrSubMerchantInactive, got: %v", err)
+	}
+
+	if _, err := registry.ResolveActive("missing"); !errors.Is(err, ErrSubMerchantNotFound) {
+		t.Errorf("Expected ErrSubMerchantNotFound, got: %v", err)
+	}
+}
+
+func TestRegistry_SupportContact(t *testing.T) {
+	registry := NewRegistry()
+
+	if err := registry.AddMerchant(Merchant{ID: "plat_1", SupportContact: &providers.SupportContact{URL: "http://acme.io"}}); err == nil {
+		t.Error("Expected invalid support URL to be rejected")
+	}
+
+	registry.AddMerchant(Merchant{ID: "plat_1"})
+	if err := registry.AddSubMerchant(SubMerchant{ID: "sub_1", PlatformID: "plat_1", SupportContact: &providers.SupportContact{Phone: "call us"}}); err == nil {
+		t.Error("Expected invalid support phone to be rejected")
+	}
+}
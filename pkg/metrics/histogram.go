@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"pgas/pkg/stats"
+)
+
+// histogram counts latencies per bucket. Buckets are kept non-cumulative
+// with a last one for values above every bound, so the count always equals
+// the sum of the buckets.
+type histogram struct {
+	bounds  []float64 // seconds
+	buckets []*stats.Counter
+	sum     *stats.Counter // nanoseconds
+}
+
+func newHistogram(bounds []float64) *histogram {
+	h := &histogram{bounds: bounds, buckets: make([]*stats.Counter, len(bounds)+1), sum: stats.NewCounter()}
+	for i := range h.buckets {
+		h.buckets[i] = stats.NewCounter()
+	}
+	return h
+}
+
+func (h *histogram) observe(latency time.Duration) {
+	seconds := latency.Seconds()
+	i := 0
+	for i < len(h.bounds) && seconds > h.bounds[i] {
+		i++
+	}
+	h.buckets[i].Inc()
+	h.sum.Add(int64(latency))
+}
+
+// samples are the cumulative _bucket samples, then _sum and _count
+func (h *histogram) samples(name string, labels ...Label) []Sample {
+	samples := make([]Sample, 0, len(h.buckets)+2)
+	var count int64
+	for i, bucket := range h.buckets {
+		count += bucket.Load()
+		bound := math.Inf(1)
+		if i < len(h.bounds) {
+			bound = h.bounds[i]
+		}
+		samples = append(samples, Sample{
+			Name:   name + "_bucket",
+			Labels: append(append([]Label(nil), labels...), Label{Name: "le", Value: formatFloat(bound)}),
+			Value:  float64(count),
+		})
+	}
+	return append(samples,
+		Sample{Name: name + "_sum", Labels: labels, Value: time.Duration(h.sum.Load()).Seconds()},
+		Sample{Name: name + "_count", Labels: labels, Value: float64(count)},
+	)
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,198 @@
+// Package metrics exports payment throughput, gateway latency, decline
+// rates and circuit breaker states in the Prometheus text exposition
+// format. A Collector receives the processor's measurements through
+// processor.WithMetrics and serves them, e.g. at /metrics; library users
+// add it to their own Registry next to their other gatherers.
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"pgas/pkg/stats"
+)
+
+// metric types of the exposition format
+const (
+	TypeCounter   = "counter"
+	TypeGauge     = "gauge"
+	TypeHistogram = "histogram"
+)
+
+// names of the metrics a Collector exports
+const (
+	MetricPayments     = "pgas_payments_total"
+	MetricDeclines     = "pgas_payment_declines_total"
+	MetricCalls        = "pgas_provider_calls_total"
+	MetricLatency      = "pgas_provider_latency_seconds"
+	MetricBreakerState = "pgas_circuit_breaker_state"
+)
+
+// Family is a named metric with its samples
+type Family struct {
+	Name    string
+	Help    string
+	Type    string
+	Samples []Sample
+}
+
+// Sample is one value of a family. Histograms have _bucket, _sum and
+// _count samples, the name includes the suffix.
+type Sample struct {
+	Name   string
+	Labels []Label
+	Value  float64
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Gatherer produces metric families when scraped
+type Gatherer interface {
+	Gather() []Family
+}
+
+// DefaultBuckets are the latency histogram's upper bounds in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// circuit breaker states, exported as 0, 1 and 2; unknown states are -1
+const breakerStateUnknown = -1
+
+var breakerStates = map[string]float64{
+	"closed":    0,
+	"half_open": 1,
+	"half-open": 1,
+	"open":      2,
+}
+
+// Collector counts payments and gateway calls. It implements
+// processor.Metrics; counting is sharded like the processor's own counters
+// so it never serializes concurrent payments.
+type Collector struct {
+	// Breakers reports the circuit breaker state per provider (closed,
+	// half_open or open), optional
+	Breakers func() map[string]string
+
+	buckets   []float64
+	payments  stats.Counters // by provider, status and currency
+	declines  stats.Counters // by provider and reason
+	calls     stats.Counters // by provider and outcome
+	latencies sync.Map       // provider -> *histogram
+}
+
+// NewCollector collects latencies into buckets, DefaultBuckets when none
+// are given
+func NewCollector(buckets ...float64) *Collector {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Collector{buckets: buckets}
+}
+
+// label values are joined into counter names with a separator that cannot
+// occur in them
+const separator = "\x00"
+
+func (c *Collector) ObservePayment(provider, outcome, currency, reason string) {
+	c.payments.Get(provider + separator + outcome + separator + currency).Inc()
+	if reason != "" {
+		c.declines.Get(provider + separator + reason).Inc()
+	}
+}
+
+func (c *Collector) ObserveCall(provider, outcome string, latency time.Duration) {
+	c.calls.Get(provider + separator + outcome).Inc()
+	h, ok := c.latencies.Load(provider)
+	if !ok {
+		h, _ = c.latencies.LoadOrStore(provider, newHistogram(c.buckets))
+	}
+	h.(*histogram).observe(latency)
+}
+
+// Gather returns the collector's families sorted by name, their samples
+// sorted by labels
+func (c *Collector) Gather() []Family {
+	families := []Family{
+		{Name: MetricPayments, Help: "Payments by provider, outcome status and currency.", Type: TypeCounter,
+			Samples: counterSamples(MetricPayments, c.payments.Snapshot(), "provider", "status", "currency")},
+		{Name: MetricDeclines, Help: "Declined payments by provider and decline reason.", Type: TypeCounter,
+			Samples: counterSamples(MetricDeclines, c.declines.Snapshot(), "provider", "reason")},
+		{Name: MetricCalls, Help: "Gateway calls by provider and outcome, FAILED for gateway errors.", Type: TypeCounter,
+			Samples: counterSamples(MetricCalls, c.calls.Snapshot(), "provider", "outcome")},
+		{Name: MetricLatency, Help: "Latency of gateway calls by provider.", Type: TypeHistogram,
+			Samples: c.latencySamples()},
+	}
+	if c.Breakers != nil {
+		families = append(families, Family{Name: MetricBreakerState, Help: "Circuit breaker state by provider: 0 closed, 1 half-open, 2 open.", Type: TypeGauge,
+			Samples: breakerSamples(c.Breakers())})
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// ServeHTTP writes the collector's metrics in the text exposition format
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serve(w, r, c)
+}
+
+func counterSamples(name string, counts map[string]int64, labels ...string) []Sample {
+	samples := make([]Sample, 0, len(counts))
+	for key, count := range counts {
+		values := strings.Split(key, separator)
+		sample := Sample{Name: name, Value: float64(count)}
+		for i, label := range labels {
+			sample.Labels = append(sample.Labels, Label{Name: label, Value: values[i]})
+		}
+		samples = append(samples, sample)
+	}
+	sortSamples(samples)
+	return samples
+}
+
+func (c *Collector) latencySamples() []Sample {
+	var providers []string
+	c.latencies.Range(func(provider, _ any) bool {
+		providers = append(providers, provider.(string))
+		return true
+	})
+	sort.Strings(providers)
+
+	var samples []Sample
+	for _, provider := range providers {
+		h, _ := c.latencies.Load(provider)
+		samples = append(samples, h.(*histogram).samples(MetricLatency, Label{Name: "provider", Value: provider})...)
+	}
+	return samples
+}
+
+func breakerSamples(breakers map[string]string) []Sample {
+	samples := make([]Sample, 0, len(breakers))
+	for provider, state := range breakers {
+		value, ok := breakerStates[strings.ToLower(state)]
+		if !ok {
+			value = breakerStateUnknown
+		}
+		samples = append(samples, Sample{Name: MetricBreakerState, Labels: []Label{{Name: "provider", Value: provider}}, Value: value})
+	}
+	sortSamples(samples)
+	return samples
+}
+
+func sortSamples(samples []Sample) {
+	sort.Slice(samples, func(i, j int) bool {
+		a, b := samples[i].Labels, samples[j].Labels
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k].Value != b[k].Value {
+				return a[k].Value < b[k].Value
+			}
+		}
+		return len(a) < len(b)
+	})
+}
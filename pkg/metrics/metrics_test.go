@@ -0,0 +1,114 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/visa"
+)
+
+func scrape(t *testing.T, handler http.Handler) string {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("Expected the metrics, got %d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	return recorder.Body.String()
+}
+
+func expectLines(t *testing.T, output string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(output, line+"\n") {
+			t.Errorf("Expected line %q in:\n%s", line, output)
+		}
+	}
+}
+
+func TestCollector_Processor(t *testing.T) {
+	collector := NewCollector()
+	payments := processor.NewPaymentProcessor([]providers.Provider{visa.GetNewVisaPaymentProvider()}, processor.WithMetrics(collector))
+
+	request := providers.PaymentRequest{Mode: "visa", Amount: 10, Currency: "USD", CardNumber: "4111111111111111", ExpiryMonth: "12", ExpiryYear: "2030", CVV: "123"}
+	if _, paymentError := payments.ProcessPayment(context.Background(), request); paymentError != nil {
+		t.Fatalf("Expected the sandbox card to be approved, got %+v", paymentError)
+	}
+	request.CardNumber = "4000000000009995"
+	if _, paymentError := payments.ProcessPayment(context.Background(), request); paymentError == nil {
+		t.Fatal("Expected the sandbox card to be declined")
+	}
+
+	expectLines(t, scrape(t, collector),
+		"# TYPE pgas_payments_total counter",
+		`pgas_payments_total{provider="visa",status="APPROVED",currency="USD"} 1`,
+		`pgas_payments_total{provider="visa",status="DECLINED",currency="USD"} 1`,
+		`pgas_payment_declines_total{provider="visa",reason="insufficient_funds"} 1`,
+		`pgas_provider_calls_total{provider="visa",outcome="APPROVED"} 1`,
+		"# TYPE pgas_provider_latency_seconds histogram",
+		`pgas_provider_latency_seconds_bucket{provider="visa",le="+Inf"} 2`,
+		`pgas_provider_latency_seconds_count{provider="visa"} 2`,
+	)
+}
+
+func TestCollector_Histogram(t *testing.T) {
+	collector := NewCollector(1, 0.1)
+	collector.ObserveCall("visa", providers.StatusApproved, 50*time.Millisecond)
+	collector.ObserveCall("visa", providers.StatusApproved, 500*time.Millisecond)
+	collector.ObserveCall("visa", "FAILED", 3*time.Second)
+
+	expectLines(t, scrape(t, collector),
+		`pgas_provider_latency_seconds_bucket{provider="visa",le="0.1"} 1`,
+		`pgas_provider_latency_seconds_bucket{provider="visa",le="1"} 2`,
+		`pgas_provider_latency_seconds_bucket{provider="visa",le="+Inf"} 3`,
+		`pgas_provider_latency_seconds_sum{provider="visa"} 3.55`,
+		`pgas_provider_latency_seconds_count{provider="visa"} 3`,
+		`pgas_provider_calls_total{provider="visa",outcome="FAILED"} 1`,
+	)
+}
+
+func TestCollector_Breakers(t *testing.T) {
+	collector := NewCollector()
+	collector.Breakers = func() map[string]string {
+		return map[string]string{"visa": "closed", "amex": "open", "mastercard": "half-open"}
+	}
+
+	expectLines(t, scrape(t, collector),
+		"# TYPE pgas_circuit_breaker_state gauge",
+		`pgas_circuit_breaker_state{provider="amex"} 2`,
+		`pgas_circuit_breaker_state{provider="mastercard"} 1`,
+		`pgas_circuit_breaker_state{provider="visa"} 0`,
+	)
+}
+
+type staticGatherer []Family
+
+func (g staticGatherer) Gather() []Family { return g }
+
+func TestRegistry(t *testing.T) {
+	collector := NewCollector()
+	collector.ObservePayment("visa", providers.StatusApproved, "EUR", "")
+
+	registry := NewRegistry()
+	registry.Register(collector)
+	registry.Register(staticGatherer{{Name: "shop_orders_total", Help: "Orders,\nby \\ channel.", Type: TypeCounter,
+		Samples: []Sample{{Name: "shop_orders_total", Labels: []Label{{Name: "channel", Value: `web "beta"`}}, Value: 7}}}})
+
+	expectLines(t, scrape(t, registry),
+		`pgas_payments_total{provider="visa",status="APPROVED",currency="EUR"} 1`,
+		`# HELP shop_orders_total Orders,\nby \\ channel.`,
+		`shop_orders_total{channel="web \"beta\""} 7`,
+	)
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected 405 for POST, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Registry serves the families of several gatherers together, e.g. a
+// Collector next to an application's own metrics
+type Registry struct {
+	mu        sync.Mutex
+	gatherers []Gatherer
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a gatherer, scraped after the ones registered before it
+func (r *Registry) Register(gatherer Gatherer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gatherers = append(r.gatherers, gatherer)
+}
+
+// Gather merges the families of every gatherer by name, sorted by name.
+// Samples of families several gatherers export are concatenated in
+// registration order.
+func (r *Registry) Gather() []Family {
+	r.mu.Lock()
+	gatherers := append([]Gatherer(nil), r.gatherers...)
+	r.mu.Unlock()
+
+	byName := make(map[string]int)
+	var families []Family
+	for _, gatherer := range gatherers {
+		for _, family := range gatherer.Gather() {
+			if i, ok := byName[family.Name]; ok {
+				families[i].Samples = append(families[i].Samples, family.Samples...)
+				continue
+			}
+			byName[family.Name] = len(families)
+			families = append(families, family)
+		}
+	}
+	sort.Slice(families, func(i, j int) bool { return families[i].Name < families[j].Name })
+	return families
+}
+
+// ServeHTTP writes the merged families in the text exposition format
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	serve(w, req, r)
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ContentType of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// Write writes families in the text exposition format. Families without
+// samples are written with their HELP and TYPE lines only.
+func Write(w io.Writer, families []Family) error {
+	buffered := bufio.NewWriter(w)
+	for _, family := range families {
+		buffered.WriteString("# HELP " + family.Name + " " + helpEscaper.Replace(family.Help) + "\n")
+		buffered.WriteString("# TYPE " + family.Name + " " + family.Type + "\n")
+		for _, sample := range family.Samples {
+			buffered.WriteString(sample.Name)
+			if len(sample.Labels) > 0 {
+				buffered.WriteByte('{')
+				for i, label := range sample.Labels {
+					if i > 0 {
+						buffered.WriteByte(',')
+					}
+					buffered.WriteString(label.Name + `="` + labelEscaper.Replace(label.Value) + `"`)
+				}
+				buffered.WriteByte('}')
+			}
+			buffered.WriteString(" " + formatFloat(sample.Value) + "\n")
+		}
+	}
+	return buffered.Flush()
+}
+
+// serve answers scrapes with the gatherer's families
+func serve(w http.ResponseWriter, r *http.Request, gatherer Gatherer) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "use GET", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	if r.Method == http.MethodHead {
+		return
+	}
+	Write(w, gatherer.Gather())
+}
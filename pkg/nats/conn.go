@@ -0,0 +1,327 @@
+// Package nats is a minimal NATS client speaking the text protocol over
+// TCP. It covers what pgas needs from NATS, publishing to JetStream and
+// pulling from durable consumers, without a third-party dependency.
+package nats
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// largest message payload accepted, guards against reading garbage as a
+// length
+const maxPayload = 64 << 20
+
+var ErrClosed = errors.New("nats: connection closed")
+
+// Msg is a message delivered to a subscription
+type Msg struct {
+	Subject string
+	Reply   string
+	// Status is the status code of header-only JetStream control messages,
+	// such as "404" when a pull request found no messages; empty otherwise
+	Status string
+	Data   []byte
+}
+
+// Options configure a connection
+type Options struct {
+	User     string
+	Password string
+	Token    string
+	// Dial opens the connection, defaults to a net.Dialer
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// Conn is a connection to one NATS server. It is safe for concurrent use.
+type Conn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	subs    map[string]chan Msg
+	nextSID int
+	err     error
+	done    chan struct{}
+}
+
+// Connect dials addr and completes the handshake
+func Connect(ctx context.Context, addr string, opts Options) (*Conn, error) {
+	dial := opts.Dial
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 5 * time.Second}).DialContext
+	}
+	netConn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Conn{conn: netConn, reader: bufio.NewReader(netConn), subs: make(map[string]chan Msg), done: make(chan struct{})}
+
+	deadline, _ := ctx.Deadline()
+	netConn.SetDeadline(deadline)
+	if err := c.handshake(opts); err != nil {
+		netConn.Close()
+		return nil, err
+	}
+	netConn.SetDeadline(time.Time{})
+
+	go c.readLoop()
+	return c, nil
+}
+
+func (c *Conn) handshake(opts Options) error {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		return fmt.Errorf("nats: expected INFO, got %q", strings.TrimSpace(line))
+	}
+
+	connect, _ := json.Marshal(map[string]interface{}{
+		"verbose":    false,
+		"pedantic":   false,
+		"headers":    true,
+		"name":       "pgas",
+		"lang":       "go",
+		"version":    "1",
+		"user":       opts.User,
+		"pass":       opts.Password,
+		"auth_token": opts.Token,
+	})
+	if _, err := c.conn.Write([]byte("CONNECT " + string(connect) + "\r\nPING\r\n")); err != nil {
+		return err
+	}
+
+	// the server answers the PING once CONNECT was accepted
+	line, err = c.reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(line, "-ERR") {
+		return fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+	}
+	if strings.TrimSpace(line) != "PONG" {
+		return fmt.Errorf("nats: expected PONG, got %q", strings.TrimSpace(line))
+	}
+	return nil
+}
+
+// Publish sends data to subject; reply names the subject answers go to and
+// may be empty
+func (c *Conn) Publish(subject, reply string, data []byte) error {
+	command := "PUB " + subject
+	if reply != "" {
+		command += " " + reply
+	}
+	return c.write([]byte(command + " " + strconv.Itoa(len(data)) + "\r\n" + string(data) + "\r\n"))
+}
+
+// Subscription receives the messages of one subject
+type Subscription struct {
+	conn *Conn
+	sid  string
+	C    <-chan Msg
+}
+
+// Subscribe delivers the messages of subject to the returned subscription.
+// Messages are dropped while its buffer of 256 is full.
+func (c *Conn) Subscribe(subject string) (*Subscription, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.nextSID++
+	sid := strconv.Itoa(c.nextSID)
+	ch := make(chan Msg, 256)
+	c.subs[sid] = ch
+	c.mu.Unlock()
+
+	if err := c.write([]byte("SUB " + subject + " " + sid + "\r\n")); err != nil {
+		c.removeSub(sid)
+		return nil, err
+	}
+	return &Subscription{conn: c, sid: sid, C: ch}, nil
+}
+
+// Unsubscribe stops the delivery of messages
+func (s *Subscription) Unsubscribe() error {
+	s.conn.removeSub(s.sid)
+	return s.conn.write([]byte("UNSUB " + s.sid + "\r\n"))
+}
+
+// Request publishes data with a fresh reply subject and waits for the first
+// answer
+func (c *Conn) Request(ctx context.Context, subject string, data []byte) (Msg, error) {
+	inbox := NewInbox()
+	sub, err := c.Subscribe(inbox)
+	if err != nil {
+		return Msg{}, err
+	}
+	defer sub.Unsubscribe()
+
+	if err := c.Publish(subject, inbox, data); err != nil {
+		return Msg{}, err
+	}
+
+	select {
+	case msg, ok := <-sub.C:
+		if !ok {
+			return Msg{}, c.closedErr()
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return Msg{}, ctx.Err()
+	}
+}
+
+// Close closes the connection and every subscription
+func (c *Conn) Close() error {
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
+
+// NewInbox returns a unique subject for replies
+func NewInbox() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "_INBOX." + hex.EncodeToString(b)
+}
+
+func (c *Conn) write(b []byte) error {
+	if err := c.closedErr(); err != nil {
+		return err
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.conn.Write(b)
+	return err
+}
+
+func (c *Conn) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func (c *Conn) removeSub(sid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ch, ok := c.subs[sid]; ok {
+		delete(c.subs, sid)
+		close(ch)
+	}
+}
+
+func (c *Conn) readLoop() {
+	defer close(c.done)
+
+	err := c.read()
+	if err == nil || errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+		err = ErrClosed
+	}
+
+	c.mu.Lock()
+	c.err = err
+	for sid, ch := range c.subs {
+		delete(c.subs, sid)
+		close(ch)
+	}
+	c.mu.Unlock()
+	c.conn.Close()
+}
+
+func (c *Conn) read() error {
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		op, args, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(op) {
+		case "MSG", "HMSG":
+			msg, sid, err := c.readMsg(strings.ToUpper(op) == "HMSG", strings.Fields(args))
+			if err != nil {
+				return err
+			}
+			c.mu.Lock()
+			if ch, ok := c.subs[sid]; ok {
+				select {
+				case ch <- msg:
+				default:
+				}
+			}
+			c.mu.Unlock()
+		case "PING":
+			if err := c.write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case "-ERR":
+			return fmt.Errorf("nats: %s", strings.Trim(args, " '"))
+		case "PONG", "+OK", "INFO":
+		default:
+			return fmt.Errorf("nats: unknown operation %q", op)
+		}
+	}
+}
+
+// readMsg reads the payload of "MSG <subject> <sid> [reply] <size>" or
+// "HMSG <subject> <sid> [reply] <header size> <total size>"
+func (c *Conn) readMsg(headers bool, fields []string) (Msg, string, error) {
+	sizes := 1
+	if headers {
+		sizes = 2
+	}
+	if len(fields) != 2+sizes && len(fields) != 3+sizes {
+		return Msg{}, "", fmt.Errorf("nats: malformed message line %q", strings.Join(fields, " "))
+	}
+
+	msg := Msg{Subject: fields[0]}
+	sid := fields[1]
+	if len(fields) == 3+sizes {
+		msg.Reply = fields[2]
+	}
+
+	total, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || total < 0 || total > maxPayload {
+		return Msg{}, "", fmt.Errorf("nats: malformed message size %q", fields[len(fields)-1])
+	}
+	headerSize := 0
+	if headers {
+		headerSize, err = strconv.Atoi(fields[len(fields)-2])
+		if err != nil || headerSize < 0 || headerSize > total {
+			return Msg{}, "", fmt.Errorf("nats: malformed header size %q", fields[len(fields)-2])
+		}
+	}
+
+	payload := make([]byte, total+2)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return Msg{}, "", err
+	}
+	if headers {
+		// "NATS/1.0 404 No Messages\r\n..."
+		statusLine, _, _ := strings.Cut(string(payload[:headerSize]), "\r\n")
+		if parts := strings.Fields(statusLine); len(parts) > 1 {
+			msg.Status = parts[1]
+		}
+	}
+	msg.Data = payload[headerSize:total]
+	return msg, sid, nil
+}
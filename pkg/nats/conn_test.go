@@ -0,0 +1,208 @@
+package nats
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer speaks enough of the protocol for the client: it answers PINGs
+// and passes every PUB to publish, which returns the messages to deliver
+type fakeServer struct {
+	publish func(s *fakeServer, subject, reply string, data []byte)
+
+	mu   sync.Mutex
+	subs map[string]string // subject -> sid
+	conn net.Conn
+}
+
+func (s *fakeServer) deliver(subject, reply, status string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sid, ok := s.subs[subject]
+	if !ok {
+		return
+	}
+	suffix := ""
+	if reply != "" {
+		suffix = " " + reply
+	}
+	if status == "" {
+		s.conn.Write([]byte("MSG " + subject + " " + sid + suffix + " " + strconv.Itoa(len(data)) + "\r\n" + string(data) + "\r\n"))
+		return
+	}
+	header := "NATS/1.0 " + status + "\r\n\r\n"
+	s.conn.Write([]byte("HMSG " + subject + " " + sid + suffix + " " + strconv.Itoa(len(header)) + " " + strconv.Itoa(len(header)) + "\r\n" + header + "\r\n"))
+}
+
+func startServer(t *testing.T, publish func(s *fakeServer, subject, reply string, data []byte)) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s := &fakeServer{publish: publish, subs: make(map[string]string), conn: c}
+			go s.serve()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func (s *fakeServer) serve() {
+	defer s.conn.Close()
+	s.conn.Write([]byte(`INFO {"server_id":"fake","headers":true}` + "\r\n"))
+	reader := bufio.NewReader(s.conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "PING":
+			s.mu.Lock()
+			s.conn.Write([]byte("PONG\r\n"))
+			s.mu.Unlock()
+		case "SUB":
+			s.mu.Lock()
+			s.subs[fields[1]] = fields[2]
+			s.mu.Unlock()
+		case "UNSUB":
+			s.mu.Lock()
+			for subject, sid := range s.subs {
+				if sid == fields[1] {
+					delete(s.subs, subject)
+				}
+			}
+			s.mu.Unlock()
+		case "PUB":
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			data := make([]byte, size+2)
+			if _, err := io.ReadFull(reader, data); err != nil {
+				return
+			}
+			reply := ""
+			if len(fields) == 4 {
+				reply = fields[2]
+			}
+			go s.publish(s, fields[1], reply, data[:size])
+		}
+	}
+}
+
+func TestConn_RequestAndPublishAck(t *testing.T) {
+	addr := startServer(t, func(s *fakeServer, subject, reply string, data []byte) {
+		switch subject {
+		case "echo":
+			s.deliver(reply, "", "", data)
+		case "events.stored":
+			s.deliver(reply, "", "", []byte(`{"stream":"EVENTS","seq":7}`))
+		case "events.full":
+			s.deliver(reply, "", "", []byte(`{"error":{"code":503,"description":"maximum messages exceeded"}}`))
+		default:
+			s.deliver(reply, "", "503", nil)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Connect(ctx, addr, Options{})
+	if err != nil {
+		t.Fatalf("Expected to connect, got: %v", err)
+	}
+	defer conn.Close()
+
+	if msg, err := conn.Request(ctx, "echo", []byte("hello")); err != nil || string(msg.Data) != "hello" {
+		t.Errorf("Expected the echoed payload, got %q, %v", msg.Data, err)
+	}
+	if ack, err := conn.PublishAck(ctx, "events.stored", []byte("{}")); err != nil || ack.Stream != "EVENTS" || ack.Sequence != 7 {
+		t.Errorf("Expected a publish ack, got %+v, %v", ack, err)
+	}
+	if _, err := conn.PublishAck(ctx, "events.full", []byte("{}")); err == nil || !strings.Contains(err.Error(), "maximum messages") {
+		t.Errorf("Expected the stream error, got: %v", err)
+	}
+	if _, err := conn.PublishAck(ctx, "nowhere", []byte("{}")); !errors.Is(err, ErrNoStream) {
+		t.Errorf("Expected ErrNoStream without responders, got: %v", err)
+	}
+}
+
+func TestConn_FetchAndAck(t *testing.T) {
+	var mu sync.Mutex
+	var acks []string
+	addr := startServer(t, func(s *fakeServer, subject, reply string, data []byte) {
+		switch {
+		case strings.HasPrefix(subject, "$JS.API.CONSUMER.MSG.NEXT.EVENTS.billing"):
+			var request struct {
+				Batch int `json:"batch"`
+			}
+			json.Unmarshal(data, &request)
+			if request.Batch != 10 {
+				s.deliver(reply, "", "400", nil)
+				return
+			}
+			s.deliver(reply, "$JS.ACK.EVENTS.billing.1", "", []byte("first"))
+			s.deliver(reply, "$JS.ACK.EVENTS.billing.2", "", []byte("second"))
+			s.deliver(reply, "", "404", nil)
+		case strings.HasPrefix(subject, "$JS.ACK."):
+			mu.Lock()
+			acks = append(acks, subject+" "+string(data))
+			mu.Unlock()
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Connect(ctx, addr, Options{})
+	if err != nil {
+		t.Fatalf("Expected to connect, got: %v", err)
+	}
+	defer conn.Close()
+
+	msgs, err := conn.Fetch(ctx, "EVENTS", "billing", 10, time.Second)
+	if err != nil || len(msgs) != 2 || string(msgs[1].Data) != "second" {
+		t.Fatalf("Expected 2 messages before the 404 status, got %d, %v", len(msgs), err)
+	}
+	conn.Ack(msgs[0])
+	conn.Nak(msgs[1])
+
+	if _, err := conn.Fetch(ctx, "EVENTS", "billing", 5, time.Second); err == nil {
+		t.Error("Expected an error for a rejected pull request")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(acks)
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	sort.Strings(acks) // the fake server handles publishes concurrently
+	if len(acks) != 2 || acks[0] != "$JS.ACK.EVENTS.billing.1 +ACK" || acks[1] != "$JS.ACK.EVENTS.billing.2 -NAK" {
+		t.Errorf("Expected an ACK and a NAK, got %q", acks)
+	}
+}
@@ -0,0 +1,118 @@
+package nats
+
+import (
+	"context"
+	"time"
+
+	"pgas/pkg/codec"
+	"pgas/pkg/events"
+)
+
+// EventPublisher publishes every processor event to "<Prefix>.<event
+// type>", e.g. pgas.events.payment.deferred, and waits until a JetStream
+// stream stored it. A failed publish should be retried, so consumers can
+// see an event more than once.
+type EventPublisher struct {
+	Conn   *Conn
+	Prefix string
+	// Codec encodes the events, defaults to codec.JSON. Consumers must be
+	// set to the same codec.
+	Codec codec.Codec
+}
+
+func NewEventPublisher(conn *Conn, prefix string) *EventPublisher {
+	return &EventPublisher{Conn: conn, Prefix: prefix}
+}
+
+func (e *EventPublisher) Publish(ctx context.Context, event events.Event) error {
+	encoder := e.Codec
+	if encoder == nil {
+		encoder = codec.JSON
+	}
+	body, err := encoder.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = e.Conn.PublishAck(ctx, e.Prefix+"."+event.Type, body)
+	return err
+}
+
+// EventConsumer pulls processor events from a durable JetStream consumer.
+// Instances sharing the durable consumer split its events like a consumer
+// group; an event is acknowledged once the handler succeeded and
+// redelivered otherwise, so events arrive at least once.
+type EventConsumer struct {
+	Conn    *Conn
+	Stream  string
+	Durable string // create it with Conn.AddConsumer
+	// Batch is the number of events pulled per poll, defaults to 10
+	Batch int
+	// Expires is how long a poll waits for events, defaults to 5s
+	Expires time.Duration
+	// Codec decodes the events, defaults to codec.JSON; it must match the
+	// publisher's
+	Codec codec.Codec
+}
+
+func NewEventConsumer(conn *Conn, stream, durable string) *EventConsumer {
+	return &EventConsumer{Conn: conn, Stream: stream, Durable: durable}
+}
+
+// Poll hands one batch of events to handle and returns how many were
+// acknowledged, with the first handler error
+func (e *EventConsumer) Poll(ctx context.Context, handle func(ctx context.Context, event events.Event) error) (int, error) {
+	batch := e.Batch
+	if batch <= 0 {
+		batch = 10
+	}
+	expires := e.Expires
+	if expires <= 0 {
+		expires = 5 * time.Second
+	}
+
+	msgs, err := e.Conn.Fetch(ctx, e.Stream, e.Durable, batch, expires)
+	if err != nil && len(msgs) == 0 {
+		return 0, err
+	}
+
+	decoder := e.Codec
+	if decoder == nil {
+		decoder = codec.JSON
+	}
+
+	acked := 0
+	var handleErr error
+	for _, msg := range msgs {
+		var event events.Event
+		if err := decoder.Unmarshal(msg.Data, &event); err != nil {
+			// unreadable messages would be redelivered forever, drop them
+			e.Conn.Ack(msg)
+			continue
+		}
+		if err := handle(ctx, event); err != nil {
+			e.Conn.Nak(msg)
+			if handleErr == nil {
+				handleErr = err
+			}
+			continue
+		}
+		if err := e.Conn.Ack(msg); err != nil {
+			return acked, err
+		}
+		acked++
+	}
+	return acked, handleErr
+}
+
+// Consume polls until ctx is done, pausing for a second after errors
+func (e *EventConsumer) Consume(ctx context.Context, handle func(ctx context.Context, event events.Event) error) error {
+	for ctx.Err() == nil {
+		if _, err := e.Poll(ctx, handle); err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,175 @@
+package nats
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"pgas/pkg/codec"
+	"pgas/pkg/events"
+)
+
+// fakeStream is a JetStream stream with one durable consumer that
+// redelivers messages it gets a NAK for
+type fakeStream struct {
+	mu       sync.Mutex
+	stored   map[string][]byte // ack subject -> message
+	subjects []string
+	queue    []string // ack subjects waiting for delivery
+	acked    []string
+}
+
+func (f *fakeStream) publish(s *fakeServer, subject, reply string, data []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case strings.HasPrefix(subject, "pgas.events."):
+		ackSubject := "$JS.ACK.EVENTS.billing." + string(rune('0'+len(f.stored)+1))
+		f.stored[ackSubject] = data
+		f.subjects = append(f.subjects, subject)
+		f.queue = append(f.queue, ackSubject)
+		s.deliver(reply, "", "", []byte(`{"stream":"EVENTS","seq":1}`))
+	case strings.HasPrefix(subject, "$JS.API.CONSUMER.MSG.NEXT.EVENTS.billing"):
+		for _, ackSubject := range f.queue {
+			s.deliver(reply, ackSubject, "", f.stored[ackSubject])
+		}
+		f.queue = nil
+		s.deliver(reply, "", "404", nil)
+	case strings.HasPrefix(subject, "$JS.ACK."):
+		if string(data) == "-NAK" {
+			f.queue = append(f.queue, subject)
+			return
+		}
+		f.acked = append(f.acked, subject)
+	}
+}
+
+// waitFor polls cond until it holds or a second passed
+func (f *fakeStream) waitFor(cond func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		ok := cond()
+		f.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEventConsumer_AtLeastOnce(t *testing.T) {
+	stream := &fakeStream{stored: make(map[string][]byte)}
+	addr := startServer(t, stream.publish)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Connect(ctx, addr, Options{})
+	if err != nil {
+		t.Fatalf("Expected to connect, got: %v", err)
+	}
+	defer conn.Close()
+
+	publisher := NewEventPublisher(conn, "pgas.events")
+	for _, event := range []events.Event{{Type: events.TypePaymentDeferred, TransactionID: "tx_1"}, {Type: events.TypeAlertFiring}} {
+		if err := publisher.Publish(ctx, event); err != nil {
+			t.Fatalf("Expected the stream to store the event, got: %v", err)
+		}
+	}
+	stream.mu.Lock()
+	if stream.subjects[0] != "pgas.events.payment.deferred" || stream.subjects[1] != "pgas.events.alert.firing" {
+		t.Errorf("Expected subjects per event type, got %v", stream.subjects)
+	}
+	stream.mu.Unlock()
+
+	consumer := NewEventConsumer(conn, "EVENTS", "billing")
+	failing := errors.New("database down")
+	acked, err := consumer.Poll(ctx, func(ctx context.Context, event events.Event) error {
+		if event.Type == events.TypeAlertFiring {
+			return failing
+		}
+		if event.TransactionID != "tx_1" {
+			t.Errorf("Expected the published event, got %+v", event)
+		}
+		return nil
+	})
+	if acked != 1 || !errors.Is(err, failing) {
+		t.Fatalf("Expected one ack and the handler error, got %d, %v", acked, err)
+	}
+
+	stream.waitFor(func() bool { return len(stream.queue) == 1 })
+	var redelivered []string
+	acked, err = consumer.Poll(ctx, func(ctx context.Context, event events.Event) error {
+		redelivered = append(redelivered, event.Type)
+		return nil
+	})
+	if err != nil || acked != 1 || len(redelivered) != 1 || redelivered[0] != events.TypeAlertFiring {
+		t.Errorf("Expected the failed event to be redelivered, got %v, %v", redelivered, err)
+	}
+
+	stream.waitFor(func() bool { return len(stream.acked) == 2 })
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	if len(stream.acked) != 2 {
+		t.Errorf("Expected both events acknowledged, got %v", stream.acked)
+	}
+}
+
+func TestEventPublisher_NoStream(t *testing.T) {
+	addr := startServer(t, func(s *fakeServer, subject, reply string, data []byte) {
+		s.deliver(reply, "", "503", nil)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Connect(ctx, addr, Options{})
+	if err != nil {
+		t.Fatalf("Expected to connect, got: %v", err)
+	}
+	defer conn.Close()
+
+	err = NewEventPublisher(conn, "pgas.events").Publish(ctx, events.Event{Type: events.TypePaymentExpired})
+	if !errors.Is(err, ErrNoStream) {
+		t.Errorf("Expected ErrNoStream, got: %v", err)
+	}
+}
+
+func TestEventConsumer_Codec(t *testing.T) {
+	stream := &fakeStream{stored: make(map[string][]byte)}
+	addr := startServer(t, stream.publish)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := Connect(ctx, addr, Options{})
+	if err != nil {
+		t.Fatalf("Expected to connect, got: %v", err)
+	}
+	defer conn.Close()
+
+	publisher := NewEventPublisher(conn, "pgas.events")
+	publisher.Codec = codec.Msgpack
+	if err := publisher.Publish(ctx, events.Event{Type: events.TypePaymentDeferred, TransactionID: "tx_1"}); err != nil {
+		t.Fatalf("Expected the stream to store the event, got: %v", err)
+	}
+	stream.mu.Lock()
+	stored := stream.stored["$JS.ACK.EVENTS.billing.1"]
+	stream.mu.Unlock()
+	if len(stored) == 0 || stored[0] == '{' {
+		t.Fatalf("Expected a MessagePack body, got %q", stored)
+	}
+
+	consumer := NewEventConsumer(conn, "EVENTS", "billing")
+	consumer.Codec = codec.Msgpack
+	var received events.Event
+	acked, err := consumer.Poll(ctx, func(ctx context.Context, event events.Event) error {
+		received = event
+		return nil
+	})
+	if err != nil || acked != 1 || received.TransactionID != "tx_1" || received.Type != events.TypePaymentDeferred {
+		t.Errorf("Expected the event decoded with the codec, got %+v, %d, %v", received, acked, err)
+	}
+}
@@ -0,0 +1,139 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNoStream is returned when no JetStream stream captures a subject
+var ErrNoStream = errors.New("nats: no stream captures the subject")
+
+// PubAck is JetStream's confirmation that a message was stored
+type PubAck struct {
+	Stream    string `json:"stream"`
+	Sequence  uint64 `json:"seq"`
+	Duplicate bool   `json:"duplicate,omitempty"`
+}
+
+type apiError struct {
+	Error *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error,omitempty"`
+}
+
+func (e apiError) err() error {
+	if e.Error == nil {
+		return nil
+	}
+	return fmt.Errorf("nats: jetstream %d: %s", e.Error.Code, e.Error.Description)
+}
+
+// PublishAck publishes data to a subject captured by a JetStream stream
+// and waits until the stream stored it
+func (c *Conn) PublishAck(ctx context.Context, subject string, data []byte) (PubAck, error) {
+	msg, err := c.Request(ctx, subject, data)
+	if err != nil {
+		return PubAck{}, err
+	}
+	if msg.Status == "503" {
+		return PubAck{}, ErrNoStream
+	}
+
+	var reply struct {
+		PubAck
+		apiError
+	}
+	if err := json.Unmarshal(msg.Data, &reply); err != nil {
+		return PubAck{}, fmt.Errorf("nats: malformed publish ack: %w", err)
+	}
+	if err := reply.apiError.err(); err != nil {
+		return PubAck{}, err
+	}
+	return reply.PubAck, nil
+}
+
+// AddConsumer creates a durable pull consumer with explicit acks on stream,
+// or leaves an existing one unchanged. Instances pulling from the same
+// durable consumer share its messages, like a consumer group.
+func (c *Conn) AddConsumer(ctx context.Context, stream, durable, filterSubject string) error {
+	request, _ := json.Marshal(map[string]interface{}{
+		"stream_name": stream,
+		"config": map[string]interface{}{
+			"durable_name":   durable,
+			"ack_policy":     "explicit",
+			"filter_subject": filterSubject,
+		},
+	})
+
+	msg, err := c.Request(ctx, "$JS.API.CONSUMER.DURABLE.CREATE."+stream+"."+durable, request)
+	if err != nil {
+		return err
+	}
+	var reply apiError
+	if err := json.Unmarshal(msg.Data, &reply); err != nil {
+		return fmt.Errorf("nats: malformed consumer reply: %w", err)
+	}
+	return reply.err()
+}
+
+// Fetch pulls up to batch messages from a durable consumer, waiting at most
+// expires for them. Every message must be acknowledged with Ack, or it is
+// delivered again after the consumer's ack wait.
+func (c *Conn) Fetch(ctx context.Context, stream, durable string, batch int, expires time.Duration) ([]Msg, error) {
+	inbox := NewInbox()
+	sub, err := c.Subscribe(inbox)
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	request, _ := json.Marshal(map[string]interface{}{"batch": batch, "expires": expires.Nanoseconds()})
+	if err := c.Publish("$JS.API.CONSUMER.MSG.NEXT."+stream+"."+durable, inbox, request); err != nil {
+		return nil, err
+	}
+
+	// the server ends the request itself, the timer only guards against a
+	// lost status message
+	timer := time.NewTimer(expires + time.Second)
+	defer timer.Stop()
+
+	var msgs []Msg
+	for len(msgs) < batch {
+		select {
+		case msg, ok := <-sub.C:
+			if !ok {
+				return msgs, c.closedErr()
+			}
+			switch msg.Status {
+			case "":
+				msgs = append(msgs, msg)
+			case "404", "408", "409":
+				// no messages, request expired or consumer changed
+				return msgs, nil
+			case "100":
+				// heartbeat
+			default:
+				return msgs, fmt.Errorf("nats: pull request ended with status %s", msg.Status)
+			}
+		case <-timer.C:
+			return msgs, nil
+		case <-ctx.Done():
+			return msgs, ctx.Err()
+		}
+	}
+	return msgs, nil
+}
+
+// Ack acknowledges a message pulled with Fetch
+func (c *Conn) Ack(msg Msg) error {
+	return c.Publish(msg.Reply, "", []byte("+ACK"))
+}
+
+// Nak asks JetStream to redeliver a message pulled with Fetch right away
+func (c *Conn) Nak(msg Msg) error {
+	return c.Publish(msg.Reply, "", []byte("-NAK"))
+}
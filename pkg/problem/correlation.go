@@ -0,0 +1,62 @@
+package problem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// HeaderCorrelationID carries the id tying a request to its logs and error
+// responses, taken from the client when it sends one
+const HeaderCorrelationID = "X-Correlation-ID"
+
+// longest client supplied id that is kept
+const maxCorrelationID = 128
+
+type correlationKey struct{}
+
+// Correlate gives every request a correlation id: the client's, when it is
+// printable and not too long, or a new one. The id is echoed in the
+// response header and added to problems written for the request.
+func Correlate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderCorrelationID)
+		if !validCorrelationID(id) {
+			id = newCorrelationID()
+		}
+
+		w.Header().Set(HeaderCorrelationID, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), correlationKey{}, id)))
+	})
+}
+
+// CorrelationID returns the request's id, set by Correlate or sent by the
+// client, empty when there is none
+func CorrelationID(r *http.Request) string {
+	if id, ok := r.Context().Value(correlationKey{}).(string); ok {
+		return id
+	}
+	if id := r.Header.Get(HeaderCorrelationID); validCorrelationID(id) {
+		return id
+	}
+	return ""
+}
+
+func validCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationID {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "req_" + hex.EncodeToString(b)
+}
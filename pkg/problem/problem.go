@@ -0,0 +1,214 @@
+// Package problem writes HTTP error bodies as RFC 7807 problem details, so
+// API consumers can handle every error of pgas the same way: by the type
+// URI of its category, the pgas error code, and the correlation id to quote
+// to support.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// ContentType is the media type of problem details
+const ContentType = "application/problem+json"
+
+// categories of errors that are not about the payment itself; payment
+// errors are categorized by their normalized reason, e.g. insufficient_funds
+const (
+	CategoryValidation       = "validation"        // fields violating the request schema
+	CategoryMalformed        = "malformed_request" // bodies or parameters that cannot be read
+	CategoryUnauthorized     = "unauthorized"
+	CategoryForbidden        = "forbidden"
+	CategoryNotFound         = "not_found"
+	CategoryMethodNotAllowed = "method_not_allowed"
+	CategoryConflict         = "conflict" // e.g. replayed requests or an idempotency key in use
+	CategoryRateLimited      = "rate_limited"
+	CategoryUnavailable      = "unavailable"
+	CategoryTimeout          = "timeout"
+	CategoryInternal         = "internal"
+)
+
+// TypeURI is the problem type of a category
+func TypeURI(category string) string {
+	return "urn:pgas:problem:" + category
+}
+
+// TypeValidation identifies requests rejected for invalid fields, listed in
+// InvalidParams
+var TypeValidation = TypeURI(CategoryValidation)
+
+// Problem is an RFC 7807 problem details object. Type defaults to
+// "about:blank" when empty, as the RFC prescribes.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+
+	// extension members
+	Code      string `json:"code,omitempty"` // pgas error code, e.g. INSUFFICIENT_FUNDS or REPLAYED_REQUEST
+	Retryable bool   `json:"retryable,omitempty"`
+	Advice    string `json:"advice,omitempty"` // issuer retry advice of declines
+	// RetryAfter is how long to wait before retrying, in seconds; Write
+	// also sends it as the Retry-After header
+	RetryAfter    int    `json:"retry_after,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// InvalidParams lists every field violation of a validation problem,
+	// the extension member used by the RFC's own example
+	InvalidParams []InvalidParam `json:"invalid-params,omitempty"`
+}
+
+// InvalidParam is one violated rule of a request field. Name is the dotted
+// JSON path, e.g. "overrides.actor" or "tags[2]"; it is empty for errors of
+// the whole body such as malformed JSON.
+type InvalidParam struct {
+	Name   string `json:"name"`
+	Rule   string `json:"rule,omitempty"` // the violated rule, e.g. required or max
+	Reason string `json:"reason"`
+}
+
+// New builds a problem of a category, titled by its status
+func New(status int, category, code, detail string) *Problem {
+	return &Problem{
+		Type:   TypeURI(category),
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// Validation builds the problem of a request with invalid fields
+func Validation(params []InvalidParam) *Problem {
+	return &Problem{
+		Type:          TypeValidation,
+		Title:         "Request validation failed",
+		Status:        http.StatusUnprocessableEntity,
+		Code:          "INVALID_REQUEST",
+		Detail:        "the request has invalid fields, see invalid-params",
+		InvalidParams: params,
+	}
+}
+
+// Malformed builds the problem of a body or query parameter that cannot be
+// read at all
+func Malformed(params []InvalidParam) *Problem {
+	return &Problem{
+		Type:          TypeURI(CategoryMalformed),
+		Title:         "Malformed request",
+		Status:        http.StatusBadRequest,
+		Code:          "MALFORMED_REQUEST",
+		Detail:        "the request cannot be read, see invalid-params",
+		InvalidParams: params,
+	}
+}
+
+// RateLimited builds the problem of a client over its rate limit, retryable
+// after the given wait
+func RateLimited(retryAfter time.Duration) *Problem {
+	p := New(http.StatusTooManyRequests, CategoryRateLimited, "RATE_LIMITED", "too many requests, retry after the given delay")
+	p.Retryable = true
+	p.RetryAfter = seconds(retryAfter)
+	return p
+}
+
+// status and category of processor error codes that are not declines
+var codes = map[string]struct {
+	status   int
+	category string
+}{
+	"INVALID_REQUEST":          {http.StatusUnprocessableEntity, CategoryValidation},
+	"INVALID_METADATA":         {http.StatusUnprocessableEntity, CategoryValidation},
+	"INVALID_DESCRIPTOR":       {http.StatusUnprocessableEntity, CategoryValidation},
+	"INVALID_PROVIDER":         {http.StatusUnprocessableEntity, CategoryValidation},
+	"INVALID_SUB_MERCHANT":     {http.StatusUnprocessableEntity, CategoryValidation},
+	"INVALID_INSTALLMENT_PLAN": {http.StatusUnprocessableEntity, CategoryValidation},
+	"CURRENCY_MISMATCH":        {http.StatusUnprocessableEntity, CategoryValidation},
+	"AMOUNT_EXCEEDS_REMAINING": {http.StatusUnprocessableEntity, CategoryValidation},
+	"UNKNOWN_CARD_NETWORK":     {http.StatusUnprocessableEntity, CategoryValidation},
+	"REFUND_NOT_SUPPORTED":     {http.StatusUnprocessableEntity, CategoryValidation},
+	"CREDIT_NOT_SUPPORTED":     {http.StatusUnprocessableEntity, CategoryValidation},
+	"CREDIT_LIMIT_EXCEEDED":    {http.StatusUnprocessableEntity, CategoryValidation},
+	"PIN_NOT_SUPPORTED":        {http.StatusUnprocessableEntity, CategoryValidation},
+	"NO_MERCHANT_ACCOUNT":      {http.StatusUnprocessableEntity, CategoryValidation},
+	"UNAUTHORIZED_OVERRIDE":    {http.StatusForbidden, CategoryForbidden},
+	"UNAUTHORIZED_CREDIT":      {http.StatusForbidden, CategoryForbidden},
+	"CREDITS_NOT_ENABLED":      {http.StatusForbidden, CategoryForbidden},
+	"ACTION_NOT_FOUND":         {http.StatusNotFound, CategoryNotFound},
+	"IDEMPOTENCY_KEY_IN_USE":   {http.StatusConflict, CategoryConflict},
+	"IDEMPOTENCY_KEY_REUSED":   {http.StatusConflict, CategoryConflict},
+	"ALREADY_CAPTURED":         {http.StatusConflict, CategoryConflict},
+	"REPLAYED_REQUEST":         {http.StatusConflict, CategoryConflict},
+	"READ_ONLY_MODE":           {http.StatusServiceUnavailable, CategoryUnavailable},
+	"PROVIDER_DRAINING":        {http.StatusServiceUnavailable, CategoryUnavailable},
+	"REQUEST_TIMEOUT":          {http.StatusGatewayTimeout, CategoryTimeout},
+	"LATENCY_BUDGET_EXCEEDED":  {http.StatusGatewayTimeout, CategoryTimeout},
+}
+
+// FromPaymentError describes a payment error as a problem. Declines are
+// categorized by their normalized reason and answered with 402, gateway
+// failures with 502; errors about the request itself keep their own
+// category and status.
+func FromPaymentError(paymentError *providers.PaymentError) *Problem {
+	status, category := http.StatusBadGateway, providers.ReasonProcessingError
+	if known, ok := codes[paymentError.ErrorCode]; ok {
+		status, category = known.status, known.category
+	} else if paymentError.Reason != "" {
+		category = paymentError.Reason
+		if paymentError.Declined() {
+			status = http.StatusPaymentRequired
+		}
+	}
+
+	p := New(status, category, paymentError.ErrorCode, paymentError.ErrorMessage)
+	p.Retryable = paymentError.Retryable
+	p.Advice = paymentError.Advice
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Title + ": " + p.Detail
+	}
+	return p.Title
+}
+
+// Write sends the problem with its status and the problem+json content
+// type. The request, which may be nil, fills in the instance and the
+// correlation id.
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if r != nil {
+		if p.Instance == "" {
+			p.Instance = r.URL.Path
+		}
+		if p.CorrelationID == "" {
+			p.CorrelationID = CorrelationID(r)
+		}
+	}
+
+	if p.CorrelationID != "" {
+		w.Header().Set(HeaderCorrelationID, p.CorrelationID)
+	}
+	if p.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(p.RetryAfter))
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// seconds rounds a wait up to whole seconds, as Retry-After requires
+func seconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,123 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+func TestWrite(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodPost, "/v1/payments", nil)
+	request.Header.Set(HeaderCorrelationID, "req_client")
+	Write(recorder, request, Validation([]InvalidParam{{Name: "amount", Rule: "gt=0", Reason: "must be greater than 0"}}))
+
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got %d", recorder.Code)
+	}
+	if contentType := recorder.Header().Get("Content-Type"); contentType != ContentType {
+		t.Errorf("Expected content type %s, got %s", ContentType, contentType)
+	}
+	if recorder.Header().Get(HeaderCorrelationID) != "req_client" {
+		t.Errorf("Expected the correlation id echoed, got %q", recorder.Header().Get(HeaderCorrelationID))
+	}
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Expected a JSON body, got: %v", err)
+	}
+	if body["type"] != TypeValidation || body["status"] != float64(422) || body["instance"] != "/v1/payments" || body["correlation_id"] != "req_client" {
+		t.Errorf("Expected the validation problem of the request, got %v", body)
+	}
+	params, _ := body["invalid-params"].([]interface{})
+	if len(params) != 1 {
+		t.Errorf("Expected one invalid param, got %v", body["invalid-params"])
+	}
+}
+
+func TestWrite_Defaults(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	Write(recorder, nil, &Problem{Status: http.StatusNotFound})
+
+	var body Problem
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	if body.Type != "about:blank" || body.Title != "Not Found" {
+		t.Errorf("Expected about:blank with the status text, got %+v", body)
+	}
+}
+
+func TestRateLimited(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	Write(recorder, nil, RateLimited(1500*time.Millisecond))
+
+	if recorder.Code != http.StatusTooManyRequests || recorder.Header().Get("Retry-After") != "2" {
+		t.Errorf("Expected 429 with Retry-After rounded up, got %d %q", recorder.Code, recorder.Header().Get("Retry-After"))
+	}
+	var body Problem
+	json.Unmarshal(recorder.Body.Bytes(), &body)
+	if body.Type != TypeURI(CategoryRateLimited) || !body.Retryable || body.RetryAfter != 2 {
+		t.Errorf("Expected a retryable rate limit problem, got %+v", body)
+	}
+}
+
+func TestFromPaymentError(t *testing.T) {
+	testCases := []struct {
+		err      providers.PaymentError
+		status   int
+		category string
+	}{
+		{providers.PaymentError{ErrorCode: "EE000051", Reason: providers.ReasonInsufficientFunds}, http.StatusPaymentRequired, providers.ReasonInsufficientFunds},
+		{providers.PaymentError{ErrorCode: "911", Reason: providers.ReasonIssuerUnavailable, Retryable: true}, http.StatusBadGateway, providers.ReasonIssuerUnavailable},
+		{providers.PaymentError{ErrorCode: "INVALID_REQUEST"}, http.StatusUnprocessableEntity, CategoryValidation},
+		{providers.PaymentError{ErrorCode: "UNKNOWN_CARD_NETWORK", Reason: providers.ReasonInvalidCard}, http.StatusUnprocessableEntity, CategoryValidation},
+		{providers.PaymentError{ErrorCode: "IDEMPOTENCY_KEY_IN_USE", Retryable: true}, http.StatusConflict, CategoryConflict},
+		{providers.PaymentError{ErrorCode: "READ_ONLY_MODE"}, http.StatusServiceUnavailable, CategoryUnavailable},
+		{providers.PaymentError{ErrorCode: "PARSING_ERROR"}, http.StatusBadGateway, providers.ReasonProcessingError},
+	}
+
+	for _, tc := range testCases {
+		p := FromPaymentError(&tc.err)
+		if p.Status != tc.status || p.Type != TypeURI(tc.category) || p.Code != tc.err.ErrorCode || p.Retryable != tc.err.Retryable {
+			t.Errorf("%s: expected %d %s, got %+v", tc.err.ErrorCode, tc.status, tc.category, p)
+		}
+	}
+}
+
+func TestCorrelate(t *testing.T) {
+	var seen string
+	handler := Correlate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = CorrelationID(r)
+		Write(w, r, New(http.StatusNotFound, CategoryNotFound, "", "no such payment"))
+	}))
+
+	for _, sent := range []string{"", "bad id with spaces", strings.Repeat("x", 200)} {
+		recorder := httptest.NewRecorder()
+		request := httptest.NewRequest(http.MethodGet, "/v1/payments/tx_1", nil)
+		if sent != "" {
+			request.Header.Set(HeaderCorrelationID, sent)
+		}
+		handler.ServeHTTP(recorder, request)
+
+		if !strings.HasPrefix(seen, "req_") || recorder.Header().Get(HeaderCorrelationID) != seen {
+			t.Errorf("%q: expected a generated id in context and header, got %q", sent, seen)
+		}
+		var body Problem
+		json.Unmarshal(recorder.Body.Bytes(), &body)
+		if body.CorrelationID != seen {
+			t.Errorf("%q: expected the id in the problem, got %+v", sent, body)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, "/", nil)
+	request.Header.Set(HeaderCorrelationID, "checkout-42")
+	handler.ServeHTTP(recorder, request)
+	if seen != "checkout-42" {
+		t.Errorf("Expected the client's id kept, got %q", seen)
+	}
+}
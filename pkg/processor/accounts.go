@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"pgas/pkg/providers"
+)
+
+// selectMerchantAccount submits the payment under the provider's merchant
+// account for it, see providers.SelectMerchantAccount. Accounts set by
+// callers are never trusted, providers without accounts get none.
+func (p *PaymentProcessor) selectMerchantAccount(paymentProvider providers.Provider, paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	paymentReqest.MerchantAccount = nil
+	accounts := p.config.MerchantAccounts[paymentProvider.GetName()]
+	if len(accounts) == 0 {
+		return paymentReqest, nil
+	}
+
+	if account, ok := providers.SelectMerchantAccount(accounts, paymentReqest); ok {
+		paymentReqest.MerchantAccount = &account
+		return paymentReqest, nil
+	}
+	return paymentReqest, &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "NO_MERCHANT_ACCOUNT",
+		ErrorMessage: "no merchant account of provider '" + paymentProvider.GetName() + "' takes " + paymentReqest.Currency + " payments" + describeAccountScope(paymentReqest),
+	}
+}
+
+// describeAccountScope names the other fields accounts are selected by
+func describeAccountScope(paymentReqest providers.PaymentRequest) string {
+	scope := ""
+	if paymentReqest.MerchantCountry != "" {
+		scope += " in " + paymentReqest.MerchantCountry
+	}
+	if paymentReqest.BusinessLine != "" {
+		scope += " for business line '" + paymentReqest.BusinessLine + "'"
+	}
+	return scope
+}
+
+// merchantAccountName names the account a payment is submitted under, empty
+// for providers without accounts
+func merchantAccountName(paymentReqest providers.PaymentRequest) string {
+	if paymentReqest.MerchantAccount == nil {
+		return ""
+	}
+	return paymentReqest.MerchantAccount.Name
+}
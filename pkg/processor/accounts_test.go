@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+var visaAccounts = []providers.MerchantAccount{
+	{Name: "eu-travel", MerchantID: "4445551", TerminalID: "T01", Currencies: []string{"EUR"}, BusinessLines: []string{"travel"}},
+	{Name: "eu", MerchantID: "4445552", TerminalID: "T01", Currencies: []string{"EUR"}},
+	{Name: "us", MerchantID: "4445550", Countries: []string{"US"}},
+}
+
+func TestMerchantAccounts_Selection(t *testing.T) {
+	visa := newStubProvider("visa")
+	processor := NewPaymentProcessor([]providers.Provider{visa}, WithMerchantAccounts("visa", visaAccounts...))
+
+	cases := []struct {
+		currency, country, businessLine string
+		account                         string
+	}{
+		{"EUR", "DE", "travel", "eu-travel"},
+		{"EUR", "DE", "retail", "eu"},
+		{"EUR", "US", "", "eu"},
+		{"USD", "US", "travel", "us"},
+	}
+	for _, tc := range cases {
+		request := stubRequest("visa")
+		request.Currency, request.MerchantCountry, request.BusinessLine = tc.currency, tc.country, tc.businessLine
+
+		if _, paymentError := processor.ProcessPayment(context.Background(), request); paymentError != nil {
+			t.Fatalf("%s: expected the payment to be approved, got %+v", tc.account, paymentError)
+		}
+		if account := visa.received().MerchantAccount; account == nil || account.Name != tc.account {
+			t.Errorf("Expected %s %s %s to use account %s, got %+v", tc.currency, tc.country, tc.businessLine, tc.account, account)
+		}
+	}
+
+	info, _ := processor.Provider("visa")
+	if !slices.Equal(info.MerchantAccounts, []string{"eu-travel", "eu", "us"}) {
+		t.Errorf("Expected the accounts to be described in order, got %v", info.MerchantAccounts)
+	}
+}
+
+func TestMerchantAccounts_NoMatch(t *testing.T) {
+	visa := newStubProvider("visa")
+	processor := NewPaymentProcessor([]providers.Provider{visa}, WithMerchantAccounts("visa", visaAccounts...))
+
+	request := stubRequest("visa")
+	request.Currency, request.MerchantCountry = "GBP", "GB"
+	_, paymentError := processor.ProcessPayment(context.Background(), request)
+	if paymentError == nil || paymentError.ErrorCode != "NO_MERCHANT_ACCOUNT" {
+		t.Fatalf("Expected NO_MERCHANT_ACCOUNT, got %+v", paymentError)
+	}
+	if visa.callCount() != 0 {
+		t.Error("Expected the payment not to reach the gateway")
+	}
+}
+
+func TestMerchantAccounts_CallerAccountDropped(t *testing.T) {
+	visa := newStubProvider("visa")
+	processor := NewPaymentProcessor([]providers.Provider{visa})
+
+	request := stubRequest("visa")
+	request.MerchantAccount = &providers.MerchantAccount{Name: "other", MerchantID: "999"}
+	if _, paymentError := processor.ProcessPayment(context.Background(), request); paymentError != nil {
+		t.Fatalf("Expected the payment to be approved, got %+v", paymentError)
+	}
+	if visa.received().MerchantAccount != nil {
+		t.Errorf("Expected the caller's account to be dropped, got %+v", visa.received().MerchantAccount)
+	}
+}
+
+func TestMerchantAccounts_Failover(t *testing.T) {
+	primary := newStubProvider("stripe", gatewayDown)
+	backup := newStubProvider("adyen")
+	processor := NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}),
+		WithMerchantAccounts("stripe", providers.MerchantAccount{Name: "stripe-us", MerchantID: "acct_1"}),
+		WithMerchantAccounts("adyen", providers.MerchantAccount{Name: "adyen-us", MerchantID: "ShopUS"}))
+
+	if _, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe")); paymentError != nil {
+		t.Fatalf("Expected the backup to charge, got %+v", paymentError)
+	}
+	if primary.received().MerchantAccount.Name != "stripe-us" || backup.received().MerchantAccount.Name != "adyen-us" {
+		t.Errorf("Expected each provider to get its own account, got %+v and %+v", primary.received().MerchantAccount, backup.received().MerchantAccount)
+	}
+}
+
+func TestMerchantAccounts_LocalCurrency(t *testing.T) {
+	visa := newStubProvider("visa")
+	processor := NewPaymentProcessor([]providers.Provider{visa}, WithMerchantAccounts("visa",
+		providers.MerchantAccount{Name: "cross-border", MerchantID: "4445550", SettlementCurrency: "USD", CrossBorder: true},
+		providers.MerchantAccount{Name: "uk", MerchantID: "4445560", SettlementCurrency: "GBP"},
+		providers.MerchantAccount{Name: "eu", MerchantID: "4445570", SettlementCurrency: "EUR"},
+	))
+
+	cases := []struct {
+		currency, account, merchantID string
+		crossBorder                   bool
+	}{
+		{"GBP", "uk", "4445560", false},
+		{"EUR", "eu", "4445570", false},
+		{"JPY", "cross-border", "4445550", true},
+		{"USD", "cross-border", "4445550", false},
+	}
+	for _, tc := range cases {
+		request := stubRequest("visa")
+		request.Currency = tc.currency
+
+		response, paymentError := processor.ProcessPayment(context.Background(), request)
+		if paymentError != nil {
+			t.Fatalf("%s: expected the payment to be approved, got %+v", tc.currency, paymentError)
+		}
+		if response.MerchantAccount != tc.account {
+			t.Errorf("Expected %s payments to use account %s, got %s", tc.currency, tc.account, response.MerchantAccount)
+		}
+
+		tx, err := processor.Transactions().Get(response.TransactionID)
+		if err != nil {
+			t.Fatalf("Expected the transaction to be stored: %v", err)
+		}
+		if tx.MerchantAccount != tc.account || tx.MerchantID != tc.merchantID || tx.CrossBorder != tc.crossBorder {
+			t.Errorf("Expected %s to be recorded as %s %s cross-border=%v, got %s %s %v",
+				tc.currency, tc.account, tc.merchantID, tc.crossBorder, tx.MerchantAccount, tx.MerchantID, tx.CrossBorder)
+		}
+	}
+}
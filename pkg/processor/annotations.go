@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/store"
+)
+
+// maximum length of an annotation note in bytes
+const maxNoteLength = 4000
+
+// AnnotateTransaction adds an operator note and tags to a stored
+// transaction. Tags are lowercased and deduplicated; either a note or a tag
+// is required. Annotated transactions are found through the Tag and Note
+// fields of store.TransactionFilter.
+func (p *PaymentProcessor) AnnotateTransaction(id, author, note string, tags []string) (store.Transaction, error) {
+	if p.config.Transactions == nil {
+		return store.Transaction{}, errors.New("no transaction store configured")
+	}
+
+	annotation := store.Annotation{
+		Author: strings.TrimSpace(author),
+		Note:   strings.TrimSpace(note),
+		Tags:   normalizeTags(tags),
+		Time:   time.Now(),
+	}
+	switch {
+	case annotation.Author == "":
+		return store.Transaction{}, errors.New("annotation author is required")
+	case annotation.Note == "" && len(annotation.Tags) == 0:
+		return store.Transaction{}, errors.New("annotation needs a note or a tag")
+	case len(annotation.Note) > maxNoteLength:
+		return store.Transaction{}, errors.New("annotation note is too long")
+	}
+
+	tx, err := p.config.Transactions.Get(id)
+	if err != nil {
+		return store.Transaction{}, err
+	}
+
+	tx.Annotations = append(append([]store.Annotation(nil), tx.Annotations...), annotation)
+	if err := p.config.Transactions.Save(tx); err != nil {
+		return store.Transaction{}, err
+	}
+
+	p.recordAudit(audit.Entry{
+		Time:      annotation.Time,
+		Actor:     annotation.Author,
+		Action:    "transaction.annotated",
+		Reference: id,
+		Details:   map[string]string{"tags": strings.Join(annotation.Tags, ",")},
+	})
+	return tx, nil
+}
+
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool, len(tags))
+	var normalized []string
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	sort.Strings(normalized)
+	return normalized
+}
@@ -0,0 +1,62 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/store"
+)
+
+func TestAnnotateTransaction(t *testing.T) {
+	log := audit.NewMemoryLog()
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")), WithAuditLog(log))
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected successful payment, got error: %v", err)
+	}
+
+	if _, err := processor.AnnotateTransaction(response.TransactionID, "alice", "Customer called, card was stolen", []string{"Fraud", " fraud ", "callback"}); err != nil {
+		t.Fatalf("Expected annotation, got error: %v", err)
+	}
+	tx, annotateErr := processor.AnnotateTransaction(response.TransactionID, "bob", "", []string{"escalated"})
+	if annotateErr != nil {
+		t.Fatalf("Expected tag-only annotation, got error: %v", annotateErr)
+	}
+
+	if len(tx.Annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, got %+v", tx.Annotations)
+	}
+	first := tx.Annotations[0]
+	if first.Author != "alice" || first.Time.IsZero() || len(first.Tags) != 2 || first.Tags[0] != "callback" || first.Tags[1] != "fraud" {
+		t.Errorf("Expected an authored, timestamped note with normalized tags, got %+v", first)
+	}
+
+	matches, _ := processor.Transactions().Query(store.TransactionFilter{Tag: "fraud", Note: "stolen"})
+	if len(matches) != 1 || matches[0].ID != response.TransactionID {
+		t.Errorf("Expected the annotated transaction to be found, got %+v", matches)
+	}
+	if entries := log.Filter("transaction.annotated"); len(entries) != 2 || entries[0].Actor != "alice" || entries[0].Reference != response.TransactionID {
+		t.Errorf("Expected annotations to be audited, got %+v", entries)
+	}
+}
+
+func TestAnnotateTransaction_Invalid(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+	response, _ := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+
+	cases := map[string]struct {
+		id, author, note string
+		tags             []string
+	}{
+		"missing author":      {response.TransactionID, "", "note", nil},
+		"empty":               {response.TransactionID, "alice", " ", []string{" "}},
+		"unknown transaction": {"txn_missing", "alice", "note", nil},
+	}
+	for name, c := range cases {
+		if _, err := processor.AnnotateTransaction(c.id, c.author, c.note, c.tags); err == nil {
+			t.Errorf("%s: expected annotation to be rejected", name)
+		}
+	}
+}
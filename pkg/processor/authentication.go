@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/threeds"
+)
+
+// authenticate runs 3-D Secure through the configured authenticator and
+// attaches the result to the request. Requests that already carry an
+// authentication result, e.g. from the merchant's own MPI, are passed as is.
+// The result fills the ECI, cryptogram and DS transaction id sent to the
+// provider, which validates them like caller supplied values.
+func (p *PaymentProcessor) authenticate(ctx context.Context, paymentProvider providers.Provider, paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	// UPI payments are authorized with the customer's UPI PIN instead, bank
+	// debits by the mandate the merchant holds and PIN debit payments by
+	// the cardholder's PIN
+	if p.config.Authenticator == nil || paymentReqest.Authentication != nil || paymentReqest.VPA != "" || paymentReqest.AccountNumber != "" || paymentReqest.PINBlock != nil {
+		return paymentReqest, nil
+	}
+
+	if p.config.DefaultTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.DefaultTimeout)
+		defer cancel()
+	}
+
+	result, err := p.config.Authenticator.Authenticate(ctx, threeds.NewRequest(paymentReqest))
+	if err != nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "AUTHENTICATION_ERROR",
+			ErrorMessage: err.Error(),
+			Err:          err,
+			Reason:       providers.ReasonProcessingError,
+		}
+	}
+
+	authentication := result.Authentication
+	switch authentication.Status {
+	case providers.AuthStatusAuthenticated, providers.AuthStatusAttempted, providers.AuthStatusUnavailable:
+		// unavailable authentication still goes ahead, without liability shift
+		paymentReqest.Authentication = &authentication
+		providers.ApplyAuthentication(&paymentReqest)
+		if err := paymentProvider.ValidateRequest(paymentReqest); err != nil {
+			return paymentReqest, &providers.PaymentError{
+				Success:      false,
+				ErrorCode:    "AUTHENTICATION_ERROR",
+				ErrorMessage: "3-D Secure result rejected: " + err.Error(),
+				Reason:       providers.ReasonProcessingError,
+			}
+		}
+		return paymentReqest, nil
+
+	case providers.AuthStatusChallenge:
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "AUTHENTICATION_REQUIRED",
+			ErrorMessage: "cardholder must complete a 3-D Secure challenge",
+		}
+	}
+
+	return paymentReqest, &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "AUTHENTICATION_FAILED",
+		ErrorMessage: "3-D Secure authentication failed with status '" + authentication.Status + "'",
+		Reason:       providers.ReasonCardDeclined,
+	}
+}
@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/threeds"
+)
+
+func TestProcessPayment_ThreeDSAuthentication(t *testing.T) {
+	stub := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(stub), WithAuthenticator(threeds.NewSimulator()))
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Fatalf("Expected authenticated payment to succeed, got: %v", err)
+	}
+
+	auth := stub.received().Authentication
+	if auth == nil || auth.Status != providers.AuthStatusAuthenticated || auth.ECI != "05" || auth.CAVV == "" {
+		t.Fatalf("Expected 3DS result to reach the provider, got %+v", auth)
+	}
+	if received := stub.received(); received.ECI != "05" || received.Cryptogram != auth.CAVV || received.DSTransactionID != auth.DSTransactionID {
+		t.Errorf("Expected authorization fields from the 3DS result, got eci=%q cryptogram=%q ds=%q",
+			received.ECI, received.Cryptogram, received.DSTransactionID)
+	}
+
+	cases := map[string]string{
+		"4000000000000001": "AUTHENTICATION_REQUIRED",
+		"4000000000000002": "AUTHENTICATION_FAILED",
+	}
+	for cardNumber, code := range cases {
+		request := stubRequest("stub")
+		request.CardNumber = cardNumber
+		if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != code {
+			t.Errorf("Card %s: expected %s, got %v", cardNumber, code, err)
+		}
+	}
+
+	if stub.callCount() != 1 {
+		t.Errorf("Expected failed authentications not to reach the provider, got %d calls", stub.callCount())
+	}
+}
+
+func TestProcessPayment_ThreeDSResultFromMerchant(t *testing.T) {
+	stub := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(stub), WithAuthenticator(threeds.NewSimulator()))
+
+	request := stubRequest("stub")
+	request.CardNumber = "4000000000000002" // would fail with the simulator
+	request.Authentication = &providers.Authentication{Status: providers.AuthStatusAuthenticated, ECI: "05", CAVV: "AAAB"}
+
+	if _, err := processor.ProcessPayment(context.Background(), request); err != nil {
+		t.Fatalf("Expected merchant supplied authentication to be used, got: %v", err)
+	}
+
+	if stub.received().Authentication.CAVV != "AAAB" {
+		t.Errorf("Expected merchant CAVV to be passed through, got %+v", stub.received().Authentication)
+	}
+}
+
+func TestProcessPayment_UPISkipsThreeDS(t *testing.T) {
+	stub := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(stub), WithAuthenticator(threeds.NewSimulator()))
+
+	request := providers.PaymentRequest{Mode: "stub", Method: "upi_collect", Amount: 499, Currency: "INR", VPA: "jane.doe@okbank"}
+	if _, err := processor.ProcessPayment(context.Background(), request); err != nil {
+		t.Fatalf("Expected the UPI payment to succeed, got: %v", err)
+	}
+
+	if received := stub.received(); received.Authentication != nil || received.VPA != "jane.doe@okbank" {
+		t.Errorf("Expected the VPA without a 3DS result to reach the provider, got %+v", received)
+	}
+}
@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// AuthorizationStrategy decides how much the captures of a payment may take
+// in total, per authorization type, see WithAuthorizationStrategy
+type AuthorizationStrategy interface {
+	CaptureLimit(tx store.Transaction) float64
+}
+
+// CaptureTolerance lets captures exceed the authorized amount by a share of
+// it, 0.2 captures up to 120% of the authorization
+type CaptureTolerance float64
+
+func (t CaptureTolerance) CaptureLimit(tx store.Transaction) float64 {
+	return tx.Amount * (1 + float64(t))
+}
+
+// captures of estimated authorizations may exceed the estimate by 20%, the
+// tolerance schemes allow e.g. for hotel incidentals
+func defaultAuthorizations() map[string]AuthorizationStrategy {
+	return map[string]AuthorizationStrategy{
+		providers.AuthorizationFinal:     CaptureTolerance(0),
+		providers.AuthorizationEstimated: CaptureTolerance(0.2),
+	}
+}
+
+// checkAuthorizationType rejects authorization types without a strategy,
+// and estimated authorizations of providers that cannot capture them
+func (p *PaymentProcessor) checkAuthorizationType(paymentProvider providers.Provider, paymentReqest providers.PaymentRequest) *providers.PaymentError {
+	authorizationType := paymentReqest.AuthorizationType
+	if authorizationType == "" {
+		return nil
+	}
+
+	if _, ok := p.config.Authorizations[authorizationType]; !ok {
+		return &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: "authorization type '" + authorizationType + "' is not supported",
+		}
+	}
+
+	if _, ok := paymentProvider.(providers.Capturer); !ok && authorizationType != providers.AuthorizationFinal {
+		return &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: "provider '" + paymentProvider.GetName() + "' cannot capture " + authorizationType + " authorizations",
+		}
+	}
+	return nil
+}
+
+// captureLimit is the most the captures of a stored payment may take
+func (p *PaymentProcessor) captureLimit(tx store.Transaction) float64 {
+	authorizationType := tx.AuthorizationType
+	if authorizationType == "" {
+		authorizationType = providers.AuthorizationFinal
+	}
+	if strategy, ok := p.config.Authorizations[authorizationType]; ok {
+		return strategy.CaptureLimit(tx)
+	}
+	return tx.Amount
+}
@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+func TestEstimatedAuthorization_CaptureTolerance(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{&capturingProvider{newStubProvider("stub")}})
+	ctx := context.Background()
+
+	request := stubRequest("stub")
+	request.AuthorizationType = providers.AuthorizationEstimated
+	response, paymentError := processor.ProcessPayment(ctx, request)
+	if paymentError != nil {
+		t.Fatalf("Expected the estimated authorization to succeed, got %v", paymentError)
+	}
+
+	tx, err := processor.Transactions().Get(response.TransactionID)
+	if err != nil || tx.AuthorizationType != providers.AuthorizationEstimated {
+		t.Fatalf("Expected the authorization type to be stored, got %+v %v", tx, err)
+	}
+
+	capture := providers.CaptureRequest{Mode: "stub", TransactionID: response.TransactionID, Amount: 115, Currency: "USD"}
+	if _, err := processor.CapturePayment(ctx, capture); err != nil {
+		t.Fatalf("Expected a capture within 120%% of the estimate, got %v", err)
+	}
+
+	capture.Amount = 5.01
+	if _, err := processor.CapturePayment(ctx, capture); err == nil || err.ErrorCode != "AMOUNT_EXCEEDS_REMAINING" {
+		t.Errorf("Expected AMOUNT_EXCEEDS_REMAINING beyond 120%%, got %v", err)
+	}
+
+	capture.Amount, capture.Final = 5, true
+	if _, err := processor.CapturePayment(ctx, capture); err != nil {
+		t.Errorf("Expected the capture up to 120%% to succeed, got %v", err)
+	}
+}
+
+func TestFinalAuthorization_NoTolerance(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{&capturingProvider{newStubProvider("stub")}})
+	ctx := context.Background()
+
+	response, paymentError := processor.ProcessPayment(ctx, stubRequest("stub"))
+	if paymentError != nil {
+		t.Fatalf("Payment failed: %v", paymentError)
+	}
+
+	capture := providers.CaptureRequest{Mode: "stub", TransactionID: response.TransactionID, Amount: 100.01, Currency: "USD"}
+	if _, err := processor.CapturePayment(ctx, capture); err == nil || err.ErrorCode != "AMOUNT_EXCEEDS_REMAINING" {
+		t.Errorf("Expected AMOUNT_EXCEEDS_REMAINING above a final authorization, got %v", err)
+	}
+}
+
+func TestWithAuthorizationStrategy(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{&capturingProvider{newStubProvider("stub")}},
+		WithAuthorizationStrategy(providers.AuthorizationEstimated, CaptureTolerance(0.5)))
+	ctx := context.Background()
+
+	request := stubRequest("stub")
+	request.AuthorizationType = providers.AuthorizationEstimated
+	response, _ := processor.ProcessPayment(ctx, request)
+
+	capture := providers.CaptureRequest{Mode: "stub", TransactionID: response.TransactionID, Amount: 150, Currency: "USD"}
+	if _, err := processor.CapturePayment(ctx, capture); err != nil {
+		t.Errorf("Expected the configured tolerance of 50%%, got %v", err)
+	}
+
+	if _, ok := DefaultConfig().Authorizations[providers.AuthorizationEstimated].(CaptureTolerance); !ok {
+		t.Error("Expected the option to leave the defaults untouched")
+	}
+	if limit := DefaultConfig().Authorizations[providers.AuthorizationEstimated].CaptureLimit(store.Transaction{Amount: 100}); limit != 120 {
+		t.Errorf("Expected the default limit of 120, got %v", limit)
+	}
+}
+
+func TestEstimatedAuthorization_Rejected(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stub")})
+
+	request := stubRequest("stub")
+	request.AuthorizationType = providers.AuthorizationEstimated
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_REQUEST" {
+		t.Errorf("Expected INVALID_REQUEST for a provider without captures, got %v", err)
+	}
+
+	request.AuthorizationType = "incremental"
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_REQUEST" {
+		t.Errorf("Expected INVALID_REQUEST for an unknown authorization type, got %v", err)
+	}
+}
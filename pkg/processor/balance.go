@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// amounts closer than this are equal, absorbs float rounding of summed parts
+const amountTolerance = 1e-6
+
+// checkCapture holds a capture against the authorized payment: same
+// currency, and no more than what is left uncaptured of the limit of its
+// authorization type
+func (p *PaymentProcessor) checkCapture(captureRequest providers.CaptureRequest, previous []store.Capture) *providers.PaymentError {
+	tx, ok := p.originalTransaction(captureRequest.TransactionID)
+	if !ok {
+		return nil
+	}
+	if mismatch := currencyMismatch("capture", tx, captureRequest.Currency); mismatch != nil {
+		return mismatch
+	}
+
+	remaining := p.captureLimit(tx) - capturedAmount(previous)
+	return exceedsRemaining("capture", captureRequest.Amount, remaining, tx.Currency)
+}
+
+// checkRefund holds a refund against the payment: same currency, and no more
+// than was captured, or charged for sales, minus earlier refunds
+func (p *PaymentProcessor) checkRefund(refundRequest providers.RefundRequest) *providers.PaymentError {
+	tx, ok := p.originalTransaction(refundRequest.TransactionID)
+	if !ok {
+		return nil
+	}
+	if mismatch := currencyMismatch("refund", tx, refundRequest.Currency); mismatch != nil {
+		return mismatch
+	}
+
+	return exceedsRemaining("refund", refundRequest.Amount, p.refundableAmount(tx), tx.Currency)
+}
+
+// refundableAmount is what was captured, or charged for sales, minus the
+// refunds so far
+func (p *PaymentProcessor) refundableAmount(tx store.Transaction) float64 {
+	refundable := tx.Amount
+	if captures, _ := p.GetCaptures(tx.ID); len(captures) > 0 {
+		refundable = capturedAmount(captures)
+	}
+	if p.config.Refunds != nil {
+		for _, refund := range p.config.Refunds.ForTransaction(tx.ID) {
+			refundable -= refund.Amount
+		}
+	}
+	return refundable
+}
+
+// originalTransaction finds the stored payment; without a record nothing
+// can be checked and the provider has the final say
+func (p *PaymentProcessor) originalTransaction(transactionID string) (store.Transaction, bool) {
+	if p.config.Transactions == nil {
+		return store.Transaction{}, false
+	}
+	tx, err := p.config.Transactions.Get(transactionID)
+	return tx, err == nil
+}
+
+func capturedAmount(captures []store.Capture) float64 {
+	var captured float64
+	for _, capture := range captures {
+		if capture.Status == providers.StatusApproved {
+			captured += capture.Amount
+		}
+	}
+	return captured
+}
+
+func currencyMismatch(operation string, tx store.Transaction, currency string) *providers.PaymentError {
+	if strings.EqualFold(tx.Currency, currency) {
+		return nil
+	}
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "CURRENCY_MISMATCH",
+		ErrorMessage: fmt.Sprintf("%s currency %s does not match payment currency %s", operation, currency, tx.Currency),
+	}
+}
+
+func exceedsRemaining(operation string, amount, remaining float64, currency string) *providers.PaymentError {
+	if amount <= remaining+amountTolerance {
+		return nil
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "AMOUNT_EXCEEDS_REMAINING",
+		ErrorMessage: fmt.Sprintf("%s of %.2f %s exceeds the remaining %.2f %s", operation, amount, currency, remaining, currency),
+	}
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/visa"
+	"pgas/pkg/store"
+)
+
+func newBalanceProcessor(t *testing.T) *PaymentProcessor {
+	t.Helper()
+	processor := NewPaymentProcessor([]providers.Provider{visa.GetNewVisaPaymentProvider()})
+	processor.Transactions().Save(store.Transaction{ID: "TX1", Provider: "visa", Status: providers.StatusApproved, Amount: 100, Currency: "USD"})
+	return processor
+}
+
+func TestCapturePayment_Balance(t *testing.T) {
+	processor := newBalanceProcessor(t)
+	ctx := context.Background()
+
+	capture := providers.CaptureRequest{Mode: "visa", TransactionID: "TX1", Amount: 60, Currency: "EUR"}
+	if _, err := processor.CapturePayment(ctx, capture); err == nil || err.ErrorCode != "CURRENCY_MISMATCH" {
+		t.Errorf("Expected CURRENCY_MISMATCH, got %v", err)
+	}
+
+	capture.Currency = "usd"
+	if _, err := processor.CapturePayment(ctx, capture); err != nil {
+		t.Fatalf("Expected capture within the authorization, got %v", err)
+	}
+
+	capture.Amount = 40.01
+	if _, err := processor.CapturePayment(ctx, capture); err == nil || err.ErrorCode != "AMOUNT_EXCEEDS_REMAINING" {
+		t.Errorf("Expected AMOUNT_EXCEEDS_REMAINING, got %v", err)
+	}
+
+	capture.Amount = 40
+	if _, err := processor.CapturePayment(ctx, capture); err != nil {
+		t.Errorf("Expected capture of the remaining amount, got %v", err)
+	}
+
+	// payments the processor has no record of are left to the provider
+	unknown := providers.CaptureRequest{Mode: "visa", TransactionID: "TX9", Amount: 500, Currency: "EUR"}
+	if _, err := processor.CapturePayment(ctx, unknown); err != nil {
+		t.Errorf("Expected unknown payments to be passed on, got %v", err)
+	}
+}
+
+func TestRefundPayment_Balance(t *testing.T) {
+	processor := newBalanceProcessor(t)
+	ctx := context.Background()
+
+	refund := providers.RefundRequest{Mode: "visa", TransactionID: "TX1", Amount: 30, Currency: "GBP"}
+	if _, err := processor.RefundPayment(ctx, refund); err == nil || err.ErrorCode != "CURRENCY_MISMATCH" {
+		t.Errorf("Expected CURRENCY_MISMATCH, got %v", err)
+	}
+
+	refund.Currency = "USD"
+	if _, err := processor.RefundPayment(ctx, refund); err != nil {
+		t.Fatalf("Expected partial refund, got %v", err)
+	}
+
+	refund.Amount = 70.5
+	if _, err := processor.RefundPayment(ctx, refund); err == nil || err.ErrorCode != "AMOUNT_EXCEEDS_REMAINING" {
+		t.Errorf("Expected AMOUNT_EXCEEDS_REMAINING after an earlier refund, got %v", err)
+	}
+}
+
+func TestRefundPayment_BalanceOfCaptures(t *testing.T) {
+	processor := newBalanceProcessor(t)
+	ctx := context.Background()
+
+	processor.CapturePayment(ctx, providers.CaptureRequest{Mode: "visa", TransactionID: "TX1", Amount: 25, Currency: "USD", Final: true})
+
+	refund := providers.RefundRequest{Mode: "visa", TransactionID: "TX1", Amount: 30, Currency: "USD"}
+	if _, err := processor.RefundPayment(ctx, refund); err == nil || err.ErrorCode != "AMOUNT_EXCEEDS_REMAINING" {
+		t.Errorf("Expected refunds to be limited to the captured amount, got %v", err)
+	}
+
+	refund.Amount = 25
+	if _, err := processor.RefundPayment(ctx, refund); err != nil {
+		t.Errorf("Expected refund of the captured amount, got %v", err)
+	}
+}
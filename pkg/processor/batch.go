@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"context"
+	"sync"
+
+	"pgas/pkg/providers"
+)
+
+// BatchResult is the outcome of one item of a batch, Index is its position
+// in the submitted batch. Results are delivered as items complete, not in
+// submission order.
+type BatchResult struct {
+	Index    int                        `json:"index"`
+	Response *providers.PaymentResponse `json:"response,omitempty"`
+	Refund   *providers.RefundResponse  `json:"refund,omitempty"`
+	Error    *providers.PaymentError    `json:"error,omitempty"`
+}
+
+func batchCancelled() *providers.PaymentError {
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "BATCH_CANCELLED",
+		ErrorMessage: "the batch was cancelled before this item was submitted",
+	}
+}
+
+// ProcessBatch processes payments with up to concurrency of them in flight
+// and streams each result as soon as it is known. The channel is closed once
+// every item has a result and must be drained by the caller; items not
+// started when ctx is done are reported as BATCH_CANCELLED.
+func (p *PaymentProcessor) ProcessBatch(ctx context.Context, requests []providers.PaymentRequest, concurrency int) <-chan BatchResult {
+	return runBatch(ctx, len(requests), concurrency, func(i int) BatchResult {
+		response, paymentError := p.ProcessPayment(ctx, requests[i])
+		return BatchResult{Index: i, Response: response, Error: paymentError}
+	})
+}
+
+// RefundBatch refunds payments like ProcessBatch processes them
+func (p *PaymentProcessor) RefundBatch(ctx context.Context, refunds []providers.RefundRequest, concurrency int) <-chan BatchResult {
+	return runBatch(ctx, len(refunds), concurrency, func(i int) BatchResult {
+		response, paymentError := p.RefundPayment(ctx, refunds[i])
+		return BatchResult{Index: i, Refund: response, Error: paymentError}
+	})
+}
+
+func runBatch(ctx context.Context, size, concurrency int, run func(i int) BatchResult) <-chan BatchResult {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make(chan BatchResult, concurrency)
+	go func() {
+		defer close(results)
+
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, concurrency)
+		for i := 0; i < size; i++ {
+			select {
+			case <-ctx.Done():
+			case slots <- struct{}{}:
+			}
+			if ctx.Err() != nil {
+				for ; i < size; i++ {
+					results <- BatchResult{Index: i, Error: batchCancelled()}
+				}
+				break
+			}
+
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				defer func() { <-slots }()
+				results <- run(i)
+			}(i)
+		}
+		wg.Wait()
+	}()
+	return results
+}
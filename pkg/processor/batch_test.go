@@ -0,0 +1,58 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestProcessBatch(t *testing.T) {
+	stub := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(stub))
+
+	requests := []providers.PaymentRequest{stubRequest("stub"), stubRequest("missing"), stubRequest("stub")}
+	seen := make(map[int]BatchResult)
+	for result := range processor.ProcessBatch(context.Background(), requests, 2) {
+		seen[result.Index] = result
+	}
+
+	if len(seen) != 3 || stub.callCount() != 2 {
+		t.Fatalf("Expected a result per item and two gateway calls, got %+v", seen)
+	}
+	if seen[0].Response == nil || seen[2].Response == nil {
+		t.Errorf("Expected items 0 and 2 to succeed, got %+v", seen)
+	}
+	if seen[1].Error == nil || seen[1].Error.ErrorCode != "INVALID_PROVIDER" {
+		t.Errorf("Expected item 1 to fail with INVALID_PROVIDER, got %+v", seen[1])
+	}
+}
+
+func TestProcessBatch_Cancelled(t *testing.T) {
+	stub := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(stub))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cancelled := 0
+	for result := range processor.ProcessBatch(ctx, []providers.PaymentRequest{stubRequest("stub"), stubRequest("stub")}, 1) {
+		if result.Error != nil && result.Error.ErrorCode == "BATCH_CANCELLED" {
+			cancelled++
+		}
+	}
+	if cancelled != 2 || stub.callCount() != 0 {
+		t.Errorf("Expected both items cancelled before submission, got %d cancelled and %d calls", cancelled, stub.callCount())
+	}
+}
+
+func TestRefundBatch(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	refunds := []providers.RefundRequest{{Mode: "stub", TransactionID: "stub-tx", Amount: 10, Currency: "USD"}}
+	for result := range processor.RefundBatch(context.Background(), refunds, 4) {
+		if result.Error == nil || result.Error.ErrorCode != "REFUND_NOT_SUPPORTED" {
+			t.Errorf("Expected the refund error per item, got %+v", result)
+		}
+	}
+}
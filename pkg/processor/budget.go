@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"fmt"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// latencyBudget tracks a caller supplied deadline for a whole payment. Each
+// stage before the gateway call may use its share of the total, the gateway
+// attempts split whatever is left. A nil budget imposes no limits.
+type latencyBudget struct {
+	total      time.Duration
+	deadline   time.Time
+	stageStart time.Time
+	shares     BudgetShares
+	now        func() time.Time
+}
+
+func newLatencyBudget(budgetMs int, shares BudgetShares) *latencyBudget {
+	if budgetMs <= 0 {
+		return nil
+	}
+
+	start := time.Now()
+	total := time.Duration(budgetMs) * time.Millisecond
+	return &latencyBudget{
+		total:      total,
+		deadline:   start.Add(total),
+		stageStart: start,
+		shares:     shares,
+		now:        time.Now,
+	}
+}
+
+func (b *latencyBudget) share(stage string) float64 {
+	switch stage {
+	case "validation":
+		return b.shares.Validation
+	case "fraud":
+		return b.shares.Fraud
+	}
+	return 0
+}
+
+// stageDeadline is when the running stage must finish; stages without a
+// share are only bound by the overall deadline
+func (b *latencyBudget) stageDeadline(stage string) time.Time {
+	share := b.share(stage)
+	if share <= 0 {
+		return b.deadline
+	}
+
+	deadline := b.stageStart.Add(time.Duration(float64(b.total) * share))
+	if deadline.After(b.deadline) {
+		return b.deadline
+	}
+	return deadline
+}
+
+// checkStage ends a stage, failing the payment when it overran its share
+func (b *latencyBudget) checkStage(stage string) *providers.PaymentError {
+	if b == nil {
+		return nil
+	}
+
+	now := b.now()
+	overran := now.After(b.stageDeadline(stage))
+	b.stageStart = now
+
+	if overran {
+		return budgetExceeded(stage)
+	}
+	return nil
+}
+
+// stageTimeout bounds a call made during the running stage: the time left
+// of the stage's share, never above the processor default. A negative value
+// means the share is spent.
+func (b *latencyBudget) stageTimeout(stage string, defaultTimeout time.Duration) time.Duration {
+	if b == nil {
+		return defaultTimeout
+	}
+
+	left := b.stageDeadline(stage).Sub(b.now())
+	if left <= 0 {
+		return -1
+	}
+	if defaultTimeout > 0 && defaultTimeout < left {
+		return defaultTimeout
+	}
+	return left
+}
+
+func (b *latencyBudget) remaining() time.Duration {
+	return b.deadline.Sub(b.now())
+}
+
+// attemptTimeout is the timeout for the next gateway attempt: the remaining
+// budget split evenly over the attempts left, never above the processor
+// default. A negative value means the budget is spent.
+func (b *latencyBudget) attemptTimeout(defaultTimeout time.Duration, attemptsLeft int) time.Duration {
+	if b == nil {
+		return defaultTimeout
+	}
+
+	remaining := b.remaining()
+	if remaining <= 0 {
+		return -1
+	}
+
+	timeout := remaining / time.Duration(attemptsLeft)
+	if defaultTimeout > 0 && defaultTimeout < timeout {
+		return defaultTimeout
+	}
+	return timeout
+}
+
+// allows reports whether waiting for wait still leaves time for another attempt
+func (b *latencyBudget) allows(wait time.Duration) bool {
+	return b == nil || b.remaining() > wait
+}
+
+func budgetExceeded(stage string) *providers.PaymentError {
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "LATENCY_BUDGET_EXCEEDED",
+		ErrorMessage: fmt.Sprintf("latency budget exhausted during %s", stage),
+	}
+}
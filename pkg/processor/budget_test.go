@@ -0,0 +1,185 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"pgas/pkg/fraud"
+	"pgas/pkg/providers"
+)
+
+// slowProvider waits for the call context to expire, or fails after delay
+// when one is set, and records the timeout each attempt was given
+type slowProvider struct {
+	*stubProvider
+	delay time.Duration
+
+	mu       sync.Mutex
+	timeouts []time.Duration
+}
+
+func (s *slowProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	deadline, _ := ctx.Deadline()
+	s.mu.Lock()
+	s.timeouts = append(s.timeouts, time.Until(deadline))
+	s.mu.Unlock()
+
+	var delayed <-chan time.Time
+	if s.delay > 0 {
+		delayed = time.After(s.delay)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, &providers.PaymentError{ErrorCode: "TIMEOUT", ErrorMessage: ctx.Err().Error(), Retryable: true}
+	case <-delayed:
+		return nil, &providers.PaymentError{ErrorCode: "GATEWAY_BUSY", ErrorMessage: "try again", Retryable: true}
+	}
+}
+
+func (s *slowProvider) attemptTimeouts() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]time.Duration(nil), s.timeouts...)
+}
+
+func TestProcessPayment_LatencyBudgetShrinksAttempts(t *testing.T) {
+	provider := &slowProvider{stubProvider: newStubProvider("stub"), delay: 10 * time.Millisecond}
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithDefaultTimeout(time.Second),
+		WithRetryPolicy(RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			Retryable:   func(*providers.PaymentError) bool { return true },
+		}),
+	)
+
+	request := stubRequest("stub")
+	request.LatencyBudgetMs = 150
+
+	start := time.Now()
+	_, err := processor.ProcessPayment(context.Background(), request)
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("Expected slow provider to fail")
+	}
+
+	if elapsed > 250*time.Millisecond {
+		t.Errorf("Expected overall deadline of 150ms to be honored, took %v", elapsed)
+	}
+
+	timeouts := provider.attemptTimeouts()
+	if len(timeouts) < 2 {
+		t.Fatalf("Expected retries within the budget, got %d attempts", len(timeouts))
+	}
+
+	if timeouts[0] > 60*time.Millisecond {
+		t.Errorf("Expected first attempt to get a third of the budget, got %v", timeouts[0])
+	}
+}
+
+func TestProcessPayment_NoLatencyBudgetUsesDefaultTimeout(t *testing.T) {
+	provider := &slowProvider{stubProvider: newStubProvider("stub")}
+	processor := NewPaymentProcessor(nil, WithProviders(provider), WithDefaultTimeout(20*time.Millisecond))
+
+	if response, _ := processor.ProcessPayment(context.Background(), stubRequest("stub")); response == nil || response.Status != providers.StatusUnknown {
+		t.Fatalf("Expected the timed out call to leave the payment UNKNOWN, got %+v", response)
+	}
+
+	if timeouts := provider.attemptTimeouts(); len(timeouts) != 1 || timeouts[0] < 15*time.Millisecond {
+		t.Errorf("Expected a single attempt with the default timeout, got %v", timeouts)
+	}
+}
+
+func TestLatencyBudget_StageOverrun(t *testing.T) {
+	budget := newLatencyBudget(100, BudgetShares{Validation: 0.1})
+	now := budget.stageStart
+	budget.now = func() time.Time { return now }
+
+	now = now.Add(5 * time.Millisecond)
+	if err := budget.checkStage("validation"); err != nil {
+		t.Fatalf("Expected validation within its share to pass, got %v", err)
+	}
+
+	now = now.Add(20 * time.Millisecond)
+	err := budget.checkStage("validation")
+	if err == nil || err.ErrorCode != "LATENCY_BUDGET_EXCEEDED" {
+		t.Fatalf("Expected LATENCY_BUDGET_EXCEEDED, got %v", err)
+	}
+
+	now = budget.deadline
+	if budget.attemptTimeout(time.Second, 1) >= 0 {
+		t.Error("Expected spent budget to report a negative timeout")
+	}
+}
+
+// sleepingFraudProvider allows every payment after a delay it does not cut
+// short when the context ends
+type sleepingFraudProvider time.Duration
+
+func (s sleepingFraudProvider) Assess(ctx context.Context, request fraud.Request) (*fraud.Assessment, error) {
+	time.Sleep(time.Duration(s))
+	return &fraud.Assessment{Decision: fraud.Allow}, nil
+}
+
+func TestProcessPayment_LatencyBudgetFraudShare(t *testing.T) {
+	provider := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithFraudPolicy(FraudPolicy{Provider: sleepingFraudProvider(30 * time.Millisecond)}),
+		WithBudgetShares(BudgetShares{Validation: 0.5, Fraud: 0.05}),
+	)
+
+	request := stubRequest("stub")
+	request.LatencyBudgetMs = 200
+
+	_, err := processor.ProcessPayment(context.Background(), request)
+	if err == nil || err.ErrorCode != "LATENCY_BUDGET_EXCEEDED" {
+		t.Fatalf("Expected fraud check overrunning its share to fail, got %v", err)
+	}
+
+	if provider.callCount() != 0 {
+		t.Errorf("Expected no gateway call after the budget overran, got %d", provider.callCount())
+	}
+}
+
+// deadlineFraudProvider records the timeout the fraud check was given
+type deadlineFraudProvider struct {
+	timeout time.Duration
+}
+
+func (d *deadlineFraudProvider) Assess(ctx context.Context, request fraud.Request) (*fraud.Assessment, error) {
+	deadline, _ := ctx.Deadline()
+	d.timeout = time.Until(deadline)
+	return &fraud.Assessment{Decision: fraud.Allow}, nil
+}
+
+func TestProcessPayment_FraudCheckTimeoutFromBudget(t *testing.T) {
+	checker := &deadlineFraudProvider{}
+	processor := NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub")),
+		WithDefaultTimeout(time.Second),
+		WithFraudPolicy(FraudPolicy{Provider: checker}),
+		WithBudgetShares(BudgetShares{Validation: 0.1, Fraud: 0.2}),
+	)
+
+	request := stubRequest("stub")
+	request.LatencyBudgetMs = 500
+	if _, err := processor.ProcessPayment(context.Background(), request); err != nil {
+		t.Fatalf("Expected payment to succeed, got error: %v", err)
+	}
+
+	if checker.timeout <= 0 || checker.timeout > 100*time.Millisecond {
+		t.Errorf("Expected the fraud check to get its 100ms share, got %v", checker.timeout)
+	}
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Fatalf("Expected payment to succeed, got error: %v", err)
+	}
+	if checker.timeout < 900*time.Millisecond {
+		t.Errorf("Expected the default timeout without a budget, got %v", checker.timeout)
+	}
+}
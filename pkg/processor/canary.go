@@ -0,0 +1,180 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+)
+
+// CanaryPolicy controls how a reloaded provider configuration is rolled out
+type CanaryPolicy struct {
+	// Percent of the provider's payments (0..100) sent through the new
+	// configuration, zero swaps it in right away
+	Percent float64 `json:"percent"`
+	// MinAttempts is the number of canary payments needed before the error
+	// rate is judged
+	MinAttempts int `json:"min_attempts"`
+	// MaxErrorRate (0..1) of gateway errors on the canary that triggers a
+	// rollback. Card declines do not count, only failures to process.
+	MaxErrorRate float64 `json:"max_error_rate"`
+	// PromoteAfter healthy canary payments the new configuration replaces
+	// the old one for all traffic
+	PromoteAfter int `json:"promote_after"`
+}
+
+func (c CanaryPolicy) Validate() error {
+	switch {
+	case c.Percent < 0 || c.Percent > 100:
+		return errors.New("canary percent must be within [0, 100]")
+	case c.Percent == 0:
+		return nil
+	case c.MaxErrorRate <= 0 || c.MaxErrorRate > 1:
+		return errors.New("canary max error rate must be within (0, 1]")
+	case c.MinAttempts < 1:
+		return errors.New("canary min attempts must be positive")
+	case c.PromoteAfter < c.MinAttempts:
+		return errors.New("canary must not be promoted before min attempts are reached")
+	}
+	return nil
+}
+
+// CanaryStatus reports the progress of a running canary
+type CanaryStatus struct {
+	Provider string       `json:"provider"`
+	Policy   CanaryPolicy `json:"policy"`
+	Attempts int          `json:"attempts"`
+	Errors   int          `json:"errors"`
+	Since    time.Time    `json:"since"`
+}
+
+type canary struct {
+	candidate providers.Provider
+	status    CanaryStatus
+}
+
+// ReloadProvider replaces the configuration of a registered provider, e.g.
+// after its endpoint or credentials changed. With a canary percent the new
+// provider only gets that share of payments at first: it is promoted once
+// PromoteAfter payments went through, and rolled back with an alert.firing
+// event when its gateway error rate reaches MaxErrorRate. Reloading while a
+// canary runs replaces that canary.
+func (p *PaymentProcessor) ReloadProvider(candidate providers.Provider, policy CanaryPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	name := candidate.GetName()
+
+	// checked under canaryMu so DeregisterProvider cannot slip in between
+	p.canaryMu.Lock()
+	defer p.canaryMu.Unlock()
+
+	if _, err := p.getProvider(name); err != nil {
+		return fmt.Errorf("provider '%s' is not registered", name)
+	}
+	p.applyDecoding(candidate)
+
+	if policy.Percent == 0 {
+		p.canaries.Delete(name)
+		p.replaceProviders(candidate)
+		return nil
+	}
+
+	p.canaries.Store(name, &canary{
+		candidate: candidate,
+		status:    CanaryStatus{Provider: name, Policy: policy, Since: time.Now()},
+	})
+	return nil
+}
+
+// Canary returns the status of the canary running for a provider
+func (p *PaymentProcessor) Canary(name string) (CanaryStatus, bool) {
+	p.canaryMu.Lock()
+	defer p.canaryMu.Unlock()
+
+	c, ok := p.canaries.Load(name)
+	if !ok {
+		return CanaryStatus{}, false
+	}
+	return c.(*canary).status, true
+}
+
+// canaryArm picks the configuration serving a payment. report must be called
+// with the outcome so the canary can be judged; gateway failures and
+// payments left UNKNOWN count against it.
+func (p *PaymentProcessor) canaryArm(ctx context.Context, stable providers.Provider) (providers.Provider, func(*providers.PaymentResponse, *providers.PaymentError)) {
+	loaded, ok := p.canaries.Load(stable.GetName())
+	if !ok {
+		return stable, func(*providers.PaymentResponse, *providers.PaymentError) {}
+	}
+
+	// the policy is fixed once the canary is stored, only its counts change
+	c := loaded.(*canary)
+	if rand.Float64()*100 >= c.status.Policy.Percent {
+		return stable, func(*providers.PaymentResponse, *providers.PaymentError) {}
+	}
+
+	return c.candidate, func(response *providers.PaymentResponse, paymentError *providers.PaymentError) {
+		unknown := response != nil && response.Status == providers.StatusUnknown
+		p.judgeCanary(ctx, c, unknown || gatewayFailure(paymentError))
+	}
+}
+
+func (p *PaymentProcessor) judgeCanary(ctx context.Context, c *canary, failed bool) {
+	p.canaryMu.Lock()
+	defer p.canaryMu.Unlock()
+
+	name := c.status.Provider
+	if current, _ := p.canaries.Load(name); current != c {
+		// rolled back, promoted or replaced meanwhile
+		return
+	}
+
+	c.status.Attempts++
+	if failed {
+		c.status.Errors++
+	}
+
+	policy := c.status.Policy
+	rate := float64(c.status.Errors) / float64(c.status.Attempts)
+	switch {
+	case c.status.Attempts >= policy.MinAttempts && rate >= policy.MaxErrorRate:
+		p.canaries.Delete(name)
+		p.publish(ctx, events.Event{
+			Type:     events.TypeAlertFiring,
+			Time:     time.Now(),
+			Provider: name,
+			Data: events.Encode(events.AlertData{
+				Rule:  "canary_rollback",
+				Value: rate,
+				Message: fmt.Sprintf("%s configuration rolled back: error rate %.1f%% over %d canary payments (threshold %.1f%%)",
+					name, rate*100, c.status.Attempts, policy.MaxErrorRate*100),
+			}),
+		})
+
+	case c.status.Attempts >= policy.PromoteAfter:
+		p.canaries.Delete(name)
+		p.replaceProviders(c.candidate)
+		p.publish(ctx, events.Event{
+			Type:     events.TypeProviderPromoted,
+			Time:     time.Now(),
+			Provider: name,
+			Data:     events.Encode(events.ProviderPromotedData{Attempts: c.status.Attempts, Errors: c.status.Errors}),
+		})
+	}
+}
+
+// gatewayFailure tells failures to process a payment apart from declines,
+// only the former point at a broken configuration. The errors are known
+// before submission or answered by the gateway, the card was not charged.
+func gatewayFailure(paymentError *providers.PaymentError) bool {
+	if paymentError == nil {
+		return false
+	}
+	return paymentError.ErrorCode == "PROCESSING_ERROR" || paymentError.Reason == providers.ReasonProcessingError
+}
@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+)
+
+func TestReloadProvider_Promotes(t *testing.T) {
+	stable := newStubProvider("stub")
+	candidate := newStubProvider("stub")
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil, WithProviders(stable), WithEventPublisher(publisher))
+
+	policy := CanaryPolicy{Percent: 100, MinAttempts: 2, MaxErrorRate: 0.5, PromoteAfter: 3}
+	if err := processor.ReloadProvider(candidate, policy); err != nil {
+		t.Fatalf("Expected reload to be accepted, got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+			t.Fatalf("Expected payment to succeed, got %v", err)
+		}
+	}
+	if _, ok := processor.Canary("stub"); ok {
+		t.Error("Expected the canary to be finished")
+	}
+
+	// the candidate now serves all traffic
+	processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if stable.callCount() != 0 || candidate.callCount() != 4 {
+		t.Errorf("Expected all calls on the candidate, got stable=%d candidate=%d", stable.callCount(), candidate.callCount())
+	}
+
+	promoted := publisher.Events()
+	if len(promoted) != 1 || promoted[0].Type != events.TypeProviderPromoted || promoted[0].Data["attempts"] != "3" {
+		t.Errorf("Expected a promotion event, got %+v", promoted)
+	}
+}
+
+func TestReloadProvider_RollsBack(t *testing.T) {
+	stable := newStubProvider("stub")
+	candidate := newStubProvider("stub", &providers.PaymentError{ErrorCode: "PROCESSING_ERROR", ErrorMessage: "bad credentials"})
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil, WithProviders(stable), WithEventPublisher(publisher))
+
+	policy := CanaryPolicy{Percent: 100, MinAttempts: 2, MaxErrorRate: 0.5, PromoteAfter: 10}
+	if err := processor.ReloadProvider(candidate, policy); err != nil {
+		t.Fatalf("Expected reload to be accepted, got %v", err)
+	}
+
+	processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	status, ok := processor.Canary("stub")
+	if !ok || status.Attempts != 1 || status.Errors != 1 {
+		t.Fatalf("Expected one failed canary attempt, got %+v", status)
+	}
+
+	processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if _, ok := processor.Canary("stub"); ok {
+		t.Fatal("Expected the canary to be rolled back")
+	}
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Errorf("Expected the previous configuration to serve payments, got %v", err)
+	}
+	if stable.callCount() != 1 || candidate.callCount() != 2 {
+		t.Errorf("Unexpected calls: stable=%d candidate=%d", stable.callCount(), candidate.callCount())
+	}
+
+	alerts := publisher.Events()
+	if len(alerts) != 1 || alerts[0].Type != events.TypeAlertFiring || alerts[0].Data["rule"] != "canary_rollback" || alerts[0].Provider != "stub" {
+		t.Errorf("Expected a rollback alert, got %+v", alerts)
+	}
+}
+
+func TestReloadProvider_DeclinesDoNotCount(t *testing.T) {
+	candidate := newStubProvider("stub", &providers.PaymentError{ErrorCode: "51", Reason: providers.ReasonInsufficientFunds})
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	processor.ReloadProvider(candidate, CanaryPolicy{Percent: 100, MinAttempts: 1, MaxErrorRate: 0.1, PromoteAfter: 5})
+	processor.ProcessPayment(context.Background(), stubRequest("stub"))
+
+	status, ok := processor.Canary("stub")
+	if !ok || status.Attempts != 1 || status.Errors != 0 {
+		t.Errorf("Expected a healthy canary attempt, got %+v", status)
+	}
+}
+
+func TestReloadProvider_Immediate(t *testing.T) {
+	candidate := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	if err := processor.ReloadProvider(candidate, CanaryPolicy{}); err != nil {
+		t.Fatalf("Expected reload to be accepted, got %v", err)
+	}
+	processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if candidate.callCount() != 1 {
+		t.Error("Expected the new configuration to be used right away")
+	}
+}
+
+func TestReloadProvider_Invalid(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	if err := processor.ReloadProvider(newStubProvider("other"), CanaryPolicy{}); err == nil {
+		t.Error("Expected unregistered providers to be rejected")
+	}
+	if err := processor.ReloadProvider(newStubProvider("stub"), CanaryPolicy{Percent: 10}); err == nil {
+		t.Error("Expected a canary without error threshold to be rejected")
+	}
+	if err := processor.ReloadProvider(newStubProvider("stub"), CanaryPolicy{Percent: 10, MinAttempts: 5, MaxErrorRate: 0.2, PromoteAfter: 2}); err == nil {
+		t.Error("Expected promotion before min attempts to be rejected")
+	}
+}
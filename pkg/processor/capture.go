@@ -0,0 +1,124 @@
+package processor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// CapturePayment captures part or all of an authorized payment through the
+// provider that authorized it. Each capture is stored as its own record with
+// the gateway's reference, see GetCaptures. Captures of stored payments must
+// match their currency and stay within the uncaptured amount. Captures keep
+// working in read-only mode.
+func (p *PaymentProcessor) CapturePayment(ctx context.Context, captureRequest providers.CaptureRequest) (*providers.CaptureResponse, *providers.PaymentError) {
+	paymentProvider, err := p.getProvider(captureRequest.Mode)
+	if err != nil {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_PROVIDER",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	if err := captureRequest.Validate(); err != nil {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	capturer, ok := paymentProvider.(providers.Capturer)
+	if !ok {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "CAPTURE_NOT_SUPPORTED",
+			ErrorMessage: "provider '" + paymentProvider.GetName() + "' does not support captures",
+		}
+	}
+
+	previous, _ := p.GetCaptures(captureRequest.TransactionID)
+	for _, capture := range previous {
+		if capture.Final && capture.Status == providers.StatusApproved {
+			return nil, &providers.PaymentError{
+				Success:      false,
+				ErrorCode:    "ALREADY_CAPTURED",
+				ErrorMessage: "payment '" + captureRequest.TransactionID + "' had its final capture as " + capture.ID,
+			}
+		}
+	}
+
+	if balanceError := p.checkCapture(captureRequest, previous); balanceError != nil {
+		return nil, balanceError
+	}
+
+	if timeout := p.providerTimeout(paymentProvider.GetName()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	record := store.Capture{
+		ID:            newCaptureID(),
+		TransactionID: captureRequest.TransactionID,
+		Provider:      paymentProvider.GetName(),
+		Sequence:      len(previous) + 1,
+		Final:         captureRequest.Final,
+		Amount:        captureRequest.Amount,
+		Currency:      captureRequest.Currency,
+		CreatedAt:     time.Now(),
+	}
+
+	gatewayRequest := captureRequest
+	gatewayRequest.TransactionID = p.gatewayTransactionID(captureRequest.TransactionID)
+	captureResponse, err := capturer.Capture(ctx, gatewayRequest)
+	if err != nil {
+		record.Status = providers.StatusDeclined
+		record.ErrorCode = "CAPTURE_FAILED"
+		p.recordCapture(record)
+
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "CAPTURE_FAILED",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	record.GatewayReference = captureResponse.CaptureID
+	record.Status = captureResponse.Status
+	record.Amount = captureResponse.Amount
+	record.Currency = captureResponse.Currency
+	p.recordCapture(record)
+
+	return captureResponse, nil
+}
+
+// GetCaptures lists the captures of a transaction in the order they were
+// made, declined captures included
+func (p *PaymentProcessor) GetCaptures(transactionID string) ([]store.Capture, error) {
+	if p.config.Captures == nil {
+		return nil, nil
+	}
+	return p.config.Captures.ForTransaction(transactionID)
+}
+
+func (p *PaymentProcessor) recordCapture(capture store.Capture) {
+	if p.config.Captures == nil {
+		return
+	}
+	p.config.Captures.Save(capture)
+}
+
+func newCaptureID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "cap_" + hex.EncodeToString(b)
+}
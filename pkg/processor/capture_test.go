@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/visa"
+)
+
+func TestCapturePayment_RecordsEachCapture(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{visa.GetNewVisaPaymentProvider()})
+
+	capture := providers.CaptureRequest{Mode: "visa", TransactionID: "TX1", Amount: 40, Currency: "USD"}
+	if _, err := processor.CapturePayment(context.Background(), capture); err != nil {
+		t.Fatalf("Expected first capture to succeed, got %v", err)
+	}
+
+	capture.Amount, capture.Final = 60, true
+	response, err := processor.CapturePayment(context.Background(), capture)
+	if err != nil {
+		t.Fatalf("Expected final capture to succeed, got %v", err)
+	}
+
+	captures, _ := processor.GetCaptures("TX1")
+	if len(captures) != 2 {
+		t.Fatalf("Expected two capture records, got %+v", captures)
+	}
+	if captures[0].Sequence != 1 || captures[0].Amount != 40 || captures[1].Sequence != 2 || captures[1].Amount != 60 || !captures[1].Final {
+		t.Errorf("Expected captures in sequence with their amounts, got %+v", captures)
+	}
+	if captures[1].GatewayReference != response.CaptureID || captures[0].GatewayReference == captures[1].GatewayReference {
+		t.Errorf("Expected each capture to keep its own gateway reference, got %+v", captures)
+	}
+	if captures[0].Status != providers.StatusApproved || captures[0].ID == "" {
+		t.Errorf("Expected an approved capture with a local id, got %+v", captures[0])
+	}
+
+	capture.Amount = 1
+	if _, err := processor.CapturePayment(context.Background(), capture); err == nil || err.ErrorCode != "ALREADY_CAPTURED" {
+		t.Errorf("Expected ALREADY_CAPTURED after the final capture, got %v", err)
+	}
+
+	if other, _ := processor.GetCaptures("TX2"); len(other) != 0 {
+		t.Errorf("Expected no captures for another transaction, got %+v", other)
+	}
+}
+
+func TestCapturePayment_NotSupported(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	_, err := processor.CapturePayment(context.Background(), providers.CaptureRequest{
+		Mode:          "stub",
+		TransactionID: "stub-tx",
+		Amount:        10,
+		Currency:      "USD",
+	})
+	if err == nil || err.ErrorCode != "CAPTURE_NOT_SUPPORTED" {
+		t.Errorf("Expected CAPTURE_NOT_SUPPORTED, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package processor
+
+import (
+	"pgas/pkg/providers"
+)
+
+// outcome counted for payments rejected without a decline reason, i.e.
+// invalid requests and processing errors
+const outcomeFailed = "FAILED"
+
+func (p *PaymentProcessor) countOutcome(response *providers.PaymentResponse, paymentError *providers.PaymentError) {
+	p.outcomes.Get(paymentOutcome(response, paymentError)).Inc()
+}
+
+// paymentOutcome is the status of an answered payment, DECLINED for
+// declines and FAILED for payments rejected without a decline reason
+func paymentOutcome(response *providers.PaymentResponse, paymentError *providers.PaymentError) string {
+	switch {
+	case response != nil:
+		return response.Status
+	case paymentError.Reason == "" || paymentError.Reason == providers.ReasonProcessingError:
+		return outcomeFailed
+	}
+	return providers.StatusDeclined
+}
+
+// countCall counts a gateway call of a provider by its outcome
+func (p *PaymentProcessor) countCall(name string, response *providers.PaymentResponse, paymentError *providers.PaymentError) {
+	p.callOutcomes.Get(name + "|" + callOutcome(response, paymentError)).Inc()
+}
+
+// callOutcome is the status of an answered call, FAILED for gateway errors
+// and unreadable answers and DECLINED otherwise
+func callOutcome(response *providers.PaymentResponse, paymentError *providers.PaymentError) string {
+	switch {
+	case response != nil:
+		return response.Status
+	case gatewayFailure(paymentError):
+		return outcomeFailed
+	}
+	return providers.StatusDeclined
+}
+
+// Counters returns the number of payments per outcome since the processor
+// started: the normalized status of answered payments, DECLINED for
+// declines and FAILED for invalid requests and processing errors. Counting
+// is sharded so it never serializes concurrent payments.
+func (p *PaymentProcessor) Counters() map[string]int64 {
+	return p.outcomes.Snapshot()
+}
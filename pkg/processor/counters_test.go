@@ -0,0 +1,84 @@
+package processor
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestCounters(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(
+		newStubProvider("ok"),
+		newStubProvider("declining", &providers.PaymentError{ErrorCode: "51", Reason: providers.ReasonInsufficientFunds}),
+		newStubProvider("broken", &providers.PaymentError{ErrorCode: "PROCESSING_ERROR"}),
+	))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			processor.ProcessPayment(context.Background(), stubRequest("ok"))
+		}()
+	}
+	wg.Wait()
+	processor.ProcessPayment(context.Background(), stubRequest("declining"))
+	processor.ProcessPayment(context.Background(), stubRequest("broken"))
+	processor.ProcessPayment(context.Background(), stubRequest("missing"))
+
+	counters := processor.Counters()
+	if counters[providers.StatusApproved] != 20 || counters[providers.StatusDeclined] != 1 || counters["FAILED"] != 2 {
+		t.Errorf("Unexpected counters: %v", counters)
+	}
+}
+
+// BenchmarkProcessPayment measures the payment path without gateway latency,
+// run with -cpu 1,2,4,8 to see it scale across cores. The in-memory
+// transaction store keeps one LRU order and takes a lock per write, the
+// "bookkeeping" case leaves it out to show the processor's own overhead.
+func BenchmarkProcessPayment(b *testing.B) {
+	cases := map[string][]Option{
+		"bookkeeping":  {WithTransactionStore(nil)},
+		"memory_store": nil,
+	}
+
+	for name, opts := range cases {
+		b.Run(name, func(b *testing.B) {
+			processor := NewPaymentProcessor(nil, append(opts, WithProviders(newBenchProvider("stub")))...)
+			request := stubRequest("stub")
+
+			b.ReportAllocs()
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					// Fatal must not be called from RunParallel goroutines
+					if _, err := processor.ProcessPayment(context.Background(), request); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// benchProvider approves every payment without any shared state, so the
+// benchmark measures the processor rather than the stub
+type benchProvider struct {
+	*stubProvider
+}
+
+func newBenchProvider(name string) benchProvider {
+	return benchProvider{stubProvider: newStubProvider(name)}
+}
+
+func (b benchProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	return &providers.PaymentResponse{
+		Success:       true,
+		TransactionID: b.name + "-tx",
+		Status:        providers.StatusApproved,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"pgas/pkg/providers"
+)
+
+// checkPriorTransaction makes sure a merchant-initiated payment references an
+// approved customer-initiated payment with the same card. The initial payment
+// must have been processed by this processor.
+func (p *PaymentProcessor) checkPriorTransaction(paymentReqest providers.PaymentRequest) *providers.PaymentError {
+	if paymentReqest.InitiatedBy != providers.InitiatedByMerchant || p.config.Transactions == nil {
+		return nil
+	}
+
+	invalid := func(message string) *providers.PaymentError {
+		return &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: message,
+		}
+	}
+
+	prior, err := p.config.Transactions.Get(paymentReqest.PriorTransactionID)
+	if err != nil {
+		return invalid("prior transaction '" + paymentReqest.PriorTransactionID + "' not found")
+	}
+
+	switch {
+	case prior.InitiatedBy == providers.InitiatedByMerchant:
+		return invalid("prior transaction must be customer-initiated")
+	case !providers.IsSuccessStatus(prior.Status):
+		return invalid("prior transaction was not approved")
+	case len(paymentReqest.CardNumber) < 4 || prior.Last4 != paymentReqest.CardNumber[len(paymentReqest.CardNumber)-4:]:
+		return invalid("prior transaction was made with a different card")
+	}
+	return nil
+}
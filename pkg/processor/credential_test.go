@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+func TestProcessPayment_MerchantInitiated(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	transactions.Save(store.Transaction{ID: "cit_1", Status: providers.StatusApproved, Last4: "1111",
+		InitiatedBy: providers.InitiatedByCustomer, StoredCredentialUsage: providers.StoredCredentialFirst})
+	transactions.Save(store.Transaction{ID: "cit_declined", Status: providers.StatusDeclined, Last4: "1111",
+		InitiatedBy: providers.InitiatedByCustomer, StoredCredentialUsage: providers.StoredCredentialFirst})
+	transactions.Save(store.Transaction{ID: "mit_1", Status: providers.StatusApproved, Last4: "1111",
+		InitiatedBy: providers.InitiatedByMerchant, StoredCredentialUsage: providers.StoredCredentialSubsequent})
+	transactions.Save(store.Transaction{ID: "cit_other_card", Status: providers.StatusApproved, Last4: "4444",
+		InitiatedBy: providers.InitiatedByCustomer, StoredCredentialUsage: providers.StoredCredentialFirst})
+
+	stub := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(stub), WithTransactionStore(transactions))
+
+	mit := func(prior string) providers.PaymentRequest {
+		request := stubRequest("stub")
+		request.CVV = ""
+		request.InitiatedBy = providers.InitiatedByMerchant
+		request.StoredCredentialUsage = providers.StoredCredentialSubsequent
+		request.PriorTransactionID = prior
+		return request
+	}
+
+	response, err := processor.ProcessPayment(context.Background(), mit("cit_1"))
+	if err != nil {
+		t.Fatalf("Expected MIT referencing an approved CIT to succeed, got: %v", err)
+	}
+	if received := stub.received(); received.InitiatedBy != providers.InitiatedByMerchant || received.PriorTransactionID != "cit_1" {
+		t.Errorf("Expected MIT indicators to reach the provider, got %+v", received)
+	}
+	if tx, _ := transactions.Get(response.TransactionID); tx.PriorTransactionID != "cit_1" {
+		t.Errorf("Expected prior transaction to be recorded, got %+v", tx)
+	}
+
+	cases := map[string]string{
+		"":               "not found",
+		"missing":        "not found",
+		"cit_declined":   "not approved",
+		"mit_1":          "customer-initiated",
+		"cit_other_card": "different card",
+	}
+	for prior, message := range cases {
+		_, err := processor.ProcessPayment(context.Background(), mit(prior))
+		if err == nil || err.ErrorCode != "INVALID_REQUEST" || !strings.Contains(err.ErrorMessage, message) {
+			t.Errorf("Prior %q: expected INVALID_REQUEST mentioning %q, got %v", prior, message, err)
+		}
+	}
+
+	if stub.callCount() != 1 {
+		t.Errorf("Expected invalid MITs not to reach the provider, got %d calls", stub.callCount())
+	}
+}
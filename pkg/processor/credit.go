@@ -0,0 +1,168 @@
+package processor
+
+import (
+	"context"
+	"errors"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+	"pgas/pkg/validation"
+)
+
+// CreditPolicy gates standalone credits, see Credit. Credits stay disabled
+// until an Authorizer is configured.
+type CreditPolicy struct {
+	// Authorizer accepts or rejects the actor and credential of a credit,
+	// returning nil authorizes it
+	Authorizer func(request providers.CreditRequest) error
+	// MaxAmount is the largest single credit, 0 for no limit
+	MaxAmount float64
+}
+
+var (
+	errCreditsNotEnabled = errors.New("credits are not enabled on this processor")
+	errCreditsNotAudited = errors.New("credits require an audit log")
+)
+
+// Credit pushes funds to a card without an earlier payment, through a
+// provider implementing providers.Creditor. Credits are refused unless the
+// processor has an audit log and a CreditPolicy authorizer, and the
+// authorizer accepts the request's actor. Every credit is audited: when it
+// is rejected, before the gateway is called, and with its outcome, so a
+// credit in flight during a crash still leaves a trace. The card number is
+// written as the redaction policy allows for logs.
+func (p *PaymentProcessor) Credit(ctx context.Context, creditRequest providers.CreditRequest) (*providers.CreditResponse, *providers.PaymentError) {
+	details := map[string]string{
+		"mode":     creditRequest.Mode,
+		"amount":   formatAmount(creditRequest.Amount),
+		"currency": creditRequest.Currency,
+		"card":     p.config.Redaction.Apply(redact.SinkLogs, redact.FieldCardNumber, creditRequest.CardNumber),
+	}
+	if creditRequest.Reference != "" {
+		details["reference"] = creditRequest.Reference
+	}
+	audited := func(action, key, value string) audit.Entry {
+		entryDetails := make(map[string]string, len(details)+1)
+		for k, v := range details {
+			entryDetails[k] = v
+		}
+		if key != "" {
+			entryDetails[key] = value
+		}
+		return audit.Entry{Actor: creditRequest.Actor, Action: action, Reference: creditRequest.Reference, Details: entryDetails}
+	}
+	reject := func(paymentError *providers.PaymentError) (*providers.CreditResponse, *providers.PaymentError) {
+		paymentError.ErrorMessage = redact.Scrub(paymentError.ErrorMessage, creditRequest.CardNumber, "")
+		p.recordAudit(audited("credit.rejected", "reason", paymentError.ErrorMessage))
+		return nil, paymentError
+	}
+
+	if p.config.AuditLog == nil {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "CREDITS_NOT_ENABLED",
+			ErrorMessage: errCreditsNotAudited.Error(),
+			Err:          errCreditsNotAudited,
+		}
+	}
+	if p.config.Credits.Authorizer == nil {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "CREDITS_NOT_ENABLED",
+			ErrorMessage: errCreditsNotEnabled.Error(),
+			Err:          errCreditsNotEnabled,
+		})
+	}
+
+	if p.ReadOnly() {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "READ_ONLY_MODE",
+			ErrorMessage: "processor is in read-only mode, credits are not accepted",
+		})
+	}
+
+	validationError := creditRequest.Validate()
+	if validationError == nil {
+		validationError = validation.ValidateCardNumber(creditRequest.CardNumber)
+	}
+	if validationError != nil {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: validationError.Error(),
+			Err:          validationError,
+		})
+	}
+
+	if authErr := p.config.Credits.Authorizer(creditRequest); authErr != nil {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "UNAUTHORIZED_CREDIT",
+			ErrorMessage: authErr.Error(),
+			Err:          authErr,
+		})
+	}
+
+	if limit := p.config.Credits.MaxAmount; limit > 0 && creditRequest.Amount > limit {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "CREDIT_LIMIT_EXCEEDED",
+			ErrorMessage: "credits are limited to " + formatAmount(limit) + " " + creditRequest.Currency,
+		})
+	}
+
+	paymentProvider, err := p.getProvider(creditRequest.Mode)
+	if err != nil {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_PROVIDER",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		})
+	}
+	creditor, ok := paymentProvider.(providers.Creditor)
+	if !ok {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "CREDIT_NOT_SUPPORTED",
+			ErrorMessage: "provider '" + paymentProvider.GetName() + "' does not support credits",
+		})
+	}
+	if p.Draining(paymentProvider.GetName()) {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "PROVIDER_DRAINING",
+			ErrorMessage: "provider '" + paymentProvider.GetName() + "' is being drained for maintenance",
+			Reason:       providers.ReasonProcessingError,
+		})
+	}
+
+	p.recordAudit(audited("credit.requested", "", ""))
+
+	if timeout := p.providerTimeout(paymentProvider.GetName()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	creditResponse, err := creditor.Credit(ctx, creditRequest)
+	if err == nil && !creditResponse.Success {
+		err = errors.New("the gateway declined the credit")
+	}
+	if err != nil {
+		message := redact.Scrub(err.Error(), creditRequest.CardNumber, "")
+		p.recordAudit(audited("credit.failed", "error", message))
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "CREDIT_FAILED",
+			ErrorMessage: message,
+			Err:          err,
+		}
+	}
+
+	creditResponse.Provider = paymentProvider.GetName()
+	p.recordAudit(audited("credit.completed", "credit_id", creditResponse.CreditID))
+	return creditResponse, nil
+}
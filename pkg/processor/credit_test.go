@@ -0,0 +1,99 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/providers"
+)
+
+// creditingProvider accepts every credit
+type creditingProvider struct {
+	*stubProvider
+	credits int
+}
+
+func (c *creditingProvider) Credit(ctx context.Context, request providers.CreditRequest) (*providers.CreditResponse, error) {
+	c.credits++
+	return &providers.CreditResponse{Success: true, CreditID: "credit-1", Status: providers.StatusApproved, Amount: request.Amount, Currency: request.Currency}, nil
+}
+
+func creditRequest(mode string) providers.CreditRequest {
+	return providers.CreditRequest{Mode: mode, Amount: 50, Currency: "USD", CardNumber: "4111111111111111", Actor: "ops", Token: "secret", Reference: "claim-7"}
+}
+
+func TestCredit(t *testing.T) {
+	provider := &creditingProvider{stubProvider: newStubProvider("stub")}
+	log := audit.NewMemoryLog()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider, newStubProvider("other")),
+		WithAuditLog(log),
+		WithCredits(CreditPolicy{
+			Authorizer: func(request providers.CreditRequest) error {
+				if request.Token != "secret" {
+					return errors.New("invalid credit token")
+				}
+				return nil
+			},
+			MaxAmount: 500,
+		}),
+	)
+
+	response, err := processor.Credit(context.Background(), creditRequest("stub"))
+	if err != nil || response.CreditID != "credit-1" || response.Provider != "stub" {
+		t.Fatalf("Expected the credit to be made, got %+v (%v)", response, err)
+	}
+
+	requested, completed := log.Filter("credit.requested"), log.Filter("credit.completed")
+	if len(requested) != 1 || len(completed) != 1 || completed[0].Details["credit_id"] != "credit-1" || completed[0].Reference != "claim-7" {
+		t.Fatalf("Expected the credit audited before and after the gateway call, got %+v", log.Entries())
+	}
+	if card := requested[0].Details["card"]; card != "411111******1111" {
+		t.Errorf("Expected the card number truncated in the audit log, got %s", card)
+	}
+
+	rejected := []struct {
+		name   string
+		modify func(*providers.CreditRequest)
+		code   string
+	}{
+		{"bad token", func(r *providers.CreditRequest) { r.Token = "guess" }, "UNAUTHORIZED_CREDIT"},
+		{"over limit", func(r *providers.CreditRequest) { r.Amount = 501 }, "CREDIT_LIMIT_EXCEEDED"},
+		{"invalid card", func(r *providers.CreditRequest) { r.CardNumber = "4111111111111112" }, "INVALID_REQUEST"},
+		{"no actor", func(r *providers.CreditRequest) { r.Actor = "" }, "INVALID_REQUEST"},
+		{"no credits", func(r *providers.CreditRequest) { r.Mode = "other" }, "CREDIT_NOT_SUPPORTED"},
+	}
+	for _, test := range rejected {
+		request := creditRequest("stub")
+		test.modify(&request)
+		if _, err := processor.Credit(context.Background(), request); err == nil || err.ErrorCode != test.code {
+			t.Errorf("%s: expected %s, got %v", test.name, test.code, err)
+		}
+	}
+	if provider.credits != 1 {
+		t.Errorf("Expected rejected credits not to reach the gateway, got %d credits", provider.credits)
+	}
+	if len(log.Filter("credit.rejected")) != len(rejected) {
+		t.Errorf("Expected every rejection audited, got %+v", log.Filter("credit.rejected"))
+	}
+}
+
+func TestCredit_DisabledByDefault(t *testing.T) {
+	provider := &creditingProvider{stubProvider: newStubProvider("stub")}
+
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+	if _, err := processor.Credit(context.Background(), creditRequest("stub")); err == nil || err.ErrorCode != "CREDITS_NOT_ENABLED" {
+		t.Errorf("Expected credits to need an audit log, got %v", err)
+	}
+
+	log := audit.NewMemoryLog()
+	processor = NewPaymentProcessor(nil, WithProviders(provider), WithAuditLog(log))
+	if _, err := processor.Credit(context.Background(), creditRequest("stub")); err == nil || err.ErrorCode != "CREDITS_NOT_ENABLED" {
+		t.Errorf("Expected credits to need an authorizer, got %v", err)
+	}
+	if provider.credits != 0 || len(log.Filter("credit.rejected")) != 1 {
+		t.Errorf("Expected the attempt audited and not sent, got %d credits and %+v", provider.credits, log.Entries())
+	}
+}
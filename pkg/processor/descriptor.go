@@ -0,0 +1,28 @@
+package processor
+
+import "pgas/pkg/providers"
+
+// renderDescriptor fills the statement descriptor template with the order
+// data of the payment. The rendered descriptor is what providers receive and
+// what the transaction record keeps as dispute evidence.
+func (p *PaymentProcessor) renderDescriptor(paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	if paymentReqest.Descriptor == "" {
+		paymentReqest.Descriptor = p.config.Descriptor
+	}
+	if paymentReqest.Descriptor == "" {
+		return paymentReqest, nil
+	}
+
+	descriptor, err := providers.RenderDescriptor(paymentReqest.Descriptor, paymentReqest.OrderData)
+	if err != nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_DESCRIPTOR",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	paymentReqest.Descriptor = descriptor
+	return paymentReqest, nil
+}
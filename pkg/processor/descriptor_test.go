@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/merchant"
+)
+
+func TestProcessPayment_DescriptorTemplate(t *testing.T) {
+	registry := merchant.NewRegistry()
+	registry.AddMerchant(merchant.Merchant{ID: "plat_1"})
+	if err := registry.AddSubMerchant(merchant.SubMerchant{ID: "sub_1", PlatformID: "plat_1", Descriptor: "SHOP*{order_id}", Active: true}); err != nil {
+		t.Fatalf("Expected descriptor template to be accepted, got: %v", err)
+	}
+
+	provider := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithMerchantRegistry(registry),
+		WithDescriptor("ACME*{order_id}"),
+	)
+
+	cases := map[string]string{"sub_1": "SHOP*A1001", "": "ACME*A1001"}
+	for subMerchantID, expected := range cases {
+		request := stubRequest("stub")
+		request.SubMerchantID = subMerchantID
+		request.OrderData = map[string]string{"order_id": "A1001"}
+
+		response, err := processor.ProcessPayment(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Expected successful payment, got error: %v", err)
+		}
+
+		if got := provider.received().Descriptor; got != expected {
+			t.Errorf("Expected provider to receive descriptor '%s', got '%s'", expected, got)
+		}
+		if tx, _ := processor.Transactions().Get(response.TransactionID); tx.Descriptor != expected {
+			t.Errorf("Expected rendered descriptor '%s' on the record, got '%s'", expected, tx.Descriptor)
+		}
+	}
+}
+
+func TestProcessPayment_InvalidDescriptor(t *testing.T) {
+	provider := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(provider), WithDescriptor("ACME*{order_id}"))
+
+	cases := map[string]map[string]string{
+		"missing order data": nil,
+		"too long":           {"order_id": "ORDER-0000000000000001"},
+		"invalid character":  {"order_id": "<A1>"},
+	}
+	for name, orderData := range cases {
+		request := stubRequest("stub")
+		request.OrderData = orderData
+
+		if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_DESCRIPTOR" {
+			t.Errorf("%s: expected INVALID_DESCRIPTOR, got: %v", name, err)
+		}
+	}
+
+	if provider.callCount() != 0 {
+		t.Errorf("Expected no provider calls for invalid descriptors, got %d", provider.callCount())
+	}
+}
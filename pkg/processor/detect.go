@@ -0,0 +1,38 @@
+package processor
+
+import (
+	"pgas/pkg/bin"
+	"pgas/pkg/providers"
+)
+
+// detectProvider fills in the Mode of card payments that leave it blank,
+// picking the provider of the network the card's BIN belongs to. A Mode set
+// by the caller, a forced provider or an installment plan always wins.
+func (p *PaymentProcessor) detectProvider(paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	if paymentReqest.Mode != "" || paymentReqest.CardNumber == "" {
+		return paymentReqest, nil
+	}
+
+	network, ok := p.binTable().Detect(paymentReqest.CardNumber)
+	if !ok {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "UNKNOWN_CARD_NETWORK",
+			ErrorMessage: "card number belongs to no known network, set mode to choose the provider",
+			Reason:       providers.ReasonInvalidCard,
+		}
+	}
+
+	paymentReqest.Mode = network
+	if provider, ok := p.config.NetworkProviders[network]; ok {
+		paymentReqest.Mode = provider
+	}
+	return paymentReqest, nil
+}
+
+func (p *PaymentProcessor) binTable() *bin.Table {
+	if p.config.BINs == nil {
+		return bin.Default
+	}
+	return p.config.BINs
+}
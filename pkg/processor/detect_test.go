@@ -0,0 +1,68 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/bin"
+)
+
+func TestProcessPayment_DetectsProviderFromBIN(t *testing.T) {
+	visa := newStubProvider("visa")
+	mastercard := newStubProvider("mastercard")
+	acquirer := newStubProvider("acquirer")
+	processor := NewPaymentProcessor(nil,
+		WithProviders(visa, mastercard, acquirer),
+		WithNetworkProviders(map[string]string{bin.Amex: "acquirer"}),
+	)
+
+	testCases := []struct {
+		name       string
+		mode       string
+		cardNumber string
+		provider   *stubProvider
+	}{
+		{"visa BIN", "", "4111111111111111", visa},
+		{"2-series mastercard BIN", "", "2223003122003222", mastercard},
+		{"network mapped to another provider", "", "378282246310005", acquirer},
+		{"explicit mode wins", "mastercard", "4111111111111111", mastercard},
+	}
+
+	for _, tc := range testCases {
+		request := stubRequest(tc.mode)
+		request.CardNumber = tc.cardNumber
+
+		before := tc.provider.callCount()
+		if _, err := processor.ProcessPayment(context.Background(), request); err != nil {
+			t.Errorf("%s: expected no error, got %+v", tc.name, err)
+		}
+		if tc.provider.callCount() != before+1 {
+			t.Errorf("%s: expected %s to be charged", tc.name, tc.provider.GetName())
+		}
+	}
+
+	request := stubRequest("")
+	request.CardNumber = "9999999999999995"
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "UNKNOWN_CARD_NETWORK" {
+		t.Errorf("Expected UNKNOWN_CARD_NETWORK, got %+v", err)
+	}
+
+	request.CardNumber = "6011111111111117"
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_PROVIDER" {
+		t.Errorf("Expected INVALID_PROVIDER for a network without provider, got %+v", err)
+	}
+}
+
+func TestProcessPayment_CustomBINTable(t *testing.T) {
+	local := newStubProvider("local")
+	processor := NewPaymentProcessor(nil,
+		WithProviders(local),
+		WithBINTable(bin.NewTable(bin.Range{Low: "9792", High: "9792", Network: "local"})),
+	)
+
+	request := stubRequest("")
+	request.CardNumber = "9792000000000001"
+	if _, err := processor.ProcessPayment(context.Background(), request); err != nil || local.callCount() != 1 {
+		t.Errorf("Expected the custom range to pick the provider, got %+v", err)
+	}
+}
@@ -0,0 +1,153 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// interval at which Drain checks for in-flight calls
+const drainPollInterval = 10 * time.Millisecond
+
+// DrainReport tells operators whether a drained provider can be taken down,
+// e.g. to rotate its credentials or close the gateway connection
+type DrainReport struct {
+	Provider string `json:"provider"`
+	// Resolved lists payments whose PENDING or UNKNOWN status was settled
+	// through status queries while draining
+	Resolved []string `json:"resolved,omitempty"`
+	// Unresolved lists payments whose outcome the provider could not confirm
+	Unresolved []string      `json:"unresolved,omitempty"`
+	Waited     time.Duration `json:"waited"`
+	// SafeToRotate is set once nothing is in flight and no payment waits for
+	// the provider's answer
+	SafeToRotate bool `json:"safe_to_rotate"`
+}
+
+// Drain takes a provider out of service gracefully: new payments to it are
+// rejected with PROVIDER_DRAINING and routers no longer see it, payments
+// already admitted are awaited and make no further gateway attempts, and
+// payments still PENDING or UNKNOWN are resolved through status queries. The provider stays drained until Resume; an
+// error is returned when ctx ends before the in-flight calls finished.
+func (p *PaymentProcessor) Drain(ctx context.Context, name string) (DrainReport, error) {
+	if _, err := p.getProvider(name); err != nil {
+		return DrainReport{}, err
+	}
+
+	p.draining.Store(name, true)
+
+	started := time.Now()
+	report := DrainReport{Provider: name}
+
+	for p.inFlight(name) > 0 {
+		select {
+		case <-ctx.Done():
+			report.Waited = time.Since(started)
+			return report, ctx.Err()
+		case <-time.After(drainPollInterval):
+		}
+	}
+
+	report.Resolved, report.Unresolved = p.settlePending(ctx, name)
+	report.Waited = time.Since(started)
+	report.SafeToRotate = len(report.Unresolved) == 0 && p.inFlight(name) == 0
+	return report, nil
+}
+
+// Resume puts a drained provider back into service
+func (p *PaymentProcessor) Resume(name string) {
+	p.draining.Delete(name)
+}
+
+// Draining reports whether a provider is being drained
+func (p *PaymentProcessor) Draining(name string) bool {
+	_, draining := p.draining.Load(name)
+	return draining
+}
+
+// admit counts a payment against its provider from the drain check until
+// release runs, so Drain also waits for payments still being screened,
+// checked for fraud, authenticated or retried. admitted is false when the
+// provider is being drained; counting before the check means a concurrent
+// Drain either sees the payment or is seen by it.
+func (p *PaymentProcessor) admit(name string) (release func(), admitted bool) {
+	payments := p.payments.Get(name)
+	payments.Inc()
+	if p.Draining(name) {
+		payments.Add(-1)
+		return nil, false
+	}
+	return func() { payments.Add(-1) }, true
+}
+
+func drainingError(name string) *providers.PaymentError {
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "PROVIDER_DRAINING",
+		ErrorMessage: "provider '" + name + "' is being drained for maintenance",
+		Reason:       providers.ReasonProcessingError,
+	}
+}
+
+// trackCall counts a gateway call as in flight until the returned func
+// runs, and keeps its payment for in-doubt reports meanwhile
+func (p *PaymentProcessor) trackCall(name string, paymentReqest providers.PaymentRequest) func() {
+	calls := p.calls.Get(name)
+	calls.Inc()
+
+	payment := &InDoubtPayment{
+		Provider:       name,
+		Status:         StatusInFlight,
+		Amount:         paymentReqest.Amount,
+		Currency:       paymentReqest.Currency,
+		IdempotencyKey: paymentReqest.IdempotencyKey,
+		OrderID:        paymentReqest.OrderData["order_id"],
+		Since:          time.Now(),
+	}
+	p.pendingCalls.Store(payment, struct{}{})
+
+	return func() {
+		p.pendingCalls.Delete(payment)
+		calls.Add(-1)
+	}
+}
+
+// inFlight counts the provider's admitted payments, their gateway calls
+// included
+func (p *PaymentProcessor) inFlight(name string) int64 {
+	return p.payments.Get(name).Load()
+}
+
+// settlePending queries the final status of the provider's payments that
+// are still waiting for the gateway
+func (p *PaymentProcessor) settlePending(ctx context.Context, name string) (resolved, unresolved []string) {
+	paymentProvider, err := p.getProvider(name)
+	if err != nil || p.config.Transactions == nil {
+		return nil, nil
+	}
+
+	for _, status := range []string{providers.StatusPending, providers.StatusUnknown} {
+		pending, err := p.config.Transactions.Query(store.TransactionFilter{Provider: name, Status: status})
+		if err != nil {
+			continue
+		}
+
+		for _, tx := range pending {
+			response := p.queryStatus(ctx, paymentProvider, gatewayReference(tx))
+			if response == nil || response.Status == providers.StatusPending {
+				unresolved = append(unresolved, tx.ID)
+				continue
+			}
+
+			p.unresolvedMu.Lock()
+			delete(p.unresolved, tx.ID)
+			p.unresolvedMu.Unlock()
+
+			p.updateTransactionStatus(tx.ID, response.Status, "resolved while draining")
+			resolved = append(resolved, tx.ID)
+		}
+	}
+	return resolved, unresolved
+}
@@ -0,0 +1,149 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/fraud"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// blockingProvider holds gateway calls until released and answers status
+// queries with APPROVED
+type blockingProvider struct {
+	*stubProvider
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	b.started <- struct{}{}
+	<-b.release
+	return b.stubProvider.ProcessPayment(ctx, request)
+}
+
+func (b *blockingProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return &providers.PaymentResponse{Success: true, TransactionID: transactionID, Status: providers.StatusApproved}, nil
+}
+
+func TestDrain(t *testing.T) {
+	provider := &blockingProvider{stubProvider: newStubProvider("stub"), started: make(chan struct{}), release: make(chan struct{})}
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider, newStubProvider("other")),
+		WithStatusQueryPolicy(StatusQueryPolicy{MaxAttempts: 1}),
+	)
+	processor.Transactions().Save(store.Transaction{ID: "pending-tx", Provider: "stub", Status: providers.StatusPending, CreatedAt: time.Now()})
+
+	inFlight := make(chan *providers.PaymentError)
+	go func() {
+		_, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+		inFlight <- err
+	}()
+	<-provider.started
+
+	reports := make(chan DrainReport)
+	go func() {
+		report, _ := processor.Drain(context.Background(), "stub")
+		reports <- report
+	}()
+
+	// wait for the drain to take effect before submitting more
+	for !processor.Draining("stub") {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err == nil || err.ErrorCode != "PROVIDER_DRAINING" {
+		t.Errorf("Expected new payments to be rejected while draining, got %v", err)
+	}
+
+	select {
+	case <-reports:
+		t.Fatal("Expected drain to wait for the in-flight payment")
+	case <-time.After(3 * drainPollInterval):
+	}
+
+	close(provider.release)
+	if err := <-inFlight; err != nil {
+		t.Errorf("Expected the in-flight payment to complete, got %v", err)
+	}
+
+	report := <-reports
+	if !report.SafeToRotate || len(report.Resolved) != 1 || report.Resolved[0] != "pending-tx" {
+		t.Errorf("Expected a safe drain resolving pending-tx, got %+v", report)
+	}
+	if tx, _ := processor.Transactions().Get("pending-tx"); tx.Status != providers.StatusApproved {
+		t.Errorf("Expected the pending payment to be resolved, got %s", tx.Status)
+	}
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("other")); err != nil {
+		t.Errorf("Expected other providers to keep working, got %v", err)
+	}
+
+	processor.Resume("stub")
+	if processor.Draining("stub") {
+		t.Error("Expected the provider to be back in service")
+	}
+}
+
+func TestDrain_Timeout(t *testing.T) {
+	provider := &blockingProvider{stubProvider: newStubProvider("stub"), started: make(chan struct{}), release: make(chan struct{})}
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+	defer close(provider.release)
+
+	go processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	<-provider.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*drainPollInterval)
+	defer cancel()
+
+	if report, err := processor.Drain(ctx, "stub"); err == nil || report.SafeToRotate {
+		t.Errorf("Expected drain to give up while a call is in flight, got %+v", report)
+	}
+	if _, err := processor.Drain(context.Background(), "missing"); err == nil {
+		t.Error("Expected an unknown provider to be rejected")
+	}
+}
+
+// blockingFraudProvider holds fraud checks until released, then allows them
+type blockingFraudProvider struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingFraudProvider) Assess(ctx context.Context, request fraud.Request) (*fraud.Assessment, error) {
+	b.started <- struct{}{}
+	<-b.release
+	return &fraud.Assessment{Decision: fraud.Allow}, nil
+}
+
+func TestDrain_WaitsForPaymentsBeforeTheGateway(t *testing.T) {
+	provider := newStubProvider("stub")
+	checks := &blockingFraudProvider{started: make(chan struct{}), release: make(chan struct{})}
+	processor := NewPaymentProcessor(nil, WithProviders(provider), WithFraudProvider(checks))
+
+	payment := make(chan *providers.PaymentError)
+	go func() {
+		_, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+		payment <- err
+	}()
+	<-checks.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*drainPollInterval)
+	defer cancel()
+	if report, err := processor.Drain(ctx, "stub"); err == nil || report.SafeToRotate {
+		t.Fatalf("Expected drain to wait for the payment in the fraud check, got %+v", report)
+	}
+
+	close(checks.release)
+	if err := <-payment; err == nil || err.ErrorCode != "PROVIDER_DRAINING" {
+		t.Errorf("Expected the payment to stop before the drained gateway, got %v", err)
+	}
+	if provider.callCount() != 0 {
+		t.Errorf("Expected no call to the drained provider, got %d", provider.callCount())
+	}
+
+	if report, err := processor.Drain(context.Background(), "stub"); err != nil || !report.SafeToRotate {
+		t.Errorf("Expected a safe drain once the payment finished, got %+v, %v", report, err)
+	}
+}
@@ -0,0 +1,33 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+)
+
+// applyDecoding hands the configured decoding to providers that support it.
+// Without an OnDrift callback, drift found in warn mode is published as a
+// provider.schema_drift event so format changes on the gateway side show up
+// before they break parsing.
+func (p *PaymentProcessor) applyDecoding(provider providers.Provider) {
+	setter, ok := provider.(providers.DecodingSetter)
+	if !ok {
+		return
+	}
+
+	decoding := p.config.Decoding
+	if decoding.OnDrift == nil {
+		decoding.OnDrift = func(drift providers.Drift) {
+			p.publish(context.Background(), events.Event{
+				Type:     events.TypeProviderDrift,
+				Time:     time.Now(),
+				Provider: drift.Provider,
+				Data:     events.Encode(events.ProviderDriftData{Fields: drift.Fields}),
+			})
+		}
+	}
+	setter.SetDecoding(decoding)
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+)
+
+// driftingProvider answers with a field its parser does not know
+type driftingProvider struct {
+	*stubProvider
+	decoding providers.Decoding
+}
+
+func (d *driftingProvider) SetDecoding(decoding providers.Decoding) {
+	d.decoding = decoding
+}
+
+func (d *driftingProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	var parsed providers.PaymentResponse
+	payload := map[string]interface{}{"transaction_id": d.name + "-tx", "status": "APPROVED", "surcharge": "0.50"}
+	if err := d.decoding.Decode(d.name, payload, &parsed); err != nil {
+		return nil, err
+	}
+	parsed.Success = true
+	return &parsed, nil
+}
+
+func TestProcessPayment_DriftPublished(t *testing.T) {
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(&driftingProvider{stubProvider: newStubProvider("stub")}),
+		WithEventPublisher(publisher),
+	)
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Fatalf("Expected drift to be a warning only, got: %v", err)
+	}
+
+	published := publisher.Events()
+	if len(published) != 1 || published[0].Type != events.TypeProviderDrift {
+		t.Fatalf("Expected one drift event, got %+v", published)
+	}
+	if published[0].Provider != "stub" || published[0].Data["fields"] != "surcharge" {
+		t.Errorf("Expected drift of stub.surcharge, got %+v", published[0])
+	}
+}
+
+func TestProcessPayment_StrictDecodingRejectsDrift(t *testing.T) {
+	processor := NewPaymentProcessor(nil,
+		WithProviders(&driftingProvider{stubProvider: newStubProvider("stub")}),
+		WithDecoding(providers.Decoding{Mode: providers.DecodeStrict}),
+	)
+
+	// the gateway has the payment, an answer strict decoding rejects leaves
+	// it in doubt
+	response, _ := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if response == nil || response.Success || response.Status != providers.StatusUnknown || response.RawStatus != "PARSING_ERROR" {
+		t.Fatalf("Expected strict decoding to leave the payment UNKNOWN, got %+v", response)
+	}
+}
@@ -0,0 +1,46 @@
+package processor
+
+import (
+	"context"
+
+	"pgas/pkg/providers"
+)
+
+// Enricher adds computed fields to a normalized payment response, e.g.
+// loyalty points earned, an internal ledger id or a localized status text.
+// It runs after the provider answered and before the response is stored and
+// returned, so this logic stays out of provider code. Enrichers set entries
+// of response.Extra and must not change the payment outcome; failures are
+// theirs to handle since the payment has already been processed.
+type Enricher func(ctx context.Context, req providers.PaymentRequest, response *providers.PaymentResponse)
+
+func (p *PaymentProcessor) enrich(ctx context.Context, paymentReqest providers.PaymentRequest, response *providers.PaymentResponse) {
+	if len(p.config.Enrichers) == 0 {
+		return
+	}
+	if response.Extra == nil {
+		response.Extra = make(map[string]string)
+	}
+
+	// enrichers see the outcome but cannot rewrite it
+	success, transactionID, status := response.Success, response.TransactionID, response.Status
+	for _, enricher := range p.config.Enrichers {
+		enricher(ctx, paymentReqest, response)
+	}
+	response.Success, response.TransactionID, response.Status = success, transactionID, status
+}
+
+// updateTransactionExtra stores the enriched fields of a payment completed
+// after it was first recorded
+func (p *PaymentProcessor) updateTransactionExtra(id string, extra map[string]string) {
+	if p.config.Transactions == nil || len(extra) == 0 {
+		return
+	}
+
+	tx, err := p.config.Transactions.Get(id)
+	if err != nil {
+		return
+	}
+	tx.Extra = extra
+	p.config.Transactions.Save(tx)
+}
@@ -0,0 +1,47 @@
+package processor
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestProcessPayment_Enrichers(t *testing.T) {
+	loyalty := func(ctx context.Context, req providers.PaymentRequest, response *providers.PaymentResponse) {
+		response.Extra["loyalty_points"] = strconv.Itoa(int(req.Amount / 10))
+	}
+	localize := func(ctx context.Context, req providers.PaymentRequest, response *providers.PaymentResponse) {
+		response.Extra["status_text"] = "Zahlung genehmigt"
+		response.Status = providers.StatusDeclined // must not stick
+	}
+
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")), WithEnrichers(loyalty, localize))
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if response.Extra["loyalty_points"] != "10" || response.Extra["status_text"] != "Zahlung genehmigt" {
+		t.Errorf("Expected enriched fields, got %+v", response.Extra)
+	}
+	if response.Status != providers.StatusApproved {
+		t.Errorf("Expected enrichers not to change the outcome, got %s", response.Status)
+	}
+
+	tx, _ := processor.Transactions().Get(response.TransactionID)
+	if tx.Extra["loyalty_points"] != "10" {
+		t.Errorf("Expected enriched fields to be stored, got %+v", tx.Extra)
+	}
+}
+
+func TestProcessPayment_NoEnrichers(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	response, _ := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if response.Extra != nil {
+		t.Errorf("Expected no extra fields without enrichers, got %+v", response.Extra)
+	}
+}
@@ -0,0 +1,114 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// expiryFor returns how long tx may stay in its current status, zero meaning
+// it never expires
+func (p *PaymentProcessor) expiryFor(tx store.Transaction) time.Duration {
+	policy := p.config.Expiry
+	if expiry, ok := policy.Methods[tx.Method]; ok && tx.Method != "" {
+		return expiry
+	}
+	if expiry, ok := policy.Providers[tx.Provider]; ok {
+		return expiry
+	}
+	if tx.Status == providers.StatusRequiresAction {
+		return p.config.ActionExpiry
+	}
+	return policy.Default
+}
+
+// ExpirePayments marks PENDING and REQUIRES_ACTION payments older than their
+// expiry as EXPIRED, drops challenges still waiting for CompletePayment and
+// releases holds through the policy's Release hook. Payments settled after
+// they were queried, e.g. by a webhook or CompletePayment, are left alone.
+// It returns the expired transactions as they were before expiry.
+func (p *PaymentProcessor) ExpirePayments(ctx context.Context) []store.Transaction {
+	if p.config.Transactions == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var expired []store.Transaction
+
+	for _, status := range []string{providers.StatusPending, providers.StatusRequiresAction} {
+		stuck, err := p.config.Transactions.Query(store.TransactionFilter{Status: status})
+		if err != nil {
+			continue
+		}
+
+		for _, tx := range stuck {
+			if ctx.Err() != nil {
+				return expired
+			}
+
+			expiry := p.expiryFor(tx)
+			if expiry <= 0 || now.Sub(tx.UpdatedAt) < expiry {
+				continue
+			}
+
+			if !p.expire(tx, expiry) {
+				continue
+			}
+
+			data := events.PaymentExpiredData{PreviousStatus: tx.Status, Expiry: expiry}
+			if p.config.Expiry.Release != nil {
+				if err := p.config.Expiry.Release(ctx, tx); err != nil {
+					data.ReleaseError = err.Error()
+				}
+			}
+			p.publish(ctx, events.Event{
+				Type:          events.TypePaymentExpired,
+				Time:          now,
+				Provider:      tx.Provider,
+				TransactionID: tx.ID,
+				Data:          events.Encode(data),
+			})
+
+			expired = append(expired, tx)
+		}
+	}
+
+	return expired
+}
+
+// expire marks tx EXPIRED unless its stored record changed since tx was
+// read or its challenge is being completed, and drops the challenge. The
+// actions lock keeps CompletePayment from taking the challenge meanwhile.
+func (p *PaymentProcessor) expire(tx store.Transaction, expiry time.Duration) bool {
+	p.actionsMu.Lock()
+	defer p.actionsMu.Unlock()
+
+	if action, ok := p.actions[tx.ID]; ok && action.completing {
+		return false
+	}
+	if p.config.Transactions.CompareAndSave(tx, withStatus(tx, providers.StatusExpired, "expired in "+tx.Status+" after "+expiry.String())) != nil {
+		return false
+	}
+	delete(p.actions, tx.ID)
+	return true
+}
+
+// StartExpirySweeper expires stuck payments every interval until ctx is done
+func (p *PaymentProcessor) StartExpirySweeper(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.ExpirePayments(ctx)
+			}
+		}
+	}()
+}
@@ -0,0 +1,136 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+func TestExpirePayments(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	publisher := events.NewMemoryPublisher()
+	var released []string
+	processor := NewPaymentProcessor(nil,
+		WithTransactionStore(transactions),
+		WithEventPublisher(publisher),
+		WithActionExpiry(15*time.Minute),
+		WithExpiryPolicy(ExpiryPolicy{
+			Default:   24 * time.Hour,
+			Providers: map[string]time.Duration{"bnpl": time.Hour},
+			Methods:   map[string]time.Duration{"upi_collect": 10 * time.Minute},
+			Release: func(ctx context.Context, tx store.Transaction) error {
+				released = append(released, tx.ID)
+				if tx.ID == "upi" {
+					return errors.New("void failed")
+				}
+				return nil
+			},
+		}),
+	)
+
+	now := time.Now()
+	for _, tx := range []store.Transaction{
+		{ID: "upi", Provider: "upi", Method: "upi_collect", Status: providers.StatusPending, UpdatedAt: now.Add(-11 * time.Minute)},
+		{ID: "bnpl", Provider: "bnpl", Status: providers.StatusPending, UpdatedAt: now.Add(-30 * time.Minute)},
+		{ID: "card", Provider: "visa", Status: providers.StatusPending, UpdatedAt: now.Add(-2 * time.Hour)},
+		{ID: "action", Provider: "visa", Status: providers.StatusRequiresAction, UpdatedAt: now.Add(-2 * time.Hour)},
+		{ID: "approved", Provider: "visa", Status: providers.StatusApproved, UpdatedAt: now.Add(-48 * time.Hour)},
+	} {
+		transactions.Save(tx)
+	}
+
+	expired := processor.ExpirePayments(context.Background())
+	if len(expired) != 2 || expired[0].ID != "upi" || expired[1].ID != "action" {
+		t.Fatalf("Expected upi and action to expire, got %+v", expired)
+	}
+
+	if tx, _ := transactions.Get("action"); tx.Status != providers.StatusExpired {
+		t.Errorf("Expected REQUIRES_ACTION to expire after ActionExpiry, got %s", tx.Status)
+	}
+	if tx, _ := transactions.Get("bnpl"); tx.Status != providers.StatusPending {
+		t.Errorf("Expected bnpl to stay pending within its hour, got %s", tx.Status)
+	}
+	if len(released) != 2 {
+		t.Errorf("Expected holds of both expired payments released, got %v", released)
+	}
+
+	published := publisher.Events()
+	if len(published) != 2 || published[0].Type != events.TypePaymentExpired || published[0].Data["release_error"] != "void failed" {
+		t.Errorf("Expected expiry events reporting the release error, got %+v", published)
+	}
+
+	if again := processor.ExpirePayments(context.Background()); len(again) != 0 {
+		t.Errorf("Expected expired payments to stay expired, got %+v", again)
+	}
+}
+
+func TestExpirePayments_DropsPendingChallenge(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithExpiryPolicy(ExpiryPolicy{Default: time.Minute}))
+	processor.actions["act_1"] = pendingAction{provider: "stub", created: time.Now().Add(-time.Hour)}
+	processor.config.Transactions.Save(store.Transaction{ID: "act_1", Status: providers.StatusRequiresAction, UpdatedAt: time.Now().Add(-time.Hour)})
+
+	processor.ExpirePayments(context.Background())
+
+	if _, err := processor.CompletePayment(context.Background(), "act_1", providers.Authentication{}); err == nil || err.ErrorCode != "ACTION_NOT_FOUND" {
+		t.Errorf("Expected the expired challenge to be gone, got %v", err)
+	}
+}
+
+// racingTransactions runs settle once, right after the first query returns
+type racingTransactions struct {
+	*store.MemoryTransactions
+	settle func()
+}
+
+func (r *racingTransactions) Query(filter store.TransactionFilter) ([]store.Transaction, error) {
+	found, err := r.MemoryTransactions.Query(filter)
+	if r.settle != nil {
+		r.settle()
+		r.settle = nil
+	}
+	return found, err
+}
+
+func TestExpirePayments_SettledAfterQuery(t *testing.T) {
+	transactions := &racingTransactions{MemoryTransactions: store.NewMemoryTransactions(store.MemoryOptions{})}
+	var released []string
+	processor := NewPaymentProcessor(nil,
+		WithTransactionStore(transactions),
+		WithExpiryPolicy(ExpiryPolicy{
+			Default: time.Minute,
+			Release: func(ctx context.Context, tx store.Transaction) error {
+				released = append(released, tx.ID)
+				return nil
+			},
+		}),
+	)
+	transactions.Save(store.Transaction{ID: "tx", Provider: "visa", Status: providers.StatusPending, UpdatedAt: time.Now().Add(-time.Hour)})
+	transactions.settle = func() {
+		processor.updateTransactionStatus("tx", providers.StatusApproved, "settled by webhook")
+	}
+
+	if expired := processor.ExpirePayments(context.Background()); len(expired) != 0 {
+		t.Errorf("Expected the payment approved meanwhile to stay, got %+v", expired)
+	}
+	if tx, _ := transactions.Get("tx"); tx.Status != providers.StatusApproved {
+		t.Errorf("Expected APPROVED to stand, got %s", tx.Status)
+	}
+	if len(released) != 0 {
+		t.Errorf("Expected no hold released, got %v", released)
+	}
+}
+
+func TestExpirePayments_ChallengeBeingCompleted(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithExpiryPolicy(ExpiryPolicy{Default: time.Minute}))
+	processor.actions["act_1"] = pendingAction{provider: "stub", created: time.Now(), completing: true}
+	processor.config.Transactions.Save(store.Transaction{ID: "act_1", Status: providers.StatusRequiresAction, UpdatedAt: time.Now().Add(-time.Hour)})
+
+	if expired := processor.ExpirePayments(context.Background()); len(expired) != 0 {
+		t.Errorf("Expected the challenge being completed to stay, got %+v", expired)
+	}
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"pgas/pkg/providers"
+)
+
+// FailoverPolicy sends payments their provider failed to process on to
+// backup providers, e.g. from an acquirer whose gateway is down to another
+// one. Declines never fail over, the issuer would decline again.
+type FailoverPolicy struct {
+	// Fallbacks lists the backup providers of each provider, tried in order
+	Fallbacks map[string][]string
+	// Eligible decides whether a failure fails over; nil fails over on
+	// gateway failures only. Do-not-retry advice never fails over. Calls
+	// that time out or whose answer is lost or unreadable never get here:
+	// the payment may have been charged, it is UNKNOWN instead of failed.
+	Eligible func(paymentError *providers.PaymentError) bool
+}
+
+// fallbacks returns the backup providers of a payment, the request's own
+// list taking precedence over the configured one. Payments forced onto a
+// provider, pinned to one by their installment plan or carrying a PIN
+// block translated to its key have none.
+func (p *PaymentProcessor) fallbacks(paymentReqest providers.PaymentRequest) []string {
+	if paymentReqest.Installments != nil || paymentReqest.PINBlock != nil || (paymentReqest.Overrides != nil && paymentReqest.Overrides.ForceProvider != "") {
+		return nil
+	}
+	if len(paymentReqest.FallbackProviders) > 0 {
+		return paymentReqest.FallbackProviders
+	}
+	return p.config.Failover.Fallbacks[paymentReqest.Mode]
+}
+
+// failsOver reports whether a failed payment may go to the next provider
+func (p *PaymentProcessor) failsOver(paymentError *providers.PaymentError) bool {
+	if paymentError.DoNotRetry() {
+		return false
+	}
+	if p.config.Failover.Eligible != nil {
+		return p.config.Failover.Eligible(paymentError)
+	}
+	return gatewayFailure(paymentError)
+}
+
+// fallbackProvider prepares a payment for a backup provider, under one of
+// its own merchant accounts. Providers that are not registered, are being
+// drained or cannot take the payment are skipped.
+func (p *PaymentProcessor) fallbackProvider(name string, paymentReqest providers.PaymentRequest) (providers.Provider, providers.PaymentRequest, bool) {
+	fallback, err := p.getProvider(name)
+	if err != nil || name == paymentReqest.Mode || p.Draining(name) {
+		return nil, paymentReqest, false
+	}
+
+	paymentReqest.Mode = name
+	paymentReqest, accountError := p.selectMerchantAccount(fallback, paymentReqest)
+	if accountError != nil {
+		return nil, paymentReqest, false
+	}
+	if p.checkAuthorizationType(fallback, paymentReqest) != nil || fallback.ValidateRequest(paymentReqest) != nil {
+		return nil, paymentReqest, false
+	}
+	return fallback, paymentReqest, true
+}
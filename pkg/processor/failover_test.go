@@ -0,0 +1,173 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgas/pkg/chaos"
+	"pgas/pkg/providers"
+)
+
+var gatewayDown = &providers.PaymentError{ErrorCode: "PROCESSING_ERROR", Reason: providers.ReasonProcessingError}
+
+func TestFailover_FallsBackOnGatewayFailure(t *testing.T) {
+	primary := newStubProvider("stripe", gatewayDown)
+	backup := newStubProvider("adyen")
+	processor := NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}))
+
+	response, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe"))
+	if paymentError != nil {
+		t.Fatalf("Expected the backup to charge, got %+v", paymentError)
+	}
+	if response.Provider != "adyen" || response.GatewayReference != "adyen-tx" {
+		t.Errorf("Expected the payment to be handled by adyen, got %+v", response)
+	}
+	if primary.callCount() != 1 || backup.callCount() != 1 {
+		t.Errorf("Expected one call each, got %d and %d", primary.callCount(), backup.callCount())
+	}
+
+	tx, err := processor.Transactions().Get(response.TransactionID)
+	if err != nil || tx.Provider != "adyen" || tx.GatewayReference != "adyen-tx" {
+		t.Errorf("Expected the transaction to be stored under adyen, got %+v (%v)", tx, err)
+	}
+}
+
+func TestFailover_DeclinesStay(t *testing.T) {
+	primary := newStubProvider("stripe", &providers.PaymentError{ErrorCode: "51", Reason: providers.ReasonInsufficientFunds})
+	backup := newStubProvider("adyen")
+	processor := NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}))
+
+	_, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe"))
+	if paymentError == nil || paymentError.Reason != providers.ReasonInsufficientFunds {
+		t.Fatalf("Expected the decline, got %+v", paymentError)
+	}
+	if backup.callCount() != 0 {
+		t.Error("Expected a decline not to fail over")
+	}
+}
+
+func TestFailover_RequestFallbacks(t *testing.T) {
+	primary := newStubProvider("stripe", gatewayDown)
+	configured := newStubProvider("adyen")
+	draining := newStubProvider("braintree")
+	requested := newStubProvider("checkout")
+	processor := NewPaymentProcessor([]providers.Provider{primary, configured, draining, requested},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}))
+	if _, err := processor.Drain(context.Background(), "braintree"); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+
+	request := stubRequest("stripe")
+	request.FallbackProviders = []string{"missing", "braintree", "checkout"}
+	response, paymentError := processor.ProcessPayment(context.Background(), request)
+	if paymentError != nil || response.Provider != "checkout" {
+		t.Fatalf("Expected the requested fallback to charge, got %+v %+v", response, paymentError)
+	}
+	if configured.callCount() != 0 || draining.callCount() != 0 {
+		t.Error("Expected the configured and draining fallbacks to be skipped")
+	}
+}
+
+func TestFailover_AllFail(t *testing.T) {
+	primary := newStubProvider("stripe", gatewayDown)
+	backup := newStubProvider("adyen", &providers.PaymentError{ErrorCode: "GATEWAY_ERROR", Reason: providers.ReasonProcessingError})
+	processor := NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}))
+
+	_, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe"))
+	if paymentError == nil || paymentError.ErrorCode != "GATEWAY_ERROR" {
+		t.Fatalf("Expected the last provider's error, got %+v", paymentError)
+	}
+}
+
+func TestFailover_Eligible(t *testing.T) {
+	timeout := &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"}
+	primary := newStubProvider("stripe", timeout)
+	backup := newStubProvider("adyen")
+
+	processor := NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}))
+	if _, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe")); paymentError == nil {
+		t.Fatal("Expected the timeout not to fail over by default")
+	}
+
+	processor = NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{
+			Fallbacks: map[string][]string{"stripe": {"adyen"}},
+			Eligible:  func(paymentError *providers.PaymentError) bool { return paymentError.ErrorCode == "GATEWAY_TIMEOUT" },
+		}))
+	if response, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe")); paymentError != nil || response.Provider != "adyen" {
+		t.Fatalf("Expected the custom rule to fail over, got %+v %+v", response, paymentError)
+	}
+}
+
+// garbledProvider charges payments but answers with a body that fails to parse
+type garbledProvider struct {
+	*stubProvider
+}
+
+func (g garbledProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	return nil, errors.New("unexpected end of JSON input")
+}
+
+func TestFailover_ParsingErrorStays(t *testing.T) {
+	primary := newStubProvider("stripe")
+	backup := newStubProvider("adyen")
+	processor := NewPaymentProcessor([]providers.Provider{garbledProvider{primary}, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}))
+
+	response, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe"))
+	if paymentError != nil || response.Status != providers.StatusUnknown || response.RawStatus != "PARSING_ERROR" {
+		t.Fatalf("Expected the unparsable approval to be UNKNOWN, got %+v %+v", response, paymentError)
+	}
+	if unresolved := processor.UnresolvedPayments(); len(unresolved) != 1 || unresolved[0].TransactionID != response.TransactionID {
+		t.Errorf("Expected the payment left for status resolution, got %+v", unresolved)
+	}
+	if primary.callCount() != 1 || backup.callCount() != 0 {
+		t.Errorf("Expected the charged payment not to fail over, got %d and %d calls", primary.callCount(), backup.callCount())
+	}
+}
+
+func TestFailover_ChaosDroppedResponseStays(t *testing.T) {
+	primary := newStubProvider("stripe")
+	backup := newStubProvider("adyen")
+	processor := NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}),
+		WithChaos(chaos.New(1, chaos.Rule{Point: chaos.AfterProvider, Fault: chaos.FaultDrop, Probability: 1})),
+	)
+
+	response, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe"))
+	if paymentError != nil || response.Status != providers.StatusUnknown {
+		t.Fatalf("Expected the lost answer to leave the payment UNKNOWN, got %+v %+v", response, paymentError)
+	}
+	if backup.callCount() != 0 {
+		t.Errorf("Expected no failover of a submitted payment, got %d backup calls", backup.callCount())
+	}
+}
+
+// unreadableErrorProvider declines with an error body it cannot parse
+type unreadableErrorProvider struct {
+	*stubProvider
+}
+
+func (u unreadableErrorProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	return nil, errors.New("unexpected error body")
+}
+
+func TestFailover_UnreadableErrorStays(t *testing.T) {
+	primary := newStubProvider("stripe", gatewayDown)
+	backup := newStubProvider("adyen")
+	processor := NewPaymentProcessor([]providers.Provider{unreadableErrorProvider{primary}, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}))
+
+	response, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stripe"))
+	if paymentError != nil || response.Status != providers.StatusUnknown || response.RawStatus != "PROCESSING_ERROR" {
+		t.Fatalf("Expected the unreadable answer to leave the payment UNKNOWN, got %+v %+v", response, paymentError)
+	}
+	if backup.callCount() != 0 {
+		t.Errorf("Expected no failover of a submitted payment, got %d backup calls", backup.callCount())
+	}
+}
@@ -0,0 +1,180 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/forward"
+	"pgas/pkg/providers"
+)
+
+// ForwardResult is the outcome of submitting one queued payment
+type ForwardResult struct {
+	EntryID  string                     `json:"entry_id"`
+	Response *providers.PaymentResponse `json:"response,omitempty"`
+	Error    *providers.PaymentError    `json:"error,omitempty"`
+}
+
+func (p *PaymentProcessor) shouldDefer(paymentReqest providers.PaymentRequest, paymentError *providers.PaymentError) bool {
+	policy := p.config.Forward
+	if policy.Queue == nil || paymentError == nil || paymentError.DoNotRetry() || paymentReqest.Amount > policy.MaxAmount || paymentReqest.PINBlock != nil {
+		return false
+	}
+
+	if policy.Unreachable != nil {
+		return policy.Unreachable(paymentError)
+	}
+	return paymentError.ErrorCode == "PROCESSING_ERROR"
+}
+
+// deferPayment accepts the payment locally into the forward queue
+func (p *PaymentProcessor) deferPayment(ctx context.Context, paymentReqest providers.PaymentRequest, paymentError *providers.PaymentError) (*providers.PaymentResponse, *providers.PaymentError) {
+	paymentReqest.Overrides = nil
+	paymentReqest.LatencyBudgetMs = 0
+
+	now := time.Now()
+	entry, err := p.config.Forward.Queue.Enqueue(paymentReqest, now)
+	if err != nil {
+		// nothing was accepted, report the original gateway failure
+		return nil, paymentError
+	}
+
+	p.publish(ctx, events.Event{
+		Type:          events.TypePaymentDeferred,
+		Time:          now,
+		Provider:      paymentReqest.Mode,
+		TransactionID: entry.ID,
+		Data:          events.Encode(events.PaymentDeferredData{ErrorCode: paymentError.ErrorCode}),
+	})
+
+	return &providers.PaymentResponse{
+		Success:         true,
+		TransactionID:   entry.ID,
+		Status:          providers.StatusDeferred,
+		Amount:          paymentReqest.Amount,
+		Currency:        paymentReqest.Currency,
+		Date:            &now,
+		SubMerchantID:   paymentReqest.SubMerchantID,
+		MerchantAccount: merchantAccountName(paymentReqest),
+	}, nil
+}
+
+// ForwardPending submits every queued payment. Entries older than MaxAge or
+// whose provider was deregistered are force-declined, entries whose provider
+// is still unreachable stay queued.
+func (p *PaymentProcessor) ForwardPending(ctx context.Context) []ForwardResult {
+	queue := p.config.Forward.Queue
+	if queue == nil {
+		return nil
+	}
+
+	var results []ForwardResult
+	for _, entry := range queue.Pending() {
+		if ctx.Err() != nil {
+			break
+		}
+
+		now := time.Now()
+		if p.config.Forward.MaxAge > 0 && now.Sub(entry.QueuedAt) > p.config.Forward.MaxAge {
+			if result, ok := p.declineEntry(ctx, entry, &providers.PaymentError{
+				Success:      false,
+				ErrorCode:    "FORWARD_EXPIRED",
+				ErrorMessage: "deferred payment could not be submitted before it expired",
+				Reason:       providers.ReasonProcessingError,
+			}); ok {
+				results = append(results, result)
+			}
+			continue
+		}
+
+		paymentProvider, err := p.getProvider(entry.Request.Mode)
+		if err != nil {
+			// the provider was deregistered while the payment waited, it
+			// would never be submitted
+			if result, ok := p.declineEntry(ctx, entry, &providers.PaymentError{
+				Success:      false,
+				ErrorCode:    "FORWARD_PROVIDER_REMOVED",
+				ErrorMessage: "deferred payment's provider is no longer registered",
+				Reason:       providers.ReasonProcessingError,
+				Err:          err,
+			}); ok {
+				results = append(results, result)
+			}
+			continue
+		}
+		release, admitted := p.admit(paymentProvider.GetName())
+		if !admitted {
+			continue
+		}
+		response, paymentError := p.attemptPayment(ctx, paymentProvider, entry.ID, entry.Request, p.providerTimeout(paymentProvider.GetName()))
+		release()
+		if paymentError != nil && p.shouldDefer(entry.Request, paymentError) {
+			queue.MarkAttempt(entry.ID, now)
+			continue
+		}
+
+		if queue.Remove(entry.ID) != nil {
+			continue
+		}
+
+		result := ForwardResult{EntryID: entry.ID, Response: response, Error: paymentError}
+		p.publish(ctx, forwardEvent(events.TypePaymentForwarded, paymentProvider.GetName(), entry.ID, response, paymentError))
+		if response != nil {
+			p.updateTransactionReference(entry.ID, response.GatewayReference)
+			p.updateTransactionStatus(entry.ID, response.Status, "forwarded as "+response.GatewayReference)
+		} else {
+			p.updateTransactionStatus(entry.ID, providers.StatusDeclined, paymentError.ErrorCode)
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// declineEntry removes a queued payment that will not be submitted and
+// reports it as declined
+func (p *PaymentProcessor) declineEntry(ctx context.Context, entry forward.Entry, paymentError *providers.PaymentError) (ForwardResult, bool) {
+	if p.config.Forward.Queue.Remove(entry.ID) != nil {
+		return ForwardResult{}, false
+	}
+	p.publish(ctx, forwardEvent(events.TypeForwardExpired, entry.Request.Mode, entry.ID, nil, paymentError))
+	p.updateTransactionStatus(entry.ID, providers.StatusDeclined, paymentError.ErrorCode)
+	return ForwardResult{EntryID: entry.ID, Error: paymentError}, true
+}
+
+// StartForwarding submits queued payments every interval until ctx is done
+func (p *PaymentProcessor) StartForwarding(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.ForwardPending(ctx)
+			}
+		}
+	}()
+}
+
+func forwardEvent(eventType, provider, entryID string, response *providers.PaymentResponse, paymentError *providers.PaymentError) events.Event {
+	event := events.Event{
+		Type:     eventType,
+		Time:     time.Now(),
+		Provider: provider,
+	}
+
+	data := events.PaymentForwardData{EntryID: entryID}
+	if response != nil {
+		event.TransactionID = response.TransactionID
+		data.Status = response.Status
+	}
+	if paymentError != nil {
+		data.ErrorCode = paymentError.ErrorCode
+	}
+	event.Data = events.Encode(data)
+	return event
+}
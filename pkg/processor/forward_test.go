@@ -0,0 +1,119 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/forward"
+	"pgas/pkg/providers"
+)
+
+func unreachable() *providers.PaymentError {
+	return &providers.PaymentError{ErrorCode: "PROCESSING_ERROR", ErrorMessage: "connection refused"}
+}
+
+func TestProcessPayment_StoreAndForward(t *testing.T) {
+	queue, _ := forward.NewQueue("", nil)
+	stub := newStubProvider("stub", unreachable(), unreachable(), nil)
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(stub),
+		WithEventPublisher(publisher),
+		WithStoreAndForward(ForwardPolicy{Queue: queue, MaxAmount: 100, MaxAge: time.Hour}),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected payment to be deferred, got error: %v", err)
+	}
+
+	if response.Status != providers.StatusDeferred || queue.Len() != 1 {
+		t.Fatalf("Expected DEFERRED response with a queued entry, got %s and %d entries", response.Status, queue.Len())
+	}
+
+	if results := processor.ForwardPending(context.Background()); len(results) != 0 {
+		t.Fatalf("Expected entry to stay queued while unreachable, got %+v", results)
+	}
+
+	if pending := queue.Pending(); len(pending) != 1 || pending[0].Attempts != 1 {
+		t.Fatalf("Expected failed attempt to be recorded, got %+v", pending)
+	}
+
+	results := processor.ForwardPending(context.Background())
+	if len(results) != 1 || results[0].Response == nil || results[0].EntryID != response.TransactionID {
+		t.Fatalf("Expected queued payment to be submitted, got %+v", results)
+	}
+
+	if queue.Len() != 0 {
+		t.Error("Expected submitted entry to leave the queue")
+	}
+
+	published := publisher.Events()
+	if len(published) != 2 || published[0].Type != events.TypePaymentDeferred || published[1].Type != events.TypePaymentForwarded {
+		t.Errorf("Expected deferred and forwarded events, got %+v", published)
+	}
+}
+
+func TestProcessPayment_StoreAndForwardLimits(t *testing.T) {
+	queue, _ := forward.NewQueue("", nil)
+	processor := NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub", unreachable())),
+		WithStoreAndForward(ForwardPolicy{Queue: queue, MaxAmount: 50, MaxAge: time.Millisecond}),
+	)
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err == nil || err.ErrorCode != "PROCESSING_ERROR" {
+		t.Fatalf("Expected payment above MaxAmount to fail, got %v", err)
+	}
+
+	request := stubRequest("stub")
+	request.Amount = 20
+	if response, err := processor.ProcessPayment(context.Background(), request); err != nil || response.Status != providers.StatusDeferred {
+		t.Fatalf("Expected small payment to be deferred, got %v", err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+
+	results := processor.ForwardPending(context.Background())
+	if len(results) != 1 || results[0].Error == nil || results[0].Error.ErrorCode != "FORWARD_EXPIRED" {
+		t.Fatalf("Expected expired entry to be force-declined, got %+v", results)
+	}
+
+	if queue.Len() != 0 {
+		t.Error("Expected expired entry to leave the queue")
+	}
+}
+
+func TestForwardPending_ProviderRemoved(t *testing.T) {
+	queue, _ := forward.NewQueue("", nil)
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub", unreachable(), unreachable())),
+		WithEventPublisher(publisher),
+		WithStoreAndForward(ForwardPolicy{Queue: queue, MaxAmount: 100, MaxAge: time.Hour}),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil || response.Status != providers.StatusDeferred {
+		t.Fatalf("Expected payment to be deferred, got %v", err)
+	}
+
+	if err := processor.DeregisterProvider("stub"); err != nil {
+		t.Fatalf("Expected provider to be deregistered, got error: %v", err)
+	}
+
+	results := processor.ForwardPending(context.Background())
+	if len(results) != 1 || results[0].Error == nil || results[0].Error.ErrorCode != "FORWARD_PROVIDER_REMOVED" {
+		t.Fatalf("Expected entry of a removed provider to be declined, got %+v", results)
+	}
+
+	if queue.Len() != 0 {
+		t.Error("Expected declined entry to leave the queue")
+	}
+
+	published := publisher.Events()
+	if last := published[len(published)-1]; last.Type != events.TypeForwardExpired || last.Provider != "stub" {
+		t.Errorf("Expected a forward_expired event for the entry, got %+v", last)
+	}
+}
@@ -0,0 +1,137 @@
+package processor
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/fraud"
+	"pgas/pkg/providers"
+)
+
+// FraudPolicy plugs an external fraud service into the payment path. The
+// decision is the stricter of the score thresholds and the service's own
+// recommendation.
+type FraudPolicy struct {
+	// Provider scores the payments, nil disables fraud checks
+	Provider fraud.Provider
+	// ReviewAt and DenyAt are the scores from which payments are sent to
+	// review or denied, zero leaves the decision to the provider
+	ReviewAt float64
+	DenyAt   float64
+	// FailOpen lets payments through when the fraud service cannot be
+	// reached, by default they fail with FRAUD_CHECK_ERROR
+	FailOpen bool
+}
+
+// decide turns an assessment into the processor's decision
+func (f FraudPolicy) decide(assessment *fraud.Assessment) fraud.Decision {
+	decision := fraud.Allow
+	switch {
+	case f.DenyAt > 0 && assessment.Score >= f.DenyAt:
+		decision = fraud.Deny
+	case f.ReviewAt > 0 && assessment.Score >= f.ReviewAt:
+		decision = fraud.Review
+	}
+	return fraud.Stricter(decision, assessment.Decision)
+}
+
+// fraudCheck is the outcome of a payment's fraud check, kept on its response
+type fraudCheck struct {
+	assessment *fraud.Assessment
+	decision   fraud.Decision
+}
+
+// checkFraud asks the fraud provider about the payment, unless an authorized
+// override skips the check. The call gets what is left of the budget's fraud
+// share. Denied payments fail with FRAUD_DENIED; reviewed and denied ones
+// publish a payment.fraud_decision event.
+func (p *PaymentProcessor) checkFraud(ctx context.Context, paymentReqest providers.PaymentRequest, budget *latencyBudget) (*fraudCheck, *providers.PaymentError) {
+	policy := p.config.Fraud
+	if policy.Provider == nil || (paymentReqest.Overrides != nil && paymentReqest.Overrides.SkipFraudCheck) {
+		return nil, nil
+	}
+
+	timeout := budget.stageTimeout("fraud", p.config.DefaultTimeout)
+	if timeout < 0 {
+		return nil, budgetExceeded("fraud")
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	request := fraud.NewRequest(paymentReqest)
+	if p.config.Fingerprinter != nil {
+		if fp, err := p.config.Fingerprinter.Fingerprint(paymentReqest.CardNumber); err == nil {
+			request.Fingerprint = fp.String()
+		}
+	}
+
+	assessment, err := policy.Provider.Assess(ctx, request)
+	if err != nil {
+		if policy.FailOpen {
+			return nil, nil
+		}
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "FRAUD_CHECK_ERROR",
+			ErrorMessage: "fraud check failed: " + err.Error(),
+			Reason:       providers.ReasonProcessingError,
+			Err:          err,
+		}
+	}
+
+	check := &fraudCheck{assessment: assessment, decision: policy.decide(assessment)}
+	if check.decision == fraud.Allow {
+		return check, nil
+	}
+
+	p.publish(ctx, events.Event{
+		Type:     events.TypeFraudDecision,
+		Time:     time.Now(),
+		Provider: paymentReqest.Mode,
+		Data: events.Encode(events.FraudDecisionData{
+			Decision:      string(check.decision),
+			Score:         assessment.Score,
+			AssessmentID:  assessment.ID,
+			Reasons:       assessment.Reasons,
+			Amount:        paymentReqest.Amount,
+			Currency:      paymentReqest.Currency,
+			SubMerchantID: paymentReqest.SubMerchantID,
+		}),
+	})
+
+	if check.decision == fraud.Deny {
+		message := "payment denied by the fraud check"
+		if len(assessment.Reasons) > 0 {
+			message += ": " + strings.Join(assessment.Reasons, ", ")
+		}
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "FRAUD_DENIED",
+			ErrorMessage: message,
+			Reason:       providers.ReasonSuspectedFraud,
+		}
+	}
+	return check, nil
+}
+
+// markFraudCheck adds the fraud score and decision to a processed payment's
+// response, and so to its stored record
+func markFraudCheck(response *providers.PaymentResponse, check *fraudCheck) {
+	if check == nil || response == nil {
+		return
+	}
+	if response.Extra == nil {
+		response.Extra = make(map[string]string)
+	}
+	response.Extra["fraud_score"] = strconv.FormatFloat(check.assessment.Score, 'f', -1, 64)
+	response.Extra["fraud_decision"] = string(check.decision)
+	if check.assessment.ID != "" {
+		response.Extra["fraud_assessment_id"] = check.assessment.ID
+	}
+}
@@ -0,0 +1,103 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgas/pkg/events"
+	"pgas/pkg/fraud"
+	"pgas/pkg/providers"
+)
+
+// scoringProvider scores payments by amount, a score per amount
+type scoringProvider map[float64]fraud.Assessment
+
+func (s scoringProvider) Assess(ctx context.Context, request fraud.Request) (*fraud.Assessment, error) {
+	assessment, ok := s[request.Amount]
+	if !ok {
+		return nil, errors.New("fraud service unavailable")
+	}
+	return &assessment, nil
+}
+
+func TestProcessPayment_FraudCheck(t *testing.T) {
+	provider := newStubProvider("stub")
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithEventPublisher(publisher),
+		WithFraudPolicy(FraudPolicy{
+			Provider: scoringProvider{
+				10: {Score: 0.1},
+				20: {ID: "a-2", Score: 0.6},
+				30: {Score: 0.2, Decision: fraud.Deny, Reasons: []string{"device blocklisted"}},
+				40: {Score: 0.95},
+			},
+			ReviewAt: 0.5,
+			DenyAt:   0.9,
+		}),
+	)
+
+	charge := func(amount float64) (*providers.PaymentResponse, *providers.PaymentError) {
+		request := stubRequest("stub")
+		request.Amount = amount
+		return processor.ProcessPayment(context.Background(), request)
+	}
+
+	response, err := charge(10)
+	if err != nil || response.Extra["fraud_decision"] != "allow" || response.Extra["fraud_score"] != "0.1" {
+		t.Fatalf("Expected the payment allowed with its score, got %+v (%v)", response, err)
+	}
+
+	response, err = charge(20)
+	if err != nil || response.Extra["fraud_decision"] != "review" || response.Extra["fraud_assessment_id"] != "a-2" {
+		t.Fatalf("Expected the payment sent to review, got %+v (%v)", response, err)
+	}
+	if tx, _ := processor.Transactions().Get(response.TransactionID); tx.Extra["fraud_decision"] != "review" {
+		t.Errorf("Expected the decision on the stored payment, got %+v", tx.Extra)
+	}
+
+	// the provider's recommendation wins over a low score, a high score
+	// over the missing recommendation
+	for _, amount := range []float64{30, 40} {
+		if _, err := charge(amount); err == nil || err.ErrorCode != "FRAUD_DENIED" || err.Reason != providers.ReasonSuspectedFraud {
+			t.Errorf("Expected %v to be denied, got %v", amount, err)
+		}
+	}
+	if provider.callCount() != 2 {
+		t.Errorf("Expected denied payments not to reach the gateway, got %d calls", provider.callCount())
+	}
+
+	published := publisher.Events()
+	if len(published) != 3 {
+		t.Fatalf("Expected a fraud decision event per review and denial, got %+v", published)
+	}
+	var data events.FraudDecisionData
+	if err := published[1].Decode(&data); err != nil || data.Decision != "deny" || data.Reasons[0] != "device blocklisted" || data.Amount != 30 {
+		t.Errorf("Expected the denial data, got %+v (%v)", data, err)
+	}
+
+	if _, err := charge(50); err == nil || err.ErrorCode != "FRAUD_CHECK_ERROR" {
+		t.Errorf("Expected payments to fail closed without a fraud service, got %v", err)
+	}
+}
+
+func TestProcessPayment_FraudCheckSkipped(t *testing.T) {
+	processor := NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub")),
+		WithFraudPolicy(FraudPolicy{Provider: scoringProvider{}, FailOpen: true}),
+		WithOverrideAuthorizer(func(overrides providers.Overrides) error { return nil }),
+	)
+
+	if response, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil || response.Extra["fraud_decision"] != "" {
+		t.Errorf("Expected the payment to fail open, got %+v (%v)", response, err)
+	}
+
+	processor.config.Fraud.FailOpen = false
+	request := stubRequest("stub")
+	request.Overrides = &providers.Overrides{Actor: "ops", SkipFraudCheck: true}
+	if _, err := processor.ProcessPayment(context.Background(), request); err != nil {
+		t.Errorf("Expected the override to skip the fraud check, got %v", err)
+	}
+}
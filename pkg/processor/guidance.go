@@ -0,0 +1,12 @@
+package processor
+
+import (
+	"pgas/pkg/guidance"
+)
+
+// DeclineGuidance returns the customer facing message and action hint of a
+// normalized decline reason in the closest available locale, so every
+// checkout shows the merchant's reviewed wording
+func (p *PaymentProcessor) DeclineGuidance(reason, locale string) guidance.Guidance {
+	return p.config.DeclineGuidance.Lookup(reason, locale)
+}
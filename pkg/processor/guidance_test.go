@@ -0,0 +1,22 @@
+package processor
+
+import (
+	"testing"
+
+	"pgas/pkg/guidance"
+	"pgas/pkg/providers"
+)
+
+func TestDeclineGuidance(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stub")})
+	if got := processor.DeclineGuidance(providers.ReasonExpiredCard, "de-DE"); got.Locale != "de" || got.Action != guidance.ActionUpdateCard {
+		t.Errorf("Expected the built-in German guidance, got %+v", got)
+	}
+
+	catalog := guidance.Default()
+	catalog.Set("en", providers.ReasonIssuerUnavailable, guidance.Entry{Message: "Please try again shortly.", Action: guidance.ActionRetryLater})
+	processor = NewPaymentProcessor([]providers.Provider{newStubProvider("stub")}, WithDeclineGuidance(catalog))
+	if got := processor.DeclineGuidance(providers.ReasonIssuerUnavailable, "en-US"); got.Message != "Please try again shortly." {
+		t.Errorf("Expected the merchant wording, got %+v", got)
+	}
+}
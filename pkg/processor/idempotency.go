@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// IdempotencyPolicy makes payments with an IdempotencyKey run once: a
+// repeated key within TTL gets the first outcome back without calling the
+// provider again
+type IdempotencyPolicy struct {
+	Store store.Idempotency // nil ignores idempotency keys
+	TTL   time.Duration     // how long outcomes are kept per key
+}
+
+// reserveIdempotencyKey claims the request's key. For a key seen before it
+// returns the first outcome, or an error while that payment is in flight or
+// when the key was used for a different payment.
+func (p *PaymentProcessor) reserveIdempotencyKey(paymentReqest providers.PaymentRequest) (*providers.PaymentResponse, *providers.PaymentError, bool) {
+	policy := p.config.Idempotency
+	if policy.Store == nil || paymentReqest.IdempotencyKey == "" {
+		return nil, nil, false
+	}
+
+	hash := idempotencyHash(paymentReqest)
+	record, reserved := policy.Store.Reserve(paymentReqest.IdempotencyKey, store.IdempotencyRecord{RequestHash: hash}, policy.TTL)
+	if reserved {
+		return nil, nil, false
+	}
+
+	switch {
+	case record.RequestHash != hash:
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "IDEMPOTENCY_KEY_REUSED",
+			ErrorMessage: "idempotency key was already used for a different payment",
+		}, true
+	case !record.Done:
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "IDEMPOTENCY_KEY_IN_USE",
+			ErrorMessage: "a payment with this idempotency key is still being processed",
+			Retryable:    true,
+		}, true
+	}
+
+	if record.Response != nil {
+		response := *record.Response
+		response.IdempotentReplay = true
+		return &response, nil, true
+	}
+	paymentError := *record.Error
+	return nil, &paymentError, true
+}
+
+// completeIdempotencyKey keeps the outcome for the request's key. Outcomes
+// that may change on a retry, such as timeouts and retryable errors,
+// release the key instead.
+func (p *PaymentProcessor) completeIdempotencyKey(paymentReqest providers.PaymentRequest, response *providers.PaymentResponse, paymentError *providers.PaymentError) {
+	policy := p.config.Idempotency
+	if policy.Store == nil || paymentReqest.IdempotencyKey == "" {
+		return
+	}
+
+	if paymentError != nil && (paymentError.Retryable || paymentError.ErrorCode == "REQUEST_CANCELLED" || paymentError.ErrorCode == "REQUEST_TIMEOUT") {
+		policy.Store.Release(paymentReqest.IdempotencyKey)
+		return
+	}
+
+	// copies, callers may change what they got back
+	record := store.IdempotencyRecord{RequestHash: idempotencyHash(paymentReqest), Done: true}
+	if response != nil {
+		stored := *response
+		record.Response = &stored
+	}
+	if paymentError != nil {
+		stored := *paymentError
+		record.Error = &stored
+	}
+	policy.Store.Complete(paymentReqest.IdempotencyKey, record, policy.TTL)
+}
+
+// idempotencyHash identifies a payment by what it charges, the card is only
+// represented by its last four digits and UPI payments by the VPA, bank
+// accounts by the routing number and the account's last four digits
+func idempotencyHash(paymentReqest providers.PaymentRequest) string {
+	last4 := paymentReqest.CardNumber
+	if last4 == "" {
+		last4 = paymentReqest.AccountNumber
+	}
+	if len(last4) > 4 {
+		last4 = last4[len(last4)-4:]
+	}
+
+	digest := sha256.Sum256([]byte(paymentReqest.Mode + "|" +
+		strconv.FormatFloat(paymentReqest.Amount, 'f', -1, 64) + "|" +
+		paymentReqest.Currency + "|" +
+		paymentReqest.Method + "|" +
+		paymentReqest.SubMerchantID + "|" +
+		last4 + "|" +
+		paymentReqest.VPA + "|" +
+		paymentReqest.RoutingNumber))
+	return hex.EncodeToString(digest[:16])
+}
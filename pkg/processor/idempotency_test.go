@@ -0,0 +1,94 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestProcessPayment_IdempotencyKey(t *testing.T) {
+	provider := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+
+	request := stubRequest("stub")
+	request.IdempotencyKey = "order-1001"
+
+	first, err := processor.ProcessPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected successful payment, got error: %v", err)
+	}
+	first.Status = "CHANGED_BY_CALLER"
+
+	second, err := processor.ProcessPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected the cached payment, got error: %v", err)
+	}
+	if provider.callCount() != 1 {
+		t.Errorf("Expected the provider to be called once, got %d calls", provider.callCount())
+	}
+	if !second.IdempotentReplay || second.TransactionID != first.TransactionID || second.Status != providers.StatusApproved {
+		t.Errorf("Expected the first outcome replayed, got %+v", second)
+	}
+
+	request.Amount = 250
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "IDEMPOTENCY_KEY_REUSED" {
+		t.Errorf("Expected a reused key to be rejected, got %+v", err)
+	}
+
+	request.IdempotencyKey = ""
+	processor.ProcessPayment(context.Background(), request)
+	processor.ProcessPayment(context.Background(), request)
+	if provider.callCount() != 3 {
+		t.Errorf("Expected payments without a key to always be charged, got %d calls", provider.callCount())
+	}
+}
+
+func TestProcessPayment_IdempotencyErrors(t *testing.T) {
+	provider := newStubProvider("stub",
+		&providers.PaymentError{ErrorCode: "PROCESSING_ERROR", Retryable: true},
+		&providers.PaymentError{ErrorCode: "CARD_DECLINED", Reason: providers.ReasonCardDeclined},
+		nil,
+	)
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+
+	request := stubRequest("stub")
+	request.IdempotencyKey = "order-1002"
+
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "PROCESSING_ERROR" {
+		t.Fatalf("Expected the retryable error, got %+v", err)
+	}
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "CARD_DECLINED" {
+		t.Fatalf("Expected a retryable error to release the key, got %+v", err)
+	}
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "CARD_DECLINED" {
+		t.Errorf("Expected the decline to be replayed, got %+v", err)
+	}
+	if provider.callCount() != 2 {
+		t.Errorf("Expected 2 provider calls, got %d", provider.callCount())
+	}
+}
+
+func TestProcessPayment_IdempotencyInFlight(t *testing.T) {
+	provider := &blockingProvider{stubProvider: newStubProvider("stub"), started: make(chan struct{}), release: make(chan struct{})}
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+
+	request := stubRequest("stub")
+	request.IdempotencyKey = "order-1003"
+
+	done := make(chan *providers.PaymentError)
+	go func() {
+		_, err := processor.ProcessPayment(context.Background(), request)
+		done <- err
+	}()
+	<-provider.started
+
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "IDEMPOTENCY_KEY_IN_USE" || !err.Retryable {
+		t.Errorf("Expected a retryable in-use error, got %+v", err)
+	}
+
+	close(provider.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected the first payment to succeed, got %+v", err)
+	}
+}
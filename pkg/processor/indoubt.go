@@ -0,0 +1,147 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// StatusInFlight marks payments whose gateway call had not returned when
+// an in-doubt report was made
+const StatusInFlight = "IN_FLIGHT"
+
+// why an in-doubt report was made
+const (
+	ReportShutdown = "shutdown"
+	ReportRecovery = "recovery" // after a restart, e.g. following a crash
+)
+
+// InDoubtReport lists the payments submitted to a gateway whose outcome is
+// not known, so operations can follow up with the gateways by hand when
+// automated recovery fails
+type InDoubtReport struct {
+	Reason      string           `json:"reason"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Payments    []InDoubtPayment `json:"payments"`
+	// Error is set when the transaction store could not be read, the
+	// report then only holds the payments known in memory
+	Error string `json:"error,omitempty"`
+}
+
+// InDoubtPayment is a payment without a final outcome
+type InDoubtPayment struct {
+	// TransactionID is the stored payment, GatewayReference what to search
+	// the gateway by; both are empty for calls in flight
+	TransactionID    string  `json:"transaction_id,omitempty"`
+	GatewayReference string  `json:"gateway_reference,omitempty"`
+	Provider         string  `json:"provider"`
+	Status           string  `json:"status"` // IN_FLIGHT, PENDING or UNKNOWN
+	RawStatus        string  `json:"raw_status,omitempty"`
+	Amount           float64 `json:"amount"`
+	Currency         string  `json:"currency"`
+	// merchant references to search the gateway by when there is no
+	// transaction id
+	IdempotencyKey string    `json:"idempotency_key,omitempty"`
+	OrderID        string    `json:"order_id,omitempty"`
+	Since          time.Time `json:"since"`
+}
+
+// Shutdown stops the processor from taking new payments, waits for the
+// gateway calls in flight and reports the payments still in doubt. When ctx
+// ends first the calls still in flight are part of the report and ctx's
+// error is returned with it.
+func (p *PaymentProcessor) Shutdown(ctx context.Context) (InDoubtReport, error) {
+	p.SetReadOnly(true)
+
+	for p.callsInFlight() > 0 {
+		select {
+		case <-ctx.Done():
+			return p.InDoubt(ReportShutdown), ctx.Err()
+		case <-time.After(drainPollInterval):
+		}
+	}
+	return p.InDoubt(ReportShutdown), nil
+}
+
+// InDoubt reports the gateway calls in flight and the stored payments
+// whose status is PENDING or UNKNOWN, oldest first. After a crash, calling
+// it on the restarted processor lists what the persistent transaction
+// store holds; calls that were in flight during the crash left no record,
+// their idempotency keys and order ids are in the gateway's reports only.
+func (p *PaymentProcessor) InDoubt(reason string) InDoubtReport {
+	report := InDoubtReport{Reason: reason, GeneratedAt: time.Now(), Payments: []InDoubtPayment{}}
+
+	p.pendingCalls.Range(func(key, _ interface{}) bool {
+		report.Payments = append(report.Payments, *key.(*InDoubtPayment))
+		return true
+	})
+
+	if p.config.Transactions != nil {
+		stored, err := InDoubtTransactions(p.config.Transactions)
+		if err != nil {
+			report.Error = err.Error()
+		}
+		report.Payments = append(report.Payments, stored...)
+	}
+
+	raw := make(map[string]string)
+	for _, payment := range p.UnresolvedPayments() {
+		raw[payment.TransactionID] = payment.RawStatus
+	}
+	for i := range report.Payments {
+		if status, ok := raw[report.Payments[i].TransactionID]; ok && report.Payments[i].RawStatus == "" {
+			report.Payments[i].RawStatus = status
+		}
+	}
+
+	sort.SliceStable(report.Payments, func(i, j int) bool { return report.Payments[i].Since.Before(report.Payments[j].Since) })
+	return report
+}
+
+// InDoubtTransactions lists the PENDING and UNKNOWN payments of a
+// transaction store, for reports made without a running processor
+func InDoubtTransactions(transactions store.Transactions) ([]InDoubtPayment, error) {
+	var payments []InDoubtPayment
+	for _, status := range []string{providers.StatusPending, providers.StatusUnknown} {
+		matches, err := transactions.Query(store.TransactionFilter{Status: status})
+		if err != nil {
+			return payments, err
+		}
+		for _, tx := range matches {
+			payments = append(payments, InDoubtPayment{
+				TransactionID:    tx.ID,
+				GatewayReference: tx.GatewayReference,
+				Provider:         tx.Provider,
+				Status:           tx.Status,
+				Amount:           tx.Amount,
+				Currency:         tx.Currency,
+				IdempotencyKey:   tx.IdempotencyKey,
+				OrderID:          tx.OrderID,
+				Since:            tx.CreatedAt,
+			})
+		}
+	}
+	return payments, nil
+}
+
+// WriteJSON writes the report as indented JSON
+func (r InDoubtReport) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// callsInFlight counts the gateway calls awaiting an answer
+func (p *PaymentProcessor) callsInFlight() int {
+	count := 0
+	p.pendingCalls.Range(func(_, _ interface{}) bool {
+		count++
+		return true
+	})
+	return count
+}
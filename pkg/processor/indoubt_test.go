@@ -0,0 +1,66 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+func TestShutdown_ReportsInDoubtPayments(t *testing.T) {
+	provider := &blockingProvider{stubProvider: newStubProvider("stub"), started: make(chan struct{}), release: make(chan struct{})}
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+	created := time.Now().Add(-time.Hour)
+	processor.Transactions().Save(store.Transaction{ID: "pending-tx", Provider: "stub", Status: providers.StatusPending, Amount: 40, Currency: "USD", OrderID: "order-1", CreatedAt: created})
+	processor.Transactions().Save(store.Transaction{ID: "approved-tx", Provider: "stub", Status: providers.StatusApproved, CreatedAt: created})
+
+	request := stubRequest("stub")
+	request.IdempotencyKey = "key-1"
+	done := make(chan struct{})
+	go func() {
+		processor.ProcessPayment(context.Background(), request)
+		close(done)
+	}()
+	<-provider.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	report, err := processor.Shutdown(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the shutdown to time out on the call in flight, got %v", err)
+	}
+	if report.Reason != ReportShutdown || len(report.Payments) != 2 {
+		t.Fatalf("Expected the stored and in flight payments, got %+v", report)
+	}
+	if stored := report.Payments[0]; stored.TransactionID != "pending-tx" || stored.Status != providers.StatusPending || stored.OrderID != "order-1" {
+		t.Errorf("Expected the oldest, stored payment first, got %+v", stored)
+	}
+	if inFlight := report.Payments[1]; inFlight.Status != StatusInFlight || inFlight.Provider != "stub" || inFlight.IdempotencyKey != "key-1" || inFlight.Amount != 100 {
+		t.Errorf("Expected the call in flight, got %+v", inFlight)
+	}
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err == nil {
+		t.Error("Expected new payments to be rejected after shutdown")
+	}
+
+	provider.release <- struct{}{}
+	<-done
+	report, err = processor.Shutdown(context.Background())
+	if err != nil || len(report.Payments) != 1 {
+		t.Errorf("Expected only the pending payment once the call returned, got %+v (%v)", report, err)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded InDoubtReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil || decoded.Payments[0].TransactionID != "pending-tx" {
+		t.Errorf("Expected the report to round trip, got %+v (%v)", decoded, err)
+	}
+}
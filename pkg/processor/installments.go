@@ -0,0 +1,142 @@
+package processor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// how long a previewed installment plan can be charged
+const installmentPlanTTL = 30 * time.Minute
+
+// InstallmentOptions are the installment plans one provider offers for a
+// payment. Ineligible providers carry the reason instead of plans.
+type InstallmentOptions struct {
+	Provider string                      `json:"provider"`
+	Eligible bool                        `json:"eligible"`
+	Reason   string                      `json:"reason,omitempty"`
+	Plans    []providers.InstallmentPlan `json:"plans,omitempty"`
+}
+
+// previewedPlan is a plan together with the payment it was offered for
+type previewedPlan struct {
+	plan     providers.InstallmentPlan
+	amount   float64
+	currency string
+}
+
+// PreviewInstallments asks every registered provider in parallel for the
+// installment plans it offers for the payment, so a checkout can show them.
+// The chosen plan's ID is then passed as InstallmentPlanID of the charge,
+// which goes to the plan's provider. Like Quote, providers missing
+// QuoteTimeout are reported as ineligible.
+func (p *PaymentProcessor) PreviewInstallments(ctx context.Context, request providers.PaymentRequest) []InstallmentOptions {
+	if p.config.QuoteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.QuoteTimeout)
+		defer cancel()
+	}
+
+	registered := p.registered()
+
+	results := make([]chan InstallmentOptions, len(registered))
+	for i, provider := range registered {
+		results[i] = make(chan InstallmentOptions, 1)
+		go func(provider providers.Provider, result chan<- InstallmentOptions) {
+			result <- p.planInstallments(ctx, provider, request)
+		}(provider, results[i])
+	}
+
+	options := make([]InstallmentOptions, len(registered))
+	for i, provider := range registered {
+		select {
+		case option := <-results[i]:
+			options[i] = option
+		case <-ctx.Done():
+			select {
+			case option := <-results[i]:
+				options[i] = option
+			default:
+				options[i] = InstallmentOptions{Provider: provider.GetName(), Reason: "installment preview timed out"}
+			}
+		}
+	}
+
+	return options
+}
+
+func (p *PaymentProcessor) planInstallments(ctx context.Context, provider providers.Provider, request providers.PaymentRequest) InstallmentOptions {
+	name := provider.GetName()
+	request.Mode = name
+	request.Installments = nil
+
+	planner, ok := provider.(providers.InstallmentPlanner)
+	if !ok {
+		return InstallmentOptions{Provider: name, Reason: "installments not supported"}
+	}
+	if p.Draining(name) {
+		return InstallmentOptions{Provider: name, Reason: "provider is being drained"}
+	}
+	if err := provider.ValidateRequest(request); err != nil {
+		return InstallmentOptions{Provider: name, Reason: err.Error()}
+	}
+
+	plans, err := planner.InstallmentPlans(ctx, request)
+	if err != nil {
+		return InstallmentOptions{Provider: name, Reason: err.Error()}
+	}
+	if len(plans) == 0 {
+		return InstallmentOptions{Provider: name, Reason: "no installment plans offered"}
+	}
+
+	for i := range plans {
+		plans[i].ID = newPlanID()
+		plans[i].Provider = name
+		p.installmentPlans.Put(plans[i].ID, previewedPlan{plan: plans[i], amount: request.Amount, currency: request.Currency})
+	}
+	return InstallmentOptions{Provider: name, Eligible: true, Plans: plans}
+}
+
+// applyInstallmentPlan attaches the previewed plan named by the request and
+// pins the payment to the plan's provider
+func (p *PaymentProcessor) applyInstallmentPlan(paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	paymentReqest.Installments = nil
+	if paymentReqest.InstallmentPlanID == "" {
+		return paymentReqest, nil
+	}
+
+	previewed, ok := p.installmentPlans.Get(paymentReqest.InstallmentPlanID)
+	if !ok {
+		return paymentReqest, invalidPlan("installment plan '%s' is unknown or expired", paymentReqest.InstallmentPlanID)
+	}
+	if math.Abs(previewed.amount-paymentReqest.Amount) > amountTolerance || previewed.currency != paymentReqest.Currency {
+		return paymentReqest, invalidPlan("installment plan '%s' was offered for %.2f %s", previewed.plan.ID, previewed.amount, previewed.currency)
+	}
+	if overrides := paymentReqest.Overrides; overrides != nil && overrides.ForceProvider != "" && overrides.ForceProvider != previewed.plan.Provider {
+		return paymentReqest, invalidPlan("installment plan '%s' is offered by '%s', not '%s'", previewed.plan.ID, previewed.plan.Provider, overrides.ForceProvider)
+	}
+
+	plan := previewed.plan
+	paymentReqest.Mode = plan.Provider
+	paymentReqest.Installments = &plan
+	return paymentReqest, nil
+}
+
+func invalidPlan(format string, args ...interface{}) *providers.PaymentError {
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "INVALID_INSTALLMENT_PLAN",
+		ErrorMessage: fmt.Sprintf(format, args...),
+	}
+}
+
+func newPlanID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "plan_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+type planningProvider struct {
+	*stubProvider
+}
+
+func (s *planningProvider) InstallmentPlans(ctx context.Context, request providers.PaymentRequest) ([]providers.InstallmentPlan, error) {
+	return []providers.InstallmentPlan{
+		{Reference: "P3", Count: 3, Amount: request.Amount / 3, Total: request.Amount, Currency: request.Currency},
+		{Reference: "P12", Count: 12, Amount: 9, Fee: 8, APR: 10, Total: 108, Currency: request.Currency},
+	}, nil
+}
+
+func TestPreviewInstallments(t *testing.T) {
+	planner := &planningProvider{stubProvider: newStubProvider("planner")}
+	processor := NewPaymentProcessor(nil,
+		WithProviders(planner, newStubProvider("plain")),
+		WithRouter(RouterFunc(func(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, error) {
+			return candidates[0], nil // always "plain"
+		})),
+	)
+
+	options := processor.PreviewInstallments(context.Background(), stubRequest(""))
+	if len(options) != 2 || options[1].Provider != "planner" || !options[1].Eligible || len(options[1].Plans) != 2 {
+		t.Fatalf("Expected plans from the planning provider only, got %+v", options)
+	}
+	if options[0].Eligible || options[0].Reason != "installments not supported" {
+		t.Errorf("Expected the plain provider to be ineligible, got %+v", options[0])
+	}
+
+	plan := options[1].Plans[1]
+	if plan.ID == "" || plan.Provider != "planner" {
+		t.Fatalf("Expected the processor to assign an ID and provider, got %+v", plan)
+	}
+
+	request := stubRequest("")
+	request.InstallmentPlanID = plan.ID
+	response, err := processor.ProcessPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected installment payment to succeed, got error: %v", err)
+	}
+	if response.GatewayReference != "planner-tx" {
+		t.Errorf("Expected the plan to pin the payment to its provider over the router, got %s", response.GatewayReference)
+	}
+	if received := planner.received().Installments; received == nil || received.Reference != "P12" || received.Count != 12 {
+		t.Errorf("Expected the chosen plan to reach the provider, got %+v", received)
+	}
+}
+
+func TestProcessPayment_InvalidInstallmentPlan(t *testing.T) {
+	planner := &planningProvider{stubProvider: newStubProvider("planner")}
+	processor := NewPaymentProcessor(nil, WithProviders(planner))
+
+	plan := processor.PreviewInstallments(context.Background(), stubRequest("planner"))[0].Plans[0]
+
+	cases := map[string]func(*providers.PaymentRequest){
+		"unknown plan":   func(r *providers.PaymentRequest) { r.InstallmentPlanID = "plan_missing" },
+		"other amount":   func(r *providers.PaymentRequest) { r.Amount = 250 },
+		"other currency": func(r *providers.PaymentRequest) { r.Currency = "EUR" },
+	}
+	for name, change := range cases {
+		request := stubRequest("planner")
+		request.InstallmentPlanID = plan.ID
+		change(&request)
+
+		if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_INSTALLMENT_PLAN" {
+			t.Errorf("%s: expected INVALID_INSTALLMENT_PLAN, got: %v", name, err)
+		}
+	}
+
+	// plans can only be chosen through the preview
+	request := stubRequest("planner")
+	request.Installments = &providers.InstallmentPlan{Count: 48}
+	processor.ProcessPayment(context.Background(), request)
+	if planner.received().Installments != nil {
+		t.Error("Expected installments set by the caller to be dropped")
+	}
+}
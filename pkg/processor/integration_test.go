@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"testing"
 
 	"pgas/pkg/providers"
@@ -28,7 +29,7 @@ func TestIntegration_SuccessfulPayments(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 		},
@@ -41,7 +42,7 @@ func TestIntegration_SuccessfulPayments(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "10",
-				ExpiryYear:  "2024",
+				ExpiryYear:  "2030",
 				CVV:         "456",
 			},
 		},
@@ -54,7 +55,7 @@ func TestIntegration_SuccessfulPayments(t *testing.T) {
 				Currency:    "EUR",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 		},
@@ -67,7 +68,7 @@ func TestIntegration_SuccessfulPayments(t *testing.T) {
 				Currency:    "GBP",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "10",
-				ExpiryYear:  "2024",
+				ExpiryYear:  "2030",
 				CVV:         "456",
 			},
 		},
@@ -75,7 +76,7 @@ func TestIntegration_SuccessfulPayments(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			response, err := processor.ProcessPayment(tc.request)
+			response, err := processor.ProcessPayment(context.Background(), tc.request)
 			if err != nil {
 				t.Fatalf("Expected successful payment, got error: %v", err)
 			}
@@ -133,7 +134,7 @@ func TestIntegration_ErrorScenarios(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			expectedError:  true,
@@ -148,7 +149,7 @@ func TestIntegration_ErrorScenarios(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			expectedError:  true,
@@ -163,7 +164,7 @@ func TestIntegration_ErrorScenarios(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			expectedError:  true,
@@ -178,7 +179,7 @@ func TestIntegration_ErrorScenarios(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			expectedError:  true,
@@ -193,7 +194,7 @@ func TestIntegration_ErrorScenarios(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "12",
 			},
 			expectedError:  true,
@@ -204,7 +205,7 @@ func TestIntegration_ErrorScenarios(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			response, err := processor.ProcessPayment(tc.request)
+			response, err := processor.ProcessPayment(context.Background(), tc.request)
 
 			if tc.expectedError {
 				if err == nil {
@@ -250,7 +251,7 @@ func TestIntegration_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111111111111111111111111111111111111111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -263,7 +264,7 @@ func TestIntegration_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "123",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -276,7 +277,7 @@ func TestIntegration_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "1234",
 			},
 			valid: true,
@@ -285,7 +286,7 @@ func TestIntegration_EdgeCases(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			response, err := processor.ProcessPayment(tc.request)
+			response, err := processor.ProcessPayment(context.Background(), tc.request)
 
 			if tc.valid && err != nil {
 				t.Errorf("Expected success for %s, got error: %v", tc.name, err)
@@ -320,14 +321,14 @@ func TestIntegration_ConcurrentPayments(t *testing.T) {
 		Currency:    "USD",
 		CardNumber:  "4111111111111111",
 		ExpiryMonth: "12",
-		ExpiryYear:  "2025",
+		ExpiryYear:  "2030",
 		CVV:         "123",
 	}
 
 	// Start concurrent payment processing
 	for i := 0; i < numGoroutines; i++ {
 		go func() {
-			_, err := processor.ProcessPayment(request)
+			_, err := processor.ProcessPayment(context.Background(), request)
 			if err != nil {
 				results <- err
 			} else {
@@ -381,11 +382,11 @@ func TestIntegration_ProviderSpecificBehavior(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  tc.cardNumber,
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			}
 
-			response, err := processor.ProcessPayment(request)
+			response, err := processor.ProcessPayment(context.Background(), request)
 			if err != nil {
 				t.Fatalf("Expected successful payment, got error: %v", err)
 			}
@@ -0,0 +1,139 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// optional features a provider implements besides payments and refunds
+const (
+	CapabilityCapture      = "capture"      // providers.Capturer
+	CapabilityStatusQuery  = "status_query" // GetPaymentStatus supported
+	CapabilityInstallments = "installments" // providers.InstallmentPlanner
+	CapabilityQuote        = "quote"        // providers.Quoter
+)
+
+// ProviderInfo describes a registered provider for admin tools. It is a
+// snapshot taken when it was requested, changing it has no effect on the
+// processor.
+type ProviderInfo struct {
+	Name         string         `json:"name"`
+	Capabilities []string       `json:"capabilities"`
+	Timeout      time.Duration  `json:"timeout"` // bound of a single gateway call, zero for none
+	Health       ProviderHealth `json:"health"`
+	Stats        ProviderStats  `json:"stats"`
+	// ResponseVersions are the gateway response versions the provider
+	// parses, in the order they are tried; empty for unversioned providers
+	ResponseVersions []string `json:"response_versions,omitempty"`
+	// MerchantAccounts names the provider's merchant accounts in the order
+	// they are tried
+	MerchantAccounts []string `json:"merchant_accounts,omitempty"`
+}
+
+// ProviderHealth is whether a provider takes payments and how busy it is
+type ProviderHealth struct {
+	Draining bool          `json:"draining"`
+	InFlight int64         `json:"in_flight"` // gateway calls awaiting an answer
+	Canary   *CanaryStatus `json:"canary,omitempty"`
+}
+
+// ProviderStats counts the provider's gateway calls since the processor
+// started
+type ProviderStats struct {
+	Calls int64 `json:"calls"`
+	// Outcomes counts the calls per outcome: the normalized status of
+	// answered calls, DECLINED for declines and FAILED for gateway failures
+	Outcomes map[string]int64 `json:"outcomes"`
+	// ErrorRate is the share of FAILED calls (0..1), card declines do not
+	// count
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// Provider describes a registered provider; it reports false for unknown
+// names
+func (p *PaymentProcessor) Provider(name string) (ProviderInfo, bool) {
+	provider, err := p.getProvider(name)
+	if err != nil {
+		return ProviderInfo{}, false
+	}
+	return p.describe(provider, p.calls.Snapshot()), true
+}
+
+// Providers describes every registered provider, sorted by name
+func (p *PaymentProcessor) Providers() []ProviderInfo {
+	counts := p.calls.Snapshot()
+
+	registered := p.registered()
+	infos := make([]ProviderInfo, 0, len(registered))
+	for _, provider := range registered {
+		infos = append(infos, p.describe(provider, counts))
+	}
+	return infos
+}
+
+func (p *PaymentProcessor) describe(provider providers.Provider, counts map[string]int64) ProviderInfo {
+	name := provider.GetName()
+	info := ProviderInfo{
+		Name:         name,
+		Capabilities: capabilities(provider),
+		Timeout:      p.providerTimeout(name),
+		Health: ProviderHealth{
+			Draining: p.Draining(name),
+			InFlight: counts[name],
+		},
+		Stats: ProviderStats{Outcomes: make(map[string]int64)},
+	}
+	if versioned, ok := provider.(providers.VersionedParsing); ok {
+		info.ResponseVersions = versioned.ResponseVersions()
+	}
+	for _, account := range p.config.MerchantAccounts[name] {
+		info.MerchantAccounts = append(info.MerchantAccounts, account.Name)
+	}
+	if status, ok := p.Canary(name); ok {
+		info.Health.Canary = &status
+	}
+
+	for key, count := range p.callOutcomes.Snapshot() {
+		provider, outcome, _ := strings.Cut(key, "|")
+		if provider != name {
+			continue
+		}
+		info.Stats.Outcomes[outcome] = count
+		info.Stats.Calls += count
+	}
+	if info.Stats.Calls > 0 {
+		info.Stats.ErrorRate = float64(info.Stats.Outcomes[outcomeFailed]) / float64(info.Stats.Calls)
+	}
+	return info
+}
+
+func capabilities(provider providers.Provider) []string {
+	capabilities := []string{}
+	if _, ok := provider.(providers.Capturer); ok {
+		capabilities = append(capabilities, CapabilityCapture)
+	}
+	if supportsStatusQuery(provider) {
+		capabilities = append(capabilities, CapabilityStatusQuery)
+	}
+	if _, ok := provider.(providers.InstallmentPlanner); ok {
+		capabilities = append(capabilities, CapabilityInstallments)
+	}
+	if _, ok := provider.(providers.Quoter); ok {
+		capabilities = append(capabilities, CapabilityQuote)
+	}
+	return capabilities
+}
+
+// supportsStatusQuery asks the provider for a status under a cancelled
+// context, so no gateway is called; only providers without lookups answer
+// ErrStatusQueryNotSupported
+func supportsStatusQuery(provider providers.Provider) bool {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := provider.GetPaymentStatus(ctx, "")
+	return !errors.Is(err, providers.ErrStatusQueryNotSupported)
+}
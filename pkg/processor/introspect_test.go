@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// capturingProvider is a stub that also captures and answers status queries
+type capturingProvider struct {
+	*stubProvider
+}
+
+func (c *capturingProvider) Capture(ctx context.Context, request providers.CaptureRequest) (*providers.CaptureResponse, error) {
+	return &providers.CaptureResponse{
+		Success:       true,
+		CaptureID:     "cap-" + request.TransactionID,
+		TransactionID: request.TransactionID,
+		Status:        providers.StatusApproved,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
+
+func (c *capturingProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return &providers.PaymentResponse{Success: true, TransactionID: transactionID, Status: providers.StatusApproved}, nil
+}
+
+func TestProviders_Describe(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{
+		newStubProvider("zeta"),
+		&capturingProvider{newStubProvider("alpha")},
+	}, WithProviderTimeout("alpha", 5*time.Second))
+
+	infos := processor.Providers()
+	if len(infos) != 2 || infos[0].Name != "alpha" || infos[1].Name != "zeta" {
+		t.Fatalf("Expected alpha and zeta sorted by name, got %+v", infos)
+	}
+
+	if !reflect.DeepEqual(infos[0].Capabilities, []string{CapabilityCapture, CapabilityStatusQuery}) {
+		t.Errorf("Unexpected capabilities of alpha: %v", infos[0].Capabilities)
+	}
+	if len(infos[1].Capabilities) != 0 {
+		t.Errorf("Expected no capabilities of zeta, got %v", infos[1].Capabilities)
+	}
+	if infos[0].Timeout != 5*time.Second || infos[1].Timeout != 30*time.Second {
+		t.Errorf("Unexpected timeouts %v and %v", infos[0].Timeout, infos[1].Timeout)
+	}
+	if infos[0].Health.Draining || infos[0].Health.InFlight != 0 || infos[0].Health.Canary != nil {
+		t.Errorf("Expected a healthy idle provider, got %+v", infos[0].Health)
+	}
+}
+
+func TestProvider_Stats(t *testing.T) {
+	stub := newStubProvider("stub",
+		nil,
+		&providers.PaymentError{ErrorCode: "INSUFFICIENT_FUNDS", Reason: providers.ReasonInsufficientFunds},
+		&providers.PaymentError{ErrorCode: "PROCESSING_ERROR", Reason: providers.ReasonProcessingError},
+		nil,
+	)
+	processor := NewPaymentProcessor([]providers.Provider{stub})
+
+	for i := 0; i < 4; i++ {
+		processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	}
+
+	info, ok := processor.Provider("stub")
+	if !ok {
+		t.Fatal("Expected the stub provider to be described")
+	}
+
+	expected := map[string]int64{providers.StatusApproved: 2, providers.StatusDeclined: 1, outcomeFailed: 1}
+	if info.Stats.Calls != 4 || !reflect.DeepEqual(info.Stats.Outcomes, expected) {
+		t.Errorf("Unexpected stats %+v", info.Stats)
+	}
+	if info.Stats.ErrorRate != 0.25 {
+		t.Errorf("Expected error rate 0.25, got %v", info.Stats.ErrorRate)
+	}
+}
+
+func TestProvider_Health(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stub")})
+
+	if _, err := processor.Drain(context.Background(), "stub"); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+	if err := processor.ReloadProvider(newStubProvider("stub"), CanaryPolicy{Percent: 10, MinAttempts: 5, MaxErrorRate: 0.5, PromoteAfter: 20}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	info, _ := processor.Provider("stub")
+	if !info.Health.Draining {
+		t.Error("Expected the provider to be reported as draining")
+	}
+	if info.Health.Canary == nil || info.Health.Canary.Policy.Percent != 10 {
+		t.Errorf("Expected the running canary, got %+v", info.Health.Canary)
+	}
+}
+
+func TestProvider_Unknown(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stub")})
+
+	if _, ok := processor.Provider("missing"); ok {
+		t.Error("Expected no description of an unknown provider")
+	}
+}
@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+)
+
+// Logger receives the processor's structured logs as a message and
+// alternating keys and values, or slog.Attr values. *slog.Logger
+// implements it.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...any)
+}
+
+// log messages of the processor
+const (
+	LogProviderSelected = "payment provider selected"
+	LogValidationFailed = "payment validation failed"
+	LogProviderCall     = "provider call"
+	LogUnreadable       = "provider response unreadable"
+	LogPaymentOutcome   = "payment outcome"
+)
+
+// log writes a record to the configured logger. Values of keys naming a
+// card field are redacted as the policy says for logs, and card numbers in
+// any other string value are masked.
+func (p *PaymentProcessor) log(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if p.config.Logger == nil {
+		return
+	}
+	p.config.Logger.Log(ctx, level, msg, p.redactArgs(args)...)
+}
+
+func (p *PaymentProcessor) redactArgs(args []any) []any {
+	redacted := make([]any, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case slog.Attr:
+			redacted = append(redacted, slog.Any(arg.Key, p.redactValue(arg.Key, arg.Value.Any())))
+		case string:
+			if i+1 == len(args) {
+				redacted = append(redacted, redact.Scrub(arg, "", ""))
+				continue
+			}
+			redacted = append(redacted, arg, p.redactValue(arg, args[i+1]))
+			i++
+		default:
+			redacted = append(redacted, arg)
+		}
+	}
+	return redacted
+}
+
+func (p *PaymentProcessor) redactValue(key string, value any) any {
+	if field, ok := redact.FieldFor(key); ok {
+		if _, masked := value.(redact.MaskedCard); !masked {
+			return p.config.Redaction.Apply(redact.SinkLogs, field, fmt.Sprint(value))
+		}
+	}
+	switch v := value.(type) {
+	case string:
+		return redact.Scrub(v, "", "")
+	case error:
+		return redact.Scrub(v.Error(), "", "")
+	}
+	return value
+}
+
+// logCall logs a gateway call at debug level with its latency and status or
+// error code
+func (p *PaymentProcessor) logCall(ctx context.Context, provider string, attempt int, started time.Time, response *providers.PaymentResponse, paymentError *providers.PaymentError) {
+	if p.config.Logger == nil {
+		return
+	}
+	args := []any{"provider", provider, "attempt", attempt, "latency_ms", time.Since(started).Milliseconds()}
+	if paymentError != nil {
+		args = append(args, "error_code", paymentError.ErrorCode)
+	} else {
+		args = append(args, "status", response.Status)
+	}
+	p.log(ctx, slog.LevelDebug, LogProviderCall, args...)
+}
+
+// logOutcome logs how a payment ended, declines at info and errors without
+// a decline reason at warn level
+func (p *PaymentProcessor) logOutcome(ctx context.Context, paymentReqest providers.PaymentRequest, response *providers.PaymentResponse, paymentError *providers.PaymentError, elapsed int64) {
+	if p.config.Logger == nil {
+		return
+	}
+	args := []any{
+		"amount", paymentReqest.Amount,
+		"currency", paymentReqest.Currency,
+		"card", redact.MaskedCard(paymentReqest.CardNumber),
+		"duration_ms", elapsed,
+	}
+	if paymentReqest.IdempotencyKey != "" {
+		args = append(args, "idempotency_key", paymentReqest.IdempotencyKey)
+	}
+
+	if paymentError != nil {
+		level := slog.LevelInfo
+		if paymentError.Reason == "" {
+			level = slog.LevelWarn
+		}
+		args = append(args, "provider", paymentReqest.Mode, "error_code", paymentError.ErrorCode, "reason", paymentError.Reason, "error", paymentError.ErrorMessage)
+		p.log(ctx, level, LogPaymentOutcome, args...)
+		return
+	}
+	args = append(args, "provider", response.Provider, "status", response.Status, "transaction_id", response.TransactionID)
+	p.log(ctx, slog.LevelInfo, LogPaymentOutcome, args...)
+}
+
+// logUnreadable logs why a gateway answer was lost or could not be read,
+// the payment it leaves in doubt only carries a code
+func (p *PaymentProcessor) logUnreadable(ctx context.Context, paymentProvider providers.Provider, paymentReqest providers.PaymentRequest, err error) {
+	p.log(ctx, slog.LevelWarn, LogUnreadable, "provider", paymentProvider.GetName(), "error", redact.Scrub(err.Error(), paymentReqest.CardNumber, paymentReqest.CVV))
+}
@@ -0,0 +1,96 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func jsonLogger(buf *bytes.Buffer) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+}
+
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("Expected JSON log lines, got %q", line)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogger_PaymentLifecycle(t *testing.T) {
+	var buf bytes.Buffer
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")), WithLogger(jsonLogger(&buf)))
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Fatal(err)
+	}
+
+	records := logRecords(t, &buf)
+	want := []string{LogProviderSelected, LogProviderCall, LogPaymentOutcome}
+	if len(records) != len(want) {
+		t.Fatalf("Expected %d records, got %s", len(want), buf.String())
+	}
+	for i, record := range records {
+		if record["msg"] != want[i] || record["provider"] != "stub" {
+			t.Errorf("record %d: expected %s from stub, got %v", i, want[i], record)
+		}
+	}
+	if records[1]["level"] != "DEBUG" || records[1]["latency_ms"] == nil {
+		t.Errorf("Expected the call's latency at debug level, got %v", records[1])
+	}
+	if records[2]["status"] != "APPROVED" || records[2]["card"] != "411111******1111" {
+		t.Errorf("Expected the outcome with the masked card, got %v", records[2])
+	}
+	if strings.Contains(buf.String(), "4111111111111111") {
+		t.Errorf("Expected no card number in the logs, got %s", buf.String())
+	}
+}
+
+func TestLogger_ValidationFailure(t *testing.T) {
+	var buf bytes.Buffer
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")), WithLogger(jsonLogger(&buf)))
+
+	request := stubRequest("stub")
+	request.Amount = 0
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil {
+		t.Fatal("Expected the payment to be rejected")
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 || records[0]["msg"] != LogValidationFailed || records[0]["level"] != "WARN" {
+		t.Fatalf("Expected a validation failure, got %s", buf.String())
+	}
+	if records[1]["msg"] != LogPaymentOutcome || records[1]["error_code"] != "INVALID_REQUEST" || records[1]["level"] != "WARN" {
+		t.Errorf("Expected the rejected outcome, got %v", records[1])
+	}
+}
+
+func TestLogger_RedactsSensitiveFields(t *testing.T) {
+	var buf bytes.Buffer
+	processor := NewPaymentProcessor(nil, WithLogger(jsonLogger(&buf)))
+
+	processor.log(context.Background(), slog.LevelInfo, "test",
+		"card_number", "4111111111111111",
+		"cvv", "123",
+		slog.String("pan", "5555555555554444"),
+		"note", "customer typed 4000000000009995 into the name field",
+	)
+
+	records := logRecords(t, &buf)
+	record := records[0]
+	if record["card_number"] != "411111******1111" || record["cvv"] != "[REDACTED]" || record["pan"] != "555555******4444" {
+		t.Errorf("Expected card fields redacted, got %v", record)
+	}
+	if record["note"] != "customer typed 400000******9995 into the name field" {
+		t.Errorf("Expected card numbers masked in free text, got %v", record["note"])
+	}
+}
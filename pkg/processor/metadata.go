@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"fmt"
+
+	"pgas/pkg/providers"
+)
+
+// limits on request metadata and tags, they are stored with every payment
+const (
+	maxMetadataKeys  = 20
+	maxMetadataKey   = 40
+	maxMetadataValue = 500
+	maxTags          = 20
+	maxTagLength     = 40
+)
+
+func validateMetadata(paymentReqest providers.PaymentRequest) *providers.PaymentError {
+	var problem string
+	switch {
+	case len(paymentReqest.Metadata) > maxMetadataKeys:
+		problem = fmt.Sprintf("at most %d metadata keys are allowed", maxMetadataKeys)
+	case len(paymentReqest.Tags) > maxTags:
+		problem = fmt.Sprintf("at most %d tags are allowed", maxTags)
+	}
+
+	for key, value := range paymentReqest.Metadata {
+		switch {
+		case key == "" || len(key) > maxMetadataKey:
+			problem = fmt.Sprintf("metadata keys must have 1 to %d characters", maxMetadataKey)
+		case len(value) > maxMetadataValue:
+			problem = fmt.Sprintf("metadata '%s' is longer than %d characters", key, maxMetadataValue)
+		}
+	}
+	for _, tag := range paymentReqest.Tags {
+		if len(tag) > maxTagLength {
+			problem = fmt.Sprintf("tags must have at most %d characters", maxTagLength)
+		}
+	}
+
+	if problem == "" {
+		return nil
+	}
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "INVALID_METADATA",
+		ErrorMessage: problem,
+	}
+}
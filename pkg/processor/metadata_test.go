@@ -0,0 +1,57 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"pgas/pkg/store"
+)
+
+func TestProcessPayment_MetadataAndTags(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	request := stubRequest("stub")
+	request.Metadata = map[string]string{"campaign": "spring", "app_version": "4.2.0", "pan": "4111111111111111"}
+	request.Tags = []string{"Promo", "app"}
+
+	response, err := processor.ProcessPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected successful payment, got error: %v", err)
+	}
+
+	tx, _ := processor.Transactions().Get(response.TransactionID)
+	if tx.Metadata["campaign"] != "spring" || tx.Metadata["app_version"] != "4.2.0" {
+		t.Errorf("Expected metadata on the record, got %+v", tx.Metadata)
+	}
+	if tx.Metadata["pan"] == request.Metadata["pan"] || !strings.Contains(tx.Metadata["pan"], "*") {
+		t.Errorf("Expected card numbers in metadata to follow the records policy, got %s", tx.Metadata["pan"])
+	}
+	if request.Metadata["pan"] != "4111111111111111" {
+		t.Error("Expected the caller's metadata to be left alone")
+	}
+	if len(tx.Tags) != 2 || tx.Tags[0] != "app" || tx.Tags[1] != "promo" {
+		t.Errorf("Expected normalized tags, got %v", tx.Tags)
+	}
+
+	matches, _ := processor.Transactions().Query(store.TransactionFilter{Tag: "promo", Metadata: map[string]string{"campaign": "spring"}})
+	if len(matches) != 1 {
+		t.Errorf("Expected the payment to be found by tag and metadata, got %+v", matches)
+	}
+}
+
+func TestProcessPayment_InvalidMetadata(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	request := stubRequest("stub")
+	request.Metadata = map[string]string{"note": strings.Repeat("x", maxMetadataValue+1)}
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_METADATA" {
+		t.Errorf("Expected long metadata to be rejected, got %+v", err)
+	}
+
+	request = stubRequest("stub")
+	request.Tags = []string{strings.Repeat("t", maxTagLength+1)}
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_METADATA" {
+		t.Errorf("Expected long tags to be rejected, got %+v", err)
+	}
+}
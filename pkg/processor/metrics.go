@@ -0,0 +1,40 @@
+package processor
+
+import (
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// Metrics receives the processor's measurements. Outcomes are the
+// normalized status of answered payments and calls, DECLINED for declines
+// and FAILED for errors; reason is the decline reason of DECLINED payments.
+// Implementations are called on the payment path and must not block.
+type Metrics interface {
+	ObservePayment(provider, outcome, currency, reason string)
+	ObserveCall(provider, outcome string, latency time.Duration)
+}
+
+// observePayment reports how a payment ended, under the provider that
+// answered it or the one the request named
+func (p *PaymentProcessor) observePayment(paymentReqest providers.PaymentRequest, response *providers.PaymentResponse, paymentError *providers.PaymentError) {
+	if p.config.Metrics == nil {
+		return
+	}
+	provider, reason := paymentReqest.Mode, ""
+	outcome := paymentOutcome(response, paymentError)
+	switch {
+	case response != nil:
+		provider = response.Provider
+	case outcome == providers.StatusDeclined:
+		reason = paymentError.Reason
+	}
+	p.config.Metrics.ObservePayment(provider, outcome, paymentReqest.Currency, reason)
+}
+
+func (p *PaymentProcessor) observeCall(provider string, response *providers.PaymentResponse, paymentError *providers.PaymentError, latency time.Duration) {
+	if p.config.Metrics == nil {
+		return
+	}
+	p.config.Metrics.ObserveCall(provider, callOutcome(response, paymentError), latency)
+}
@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+type recordedMetrics struct {
+	mu       sync.Mutex
+	payments []string
+	calls    []string
+}
+
+func (m *recordedMetrics) ObservePayment(provider, outcome, currency, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.payments = append(m.payments, provider+" "+outcome+" "+currency+" "+reason)
+}
+
+func (m *recordedMetrics) ObserveCall(provider, outcome string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, provider+" "+outcome)
+}
+
+func TestMetrics_Observed(t *testing.T) {
+	metrics := &recordedMetrics{}
+	primary := newStubProvider("stripe", gatewayDown)
+	backup := newStubProvider("adyen", nil, &providers.PaymentError{ErrorCode: "51", Reason: providers.ReasonInsufficientFunds})
+	processor := NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stripe": {"adyen"}}}),
+		WithMetrics(metrics))
+
+	processor.ProcessPayment(context.Background(), stubRequest("stripe"))
+	processor.ProcessPayment(context.Background(), stubRequest("adyen"))
+
+	// the failed-over payment counts under the provider that answered it
+	if want := []string{"adyen APPROVED USD ", "adyen DECLINED USD insufficient_funds"}; !slices.Equal(metrics.payments, want) {
+		t.Errorf("Expected payments %v, got %v", want, metrics.payments)
+	}
+	if want := []string{"stripe FAILED", "adyen APPROVED", "adyen DECLINED"}; !slices.Equal(metrics.calls, want) {
+		t.Errorf("Expected calls %v, got %v", want, metrics.calls)
+	}
+}
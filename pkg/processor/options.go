@@ -0,0 +1,421 @@
+package processor
+
+import (
+	"time"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/bin"
+	"pgas/pkg/chaos"
+	"pgas/pkg/events"
+	"pgas/pkg/fees"
+	"pgas/pkg/fingerprint"
+	"pgas/pkg/fraud"
+	"pgas/pkg/guidance"
+	"pgas/pkg/merchant"
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+	"pgas/pkg/reporting"
+	"pgas/pkg/store"
+	"pgas/pkg/threeds"
+	"pgas/pkg/webhooks"
+)
+
+// Option customizes the processor configuration
+type Option func(*ProcessorConfig)
+
+// WithProviders registers additional payment providers
+func WithProviders(paymentProviders ...providers.Provider) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Providers = append(cfg.Providers, paymentProviders...)
+	}
+}
+
+// WithDefaultTimeout bounds each gateway call
+func WithDefaultTimeout(timeout time.Duration) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.DefaultTimeout = timeout
+	}
+}
+
+// WithProviderTimeout bounds each gateway call to one provider, overriding
+// the default timeout
+func WithProviderTimeout(provider string, timeout time.Duration) Option {
+	return func(cfg *ProcessorConfig) {
+		timeouts := make(map[string]time.Duration, len(cfg.ProviderTimeouts)+1)
+		for name, t := range cfg.ProviderTimeouts {
+			timeouts[name] = t
+		}
+		timeouts[provider] = timeout
+		cfg.ProviderTimeouts = timeouts
+	}
+}
+
+// WithRetryPolicy configures retries of failed gateway calls
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Retry = policy
+	}
+}
+
+// WithRetryBudget caps retries across all payments, a zero Window disables
+// the budget
+func WithRetryBudget(budget RetryBudget) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.RetryBudget = budget
+	}
+}
+
+// WithChaos injects faults into the payment pipeline; meant for test setups only
+func WithChaos(injector *chaos.Injector) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Chaos = injector
+	}
+}
+
+// WithReadOnly starts the processor with new charges halted
+func WithReadOnly(enabled bool) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.ReadOnly = enabled
+	}
+}
+
+// WithAuditLog records sensitive operations such as overrides
+func WithAuditLog(log audit.Log) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.AuditLog = log
+	}
+}
+
+// WithOverrideAuthorizer enables per-payment overrides for authorized callers
+func WithOverrideAuthorizer(authorizer OverrideAuthorizer) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.OverrideAuthorizer = authorizer
+	}
+}
+
+// WithMerchantRegistry enables charges on behalf of sub-merchants
+func WithMerchantRegistry(registry *merchant.Registry) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Merchants = registry
+	}
+}
+
+// WithSupportContact sets the support phone and URL cardholders see next to
+// the statement descriptor
+func WithSupportContact(contact providers.SupportContact) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.SupportContact = &contact
+	}
+}
+
+// WithEventPublisher sends processor events such as unknown statuses to publisher
+func WithEventPublisher(publisher events.Publisher) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Events = publisher
+	}
+}
+
+// WithStatusQueryPolicy configures how UNKNOWN statuses are resolved
+func WithStatusQueryPolicy(policy StatusQueryPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.StatusQuery = policy
+	}
+}
+
+// WithBudgetShares sets how request latency budgets are divided between stages
+func WithBudgetShares(shares BudgetShares) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Budget = shares
+	}
+}
+
+// WithQuoteTimeout bounds how long Quote waits for provider quotes
+func WithQuoteTimeout(timeout time.Duration) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.QuoteTimeout = timeout
+	}
+}
+
+// WithFeeCalculator prices quotes for providers without a quoting endpoint
+func WithFeeCalculator(calculator *fees.Calculator) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Fees = calculator
+	}
+}
+
+// WithEnrichers appends response enrichers, they run in the order given
+func WithEnrichers(enrichers ...Enricher) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Enrichers = append(cfg.Enrichers, enrichers...)
+	}
+}
+
+// WithRouter lets router choose the provider of every payment not forced
+// onto one through overrides
+func WithRouter(router Router) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Router = router
+	}
+}
+
+// WithStoreAndForward queues small payments while providers are unreachable
+func WithStoreAndForward(policy ForwardPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Forward = policy
+	}
+}
+
+// WithExpiryPolicy sets when PENDING and REQUIRES_ACTION payments expire
+func WithExpiryPolicy(policy ExpiryPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Expiry = policy
+	}
+}
+
+// WithRequiredRefundReason makes the refund reason mandatory
+func WithRequiredRefundReason(required bool) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.RequireRefundReason = required
+	}
+}
+
+// WithRefundLedger records completed refunds into ledger for reporting
+func WithRefundLedger(ledger *reporting.RefundLedger) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Refunds = ledger
+	}
+}
+
+// WithTransactionStore replaces the default in-memory transaction store
+func WithTransactionStore(transactions store.Transactions) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Transactions = transactions
+	}
+}
+
+// WithCaptureStore replaces the in-memory capture store
+func WithCaptureStore(captures store.Captures) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Captures = captures
+	}
+}
+
+// WithRedactionPolicy replaces the default redaction policy; card numbers and
+// CVVs stay protected whatever the policy says
+func WithRedactionPolicy(policy *redact.Policy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Redaction = policy
+	}
+}
+
+// WithAuthenticator authenticates cardholders through a 3DS server before charging
+func WithAuthenticator(authenticator threeds.Authenticator) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Authenticator = authenticator
+	}
+}
+
+// WithScreeningPolicy screens payments for AML and sanctions before charging
+func WithScreeningPolicy(policy ScreeningPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Screening = policy
+	}
+}
+
+// WithTimings adds the validation, fraud, gateway and total processing
+// times to every payment response and error
+func WithTimings() Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Timings = true
+	}
+}
+
+// WithRawResponseCapture stores the sanitized raw provider response of every
+// payment, compressed by the blob store
+func WithRawResponseCapture(blobs *store.CompressedBlobs) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.RawResponses = blobs
+	}
+}
+
+// WithDescriptor sets the default statement descriptor, which may be a
+// template such as "ACME*{order_id}" filled from the request's order data
+func WithDescriptor(template string) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Descriptor = template
+	}
+}
+
+// WithIdempotency replaces the idempotency store and how long outcomes are
+// kept; a nil store ignores idempotency keys
+func WithIdempotency(policy IdempotencyPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Idempotency = policy
+	}
+}
+
+// WithBINTable replaces the prefix ranges that pick the provider of
+// payments without a Mode
+func WithBINTable(table *bin.Table) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.BINs = table
+	}
+}
+
+// WithNetworkProviders routes detected card networks to providers whose
+// names differ from the network, e.g. "discover" to "acquirer_x"
+func WithNetworkProviders(networks map[string]string) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.NetworkProviders = networks
+	}
+}
+
+// WithAuthorizationStrategy sets how much may be captured of payments of
+// an authorization type, e.g. CaptureTolerance(0.15) for estimated ones
+func WithAuthorizationStrategy(authorizationType string, strategy AuthorizationStrategy) Option {
+	return func(cfg *ProcessorConfig) {
+		authorizations := make(map[string]AuthorizationStrategy, len(cfg.Authorizations)+1)
+		for name, existing := range cfg.Authorizations {
+			authorizations[name] = existing
+		}
+		authorizations[authorizationType] = strategy
+		cfg.Authorizations = authorizations
+	}
+}
+
+// WithFailover configures backup providers for payments their provider
+// fails to process
+func WithFailover(policy FailoverPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Failover = policy
+	}
+}
+
+// WithDeclineGuidance replaces the built-in customer facing decline
+// messages, nil keeps them
+func WithDeclineGuidance(catalog *guidance.Catalog) Option {
+	return func(cfg *ProcessorConfig) {
+		if catalog != nil {
+			cfg.DeclineGuidance = catalog
+		}
+	}
+}
+
+// WithWebhookParser accepts the webhooks of the parser's provider in
+// HandleWebhook, replacing any parser registered for it
+func WithWebhookParser(parser webhooks.WebhookParser) Option {
+	return func(cfg *ProcessorConfig) {
+		parsers := make(map[string]webhooks.WebhookParser, len(cfg.Webhooks.Parsers)+1)
+		for name, existing := range cfg.Webhooks.Parsers {
+			parsers[name] = existing
+		}
+		parsers[parser.Provider()] = parser
+		cfg.Webhooks.Parsers = parsers
+	}
+}
+
+// WithWebhooks replaces the webhook policy, parsers included
+func WithWebhooks(policy WebhookPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Webhooks = policy
+	}
+}
+
+// WithPartialApproval sets what to do with partial approvals when neither
+// the request nor its merchant says
+func WithPartialApproval(behavior string) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.PartialApproval = behavior
+	}
+}
+
+// WithCredits enables standalone credits, see Credit
+func WithCredits(policy CreditPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Credits = policy
+	}
+}
+
+// WithFraudProvider checks payments with an external fraud service, see
+// FraudPolicy for the thresholds
+func WithFraudProvider(provider fraud.Provider) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Fraud.Provider = provider
+	}
+}
+
+// WithFraudPolicy replaces the fraud policy, provider included
+func WithFraudPolicy(policy FraudPolicy) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Fraud = policy
+	}
+}
+
+// WithLogger logs the processing of payments, e.g. to a *slog.Logger;
+// sensitive fields are redacted as the redaction policy says for logs
+func WithLogger(logger Logger) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Logger = logger
+	}
+}
+
+// WithPINTranslator translates the PIN blocks of debit payments to the
+// keys of their providers, e.g. through an HSM
+func WithPINTranslator(translator providers.PINTranslator) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.PINTranslator = translator
+	}
+}
+
+// WithMerchantAccounts gives a provider several merchant accounts, each
+// payment is submitted under the first one that takes it
+func WithMerchantAccounts(provider string, accounts ...providers.MerchantAccount) Option {
+	return func(cfg *ProcessorConfig) {
+		merchantAccounts := make(map[string][]providers.MerchantAccount, len(cfg.MerchantAccounts)+1)
+		for name, a := range cfg.MerchantAccounts {
+			merchantAccounts[name] = a
+		}
+		merchantAccounts[provider] = accounts
+		cfg.MerchantAccounts = merchantAccounts
+	}
+}
+
+// WithMetrics exports payment outcomes and gateway latencies, e.g. to a
+// metrics.Collector served at /metrics
+func WithMetrics(metrics Metrics) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Metrics = metrics
+	}
+}
+
+// WithFingerprinter fingerprints cards of stored transactions
+func WithFingerprinter(fingerprinter *fingerprint.Fingerprinter) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Fingerprinter = fingerprinter
+	}
+}
+
+// WithActionExpiry sets how long soft-declined payments wait for CompletePayment
+func WithActionExpiry(expiry time.Duration) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.ActionExpiry = expiry
+	}
+}
+
+// WithDecoding sets how provider responses with unknown fields are parsed
+func WithDecoding(decoding providers.Decoding) Option {
+	return func(cfg *ProcessorConfig) {
+		cfg.Decoding = decoding
+	}
+}
+
+// WithConfig replaces the whole configuration, later options still apply on
+// top. The providers given so far are kept when config has none.
+func WithConfig(config ProcessorConfig) Option {
+	return func(cfg *ProcessorConfig) {
+		if len(config.Providers) == 0 {
+			config.Providers = cfg.Providers
+		}
+		*cfg = config
+	}
+}
@@ -0,0 +1,113 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+func TestNewPaymentProcessor_Defaults(t *testing.T) {
+	processor := NewPaymentProcessor(nil)
+
+	config := processor.Config()
+	if config.DefaultTimeout != 30*time.Second {
+		t.Errorf("Expected default timeout of 30s, got %v", config.DefaultTimeout)
+	}
+
+	if config.Retry.MaxAttempts != 1 {
+		t.Errorf("Expected a single attempt by default, got %d", config.Retry.MaxAttempts)
+	}
+}
+
+func TestNewPaymentProcessor_WithProviders(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	if _, err := processor.getProvider("stub"); err != nil {
+		t.Errorf("Expected provider registered via option, got error: %v", err)
+	}
+}
+
+func TestNewPaymentProcessor_WithConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.DefaultTimeout = 5 * time.Second
+	processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stub")}, WithConfig(config))
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Fatalf("Expected the positional provider to be kept, got %+v", err)
+	}
+	if processor.Config().DefaultTimeout != 5*time.Second {
+		t.Errorf("Expected the configured timeout, got %v", processor.Config().DefaultTimeout)
+	}
+
+	config.Providers = []providers.Provider{newStubProvider("configured")}
+	processor = NewPaymentProcessor([]providers.Provider{newStubProvider("stub")}, WithConfig(config))
+	if _, err := processor.getProvider("stub"); err == nil {
+		t.Error("Expected the configured providers to replace the positional ones")
+	}
+}
+
+func TestProcessPayment_RetryPolicy(t *testing.T) {
+	transient := &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"}
+	stub := newStubProvider("stub", transient, nil)
+
+	processor := NewPaymentProcessor(nil,
+		WithProviders(stub),
+		WithRetryPolicy(RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			Retryable: func(paymentError *providers.PaymentError) bool {
+				return paymentError.ErrorCode == "GATEWAY_TIMEOUT"
+			},
+		}),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected retry to succeed, got error: %v", err)
+	}
+
+	if response.GatewayReference != "stub-tx" {
+		t.Errorf("Expected gateway reference 'stub-tx', got %s", response.GatewayReference)
+	}
+
+	if stub.callCount() != 2 {
+		t.Errorf("Expected 2 provider calls, got %d", stub.callCount())
+	}
+}
+
+func TestProcessPayment_DoNotRetryAdvice(t *testing.T) {
+	declined := (&providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"}).WithAdvice(providers.AdviceDoNotRetry)
+	stub := newStubProvider("stub", declined, nil)
+
+	processor := NewPaymentProcessor(nil,
+		WithProviders(stub),
+		WithRetryPolicy(RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			Retryable:   func(*providers.PaymentError) bool { return true },
+		}),
+	)
+
+	_, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err == nil || err.Advice != providers.AdviceDoNotRetry {
+		t.Fatalf("Expected the decline with its do-not-retry advice, got: %v", err)
+	}
+	if stub.callCount() != 1 {
+		t.Errorf("Expected do-not-retry advice to stop retries, got %d provider calls", stub.callCount())
+	}
+}
+
+func TestProcessPayment_NoRetryByDefault(t *testing.T) {
+	stub := newStubProvider("stub", &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"})
+	processor := NewPaymentProcessor([]providers.Provider{stub})
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err == nil {
+		t.Fatal("Expected error from provider")
+	}
+
+	if stub.callCount() != 1 {
+		t.Errorf("Expected 1 provider call, got %d", stub.callCount())
+	}
+}
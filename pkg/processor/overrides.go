@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"errors"
+	"strconv"
+
+	"pgas/pkg/api"
+	"pgas/pkg/audit"
+	"pgas/pkg/providers"
+)
+
+// OverrideAuthorizer accepts or rejects the credentials of a request carrying
+// overrides; returning nil authorizes all overrides on the request
+type OverrideAuthorizer = api.OverrideAuthorizer
+
+var errOverridesNotEnabled = errors.New("payment overrides are not enabled on this processor")
+
+// applyOverrides authorizes the request overrides, audits each of them and
+// returns the request and retry policy to use for this payment
+func (p *PaymentProcessor) applyOverrides(paymentReqest providers.PaymentRequest) (providers.PaymentRequest, RetryPolicy, *providers.PaymentError) {
+	retry := p.config.Retry
+
+	overrides := paymentReqest.Overrides
+	if overrides == nil {
+		return paymentReqest, retry, nil
+	}
+
+	authErr := errOverridesNotEnabled
+	if p.config.OverrideAuthorizer != nil {
+		authErr = p.config.OverrideAuthorizer(*overrides)
+	}
+	if authErr != nil {
+		p.recordAudit(audit.Entry{
+			Actor:   overrides.Actor,
+			Action:  "override.rejected",
+			Details: map[string]string{"reason": authErr.Error()},
+		})
+		return paymentReqest, retry, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "UNAUTHORIZED_OVERRIDE",
+			ErrorMessage: authErr.Error(),
+			Err:          authErr,
+		}
+	}
+
+	details := map[string]string{
+		"mode":     paymentReqest.Mode,
+		"amount":   strconv.FormatFloat(paymentReqest.Amount, 'f', -1, 64),
+		"currency": paymentReqest.Currency,
+	}
+
+	if overrides.ForceProvider != "" {
+		p.recordOverride(overrides.Actor, "override.force_provider", details, "provider", overrides.ForceProvider)
+		paymentReqest.Mode = overrides.ForceProvider
+	}
+
+	if overrides.DisableRetries {
+		p.recordOverride(overrides.Actor, "override.disable_retries", details, "", "")
+		retry.MaxAttempts = 1
+	}
+
+	if overrides.SkipFraudCheck {
+		p.recordOverride(overrides.Actor, "override.skip_fraud_check", details, "", "")
+	}
+
+	return paymentReqest, retry, nil
+}
+
+func (p *PaymentProcessor) recordOverride(actor string, action string, details map[string]string, key string, value string) {
+	entryDetails := make(map[string]string, len(details)+1)
+	for k, v := range details {
+		entryDetails[k] = v
+	}
+	if key != "" {
+		entryDetails[key] = value
+	}
+
+	p.recordAudit(audit.Entry{Actor: actor, Action: action, Details: entryDetails})
+}
+
+func (p *PaymentProcessor) recordAudit(entry audit.Entry) {
+	if p.config.AuditLog != nil {
+		p.config.AuditLog.Record(entry)
+	}
+}
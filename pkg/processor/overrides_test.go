@@ -0,0 +1,102 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/providers"
+)
+
+func tokenAuthorizer(token string) OverrideAuthorizer {
+	return func(overrides providers.Overrides) error {
+		if overrides.Token != token {
+			return errors.New("invalid override token")
+		}
+		return nil
+	}
+}
+
+func TestProcessPayment_OverridesRejectedWithoutAuthorizer(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	request := stubRequest("stub")
+	request.Overrides = &providers.Overrides{Actor: "billing-job", DisableRetries: true}
+
+	_, err := processor.ProcessPayment(context.Background(), request)
+	if err == nil || err.ErrorCode != "UNAUTHORIZED_OVERRIDE" {
+		t.Fatalf("Expected UNAUTHORIZED_OVERRIDE, got: %v", err)
+	}
+}
+
+func TestProcessPayment_ForceProviderOverride(t *testing.T) {
+	primary := newStubProvider("primary")
+	forced := newStubProvider("forced")
+	auditLog := audit.NewMemoryLog()
+
+	processor := NewPaymentProcessor(nil,
+		WithProviders(primary, forced),
+		WithAuditLog(auditLog),
+		WithOverrideAuthorizer(tokenAuthorizer("secret")),
+	)
+
+	request := stubRequest("primary")
+	request.Overrides = &providers.Overrides{Actor: "ops", Token: "secret", ForceProvider: "forced", SkipFraudCheck: true}
+
+	response, err := processor.ProcessPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected successful payment, got error: %v", err)
+	}
+
+	if response.GatewayReference != "forced-tx" || primary.callCount() != 0 {
+		t.Errorf("Expected forced provider to handle the payment, got %s", response.GatewayReference)
+	}
+
+	entries := auditLog.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %d", len(entries))
+	}
+
+	if entries[0].Action != "override.force_provider" || entries[0].Details["provider"] != "forced" || entries[0].Actor != "ops" {
+		t.Errorf("Unexpected audit entry: %+v", entries[0])
+	}
+
+	if entries[1].Action != "override.skip_fraud_check" {
+		t.Errorf("Unexpected audit entry: %+v", entries[1])
+	}
+}
+
+func TestProcessPayment_DisableRetriesOverride(t *testing.T) {
+	stub := newStubProvider("stub", &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"})
+	auditLog := audit.NewMemoryLog()
+
+	processor := NewPaymentProcessor(nil,
+		WithProviders(stub),
+		WithAuditLog(auditLog),
+		WithOverrideAuthorizer(tokenAuthorizer("secret")),
+		WithRetryPolicy(RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Millisecond,
+			Retryable:   func(*providers.PaymentError) bool { return true },
+		}),
+	)
+
+	request := stubRequest("stub")
+	request.Overrides = &providers.Overrides{Actor: "ops", Token: "secret", DisableRetries: true}
+	processor.ProcessPayment(context.Background(), request)
+
+	if stub.callCount() != 1 {
+		t.Errorf("Expected retries to be disabled, got %d calls", stub.callCount())
+	}
+
+	request.Overrides.Token = "wrong"
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "UNAUTHORIZED_OVERRIDE" {
+		t.Errorf("Expected UNAUTHORIZED_OVERRIDE for bad token, got: %v", err)
+	}
+
+	if rejected := auditLog.Filter("override.rejected"); len(rejected) != 1 {
+		t.Errorf("Expected rejected override to be audited, got %d entries", len(rejected))
+	}
+}
@@ -0,0 +1,101 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"pgas/pkg/providers"
+)
+
+var errVoidRejected = errors.New("the gateway rejected the void")
+
+// resolvePartialApproval settles what to do with a partial approval of the
+// payment: the request's choice, else its sub-merchant's, its platform's
+// and finally the processor's
+func (p *PaymentProcessor) resolvePartialApproval(paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	if paymentReqest.PartialApproval == "" && paymentReqest.SubMerchantID != "" && p.config.Merchants != nil {
+		if subMerchant, err := p.config.Merchants.SubMerchant(paymentReqest.SubMerchantID); err == nil {
+			paymentReqest.PartialApproval = subMerchant.PartialApproval
+			if paymentReqest.PartialApproval == "" {
+				if platform, err := p.config.Merchants.Merchant(subMerchant.PlatformID); err == nil {
+					paymentReqest.PartialApproval = platform.PartialApproval
+				}
+			}
+		}
+	}
+	if paymentReqest.PartialApproval == "" {
+		paymentReqest.PartialApproval = p.config.PartialApproval
+	}
+
+	if err := providers.ValidatePartialApproval(paymentReqest.PartialApproval); err != nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+	return paymentReqest, nil
+}
+
+// markPartialApproval flags approvals of less than the requested amount,
+// amounts in another currency are not compared
+func markPartialApproval(paymentReqest providers.PaymentRequest, response *providers.PaymentResponse) {
+	if response.Status != providers.StatusApproved || response.Amount <= 0 {
+		return
+	}
+	if response.Currency != "" && response.Currency != paymentReqest.Currency {
+		return
+	}
+	// half a minor unit absorbs rounding of gateways that answer in minor units
+	if paymentReqest.Amount-response.Amount < 0.005 {
+		return
+	}
+	response.PartialApproval = true
+	response.RequestedAmount = paymentReqest.Amount
+}
+
+// voidPartialApproval reverses a stored partial approval and fails the
+// payment. When the gateway cannot reverse it the payment stays APPROVED
+// for the approved amount and the error says so.
+func (p *PaymentProcessor) voidPartialApproval(ctx context.Context, paymentProvider providers.Provider, response *providers.PaymentResponse) *providers.PaymentError {
+	approved := formatAmount(response.Amount) + " of " + formatAmount(response.RequestedAmount) + " " + response.Currency
+
+	if timeout := p.providerTimeout(paymentProvider.GetName()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	refundResponse, err := paymentProvider.Refund(ctx, providers.RefundRequest{
+		Mode:          paymentProvider.GetName(),
+		TransactionID: response.GatewayReference,
+		Amount:        response.Amount,
+		Currency:      response.Currency,
+		Note:          "void of partial approval",
+	})
+	if err == nil && !refundResponse.Success {
+		err = errVoidRejected
+	}
+	if err != nil {
+		p.updateTransactionStatus(response.TransactionID, providers.StatusApproved, "void of partial approval failed: "+err.Error())
+		return &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "PARTIAL_APPROVAL_VOID_FAILED",
+			ErrorMessage: "issuer approved " + approved + " and the approval could not be voided, payment '" + response.TransactionID + "' stays approved: " + err.Error(),
+			Err:          err,
+		}
+	}
+
+	p.updateTransactionStatus(response.TransactionID, providers.StatusVoided, "partial approval of "+approved+" voided")
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "PARTIAL_APPROVAL_VOIDED",
+		ErrorMessage: "issuer approved " + approved + ", the approval was voided",
+		Reason:       providers.ReasonInsufficientFunds,
+	}
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', -1, 64)
+}
@@ -0,0 +1,129 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgas/pkg/merchant"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// prepaidProvider approves at most its balance and voids through refunds
+type prepaidProvider struct {
+	*stubProvider
+	balance float64
+	voids   []providers.RefundRequest
+	voidErr error
+}
+
+func (b *prepaidProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	if request.Amount > b.balance {
+		request.Amount = b.balance
+	}
+	return b.stubProvider.ProcessPayment(ctx, request)
+}
+
+func (b *prepaidProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	if b.voidErr != nil {
+		return nil, b.voidErr
+	}
+	b.voids = append(b.voids, request)
+	return &providers.RefundResponse{Success: true, RefundID: "void-1", TransactionID: request.TransactionID, Amount: request.Amount, Currency: request.Currency}, nil
+}
+
+func TestProcessPayment_PartialApprovalAccepted(t *testing.T) {
+	provider := &prepaidProvider{stubProvider: newStubProvider("stub"), balance: 40}
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected the partial approval to be accepted, got %v", err)
+	}
+	if !response.PartialApproval || response.Amount != 40 || response.RequestedAmount != 100 {
+		t.Errorf("Expected 40 of 100 approved, got %+v", response)
+	}
+	if tx, _ := processor.Transactions().Get(response.TransactionID); tx.Amount != 40 || tx.RequestedAmount != 100 || tx.Status != providers.StatusApproved {
+		t.Errorf("Expected the approved amount stored, got %+v", tx)
+	}
+
+	provider.balance = 500
+	if response, _ = processor.ProcessPayment(context.Background(), stubRequest("stub")); response.PartialApproval || response.RequestedAmount != 0 {
+		t.Errorf("Expected full approvals not to be flagged, got %+v", response)
+	}
+	if len(provider.voids) != 0 {
+		t.Errorf("Expected nothing voided, got %+v", provider.voids)
+	}
+}
+
+func TestProcessPayment_PartialApprovalVoided(t *testing.T) {
+	provider := &prepaidProvider{stubProvider: newStubProvider("stub"), balance: 40}
+	processor := NewPaymentProcessor(nil, WithProviders(provider), WithPartialApproval(providers.PartialApprovalVoid))
+
+	_, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err == nil || err.ErrorCode != "PARTIAL_APPROVAL_VOIDED" || err.Reason != providers.ReasonInsufficientFunds {
+		t.Fatalf("Expected the partial approval voided, got %v", err)
+	}
+	if len(provider.voids) != 1 || provider.voids[0].Amount != 40 || provider.voids[0].TransactionID != "stub-tx" {
+		t.Errorf("Expected the approved amount voided, got %+v", provider.voids)
+	}
+	if tx := lastTransaction(processor); tx.Status != providers.StatusVoided || tx.GatewayReference != "stub-tx" {
+		t.Errorf("Expected the payment stored as voided, got %s", tx.Status)
+	}
+
+	// the request's own choice wins over the processor's
+	request := stubRequest("stub")
+	request.PartialApproval = providers.PartialApprovalAccept
+	if response, err := processor.ProcessPayment(context.Background(), request); err != nil || !response.PartialApproval {
+		t.Errorf("Expected the request to accept the partial approval, got %+v (%v)", response, err)
+	}
+
+	provider.voidErr = errors.New("gateway down")
+	_, err = processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err == nil || err.ErrorCode != "PARTIAL_APPROVAL_VOID_FAILED" {
+		t.Fatalf("Expected the failed void reported, got %v", err)
+	}
+	if tx := lastTransaction(processor); tx.Status != providers.StatusApproved {
+		t.Errorf("Expected the payment to stay approved when the void fails, got %s", tx.Status)
+	}
+}
+
+func TestProcessPayment_PartialApprovalPerMerchant(t *testing.T) {
+	registry := merchant.NewRegistry()
+	registry.AddMerchant(merchant.Merchant{ID: "plat_1", PartialApproval: providers.PartialApprovalVoid})
+	registry.AddSubMerchant(merchant.SubMerchant{ID: "sub_1", PlatformID: "plat_1", Active: true})
+	registry.AddSubMerchant(merchant.SubMerchant{ID: "sub_2", PlatformID: "plat_1", Active: true, PartialApproval: providers.PartialApprovalAccept})
+	if err := registry.AddSubMerchant(merchant.SubMerchant{ID: "sub_3", PlatformID: "plat_1", PartialApproval: "decline"}); err == nil {
+		t.Error("Expected unknown behaviors to be rejected")
+	}
+
+	provider := &prepaidProvider{stubProvider: newStubProvider("stub"), balance: 40}
+	processor := NewPaymentProcessor(nil, WithProviders(provider), WithMerchantRegistry(registry))
+
+	request := stubRequest("stub")
+	request.SubMerchantID = "sub_1"
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "PARTIAL_APPROVAL_VOIDED" {
+		t.Errorf("Expected the platform's behavior, got %v", err)
+	}
+
+	request.SubMerchantID = "sub_2"
+	if response, err := processor.ProcessPayment(context.Background(), request); err != nil || !response.PartialApproval {
+		t.Errorf("Expected the sub-merchant's behavior, got %+v (%v)", response, err)
+	}
+
+	request.PartialApproval = "decline"
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_REQUEST" {
+		t.Errorf("Expected INVALID_REQUEST for an unknown behavior, got %v", err)
+	}
+}
+
+// lastTransaction is the latest stored payment, for outcomes returned
+// without a transaction id
+func lastTransaction(processor *PaymentProcessor) store.Transaction {
+	stored, _ := processor.Transactions().Query(store.TransactionFilter{})
+	if len(stored) == 0 {
+		return store.Transaction{}
+	}
+	return stored[len(stored)-1]
+}
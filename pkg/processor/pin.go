@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"context"
+	"slices"
+
+	"pgas/pkg/providers"
+)
+
+// preparePIN checks the PIN block of a debit payment against its provider
+// and translates it to the provider's key and format. Blocks only go to
+// providers implementing providers.PINAcceptor; those encrypted under
+// another key need the configured PINTranslator. The caller's block is
+// never modified, the request gets a translated copy.
+func (p *PaymentProcessor) preparePIN(ctx context.Context, paymentProvider providers.Provider, paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	pin := paymentReqest.PINBlock
+	if pin == nil {
+		return paymentReqest, nil
+	}
+
+	if err := providers.ValidatePINBlock(pin); err != nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	acceptor, ok := paymentProvider.(providers.PINAcceptor)
+	if !ok {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "PIN_NOT_SUPPORTED",
+			ErrorMessage: "provider '" + paymentProvider.GetName() + "' does not accept PINs",
+		}
+	}
+
+	format := pin.Format
+	if formats := acceptor.PINFormats(); len(formats) > 0 && !slices.Contains(formats, format) {
+		format = formats[0]
+	}
+	if pin.KeyID == acceptor.PINKey() && format == pin.Format {
+		return paymentReqest, nil
+	}
+
+	if p.config.PINTranslator == nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "PIN_NOT_SUPPORTED",
+			ErrorMessage: "provider '" + paymentProvider.GetName() + "' needs " + format + " PIN blocks under its own key and no PIN translator is configured",
+		}
+	}
+
+	if p.config.DefaultTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.DefaultTimeout)
+		defer cancel()
+	}
+
+	translated, err := p.config.PINTranslator.TranslatePIN(ctx, *pin, paymentReqest.CardNumber, acceptor.PINKey(), format)
+	if err != nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "PIN_TRANSLATION_ERROR",
+			ErrorMessage: "PIN block could not be translated: " + err.Error(),
+			Err:          err,
+			Reason:       providers.ReasonProcessingError,
+		}
+	}
+	paymentReqest.PINBlock = &translated
+	return paymentReqest, nil
+}
@@ -0,0 +1,135 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+// debitProvider takes ISO-0 PIN blocks under its zone key "zpk-debit"
+type debitProvider struct {
+	*stubProvider
+}
+
+func (debitProvider) PINKey() string       { return "zpk-debit" }
+func (debitProvider) PINFormats() []string { return []string{providers.PINFormatISO0} }
+
+// stubTranslator "translates" by rewriting the key id and format
+type stubTranslator struct {
+	err   error
+	calls int
+}
+
+func (s *stubTranslator) TranslatePIN(ctx context.Context, pin providers.PINBlock, cardNumber, keyID, format string) (providers.PINBlock, error) {
+	s.calls++
+	if s.err != nil {
+		return providers.PINBlock{}, s.err
+	}
+	return providers.PINBlock{Format: format, Block: "FEDCBA9876543210", KeyID: keyID}, nil
+}
+
+func pinRequest(mode, keyID string) providers.PaymentRequest {
+	request := stubRequest(mode)
+	request.CVV = ""
+	request.PINBlock = &providers.PINBlock{Format: providers.PINFormatISO0, Block: "0412AC89ABCDEF67", KeyID: keyID}
+	return request
+}
+
+func TestPIN_OnlyDebitProviders(t *testing.T) {
+	credit := newStubProvider("visa")
+	processor := NewPaymentProcessor([]providers.Provider{credit})
+
+	_, paymentError := processor.ProcessPayment(context.Background(), pinRequest("visa", "zpk-debit"))
+	if paymentError == nil || paymentError.ErrorCode != "PIN_NOT_SUPPORTED" {
+		t.Fatalf("Expected PIN_NOT_SUPPORTED, got %+v", paymentError)
+	}
+	if credit.callCount() != 0 {
+		t.Error("Expected the PIN block not to reach a provider without debit support")
+	}
+}
+
+func TestPIN_ProviderKeyPassesThrough(t *testing.T) {
+	debit := debitProvider{newStubProvider("debit")}
+	translator := &stubTranslator{}
+	processor := NewPaymentProcessor([]providers.Provider{debit}, WithPINTranslator(translator))
+
+	if _, paymentError := processor.ProcessPayment(context.Background(), pinRequest("debit", "zpk-debit")); paymentError != nil {
+		t.Fatalf("Expected the payment to be approved, got %+v", paymentError)
+	}
+	if translator.calls != 0 || debit.received().PINBlock.Block != "0412AC89ABCDEF67" {
+		t.Errorf("Expected the block to be sent as is, got %d translations", translator.calls)
+	}
+}
+
+func TestPIN_TranslatedToProviderKey(t *testing.T) {
+	debit := debitProvider{newStubProvider("debit")}
+	processor := NewPaymentProcessor([]providers.Provider{debit}, WithPINTranslator(&stubTranslator{}))
+
+	request := pinRequest("debit", "tpk-store-7")
+	response, paymentError := processor.ProcessPayment(context.Background(), request)
+	if paymentError != nil {
+		t.Fatalf("Expected the payment to be approved, got %+v", paymentError)
+	}
+
+	sent := debit.received().PINBlock
+	if sent.KeyID != "zpk-debit" || sent.Block != "FEDCBA9876543210" {
+		t.Errorf("Expected the provider to get the translated block, got %+v", sent)
+	}
+	if request.PINBlock.KeyID != "tpk-store-7" {
+		t.Error("Expected the caller's block to stay untouched")
+	}
+
+	tx, err := processor.Transactions().Get(response.TransactionID)
+	if err != nil {
+		t.Fatalf("Expected the transaction to be stored: %v", err)
+	}
+	stored, _ := json.Marshal(tx)
+	if strings.Contains(string(stored), "0412AC89ABCDEF67") || strings.Contains(string(stored), "FEDCBA9876543210") {
+		t.Errorf("Expected no PIN material in the stored transaction, got %s", stored)
+	}
+}
+
+func TestPIN_TranslationErrors(t *testing.T) {
+	debit := debitProvider{newStubProvider("debit")}
+
+	untranslated := NewPaymentProcessor([]providers.Provider{debit})
+	_, paymentError := untranslated.ProcessPayment(context.Background(), pinRequest("debit", "tpk-store-7"))
+	if paymentError == nil || paymentError.ErrorCode != "PIN_NOT_SUPPORTED" {
+		t.Errorf("Expected PIN_NOT_SUPPORTED without a translator, got %+v", paymentError)
+	}
+
+	failing := NewPaymentProcessor([]providers.Provider{debit}, WithPINTranslator(&stubTranslator{err: errors.New("hsm unavailable")}))
+	_, paymentError = failing.ProcessPayment(context.Background(), pinRequest("debit", "tpk-store-7"))
+	if paymentError == nil || paymentError.ErrorCode != "PIN_TRANSLATION_ERROR" {
+		t.Errorf("Expected PIN_TRANSLATION_ERROR, got %+v", paymentError)
+	}
+
+	request := pinRequest("debit", "zpk-debit")
+	request.PINBlock.Block = "0412"
+	_, paymentError = failing.ProcessPayment(context.Background(), request)
+	if paymentError == nil || paymentError.ErrorCode != "INVALID_REQUEST" {
+		t.Errorf("Expected a short block to be rejected, got %+v", paymentError)
+	}
+	if debit.callCount() != 0 {
+		t.Errorf("Expected no gateway calls, got %d", debit.callCount())
+	}
+}
+
+func TestPIN_NoFailover(t *testing.T) {
+	primary := debitProvider{newStubProvider("debit", gatewayDown)}
+	backup := debitProvider{newStubProvider("debit-backup")}
+	processor := NewPaymentProcessor([]providers.Provider{primary, backup},
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"debit": {"debit-backup"}}}))
+
+	_, paymentError := processor.ProcessPayment(context.Background(), pinRequest("debit", "zpk-debit"))
+	if paymentError == nil || paymentError.ErrorCode != "PROCESSING_ERROR" {
+		t.Fatalf("Expected the gateway failure, got %+v", paymentError)
+	}
+	if backup.callCount() != 0 {
+		t.Error("Expected a PIN block translated for one provider not to fail over")
+	}
+}
@@ -2,40 +2,172 @@ package processor
 
 import (
 	"context"
-	"errors"
+	"log/slog"
+	"pgas/pkg/chaos"
 	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+	"pgas/pkg/stats"
+	"pgas/pkg/store"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
+// PaymentProcessor keeps the payment path free of processor wide locks:
+// state read on every payment is copy-on-write or sharded, mutexes are only
+// taken to change it.
 type PaymentProcessor struct {
-	providers map[string]providers.Provider
+	providersMu sync.Mutex // serializes registry changes
+	providers   atomic.Pointer[map[string]providers.Provider]
+	config      ProcessorConfig
+	readOnly    atomic.Bool
+	outcomes    stats.Counters // payments per outcome, see Counters
+
+	unresolvedMu sync.Mutex
+	unresolved   map[string]UnresolvedPayment
+
+	actionsMu sync.Mutex
+	actions   map[string]pendingAction
+
+	draining     sync.Map       // names of providers being drained
+	payments     stats.Counters // payments admitted past the drain check per provider, see admit
+	calls        stats.Counters // gateway calls in flight per provider
+	callOutcomes stats.Counters // gateway calls per "provider|outcome", see Providers
+	pendingCalls sync.Map       // *InDoubtPayment of each call in flight, see InDoubt
+
+	canaryMu sync.Mutex // serializes canary changes
+	canaries sync.Map   // provider name -> *canary
+
+	installmentPlans *store.Memory[string, previewedPlan] // by plan ID
+
+	retryBudget *retryBudget // nil without a budget
 }
 
-func NewPaymentProcessor(paymentProviders []providers.Provider) *PaymentProcessor {
+func NewPaymentProcessor(paymentProviders []providers.Provider, opts ...Option) *PaymentProcessor {
+	config := DefaultConfig()
+	config.Providers = append(config.Providers, paymentProviders...)
+
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if config.Retry.MaxAttempts < 1 {
+		config.Retry.MaxAttempts = 1
+	}
+
 	newProvider := &PaymentProcessor{
-		providers: make(map[string]providers.Provider),
+		config:     config,
+		unresolved: make(map[string]UnresolvedPayment),
+		actions:    make(map[string]pendingAction),
+		installmentPlans: store.NewMemory[string, previewedPlan](store.MemoryOptions{
+			TTL:        installmentPlanTTL,
+			MaxEntries: 100000,
+		}),
+		retryBudget: newRetryBudget(config.RetryBudget),
 	}
 
-	newProvider.registerProviders(paymentProviders)
+	newProvider.registerProviders(config.Providers)
+	newProvider.readOnly.Store(config.ReadOnly)
 
 	return newProvider
 }
 
-func (p *PaymentProcessor) registerProviders(providers []providers.Provider) {
-	for _, provider := range providers {
-		p.providers[provider.GetName()] = provider
-	}
+// Config returns the configuration the processor was built with
+func (p *PaymentProcessor) Config() ProcessorConfig {
+	return p.config
 }
 
-func (p *PaymentProcessor) getProvider(requiredProvider string) (providers.Provider, error) {
-	pr := p.providers[requiredProvider]
-	if pr == nil {
-		return nil, errors.New("invalid provider name provided: '" + requiredProvider + "'")
+// ProcessPayment charges a card. ctx bounds the whole payment, retries
+// included, and is passed on to the provider; each gateway call is further
+// bounded by the provider's timeout.
+func (p *PaymentProcessor) ProcessPayment(ctx context.Context, paymentReqest providers.PaymentRequest) (*providers.PaymentResponse, *providers.PaymentError) {
+	if response, paymentError, duplicate := p.reserveIdempotencyKey(paymentReqest); duplicate {
+		return response, paymentError
 	}
 
-	return pr, nil
+	var timer *stageTimer
+	if p.config.Timings {
+		timer = newStageTimer()
+	}
+	started := time.Now()
+	response, paymentError := timer.attach(p.processPayment(ctx, paymentReqest, timer, &paymentTrace{}))
+	response, paymentError = p.finishPayment(ctx, paymentReqest, response, paymentError, started)
+	p.completeIdempotencyKey(paymentReqest, response, paymentError)
+	return response, paymentError
+}
+
+// finishPayment scrubs card data from the outcome of a payment handed back
+// to the caller, then logs, counts and observes it
+func (p *PaymentProcessor) finishPayment(ctx context.Context, paymentReqest providers.PaymentRequest, response *providers.PaymentResponse, paymentError *providers.PaymentError, started time.Time) (*providers.PaymentResponse, *providers.PaymentError) {
+	response, paymentError = sanitizeOutcome(paymentReqest, response, paymentError)
+	p.logOutcome(ctx, paymentReqest, response, paymentError, time.Since(started).Milliseconds())
+	p.countOutcome(response, paymentError)
+	p.observePayment(paymentReqest, response, paymentError)
+	return response, paymentError
 }
 
-func (p *PaymentProcessor) ProcessPayment(paymentReqest providers.PaymentRequest) (*providers.PaymentResponse, *providers.PaymentError) {
+func (p *PaymentProcessor) processPayment(ctx context.Context, paymentReqest providers.PaymentRequest, timer *stageTimer, trace *paymentTrace) (*providers.PaymentResponse, *providers.PaymentError) {
+
+	budget := newLatencyBudget(paymentReqest.LatencyBudgetMs, p.config.Budget)
+
+	if ctx.Err() != nil {
+		return nil, cancelled(ctx)
+	}
+
+	if p.ReadOnly() {
+		return nil, readOnlyError()
+	}
+
+	if metadataError := validateMetadata(paymentReqest); metadataError != nil {
+		return nil, metadataError
+	}
+
+	// how the provider is chosen, for the payment's timeline
+	via, mode := "request", paymentReqest.Mode
+	choose := func(how string) {
+		if paymentReqest.Mode != mode {
+			via, mode = how, paymentReqest.Mode
+		}
+	}
+
+	paymentReqest, retry, overrideError := p.applyOverrides(paymentReqest)
+	if overrideError != nil {
+		return nil, overrideError
+	}
+	choose("override")
+
+	paymentReqest, subMerchantError := p.resolveSubMerchant(paymentReqest)
+	if subMerchantError != nil {
+		return nil, subMerchantError
+	}
+
+	paymentReqest, partialError := p.resolvePartialApproval(paymentReqest)
+	if partialError != nil {
+		return nil, partialError
+	}
+
+	paymentReqest, descriptorError := p.renderDescriptor(paymentReqest)
+	if descriptorError != nil {
+		return nil, descriptorError
+	}
+
+	paymentReqest, planError := p.applyInstallmentPlan(paymentReqest)
+	if planError != nil {
+		return nil, planError
+	}
+	choose("installment_plan")
+
+	paymentReqest, detectionError := p.detectProvider(paymentReqest)
+	if detectionError != nil {
+		return nil, detectionError
+	}
+	choose("bin")
+
+	paymentReqest, routingError := p.route(ctx, paymentReqest)
+	if routingError != nil {
+		return nil, routingError
+	}
+	choose("router")
 
 	paymentProvider, err := p.getProvider(paymentReqest.Mode)
 	if err != nil {
@@ -43,45 +175,293 @@ func (p *PaymentProcessor) ProcessPayment(paymentReqest providers.PaymentRequest
 			Success:      false,
 			ErrorCode:    "INVALID_PROVIDER",
 			ErrorMessage: err.Error(),
+			Err:          err,
 		}
 	}
 
+	release, admitted := p.admit(paymentProvider.GetName())
+	if !admitted {
+		return nil, drainingError(paymentProvider.GetName())
+	}
+	defer release()
+
+	if authorizationError := p.checkAuthorizationType(paymentProvider, paymentReqest); authorizationError != nil {
+		return nil, authorizationError
+	}
+
+	paymentReqest, pinError := p.preparePIN(ctx, paymentProvider, paymentReqest)
+	if pinError != nil {
+		return nil, pinError
+	}
+
+	paymentReqest, accountError := p.selectMerchantAccount(paymentProvider, paymentReqest)
+	if accountError != nil {
+		return nil, accountError
+	}
+
+	providers.ApplyAuthentication(&paymentReqest)
 	validationError := paymentProvider.ValidateRequest(paymentReqest)
 	if validationError != nil {
+		p.log(ctx, slog.LevelWarn, LogValidationFailed, "provider", paymentProvider.GetName(), "error", redact.Scrub(validationError.Error(), paymentReqest.CardNumber, paymentReqest.CVV))
 		return nil, &providers.PaymentError{
 			Success:      false,
 			ErrorCode:    "INVALID_REQUEST",
 			ErrorMessage: validationError.Error(),
+			Err:          validationError,
 		}
 	}
 
-	ctx := context.Background()
+	if priorError := p.checkPriorTransaction(paymentReqest); priorError != nil {
+		return nil, priorError
+	}
 
-	processResponse, processError := paymentProvider.ProcessPayment(ctx, paymentReqest)
+	if budgetError := budget.checkStage("validation"); budgetError != nil {
+		return nil, budgetError
+	}
+	timer.validation()
+	trace.validated()
+	trace.routed(paymentProvider.GetName(), via)
+	p.log(ctx, slog.LevelInfo, LogProviderSelected, "provider", paymentProvider.GetName(), "via", via)
 
-	if processError != nil {
+	flagged, screeningError := p.screen(ctx, paymentReqest)
+	if screeningError != nil {
+		timer.fraud()
+		return nil, screeningError
+	}
 
-		parseErrorRes, parseErroErr := paymentProvider.ParseErrorResponse(processError)
-		if parseErroErr != nil {
-			return nil, &providers.PaymentError{
-				Success:      false,
-				ErrorCode:    "PROCESSING_ERROR",
-				ErrorMessage: parseErroErr.Error(),
+	fraudChecked, fraudError := p.checkFraud(ctx, paymentReqest, budget)
+	if fraudError != nil {
+		timer.fraud()
+		return nil, fraudError
+	}
+
+	paymentReqest, authError := p.authenticate(ctx, paymentProvider, paymentReqest)
+	timer.fraud()
+	if authError != nil {
+		return nil, authError
+	}
+
+	if budgetError := budget.checkStage("fraud"); budgetError != nil {
+		return nil, budgetError
+	}
+
+	started := time.Now()
+	id := newTransactionID()
+
+	successResponse, paymentError := p.charge(ctx, paymentProvider, id, paymentReqest, retry, budget, timer, trace)
+	for _, name := range p.fallbacks(paymentReqest) {
+		if paymentError == nil || ctx.Err() != nil || !p.failsOver(paymentError) {
+			break
+		}
+		fallback, fallbackReqest, ok := p.fallbackProvider(name, paymentReqest)
+		if !ok {
+			continue
+		}
+		release, admitted := p.admit(name)
+		if !admitted {
+			continue
+		}
+		defer release()
+		paymentProvider, paymentReqest = fallback, fallbackReqest
+		trace.routed(name, "fallback")
+		p.log(ctx, slog.LevelInfo, LogProviderSelected, "provider", name, "via", "fallback")
+		successResponse, paymentError = p.charge(ctx, paymentProvider, id, paymentReqest, retry, budget, timer, trace)
+	}
+
+	if paymentError == nil {
+		successResponse = p.completeResponse(ctx, paymentProvider, paymentReqest, successResponse)
+		markFlagged(successResponse, flagged)
+		markFraudCheck(successResponse, fraudChecked)
+		p.recordTransaction(paymentReqest, successResponse, nil, time.Since(started), trace)
+		if successResponse.PartialApproval && paymentReqest.PartialApproval == providers.PartialApprovalVoid {
+			return nil, p.voidPartialApproval(ctx, paymentProvider, successResponse)
+		}
+		return successResponse, nil
+	}
+
+	// PIN blocks are not kept for a challenge or the forward queue, PIN
+	// payments are neither parked nor deferred
+	if paymentReqest.PINBlock == nil && p.shouldStepUp(paymentError) {
+		if actionResponse := p.stepUp(ctx, paymentProvider, paymentReqest, time.Since(started), trace); actionResponse != nil {
+			return actionResponse, nil
+		}
+	}
+
+	if p.shouldDefer(paymentReqest, paymentError) {
+		deferredResponse, deferError := p.deferPayment(ctx, paymentReqest, paymentError)
+		if deferredResponse != nil {
+			p.enrich(ctx, paymentReqest, deferredResponse)
+			markFlagged(deferredResponse, flagged)
+			markFraudCheck(deferredResponse, fraudChecked)
+		}
+		p.recordTransaction(paymentReqest, deferredResponse, deferError, time.Since(started), trace)
+		return deferredResponse, deferError
+	}
+
+	p.recordTransaction(paymentReqest, nil, paymentError, time.Since(started), trace)
+	return nil, paymentError
+}
+
+// completeResponse finishes the response of an answered charge: an UNKNOWN
+// status is queried for, and the response names the provider, sub-merchant
+// and merchant account and carries the enrichers' fields
+func (p *PaymentProcessor) completeResponse(ctx context.Context, paymentProvider providers.Provider, paymentReqest providers.PaymentRequest, response *providers.PaymentResponse) *providers.PaymentResponse {
+	if response.Status == providers.StatusUnknown {
+		response = p.resolveUnknown(ctx, paymentProvider, response)
+	}
+	response.Provider = paymentProvider.GetName()
+	response.SubMerchantID = paymentReqest.SubMerchantID
+	response.MerchantAccount = merchantAccountName(paymentReqest)
+	markPartialApproval(paymentReqest, response)
+	p.enrich(ctx, paymentReqest, response)
+	return response
+}
+
+// charge calls the provider's gateway, retrying failed attempts as the
+// retry policy, the latency budget and the retry budget allow. It returns
+// the outcome of the last attempt, approved under the local id.
+func (p *PaymentProcessor) charge(ctx context.Context, paymentProvider providers.Provider, id string, paymentReqest providers.PaymentRequest, retry RetryPolicy, budget *latencyBudget, timer *stageTimer, trace *paymentTrace) (*providers.PaymentResponse, *providers.PaymentError) {
+	var paymentError *providers.PaymentError
+	backoff := retry.Backoff
+
+	for attempt := 1; attempt <= retry.MaxAttempts; attempt++ {
+		if ctx.Err() != nil {
+			// a retry abandoned by the caller reports the last attempt's outcome
+			if paymentError == nil {
+				paymentError = cancelled(ctx)
 			}
+			break
 		}
 
-		return nil, parseErrorRes
+		var successResponse *providers.PaymentResponse
+		timeout := budget.attemptTimeout(p.providerTimeout(paymentProvider.GetName()), retry.MaxAttempts-attempt+1)
+		if timeout < 0 {
+			paymentError = budgetExceeded("gateway")
+			break
+		}
+
+		// a drain that began after the payment was admitted stops its
+		// gateway calls, retries included
+		if p.Draining(paymentProvider.GetName()) {
+			paymentError = drainingError(paymentProvider.GetName())
+			break
+		}
 
+		if attempt == 1 {
+			p.retryBudget.primary()
+		}
+		attempted := time.Now()
+		successResponse, paymentError = p.attemptPayment(ctx, paymentProvider, id, paymentReqest, timeout)
+		timer.gateway()
+		trace.attempt(paymentProvider.GetName(), attempted, successResponse, paymentError)
+		p.logCall(ctx, paymentProvider.GetName(), attempt, attempted, successResponse, paymentError)
+		if paymentError == nil {
+			return successResponse, nil
+		}
+
+		if attempt == retry.MaxAttempts || !retry.retryable(paymentError) || !budget.allows(backoff) || !p.retryBudget.allow() {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(backoff):
+		}
+		timer.skip()
+		backoff *= 2
 	}
 
-	successResponse, successParseError := paymentProvider.ParseSuccessResponse(processResponse)
-	if successParseError != nil {
+	return nil, paymentError
+}
+
+// retryable never retries against do-not-retry advice, whatever the
+// configured decision says
+func (r RetryPolicy) retryable(paymentError *providers.PaymentError) bool {
+	return r.Retryable != nil && !paymentError.DoNotRetry() && r.Retryable(paymentError)
+}
+
+// attemptPayment performs a single gateway call, on the canary configuration
+// of the provider when one is picked
+func (p *PaymentProcessor) attemptPayment(ctx context.Context, paymentProvider providers.Provider, id string, paymentReqest providers.PaymentRequest, timeout time.Duration) (*providers.PaymentResponse, *providers.PaymentError) {
+	paymentProvider, report := p.canaryArm(ctx, paymentProvider)
+
+	started := time.Now()
+	response, paymentError := p.callProvider(ctx, paymentProvider, id, paymentReqest, timeout)
+	report(response, paymentError)
+	p.countCall(paymentProvider.GetName(), response, paymentError)
+	p.observeCall(paymentProvider.GetName(), response, paymentError, time.Since(started))
+
+	return response, paymentError
+}
+
+// callProvider performs a single gateway call and normalizes its outcome.
+// An approved payment gets id as its transaction id, the gateway's id is
+// kept as its reference. A call still unanswered when its timeout or ctx
+// ends, or whose answer is lost or unreadable, is UNKNOWN, see inDoubt.
+// Errors are either raised before the payment was submitted or are the
+// gateway's own answer.
+func (p *PaymentProcessor) callProvider(ctx context.Context, paymentProvider providers.Provider, id string, paymentReqest providers.PaymentRequest, timeout time.Duration) (*providers.PaymentResponse, *providers.PaymentError) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	defer p.trackCall(paymentProvider.GetName(), paymentReqest)()
+
+	if chaosErr := p.config.Chaos.Inject(ctx, chaos.BeforeProvider); chaosErr != nil {
 		return nil, &providers.PaymentError{
 			Success:      false,
-			ErrorCode:    "PARSING_ERROR",
-			ErrorMessage: successParseError.Error(),
+			ErrorCode:    "PROCESSING_ERROR",
+			ErrorMessage: chaosErr.Error(),
+			Err:          chaosErr,
+		}
+	}
+
+	// the call is abandoned when ctx ends, providers need not watch it
+	type answer struct{ response, err interface{} }
+	answered := make(chan answer, 1)
+	go func() {
+		response, err := paymentProvider.ProcessPayment(ctx, paymentReqest)
+		answered <- answer{response, err}
+	}()
+
+	var processResponse, processError interface{}
+	select {
+	case result := <-answered:
+		processResponse, processError = result.response, result.err
+	case <-ctx.Done():
+		return inDoubt(id, paymentReqest, cancelled(ctx).ErrorCode), nil
+	}
+	if processError != nil && ctx.Err() != nil {
+		// a provider giving up on ctx does not know the outcome either
+		return inDoubt(id, paymentReqest, cancelled(ctx).ErrorCode), nil
+	}
+
+	// from here on the gateway has the payment: an answer that is lost or
+	// cannot be read leaves it in doubt, it may have been charged
+	if chaosErr := p.config.Chaos.Inject(ctx, chaos.AfterProvider); chaosErr != nil {
+		p.logUnreadable(ctx, paymentProvider, paymentReqest, chaosErr)
+		return inDoubt(id, paymentReqest, "PROCESSING_ERROR"), nil
+	}
+
+	if processError != nil {
+		parseErrorRes, parseErroErr := paymentProvider.ParseErrorResponse(processError)
+		if parseErroErr != nil {
+			p.logUnreadable(ctx, paymentProvider, paymentReqest, parseErroErr)
+			return inDoubt(id, paymentReqest, "PROCESSING_ERROR"), nil
 		}
+
+		return nil, parseErrorRes
+	}
+
+	p.captureRawResponse(id, processResponse)
+	successResponse, successParseError := paymentProvider.ParseSuccessResponse(processResponse)
+	if successParseError != nil {
+		p.logUnreadable(ctx, paymentProvider, paymentReqest, successParseError)
+		return inDoubt(id, paymentReqest, "PARSING_ERROR"), nil
 	}
+	successResponse.GatewayReference, successResponse.TransactionID = successResponse.TransactionID, id
 
 	return successResponse, nil
 }
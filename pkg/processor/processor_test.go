@@ -1,8 +1,10 @@
 package processor
 
 import (
+	"context"
 	"testing"
 
+	"pgas/pkg/chaos"
 	"pgas/pkg/providers"
 	"pgas/pkg/providers/mastercard"
 	"pgas/pkg/providers/visa"
@@ -67,11 +69,11 @@ func TestProcessPayment_Success(t *testing.T) {
 		Currency:    "USD",
 		CardNumber:  "4111111111111111",
 		ExpiryMonth: "12",
-		ExpiryYear:  "2025",
+		ExpiryYear:  "2030",
 		CVV:         "123",
 	}
 
-	response, err := processor.ProcessPayment(request)
+	response, err := processor.ProcessPayment(context.Background(), request)
 	if err != nil {
 		t.Fatalf("Expected successful payment, got error: %v", err)
 	}
@@ -111,11 +113,11 @@ func TestProcessPayment_InvalidProvider(t *testing.T) {
 		Currency:    "USD",
 		CardNumber:  "4111111111111111",
 		ExpiryMonth: "12",
-		ExpiryYear:  "2025",
+		ExpiryYear:  "2030",
 		CVV:         "123",
 	}
 
-	_, err := processor.ProcessPayment(request)
+	_, err := processor.ProcessPayment(context.Background(), request)
 	if err == nil {
 		t.Fatal("Expected error for invalid provider")
 	}
@@ -136,11 +138,11 @@ func TestProcessPayment_ValidationError(t *testing.T) {
 		Currency:    "USD",
 		CardNumber:  "5555555555554444",
 		ExpiryMonth: "12",
-		ExpiryYear:  "2025",
+		ExpiryYear:  "2030",
 		CVV:         "123",
 	}
 
-	_, err := processor.ProcessPayment(request)
+	_, err := processor.ProcessPayment(context.Background(), request)
 	if err == nil {
 		t.Fatal("Expected error for invalid amount")
 	}
@@ -160,11 +162,11 @@ func TestProcessPayment_EmptyCardNumber(t *testing.T) {
 		Currency:    "USD",
 		CardNumber:  "", // Empty card number
 		ExpiryMonth: "12",
-		ExpiryYear:  "2025",
+		ExpiryYear:  "2030",
 		CVV:         "123",
 	}
 
-	_, err := processor.ProcessPayment(request)
+	_, err := processor.ProcessPayment(context.Background(), request)
 	if err == nil {
 		t.Fatal("Expected error for empty card number")
 	}
@@ -184,11 +186,11 @@ func TestProcessPayment_InvalidCVV(t *testing.T) {
 		Currency:    "USD",
 		CardNumber:  "5555555555554444",
 		ExpiryMonth: "12",
-		ExpiryYear:  "2025",
+		ExpiryYear:  "2030",
 		CVV:         "12", // Invalid CVV (too short)
 	}
 
-	_, err := processor.ProcessPayment(request)
+	_, err := processor.ProcessPayment(context.Background(), request)
 	if err == nil {
 		t.Fatal("Expected error for invalid CVV")
 	}
@@ -222,11 +224,11 @@ func TestProcessPayment_EdgeCaseAmounts(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			}
 
-			_, err := processor.ProcessPayment(request)
+			_, err := processor.ProcessPayment(context.Background(), request)
 
 			if tc.valid && err != nil {
 				t.Errorf("Expected success for amount %f, got error: %v", tc.amount, err)
@@ -261,11 +263,11 @@ func TestProcessPayment_DifferentCurrencies(t *testing.T) {
 				Currency:    tc.currency,
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			}
 
-			_, err := processor.ProcessPayment(request)
+			_, err := processor.ProcessPayment(context.Background(), request)
 
 			if tc.valid && err != nil {
 				t.Errorf("Expected success for currency %s, got error: %v", tc.currency, err)
@@ -277,3 +279,29 @@ func TestProcessPayment_DifferentCurrencies(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessPayment_ChaosDroppedResponse(t *testing.T) {
+	processor := NewPaymentProcessor(
+		[]providers.Provider{visa.GetNewVisaPaymentProvider()},
+		WithChaos(chaos.New(1, chaos.Rule{Point: chaos.AfterProvider, Fault: chaos.FaultDrop, Probability: 1})),
+	)
+
+	request := providers.PaymentRequest{
+		Mode:        "visa",
+		Amount:      100.00,
+		Currency:    "USD",
+		CardNumber:  "4111111111111111",
+		ExpiryMonth: "12",
+		ExpiryYear:  "2030",
+		CVV:         "123",
+	}
+
+	response, err := processor.ProcessPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected the dropped response to leave the payment in doubt, got: %v", err)
+	}
+
+	if response.Success || response.Status != providers.StatusUnknown || response.RawStatus != "PROCESSING_ERROR" {
+		t.Errorf("Expected UNKNOWN after PROCESSING_ERROR, got: %+v", response)
+	}
+}
@@ -0,0 +1,106 @@
+package processor
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"pgas/pkg/fees"
+	"pgas/pkg/providers"
+)
+
+// Quote asks every registered provider in parallel for an indicative price of
+// the payment and returns the quotes ranked cheapest first, ineligible ones
+// last. The request mode is ignored; the caller picks a route from the result
+// and charges it explicitly. Providers that do not answer within QuoteTimeout
+// are reported as ineligible.
+func (p *PaymentProcessor) Quote(ctx context.Context, request providers.PaymentRequest) []providers.Quote {
+	if p.config.QuoteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.QuoteTimeout)
+		defer cancel()
+	}
+
+	registered := p.registered()
+
+	results := make([]chan providers.Quote, len(registered))
+	for i, provider := range registered {
+		results[i] = make(chan providers.Quote, 1)
+		go func(provider providers.Provider, result chan<- providers.Quote) {
+			result <- p.quoteProvider(ctx, provider, request)
+		}(provider, results[i])
+	}
+
+	quotes := make([]providers.Quote, len(registered))
+	for i, provider := range registered {
+		select {
+		case quote := <-results[i]:
+			quotes[i] = quote
+		case <-ctx.Done():
+			// quotes that arrived before the deadline still count
+			select {
+			case quote := <-results[i]:
+				quotes[i] = quote
+			default:
+				quotes[i] = ineligible(provider.GetName(), "quote timed out")
+			}
+		}
+	}
+
+	sort.SliceStable(quotes, func(i, j int) bool {
+		if quotes[i].Eligible != quotes[j].Eligible {
+			return quotes[i].Eligible
+		}
+		return quotes[i].Fee < quotes[j].Fee
+	})
+
+	return quotes
+}
+
+func (p *PaymentProcessor) quoteProvider(ctx context.Context, provider providers.Provider, request providers.PaymentRequest) providers.Quote {
+	name := provider.GetName()
+	request.Mode = name
+
+	if err := provider.ValidateRequest(request); err != nil {
+		return ineligible(name, err.Error())
+	}
+
+	if quoter, ok := provider.(providers.Quoter); ok {
+		quote, err := quoter.Quote(ctx, request)
+		if err != nil {
+			return ineligible(name, err.Error())
+		}
+		quote.Provider = name
+		quote.Source = "provider"
+		return *quote
+	}
+
+	if p.config.Fees == nil {
+		return ineligible(name, "no quote available")
+	}
+
+	// the network is enough to look up fee schedules
+	brand, _ := p.binTable().Detect(request.CardNumber)
+	estimate, err := p.config.Fees.Estimate(name, fees.Input{
+		Brand:    brand,
+		Amount:   request.Amount,
+		Currency: request.Currency,
+	})
+	if err != nil {
+		return ineligible(name, err.Error())
+	}
+
+	return providers.Quote{
+		Provider:         name,
+		Eligible:         true,
+		Fee:              estimate.Total,
+		FXRate:           1,
+		Currency:         request.Currency,
+		SettlementAmount: math.Round((request.Amount-estimate.Total)*100) / 100,
+		Source:           "estimate",
+	}
+}
+
+func ineligible(provider, reason string) providers.Quote {
+	return providers.Quote{Provider: provider, Eligible: false, Reason: reason}
+}
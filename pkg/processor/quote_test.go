@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pgas/pkg/fees"
+	"pgas/pkg/providers"
+)
+
+type quotingProvider struct {
+	*stubProvider
+	fee   float64
+	delay time.Duration
+}
+
+func (q *quotingProvider) Quote(ctx context.Context, request providers.PaymentRequest) (*providers.Quote, error) {
+	select {
+	case <-time.After(q.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &providers.Quote{
+		Eligible:         true,
+		Fee:              q.fee,
+		FXRate:           0.5,
+		Currency:         "GBP",
+		SettlementAmount: (request.Amount - q.fee) * 0.5,
+	}, nil
+}
+
+type rejectingProvider struct {
+	*stubProvider
+}
+
+func (r *rejectingProvider) ValidateRequest(request providers.PaymentRequest) error {
+	return errors.New("currency not supported")
+}
+
+func TestQuote_RanksEligibleProviders(t *testing.T) {
+	calculator, err := fees.NewCalculator(fees.DefaultTable())
+	if err != nil {
+		t.Fatalf("Expected default fee table to load, got: %v", err)
+	}
+
+	processor := NewPaymentProcessor(nil,
+		WithProviders(
+			&quotingProvider{stubProvider: newStubProvider("fx"), fee: 1.00},
+			newStubProvider("visa"),
+			&rejectingProvider{stubProvider: newStubProvider("rejecting")},
+			&quotingProvider{stubProvider: newStubProvider("slow"), fee: 0.10, delay: time.Second},
+		),
+		WithFeeCalculator(calculator),
+		WithQuoteTimeout(50*time.Millisecond),
+	)
+
+	start := time.Now()
+	quotes := processor.Quote(context.Background(), stubRequest(""))
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Expected quoting to respect the timeout, took %v", time.Since(start))
+	}
+
+	if len(quotes) != 4 {
+		t.Fatalf("Expected 4 quotes, got %d", len(quotes))
+	}
+
+	if quotes[0].Provider != "fx" || quotes[0].Source != "provider" || quotes[0].Currency != "GBP" {
+		t.Errorf("Expected cheapest provider quote first, got %+v", quotes[0])
+	}
+
+	if quotes[1].Provider != "visa" || quotes[1].Source != "estimate" || quotes[1].Fee != 2.34 {
+		t.Errorf("Expected fee table estimate for visa, got %+v", quotes[1])
+	}
+
+	for _, quote := range quotes[2:] {
+		if quote.Eligible || quote.Reason == "" {
+			t.Errorf("Expected ineligible quote with a reason, got %+v", quote)
+		}
+	}
+}
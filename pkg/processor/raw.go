@@ -0,0 +1,30 @@
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+
+	"pgas/pkg/redact"
+)
+
+// captureRawResponse keeps the provider's unparsed answer for disputes and
+// debugging, sanitized for the raw responses sink. Capture is best effort.
+func (p *PaymentProcessor) captureRawResponse(transactionID string, raw interface{}) {
+	if p.config.RawResponses == nil || transactionID == "" {
+		return
+	}
+
+	data, err := json.Marshal(p.config.Redaction.ApplyValue(redact.SinkRawResponses, raw))
+	if err != nil {
+		return
+	}
+	_ = p.config.RawResponses.Put(transactionID, data)
+}
+
+// RawResponse returns the captured provider response of a payment as JSON
+func (p *PaymentProcessor) RawResponse(transactionID string) ([]byte, error) {
+	if p.config.RawResponses == nil {
+		return nil, errors.New("raw response capture is not enabled")
+	}
+	return p.config.RawResponses.Read(transactionID)
+}
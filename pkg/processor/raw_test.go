@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"pgas/pkg/compress"
+	"pgas/pkg/store"
+)
+
+func TestRawResponseCapture(t *testing.T) {
+	backend := store.NewMemoryBlobs(store.MemoryOptions{})
+	processor := NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub")),
+		WithRawResponseCapture(store.NewCompressedBlobs(backend, compress.Gzip{})),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected successful payment, got error: %v", err)
+	}
+
+	raw, readErr := processor.RawResponse(response.TransactionID)
+	if readErr != nil {
+		t.Fatalf("Expected raw response to be captured, got error: %v", readErr)
+	}
+	if !strings.Contains(string(raw), `"transaction_id":"stub-tx"`) {
+		t.Errorf("Expected the provider's answer, got %s", raw)
+	}
+
+	if blob, _ := backend.Get(response.TransactionID); blob.Encoding != "gzip" {
+		t.Errorf("Expected the capture to be stored compressed, got encoding '%s'", blob.Encoding)
+	}
+}
+
+func TestRawResponseCapture_Disabled(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	if _, err := processor.RawResponse("stub-tx"); err == nil {
+		t.Error("Expected an error when capture is not enabled")
+	}
+}
@@ -0,0 +1,23 @@
+package processor
+
+import "pgas/pkg/providers"
+
+// SetReadOnly halts or resumes new charges. While read-only, ProcessPayment
+// and CompletePayment reject requests with READ_ONLY_MODE; non-charging
+// operations keep working.
+func (p *PaymentProcessor) SetReadOnly(enabled bool) {
+	p.readOnly.Store(enabled)
+}
+
+// ReadOnly reports whether new charges are currently rejected
+func (p *PaymentProcessor) ReadOnly() bool {
+	return p.readOnly.Load()
+}
+
+func readOnlyError() *providers.PaymentError {
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "READ_ONLY_MODE",
+		ErrorMessage: "processor is in read-only mode, new payments are not accepted",
+	}
+}
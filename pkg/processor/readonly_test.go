@@ -0,0 +1,34 @@
+package processor
+
+import (
+	"context"
+	"testing"
+)
+
+func TestProcessPayment_ReadOnlyMode(t *testing.T) {
+	stub := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(stub), WithReadOnly(true))
+
+	if !processor.ReadOnly() {
+		t.Fatal("Expected processor to start in read-only mode")
+	}
+
+	_, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err == nil {
+		t.Fatal("Expected error in read-only mode")
+	}
+
+	if err.ErrorCode != "READ_ONLY_MODE" {
+		t.Errorf("Expected error code 'READ_ONLY_MODE', got: %s", err.ErrorCode)
+	}
+
+	if stub.callCount() != 0 {
+		t.Errorf("Expected provider not to be called, got %d calls", stub.callCount())
+	}
+
+	processor.SetReadOnly(false)
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Errorf("Expected payment to succeed after leaving read-only mode, got: %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/reporting"
+)
+
+// RefundPayment returns funds of an earlier payment, in full or in part,
+// through the provider that processed it. Refunds of stored payments must
+// match their currency and stay within what is left to refund; their mode
+// may be left empty. Refunds keep working in read-only mode.
+func (p *PaymentProcessor) RefundPayment(ctx context.Context, refundRequest providers.RefundRequest) (*providers.RefundResponse, *providers.PaymentError) {
+	if tx, ok := p.originalTransaction(refundRequest.TransactionID); ok {
+		if refundRequest.Mode == "" {
+			refundRequest.Mode = tx.Provider
+		}
+		if refundRequest.Mode != tx.Provider {
+			return nil, &providers.PaymentError{
+				Success:      false,
+				ErrorCode:    "INVALID_REQUEST",
+				ErrorMessage: "payment '" + tx.ID + "' was processed by '" + tx.Provider + "', not '" + refundRequest.Mode + "'",
+			}
+		}
+	}
+
+	paymentProvider, err := p.getProvider(refundRequest.Mode)
+	if err != nil {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_PROVIDER",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	if err := refundRequest.Validate(p.config.RequireRefundReason); err != nil {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	if balanceError := p.checkRefund(refundRequest); balanceError != nil {
+		return nil, balanceError
+	}
+
+	if timeout := p.providerTimeout(paymentProvider.GetName()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	gatewayRequest := refundRequest
+	gatewayRequest.TransactionID = p.gatewayTransactionID(refundRequest.TransactionID)
+	refundResponse, err := paymentProvider.Refund(ctx, gatewayRequest)
+	if errors.Is(err, providers.ErrRefundNotSupported) {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "REFUND_NOT_SUPPORTED",
+			ErrorMessage: "provider '" + paymentProvider.GetName() + "' does not support refunds",
+		}
+	}
+	if err != nil {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "REFUND_FAILED",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	// the reason is always reported back, also for gateways without reason codes
+	refundResponse.Reason = refundRequest.Reason
+
+	tx, stored := p.originalTransaction(refundRequest.TransactionID)
+	if p.config.Refunds != nil && refundResponse.Success {
+		p.config.Refunds.Record(reporting.RefundRecord{
+			RefundID:      refundResponse.RefundID,
+			TransactionID: refundRequest.TransactionID,
+			Provider:      paymentProvider.GetName(),
+			Amount:        refundResponse.Amount,
+			Currency:      refundResponse.Currency,
+			Reason:        refundRequest.Reason,
+			Time:          time.Now(),
+			Metadata:      tx.Metadata,
+			Tags:          tx.Tags,
+		})
+	}
+
+	if stored {
+		remaining := math.Max(p.refundableAmount(tx), 0)
+		refundResponse.Remaining = &remaining
+	}
+
+	return refundResponse, nil
+}
+
+// RefundSummary aggregates refunds since the given time by reason
+func (p *PaymentProcessor) RefundSummary(since time.Time) []reporting.ReasonSummary {
+	if p.config.Refunds == nil {
+		return nil
+	}
+	return p.config.Refunds.ByReason(since)
+}
@@ -0,0 +1,85 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/providers/visa"
+	"pgas/pkg/store"
+)
+
+func TestRefundPayment_ReasonsAndReporting(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{mastercard.GetNewMasterCardPaymentProvider()},
+		WithRequiredRefundReason(true),
+	)
+
+	refund := providers.RefundRequest{
+		Mode:          "mastercard",
+		TransactionID: "TX1",
+		Amount:        25,
+		Currency:      "USD",
+	}
+
+	if _, err := processor.RefundPayment(context.Background(), refund); err == nil || err.ErrorCode != "INVALID_REQUEST" {
+		t.Fatalf("Expected missing reason to be rejected, got %v", err)
+	}
+
+	refund.Reason = "changed_mind"
+	if _, err := processor.RefundPayment(context.Background(), refund); err == nil || err.ErrorCode != "INVALID_REQUEST" {
+		t.Fatalf("Expected unknown reason to be rejected, got %v", err)
+	}
+
+	refund.Reason = providers.RefundDuplicate
+	response, err := processor.RefundPayment(context.Background(), refund)
+	if err != nil {
+		t.Fatalf("Expected refund to succeed, got error: %v", err)
+	}
+
+	if response.Reason != providers.RefundDuplicate || response.Amount != 25 {
+		t.Errorf("Expected refund response with reason, got %+v", response)
+	}
+
+	summary := processor.RefundSummary(time.Now().Add(-time.Minute))
+	if len(summary) != 1 || summary[0].Category != providers.RefundCategoryProcessing || summary[0].Totals["USD"] != 25 {
+		t.Errorf("Expected refund in reason summary, got %+v", summary)
+	}
+}
+
+func TestRefundPayment_NotSupported(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	_, err := processor.RefundPayment(context.Background(), providers.RefundRequest{
+		Mode:          "stub",
+		TransactionID: "stub-tx",
+		Amount:        10,
+		Currency:      "USD",
+	})
+	if err == nil || err.ErrorCode != "REFUND_NOT_SUPPORTED" {
+		t.Errorf("Expected REFUND_NOT_SUPPORTED, got %v", err)
+	}
+}
+
+func TestRefundPayment_Dispatch(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{
+		visa.GetNewVisaPaymentProvider(),
+		mastercard.GetNewMasterCardPaymentProvider(),
+	})
+	processor.Transactions().Save(store.Transaction{ID: "TX1", Provider: "visa", Status: providers.StatusApproved, Amount: 100, Currency: "USD"})
+
+	refund := providers.RefundRequest{TransactionID: "TX1", Amount: 30, Currency: "USD"}
+	response, err := processor.RefundPayment(context.Background(), refund)
+	if err != nil {
+		t.Fatalf("Expected the refund to go to the payment's provider, got %v", err)
+	}
+	if response.Remaining == nil || *response.Remaining != 70 {
+		t.Errorf("Expected 70 left to refund, got %v", response.Remaining)
+	}
+
+	refund.Mode = "mastercard"
+	if _, err := processor.RefundPayment(context.Background(), refund); err == nil || err.ErrorCode != "INVALID_REQUEST" {
+		t.Errorf("Expected refunds through another provider to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"pgas/pkg/providers"
+)
+
+// RegisterProvider adds a provider while the processor is running. Names
+// are unique, a registered provider is changed with ReloadProvider.
+func (p *PaymentProcessor) RegisterProvider(provider providers.Provider) error {
+	name := provider.GetName()
+	if name == "" {
+		return errors.New("provider name is required")
+	}
+	p.applyDecoding(provider)
+
+	return p.updateProviders(func(registry map[string]providers.Provider) error {
+		if _, ok := registry[name]; ok {
+			return fmt.Errorf("provider '%s' is already registered", name)
+		}
+		registry[name] = provider
+		return nil
+	})
+}
+
+// DeregisterProvider removes a provider while the processor is running. New
+// payments to it fail with INVALID_PROVIDER and a running canary is
+// dropped; gateway calls already made complete, Drain the provider first to
+// wait for them and settle its pending payments.
+func (p *PaymentProcessor) DeregisterProvider(name string) error {
+	// canary changes check the registration under canaryMu, holding it
+	// keeps a reload or promotion from registering the provider again
+	p.canaryMu.Lock()
+	defer p.canaryMu.Unlock()
+
+	err := p.updateProviders(func(registry map[string]providers.Provider) error {
+		if _, ok := registry[name]; !ok {
+			return fmt.Errorf("provider '%s' is not registered", name)
+		}
+		delete(registry, name)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	p.canaries.Delete(name)
+	p.draining.Delete(name)
+	return nil
+}
+
+func (p *PaymentProcessor) registerProviders(providers []providers.Provider) {
+	for _, provider := range providers {
+		p.applyDecoding(provider)
+	}
+	p.replaceProviders(providers...)
+}
+
+// replaceProviders adds or replaces providers by name
+func (p *PaymentProcessor) replaceProviders(replacements ...providers.Provider) {
+	p.updateProviders(func(registry map[string]providers.Provider) error {
+		for _, provider := range replacements {
+			registry[provider.GetName()] = provider
+		}
+		return nil
+	})
+}
+
+// updateProviders applies change to a copy of the registry and publishes
+// the copy unless change fails. Payments read the registry without
+// locking, so it is never modified in place; changes are serialized so none
+// of them is lost.
+func (p *PaymentProcessor) updateProviders(change func(registry map[string]providers.Provider) error) error {
+	p.providersMu.Lock()
+	defer p.providersMu.Unlock()
+
+	current := p.providerMap()
+	next := make(map[string]providers.Provider, len(current)+1)
+	for name, provider := range current {
+		next[name] = provider
+	}
+	if err := change(next); err != nil {
+		return err
+	}
+	p.providers.Store(&next)
+	return nil
+}
+
+func (p *PaymentProcessor) providerMap() map[string]providers.Provider {
+	if registry := p.providers.Load(); registry != nil {
+		return *registry
+	}
+	return nil
+}
+
+// registered lists the registered providers sorted by name
+func (p *PaymentProcessor) registered() []providers.Provider {
+	registry := p.providerMap()
+
+	list := make([]providers.Provider, 0, len(registry))
+	for _, provider := range registry {
+		list = append(list, provider)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].GetName() < list[j].GetName() })
+	return list
+}
+
+func (p *PaymentProcessor) getProvider(requiredProvider string) (providers.Provider, error) {
+	pr := p.providerMap()[requiredProvider]
+	if pr == nil {
+		return nil, errors.New("invalid provider name provided: '" + requiredProvider + "'")
+	}
+
+	return pr, nil
+}
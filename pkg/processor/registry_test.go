@@ -0,0 +1,131 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestRegisterProvider(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stub")})
+
+	if err := processor.RegisterProvider(newStubProvider("stub")); err == nil {
+		t.Error("Expected error registering a name twice")
+	}
+	if err := processor.RegisterProvider(newStubProvider("")); err == nil {
+		t.Error("Expected error registering a provider without name")
+	}
+
+	if err := processor.RegisterProvider(newStubProvider("late")); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	response, paymentError := processor.ProcessPayment(context.Background(), stubRequest("late"))
+	if paymentError != nil || response.GatewayReference != "late-tx" {
+		t.Fatalf("Expected the registered provider to be charged, got %+v %+v", response, paymentError)
+	}
+}
+
+func TestDeregisterProvider(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stub")})
+	if err := processor.ReloadProvider(newStubProvider("stub"), CanaryPolicy{Percent: 50, MinAttempts: 1, MaxErrorRate: 1, PromoteAfter: 1}); err != nil {
+		t.Fatalf("Reload failed: %v", err)
+	}
+
+	if err := processor.DeregisterProvider("stub"); err != nil {
+		t.Fatalf("Deregister failed: %v", err)
+	}
+	if err := processor.DeregisterProvider("stub"); err == nil {
+		t.Error("Expected error deregistering an unknown provider")
+	}
+
+	_, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if paymentError == nil || paymentError.ErrorCode != "INVALID_PROVIDER" {
+		t.Fatalf("Expected INVALID_PROVIDER, got %+v", paymentError)
+	}
+	if _, running := processor.Canary("stub"); running {
+		t.Error("Expected the canary to be dropped")
+	}
+	if err := processor.ReloadProvider(newStubProvider("stub"), CanaryPolicy{}); err == nil {
+		t.Error("Expected reloading a deregistered provider to fail")
+	}
+}
+
+// run with -race: registry changes must not race with payments reading it
+func TestRegistry_ConcurrentRegisterProcessDeregister(t *testing.T) {
+	processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stable")})
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				name := fmt.Sprintf("dynamic-%d-%d", worker, i)
+				if err := processor.RegisterProvider(newStubProvider(name)); err != nil {
+					t.Errorf("Register %s failed: %v", name, err)
+					return
+				}
+				processor.ProcessPayment(context.Background(), stubRequest(name))
+				if err := processor.DeregisterProvider(name); err != nil {
+					t.Errorf("Deregister %s failed: %v", name, err)
+					return
+				}
+			}
+		}()
+	}
+
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stable")); paymentError != nil {
+					t.Errorf("Payment to the stable provider failed: %+v", paymentError)
+					return
+				}
+				processor.Providers()
+			}
+		}()
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			processor.ReloadProvider(newStubProvider("stable"), CanaryPolicy{Percent: 50, MinAttempts: 1, MaxErrorRate: 1, PromoteAfter: 2})
+		}
+	}()
+
+	wg.Wait()
+
+	if infos := processor.Providers(); len(infos) != 1 || infos[0].Name != "stable" {
+		t.Fatalf("Expected only the stable provider to remain, got %+v", infos)
+	}
+}
+
+// a promotion racing with a deregistration must not bring the provider back
+func TestRegistry_DeregisterDuringCanary(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		processor := NewPaymentProcessor([]providers.Provider{newStubProvider("stub")})
+		processor.ReloadProvider(newStubProvider("stub"), CanaryPolicy{Percent: 100, MinAttempts: 1, MaxErrorRate: 1, PromoteAfter: 1})
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			processor.ProcessPayment(context.Background(), stubRequest("stub"))
+		}()
+		go func() {
+			defer wg.Done()
+			processor.DeregisterProvider("stub")
+		}()
+		wg.Wait()
+
+		if _, ok := processor.Provider("stub"); ok {
+			t.Fatal("Expected the deregistered provider to stay removed")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package processor
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// buckets the retry budget window is split into, counts expire a bucket at
+// a time
+const retryBudgetBuckets = 10
+
+// RetryBudgetStats is the use of the retry budget over its current window
+type RetryBudgetStats struct {
+	Window    time.Duration `json:"window"`
+	Primaries int64         `json:"primaries"` // first gateway attempts of payments
+	Retries   int64         `json:"retries"`   // retries made
+	Denied    int64         `json:"denied"`    // retries refused because the budget was spent
+	Allowed   int64         `json:"allowed"`   // retries the budget allows in the window
+	// Used is the share of the allowed retries made (0..1), 1 means retries
+	// are being refused
+	Used float64 `json:"used"`
+}
+
+type retryBucket struct {
+	slot      atomic.Int64 // time slot the counts belong to
+	primaries atomic.Int64
+	retries   atomic.Int64
+	denied    atomic.Int64
+}
+
+// retryBudget counts first attempts and retries over a sliding window.
+// Payments only touch atomic counters; concurrent retries may overshoot the
+// budget by a few, which is fine for shedding load.
+type retryBudget struct {
+	policy  RetryBudget
+	width   time.Duration // of one bucket
+	buckets [retryBudgetBuckets]retryBucket
+	now     func() time.Time
+}
+
+func newRetryBudget(policy RetryBudget) *retryBudget {
+	if policy.Window <= 0 {
+		return nil
+	}
+	return &retryBudget{
+		policy: policy,
+		width:  max(policy.Window/retryBudgetBuckets, time.Nanosecond),
+		now:    time.Now,
+	}
+}
+
+// bucket returns the bucket of the current time slot, emptying it when it
+// still holds counts of an earlier slot
+func (b *retryBudget) bucket(slot int64) *retryBucket {
+	bucket := &b.buckets[slot%retryBudgetBuckets]
+	if previous := bucket.slot.Load(); previous != slot && bucket.slot.CompareAndSwap(previous, slot) {
+		bucket.primaries.Store(0)
+		bucket.retries.Store(0)
+		bucket.denied.Store(0)
+	}
+	return bucket
+}
+
+func (b *retryBudget) slot() int64 {
+	return b.now().UnixNano() / int64(b.width)
+}
+
+// primary counts a first attempt, which earns the budget Ratio retries
+func (b *retryBudget) primary() {
+	if b == nil {
+		return
+	}
+	b.bucket(b.slot()).primaries.Add(1)
+}
+
+// allow takes a retry from the budget, reporting false when it is spent
+func (b *retryBudget) allow() bool {
+	if b == nil {
+		return true
+	}
+
+	slot := b.slot()
+	stats := b.window(slot)
+	bucket := b.bucket(slot)
+	if stats.Retries >= stats.Allowed {
+		bucket.denied.Add(1)
+		return false
+	}
+	bucket.retries.Add(1)
+	return true
+}
+
+// window sums the buckets of the window ending at slot
+func (b *retryBudget) window(slot int64) RetryBudgetStats {
+	stats := RetryBudgetStats{Window: b.policy.Window}
+	for i := range b.buckets {
+		bucket := &b.buckets[i]
+		if age := slot - bucket.slot.Load(); age < 0 || age >= retryBudgetBuckets {
+			continue
+		}
+		stats.Primaries += bucket.primaries.Load()
+		stats.Retries += bucket.retries.Load()
+		stats.Denied += bucket.denied.Load()
+	}
+
+	stats.Allowed = int64(b.policy.MinRetries) + int64(b.policy.Ratio*float64(stats.Primaries))
+	if stats.Allowed > 0 {
+		stats.Used = min(float64(stats.Retries)/float64(stats.Allowed), 1)
+	} else if stats.Denied > 0 {
+		stats.Used = 1
+	}
+	return stats
+}
+
+// RetryBudget reports how much of the retry budget the current window used;
+// it reports false when the budget is disabled
+func (p *PaymentProcessor) RetryBudget() (RetryBudgetStats, bool) {
+	if p.retryBudget == nil {
+		return RetryBudgetStats{}, false
+	}
+	return p.retryBudget.window(p.retryBudget.slot()), true
+}
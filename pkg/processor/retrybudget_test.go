@@ -0,0 +1,93 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+func retryingProcessor(stub *stubProvider, budget RetryBudget) *PaymentProcessor {
+	return NewPaymentProcessor([]providers.Provider{stub},
+		WithRetryPolicy(RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Microsecond,
+			Retryable:   func(*providers.PaymentError) bool { return true },
+		}),
+		WithRetryBudget(budget),
+	)
+}
+
+func TestRetryBudget_ShedsRetries(t *testing.T) {
+	stub := newStubProvider("stub", &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"})
+	processor := retryingProcessor(stub, RetryBudget{Ratio: 0.1, Window: time.Minute})
+
+	for i := 0; i < 20; i++ {
+		processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	}
+
+	// without the budget the failing gateway would see 60 calls
+	if stub.callCount() != 22 {
+		t.Errorf("Expected 20 first attempts and 2 retries, got %d calls", stub.callCount())
+	}
+
+	stats, enabled := processor.RetryBudget()
+	if !enabled {
+		t.Fatal("Expected the retry budget to be enabled")
+	}
+	expected := RetryBudgetStats{Window: time.Minute, Primaries: 20, Retries: 2, Denied: 20, Allowed: 2, Used: 1}
+	if stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+}
+
+func TestRetryBudget_MinRetries(t *testing.T) {
+	stub := newStubProvider("stub", &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"}, nil)
+	processor := retryingProcessor(stub, RetryBudget{Ratio: 0.1, MinRetries: 1, Window: time.Minute})
+
+	if _, paymentError := processor.ProcessPayment(context.Background(), stubRequest("stub")); paymentError != nil {
+		t.Fatalf("Expected the retry to succeed, got %+v", paymentError)
+	}
+	if stats, _ := processor.RetryBudget(); stats.Retries != 1 || stats.Used != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+}
+
+func TestRetryBudget_WindowSlides(t *testing.T) {
+	now := time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC)
+	stub := newStubProvider("stub", &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"})
+	processor := retryingProcessor(stub, RetryBudget{MinRetries: 1, Window: time.Minute})
+	processor.retryBudget.now = func() time.Time { return now }
+
+	processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if stats, _ := processor.RetryBudget(); stats.Retries != 1 || stats.Denied != 2 {
+		t.Fatalf("Expected the budget to be spent, got %+v", stats)
+	}
+
+	now = now.Add(time.Minute)
+	if stats, _ := processor.RetryBudget(); stats != (RetryBudgetStats{Window: time.Minute, Allowed: 1}) {
+		t.Fatalf("Expected an empty window, got %+v", stats)
+	}
+	calls := stub.callCount()
+	processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if stub.callCount()-calls != 2 {
+		t.Errorf("Expected the new window to allow a retry, got %d calls", stub.callCount()-calls)
+	}
+}
+
+func TestRetryBudget_Disabled(t *testing.T) {
+	stub := newStubProvider("stub", &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"})
+	processor := retryingProcessor(stub, RetryBudget{})
+
+	for i := 0; i < 5; i++ {
+		processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	}
+	if stub.callCount() != 15 {
+		t.Errorf("Expected every retry without a budget, got %d calls", stub.callCount())
+	}
+	if _, enabled := processor.RetryBudget(); enabled {
+		t.Error("Expected the budget to be reported as disabled")
+	}
+}
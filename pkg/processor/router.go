@@ -0,0 +1,80 @@
+package processor
+
+import (
+	"context"
+
+	"pgas/pkg/providers"
+)
+
+// Router picks the provider for a payment, e.g. by calling an external
+// routing service or a scoring model. candidates are the registered
+// providers not being drained, sorted by name. Returning nil keeps the
+// provider named by the request's Mode.
+type Router interface {
+	Route(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, error)
+}
+
+// RouterFunc adapts a function to the Router interface
+type RouterFunc func(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, error)
+
+func (f RouterFunc) Route(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, error) {
+	return f(ctx, req, candidates)
+}
+
+// RouteExplainer is implemented by routers that can name the rules behind
+// their decision, for SimulateRouting. Explain must choose exactly what
+// Route would.
+type RouteExplainer interface {
+	Explain(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, []string, error)
+}
+
+// route asks the configured router for the payment's provider. Payments
+// forced onto a provider through overrides or pinned to one by their
+// installment plan are not routed.
+func (p *PaymentProcessor) route(ctx context.Context, paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	if p.config.Router == nil || paymentReqest.Installments != nil ||
+		(paymentReqest.Overrides != nil && paymentReqest.Overrides.ForceProvider != "") {
+		return paymentReqest, nil
+	}
+
+	chosen, _, err := p.chooseRoute(ctx, paymentReqest, false)
+	if err != nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "ROUTING_ERROR",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+	if chosen == nil {
+		return paymentReqest, nil
+	}
+
+	if registered, _ := p.getProvider(chosen.GetName()); registered != chosen {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "ROUTING_ERROR",
+			ErrorMessage: "router chose provider '" + chosen.GetName() + "' which is not registered",
+		}
+	}
+
+	paymentReqest.Mode = chosen.GetName()
+	return paymentReqest, nil
+}
+
+// chooseRoute runs the router over the providers not being drained, with
+// the matched rules when explain is set and the router can name them
+func (p *PaymentProcessor) chooseRoute(ctx context.Context, paymentReqest providers.PaymentRequest, explain bool) (providers.Provider, []string, error) {
+	var candidates []providers.Provider
+	for _, provider := range p.registered() {
+		if !p.Draining(provider.GetName()) {
+			candidates = append(candidates, provider)
+		}
+	}
+
+	if explainer, ok := p.config.Router.(RouteExplainer); ok && explain {
+		return explainer.Explain(ctx, paymentReqest, candidates)
+	}
+	chosen, err := p.config.Router.Route(ctx, paymentReqest, candidates)
+	return chosen, nil, err
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestProcessPayment_Router(t *testing.T) {
+	primary, secondary := newStubProvider("primary"), newStubProvider("secondary")
+
+	var seen []string
+	router := RouterFunc(func(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, error) {
+		seen = seen[:0]
+		for _, candidate := range candidates {
+			seen = append(seen, candidate.GetName())
+		}
+		switch {
+		case req.Amount > 1000:
+			return nil, errors.New("amount too large for any route")
+		case req.Currency == "EUR":
+			return candidates[1], nil
+		}
+		return nil, nil
+	})
+
+	processor := NewPaymentProcessor(nil, WithProviders(secondary, primary), WithRouter(router))
+
+	request := stubRequest("primary")
+	request.Currency = "EUR"
+	response, err := processor.ProcessPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected routed payment to succeed, got: %v", err)
+	}
+	if response.GatewayReference != "secondary-tx" || secondary.callCount() != 1 {
+		t.Errorf("Expected router to send the payment to secondary, got %s", response.GatewayReference)
+	}
+	if len(seen) != 2 || seen[0] != "primary" || seen[1] != "secondary" {
+		t.Errorf("Expected candidates sorted by name, got %v", seen)
+	}
+
+	if response, _ := processor.ProcessPayment(context.Background(), stubRequest("primary")); response == nil || response.GatewayReference != "primary-tx" {
+		t.Errorf("Expected a nil decision to keep the requested provider, got %+v", response)
+	}
+
+	request.Amount = 5000
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "ROUTING_ERROR" {
+		t.Errorf("Expected ROUTING_ERROR, got %v", err)
+	}
+}
+
+func TestProcessPayment_RouterUnregisteredChoice(t *testing.T) {
+	router := RouterFunc(func(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, error) {
+		return newStubProvider("rogue"), nil
+	})
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")), WithRouter(router))
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err == nil || err.ErrorCode != "ROUTING_ERROR" {
+		t.Errorf("Expected a provider outside the candidates to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package processor
+
+import (
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+)
+
+// sanitizeOutcome is the last step of every payment: the response carries
+// the card only as a redact.MaskedCard, and card numbers and the CVV are
+// scrubbed from the texts that gateways and validators fill in, so neither
+// can reach callers, logs or the idempotency store. Err keeps the original
+// error for errors.Is but is never serialized. Errors may be shared by
+// providers, so they are copied first.
+func sanitizeOutcome(paymentReqest providers.PaymentRequest, response *providers.PaymentResponse, paymentError *providers.PaymentError) (*providers.PaymentResponse, *providers.PaymentError) {
+	scrub := func(text string) string {
+		return redact.Scrub(text, paymentReqest.CardNumber, paymentReqest.CVV)
+	}
+
+	if response != nil {
+		if paymentReqest.CardNumber != "" {
+			response.Card = redact.MaskedCard(paymentReqest.CardNumber)
+		}
+		response.RawStatus = scrub(response.RawStatus)
+		response.ReturnCode = scrub(response.ReturnCode)
+		for key, value := range response.Extra {
+			response.Extra[key] = scrub(value)
+		}
+	}
+	if paymentError != nil {
+		if message := scrub(paymentError.ErrorMessage); message != paymentError.ErrorMessage {
+			copied := *paymentError
+			copied.ErrorMessage = message
+			paymentError = &copied
+		}
+	}
+	return response, paymentError
+}
@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestProcessPayment_MasksCardInResponse(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected approval, got: %v", err)
+	}
+	if response.Card.Reveal() != "4111111111111111" {
+		t.Errorf("Expected the response to carry the card, got %q", response.Card.Reveal())
+	}
+
+	data, _ := json.Marshal(response)
+	if strings.Contains(string(data), "4111111111111111") || !strings.Contains(string(data), `"card":"411111******1111"`) {
+		t.Errorf("Expected the card masked in JSON, got %s", data)
+	}
+}
+
+func TestProcessPayment_ScrubsCardDataFromErrors(t *testing.T) {
+	decline := &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    "DECLINED",
+		ErrorMessage: "card 4111111111111111 with cvv 123 declined",
+		Reason:       providers.ReasonCardDeclined,
+	}
+	provider := newStubProvider("stub", decline)
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+
+	_, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err == nil {
+		t.Fatal("Expected a decline")
+	}
+	if err.ErrorMessage != "card 411111******1111 with cvv [REDACTED] declined" {
+		t.Errorf("Expected card data scrubbed from the message, got %q", err.ErrorMessage)
+	}
+	if decline.ErrorMessage != "card 4111111111111111 with cvv 123 declined" {
+		t.Errorf("Expected the provider's error to be left alone, got %q", decline.ErrorMessage)
+	}
+}
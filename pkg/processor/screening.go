@@ -0,0 +1,115 @@
+package processor
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/screening"
+)
+
+// ScreeningPolicy decides which payments are screened for AML and sanctions
+// before they reach the gateway. Without thresholds and countries every
+// payment is screened.
+type ScreeningPolicy struct {
+	// Screener runs the checks, nil disables screening
+	Screener screening.Screener
+	// Threshold screens payments of at least this amount, Thresholds
+	// overrides it by currency
+	Threshold  float64
+	Thresholds map[string]float64
+	// Countries screens payments whose merchant or issuer country is listed
+	Countries []string
+	// FailOpen lets payments through when the screener cannot be reached,
+	// by default they fail with SCREENING_ERROR
+	FailOpen bool
+}
+
+func (s ScreeningPolicy) applies(paymentReqest providers.PaymentRequest) bool {
+	threshold, ok := s.Thresholds[paymentReqest.Currency]
+	if !ok {
+		threshold = s.Threshold
+	}
+	if threshold == 0 && len(s.Countries) == 0 {
+		return true
+	}
+
+	if threshold > 0 && paymentReqest.Amount >= threshold {
+		return true
+	}
+	for _, country := range s.Countries {
+		if strings.EqualFold(country, paymentReqest.MerchantCountry) || strings.EqualFold(country, paymentReqest.IssuerCountry) {
+			return true
+		}
+	}
+	return false
+}
+
+// screen runs the configured screener. Blocked payments fail with
+// COMPLIANCE_HOLD, flagged ones are returned so the response can carry the
+// flag; both publish a payment.compliance_hold event for the compliance team.
+func (p *PaymentProcessor) screen(ctx context.Context, paymentReqest providers.PaymentRequest) (*screening.Result, *providers.PaymentError) {
+	policy := p.config.Screening
+	if policy.Screener == nil || !policy.applies(paymentReqest) {
+		return nil, nil
+	}
+
+	if p.config.DefaultTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.DefaultTimeout)
+		defer cancel()
+	}
+
+	result, err := policy.Screener.Screen(ctx, screening.NewRequest(paymentReqest))
+	if err != nil {
+		if policy.FailOpen {
+			return nil, nil
+		}
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "SCREENING_ERROR",
+			ErrorMessage: "compliance screening failed: " + err.Error(),
+			Reason:       providers.ReasonProcessingError,
+		}
+	}
+	if result.Decision == screening.Clear {
+		return nil, nil
+	}
+
+	p.publish(ctx, events.Event{
+		Type:     events.TypeComplianceHold,
+		Time:     time.Now(),
+		Provider: paymentReqest.Mode,
+		Data: events.Encode(events.ComplianceHoldData{
+			Decision:      string(result.Decision),
+			Reason:        result.Reason,
+			Matches:       result.Matches,
+			SubMerchantID: paymentReqest.SubMerchantID,
+		}),
+	})
+
+	if result.Decision == screening.Block {
+		return nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "COMPLIANCE_HOLD",
+			ErrorMessage: "payment held by compliance screening: " + result.Reason,
+		}
+	}
+	return result, nil
+}
+
+// markFlagged adds a screening flag to a processed payment's response
+func markFlagged(response *providers.PaymentResponse, flagged *screening.Result) {
+	if flagged == nil || response == nil {
+		return
+	}
+	if response.Extra == nil {
+		response.Extra = make(map[string]string)
+	}
+	response.Extra["compliance_hold"] = flagged.Reason
+	if len(flagged.Matches) > 0 {
+		response.Extra["compliance_matches"] = strings.Join(flagged.Matches, ",")
+	}
+}
@@ -0,0 +1,108 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgas/pkg/events"
+	"pgas/pkg/screening"
+)
+
+type failingScreener struct{}
+
+func (failingScreener) Screen(ctx context.Context, request screening.Request) (*screening.Result, error) {
+	return nil, errors.New("service unavailable")
+}
+
+func TestScreening_Blocks(t *testing.T) {
+	provider := newStubProvider("stub")
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithEventPublisher(publisher),
+		WithScreeningPolicy(ScreeningPolicy{
+			Screener:  &screening.ListScreener{Countries: []string{"IR"}},
+			Countries: []string{"IR"},
+		}),
+	)
+
+	request := stubRequest("stub")
+	request.IssuerCountry = "ir"
+	_, err := processor.ProcessPayment(context.Background(), request)
+	if err == nil || err.ErrorCode != "COMPLIANCE_HOLD" {
+		t.Fatalf("Expected COMPLIANCE_HOLD, got %v", err)
+	}
+	if provider.callCount() != 0 {
+		t.Error("Expected blocked payments to never reach the gateway")
+	}
+
+	held := publisher.Events()
+	if len(held) != 1 || held[0].Type != events.TypeComplianceHold || held[0].Data["decision"] != "block" || held[0].Data["matches"] != "issuer_country:IR" {
+		t.Errorf("Expected a compliance hold event, got %+v", held)
+	}
+}
+
+func TestScreening_Flags(t *testing.T) {
+	processor := NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub")),
+		WithScreeningPolicy(ScreeningPolicy{
+			Screener:  &screening.ListScreener{BINs: []string{"4111"}, Decision: screening.Flag},
+			Threshold: 50,
+		}),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected flagged payment to go ahead, got %v", err)
+	}
+	if response.Extra["compliance_hold"] != "listed" || response.Extra["compliance_matches"] != "bin:4111" {
+		t.Errorf("Expected compliance flag on the response, got %+v", response.Extra)
+	}
+
+	tx, _ := processor.Transactions().Get(response.TransactionID)
+	if tx.Extra["compliance_hold"] != "listed" {
+		t.Errorf("Expected compliance flag on the stored record, got %+v", tx.Extra)
+	}
+}
+
+func TestScreening_Thresholds(t *testing.T) {
+	provider := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithScreeningPolicy(ScreeningPolicy{
+			Screener:   &screening.ListScreener{BINs: []string{"4111"}},
+			Threshold:  50,
+			Thresholds: map[string]float64{"USD": 1000},
+		}),
+	)
+
+	// below the USD threshold, never screened
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Errorf("Expected unscreened payment to succeed, got %v", err)
+	}
+
+	request := stubRequest("stub")
+	request.Currency = "EUR"
+	if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "COMPLIANCE_HOLD" {
+		t.Errorf("Expected EUR payment above the default threshold to be held, got %v", err)
+	}
+}
+
+func TestScreening_ScreenerUnavailable(t *testing.T) {
+	processor := NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub")),
+		WithScreeningPolicy(ScreeningPolicy{Screener: failingScreener{}}),
+	)
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err == nil || err.ErrorCode != "SCREENING_ERROR" {
+		t.Errorf("Expected SCREENING_ERROR, got %v", err)
+	}
+
+	processor = NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub")),
+		WithScreeningPolicy(ScreeningPolicy{Screener: failingScreener{}, FailOpen: true}),
+	)
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err != nil {
+		t.Errorf("Expected fail-open screening to let the payment through, got %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"errors"
+	"time"
+
+	"pgas/pkg/redact"
+	"pgas/pkg/store"
+)
+
+// CardSearch finds the transactions of one card for support lookups. The
+// card is identified either by its number, which is only used to compute
+// fingerprints and never stored or searched for, or by last4 and expiry.
+type CardSearch struct {
+	CardNumber  string    `json:"-"`
+	Last4       string    `json:"last4,omitempty"`
+	ExpiryMonth string    `json:"expiry_month,omitempty"`
+	ExpiryYear  string    `json:"expiry_year,omitempty"`
+	Since       time.Time `json:"since,omitempty"`
+	Until       time.Time `json:"until,omitempty"`
+}
+
+// SearchTransactions returns the transactions made with a card, oldest first
+func (p *PaymentProcessor) SearchTransactions(search CardSearch) ([]store.Transaction, error) {
+	if p.config.Transactions == nil {
+		return nil, errors.New("no transaction store configured")
+	}
+
+	filter := store.TransactionFilter{Since: search.Since, Until: search.Until}
+
+	switch {
+	case search.CardNumber != "":
+		if p.config.Fingerprinter == nil {
+			return nil, errors.New("searching by card number requires a fingerprinter")
+		}
+		candidates, err := p.config.Fingerprinter.Candidates(search.CardNumber)
+		if err != nil {
+			return nil, err
+		}
+		for _, fp := range candidates {
+			filter.Fingerprints = append(filter.Fingerprints, fp.String())
+		}
+
+	case search.Last4 != "" && search.ExpiryMonth != "" && search.ExpiryYear != "":
+		// expiry is compared in its stored form, which only identifies the
+		// card when the records policy keeps or hashes it
+		switch p.config.Redaction.Action(redact.SinkRecords, redact.FieldExpiry) {
+		case redact.Passthrough, redact.Hash:
+		default:
+			return nil, errors.New("expiry dates are not kept in transaction records, search by card number instead")
+		}
+		filter.Last4 = search.Last4
+		filter.ExpiryMonth = p.config.Redaction.Apply(redact.SinkRecords, redact.FieldExpiry, search.ExpiryMonth)
+		filter.ExpiryYear = p.config.Redaction.Apply(redact.SinkRecords, redact.FieldExpiry, search.ExpiryYear)
+
+	default:
+		return nil, errors.New("search needs a card number or last4 with expiry month and year")
+	}
+
+	return p.config.Transactions.Query(filter)
+}
@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/fingerprint"
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+)
+
+func TestSearchTransactions(t *testing.T) {
+	fingerprinter, err := fingerprint.NewFingerprinter(fingerprint.Config{
+		Keys:          []fingerprint.Key{{Version: 1, Secret: []byte("0123456789abcdef")}},
+		ActiveVersion: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// declines get unique local ids, so every payment is kept
+	decline := &providers.PaymentError{ErrorCode: "EE000051", Reason: providers.ReasonInsufficientFunds}
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub", decline)), WithFingerprinter(fingerprinter))
+
+	for _, cardNumber := range []string{"4111111111111111", "4111111111111111", "4000000000001111", "5555555555554444"} {
+		request := stubRequest("stub")
+		request.CardNumber = cardNumber
+		processor.ProcessPayment(context.Background(), request)
+	}
+
+	byCard, err := processor.SearchTransactions(CardSearch{CardNumber: "4111111111111111", Since: time.Now().Add(-time.Hour)})
+	if err != nil || len(byCard) != 2 {
+		t.Errorf("Expected two charges on the card, got %d (%v)", len(byCard), err)
+	}
+	for _, tx := range byCard {
+		if tx.Fingerprint == "" || tx.Last4 != "1111" {
+			t.Errorf("Expected fingerprinted record, got %+v", tx)
+		}
+	}
+
+	byLast4, err := processor.SearchTransactions(CardSearch{Last4: "1111", ExpiryMonth: "12", ExpiryYear: "2030"})
+	if err != nil || len(byLast4) != 3 {
+		t.Errorf("Expected three charges with last4 1111, got %d (%v)", len(byLast4), err)
+	}
+
+	invalid := []CardSearch{
+		{},
+		{Last4: "1111"},
+		{Last4: "4111111111111111", ExpiryMonth: "12", ExpiryYear: "2030"},
+	}
+	for _, search := range invalid {
+		if _, err := processor.SearchTransactions(search); err == nil {
+			t.Errorf("Expected search %+v to be rejected", search)
+		}
+	}
+}
+
+func TestSearchTransactions_RedactedExpiry(t *testing.T) {
+	policy := redact.DefaultPolicy()
+	policy.Sinks[redact.SinkRecords][redact.FieldExpiry] = redact.Redact
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")), WithRedactionPolicy(policy))
+
+	if _, err := processor.SearchTransactions(CardSearch{Last4: "1111", ExpiryMonth: "12", ExpiryYear: "2030"}); err == nil {
+		t.Error("Expected expiry search to fail when records do not keep expiry dates")
+	}
+	if _, err := processor.SearchTransactions(CardSearch{CardNumber: "4111111111111111"}); err == nil {
+		t.Error("Expected card number search to fail without a fingerprinter")
+	}
+}
@@ -0,0 +1,98 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// SettlePendingPayments queries providers that support status queries for
+// every stored PENDING payment, once each, and records the ones that moved
+// on. Bank transfers use it to follow a debit from submission to
+// settlement or return. It returns the new status of the changed payments.
+func (p *PaymentProcessor) SettlePendingPayments(ctx context.Context) []*providers.PaymentResponse {
+	if p.config.Transactions == nil {
+		return nil
+	}
+
+	pending, err := p.config.Transactions.Query(store.TransactionFilter{Status: providers.StatusPending})
+	if err != nil {
+		return nil
+	}
+
+	var changed []*providers.PaymentResponse
+	for _, tx := range pending {
+		if ctx.Err() != nil {
+			return changed
+		}
+
+		paymentProvider, err := p.getProvider(tx.Provider)
+		if err != nil {
+			continue
+		}
+		response, err := paymentProvider.GetPaymentStatus(ctx, gatewayReference(tx))
+		if err != nil || response == nil || response.Status == providers.StatusPending || response.Status == providers.StatusUnknown {
+			continue
+		}
+		response.TransactionID, response.GatewayReference = tx.ID, gatewayReference(tx)
+
+		p.recordSettlement(ctx, tx, response)
+		changed = append(changed, response)
+	}
+
+	return changed
+}
+
+// recordSettlement stores the new status of a pending payment, with the
+// return code of returned transfers, and announces it
+func (p *PaymentProcessor) recordSettlement(ctx context.Context, tx store.Transaction, response *providers.PaymentResponse) {
+	now := time.Now()
+	detail := response.RawStatus
+	if response.ReturnCode != "" {
+		detail += " " + response.ReturnCode
+		tx.ErrorCode = response.ReturnCode
+		tx.Reason = providers.NewCatalogError(tx.Provider, response.ReturnCode, "").Reason
+	}
+
+	previous := tx.Status
+	tx.Status = response.Status
+	tx.UpdatedAt = now
+	tx.Timeline = append(append([]store.StatusChange(nil), tx.Timeline...), store.StatusChange{Status: response.Status, Time: now, Detail: detail})
+	p.config.Transactions.Save(tx)
+
+	eventType := events.TypePaymentSettled
+	data := events.PaymentSettlementData{PreviousStatus: previous, Status: response.Status}
+	if response.Status != providers.StatusApproved {
+		eventType = events.TypePaymentReturned
+		data.ReturnCode = response.ReturnCode
+		data.Reason = tx.Reason
+	}
+	p.publish(ctx, events.Event{
+		Type:          eventType,
+		Time:          now,
+		Provider:      tx.Provider,
+		TransactionID: tx.ID,
+		Data:          events.Encode(data),
+	})
+}
+
+// StartSettlementPoller follows pending payments every interval until ctx
+// is done
+func (p *PaymentProcessor) StartSettlementPoller(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				p.SettlePendingPayments(ctx)
+			}
+		}
+	}()
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// settlingProvider answers status queries from a fixed table
+type settlingProvider struct {
+	*stubProvider
+	statuses map[string]*providers.PaymentResponse
+}
+
+func (s *settlingProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return s.statuses[transactionID], nil
+}
+
+func TestSettlePendingPayments(t *testing.T) {
+	provider := &settlingProvider{
+		stubProvider: newStubProvider("ach"),
+		statuses: map[string]*providers.PaymentResponse{
+			"settled":  {Success: true, TransactionID: "settled", Status: providers.StatusApproved, RawStatus: "SETTLED"},
+			"returned": {TransactionID: "returned", Status: providers.StatusDeclined, RawStatus: "RETURNED", ReturnCode: "R01"},
+			"waiting":  {Success: true, TransactionID: "waiting", Status: providers.StatusPending, RawStatus: "SUBMITTED"},
+		},
+	}
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider, newStubProvider("visa")),
+		WithTransactionStore(transactions),
+		WithEventPublisher(publisher),
+	)
+
+	now := time.Now()
+	for _, tx := range []store.Transaction{
+		{ID: "settled", Provider: "ach", Status: providers.StatusPending, UpdatedAt: now},
+		{ID: "returned", Provider: "ach", Status: providers.StatusPending, UpdatedAt: now},
+		{ID: "waiting", Provider: "ach", Status: providers.StatusPending, UpdatedAt: now},
+		{ID: "card", Provider: "visa", Status: providers.StatusPending, UpdatedAt: now},
+	} {
+		transactions.Save(tx)
+	}
+
+	changed := processor.SettlePendingPayments(context.Background())
+	if len(changed) != 2 {
+		t.Fatalf("Expected the settled and returned payments, got %+v", changed)
+	}
+
+	if tx, _ := transactions.Get("settled"); tx.Status != providers.StatusApproved || tx.Timeline[len(tx.Timeline)-1].Detail != "SETTLED" {
+		t.Errorf("Expected the settlement on the timeline, got %+v", tx)
+	}
+	tx, _ := transactions.Get("returned")
+	if tx.Status != providers.StatusDeclined || tx.ErrorCode != "R01" || tx.Reason != providers.ReasonInsufficientFunds {
+		t.Errorf("Expected the return code and its reason stored, got %+v", tx)
+	}
+	for _, id := range []string{"waiting", "card"} {
+		if tx, _ := transactions.Get(id); tx.Status != providers.StatusPending {
+			t.Errorf("Expected %s to stay pending, got %s", id, tx.Status)
+		}
+	}
+
+	published := publisher.Events()
+	if len(published) != 2 {
+		t.Fatalf("Expected two events, got %+v", published)
+	}
+	for _, event := range published {
+		switch event.TransactionID {
+		case "settled":
+			if event.Type != events.TypePaymentSettled {
+				t.Errorf("Expected a settled event, got %+v", event)
+			}
+		case "returned":
+			if event.Type != events.TypePaymentReturned || event.Data["return_code"] != "R01" {
+				t.Errorf("Expected a returned event with its code, got %+v", event)
+			}
+		}
+	}
+
+	if changed := processor.SettlePendingPayments(context.Background()); len(changed) != 0 {
+		t.Errorf("Expected nothing left to settle, got %+v", changed)
+	}
+}
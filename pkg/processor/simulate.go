@@ -0,0 +1,216 @@
+package processor
+
+import (
+	"context"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// routing stages reported by SimulateRouting
+const (
+	StageRequest         = "request"          // the provider named by the request's Mode
+	StageOverride        = "override"         // an authorized ForceProvider override
+	StageInstallmentPlan = "installment_plan" // the provider offering the installment plan
+	StageCardNetwork     = "card_network"     // detected from the card's BIN
+	StageRouter          = "router"           // chosen by the configured Router
+)
+
+// RoutingSimulation is how a payment would be processed, see SimulateRouting
+type RoutingSimulation struct {
+	// Provider is the provider that would charge the payment
+	Provider string `json:"provider,omitempty"`
+	// Steps are the routing decisions in the order they were taken
+	Steps []RoutingStep `json:"steps"`
+	// Fallbacks are the backup providers tried on gateway failures, in order
+	Fallbacks []string `json:"fallbacks,omitempty"`
+	// Quote is the expected fee of the chosen provider
+	Quote  *providers.Quote `json:"quote,omitempty"`
+	Limits RoutingLimits    `json:"limits"`
+	// Canary is set while a reloaded configuration of the provider takes a
+	// share of its payments
+	Canary *CanaryStatus `json:"canary,omitempty"`
+	// Error is why the payment would be rejected before reaching a provider
+	Error *providers.PaymentError `json:"error,omitempty"`
+}
+
+// RoutingStep is one routing decision
+type RoutingStep struct {
+	Stage    string   `json:"stage"`
+	Provider string   `json:"provider"`        // provider after the step
+	Rules    []string `json:"rules,omitempty"` // router rules that matched
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// RoutingLimits are the limits that apply to the payment on its provider
+type RoutingLimits struct {
+	Timeout time.Duration `json:"timeout"` // bound of a single gateway call, zero for none
+	// MaxAttempts counts the gateway attempts on each provider, retries
+	// included
+	MaxAttempts int `json:"max_attempts"`
+	// RetryBudget is the current window of the processor wide retry budget
+	RetryBudget *RetryBudgetStats `json:"retry_budget,omitempty"`
+	// LatencyBudget bounds the whole payment, zero for none
+	LatencyBudget time.Duration `json:"latency_budget,omitempty"`
+	// CaptureLimit is the most the captures of the authorization may take
+	CaptureLimit float64 `json:"capture_limit"`
+	// Forwardable reports whether the payment would be stored and forwarded
+	// if its provider were unreachable
+	Forwardable bool `json:"forwardable"`
+}
+
+// SimulateRouting reports which provider would charge a payment, the
+// routing decisions and rules behind the choice, the expected fee and the
+// limits that apply, without charging anything. Overrides are checked but
+// not audited; providers are only asked for a quote.
+func (p *PaymentProcessor) SimulateRouting(ctx context.Context, paymentReqest providers.PaymentRequest) RoutingSimulation {
+	simulation := RoutingSimulation{Steps: []RoutingStep{}}
+	step := func(stage, detail string, rules []string) {
+		simulation.Steps = append(simulation.Steps, RoutingStep{Stage: stage, Provider: paymentReqest.Mode, Rules: rules, Detail: detail})
+	}
+	reject := func(paymentError *providers.PaymentError) RoutingSimulation {
+		simulation.Provider = paymentReqest.Mode
+		simulation.Error = paymentError
+		return simulation
+	}
+
+	if paymentReqest.Mode != "" {
+		step(StageRequest, "", nil)
+	}
+
+	retry := p.config.Retry
+	if overrides := paymentReqest.Overrides; overrides != nil {
+		authErr := errOverridesNotEnabled
+		if p.config.OverrideAuthorizer != nil {
+			authErr = p.config.OverrideAuthorizer(*overrides)
+		}
+		if authErr != nil {
+			return reject(&providers.PaymentError{
+				Success:      false,
+				ErrorCode:    "UNAUTHORIZED_OVERRIDE",
+				ErrorMessage: authErr.Error(),
+				Err:          authErr,
+			})
+		}
+		if overrides.ForceProvider != "" {
+			paymentReqest.Mode = overrides.ForceProvider
+			step(StageOverride, "forced by "+overrides.Actor, nil)
+		}
+		if overrides.DisableRetries {
+			retry.MaxAttempts = 1
+		}
+	}
+
+	var paymentError *providers.PaymentError
+	if paymentReqest, paymentError = p.resolveSubMerchant(paymentReqest); paymentError != nil {
+		return reject(paymentError)
+	}
+	if paymentReqest, paymentError = p.resolvePartialApproval(paymentReqest); paymentError != nil {
+		return reject(paymentError)
+	}
+	if paymentReqest, paymentError = p.renderDescriptor(paymentReqest); paymentError != nil {
+		return reject(paymentError)
+	}
+
+	if paymentReqest, paymentError = p.applyInstallmentPlan(paymentReqest); paymentError != nil {
+		return reject(paymentError)
+	}
+	if paymentReqest.Installments != nil {
+		step(StageInstallmentPlan, "plan "+paymentReqest.Installments.ID, nil)
+	}
+
+	detected := paymentReqest.Mode == ""
+	if paymentReqest, paymentError = p.detectProvider(paymentReqest); paymentError != nil {
+		return reject(paymentError)
+	}
+	if detected && paymentReqest.Mode != "" {
+		network, _ := p.binTable().Detect(paymentReqest.CardNumber)
+		step(StageCardNetwork, "network "+network, nil)
+	}
+
+	if p.config.Router != nil && paymentReqest.Installments == nil &&
+		(paymentReqest.Overrides == nil || paymentReqest.Overrides.ForceProvider == "") {
+		chosen, rules, err := p.chooseRoute(ctx, paymentReqest, true)
+		if err != nil {
+			return reject(&providers.PaymentError{
+				Success:      false,
+				ErrorCode:    "ROUTING_ERROR",
+				ErrorMessage: err.Error(),
+				Err:          err,
+			})
+		}
+		detail := "no rule matched, the provider is kept"
+		if chosen != nil {
+			paymentReqest.Mode = chosen.GetName()
+			detail = ""
+		}
+		step(StageRouter, detail, rules)
+	}
+
+	paymentProvider, err := p.getProvider(paymentReqest.Mode)
+	if err != nil {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_PROVIDER",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		})
+	}
+	if p.Draining(paymentProvider.GetName()) {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "PROVIDER_DRAINING",
+			ErrorMessage: "provider '" + paymentProvider.GetName() + "' is being drained for maintenance",
+			Reason:       providers.ReasonProcessingError,
+		})
+	}
+	if paymentError = p.checkAuthorizationType(paymentProvider, paymentReqest); paymentError != nil {
+		return reject(paymentError)
+	}
+	providers.ApplyAuthentication(&paymentReqest)
+	if validationError := paymentProvider.ValidateRequest(paymentReqest); validationError != nil {
+		return reject(&providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: validationError.Error(),
+			Err:          validationError,
+		})
+	}
+	if paymentError = p.checkPriorTransaction(paymentReqest); paymentError != nil {
+		return reject(paymentError)
+	}
+
+	simulation.Provider = paymentProvider.GetName()
+	if p.config.QuoteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.QuoteTimeout)
+		defer cancel()
+	}
+	quote := p.quoteProvider(ctx, paymentProvider, paymentReqest)
+	simulation.Quote = &quote
+
+	for _, name := range p.fallbacks(paymentReqest) {
+		if _, _, ok := p.fallbackProvider(name, paymentReqest); ok {
+			simulation.Fallbacks = append(simulation.Fallbacks, name)
+		}
+	}
+	if canary, ok := p.Canary(simulation.Provider); ok {
+		simulation.Canary = &canary
+	}
+
+	simulation.Limits = RoutingLimits{
+		Timeout:       p.providerTimeout(simulation.Provider),
+		MaxAttempts:   retry.MaxAttempts,
+		LatencyBudget: time.Duration(paymentReqest.LatencyBudgetMs) * time.Millisecond,
+		CaptureLimit: p.captureLimit(store.Transaction{
+			Amount:            paymentReqest.Amount,
+			AuthorizationType: paymentReqest.AuthorizationType,
+		}),
+		Forwardable: p.config.Forward.Queue != nil && paymentReqest.Amount <= p.config.Forward.MaxAmount,
+	}
+	if budget, ok := p.RetryBudget(); ok {
+		simulation.Limits.RetryBudget = &budget
+	}
+	return simulation
+}
@@ -0,0 +1,88 @@
+package processor
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// currencyRouter sends each currency to a provider and names the rule
+type currencyRouter map[string]string
+
+func (r currencyRouter) Route(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, error) {
+	chosen, _, err := r.Explain(ctx, req, candidates)
+	return chosen, err
+}
+
+func (r currencyRouter) Explain(ctx context.Context, req providers.PaymentRequest, candidates []providers.Provider) (providers.Provider, []string, error) {
+	for _, candidate := range candidates {
+		if candidate.GetName() == r[req.Currency] {
+			return candidate, []string{req.Currency + "-rule"}, nil
+		}
+	}
+	return nil, nil, nil
+}
+
+func TestSimulateRouting(t *testing.T) {
+	visa := newStubProvider("visa")
+	adyen := &quotingProvider{stubProvider: newStubProvider("adyen"), fee: 1.25}
+	processor := NewPaymentProcessor(nil,
+		WithProviders(visa, adyen),
+		WithRouter(currencyRouter{"EUR": "adyen"}),
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"adyen": {"visa"}}}),
+		WithProviderTimeout("adyen", 5*time.Second),
+	)
+
+	request := stubRequest("")
+	request.Currency = "EUR"
+	request.LatencyBudgetMs = 800
+	simulation := processor.SimulateRouting(context.Background(), request)
+	if simulation.Error != nil {
+		t.Fatalf("Expected the payment to be routable, got %+v", simulation.Error)
+	}
+
+	steps := []RoutingStep{
+		{Stage: StageCardNetwork, Provider: "visa", Detail: "network visa"},
+		{Stage: StageRouter, Provider: "adyen", Rules: []string{"EUR-rule"}},
+	}
+	if simulation.Provider != "adyen" || !reflect.DeepEqual(simulation.Steps, steps) {
+		t.Errorf("Expected detection then the EUR rule, got %s via %+v", simulation.Provider, simulation.Steps)
+	}
+	if !reflect.DeepEqual(simulation.Fallbacks, []string{"visa"}) {
+		t.Errorf("Expected visa as the fallback, got %v", simulation.Fallbacks)
+	}
+	if simulation.Quote == nil || simulation.Quote.Fee != 1.25 {
+		t.Errorf("Expected adyen's quote, got %+v", simulation.Quote)
+	}
+
+	limits := simulation.Limits
+	if limits.Timeout != 5*time.Second || limits.MaxAttempts != 1 || limits.CaptureLimit != 100 || limits.LatencyBudget != 800*time.Millisecond || limits.RetryBudget == nil || limits.Forwardable {
+		t.Errorf("Unexpected limits %+v", limits)
+	}
+
+	if visa.callCount() != 0 || adyen.callCount() != 0 {
+		t.Error("Expected the simulation not to charge")
+	}
+}
+
+func TestSimulateRouting_Rejected(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("visa")))
+	if _, err := processor.Drain(context.Background(), "visa"); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+
+	simulation := processor.SimulateRouting(context.Background(), stubRequest("visa"))
+	if simulation.Provider != "visa" || simulation.Error == nil || simulation.Error.ErrorCode != "PROVIDER_DRAINING" {
+		t.Errorf("Expected the draining provider to reject the payment, got %+v", simulation)
+	}
+
+	request := stubRequest("visa")
+	request.Overrides = &providers.Overrides{ForceProvider: "adyen"}
+	simulation = processor.SimulateRouting(context.Background(), request)
+	if simulation.Error == nil || simulation.Error.ErrorCode != "UNAUTHORIZED_OVERRIDE" {
+		t.Errorf("Expected unauthorized overrides to be rejected, got %+v", simulation)
+	}
+}
@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// where a reported payment status comes from
+const (
+	StatusSourceProvider = "provider" // the gateway answered a lookup
+	StatusSourceStored   = "stored"   // the stored record, the gateway cannot be asked
+)
+
+// PaymentStatus is the current state of a processed payment
+type PaymentStatus struct {
+	TransactionID string    `json:"transaction_id"`
+	Provider      string    `json:"provider"`
+	Status        string    `json:"status"`               // normalized status
+	RawStatus     string    `json:"raw_status,omitempty"` // status as the gateway reported it
+	Source        string    `json:"source"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// GetPaymentStatus returns the current status of a stored payment, asking
+// the provider that processed it. Gateways that cannot look payments up and
+// payments the gateway never accepted, such as declines and deferred
+// payments, are answered from the stored record. A changed status is
+// stored on the payment's timeline.
+func (p *PaymentProcessor) GetPaymentStatus(ctx context.Context, transactionID string) (PaymentStatus, error) {
+	if p.config.Transactions == nil {
+		return PaymentStatus{}, errors.New("no transaction store configured")
+	}
+	tx, err := p.config.Transactions.Get(transactionID)
+	if err != nil {
+		return PaymentStatus{}, err
+	}
+
+	status := PaymentStatus{
+		TransactionID: tx.ID,
+		Provider:      tx.Provider,
+		Status:        tx.Status,
+		Source:        StatusSourceStored,
+		UpdatedAt:     tx.UpdatedAt,
+	}
+	if tx.Status == providers.StatusDeclined || tx.Status == providers.StatusDeferred {
+		return status, nil
+	}
+
+	paymentProvider, err := p.getProvider(tx.Provider)
+	if err != nil {
+		return PaymentStatus{}, err
+	}
+	if timeout := p.providerTimeout(tx.Provider); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	response, err := paymentProvider.GetPaymentStatus(ctx, gatewayReference(tx))
+	if errors.Is(err, providers.ErrStatusQueryNotSupported) {
+		return status, nil
+	}
+	if err != nil {
+		return PaymentStatus{}, err
+	}
+
+	status.Status = response.Status
+	status.RawStatus = response.RawStatus
+	status.Source = StatusSourceProvider
+	if response.Status != tx.Status && response.Status != providers.StatusUnknown {
+		p.updateTransactionStatus(tx.ID, response.Status, "resolved by status query")
+		status.UpdatedAt = time.Now()
+	}
+	return status, nil
+}
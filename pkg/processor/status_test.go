@@ -0,0 +1,69 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// lookupProvider answers status lookups from a fixed table
+type lookupProvider struct {
+	*stubProvider
+	statuses map[string]*providers.PaymentResponse
+	lookups  int
+}
+
+func (l *lookupProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	l.lookups++
+	if response, ok := l.statuses[transactionID]; ok {
+		return response, nil
+	}
+	return nil, errors.New("unknown payment")
+}
+
+func TestGetPaymentStatus(t *testing.T) {
+	provider := &lookupProvider{
+		stubProvider: newStubProvider("ach"),
+		statuses: map[string]*providers.PaymentResponse{
+			"settled": {Success: true, TransactionID: "settled", Status: providers.StatusApproved, RawStatus: "SETTLED"},
+		},
+	}
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	processor := NewPaymentProcessor(nil, WithProviders(provider, newStubProvider("visa")), WithTransactionStore(transactions))
+	for _, tx := range []store.Transaction{
+		{ID: "settled", Provider: "ach", Status: providers.StatusPending},
+		{ID: "lost", Provider: "ach", Status: providers.StatusPending},
+		{ID: "txn_declined", Provider: "ach", Status: providers.StatusDeclined},
+		{ID: "card", Provider: "visa", Status: providers.StatusApproved},
+	} {
+		transactions.Save(tx)
+	}
+	ctx := context.Background()
+
+	status, err := processor.GetPaymentStatus(ctx, "settled")
+	if err != nil || status.Status != providers.StatusApproved || status.RawStatus != "SETTLED" || status.Source != StatusSourceProvider {
+		t.Fatalf("Expected the provider's answer, got %+v (%v)", status, err)
+	}
+	if tx, _ := transactions.Get("settled"); tx.Status != providers.StatusApproved || len(tx.Timeline) != 1 {
+		t.Errorf("Expected the new status stored, got %+v", tx)
+	}
+
+	if status, err := processor.GetPaymentStatus(ctx, "card"); err != nil || status.Status != providers.StatusApproved || status.Source != StatusSourceStored {
+		t.Errorf("Expected the stored status of providers without lookups, got %+v (%v)", status, err)
+	}
+
+	lookups := provider.lookups
+	if status, err := processor.GetPaymentStatus(ctx, "txn_declined"); err != nil || status.Status != providers.StatusDeclined || provider.lookups != lookups {
+		t.Errorf("Expected declines to be answered without a lookup, got %+v (%v)", status, err)
+	}
+
+	if _, err := processor.GetPaymentStatus(ctx, "lost"); err == nil {
+		t.Error("Expected the lookup failure to be returned")
+	}
+	if _, err := processor.GetPaymentStatus(ctx, "missing"); !errors.Is(err, store.ErrTransactionNotFound) {
+		t.Errorf("Expected ErrTransactionNotFound, got %v", err)
+	}
+}
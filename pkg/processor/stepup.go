@@ -0,0 +1,180 @@
+package processor
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/threeds"
+)
+
+// pendingAction is a soft-declined payment waiting for its 3DS challenge.
+// The request, card data included, is only kept in memory.
+type pendingAction struct {
+	request    providers.PaymentRequest
+	provider   string
+	created    time.Time
+	completing bool // taken by CompletePayment, it is not expired meanwhile
+}
+
+func (p *PaymentProcessor) shouldStepUp(paymentError *providers.PaymentError) bool {
+	return p.config.Authenticator != nil && paymentError != nil &&
+		paymentError.Reason == providers.ReasonAuthenticationRequired && !paymentError.DoNotRetry()
+}
+
+// stepUp answers an issuer soft decline with a mandated 3DS challenge and
+// parks the payment until CompletePayment. It returns nil when no challenge
+// could be started, the soft decline then stands.
+func (p *PaymentProcessor) stepUp(ctx context.Context, paymentProvider providers.Provider, paymentReqest providers.PaymentRequest, latency time.Duration, trace *paymentTrace) *providers.PaymentResponse {
+	if p.config.DefaultTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.config.DefaultTimeout)
+		defer cancel()
+	}
+
+	authRequest := threeds.NewRequest(paymentReqest)
+	authRequest.ChallengeMandated = true
+
+	result, err := p.config.Authenticator.Authenticate(ctx, authRequest)
+	if err != nil || result.Authentication.Status != providers.AuthStatusChallenge || result.Challenge == nil {
+		return nil
+	}
+
+	id := newActionID()
+	now := time.Now()
+
+	p.actionsMu.Lock()
+	p.actions[id] = pendingAction{request: paymentReqest, provider: paymentProvider.GetName(), created: now}
+	p.actionsMu.Unlock()
+
+	response := &providers.PaymentResponse{
+		Success:         false,
+		TransactionID:   id,
+		Status:          providers.StatusRequiresAction,
+		Amount:          paymentReqest.Amount,
+		Currency:        paymentReqest.Currency,
+		Date:            &now,
+		SubMerchantID:   paymentReqest.SubMerchantID,
+		MerchantAccount: merchantAccountName(paymentReqest),
+		Challenge:       result.Challenge,
+	}
+	p.enrich(ctx, paymentReqest, response)
+	p.recordTransaction(paymentReqest, response, nil, latency, trace)
+	return response
+}
+
+// CompletePayment resumes a payment in REQUIRES_ACTION state with the result
+// of the cardholder's 3DS challenge. Each payment can be completed once; a
+// payment rejected because the processor is read-only or its provider is
+// being drained stays parked until it expires.
+func (p *PaymentProcessor) CompletePayment(ctx context.Context, paymentID string, authentication providers.Authentication) (*providers.PaymentResponse, *providers.PaymentError) {
+	started := time.Now()
+	paymentReqest, response, paymentError := p.completePayment(ctx, paymentID, authentication)
+	return p.finishPayment(ctx, paymentReqest, response, paymentError, started)
+}
+
+func (p *PaymentProcessor) completePayment(ctx context.Context, paymentID string, authentication providers.Authentication) (providers.PaymentRequest, *providers.PaymentResponse, *providers.PaymentError) {
+	if p.ReadOnly() {
+		return providers.PaymentRequest{}, nil, readOnlyError()
+	}
+
+	p.actionsMu.Lock()
+	action, ok := p.actions[paymentID]
+	ok = ok && !action.completing
+	if ok {
+		release, admitted := p.admit(action.provider)
+		if !admitted {
+			p.actionsMu.Unlock()
+			return action.request, nil, drainingError(action.provider)
+		}
+		defer release()
+
+		action.completing = true
+		p.actions[paymentID] = action
+		defer func() {
+			p.actionsMu.Lock()
+			delete(p.actions, paymentID)
+			p.actionsMu.Unlock()
+		}()
+	}
+	p.actionsMu.Unlock()
+
+	if !ok {
+		return providers.PaymentRequest{}, nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "ACTION_NOT_FOUND",
+			ErrorMessage: "no payment '" + paymentID + "' is waiting for authentication",
+		}
+	}
+
+	if time.Since(action.created) > p.config.ActionExpiry {
+		p.updateTransactionStatus(paymentID, providers.StatusDeclined, "ACTION_EXPIRED")
+		return action.request, nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "ACTION_EXPIRED",
+			ErrorMessage: "the 3-D Secure challenge was not completed in time",
+		}
+	}
+
+	switch authentication.Status {
+	case providers.AuthStatusAuthenticated, providers.AuthStatusAttempted:
+	default:
+		p.updateTransactionStatus(paymentID, providers.StatusDeclined, "AUTHENTICATION_FAILED")
+		return action.request, nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "AUTHENTICATION_FAILED",
+			ErrorMessage: "3-D Secure authentication failed with status '" + authentication.Status + "'",
+			Reason:       providers.ReasonCardDeclined,
+		}
+	}
+
+	paymentProvider, err := p.getProvider(action.provider)
+	if err != nil {
+		return action.request, nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_PROVIDER",
+			ErrorMessage: err.Error(),
+			Err:          err,
+		}
+	}
+
+	// the challenge result replaces whatever authentication the first
+	// attempt carried
+	paymentReqest := action.request
+	paymentReqest.Authentication = &authentication
+	paymentReqest.ECI, paymentReqest.Cryptogram, paymentReqest.DSTransactionID = "", "", ""
+	providers.ApplyAuthentication(&paymentReqest)
+
+	if validationError := paymentProvider.ValidateRequest(paymentReqest); validationError != nil {
+		p.updateTransactionStatus(paymentID, providers.StatusDeclined, "INVALID_REQUEST")
+		return paymentReqest, nil, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_REQUEST",
+			ErrorMessage: validationError.Error(),
+			Err:          validationError,
+		}
+	}
+
+	response, paymentError := p.attemptPayment(ctx, paymentProvider, paymentID, paymentReqest, p.providerTimeout(paymentProvider.GetName()))
+	if paymentError != nil {
+		p.updateTransactionStatus(paymentID, providers.StatusDeclined, paymentError.ErrorCode)
+		return paymentReqest, nil, paymentError
+	}
+
+	response = p.completeResponse(ctx, paymentProvider, paymentReqest, response)
+	p.updateTransactionReference(paymentID, response.GatewayReference)
+	p.updateTransactionStatus(paymentID, response.Status, "completed as "+response.GatewayReference)
+	p.updateTransactionExtra(paymentID, response.Extra)
+	if response.PartialApproval && paymentReqest.PartialApproval == providers.PartialApprovalVoid {
+		return paymentReqest, nil, p.voidPartialApproval(ctx, paymentProvider, response)
+	}
+	return paymentReqest, response, nil
+}
+
+func newActionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "act_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,145 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/threeds"
+)
+
+var softDecline = &providers.PaymentError{
+	ErrorCode:    "EE00001A",
+	ErrorMessage: "Additional customer authentication required",
+	Reason:       providers.ReasonAuthenticationRequired,
+}
+
+var challengeResult = providers.Authentication{
+	Version:         "2.2.0",
+	Status:          providers.AuthStatusAuthenticated,
+	ECI:             "05",
+	CAVV:            "AAABBEg0VhI0VniQEjRWAAAAAAA=",
+	DSTransactionID: "f25084f0-5b16-4c0a-ae5d-b24808a95e4b",
+}
+
+func TestProcessPayment_SoftDeclineStepUp(t *testing.T) {
+	stub := newStubProvider("stub", softDecline, nil)
+	processor := NewPaymentProcessor(nil, WithProviders(stub), WithAuthenticator(threeds.NewSimulator()))
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected soft decline to require action, got error: %v", err)
+	}
+	if response.Success || response.Status != providers.StatusRequiresAction || response.Challenge == nil {
+		t.Fatalf("Expected REQUIRES_ACTION with a challenge, got %+v", response)
+	}
+	if tx, _ := processor.Transactions().Get(response.TransactionID); tx.Status != providers.StatusRequiresAction {
+		t.Errorf("Expected stored status REQUIRES_ACTION, got %q", tx.Status)
+	}
+
+	completed, err := processor.CompletePayment(context.Background(), response.TransactionID, challengeResult)
+	if err != nil {
+		t.Fatalf("Expected completed payment to succeed, got: %v", err)
+	}
+	if completed.Status != providers.StatusApproved || completed.Provider != "stub" {
+		t.Errorf("Expected APPROVED by stub, got %s by %q", completed.Status, completed.Provider)
+	}
+	if counted := processor.Counters()[providers.StatusApproved]; counted != 1 {
+		t.Errorf("Expected the completion counted as APPROVED, got %d", counted)
+	}
+	if received := stub.received(); received.ECI != "05" || received.Cryptogram != challengeResult.CAVV {
+		t.Errorf("Expected challenge result to reach the provider, got eci=%q cryptogram=%q", received.ECI, received.Cryptogram)
+	}
+
+	tx, _ := processor.Transactions().Get(response.TransactionID)
+	if tx.Status != providers.StatusApproved || tx.Timeline[len(tx.Timeline)-1].Detail != "completed as stub-tx" {
+		t.Errorf("Expected completion on the timeline, got %+v", tx.Timeline)
+	}
+
+	if _, err := processor.CompletePayment(context.Background(), response.TransactionID, challengeResult); err == nil || err.ErrorCode != "ACTION_NOT_FOUND" {
+		t.Errorf("Expected second completion to fail with ACTION_NOT_FOUND, got %v", err)
+	}
+}
+
+func TestCompletePayment_Failures(t *testing.T) {
+	failed := challengeResult
+	failed.Status = providers.AuthStatusFailed
+
+	cases := []struct {
+		name   string
+		expiry bool
+		result providers.Authentication
+		code   string
+	}{
+		{"failed challenge", false, failed, "AUTHENTICATION_FAILED"},
+		{"expired", true, challengeResult, "ACTION_EXPIRED"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []Option{WithProviders(newStubProvider("stub", softDecline, nil)), WithAuthenticator(threeds.NewSimulator())}
+			if tc.expiry {
+				opts = append(opts, WithActionExpiry(0))
+			}
+			processor := NewPaymentProcessor(nil, opts...)
+
+			response, _ := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+			if _, err := processor.CompletePayment(context.Background(), response.TransactionID, tc.result); err == nil || err.ErrorCode != tc.code {
+				t.Fatalf("Expected %s, got %v", tc.code, err)
+			}
+			if tx, _ := processor.Transactions().Get(response.TransactionID); tx.Status != providers.StatusDeclined {
+				t.Errorf("Expected stored status DECLINED, got %q", tx.Status)
+			}
+		})
+	}
+}
+
+func TestCompletePayment_ReadOnly(t *testing.T) {
+	stub := newStubProvider("stub", softDecline, nil)
+	processor := NewPaymentProcessor(nil, WithProviders(stub), WithAuthenticator(threeds.NewSimulator()))
+
+	response, _ := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	processor.SetReadOnly(true)
+
+	if _, err := processor.CompletePayment(context.Background(), response.TransactionID, challengeResult); err == nil || err.ErrorCode != "READ_ONLY_MODE" {
+		t.Fatalf("Expected READ_ONLY_MODE, got %v", err)
+	}
+	if calls := stub.callCount(); calls != 1 {
+		t.Fatalf("Expected no charge while read-only, got %d calls", calls)
+	}
+
+	processor.SetReadOnly(false)
+	if completed, err := processor.CompletePayment(context.Background(), response.TransactionID, challengeResult); err != nil || completed.Status != providers.StatusApproved {
+		t.Errorf("Expected the parked payment to complete once writable, got %+v, %v", completed, err)
+	}
+}
+
+func TestCompletePayment_Draining(t *testing.T) {
+	stub := newStubProvider("stub", softDecline, nil)
+	processor := NewPaymentProcessor(nil, WithProviders(stub), WithAuthenticator(threeds.NewSimulator()))
+
+	response, _ := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if _, err := processor.Drain(context.Background(), "stub"); err != nil {
+		t.Fatalf("Drain failed: %v", err)
+	}
+
+	if _, err := processor.CompletePayment(context.Background(), response.TransactionID, challengeResult); err == nil || err.ErrorCode != "PROVIDER_DRAINING" {
+		t.Fatalf("Expected PROVIDER_DRAINING, got %v", err)
+	}
+	if calls := stub.callCount(); calls != 1 {
+		t.Fatalf("Expected no call to the drained provider, got %d calls", calls)
+	}
+
+	processor.Resume("stub")
+	if completed, err := processor.CompletePayment(context.Background(), response.TransactionID, challengeResult); err != nil || completed.Status != providers.StatusApproved {
+		t.Errorf("Expected the parked payment to complete after Resume, got %+v, %v", completed, err)
+	}
+}
+
+func TestProcessPayment_SoftDeclineWithoutAuthenticator(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub", softDecline)))
+
+	if _, err := processor.ProcessPayment(context.Background(), stubRequest("stub")); err == nil || err.Reason != providers.ReasonAuthenticationRequired {
+		t.Errorf("Expected the soft decline without an authenticator, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"pgas/pkg/providers"
+)
+
+// stubProvider is a deterministic provider for processor tests
+type stubProvider struct {
+	name string
+
+	mu          sync.Mutex
+	calls       int
+	lastRequest providers.PaymentRequest
+	// outcomes are consumed in order, the last one repeats; nil means success
+	outcomes []*providers.PaymentError
+}
+
+func newStubProvider(name string, outcomes ...*providers.PaymentError) *stubProvider {
+	return &stubProvider{name: name, outcomes: outcomes}
+}
+
+func (s *stubProvider) GetName() string {
+	return s.name
+}
+
+func (s *stubProvider) ValidateRequest(request providers.PaymentRequest) error {
+	if request.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	return nil
+}
+
+func (s *stubProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var outcome *providers.PaymentError
+	if len(s.outcomes) > 0 {
+		index := s.calls
+		if index >= len(s.outcomes) {
+			index = len(s.outcomes) - 1
+		}
+		outcome = s.outcomes[index]
+	}
+	s.calls++
+	s.lastRequest = request
+
+	if outcome != nil {
+		return nil, outcome
+	}
+
+	return &providers.PaymentResponse{
+		Success:       true,
+		TransactionID: s.name + "-tx",
+		Status:        "APPROVED",
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
+
+func (s *stubProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	parsed, ok := response.(*providers.PaymentResponse)
+	if !ok {
+		return nil, errors.New("invalid response type")
+	}
+	return parsed, nil
+}
+
+func (s *stubProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	return nil, providers.ErrRefundNotSupported
+}
+
+func (s *stubProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return nil, providers.ErrStatusQueryNotSupported
+}
+
+func (s *stubProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	parsed, ok := response.(*providers.PaymentError)
+	if !ok {
+		return nil, errors.New("invalid response error type")
+	}
+	return parsed, nil
+}
+
+func (s *stubProvider) callCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+func (s *stubProvider) received() providers.PaymentRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastRequest
+}
+
+func stubRequest(mode string) providers.PaymentRequest {
+	return providers.PaymentRequest{
+		Mode:        mode,
+		Amount:      100.00,
+		Currency:    "USD",
+		CardNumber:  "4111111111111111",
+		ExpiryMonth: "12",
+		ExpiryYear:  "2030",
+		CVV:         "123",
+	}
+}
@@ -0,0 +1,60 @@
+package processor
+
+import (
+	"pgas/pkg/merchant"
+	"pgas/pkg/providers"
+)
+
+// resolveSubMerchant checks the sub-merchant of record and applies its
+// statement descriptor, support contact and country unless the request
+// already carries them
+func (p *PaymentProcessor) resolveSubMerchant(paymentReqest providers.PaymentRequest) (providers.PaymentRequest, *providers.PaymentError) {
+	if paymentReqest.SubMerchantID == "" {
+		if paymentReqest.SupportContact == nil {
+			paymentReqest.SupportContact = p.config.SupportContact
+		}
+		return paymentReqest, nil
+	}
+
+	if p.config.Merchants == nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_SUB_MERCHANT",
+			ErrorMessage: "sub-merchant charges are not enabled on this processor",
+		}
+	}
+
+	subMerchant, err := p.config.Merchants.ResolveActive(paymentReqest.SubMerchantID)
+	if err != nil {
+		return paymentReqest, &providers.PaymentError{
+			Success:      false,
+			ErrorCode:    "INVALID_SUB_MERCHANT",
+			ErrorMessage: err.Error() + ": '" + paymentReqest.SubMerchantID + "'",
+			Err:          err,
+		}
+	}
+
+	if paymentReqest.Descriptor == "" {
+		paymentReqest.Descriptor = subMerchant.Descriptor
+	}
+	if paymentReqest.MerchantCountry == "" {
+		paymentReqest.MerchantCountry = subMerchant.Country
+	}
+	if paymentReqest.SupportContact == nil {
+		paymentReqest.SupportContact = p.supportContact(subMerchant)
+	}
+
+	return paymentReqest, nil
+}
+
+// supportContact picks the sub-merchant's contact, falling back to its
+// platform's and then to the processor's
+func (p *PaymentProcessor) supportContact(subMerchant merchant.SubMerchant) *providers.SupportContact {
+	if subMerchant.SupportContact != nil {
+		return subMerchant.SupportContact
+	}
+	if platform, err := p.config.Merchants.Merchant(subMerchant.PlatformID); err == nil && platform.SupportContact != nil {
+		return platform.SupportContact
+	}
+	return p.config.SupportContact
+}
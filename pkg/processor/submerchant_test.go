@@ -0,0 +1,72 @@
+package processor
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/merchant"
+	"pgas/pkg/providers"
+)
+
+func TestProcessPayment_SubMerchant(t *testing.T) {
+	registry := merchant.NewRegistry()
+	registry.AddMerchant(merchant.Merchant{ID: "plat_1"})
+	registry.AddSubMerchant(merchant.SubMerchant{ID: "sub_1", PlatformID: "plat_1", Descriptor: "SHOP*ACME", Country: "DE", Active: true})
+	registry.AddSubMerchant(merchant.SubMerchant{ID: "sub_2", PlatformID: "plat_1", Active: false})
+
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")), WithMerchantRegistry(registry))
+
+	request := stubRequest("stub")
+	request.SubMerchantID = "sub_1"
+
+	response, err := processor.ProcessPayment(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected successful payment, got error: %v", err)
+	}
+
+	if response.SubMerchantID != "sub_1" {
+		t.Errorf("Expected sub-merchant 'sub_1' on response, got %s", response.SubMerchantID)
+	}
+
+	if tx, _ := processor.Transactions().Get(response.TransactionID); tx.MerchantCountry != "DE" {
+		t.Errorf("Expected the sub-merchant's country on the record for data residency, got '%s'", tx.MerchantCountry)
+	}
+
+	for _, id := range []string{"sub_2", "missing"} {
+		request.SubMerchantID = id
+		if _, err := processor.ProcessPayment(context.Background(), request); err == nil || err.ErrorCode != "INVALID_SUB_MERCHANT" {
+			t.Errorf("Expected INVALID_SUB_MERCHANT for %s, got: %v", id, err)
+		}
+	}
+}
+
+func TestProcessPayment_SupportContact(t *testing.T) {
+	registry := merchant.NewRegistry()
+	registry.AddMerchant(merchant.Merchant{ID: "plat_1", SupportContact: &providers.SupportContact{URL: "plat.io/help"}})
+	registry.AddSubMerchant(merchant.SubMerchant{ID: "sub_1", PlatformID: "plat_1", Active: true,
+		SupportContact: &providers.SupportContact{Phone: "+1 555 0100"}})
+	registry.AddSubMerchant(merchant.SubMerchant{ID: "sub_2", PlatformID: "plat_1", Active: true})
+
+	provider := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithMerchantRegistry(registry),
+		WithSupportContact(providers.SupportContact{Phone: "+1 555 0199"}),
+	)
+
+	cases := map[string]providers.SupportContact{
+		"sub_1": {Phone: "+1 555 0100"},
+		"sub_2": {URL: "plat.io/help"},
+		"":      {Phone: "+1 555 0199"},
+	}
+	for subMerchantID, expected := range cases {
+		request := stubRequest("stub")
+		request.SubMerchantID = subMerchantID
+		if _, err := processor.ProcessPayment(context.Background(), request); err != nil {
+			t.Fatalf("Expected successful payment, got error: %v", err)
+		}
+		if sent := provider.received().SupportContact; sent == nil || *sent != expected {
+			t.Errorf("Sub-merchant '%s': expected contact %+v, got %+v", subMerchantID, expected, sent)
+		}
+	}
+}
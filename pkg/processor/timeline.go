@@ -0,0 +1,210 @@
+package processor
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+	"pgas/pkg/webhooks"
+)
+
+// kinds of timeline entries
+const (
+	TimelineStatus     = "status" // status change of the stored payment
+	TimelineValidation = "validation"
+	TimelineRouting    = "routing"
+	TimelineAttempt    = "attempt" // one gateway call, retries and fallbacks included
+	TimelineWebhook    = "webhook"
+	TimelineCapture    = "capture"
+	TimelineRefund     = "refund"
+	TimelineDispute    = "dispute"
+	TimelineNote       = "note"
+	TimelineAudit      = "audit"
+	TimelineEvent      = "event"
+)
+
+// sources of timeline entries
+const (
+	SourceTransactions = "transactions"
+	SourceCaptures     = "captures"
+	SourceRefunds      = "refunds"
+	SourceAudit        = "audit"
+	SourceEvents       = "events"
+)
+
+// TimelineEntry is one thing that happened to a payment
+type TimelineEntry struct {
+	Time     time.Time         `json:"time"`
+	Kind     string            `json:"kind"`
+	Source   string            `json:"source"`
+	Status   string            `json:"status,omitempty"`
+	Provider string            `json:"provider,omitempty"`
+	Actor    string            `json:"actor,omitempty"`
+	Detail   string            `json:"detail,omitempty"`
+	Data     map[string]string `json:"data,omitempty"`
+}
+
+// GetTimeline assembles everything that happened to a stored payment, oldest
+// first, for support tooling: its validation, provider choice and gateway
+// attempts, status changes and gateway webhooks, captures, refunds,
+// chargebacks, operator notes, audited actions and published events.
+// Audited actions and events are only included when the audit log
+// implements audit.Reader and the publisher events.History. Webhooks come
+// from the payment's status changes, so their notification events are left
+// out. Payments that were never stored return store.ErrTransactionNotFound.
+func (p *PaymentProcessor) GetTimeline(transactionID string) ([]TimelineEntry, error) {
+	if p.config.Transactions == nil {
+		return nil, store.ErrTransactionNotFound
+	}
+	tx, err := p.config.Transactions.Get(transactionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var timeline []TimelineEntry
+	for _, step := range tx.Processing {
+		timeline = append(timeline, processingEntry(step))
+	}
+	for _, change := range tx.Timeline {
+		timeline = append(timeline, statusEntry(tx, change))
+	}
+	for _, annotation := range tx.Annotations {
+		entry := TimelineEntry{Time: annotation.Time, Kind: TimelineNote, Source: SourceTransactions, Actor: annotation.Author, Detail: annotation.Note}
+		if len(annotation.Tags) > 0 {
+			entry.Data = map[string]string{"tags": strings.Join(annotation.Tags, ",")}
+		}
+		timeline = append(timeline, entry)
+	}
+
+	if p.config.Captures != nil {
+		captures, err := p.config.Captures.ForTransaction(transactionID)
+		if err != nil {
+			return nil, err
+		}
+		for _, capture := range captures {
+			timeline = append(timeline, TimelineEntry{
+				Time:     capture.CreatedAt,
+				Kind:     TimelineCapture,
+				Source:   SourceCaptures,
+				Status:   capture.Status,
+				Provider: capture.Provider,
+				Detail:   capture.ErrorCode,
+				Data:     map[string]string{"capture_id": capture.ID, "amount": formatAmount(capture.Amount), "currency": capture.Currency},
+			})
+		}
+	}
+
+	if p.config.Refunds != nil {
+		for _, refund := range p.config.Refunds.ForTransaction(transactionID) {
+			timeline = append(timeline, TimelineEntry{
+				Time:     refund.Time,
+				Kind:     TimelineRefund,
+				Source:   SourceRefunds,
+				Provider: refund.Provider,
+				Detail:   string(refund.Reason),
+				Data:     map[string]string{"refund_id": refund.RefundID, "amount": formatAmount(refund.Amount), "currency": refund.Currency},
+			})
+		}
+	}
+
+	if log, ok := p.config.AuditLog.(audit.Reader); ok {
+		for _, entry := range log.Entries() {
+			// notes are on the stored payment already
+			if entry.Reference != transactionID || entry.Action == "transaction.annotated" {
+				continue
+			}
+			timeline = append(timeline, TimelineEntry{Time: entry.Time, Kind: TimelineAudit, Source: SourceAudit, Actor: entry.Actor, Detail: entry.Action, Data: entry.Details})
+		}
+	}
+
+	if history, ok := p.config.Events.(events.History); ok {
+		for _, event := range history.Events() {
+			if event.TransactionID != transactionID || event.Type == events.TypeGatewayNotification {
+				continue
+			}
+			timeline = append(timeline, TimelineEntry{Time: event.Time, Kind: TimelineEvent, Source: SourceEvents, Provider: event.Provider, Detail: event.Type, Data: event.Data})
+		}
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return timeline[i].Time.Before(timeline[j].Time)
+	})
+	return timeline, nil
+}
+
+func processingEntry(step store.ProcessingStep) TimelineEntry {
+	entry := TimelineEntry{Time: step.Time, Source: SourceTransactions, Provider: step.Provider}
+	switch step.Step {
+	case store.StepValidated:
+		entry.Kind = TimelineValidation
+		entry.Status = "PASSED"
+	case store.StepRouted:
+		entry.Kind = TimelineRouting
+		entry.Detail = step.Outcome
+	default:
+		entry.Kind = TimelineAttempt
+		entry.Status = step.Outcome
+		entry.Data = map[string]string{"latency_ms": strconv.FormatInt(step.LatencyMs, 10)}
+	}
+	return entry
+}
+
+// statusEntry classifies a status change, webhooks record theirs with a
+// detail starting with "webhook"
+func statusEntry(tx store.Transaction, change store.StatusChange) TimelineEntry {
+	entry := TimelineEntry{Time: change.Time, Kind: TimelineStatus, Source: SourceTransactions, Status: change.Status, Provider: tx.Provider, Detail: change.Detail}
+	switch {
+	case change.Status == webhookTimeline[webhooks.EventChargeback]:
+		entry.Kind = TimelineDispute
+	case strings.HasPrefix(change.Detail, "webhook "):
+		entry.Kind = TimelineWebhook
+	}
+	return entry
+}
+
+// paymentTrace collects the processing steps of a payment for its stored
+// record. A nil trace collects nothing.
+type paymentTrace struct {
+	steps []store.ProcessingStep
+}
+
+func (t *paymentTrace) add(step store.ProcessingStep) {
+	if t != nil {
+		t.steps = append(t.steps, step)
+	}
+}
+
+func (t *paymentTrace) validated() {
+	t.add(store.ProcessingStep{Step: store.StepValidated, Time: time.Now()})
+}
+
+// routed records the provider chosen and how: request, override,
+// installment_plan, bin, router or fallback
+func (t *paymentTrace) routed(provider, via string) {
+	t.add(store.ProcessingStep{Step: store.StepRouted, Time: time.Now(), Provider: provider, Outcome: via})
+}
+
+// attempt records a gateway call started at started with its status or
+// error code
+func (t *paymentTrace) attempt(provider string, started time.Time, response *providers.PaymentResponse, paymentError *providers.PaymentError) {
+	step := store.ProcessingStep{Step: store.StepAttempt, Time: started, Provider: provider, LatencyMs: time.Since(started).Milliseconds()}
+	if paymentError != nil {
+		step.Outcome = paymentError.ErrorCode
+	} else if response != nil {
+		step.Outcome = response.Status
+	}
+	t.add(step)
+}
+
+// processing returns the collected steps
+func (t *paymentTrace) processing() []store.ProcessingStep {
+	if t == nil {
+		return nil
+	}
+	return t.steps
+}
@@ -0,0 +1,83 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pgas/pkg/audit"
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+func TestGetTimeline(t *testing.T) {
+	provider := &prepaidProvider{
+		stubProvider: newStubProvider("stub", &providers.PaymentError{Success: false, ErrorCode: "PROCESSING_ERROR", ErrorMessage: "gateway timeout"}, nil),
+		balance:      1000,
+	}
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, Retryable: func(*providers.PaymentError) bool { return true }}),
+		WithRetryBudget(RetryBudget{}),
+		WithAuditLog(audit.NewMemoryLog()),
+		WithEventPublisher(publisher),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected the retry to be approved, got: %v", err)
+	}
+	if _, err := processor.RefundPayment(context.Background(), providers.RefundRequest{TransactionID: response.TransactionID, Amount: 30, Currency: "USD", Reason: providers.RefundDuplicate}); err != nil {
+		t.Fatalf("Expected the refund to succeed, got: %v", err)
+	}
+	if _, err := processor.AnnotateTransaction(response.TransactionID, "support", "customer called", nil); err != nil {
+		t.Fatal(err)
+	}
+	processor.publish(context.Background(), events.Event{Type: events.TypePaymentSettled, Time: time.Now(), TransactionID: response.TransactionID})
+	processor.publish(context.Background(), events.Event{Type: events.TypePaymentSettled, Time: time.Now(), TransactionID: "other"})
+
+	timeline, timelineErr := processor.GetTimeline(response.TransactionID)
+	if timelineErr != nil {
+		t.Fatalf("Expected the timeline, got: %v", timelineErr)
+	}
+
+	want := []struct{ kind, status string }{
+		{TimelineValidation, "PASSED"},
+		{TimelineRouting, ""},
+		{TimelineAttempt, "PROCESSING_ERROR"},
+		{TimelineAttempt, providers.StatusApproved},
+		{TimelineStatus, "SUBMITTED"},
+		{TimelineStatus, providers.StatusApproved},
+		{TimelineRefund, ""},
+		{TimelineNote, ""},
+		{TimelineEvent, ""},
+	}
+	if len(timeline) != len(want) {
+		t.Fatalf("Expected %d entries, got %+v", len(want), timeline)
+	}
+	for i, entry := range timeline {
+		if entry.Kind != want[i].kind || entry.Status != want[i].status {
+			t.Errorf("entry %d: expected %s %s, got %+v", i, want[i].kind, want[i].status, entry)
+		}
+		if i > 0 && entry.Time.Before(timeline[i-1].Time) {
+			t.Errorf("entry %d is out of order", i)
+		}
+	}
+	if timeline[1].Provider != "stub" || timeline[1].Detail != "request" {
+		t.Errorf("Expected the routing decision, got %+v", timeline[1])
+	}
+	if timeline[6].Data["amount"] != "30" || timeline[7].Actor != "support" || timeline[8].Detail != events.TypePaymentSettled {
+		t.Errorf("Expected refund, note and event details, got %+v", timeline[6:])
+	}
+}
+
+func TestGetTimeline_UnknownPayment(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	if _, err := processor.GetTimeline("missing"); !errors.Is(err, store.ErrTransactionNotFound) {
+		t.Errorf("Expected ErrTransactionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// providerTimeout bounds a single gateway call to the named provider
+func (p *PaymentProcessor) providerTimeout(name string) time.Duration {
+	if timeout, ok := p.config.ProviderTimeouts[name]; ok {
+		return timeout
+	}
+	return p.config.DefaultTimeout
+}
+
+// cancelled reports a payment the caller abandoned before it reached the
+// provider
+func cancelled(ctx context.Context) *providers.PaymentError {
+	code := "REQUEST_CANCELLED"
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		code = "REQUEST_TIMEOUT"
+	}
+	return &providers.PaymentError{
+		Success:      false,
+		ErrorCode:    code,
+		ErrorMessage: "payment abandoned by the caller: " + ctx.Err().Error(),
+		Reason:       providers.ReasonProcessingError,
+		Err:          ctx.Err(),
+	}
+}
+
+// inDoubt answers for a submitted payment whose outcome is not known, e.g.
+// a gateway call that timed out: the card may have been charged, so the
+// payment is neither declined nor retried or failed over. It is UNKNOWN
+// under the local id, with the reason as its raw status, and followed up
+// through status queries and in-doubt reports.
+func inDoubt(id string, paymentReqest providers.PaymentRequest, reason string) *providers.PaymentResponse {
+	return &providers.PaymentResponse{
+		Success:       false,
+		TransactionID: id,
+		Status:        providers.StatusUnknown,
+		RawStatus:     reason,
+		Amount:        paymentReqest.Amount,
+		Currency:      paymentReqest.Currency,
+	}
+}
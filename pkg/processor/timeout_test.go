@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+func TestProcessPayment_ProviderTimeout(t *testing.T) {
+	slow := &slowProvider{stubProvider: newStubProvider("slow")}
+	other := &slowProvider{stubProvider: newStubProvider("other")}
+	processor := NewPaymentProcessor(nil,
+		WithProviders(slow, other),
+		WithDefaultTimeout(time.Second),
+		WithProviderTimeout("slow", 20*time.Millisecond),
+	)
+
+	started := time.Now()
+	if response, _ := processor.ProcessPayment(context.Background(), stubRequest("slow")); response == nil || response.RawStatus != "REQUEST_TIMEOUT" {
+		t.Fatalf("Expected the provider timeout to end the payment, got %+v", response)
+	}
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected the 20ms provider timeout to apply, payment took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	processor.ProcessPayment(ctx, stubRequest("other"))
+	if timeouts := other.attemptTimeouts(); timeouts[0] > 100*time.Millisecond {
+		t.Errorf("Expected the caller's deadline to reach the provider, got %v left", timeouts[0])
+	}
+}
+
+// stuckProvider never answers in time and ignores its context
+type stuckProvider struct {
+	*stubProvider
+	release chan struct{}
+}
+
+func (s *stuckProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	<-s.release
+	return s.stubProvider.ProcessPayment(ctx, request)
+}
+
+func TestProcessPayment_ProviderTimeoutInDoubt(t *testing.T) {
+	stuck := &stuckProvider{stubProvider: newStubProvider("stuck"), release: make(chan struct{})}
+	defer close(stuck.release)
+	backup := newStubProvider("backup")
+	processor := NewPaymentProcessor(nil,
+		WithProviders(stuck, backup),
+		WithProviderTimeout("stuck", 20*time.Millisecond),
+		WithFailover(FailoverPolicy{Fallbacks: map[string][]string{"stuck": {"backup"}}}),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 3, Retryable: func(*providers.PaymentError) bool { return true }}),
+	)
+
+	started := time.Now()
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stuck"))
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Fatalf("Expected the call to be abandoned after 20ms, payment took %v", elapsed)
+	}
+	if err != nil {
+		t.Fatalf("Expected a timeout to be in doubt rather than failed, got %v", err)
+	}
+	if response.Success || response.Status != providers.StatusUnknown || response.RawStatus != "REQUEST_TIMEOUT" {
+		t.Errorf("Expected UNKNOWN after REQUEST_TIMEOUT, got %+v", response)
+	}
+	if backup.callCount() != 0 {
+		t.Errorf("Expected no failover of a payment that may have been charged, got %d backup calls", backup.callCount())
+	}
+
+	if tx, _ := processor.Transactions().Get(response.TransactionID); tx.Status != providers.StatusUnknown {
+		t.Errorf("Expected the payment stored as UNKNOWN, got %q", tx.Status)
+	}
+	if unresolved := processor.UnresolvedPayments(); len(unresolved) != 1 || unresolved[0].RawStatus != "REQUEST_TIMEOUT" {
+		t.Errorf("Expected the payment tracked as unresolved, got %+v", unresolved)
+	}
+}
+
+func TestProcessPayment_Cancelled(t *testing.T) {
+	provider := newStubProvider("stub")
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := processor.ProcessPayment(ctx, stubRequest("stub"))
+	if err == nil || err.ErrorCode != "REQUEST_CANCELLED" {
+		t.Fatalf("Expected REQUEST_CANCELLED, got: %v", err)
+	}
+	if !errors.Is(err, providers.ErrRequestCancelled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the error to match ErrRequestCancelled and wrap context.Canceled")
+	}
+	if provider.callCount() != 0 {
+		t.Errorf("Expected no provider call for a cancelled payment, got %d", provider.callCount())
+	}
+}
+
+func TestProcessPayment_CancelStopsRetries(t *testing.T) {
+	provider := newStubProvider("stub", &providers.PaymentError{ErrorCode: "GATEWAY_TIMEOUT"})
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithRetryPolicy(RetryPolicy{
+			MaxAttempts: 5,
+			Backoff:     time.Second,
+			Retryable:   func(*providers.PaymentError) bool { return true },
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	_, err := processor.ProcessPayment(ctx, stubRequest("stub"))
+	if err == nil || err.ErrorCode != "GATEWAY_TIMEOUT" {
+		t.Errorf("Expected the last attempt's error, got: %v", err)
+	}
+	if provider.callCount() != 1 || time.Since(started) > 500*time.Millisecond {
+		t.Errorf("Expected cancellation to end the backoff, got %d calls in %v", provider.callCount(), time.Since(started))
+	}
+}
@@ -0,0 +1,74 @@
+package processor
+
+import (
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// stageTimer measures the stages of a payment for the optional Timings
+// breakdown. A nil timer measures nothing.
+type stageTimer struct {
+	start   time.Time
+	lap     time.Time
+	timings providers.Timings
+}
+
+func newStageTimer() *stageTimer {
+	now := time.Now()
+	return &stageTimer{start: now, lap: now}
+}
+
+// stage ends the running stage and adds its duration to the field picked
+func (t *stageTimer) stage(field func(*providers.Timings) *float64) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	*field(&t.timings) += milliseconds(now.Sub(t.lap))
+	t.lap = now
+}
+
+func (t *stageTimer) validation() {
+	t.stage(func(timings *providers.Timings) *float64 { return &timings.ValidationMs })
+}
+
+func (t *stageTimer) fraud() {
+	t.stage(func(timings *providers.Timings) *float64 { return &timings.FraudMs })
+}
+
+func (t *stageTimer) gateway() {
+	t.stage(func(timings *providers.Timings) *float64 { return &timings.GatewayMs })
+}
+
+// skip ends the running stage without attributing it, e.g. retry backoff
+func (t *stageTimer) skip() {
+	if t != nil {
+		t.lap = time.Now()
+	}
+}
+
+// attach sets the breakdown on whichever outcome the payment had. Errors
+// may be shared by providers, so they are copied first.
+func (t *stageTimer) attach(response *providers.PaymentResponse, paymentError *providers.PaymentError) (*providers.PaymentResponse, *providers.PaymentError) {
+	if t == nil {
+		return response, paymentError
+	}
+
+	timings := t.timings
+	timings.TotalMs = milliseconds(time.Since(t.start))
+
+	if response != nil {
+		response.Timings = &timings
+	}
+	if paymentError != nil {
+		copied := *paymentError
+		copied.Timings = &timings
+		paymentError = &copied
+	}
+	return response, paymentError
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
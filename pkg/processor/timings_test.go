@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/screening"
+)
+
+// delayedProvider answers every gateway call after a fixed delay
+type delayedProvider struct {
+	*stubProvider
+	delay time.Duration
+}
+
+func (s *delayedProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	time.Sleep(s.delay)
+	return s.stubProvider.ProcessPayment(ctx, request)
+}
+
+type slowScreener struct {
+	delay time.Duration
+}
+
+func (s slowScreener) Screen(ctx context.Context, request screening.Request) (*screening.Result, error) {
+	time.Sleep(s.delay)
+	return &screening.Result{Decision: screening.Clear}, nil
+}
+
+func TestTimings(t *testing.T) {
+	processor := NewPaymentProcessor(nil,
+		WithProviders(&delayedProvider{stubProvider: newStubProvider("stub"), delay: 20 * time.Millisecond}),
+		WithScreeningPolicy(ScreeningPolicy{Screener: slowScreener{delay: 10 * time.Millisecond}}),
+		WithTimings(),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected successful payment, got error: %v", err)
+	}
+
+	timings := response.Timings
+	if timings == nil {
+		t.Fatal("Expected timings on the response")
+	}
+	if timings.GatewayMs < 20 || timings.FraudMs < 10 || timings.FraudMs >= 20 {
+		t.Errorf("Expected gateway and fraud time to be attributed, got %+v", timings)
+	}
+	if timings.TotalMs < timings.ValidationMs+timings.FraudMs+timings.GatewayMs {
+		t.Errorf("Expected total to cover all stages, got %+v", timings)
+	}
+}
+
+func TestTimings_Errors(t *testing.T) {
+	declined := &providers.PaymentError{ErrorCode: "51", Reason: providers.ReasonInsufficientFunds}
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub", declined)), WithTimings())
+
+	_, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err == nil || err.Timings == nil {
+		t.Fatalf("Expected timings on the error, got %+v", err)
+	}
+	if declined.Timings != nil {
+		t.Error("Expected the provider's error to be left untouched")
+	}
+}
+
+func TestTimings_Disabled(t *testing.T) {
+	processor := NewPaymentProcessor(nil, WithProviders(newStubProvider("stub")))
+
+	response, _ := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if response.Timings != nil {
+		t.Errorf("Expected no timings unless enabled, got %+v", response.Timings)
+	}
+}
@@ -0,0 +1,163 @@
+package processor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+	"pgas/pkg/store"
+)
+
+// Transactions returns the store holding processed payments
+func (p *PaymentProcessor) Transactions() store.Transactions {
+	return p.config.Transactions
+}
+
+// recordTransaction stores the outcome of a payment that reached a provider.
+// Payments are stored under their local id, never the gateway reference:
+// gateways need not hand out unique ones. Declines get their id here.
+// latency covers all gateway attempts including retries, trace the steps
+// that led to the outcome.
+func (p *PaymentProcessor) recordTransaction(paymentReqest providers.PaymentRequest, response *providers.PaymentResponse, paymentError *providers.PaymentError, latency time.Duration, trace *paymentTrace) {
+	if p.config.Transactions == nil {
+		return
+	}
+
+	now := time.Now()
+	tx := store.Transaction{
+		Provider:              paymentReqest.Mode,
+		Amount:                paymentReqest.Amount,
+		Currency:              paymentReqest.Currency,
+		ExpiryMonth:           p.config.Redaction.Apply(redact.SinkRecords, redact.FieldExpiry, paymentReqest.ExpiryMonth),
+		ExpiryYear:            p.config.Redaction.Apply(redact.SinkRecords, redact.FieldExpiry, paymentReqest.ExpiryYear),
+		SubMerchantID:         paymentReqest.SubMerchantID,
+		Descriptor:            paymentReqest.Descriptor,
+		Method:                paymentReqest.Method,
+		InitiatedBy:           paymentReqest.InitiatedBy,
+		StoredCredentialUsage: paymentReqest.StoredCredentialUsage,
+		PriorTransactionID:    paymentReqest.PriorTransactionID,
+		IdempotencyKey:        paymentReqest.IdempotencyKey,
+		OrderID:               paymentReqest.OrderData["order_id"],
+		MerchantCountry:       paymentReqest.MerchantCountry,
+		IssuerCountry:         paymentReqest.IssuerCountry,
+		AuthorizationType:     paymentReqest.AuthorizationType,
+		LatencyMs:             latency.Milliseconds(),
+		Processing:            trace.processing(),
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+
+	if len(paymentReqest.Metadata) > 0 {
+		tx.Metadata = make(map[string]string, len(paymentReqest.Metadata))
+		for key, value := range paymentReqest.Metadata {
+			tx.Metadata[key] = value
+		}
+		p.config.Redaction.ApplyMap(redact.SinkRecords, tx.Metadata)
+	}
+	tx.Tags = normalizeTags(paymentReqest.Tags)
+	if account := paymentReqest.MerchantAccount; account != nil {
+		tx.MerchantAccount = account.Name
+		tx.MerchantID = account.MerchantID
+		tx.CrossBorder = account.CrossBorder && !account.Local(paymentReqest.Currency)
+	}
+
+	if len(paymentReqest.CardNumber) >= 10 {
+		tx.BIN = paymentReqest.CardNumber[:6]
+		tx.Last4 = paymentReqest.CardNumber[len(paymentReqest.CardNumber)-4:]
+	}
+	if p.config.Fingerprinter != nil {
+		if fp, err := p.config.Fingerprinter.Fingerprint(paymentReqest.CardNumber); err == nil {
+			tx.Fingerprint = fp.String()
+		}
+	}
+
+	if response != nil {
+		tx.ID = response.TransactionID
+		tx.GatewayReference = response.GatewayReference
+		tx.Status = response.Status
+		tx.Extra = response.Extra
+		tx.ResponseVersion = response.ResponseVersion
+		if response.PartialApproval {
+			tx.Amount = response.Amount
+			tx.RequestedAmount = response.RequestedAmount
+		}
+	} else {
+		tx.ID = newTransactionID()
+		tx.Status = providers.StatusDeclined
+		tx.ErrorCode = paymentError.ErrorCode
+		tx.Reason = paymentError.Reason
+	}
+
+	tx.Timeline = []store.StatusChange{
+		{Status: "SUBMITTED", Time: now},
+		{Status: tx.Status, Time: now, Detail: tx.ErrorCode},
+	}
+
+	p.config.Transactions.Save(tx)
+}
+
+// updateTransactionStatus appends a status change to a stored transaction
+func (p *PaymentProcessor) updateTransactionStatus(id, status, detail string) {
+	if p.config.Transactions == nil {
+		return
+	}
+
+	tx, err := p.config.Transactions.Get(id)
+	if err != nil {
+		return
+	}
+
+	p.config.Transactions.Save(withStatus(tx, status, detail))
+}
+
+// withStatus returns tx moved to status, the change on its timeline
+func withStatus(tx store.Transaction, status, detail string) store.Transaction {
+	now := time.Now()
+	tx.Status = status
+	tx.UpdatedAt = now
+	tx.Timeline = append(append([]store.StatusChange(nil), tx.Timeline...), store.StatusChange{Status: status, Time: now, Detail: detail})
+	return tx
+}
+
+// updateTransactionReference records the gateway reference of a stored
+// payment the gateway accepted later, after a challenge or from the forward
+// queue
+func (p *PaymentProcessor) updateTransactionReference(id, reference string) {
+	if p.config.Transactions == nil || reference == "" {
+		return
+	}
+
+	tx, err := p.config.Transactions.Get(id)
+	if err != nil {
+		return
+	}
+	tx.GatewayReference = reference
+	p.config.Transactions.Save(tx)
+}
+
+// gatewayReference is the provider's reference of a stored payment, the id
+// itself for records without one
+func gatewayReference(tx store.Transaction) string {
+	if tx.GatewayReference != "" {
+		return tx.GatewayReference
+	}
+	return tx.ID
+}
+
+// gatewayTransactionID is the id to send the gateway for a payment. Ids
+// the store does not know are passed on as given, they may be gateway
+// references already.
+func (p *PaymentProcessor) gatewayTransactionID(transactionID string) string {
+	if tx, ok := p.originalTransaction(transactionID); ok {
+		return gatewayReference(tx)
+	}
+	return transactionID
+}
+
+func newTransactionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "txn_" + hex.EncodeToString(b)
+}
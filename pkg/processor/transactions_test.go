@@ -0,0 +1,78 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/forward"
+	"pgas/pkg/providers"
+)
+
+func TestProcessPayment_RecordsTransactions(t *testing.T) {
+	queue, _ := forward.NewQueue("", nil)
+	processor := NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stub", unreachable(), nil)),
+		WithStoreAndForward(ForwardPolicy{Queue: queue, MaxAmount: 100, MaxAge: time.Hour}),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected payment to be deferred, got error: %v", err)
+	}
+
+	processor.ForwardPending(context.Background())
+
+	tx, getErr := processor.Transactions().Get(response.TransactionID)
+	if getErr != nil {
+		t.Fatalf("Expected deferred payment to be stored, got error: %v", getErr)
+	}
+
+	if tx.Status != providers.StatusApproved || tx.Last4 != "1111" || tx.BIN != "411111" {
+		t.Errorf("Expected forwarded transaction with card summary, got %+v", tx)
+	}
+
+	statuses := make([]string, 0, len(tx.Timeline))
+	for _, change := range tx.Timeline {
+		statuses = append(statuses, change.Status)
+	}
+	if len(statuses) != 3 || statuses[1] != providers.StatusDeferred || statuses[2] != providers.StatusApproved {
+		t.Errorf("Expected SUBMITTED, DEFERRED, APPROVED timeline, got %v", statuses)
+	}
+}
+
+func TestProcessPayment_LocalTransactionIDs(t *testing.T) {
+	// the stub answers every payment with the same gateway reference
+	provider := &prepaidProvider{stubProvider: newStubProvider("stub"), balance: 1000}
+	processor := NewPaymentProcessor(nil, WithProviders(provider))
+
+	ids := make(map[string]bool)
+	for i := 0; i < 5; i++ {
+		request := stubRequest("stub")
+		request.Amount = float64(10 * (i + 1))
+		response, err := processor.ProcessPayment(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Expected payment to succeed, got error: %v", err)
+		}
+		if response.GatewayReference != "stub-tx" || ids[response.TransactionID] {
+			t.Fatalf("Expected a new local id with the gateway reference, got %s (%s)", response.TransactionID, response.GatewayReference)
+		}
+		ids[response.TransactionID] = true
+
+		if tx, _ := processor.Transactions().Get(response.TransactionID); tx.Amount != request.Amount || tx.GatewayReference != "stub-tx" {
+			t.Errorf("Expected payment %d stored on its own, got %+v", i, tx)
+		}
+	}
+
+	var refunded string
+	for id := range ids {
+		refunded = id
+		break
+	}
+	if _, err := processor.RefundPayment(context.Background(), providers.RefundRequest{TransactionID: refunded, Amount: 5, Currency: "USD"}); err != nil {
+		t.Fatalf("Expected refund to succeed, got error: %v", err)
+	}
+	if len(provider.voids) != 1 || provider.voids[0].TransactionID != "stub-tx" {
+		t.Errorf("Expected the gateway to be asked by its own reference, got %+v", provider.voids)
+	}
+}
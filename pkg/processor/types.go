@@ -1 +1,235 @@
 package processor
+
+import (
+	"context"
+	"time"
+
+	"pgas/pkg/api"
+	"pgas/pkg/audit"
+	"pgas/pkg/bin"
+	"pgas/pkg/chaos"
+	"pgas/pkg/events"
+	"pgas/pkg/fees"
+	"pgas/pkg/fingerprint"
+	"pgas/pkg/forward"
+	"pgas/pkg/guidance"
+	"pgas/pkg/merchant"
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+	"pgas/pkg/reporting"
+	"pgas/pkg/store"
+	"pgas/pkg/threeds"
+	"pgas/pkg/webhooks"
+)
+
+// RetryPolicy controls how often a failed gateway call is attempted again
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first one
+	Backoff     time.Duration // wait between attempts, doubled after each retry
+	// Retryable decides whether a failed attempt may be retried; a nil
+	// function never retries since replaying a charge can double-bill
+	Retryable api.RetryableFunc
+}
+
+// RetryBudget caps gateway retries at a share of the payments' first
+// attempts over a sliding window. When a gateway degrades, retries then back
+// off by themselves instead of multiplying its load. A zero Window disables
+// the budget.
+type RetryBudget struct {
+	Ratio      float64 // retries allowed per first attempt, e.g. 0.1
+	MinRetries int     // retries allowed per window whatever the traffic
+	Window     time.Duration
+}
+
+// StatusQueryPolicy controls how UNKNOWN payment statuses are resolved
+// through the providers' GetPaymentStatus
+type StatusQueryPolicy struct {
+	MaxAttempts int           // status lookups made before leaving the payment unresolved
+	Interval    time.Duration // wait between lookups
+}
+
+// BudgetShares are the fractions of a request latency budget reserved for the
+// stages before the gateway call; the gateway gets whatever is left
+type BudgetShares struct {
+	Validation float64
+	Fraud      float64
+}
+
+// ForwardPolicy enables store-and-forward: when the provider is unreachable,
+// payments up to MaxAmount are queued and answered with DEFERRED
+type ForwardPolicy struct {
+	Queue     *forward.Queue // nil disables store-and-forward
+	MaxAmount float64
+	// MaxAge is how long an entry may wait; older entries are force-declined
+	MaxAge time.Duration
+	// Unreachable decides whether a failure means the provider could not be
+	// reached, defaults to processing errors
+	Unreachable func(paymentError *providers.PaymentError) bool
+}
+
+// ExpiryPolicy bounds how long payments may stay PENDING or REQUIRES_ACTION
+// before ExpirePayments marks them EXPIRED. Method entries win over provider
+// entries; without either, PENDING payments use Default and REQUIRES_ACTION
+// payments ActionExpiry.
+type ExpiryPolicy struct {
+	Default   time.Duration            // zero keeps PENDING payments
+	Providers map[string]time.Duration // by provider name
+	Methods   map[string]time.Duration // by payment method, e.g. upi_collect: 10m, bnpl: 1h
+	// Release frees whatever was reserved for an expired payment, such as an
+	// authorization hold or stock; nil releases nothing
+	Release func(ctx context.Context, tx store.Transaction) error
+}
+
+// processor wide configuration, built from DefaultConfig and Options
+type ProcessorConfig struct {
+	Providers      []providers.Provider
+	DefaultTimeout time.Duration // upper bound for a single gateway call
+	// ProviderTimeouts replace DefaultTimeout for the named providers
+	ProviderTimeouts map[string]time.Duration
+	Retry            RetryPolicy
+	Chaos            *chaos.Injector
+	ReadOnly         bool // start with new charges halted
+	AuditLog         audit.Log
+	// OverrideAuthorizer enables per-payment overrides, nil rejects them all
+	OverrideAuthorizer OverrideAuthorizer
+	// Enrichers add computed fields to responses before they are stored
+	// and returned, in order
+	Enrichers []Enricher
+	// Router picks the provider per payment, nil uses the request's Mode
+	Router Router
+	// Merchants resolves sub-merchants of record for platform charges
+	Merchants *merchant.Registry
+	// SupportContact is sent with charges of merchants and sub-merchants
+	// without their own
+	SupportContact *providers.SupportContact
+	// Events receives warnings such as unrecognized provider statuses
+	Events      events.Publisher
+	StatusQuery StatusQueryPolicy
+	Budget      BudgetShares
+	// QuoteTimeout bounds how long Quote waits for the slowest provider
+	QuoteTimeout time.Duration
+	// Fees estimates costs for providers that cannot quote themselves
+	Fees    *fees.Calculator
+	Forward ForwardPolicy
+	// RequireRefundReason rejects refunds without a normalized reason
+	RequireRefundReason bool
+	// Refunds records completed refunds for reporting
+	Refunds *reporting.RefundLedger
+	// Transactions stores a record of every payment that reached a provider
+	Transactions store.Transactions
+	// Captures stores every capture of a payment as its own record
+	Captures store.Captures
+	// Redaction decides how card fields appear in events and stored records
+	Redaction *redact.Policy
+	// Authenticator runs 3-D Secure before charging, nil skips authentication
+	Authenticator threeds.Authenticator
+	// ActionExpiry is how long a soft-declined payment waits for the
+	// cardholder to complete its 3DS challenge
+	ActionExpiry time.Duration
+	// Expiry sets when stuck PENDING and REQUIRES_ACTION payments expire
+	Expiry ExpiryPolicy
+	// Fingerprinter adds card fingerprints to stored transactions so they
+	// can be searched by card, nil stores no fingerprints
+	Fingerprinter *fingerprint.Fingerprinter
+	// Decoding sets how providers treat unknown fields in gateway
+	// responses. Drift is published as an event unless OnDrift is set.
+	Decoding providers.Decoding
+	// Screening checks payments against AML and sanctions rules before they
+	// are charged
+	Screening ScreeningPolicy
+	// RawResponses keeps each approved payment's unparsed provider response,
+	// nil captures nothing
+	RawResponses *store.CompressedBlobs
+	// Timings adds a per-stage processing time breakdown to responses
+	Timings bool
+	// Descriptor is the statement descriptor, or descriptor template, of
+	// payments that get none from the request or their sub-merchant
+	Descriptor string
+	// Idempotency answers repeated idempotency keys with the first outcome
+	Idempotency IdempotencyPolicy
+	// BINs detects the card network of payments without a Mode, nil uses
+	// bin.Default
+	BINs *bin.Table
+	// NetworkProviders names the provider charging each detected network;
+	// networks not listed go to the provider of the same name
+	NetworkProviders map[string]string
+	// Authorizations limits the captures of each authorization type,
+	// payments of types not listed are rejected
+	Authorizations map[string]AuthorizationStrategy
+	// RetryBudget caps the retries of all payments together, on top of the
+	// per payment Retry policy
+	RetryBudget RetryBudget
+	// Failover sends payments their provider failed to process on to
+	// backup providers
+	Failover FailoverPolicy
+	// DeclineGuidance maps decline reasons to customer facing messages,
+	// see guidance.Parse for merchant wording
+	DeclineGuidance *guidance.Catalog
+	// Webhooks applies asynchronous gateway notifications to payments, see
+	// HandleWebhook
+	Webhooks WebhookPolicy
+	// PartialApproval is what to do when issuers approve less than the
+	// requested amount of payments whose request and merchant leave it
+	// open, providers.PartialApprovalAccept or PartialApprovalVoid
+	PartialApproval string
+	// Credits gates standalone credits to cards, see Credit
+	Credits CreditPolicy
+	// Fraud scores payments with an external fraud service before they
+	// reach the gateway
+	Fraud FraudPolicy
+	// Logger receives structured logs of provider selection, validation
+	// failures, gateway calls and payment outcomes, nil logs nothing
+	Logger Logger
+	// PINTranslator re-encrypts PIN blocks under the key of the provider
+	// the payment is sent to, nil only accepts blocks already encrypted
+	// under it
+	PINTranslator providers.PINTranslator
+	// MerchantAccounts are the MIDs and TIDs of each provider, in the order
+	// they are tried; providers without any submit under their
+	// connection's default account
+	MerchantAccounts map[string][]providers.MerchantAccount
+	// Metrics receives payment outcomes and gateway call latencies for
+	// export, e.g. a metrics.Collector; nil measures nothing
+	Metrics Metrics
+}
+
+func DefaultConfig() ProcessorConfig {
+	return ProcessorConfig{
+		DefaultTimeout: 30 * time.Second,
+		Retry: RetryPolicy{
+			MaxAttempts: 1,
+			Backoff:     100 * time.Millisecond,
+		},
+		Budget: BudgetShares{
+			Validation: 0.05,
+			Fraud:      0.15,
+		},
+		QuoteTimeout: 2 * time.Second,
+		ActionExpiry: 15 * time.Minute,
+		Refunds:      reporting.NewRefundLedger(),
+		Redaction:    redact.DefaultPolicy(),
+		Transactions: store.NewMemoryTransactions(store.MemoryOptions{MaxEntries: 100000}),
+		Captures:     store.NewMemoryCaptures(store.MemoryOptions{MaxEntries: 100000}),
+		Idempotency: IdempotencyPolicy{
+			Store: store.NewMemoryIdempotency(store.MemoryOptions{MaxEntries: 100000}),
+			TTL:   24 * time.Hour,
+		},
+		StatusQuery: StatusQueryPolicy{
+			MaxAttempts: 3,
+			Interval:    200 * time.Millisecond,
+		},
+		Authorizations:  defaultAuthorizations(),
+		DeclineGuidance: guidance.Default(),
+		Webhooks: WebhookPolicy{
+			Dedupe:        webhooks.NewMemoryDedupe(store.MemoryOptions{TTL: 24 * time.Hour, MaxEntries: 100000}),
+			MinConfidence: 0.5,
+			Window:        10 * time.Minute,
+		},
+		RetryBudget: RetryBudget{
+			Ratio:      0.1,
+			MinRetries: 10,
+			Window:     10 * time.Second,
+		},
+		PartialApproval: providers.PartialApprovalAccept,
+	}
+}
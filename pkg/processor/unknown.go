@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+)
+
+// UnresolvedPayment is a payment whose provider status was not recognized
+// and could not be settled through status queries yet
+type UnresolvedPayment struct {
+	Provider         string    `json:"provider"`
+	TransactionID    string    `json:"transaction_id"`
+	GatewayReference string    `json:"gateway_reference"`
+	RawStatus        string    `json:"raw_status"`
+	Since            time.Time `json:"since"`
+}
+
+// resolveUnknown warns about an UNKNOWN status and queries the provider for
+// the real outcome. The payment is tracked as unresolved when it stays unknown.
+func (p *PaymentProcessor) resolveUnknown(ctx context.Context, paymentProvider providers.Provider, response *providers.PaymentResponse) *providers.PaymentResponse {
+	p.publish(ctx, events.Event{
+		Type:          events.TypePaymentStatusUnknown,
+		Time:          time.Now(),
+		Provider:      paymentProvider.GetName(),
+		TransactionID: response.TransactionID,
+		Data:          events.Encode(events.PaymentStatusUnknownData{RawStatus: response.RawStatus}),
+	})
+
+	if resolved := p.queryStatus(ctx, paymentProvider, response.GatewayReference); resolved != nil {
+		resolved.TransactionID, resolved.GatewayReference = response.TransactionID, response.GatewayReference
+		return resolved
+	}
+
+	p.unresolvedMu.Lock()
+	p.unresolved[response.TransactionID] = UnresolvedPayment{
+		Provider:         paymentProvider.GetName(),
+		TransactionID:    response.TransactionID,
+		GatewayReference: response.GatewayReference,
+		RawStatus:        response.RawStatus,
+		Since:            time.Now(),
+	}
+	p.unresolvedMu.Unlock()
+
+	return response
+}
+
+// queryStatus asks the provider for the current status of the payment with
+// the gateway reference, returning nil when it cannot be resolved within
+// the configured attempts
+func (p *PaymentProcessor) queryStatus(ctx context.Context, paymentProvider providers.Provider, transactionID string) *providers.PaymentResponse {
+	if transactionID == "" {
+		return nil
+	}
+
+	for attempt := 1; attempt <= p.config.StatusQuery.MaxAttempts; attempt++ {
+		if attempt > 1 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(p.config.StatusQuery.Interval):
+			}
+		}
+
+		response, err := paymentProvider.GetPaymentStatus(ctx, transactionID)
+		if errors.Is(err, providers.ErrStatusQueryNotSupported) {
+			return nil
+		}
+		if err == nil && response != nil && response.Status != providers.StatusUnknown {
+			return response
+		}
+	}
+
+	return nil
+}
+
+// UnresolvedPayments lists payments still in UNKNOWN state, oldest first
+func (p *PaymentProcessor) UnresolvedPayments() []UnresolvedPayment {
+	p.unresolvedMu.Lock()
+	defer p.unresolvedMu.Unlock()
+
+	list := make([]UnresolvedPayment, 0, len(p.unresolved))
+	for _, payment := range p.unresolved {
+		list = append(list, payment)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Since.Before(list[j].Since) })
+	return list
+}
+
+// ResolveUnknownPayments retries status queries for every unresolved payment
+// and returns the ones that now have a known status
+func (p *PaymentProcessor) ResolveUnknownPayments(ctx context.Context) []*providers.PaymentResponse {
+	var resolved []*providers.PaymentResponse
+
+	for _, payment := range p.UnresolvedPayments() {
+		paymentProvider, err := p.getProvider(payment.Provider)
+		if err != nil {
+			continue
+		}
+
+		response := p.queryStatus(ctx, paymentProvider, payment.GatewayReference)
+		if response == nil {
+			continue
+		}
+		response.TransactionID, response.GatewayReference = payment.TransactionID, payment.GatewayReference
+
+		p.unresolvedMu.Lock()
+		delete(p.unresolved, payment.TransactionID)
+		p.unresolvedMu.Unlock()
+
+		p.updateTransactionStatus(payment.TransactionID, response.Status, "resolved by status query")
+
+		resolved = append(resolved, response)
+	}
+
+	return resolved
+}
+
+func (p *PaymentProcessor) publish(ctx context.Context, event events.Event) {
+	if p.config.Events == nil {
+		return
+	}
+	event.Version = events.SchemaVersion
+	p.config.Redaction.ApplyMap(redact.SinkEvents, event.Data)
+
+	// events are best effort, a broken publisher must not fail the payment
+	_ = p.config.Events.Publish(ctx, event)
+}
@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+)
+
+// unknownStatusProvider reports an unrecognized status and answers status
+// queries with the outcomes in order
+type unknownStatusProvider struct {
+	*stubProvider
+	queries []string
+	queried int
+}
+
+func (u *unknownStatusProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	parsed, err := u.stubProvider.ParseSuccessResponse(response)
+	if err != nil {
+		return nil, err
+	}
+	parsed.Success = false
+	parsed.Status = providers.StatusUnknown
+	parsed.RawStatus = "UNDER_REVIEW"
+	return parsed, nil
+}
+
+func (u *unknownStatusProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	status := providers.StatusUnknown
+	if u.queried < len(u.queries) {
+		status = u.queries[u.queried]
+	}
+	u.queried++
+
+	return &providers.PaymentResponse{
+		Success:       providers.IsSuccessStatus(status),
+		TransactionID: transactionID,
+		Status:        status,
+	}, nil
+}
+
+func TestProcessPayment_UnknownStatusResolvedByQuery(t *testing.T) {
+	provider := &unknownStatusProvider{
+		stubProvider: newStubProvider("stub"),
+		queries:      []string{providers.StatusUnknown, providers.StatusApproved},
+	}
+	publisher := events.NewMemoryPublisher()
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithEventPublisher(publisher),
+		WithStatusQueryPolicy(StatusQueryPolicy{MaxAttempts: 3, Interval: time.Millisecond}),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if response.Status != providers.StatusApproved || !response.Success {
+		t.Errorf("Expected status query to resolve to APPROVED, got %s", response.Status)
+	}
+
+	if provider.queried != 2 {
+		t.Errorf("Expected 2 status queries, got %d", provider.queried)
+	}
+
+	published := publisher.Events()
+	if len(published) != 1 || published[0].Type != events.TypePaymentStatusUnknown {
+		t.Fatalf("Expected one unknown status event, got %+v", published)
+	}
+	if published[0].Version != events.SchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", events.SchemaVersion, published[0].Version)
+	}
+
+	if published[0].Data["raw_status"] != "UNDER_REVIEW" {
+		t.Errorf("Expected raw status in event, got %+v", published[0].Data)
+	}
+
+	if len(processor.UnresolvedPayments()) != 0 {
+		t.Error("Expected no unresolved payments")
+	}
+}
+
+func TestProcessPayment_UnknownStatusStaysUnresolved(t *testing.T) {
+	provider := &unknownStatusProvider{stubProvider: newStubProvider("stub")}
+	processor := NewPaymentProcessor(nil,
+		WithProviders(provider),
+		WithStatusQueryPolicy(StatusQueryPolicy{MaxAttempts: 2, Interval: time.Millisecond}),
+	)
+
+	response, err := processor.ProcessPayment(context.Background(), stubRequest("stub"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if response.Status != providers.StatusUnknown || response.Success {
+		t.Errorf("Expected unsuccessful UNKNOWN response, got %+v", response)
+	}
+
+	if response.RawStatus != "UNDER_REVIEW" {
+		t.Errorf("Expected raw status to be kept, got %s", response.RawStatus)
+	}
+
+	unresolved := processor.UnresolvedPayments()
+	if len(unresolved) != 1 || unresolved[0].GatewayReference != "stub-tx" {
+		t.Fatalf("Expected payment to be tracked as unresolved, got %+v", unresolved)
+	}
+
+	provider.queries = []string{providers.StatusDeclined}
+	provider.queried = 0
+
+	resolved := processor.ResolveUnknownPayments(context.Background())
+	if len(resolved) != 1 || resolved[0].Status != providers.StatusDeclined {
+		t.Fatalf("Expected payment to resolve to DECLINED, got %+v", resolved)
+	}
+
+	if len(processor.UnresolvedPayments()) != 0 {
+		t.Error("Expected resolved payment to be removed")
+	}
+}
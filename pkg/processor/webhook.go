@@ -0,0 +1,173 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+	"pgas/pkg/webhooks"
+)
+
+// WebhookPolicy sets how asynchronous gateway notifications are applied to
+// stored payments
+type WebhookPolicy struct {
+	// Parsers verify and normalize the webhooks of each provider, by name
+	Parsers map[string]webhooks.WebhookParser
+	// Dedupe drops notifications the gateway resent, nil applies every one
+	Dedupe webhooks.Dedupe
+	// MinConfidence is the lowest correlation confidence applied; weaker
+	// matches are rejected so the gateway resends them
+	MinConfidence float64
+	// Window is how far from the notification time payments are matched by
+	// card, amount and time
+	Window time.Duration
+}
+
+// WebhookResult is what a notification did to its payment
+type WebhookResult struct {
+	Event webhooks.PaymentEvent `json:"event"`
+	// Duplicate marks resends of an applied notification
+	Duplicate bool `json:"duplicate,omitempty"`
+	// Ignored marks notifications of event types pgas does not act on
+	Ignored       bool    `json:"ignored,omitempty"`
+	TransactionID string  `json:"transaction_id,omitempty"`
+	Status        string  `json:"status,omitempty"` // status of the payment afterwards
+	Confidence    float64 `json:"confidence,omitempty"`
+}
+
+var (
+	ErrNoWebhookParser = errors.New("no webhook parser registered for provider")
+	ErrLowConfidence   = errors.New("webhook correlation confidence is too low")
+)
+
+// timeline entries of notifications that leave the payment's status as is
+var webhookTimeline = map[string]string{
+	webhooks.EventCaptured:   "CAPTURED",
+	webhooks.EventRefunded:   "REFUNDED",
+	webhooks.EventChargeback: "DISPUTED",
+}
+
+// HandleWebhook verifies a provider's webhook request and applies it to the
+// stored payment it concerns. Approvals and declines set the status of
+// pending payments; captures, refunds and chargebacks are added to the
+// payment's timeline. Every applied notification is published as a
+// payment.gateway_notification event.
+//
+// Duplicates and unsupported event types return no error, so the gateway
+// stops resending them. Any error means the notification was not applied
+// and a resend is processed again; signature failures wrap
+// webhooks.ErrInvalidSignature.
+func (p *PaymentProcessor) HandleWebhook(ctx context.Context, provider string, header http.Header, body []byte) (WebhookResult, error) {
+	policy := p.config.Webhooks
+	parser, ok := policy.Parsers[provider]
+	if !ok {
+		return WebhookResult{}, fmt.Errorf("%w: %s", ErrNoWebhookParser, provider)
+	}
+	if err := parser.Verify(header, body); err != nil {
+		return WebhookResult{}, err
+	}
+
+	event, err := parser.Parse(body)
+	if errors.Is(err, webhooks.ErrUnsupportedEvent) {
+		return WebhookResult{Ignored: true}, nil
+	}
+	if err != nil {
+		return WebhookResult{}, err
+	}
+	return p.ApplyWebhookEvent(ctx, event)
+}
+
+// ApplyWebhookEvent applies a notification that was already verified and
+// parsed, such as one taken off a queue or a replayed one, the same way
+// HandleWebhook does
+func (p *PaymentProcessor) ApplyWebhookEvent(ctx context.Context, event webhooks.PaymentEvent) (WebhookResult, error) {
+	policy := p.config.Webhooks
+	result := WebhookResult{Event: event}
+
+	// notification ids are only unique per gateway
+	key := event.Provider + ":" + event.ID
+	if policy.Dedupe != nil && policy.Dedupe.Seen(key) {
+		result.Duplicate = true
+		return result, nil
+	}
+
+	tx, correlation, err := p.applyWebhook(ctx, event)
+	if err != nil {
+		if policy.Dedupe != nil {
+			policy.Dedupe.Forget(key)
+		}
+		return result, err
+	}
+
+	result.TransactionID = tx.ID
+	result.Status = tx.Status
+	result.Confidence = correlation.Confidence
+	return result, nil
+}
+
+// applyWebhook finds the payment of a notification and records it
+func (p *PaymentProcessor) applyWebhook(ctx context.Context, event webhooks.PaymentEvent) (store.Transaction, webhooks.Correlation, error) {
+	if p.config.Transactions == nil {
+		return store.Transaction{}, webhooks.Correlation{}, errors.New("no transaction store configured")
+	}
+
+	correlation, err := webhooks.NewCorrelator(p.config.Transactions, p.config.Webhooks.Window).Correlate(event.Reference)
+	if err != nil {
+		return store.Transaction{}, correlation, err
+	}
+	if correlation.Confidence < p.config.Webhooks.MinConfidence {
+		return store.Transaction{}, correlation, fmt.Errorf("%w: %.2f via %s", ErrLowConfidence, correlation.Confidence, correlation.Key)
+	}
+
+	now := time.Now()
+	tx := correlation.Transaction
+	change := store.StatusChange{Time: now, Detail: "webhook " + event.ID}
+	if event.Reason != "" {
+		change.Detail += " " + event.Reason
+	}
+
+	switch event.Type {
+	case webhooks.EventApproved:
+		tx.Status = providers.StatusApproved
+		change.Status = tx.Status
+	case webhooks.EventDeclined:
+		tx.Status = providers.StatusDeclined
+		tx.Reason = event.Reason
+		change.Status = tx.Status
+	default:
+		status, ok := webhookTimeline[event.Type]
+		if !ok {
+			return store.Transaction{}, correlation, fmt.Errorf("%w: %s", webhooks.ErrUnsupportedEvent, event.Type)
+		}
+		change.Status = status
+	}
+
+	tx.UpdatedAt = now
+	tx.Timeline = append(append([]store.StatusChange(nil), tx.Timeline...), change)
+	if err := p.config.Transactions.Save(tx); err != nil {
+		return store.Transaction{}, correlation, err
+	}
+
+	p.publish(ctx, events.Event{
+		Type:          events.TypeGatewayNotification,
+		Time:          now,
+		Provider:      tx.Provider,
+		TransactionID: tx.ID,
+		Data: events.Encode(events.GatewayNotificationData{
+			EventID:    event.ID,
+			Event:      event.Type,
+			Status:     tx.Status,
+			Amount:     event.Amount,
+			Currency:   event.Currency,
+			Reason:     event.Reason,
+			Key:        correlation.Key,
+			Confidence: correlation.Confidence,
+		}),
+	})
+	return tx, correlation, nil
+}
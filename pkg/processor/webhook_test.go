@@ -0,0 +1,152 @@
+package processor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"pgas/pkg/events"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+	"pgas/pkg/webhooks"
+)
+
+// jsonWebhooks parses PaymentEvents sent as JSON, signed with a shared token
+type jsonWebhooks struct {
+	name string
+}
+
+func (j jsonWebhooks) Provider() string { return j.name }
+
+func (j jsonWebhooks) Verify(header http.Header, body []byte) error {
+	if header.Get("X-Token") != "secret" {
+		return webhooks.ErrInvalidSignature
+	}
+	return nil
+}
+
+func (j jsonWebhooks) Parse(body []byte) (webhooks.PaymentEvent, error) {
+	var event webhooks.PaymentEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return event, err
+	}
+	if event.Type == "payout" {
+		return event, webhooks.ErrUnsupportedEvent
+	}
+	event.Reference.Provider = j.name
+	return event, nil
+}
+
+func webhookBody(t *testing.T, event webhooks.PaymentEvent) []byte {
+	t.Helper()
+	body, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return body
+}
+
+var signed = http.Header{"X-Token": {"secret"}}
+
+func newWebhookProcessor(publisher events.Publisher) (*PaymentProcessor, store.Transactions) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	transactions.Save(store.Transaction{ID: "tx-1", Provider: "stripe", GatewayReference: "gw-1", Status: providers.StatusPending, OrderID: "order-1", CreatedAt: time.Now()})
+	return NewPaymentProcessor(nil,
+		WithProviders(newStubProvider("stripe")),
+		WithTransactionStore(transactions),
+		WithEventPublisher(publisher),
+		WithWebhookParser(jsonWebhooks{name: "stripe"}),
+	), transactions
+}
+
+func TestHandleWebhook_AppliesEvents(t *testing.T) {
+	publisher := events.NewMemoryPublisher()
+	processor, transactions := newWebhookProcessor(publisher)
+	ctx := context.Background()
+
+	result, err := processor.HandleWebhook(ctx, "stripe", signed, webhookBody(t, webhooks.PaymentEvent{
+		ID: "evt-1", Type: webhooks.EventApproved, Reference: webhooks.Reference{GatewayRef: "gw-1"},
+	}))
+	if err != nil || result.TransactionID != "tx-1" || result.Status != providers.StatusApproved || result.Confidence != 1 {
+		t.Fatalf("Expected the pending payment approved, got %+v (%v)", result, err)
+	}
+
+	result, err = processor.HandleWebhook(ctx, "stripe", signed, webhookBody(t, webhooks.PaymentEvent{
+		ID: "evt-2", Type: webhooks.EventChargeback, Reason: "10.4", Amount: 100, Currency: "USD",
+		Reference: webhooks.Reference{OrderID: "order-1"},
+	}))
+	if err != nil || result.Status != providers.StatusApproved {
+		t.Fatalf("Expected the chargeback to leave the status as is, got %+v (%v)", result, err)
+	}
+
+	tx, _ := transactions.Get("tx-1")
+	if len(tx.Timeline) != 2 || tx.Timeline[0].Status != providers.StatusApproved || tx.Timeline[1].Status != "DISPUTED" || tx.Timeline[1].Detail != "webhook evt-2 10.4" {
+		t.Errorf("Expected the approval and dispute on the timeline, got %+v", tx.Timeline)
+	}
+
+	published := publisher.Events()
+	if len(published) != 2 || published[1].Type != events.TypeGatewayNotification {
+		t.Fatalf("Expected a notification event per webhook, got %+v", published)
+	}
+	var data events.GatewayNotificationData
+	if err := published[1].Decode(&data); err != nil || data.Event != webhooks.EventChargeback || data.Key != webhooks.KeyOrderID || data.Amount != 100 {
+		t.Errorf("Expected the chargeback data, got %+v (%v)", data, err)
+	}
+}
+
+func TestHandleWebhook_Duplicates(t *testing.T) {
+	publisher := events.NewMemoryPublisher()
+	processor, _ := newWebhookProcessor(publisher)
+	body := webhookBody(t, webhooks.PaymentEvent{ID: "evt-1", Type: webhooks.EventCaptured, Reference: webhooks.Reference{GatewayRef: "gw-1"}})
+
+	for i := 0; i < 3; i++ {
+		result, err := processor.HandleWebhook(context.Background(), "stripe", signed, body)
+		if err != nil || result.Duplicate != (i > 0) {
+			t.Fatalf("Delivery %d: expected only resends to be duplicates, got %+v (%v)", i, result, err)
+		}
+	}
+	if len(publisher.Events()) != 1 {
+		t.Errorf("Expected the capture applied once, got %d events", len(publisher.Events()))
+	}
+}
+
+func TestHandleWebhook_Rejected(t *testing.T) {
+	processor, transactions := newWebhookProcessor(events.NewMemoryPublisher())
+	ctx := context.Background()
+	approve := webhooks.PaymentEvent{ID: "evt-1", Type: webhooks.EventApproved, Reference: webhooks.Reference{GatewayRef: "gw-2"}}
+
+	if _, err := processor.HandleWebhook(ctx, "adyen", signed, webhookBody(t, approve)); !errors.Is(err, ErrNoWebhookParser) {
+		t.Errorf("Expected providers without a parser to be rejected, got %v", err)
+	}
+	if _, err := processor.HandleWebhook(ctx, "stripe", http.Header{}, webhookBody(t, approve)); !errors.Is(err, webhooks.ErrInvalidSignature) {
+		t.Errorf("Expected unsigned webhooks to be rejected, got %v", err)
+	}
+	if result, err := processor.HandleWebhook(ctx, "stripe", signed, []byte(`{"id": "evt-0", "type": "payout"}`)); err != nil || !result.Ignored {
+		t.Errorf("Expected unsupported events to be ignored, got %+v (%v)", result, err)
+	}
+
+	// the webhook raced ahead of the payment, its resend applies once the
+	// payment is stored
+	if _, err := processor.HandleWebhook(ctx, "stripe", signed, webhookBody(t, approve)); !errors.Is(err, webhooks.ErrNoCorrelation) {
+		t.Fatalf("Expected no correlation, got %v", err)
+	}
+	transactions.Save(store.Transaction{ID: "tx-2", Provider: "stripe", GatewayReference: "gw-2", Status: providers.StatusPending})
+	if result, err := processor.HandleWebhook(ctx, "stripe", signed, webhookBody(t, approve)); err != nil || result.Duplicate || result.Status != providers.StatusApproved {
+		t.Errorf("Expected the resend to apply, got %+v (%v)", result, err)
+	}
+}
+
+func TestHandleWebhook_LowConfidence(t *testing.T) {
+	processor, transactions := newWebhookProcessor(events.NewMemoryPublisher())
+	transactions.Save(store.Transaction{ID: "tx-2", Provider: "stripe", Status: providers.StatusPending, OrderID: "order-1"})
+
+	_, err := processor.HandleWebhook(context.Background(), "stripe", signed, webhookBody(t, webhooks.PaymentEvent{
+		ID: "evt-1", Type: webhooks.EventRefunded, Reference: webhooks.Reference{OrderID: "order-1"},
+	}))
+	if !errors.Is(err, ErrLowConfidence) {
+		t.Errorf("Expected an order shared by two payments to be too weak a match, got %v", err)
+	}
+}
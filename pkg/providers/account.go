@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"errors"
+	"slices"
+)
+
+// MerchantAccount is one merchant configuration at the acquirer behind a
+// provider connection: the merchant id (MID) payments settle to and the
+// terminal id (TID) they are submitted from. A provider may hold several,
+// e.g. one per settlement currency, region or business line; the processor
+// selects one per payment and passes it on the request.
+//
+// Accounts with a SettlementCurrency settle locally and only take payments
+// in that currency. A CrossBorder account takes the payments no other
+// account takes, whatever its settlement currency, and is always tried
+// last.
+type MerchantAccount struct {
+	// Name identifies the account in configuration and reports
+	Name       string `json:"name"`
+	MerchantID string `json:"merchant_id"`
+	TerminalID string `json:"terminal_id,omitempty"`
+	// Currencies, Countries and BusinessLines select the payments the
+	// account takes, by currency, merchant country and business line of the
+	// request; empty lists match any
+	Currencies    []string `json:"currencies,omitempty"`
+	Countries     []string `json:"countries,omitempty"`
+	BusinessLines []string `json:"business_lines,omitempty"`
+	// SettlementCurrency is the ISO 4217 currency the acquirer settles the
+	// account's funds in
+	SettlementCurrency string `json:"settlement_currency,omitempty"`
+	CrossBorder        bool   `json:"cross_border,omitempty"`
+}
+
+// Matches reports whether the account takes the payment
+func (a MerchantAccount) Matches(request PaymentRequest) bool {
+	if !a.CrossBorder && a.SettlementCurrency != "" && a.SettlementCurrency != request.Currency {
+		return false
+	}
+	return matchesAny(a.Currencies, request.Currency) &&
+		matchesAny(a.Countries, request.MerchantCountry) &&
+		matchesAny(a.BusinessLines, request.BusinessLine)
+}
+
+func matchesAny(values []string, value string) bool {
+	return len(values) == 0 || slices.Contains(values, value)
+}
+
+// Local reports whether the account settles payments in their own currency
+func (a MerchantAccount) Local(currency string) bool {
+	return a.SettlementCurrency == currency
+}
+
+// SelectMerchantAccount returns the first account taking the payment that
+// is not cross-border, else the first cross-border one. Payments in a
+// currency with a local account thereby settle locally, the others fall
+// back to the cross-border account.
+func SelectMerchantAccount(accounts []MerchantAccount, request PaymentRequest) (MerchantAccount, bool) {
+	for _, crossBorder := range []bool{false, true} {
+		for _, account := range accounts {
+			if account.CrossBorder == crossBorder && account.Matches(request) {
+				return account, true
+			}
+		}
+	}
+	return MerchantAccount{}, false
+}
+
+// Validate checks the account can be submitted to a gateway
+func (a MerchantAccount) Validate() error {
+	if a.Name == "" {
+		return errors.New("merchant account name is required")
+	}
+	if a.MerchantID == "" {
+		return errors.New("merchant account '" + a.Name + "' has no merchant id")
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package ach
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/fixtures"
+	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+)
+
+func TestGetNewACHPaymentProvider(t *testing.T) {
+	provider := GetNewACHPaymentProvider()
+	if provider.GetName() != "ach" {
+		t.Errorf("Expected provider name 'ach', got: %s", provider.GetName())
+	}
+	if provider.SettlementDelay != DefaultSettlementDelay {
+		t.Errorf("Expected the default settlement delay, got %s", provider.SettlementDelay)
+	}
+}
+
+func TestValidateRoutingNumber(t *testing.T) {
+	testCases := []struct {
+		routingNumber string
+		valid         bool
+	}{
+		{"011000015", true},  // Federal Reserve Bank of Boston
+		{"021000021", true},  // JPMorgan Chase
+		{"322271627", true},  // thrift institution prefix
+		{"011000016", false}, // checksum off by one
+		{"021000012", false}, // transposed digits
+		{"131000013", false}, // 13 is not a Federal Reserve prefix
+		{"01100001", false},
+		{"0110000150", false},
+		{"01100001a", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateRoutingNumber(tc.routingNumber)
+		if tc.valid && err != nil {
+			t.Errorf("%q: expected valid, got: %v", tc.routingNumber, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("%q: expected invalid, got no error", tc.routingNumber)
+		}
+	}
+}
+
+func TestACHProvider_ValidateRequest(t *testing.T) {
+	provider := GetNewACHPaymentProvider()
+
+	valid := providers.PaymentRequest{
+		Mode:          "ach",
+		Method:        "ach",
+		Amount:        1250.50,
+		Currency:      "USD",
+		RoutingNumber: "011000015",
+		AccountNumber: "000123456789",
+		AccountType:   "checking",
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(*providers.PaymentRequest)
+		valid  bool
+	}{
+		{"valid request", func(r *providers.PaymentRequest) {}, true},
+		{"method defaults to ach", func(r *providers.PaymentRequest) { r.Method = "" }, true},
+		{"savings account", func(r *providers.PaymentRequest) { r.AccountType = "savings" }, true},
+		{"account type defaults to checking", func(r *providers.PaymentRequest) { r.AccountType = "" }, true},
+		{"shortest account number", func(r *providers.PaymentRequest) { r.AccountNumber = "1234" }, true},
+		{"missing routing number", func(r *providers.PaymentRequest) { r.RoutingNumber = "" }, false},
+		{"routing checksum", func(r *providers.PaymentRequest) { r.RoutingNumber = "011000016" }, false},
+		{"missing account number", func(r *providers.PaymentRequest) { r.AccountNumber = "" }, false},
+		{"account number too long", func(r *providers.PaymentRequest) { r.AccountNumber = "123456789012345678" }, false},
+		{"account number with dashes", func(r *providers.PaymentRequest) { r.AccountNumber = "1234-5678" }, false},
+		{"unknown account type", func(r *providers.PaymentRequest) { r.AccountType = "brokerage" }, false},
+		{"card data", func(r *providers.PaymentRequest) { r.CardNumber = "4111111111111111" }, false},
+		{"other currency", func(r *providers.PaymentRequest) { r.Currency = "EUR" }, false},
+		{"above the ACH limit", func(r *providers.PaymentRequest) { r.Amount = 1000000.01 }, false},
+		{"zero amount", func(r *providers.PaymentRequest) { r.Amount = 0 }, false},
+		{"other method", func(r *providers.PaymentRequest) { r.Method = "upi_collect" }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := valid
+			tc.modify(&request)
+
+			err := provider.ValidateRequest(request)
+			if tc.valid && err != nil {
+				t.Errorf("Expected valid request, got error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected invalid request, got no error")
+			}
+		})
+	}
+}
+
+func TestACHProvider_ParseSuccessResponse(t *testing.T) {
+	provider := GetNewACHPaymentProvider()
+
+	achResponse := map[string]interface{}{
+		"trace_number": "011000010012345",
+		"status":       "RETURNED",
+		"amount_cents": 125050,
+		"currency":     "USD",
+		"submitted_at": "2024-01-15T10:30:00Z",
+	}
+
+	if _, err := provider.ParseSuccessResponse(achResponse); err == nil || !strings.Contains(err.Error(), "return_code") {
+		t.Errorf("Expected an error for a return without code, got: %v", err)
+	}
+
+	achResponse["return_code"] = "R02"
+	response, err := provider.ParseSuccessResponse(achResponse)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	if response.Status != providers.StatusDeclined || response.Success || response.ReturnCode != "R02" {
+		t.Errorf("Expected a declined return, got %+v", response)
+	}
+	if response.Amount != 1250.50 {
+		t.Errorf("Expected amount 1250.50, got %f", response.Amount)
+	}
+}
+
+func TestACHProvider_GoldenFixtures(t *testing.T) {
+	fixtures.Run(t, GetNewACHPaymentProvider(), "testdata/fixtures")
+}
+
+func TestACHProvider_SandboxScenarios(t *testing.T) {
+	provider := GetNewACHPaymentProvider()
+	provider.SettlementDelay = 50 * time.Millisecond
+
+	submitted := make(map[string]string) // account number -> trace number
+	for _, scenario := range sandbox.ForProvider("ach") {
+		request := providers.PaymentRequest{Mode: "ach", Method: scenario.Method, Amount: 10, Currency: "USD", RoutingNumber: "021000021", AccountNumber: scenario.AccountNumber}
+		if err := provider.ValidateRequest(request); err != nil {
+			t.Errorf("Account %s: expected a valid request, got: %v", scenario.AccountNumber, err)
+		}
+
+		success, _ := provider.ProcessPayment(context.Background(), request)
+		response, err := provider.ParseSuccessResponse(success)
+		if err != nil || response.Status != providers.StatusPending || !response.Success {
+			t.Fatalf("Account %s: expected the debit to be submitted, got %+v (%v)", scenario.AccountNumber, response, err)
+		}
+		if scenario.Status != providers.StatusPending && response.SettlesAt == nil {
+			t.Errorf("Account %s: expected a settlement time", scenario.AccountNumber)
+		}
+		submitted[scenario.AccountNumber] = response.TransactionID
+
+		if response, _ := provider.GetPaymentStatus(context.Background(), response.TransactionID); response.Status != providers.StatusPending {
+			t.Errorf("Account %s: expected PENDING before the settlement delay, got %s", scenario.AccountNumber, response.Status)
+		}
+	}
+
+	time.Sleep(provider.SettlementDelay)
+	for _, scenario := range sandbox.ForProvider("ach") {
+		response, err := provider.GetPaymentStatus(context.Background(), submitted[scenario.AccountNumber])
+		if err != nil || response.Status != scenario.Status || response.ReturnCode != scenario.ErrorCode {
+			t.Errorf("Account %s: expected %s %s, got %+v (%v)", scenario.AccountNumber, scenario.Status, scenario.ErrorCode, response, err)
+		}
+	}
+
+	if _, err := provider.GetPaymentStatus(context.Background(), "unknown"); err == nil {
+		t.Error("Expected an error for an unknown trace number")
+	}
+}
@@ -0,0 +1,276 @@
+package ach
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+	"pgas/pkg/validation"
+)
+
+// same day ACH caps a single entry at one million dollars
+const maxAmount = 1000000
+
+// DefaultSettlementDelay is how long debits stay SUBMITTED, the next
+// banking day of standard ACH
+const DefaultSettlementDelay = 24 * time.Hour
+
+// entry is a debit the simulator accepted, kept so status queries can move
+// it from SUBMITTED to SETTLED or RETURNED
+type entry struct {
+	amountCents   int64
+	currency      string
+	last4         string
+	routingNumber string
+	submittedAt   time.Time
+	settlesAt     time.Time
+	returnCode    string // set when the receiving bank returns the entry
+	neverSettles  bool
+}
+
+type ACHPaymentProvider struct {
+	providers.ResponseVersions
+
+	Name string
+	// SettlementDelay is how long the simulator keeps debits SUBMITTED
+	// before settling or returning them
+	SettlementDelay time.Duration
+	decoding        providers.Decoding
+
+	mu      sync.Mutex
+	entries map[string]*entry // by trace number
+}
+
+func GetNewACHPaymentProvider() *ACHPaymentProvider {
+	return &ACHPaymentProvider{
+		Name:            "ach",
+		SettlementDelay: DefaultSettlementDelay,
+		entries:         make(map[string]*entry),
+	}
+}
+
+func (p *ACHPaymentProvider) GetName() string {
+	return p.Name
+}
+
+// SetDecoding configures how unknown response fields are handled
+func (p *ACHPaymentProvider) SetDecoding(decoding providers.Decoding) {
+	p.decoding = decoding
+}
+
+// ValidateRoutingNumber checks an ABA routing number: nine digits, a
+// Federal Reserve routing symbol prefix and the weighted 3-7-1 checksum
+func ValidateRoutingNumber(routingNumber string) error {
+	if routingNumber == "" {
+		return errors.New("routing number is required")
+	}
+	if len(routingNumber) != 9 || !validation.Digits(routingNumber) {
+		return errors.New("routing number must be 9 digits")
+	}
+
+	prefix := int(routingNumber[0]-'0')*10 + int(routingNumber[1]-'0')
+	switch {
+	case prefix <= 12, prefix >= 21 && prefix <= 32, prefix >= 61 && prefix <= 72, prefix == 80:
+	default:
+		return fmt.Errorf("routing number '%s' has no valid Federal Reserve prefix", routingNumber)
+	}
+
+	weights := [3]int{3, 7, 1}
+	sum := 0
+	for i := 0; i < 9; i++ {
+		sum += int(routingNumber[i]-'0') * weights[i%3]
+	}
+	if sum%10 != 0 {
+		return fmt.Errorf("routing number '%s' fails the ABA checksum", routingNumber)
+	}
+	return nil
+}
+
+// ValidateRequest checks ACH debits: a USD amount, a bank account given by
+// routing and account number, and no card data
+func (p *ACHPaymentProvider) ValidateRequest(request providers.PaymentRequest) error {
+
+	if request.Method != "" && request.Method != sandbox.MethodACH {
+		return fmt.Errorf("payment method '%s' is not supported by ach", request.Method)
+	}
+
+	if request.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+
+	if request.Amount > maxAmount {
+		return errors.New("amount exceeds the ACH limit of 1,000,000")
+	}
+
+	if request.Currency != "USD" {
+		return errors.New("ach payments must be in USD")
+	}
+
+	if request.CardNumber != "" || request.CVV != "" || request.ExpiryMonth != "" || request.ExpiryYear != "" {
+		return errors.New("ach payments must not carry card data")
+	}
+
+	if err := ValidateRoutingNumber(request.RoutingNumber); err != nil {
+		return err
+	}
+
+	if request.AccountNumber == "" {
+		return errors.New("account number is required")
+	}
+	if len(request.AccountNumber) < 4 || len(request.AccountNumber) > 17 || !validation.Digits(request.AccountNumber) {
+		return errors.New("account number must be 4 to 17 digits")
+	}
+
+	switch request.AccountType {
+	case "", "checking", "savings":
+	default:
+		return fmt.Errorf("account type '%s' must be checking or savings", request.AccountType)
+	}
+
+	return nil
+}
+
+func (p *ACHPaymentProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	now := time.Now().UTC()
+	submitted := &entry{
+		amountCents:   int64(math.Round(request.Amount * 100)),
+		currency:      request.Currency,
+		last4:         request.AccountNumber[len(request.AccountNumber)-4:],
+		routingNumber: request.RoutingNumber,
+		submittedAt:   now,
+		settlesAt:     now.Add(p.SettlementDelay),
+	}
+
+	// sandbox accounts are accepted and later settle or return with their
+	// catalogued code, the bank only answers after the settlement delay
+	if scenario, ok := sandbox.Payment(p.Name, request.AccountNumber); ok {
+		submitted.returnCode = scenario.ErrorCode
+		submitted.neverSettles = scenario.Status == providers.StatusPending
+	} else if rand.Float64() < 0.1 {
+		// Simulate a dummy error response sometimes
+		errorResponse := map[string]interface{}{
+			"code":    "E01",
+			"message": "Originating bank unavailable",
+		}
+		return nil, errorResponse
+	}
+
+	traceNumber := fmt.Sprintf("%s%07d", request.RoutingNumber[:8], rand.Int64N(1e7))
+
+	p.mu.Lock()
+	if p.entries == nil {
+		p.entries = make(map[string]*entry)
+	}
+	p.entries[traceNumber] = submitted
+	p.mu.Unlock()
+
+	return p.wireResponse(traceNumber, submitted, "SUBMITTED"), nil
+}
+
+// GetPaymentStatus looks a debit up by its trace number and reports where it
+// is in its lifecycle: SUBMITTED until the settlement delay passed, then
+// SETTLED or RETURNED
+func (p *ACHPaymentProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	p.mu.Lock()
+	submitted, ok := p.entries[transactionID]
+	p.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("ach: unknown trace number '%s'", transactionID)
+	}
+
+	status := "SUBMITTED"
+	if !submitted.neverSettles && !time.Now().Before(submitted.settlesAt) {
+		status = "SETTLED"
+		if submitted.returnCode != "" {
+			status = "RETURNED"
+		}
+	}
+
+	return p.ParseSuccessResponse(p.wireResponse(transactionID, submitted, status))
+}
+
+func (p *ACHPaymentProvider) wireResponse(traceNumber string, submitted *entry, status string) map[string]interface{} {
+	response := map[string]interface{}{
+		"trace_number":   traceNumber,
+		"status":         status,
+		"amount_cents":   submitted.amountCents,
+		"currency":       submitted.currency,
+		"effective_date": submitted.settlesAt.Format(time.DateOnly),
+		"submitted_at":   submitted.submittedAt.Format(time.RFC3339),
+		"account_last4":  submitted.last4,
+		"routing_number": submitted.routingNumber,
+		"sec_code":       "WEB",
+	}
+	if status == "SUBMITTED" && !submitted.neverSettles {
+		response["settles_at"] = submitted.settlesAt.Format(time.RFC3339)
+	}
+	if status == "RETURNED" {
+		response["return_code"] = submitted.returnCode
+	}
+	return response
+}
+
+func (p *ACHPaymentProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	return p.ParseResponse(response, p.parseResponse)
+}
+
+func (p *ACHPaymentProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	return p.ParseError(response, p.parseError)
+}
+
+// parseResponse parses the ach response format, version v1
+func (p *ACHPaymentProvider) parseResponse(response interface{}) (*providers.PaymentResponse, error) {
+	var providerResponse PaymentResponse
+	if err := p.decoding.Decode(p.Name, response, &providerResponse); err != nil {
+		return nil, err
+	}
+
+	if providerResponse.TraceNumber == "" {
+		return nil, errors.New("ach: field 'trace_number' is required")
+	}
+	submittedAt, err := time.Parse(time.RFC3339, providerResponse.SubmittedAt)
+	if err != nil {
+		return nil, fmt.Errorf("ach: field 'submitted_at' must be an RFC 3339 time, got '%s'", providerResponse.SubmittedAt)
+	}
+
+	status := providers.NormalizeStatus(providerResponse.Status, statuses)
+	if status == providers.StatusDeclined && providerResponse.ReturnCode == "" {
+		return nil, errors.New("ach: field 'return_code' is required for returned entries")
+	}
+
+	paymentResponse := &providers.PaymentResponse{
+		Success:       providers.IsSuccessStatus(status),
+		TransactionID: providerResponse.TraceNumber,
+		Status:        status,
+		RawStatus:     providerResponse.Status,
+		Amount:        float64(providerResponse.AmountCents) / 100,
+		Currency:      providerResponse.Currency,
+		Date:          &submittedAt,
+		ReturnCode:    providerResponse.ReturnCode,
+	}
+	if providerResponse.SettlesAt != "" {
+		settlesAt, err := time.Parse(time.RFC3339, providerResponse.SettlesAt)
+		if err != nil {
+			return nil, fmt.Errorf("ach: field 'settles_at' must be an RFC 3339 time, got '%s'", providerResponse.SettlesAt)
+		}
+		paymentResponse.SettlesAt = &settlesAt
+	}
+
+	return paymentResponse, nil
+}
+
+// parseError parses the ach error format, version v1
+func (p *ACHPaymentProvider) parseError(response interface{}) (*providers.PaymentError, error) {
+	var providerError PaymentError
+	if err := p.decoding.Decode(p.Name, response, &providerError); err != nil {
+		return nil, err
+	}
+
+	return providers.NewCatalogError(p.Name, providerError.Code, providerError.Message), nil
+}
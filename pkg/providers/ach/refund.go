@@ -0,0 +1,25 @@
+package ach
+
+import (
+	"context"
+	"errors"
+
+	"pgas/pkg/providers"
+)
+
+// Refund simulates the ach refund endpoint; a refund is a credit entry to
+// the debited account and stays PENDING until it settles like the debit
+func (p *ACHPaymentProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	if request.TransactionID == "" {
+		return nil, errors.New("transaction id is required")
+	}
+
+	return &providers.RefundResponse{
+		Success:       true,
+		RefundID:      "ACHCR" + request.TransactionID,
+		TransactionID: request.TransactionID,
+		Status:        providers.StatusPending,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
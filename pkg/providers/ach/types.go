@@ -0,0 +1,40 @@
+package ach
+
+import "pgas/pkg/providers"
+
+// success response format for ach, amounts are integer cents
+type PaymentResponse struct {
+	TraceNumber   string `json:"trace_number"` // 15 digit ACH trace number, used as transaction id
+	Status        string `json:"status"`
+	AmountCents   int64  `json:"amount_cents"`
+	Currency      string `json:"currency"`
+	EffectiveDate string `json:"effective_date"` // settlement date requested in the entry, eg: "2024-01-17"
+	SubmittedAt   string `json:"submitted_at"`   // RFC 3339
+	SettlesAt     string `json:"settles_at,omitempty"`
+	ReturnCode    string `json:"return_code,omitempty"`
+	AccountLast4  string `json:"account_last4,omitempty"`
+	RoutingNumber string `json:"routing_number,omitempty"`
+	StandardEntry string `json:"sec_code,omitempty"` // NACHA standard entry class, WEB for online debits
+}
+
+// error response format for ach
+type PaymentError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// entries are SUBMITTED until the settlement delay passed, then either
+// SETTLED or RETURNED by the receiving bank
+var statuses = map[string]string{
+	"SUBMITTED": providers.StatusPending,
+	"SETTLED":   providers.StatusApproved,
+	"RETURNED":  providers.StatusDeclined,
+}
+
+func (p *ACHPaymentProvider) NewWireResponse() interface{} {
+	return &PaymentResponse{}
+}
+
+func (p *ACHPaymentProvider) NewWireError() interface{} {
+	return &PaymentError{}
+}
@@ -0,0 +1,26 @@
+package providers
+
+// normalized issuer advice returned with declines, see PaymentError.Advice
+const (
+	AdviceRetryLater = "retry_later"
+	// AdviceDoNotRetry forbids any further attempt with the same card;
+	// schemes fine merchants that keep retrying
+	AdviceDoNotRetry = "do_not_retry"
+	// AdviceUpdateCard asks for new card details before a retry
+	AdviceUpdateCard = "update_card"
+)
+
+// WithAdvice sets the normalized advice of a decline. Do-not-retry advice
+// overrides the catalog's retryability.
+func (e *PaymentError) WithAdvice(advice string) *PaymentError {
+	e.Advice = advice
+	if advice == AdviceDoNotRetry {
+		e.Retryable = false
+	}
+	return e
+}
+
+// DoNotRetry reports whether the issuer forbade further attempts
+func (e *PaymentError) DoNotRetry() bool {
+	return e != nil && e.Advice == AdviceDoNotRetry
+}
@@ -0,0 +1,171 @@
+package amex
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/bin"
+	"pgas/pkg/certification"
+	"pgas/pkg/fixtures"
+	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+)
+
+func TestGetNewAmexPaymentProvider(t *testing.T) {
+	provider := GetNewAmexPaymentProvider()
+	if provider.GetName() != "amex" {
+		t.Errorf("Expected provider name 'amex', got: %s", provider.GetName())
+	}
+}
+
+func TestAmexProvider_ValidateRequest(t *testing.T) {
+	provider := GetNewAmexPaymentProvider()
+
+	valid := providers.PaymentRequest{
+		Mode:        "amex",
+		Amount:      100.00,
+		Currency:    "USD",
+		CardNumber:  "378282246310005",
+		ExpiryMonth: "12",
+		ExpiryYear:  "2030",
+		CVV:         "1234",
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(*providers.PaymentRequest)
+		valid  bool
+	}{
+		{"valid request", func(r *providers.PaymentRequest) {}, true},
+		{"34 range", func(r *providers.PaymentRequest) { r.CardNumber = "341111111111111" }, true},
+		{"16 digit card", func(r *providers.PaymentRequest) { r.CardNumber = "4111111111111111" }, false},
+		{"15 digits outside the amex range", func(r *providers.PaymentRequest) { r.CardNumber = "361111111111111" }, false},
+		{"non-digit card", func(r *providers.PaymentRequest) { r.CardNumber = "3782 822463 1000" }, false},
+		{"card failing the Luhn check", func(r *providers.PaymentRequest) { r.CardNumber = "378282246310006" }, false},
+		{"3 digit CID", func(r *providers.PaymentRequest) { r.CVV = "123" }, false},
+		{"non-digit CID", func(r *providers.PaymentRequest) { r.CVV = "12a4" }, false},
+		{"missing CID", func(r *providers.PaymentRequest) { r.CVV = "" }, false},
+		{"stored credential without CID", func(r *providers.PaymentRequest) {
+			r.CVV = ""
+			r.InitiatedBy = providers.InitiatedByMerchant
+			r.StoredCredentialUsage = providers.StoredCredentialSubsequent
+			r.PriorTransactionID = "AX0000012345678"
+		}, true},
+		{"zero amount", func(r *providers.PaymentRequest) { r.Amount = 0 }, false},
+		{"empty currency", func(r *providers.PaymentRequest) { r.Currency = "" }, false},
+		{"missing expiry", func(r *providers.PaymentRequest) { r.ExpiryYear = "" }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := valid
+			tc.modify(&request)
+
+			err := provider.ValidateRequest(request)
+			if tc.valid && err != nil {
+				t.Errorf("Expected valid request, got error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected invalid request, got no error")
+			}
+		})
+	}
+}
+
+func TestAmexProvider_ParseSuccessResponse(t *testing.T) {
+	provider := GetNewAmexPaymentProvider()
+
+	amexResponse := map[string]interface{}{
+		"transaction_identifier": "AX0000012345678",
+		"action_code":            "002",
+		"amount":                 map[string]interface{}{"value": 5000, "currency": "JPY"},
+		"transaction_time":       "2024-01-15T10:30:00Z",
+		"safekey":                map[string]interface{}{"eci": "05", "liability_shift": true},
+	}
+
+	response, err := provider.ParseSuccessResponse(amexResponse)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	if response.Status != providers.StatusPending || response.RawStatus != "002" {
+		t.Errorf("Expected action code 002 to be pending, got %s (%s)", response.Status, response.RawStatus)
+	}
+	if response.Amount != 5000 {
+		t.Errorf("Expected JPY amounts without minor units, got %f", response.Amount)
+	}
+	if !response.LiabilityShift {
+		t.Error("Expected the SafeKey liability shift to be reported")
+	}
+
+	amexResponse["transaction_time"] = "yesterday"
+	if _, err := provider.ParseSuccessResponse(amexResponse); err == nil || !strings.Contains(err.Error(), "transaction_time") {
+		t.Errorf("Expected an error for a malformed transaction time, got: %v", err)
+	}
+}
+
+func TestAmexProvider_ParseErrorResponse(t *testing.T) {
+	provider := GetNewAmexPaymentProvider()
+
+	paymentError, err := provider.ParseErrorResponse(map[string]interface{}{
+		"action_code":     "911",
+		"response_reason": "Card issuer timed out",
+	})
+	if err != nil {
+		t.Fatalf("Expected successful error parsing, got error: %v", err)
+	}
+
+	if paymentError.ErrorCode != "911" || paymentError.Reason != providers.ReasonIssuerUnavailable || !paymentError.Retryable {
+		t.Errorf("Expected a retryable issuer_unavailable error, got %+v", paymentError)
+	}
+}
+
+func TestAmexProvider_MinorUnits(t *testing.T) {
+	if got := toMinorUnits(19.99, "USD"); got != 1999 {
+		t.Errorf("Expected 1999 minor units, got %d", got)
+	}
+	if got := toMinorUnits(500, "jpy"); got != 500 {
+		t.Errorf("Expected JPY to have no minor units, got %d", got)
+	}
+	if got := fromMinorUnits(1999, "USD"); got != 19.99 {
+		t.Errorf("Expected 19.99, got %f", got)
+	}
+}
+
+func TestAmexProvider_GoldenFixtures(t *testing.T) {
+	fixtures.Run(t, GetNewAmexPaymentProvider(), "testdata/fixtures")
+}
+
+func TestAmexProvider_Certification(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 10, 0, 0, 0, time.UTC)
+	provider := GetNewAmexPaymentProvider()
+	provider.Now = func() time.Time { return now }
+
+	certification.Run(t, provider, bin.Amex, certification.Options{Now: now})
+}
+
+func TestAmexProvider_SandboxScenarios(t *testing.T) {
+	provider := GetNewAmexPaymentProvider()
+	for _, scenario := range sandbox.ForProvider("amex") {
+		if scenario.Operation != sandbox.OperationPayment {
+			continue
+		}
+		request := providers.PaymentRequest{Mode: "amex", Amount: 10, Currency: "USD", CardNumber: scenario.CardNumber}
+
+		success, failure := provider.ProcessPayment(context.Background(), request)
+		if scenario.ErrorCode == "" {
+			response, err := provider.ParseSuccessResponse(success)
+			if err != nil || response.Status != scenario.Status {
+				t.Errorf("Card %s: expected status %s, got %+v (%v)", scenario.CardNumber, scenario.Status, response, err)
+			}
+			continue
+		}
+
+		paymentError, err := provider.ParseErrorResponse(failure)
+		if err != nil || paymentError.ErrorCode != scenario.ErrorCode || paymentError.Reason != scenario.Reason || paymentError.Advice != scenario.Advice {
+			t.Errorf("Card %s: expected %s/%s/%s, got %+v (%v)", scenario.CardNumber, scenario.ErrorCode, scenario.Reason, scenario.Advice, paymentError, err)
+		}
+	}
+}
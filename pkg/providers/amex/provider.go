@@ -0,0 +1,196 @@
+package amex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+	"pgas/pkg/validation"
+)
+
+// amex SafeKey ECI values, authenticated ones need an AEVV
+var eciValues = providers.ECIValues{
+	"05": true,  // fully authenticated
+	"06": true,  // authentication attempted
+	"07": false, // not authenticated
+}
+
+// support contacts travel in the merchant city field of the descriptor
+var contactRules = providers.ContactRules{PhoneMax: 13, URLMax: 13}
+
+type AmexPaymentProvider struct {
+	providers.ResponseVersions
+
+	Name string
+	// Now is the clock card expiry dates are checked against
+	Now      func() time.Time
+	decoding providers.Decoding
+}
+
+func GetNewAmexPaymentProvider() *AmexPaymentProvider {
+	return &AmexPaymentProvider{Name: "amex", Now: time.Now}
+}
+
+func (p *AmexPaymentProvider) GetName() string {
+	return p.Name
+}
+
+// SetDecoding configures how unknown response fields are handled
+func (p *AmexPaymentProvider) SetDecoding(decoding providers.Decoding) {
+	p.decoding = decoding
+}
+
+// ValidateRequest enforces the Amex card format: 15 digits starting with 34
+// or 37, and the 4-digit CID printed on the front of the card
+func (p *AmexPaymentProvider) ValidateRequest(request providers.PaymentRequest) error {
+
+	if request.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+
+	if request.Amount > 1000000 {
+		return errors.New("amount exceeds maximum limit of 1,000,000")
+	}
+
+	if len(request.Currency) != 3 {
+		return errors.New("currency must be a 3-letter ISO 4217 code")
+	}
+
+	if request.CardNumber == "" {
+		return errors.New("card number is required")
+	}
+
+	if len(request.CardNumber) != 15 || !validation.Digits(request.CardNumber) {
+		return errors.New("amex card numbers must be 15 digits")
+	}
+
+	if !strings.HasPrefix(request.CardNumber, "34") && !strings.HasPrefix(request.CardNumber, "37") {
+		return errors.New("amex card numbers must start with 34 or 37")
+	}
+
+	if err := validation.ValidateCardNumber(request.CardNumber); err != nil {
+		return err
+	}
+
+	if err := validation.ValidateExpiry(request.ExpiryMonth, request.ExpiryYear, p.Now()); err != nil {
+		return err
+	}
+
+	// stored credentials are charged without the CID, it must not be kept
+	storedCredential := request.StoredCredentialUsage == providers.StoredCredentialSubsequent
+	if request.CVV == "" && !storedCredential {
+		return errors.New("CID is required")
+	}
+
+	if request.CVV != "" && (len(request.CVV) != 4 || !validation.Digits(request.CVV)) {
+		return errors.New("CID must be 4 digits")
+	}
+
+	if err := providers.ValidateStoredCredential(request); err != nil {
+		return err
+	}
+
+	if err := providers.ValidateSupportContact(request.SupportContact, contactRules); err != nil {
+		return err
+	}
+
+	return providers.ValidateAuthenticationData(request, eciValues)
+}
+
+func (p *AmexPaymentProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	// sandbox test cards trigger their catalogued outcome
+	actionCode := "000"
+	if scenario, ok := sandbox.Payment(p.Name, request.CardNumber); ok {
+		if scenario.ErrorCode != "" {
+			info, _ := providers.LookupErrorCode(p.Name, scenario.ErrorCode)
+			errorResponse := map[string]interface{}{
+				"action_code":     scenario.ErrorCode,
+				"response_reason": info.Description,
+			}
+			return nil, errorResponse
+		}
+		if scenario.Status == providers.StatusPending {
+			actionCode = "002"
+		}
+	} else if rand.Float64() < 0.1 {
+		// Simulate a dummy error response sometimes
+		errorResponse := map[string]interface{}{
+			"action_code":     "100",
+			"response_reason": "Deny",
+		}
+		return nil, errorResponse
+	}
+
+	// Simulate a dummy successful payment response
+	successResponse := map[string]interface{}{
+		"transaction_identifier": fmt.Sprintf("AX%013d", rand.Int64N(1e13)),
+		"action_code":            actionCode,
+		"approval_code":          fmt.Sprintf("%06d", rand.IntN(1000000)),
+		"amount": map[string]interface{}{
+			"value":    toMinorUnits(request.Amount, request.Currency),
+			"currency": request.Currency,
+		},
+		"transaction_time": time.Now().UTC().Format(time.RFC3339),
+	}
+	if request.ECI != "" {
+		successResponse["safekey"] = map[string]interface{}{
+			"eci":             request.ECI,
+			"liability_shift": providers.LiabilityShift(request, eciValues),
+		}
+	}
+
+	return successResponse, nil
+}
+
+func (p *AmexPaymentProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	return p.ParseResponse(response, p.parseResponse)
+}
+
+func (p *AmexPaymentProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	return p.ParseError(response, p.parseError)
+}
+
+// parseResponse parses the amex response format, version v1
+func (p *AmexPaymentProvider) parseResponse(response interface{}) (*providers.PaymentResponse, error) {
+	var providerResponse PaymentResponse
+	if err := p.decoding.Decode(p.Name, response, &providerResponse); err != nil {
+		return nil, err
+	}
+
+	if providerResponse.TransactionIdentifier == "" {
+		return nil, errors.New("amex: field 'transaction_identifier' is required")
+	}
+	transactionTime, err := time.Parse(time.RFC3339, providerResponse.TransactionTime)
+	if err != nil {
+		return nil, fmt.Errorf("amex: field 'transaction_time' must be an RFC 3339 time, got '%s'", providerResponse.TransactionTime)
+	}
+
+	status := providers.NormalizeStatus(providerResponse.ActionCode, approvals)
+	currency := providerResponse.Amount.Currency
+
+	return &providers.PaymentResponse{
+		Success:        providers.IsSuccessStatus(status),
+		TransactionID:  providerResponse.TransactionIdentifier,
+		Status:         status,
+		RawStatus:      providerResponse.ActionCode,
+		Amount:         fromMinorUnits(providerResponse.Amount.Value, currency),
+		Currency:       currency,
+		Date:           &transactionTime,
+		LiabilityShift: providerResponse.SafeKey != nil && providerResponse.SafeKey.LiabilityShift,
+	}, nil
+}
+
+// parseError parses the amex error format, version v1
+func (p *AmexPaymentProvider) parseError(response interface{}) (*providers.PaymentError, error) {
+	var providerError PaymentError
+	if err := p.decoding.Decode(p.Name, response, &providerError); err != nil {
+		return nil, err
+	}
+
+	return providers.NewCatalogError(p.Name, providerError.ActionCode, providerError.ResponseReason), nil
+}
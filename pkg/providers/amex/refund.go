@@ -0,0 +1,25 @@
+package amex
+
+import (
+	"context"
+	"errors"
+
+	"pgas/pkg/providers"
+)
+
+// Refund simulates the amex credit endpoint; it has no reason field so the
+// normalized reason is only kept on pgas' side
+func (p *AmexPaymentProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	if request.TransactionID == "" {
+		return nil, errors.New("transaction id is required")
+	}
+
+	return &providers.RefundResponse{
+		Success:       true,
+		RefundID:      "AXCR-" + request.TransactionID,
+		TransactionID: request.TransactionID,
+		Status:        providers.StatusApproved,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
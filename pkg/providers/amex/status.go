@@ -0,0 +1,13 @@
+package amex
+
+import (
+	"context"
+
+	"pgas/pkg/providers"
+)
+
+// GetPaymentStatus is not offered by the simulated amex gateway, the
+// processor answers from its stored record instead
+func (p *AmexPaymentProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return nil, providers.ErrStatusQueryNotSupported
+}
@@ -0,0 +1,63 @@
+package amex
+
+import (
+	"math"
+	"strings"
+
+	"pgas/pkg/providers"
+)
+
+// success response format for amex, amounts are in minor units
+type PaymentResponse struct {
+	TransactionIdentifier string `json:"transaction_identifier"`
+	ActionCode            string `json:"action_code"`
+	ApprovalCode          string `json:"approval_code,omitempty"`
+	Amount                struct {
+		Value    int64  `json:"value"`
+		Currency string `json:"currency"`
+	} `json:"amount"`
+	TransactionTime string `json:"transaction_time"` // RFC 3339, eg: "2024-01-15T10:30:00Z"
+	SafeKey         *struct {
+		ECI            string `json:"eci"`
+		LiabilityShift bool   `json:"liability_shift"`
+	} `json:"safekey,omitempty"`
+}
+
+// error response format for amex
+type PaymentError struct {
+	ActionCode     string `json:"action_code"`
+	ResponseReason string `json:"response_reason"`
+}
+
+// action codes that approve the payment; every other action code is
+// returned as an error
+var approvals = map[string]string{
+	"000": providers.StatusApproved,
+	"001": providers.StatusApproved, // approve with cardholder identification
+	"002": providers.StatusPending,  // partial review by the issuer
+}
+
+// currencies without minor units, all others have two decimals
+var zeroDecimal = map[string]bool{"JPY": true, "KRW": true, "VND": true, "CLP": true}
+
+func toMinorUnits(amount float64, currency string) int64 {
+	if zeroDecimal[strings.ToUpper(currency)] {
+		return int64(math.Round(amount))
+	}
+	return int64(math.Round(amount * 100))
+}
+
+func fromMinorUnits(value int64, currency string) float64 {
+	if zeroDecimal[strings.ToUpper(currency)] {
+		return float64(value)
+	}
+	return float64(value) / 100
+}
+
+func (p *AmexPaymentProvider) NewWireResponse() interface{} {
+	return &PaymentResponse{}
+}
+
+func (p *AmexPaymentProvider) NewWireError() interface{} {
+	return &PaymentError{}
+}
@@ -0,0 +1,100 @@
+package providers
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 3-D Secure transaction statuses as defined by EMVCo
+const (
+	AuthStatusAuthenticated = "Y" // fully authenticated
+	AuthStatusAttempted     = "A" // attempt recorded, liability usually shifts
+	AuthStatusFailed        = "N"
+	AuthStatusUnavailable   = "U"
+	AuthStatusRejected      = "R"
+	AuthStatusChallenge     = "C" // cardholder must complete a challenge
+)
+
+// Authentication carries the outcome of cardholder authentication, e.g.
+// 3-D Secure, for providers to include in the authorization
+type Authentication struct {
+	Version         string `json:"version,omitempty"` // protocol version such as "2.2.0"
+	Status          string `json:"status"`
+	ECI             string `json:"eci,omitempty"`
+	CAVV            string `json:"cavv,omitempty"`
+	DSTransactionID string `json:"ds_transaction_id,omitempty"`
+}
+
+// Challenge is handed to the front end when the issuer wants to challenge
+// the cardholder
+type Challenge struct {
+	TransactionID string `json:"transaction_id"` // 3DS server transaction id
+	URL           string `json:"url"`            // ACS URL the CReq is posted to
+	Payload       string `json:"payload"`        // base64 encoded CReq
+}
+
+// ECIValues lists a scheme's electronic commerce indicators and whether the
+// value claims cardholder authentication, which needs a cryptogram and
+// shifts liability to the issuer
+type ECIValues map[string]bool
+
+// ValidateAuthenticationData checks the ECI, cryptogram and DS transaction id
+// of a request against the scheme's ECI values. Requests without any of them
+// are unauthenticated and valid.
+func ValidateAuthenticationData(request PaymentRequest, values ECIValues) error {
+	if request.ECI == "" {
+		if request.Cryptogram != "" || request.DSTransactionID != "" {
+			return errors.New("ECI is required with a cryptogram or DS transaction id")
+		}
+		return nil
+	}
+
+	authenticated, ok := values[request.ECI]
+	if !ok {
+		return fmt.Errorf("ECI '%s' is not valid for this card scheme", request.ECI)
+	}
+	if authenticated && request.Cryptogram == "" {
+		return fmt.Errorf("ECI '%s' requires a cryptogram", request.ECI)
+	}
+	if request.Cryptogram != "" && !validCryptogram(request.Cryptogram) {
+		return errors.New("cryptogram must be 20 bytes encoded as base64 or hex")
+	}
+	if len(request.DSTransactionID) > 36 || strings.Trim(request.DSTransactionID, "0123456789abcdefABCDEF-") != "" {
+		return errors.New("DS transaction id must be a UUID")
+	}
+	return nil
+}
+
+// LiabilityShift reports whether the request carries authentication data
+// that shifts liability under the scheme's ECI values
+func LiabilityShift(request PaymentRequest, values ECIValues) bool {
+	return values[request.ECI] && request.Cryptogram != ""
+}
+
+// ApplyAuthentication copies the 3-D Secure result into the authorization
+// fields, keeping values the caller set explicitly
+func ApplyAuthentication(request *PaymentRequest) {
+	if request.Authentication == nil {
+		return
+	}
+	if request.ECI == "" {
+		request.ECI = request.Authentication.ECI
+	}
+	if request.Cryptogram == "" {
+		request.Cryptogram = request.Authentication.CAVV
+	}
+	if request.DSTransactionID == "" {
+		request.DSTransactionID = request.Authentication.DSTransactionID
+	}
+}
+
+func validCryptogram(cryptogram string) bool {
+	if decoded, err := hex.DecodeString(cryptogram); err == nil {
+		return len(decoded) == 20
+	}
+	decoded, err := base64.StdEncoding.DecodeString(cryptogram)
+	return err == nil && len(decoded) == 20
+}
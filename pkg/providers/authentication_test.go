@@ -0,0 +1,56 @@
+package providers
+
+import "testing"
+
+var testECIValues = ECIValues{"05": true, "06": true, "07": false}
+
+func TestValidateAuthenticationData(t *testing.T) {
+	cavv := "AAABBEg0VhI0VniQEjRWAAAAAAA="
+	tests := []struct {
+		name    string
+		request PaymentRequest
+		wantErr bool
+	}{
+		{"unauthenticated", PaymentRequest{}, false},
+		{"authenticated", PaymentRequest{ECI: "05", Cryptogram: cavv, DSTransactionID: "f25084f0-5b16-4c0a-ae5d-b24808a95e4b"}, false},
+		{"hex cryptogram", PaymentRequest{ECI: "06", Cryptogram: "0001020304050607080900010203040506070809"}, false},
+		{"not authenticated without cryptogram", PaymentRequest{ECI: "07"}, false},
+		{"cryptogram without ECI", PaymentRequest{Cryptogram: cavv}, true},
+		{"DS transaction id without ECI", PaymentRequest{DSTransactionID: "f25084f0"}, true},
+		{"ECI of another scheme", PaymentRequest{ECI: "02", Cryptogram: cavv}, true},
+		{"authenticated without cryptogram", PaymentRequest{ECI: "05"}, true},
+		{"short cryptogram", PaymentRequest{ECI: "05", Cryptogram: "AAAB"}, true},
+		{"malformed DS transaction id", PaymentRequest{ECI: "05", Cryptogram: cavv, DSTransactionID: "not-a-uuid"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAuthenticationData(tt.request, testECIValues)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAuthenticationData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyAuthentication(t *testing.T) {
+	request := PaymentRequest{
+		ECI:            "06",
+		Authentication: &Authentication{Status: AuthStatusAuthenticated, ECI: "05", CAVV: "cavv", DSTransactionID: "ds"},
+	}
+	ApplyAuthentication(&request)
+
+	if request.ECI != "06" {
+		t.Errorf("Expected explicit ECI to be kept, got %s", request.ECI)
+	}
+	if request.Cryptogram != "cavv" || request.DSTransactionID != "ds" {
+		t.Errorf("Expected cryptogram and DS transaction id from the 3DS result, got %q and %q", request.Cryptogram, request.DSTransactionID)
+	}
+
+	if !LiabilityShift(request, testECIValues) {
+		t.Error("Expected an attempted authentication with cryptogram to shift liability")
+	}
+	if LiabilityShift(PaymentRequest{ECI: "07"}, testECIValues) {
+		t.Error("Expected no liability shift without authentication")
+	}
+}
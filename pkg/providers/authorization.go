@@ -0,0 +1,34 @@
+package providers
+
+import "fmt"
+
+// authorization types, see PaymentRequest.AuthorizationType
+const (
+	// AuthorizationFinal holds the amount that will be captured
+	AuthorizationFinal = "final"
+	// AuthorizationEstimated holds an estimate, e.g. at a fuel pump or a
+	// hotel check-in; the captured amount may differ within the tolerance
+	// of the scheme
+	AuthorizationEstimated = "estimated"
+)
+
+// partial approval behaviors, see PaymentRequest.PartialApproval
+const (
+	// PartialApprovalAccept keeps an approval of less than the requested
+	// amount, e.g. the balance of a prepaid card; the caller collects the
+	// rest by other means
+	PartialApprovalAccept = "accept"
+	// PartialApprovalVoid reverses a partial approval right away and fails
+	// the payment
+	PartialApprovalVoid = "void"
+)
+
+// ValidatePartialApproval accepts the partial approval behaviors, empty
+// included
+func ValidatePartialApproval(behavior string) error {
+	switch behavior {
+	case "", PartialApprovalAccept, PartialApprovalVoid:
+		return nil
+	}
+	return fmt.Errorf("partial approval behavior must be %s or %s, got '%s'", PartialApprovalAccept, PartialApprovalVoid, behavior)
+}
@@ -0,0 +1,47 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+)
+
+// CaptureRequest settles part or all of an authorized payment. A payment can
+// be captured several times until a capture marked Final.
+type CaptureRequest struct {
+	Mode          string  `json:"mode"` // provider that authorized the payment
+	TransactionID string  `json:"transaction_id"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+	Final         bool    `json:"final,omitempty"` // no further captures follow
+}
+
+// Validate checks the fields every capture needs
+func (r CaptureRequest) Validate() error {
+	if r.TransactionID == "" {
+		return fmt.Errorf("transaction id is required")
+	}
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	if r.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	return nil
+}
+
+// normalized capture result, CaptureID is the gateway's reference for this
+// capture alone
+type CaptureResponse struct {
+	Success       bool    `json:"success"`
+	CaptureID     string  `json:"capture_id"`
+	TransactionID string  `json:"transaction_id"`
+	Status        string  `json:"status"`
+	Amount        float64 `json:"amount"`
+	Currency      string  `json:"currency"`
+}
+
+// Capturer is implemented by providers able to capture authorized payments,
+// possibly in several parts
+type Capturer interface {
+	Capture(ctx context.Context, request CaptureRequest) (*CaptureResponse, error)
+}
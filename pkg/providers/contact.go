@@ -0,0 +1,86 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// SupportContact is shown next to the statement descriptor so cardholders
+// can reach the merchant instead of disputing a charge they don't recognize
+type SupportContact struct {
+	Phone string `json:"phone,omitempty"`
+	URL   string `json:"url,omitempty"` // without scheme, e.g. shop.example/help
+}
+
+// ContactRules are a scheme's limits for support contacts. Both usually
+// travel in the merchant city field of the descriptor; zero checks the
+// format only.
+type ContactRules struct {
+	PhoneMax int
+	URLMax   int
+}
+
+// minimum digits of a dialable support number
+const minPhoneDigits = 5
+
+// ValidateSupportContact checks the format of a support contact and the
+// lengths allowed by the scheme
+func ValidateSupportContact(contact *SupportContact, rules ContactRules) error {
+	if contact == nil {
+		return nil
+	}
+
+	if contact.Phone != "" {
+		if err := validatePhone(contact.Phone); err != nil {
+			return err
+		}
+		if rules.PhoneMax > 0 && len(contact.Phone) > rules.PhoneMax {
+			return fmt.Errorf("support phone must be at most %d characters", rules.PhoneMax)
+		}
+	}
+
+	if contact.URL != "" {
+		if err := validateURL(contact.URL); err != nil {
+			return err
+		}
+		if rules.URLMax > 0 && len(contact.URL) > rules.URLMax {
+			return fmt.Errorf("support URL must be at most %d characters", rules.URLMax)
+		}
+	}
+	return nil
+}
+
+func validatePhone(phone string) error {
+	digits := 0
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0, r == '-', r == ' ':
+		default:
+			return fmt.Errorf("support phone may only contain digits, spaces, hyphens and a leading '+', got '%s'", phone)
+		}
+	}
+	if digits < minPhoneDigits {
+		return fmt.Errorf("support phone must have at least %d digits", minPhoneDigits)
+	}
+	return nil
+}
+
+func validateURL(url string) error {
+	lower := strings.ToLower(url)
+	if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+		return errors.New("support URL must not include a scheme, it wastes descriptor space")
+	}
+	for _, r := range url {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(".-/_", r)) {
+			return fmt.Errorf("support URL contains invalid character '%c'", r)
+		}
+	}
+	host, _, _ := strings.Cut(url, "/")
+	if !strings.Contains(host, ".") || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return fmt.Errorf("support URL must start with a domain name, got '%s'", url)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package providers
+
+import "testing"
+
+func TestValidateSupportContact(t *testing.T) {
+	rules := ContactRules{PhoneMax: 13, URLMax: 13}
+
+	cases := []struct {
+		name    string
+		contact *SupportContact
+		valid   bool
+	}{
+		{"none", nil, true},
+		{"phone", &SupportContact{Phone: "+1 800-555-01"}, true},
+		{"url", &SupportContact{URL: "acme.io/help"}, true},
+		{"phone letters", &SupportContact{Phone: "1-800-FLOWERS"}, false},
+		{"phone too short", &SupportContact{Phone: "12-34"}, false},
+		{"phone too long", &SupportContact{Phone: "+44 20 7946 0958"}, false},
+		{"url with scheme", &SupportContact{URL: "https://a.io"}, false},
+		{"url without domain", &SupportContact{URL: "localhost"}, false},
+		{"url with spaces", &SupportContact{URL: "acme.io/a b"}, false},
+		{"url too long", &SupportContact{URL: "support.acme.example"}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSupportContact(tc.contact, rules)
+			if (err == nil) != tc.valid {
+				t.Errorf("Expected valid=%v, got error: %v", tc.valid, err)
+			}
+		})
+	}
+
+	// without scheme limits only the format is checked
+	if err := ValidateSupportContact(&SupportContact{URL: "support.acme.example/contact"}, ContactRules{}); err != nil {
+		t.Errorf("Expected long URL to pass format checks, got %v", err)
+	}
+}
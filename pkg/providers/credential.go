@@ -0,0 +1,49 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+)
+
+// who started a payment
+const (
+	InitiatedByCustomer = "customer" // cardholder present in the flow (CIT)
+	InitiatedByMerchant = "merchant" // merchant charges a stored card (MIT)
+)
+
+// how a stored credential is used
+const (
+	StoredCredentialFirst      = "first"      // card is stored with this payment
+	StoredCredentialSubsequent = "subsequent" // card was stored by an earlier payment
+)
+
+// ValidateStoredCredential checks the scheme rules for stored credentials: a
+// credential is first stored by a customer-initiated payment and merchant
+// initiated payments reference that initial payment.
+func ValidateStoredCredential(request PaymentRequest) error {
+	switch request.InitiatedBy {
+	case "", InitiatedByCustomer, InitiatedByMerchant:
+	default:
+		return fmt.Errorf("initiated_by must be '%s' or '%s'", InitiatedByCustomer, InitiatedByMerchant)
+	}
+
+	switch request.StoredCredentialUsage {
+	case "", StoredCredentialFirst, StoredCredentialSubsequent:
+	default:
+		return fmt.Errorf("stored_credential_usage must be '%s' or '%s'", StoredCredentialFirst, StoredCredentialSubsequent)
+	}
+
+	if request.InitiatedBy == InitiatedByMerchant {
+		if request.StoredCredentialUsage != StoredCredentialSubsequent {
+			return errors.New("merchant-initiated payments must use a stored credential from a prior payment")
+		}
+		if request.PriorTransactionID == "" {
+			return errors.New("merchant-initiated payments must reference the initial customer-initiated payment")
+		}
+	}
+
+	if request.PriorTransactionID != "" && request.StoredCredentialUsage != StoredCredentialSubsequent {
+		return errors.New("prior_transaction_id is only valid for subsequent stored credential usage")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package providers
+
+import "testing"
+
+func TestValidateStoredCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		request PaymentRequest
+		wantErr bool
+	}{
+		{"one-off payment", PaymentRequest{}, false},
+		{"initial CIT", PaymentRequest{InitiatedBy: InitiatedByCustomer, StoredCredentialUsage: StoredCredentialFirst}, false},
+		{"cardholder using stored card", PaymentRequest{InitiatedBy: InitiatedByCustomer, StoredCredentialUsage: StoredCredentialSubsequent}, false},
+		{"subsequent MIT", PaymentRequest{InitiatedBy: InitiatedByMerchant, StoredCredentialUsage: StoredCredentialSubsequent, PriorTransactionID: "tx_1"}, false},
+		{"MIT without prior transaction", PaymentRequest{InitiatedBy: InitiatedByMerchant, StoredCredentialUsage: StoredCredentialSubsequent}, true},
+		{"MIT storing the credential", PaymentRequest{InitiatedBy: InitiatedByMerchant, StoredCredentialUsage: StoredCredentialFirst, PriorTransactionID: "tx_1"}, true},
+		{"MIT without stored credential", PaymentRequest{InitiatedBy: InitiatedByMerchant}, true},
+		{"prior transaction on first use", PaymentRequest{StoredCredentialUsage: StoredCredentialFirst, PriorTransactionID: "tx_1"}, true},
+		{"unknown initiator", PaymentRequest{InitiatedBy: "system"}, true},
+		{"unknown usage", PaymentRequest{StoredCredentialUsage: "recurring"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateStoredCredential(tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateStoredCredential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
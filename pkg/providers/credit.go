@@ -0,0 +1,61 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+)
+
+// CreditRequest pushes funds to a card without an earlier payment, such as
+// a Visa Original Credit or a Mastercard MoneySend payout. Credits are a
+// common cash-out route for stolen cards, so the processor only accepts
+// them from authorized actors and audits each one.
+type CreditRequest struct {
+	Mode        string  `json:"mode" validate:"required"`
+	Amount      float64 `json:"amount" validate:"required,gt=0"`
+	Currency    string  `json:"currency" validate:"required,len=3"`
+	CardNumber  string  `json:"card_number" validate:"required,digits,min=12,max=19"`
+	ExpiryMonth string  `json:"expiry_month" validate:"digits,max=2"`
+	ExpiryYear  string  `json:"expiry_year" validate:"digits,min=2,max=4"`
+	// Actor is who initiated the credit, Token their credential for the
+	// processor's credit authorizer
+	Actor string `json:"actor" validate:"required"`
+	Token string `json:"-"`
+	// Reference is the merchant's id of the credit, e.g. a payout or
+	// claim number
+	Reference string `json:"reference,omitempty" validate:"max=255"`
+	Note      string `json:"note,omitempty"` // free text for the merchant's records
+}
+
+// Validate checks the fields every credit needs
+func (r CreditRequest) Validate() error {
+	if r.Actor == "" {
+		return fmt.Errorf("actor is required")
+	}
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	if r.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	if r.CardNumber == "" {
+		return fmt.Errorf("card number is required")
+	}
+	return nil
+}
+
+// normalized credit result, CreditID is the gateway's reference
+type CreditResponse struct {
+	Success   bool    `json:"success"`
+	CreditID  string  `json:"credit_id"`
+	Status    string  `json:"status"`
+	Amount    float64 `json:"amount"`
+	Currency  string  `json:"currency"`
+	Provider  string  `json:"provider,omitempty"`
+	Reference string  `json:"reference,omitempty"`
+}
+
+// Creditor is implemented by providers whose scheme allows credits to
+// cards without a prior charge
+type Creditor interface {
+	Credit(ctx context.Context, request CreditRequest) (*CreditResponse, error)
+}
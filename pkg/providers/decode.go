@@ -0,0 +1,181 @@
+package providers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// DecodeMode controls how provider parsers treat response fields they do not
+// know, which usually means the gateway changed its format
+type DecodeMode int
+
+const (
+	// DecodeWarn parses the response and reports unknown fields as drift
+	DecodeWarn DecodeMode = iota
+	// DecodeStrict fails parsing when the response has unknown fields
+	DecodeStrict
+	// DecodeLenient ignores unknown fields
+	DecodeLenient
+)
+
+// Drift lists the unknown fields found in a provider response, as dotted
+// paths such as "value.fee"
+type Drift struct {
+	Provider string   `json:"provider"`
+	Fields   []string `json:"fields"`
+}
+
+// DriftError is returned by strict decoding
+type DriftError struct {
+	Drift
+}
+
+func (e *DriftError) Error() string {
+	return e.Provider + ": unknown fields in response: " + strings.Join(e.Fields, ", ")
+}
+
+// Decoding configures provider response parsing
+type Decoding struct {
+	Mode DecodeMode
+	// OnDrift receives unknown fields in DecodeWarn mode
+	OnDrift func(drift Drift)
+}
+
+// DecodingSetter is implemented by providers whose parsers honor Decoding
+type DecodingSetter interface {
+	SetDecoding(decoding Decoding)
+}
+
+// WireFormat is implemented by providers that parse responses through typed
+// wire structs, each method returns a pointer to a new zero struct
+type WireFormat interface {
+	NewWireResponse() interface{}
+	NewWireError() interface{}
+}
+
+// Decode reads a raw provider payload (a decoded JSON value, a struct or raw
+// JSON bytes) into target, a pointer to the provider's response struct. Type
+// mismatches name the offending field.
+func (d Decoding) Decode(provider string, payload interface{}, target interface{}) error {
+	var data []byte
+	switch raw := payload.(type) {
+	case []byte:
+		data = raw
+	case json.RawMessage:
+		data = raw
+	default:
+		var err error
+		if data, err = json.Marshal(payload); err != nil {
+			return fmt.Errorf("%s: response is not JSON encodable: %v", provider, err)
+		}
+	}
+
+	if d.Mode != DecodeLenient {
+		if fields := unknownFields(data, reflect.TypeOf(target)); len(fields) > 0 {
+			drift := Drift{Provider: provider, Fields: fields}
+			if d.Mode == DecodeStrict {
+				return &DriftError{Drift: drift}
+			}
+			if d.OnDrift != nil {
+				d.OnDrift(drift)
+			}
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if d.Mode == DecodeStrict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(target); err != nil {
+		var typeError *json.UnmarshalTypeError
+		if errors.As(err, &typeError) && typeError.Field == "" {
+			return fmt.Errorf("%s: response must be an object, got %s", provider, typeError.Value)
+		}
+		if errors.As(err, &typeError) {
+			return fmt.Errorf("%s: field '%s' must be %s, got %s", provider, typeError.Field, typeError.Type, typeError.Value)
+		}
+		return fmt.Errorf("%s: invalid response: %v", provider, err)
+	}
+	return nil
+}
+
+// unknownFields walks the JSON document alongside the target type and lists
+// the object keys the type has no field for
+func unknownFields(data []byte, target reflect.Type) []string {
+	var document interface{}
+	if json.Unmarshal(data, &document) != nil {
+		return nil
+	}
+
+	var fields []string
+	collectUnknown(document, target, "", &fields)
+	sort.Strings(fields)
+	return fields
+}
+
+func collectUnknown(value interface{}, typ reflect.Type, prefix string, fields *[]string) {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if typ.Kind() != reflect.Struct {
+			return // maps and interfaces accept any key
+		}
+		known := jsonFields(typ)
+		for key, item := range v {
+			field, ok := known[key]
+			if !ok {
+				*fields = append(*fields, prefix+key)
+				continue
+			}
+			collectUnknown(item, field, prefix+key+".", fields)
+		}
+	case []interface{}:
+		if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+			return
+		}
+		for _, item := range v {
+			collectUnknown(item, typ.Elem(), prefix, fields)
+		}
+	}
+}
+
+// jsonFields maps the JSON names of a struct's fields to their types,
+// including promoted fields of embedded structs
+func jsonFields(typ reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for key, fieldType := range jsonFields(embedded) {
+					fields[key] = fieldType
+				}
+				continue
+			}
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type decodeTarget struct {
+	ID    string `json:"id"`
+	Value struct {
+		Amount string `json:"amount"`
+	} `json:"value"`
+	Items []struct {
+		Code string `json:"code"`
+	} `json:"items,omitempty"`
+}
+
+var driftedPayload = map[string]interface{}{
+	"id":    "tx1",
+	"fee":   "0.30",
+	"value": map[string]interface{}{"amount": "10.00", "network": "visa"},
+	"items": []interface{}{map[string]interface{}{"code": "A", "note": "x"}},
+}
+
+func TestDecoding_Warn(t *testing.T) {
+	var drifts []Drift
+	decoding := Decoding{OnDrift: func(drift Drift) { drifts = append(drifts, drift) }}
+
+	var target decodeTarget
+	if err := decoding.Decode("visa", driftedPayload, &target); err != nil {
+		t.Fatalf("Expected warn mode to parse, got: %v", err)
+	}
+	if target.ID != "tx1" || target.Value.Amount != "10.00" {
+		t.Errorf("Expected known fields to be decoded, got %+v", target)
+	}
+
+	want := []string{"fee", "items.note", "value.network"}
+	if len(drifts) != 1 || drifts[0].Provider != "visa" || !reflect.DeepEqual(drifts[0].Fields, want) {
+		t.Errorf("Expected drift %v, got %+v", want, drifts)
+	}
+}
+
+func TestDecoding_Strict(t *testing.T) {
+	decoding := Decoding{Mode: DecodeStrict, OnDrift: func(Drift) { t.Error("Expected no drift callback in strict mode") }}
+
+	var target decodeTarget
+	err := decoding.Decode("visa", driftedPayload, &target)
+
+	var driftError *DriftError
+	if !errors.As(err, &driftError) {
+		t.Fatalf("Expected a drift error, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "fee, items.note, value.network") {
+		t.Errorf("Expected the offending fields in the error, got: %v", err)
+	}
+
+	if err := decoding.Decode("visa", map[string]interface{}{"id": "tx1"}, &target); err != nil {
+		t.Errorf("Expected a payload without unknown fields to parse, got: %v", err)
+	}
+}
+
+func TestDecoding_Lenient(t *testing.T) {
+	decoding := Decoding{Mode: DecodeLenient, OnDrift: func(Drift) { t.Error("Expected no drift callback in lenient mode") }}
+
+	var target decodeTarget
+	if err := decoding.Decode("visa", driftedPayload, &target); err != nil {
+		t.Fatalf("Expected lenient mode to parse, got: %v", err)
+	}
+}
+
+func TestDecoding_TypeMismatch(t *testing.T) {
+	var target decodeTarget
+	err := Decoding{}.Decode("visa", []byte(`{"id":"tx1","value":{"amount":10}}`), &target)
+	if err == nil || !strings.Contains(err.Error(), "field 'value.amount' must be string, got number") {
+		t.Errorf("Expected the mismatched field to be named, got: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxDescriptorLength is the longest statement descriptor the card schemes
+// carry in the merchant name field
+const MaxDescriptorLength = 22
+
+// characters schemes and issuers reject or mangle on statements
+const forbiddenDescriptorChars = `<>\'"`
+
+// DescriptorPlaceholders lists the placeholders of a descriptor template
+// such as "ACME*{order_id}", failing on unbalanced or empty braces
+func DescriptorPlaceholders(template string) ([]string, error) {
+	var placeholders []string
+	rest := template
+	for {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			return placeholders, nil
+		}
+		if rest[open] == '}' {
+			return nil, fmt.Errorf("descriptor template %q has an unopened '}'", template)
+		}
+
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] == '{' {
+			return nil, fmt.Errorf("descriptor template %q has an unclosed '{'", template)
+		}
+		name := rest[open+1 : open+1+end]
+		if name == "" {
+			return nil, fmt.Errorf("descriptor template %q has an empty placeholder", template)
+		}
+		placeholders = append(placeholders, name)
+		rest = rest[open+end+2:]
+	}
+}
+
+// RenderDescriptor fills the placeholders of a descriptor template from
+// data and validates the result
+func RenderDescriptor(template string, data map[string]string) (string, error) {
+	placeholders, err := DescriptorPlaceholders(template)
+	if err != nil {
+		return "", err
+	}
+
+	rendered := template
+	for _, name := range placeholders {
+		value, ok := data[name]
+		if !ok {
+			return "", fmt.Errorf("descriptor template %q needs '%s'", template, name)
+		}
+		rendered = strings.Replace(rendered, "{"+name+"}", value, 1)
+	}
+
+	if err := ValidateDescriptor(rendered); err != nil {
+		return "", err
+	}
+	return rendered, nil
+}
+
+// ValidateDescriptor checks a rendered descriptor against the scheme
+// constraints: printable ASCII without quotes, backslashes or angle brackets
+// and at most MaxDescriptorLength characters
+func ValidateDescriptor(descriptor string) error {
+	if len(descriptor) > MaxDescriptorLength {
+		return fmt.Errorf("descriptor %q exceeds %d characters", descriptor, MaxDescriptorLength)
+	}
+	for _, r := range descriptor {
+		if r < ' ' || r > '~' || strings.ContainsRune(forbiddenDescriptorChars, r) {
+			return fmt.Errorf("descriptor %q contains invalid character %q", descriptor, r)
+		}
+	}
+	return nil
+}
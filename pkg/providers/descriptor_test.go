@@ -0,0 +1,41 @@
+package providers
+
+import "testing"
+
+func TestRenderDescriptor(t *testing.T) {
+	data := map[string]string{"order_id": "A1234", "city": "BERLIN"}
+
+	cases := []struct {
+		template string
+		expected string
+		valid    bool
+	}{
+		{"ACME*{order_id}", "ACME*A1234", true},
+		{"ACME {city} {order_id}", "ACME BERLIN A1234", true},
+		{"ACME STORE", "ACME STORE", true},
+		{"ACME*{missing}", "", false},
+		{"ACME*{order_id", "", false},
+		{"ACME*order_id}", "", false},
+		{"ACME*{}", "", false},
+		{"ACME*{order_{id}}", "", false},
+		{"ACME ONLINE STORE*{order_id}", "", false}, // 28 characters rendered
+	}
+
+	for _, tc := range cases {
+		rendered, err := RenderDescriptor(tc.template, data)
+		if (err == nil) != tc.valid || rendered != tc.expected {
+			t.Errorf("%q: expected %q (valid=%v), got %q, %v", tc.template, tc.expected, tc.valid, rendered, err)
+		}
+	}
+}
+
+func TestValidateDescriptor(t *testing.T) {
+	for _, descriptor := range []string{`ACME "SALE"`, "ACME<1>", "CAFÉ PARIS", "ACME\tSTORE"} {
+		if err := ValidateDescriptor(descriptor); err == nil {
+			t.Errorf("Expected %q to be rejected", descriptor)
+		}
+	}
+	if err := ValidateDescriptor("ACME*SHOP 24-7 #1"); err != nil {
+		t.Errorf("Expected valid descriptor, got %v", err)
+	}
+}
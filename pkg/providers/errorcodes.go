@@ -0,0 +1,114 @@
+package providers
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"strings"
+	"sync"
+)
+
+// normalized decline/error reasons shared across providers
+const (
+	ReasonCardDeclined      = "card_declined"
+	ReasonDoNotHonor        = "do_not_honor"
+	ReasonInsufficientFunds = "insufficient_funds"
+	ReasonExpiredCard       = "expired_card"
+	ReasonInvalidCard       = "invalid_card"
+	ReasonSuspectedFraud    = "suspected_fraud"
+	ReasonIssuerUnavailable = "issuer_unavailable"
+	ReasonProcessingError   = "processing_error"
+	// ReasonAuthenticationRequired is a soft decline, the issuer approves
+	// only after the cardholder is authenticated
+	ReasonAuthenticationRequired = "authentication_required"
+	ReasonUnknown                = "unknown"
+)
+
+// ErrorCodeInfo describes a provider specific error code
+type ErrorCodeInfo struct {
+	Code        string `json:"code"`
+	Reason      string `json:"reason"`
+	Description string `json:"description"`
+	Retryable   bool   `json:"retryable"`
+}
+
+//go:embed errorcodes/*.json
+var errorCodeFiles embed.FS
+
+var (
+	errorCatalogOnce sync.Once
+	errorCatalogMu   sync.RWMutex
+	errorCatalog     map[string]map[string]ErrorCodeInfo
+)
+
+func loadErrorCatalog() {
+	errorCatalog = make(map[string]map[string]ErrorCodeInfo)
+
+	entries, _ := errorCodeFiles.ReadDir("errorcodes")
+	for _, entry := range entries {
+		data, err := errorCodeFiles.ReadFile(path.Join("errorcodes", entry.Name()))
+		if err != nil {
+			panic("providers: reading embedded error codes: " + err.Error())
+		}
+
+		var codes []ErrorCodeInfo
+		if err := json.Unmarshal(data, &codes); err != nil {
+			panic("providers: invalid embedded error codes in " + entry.Name() + ": " + err.Error())
+		}
+
+		provider := strings.TrimSuffix(entry.Name(), ".json")
+		errorCatalog[provider] = make(map[string]ErrorCodeInfo, len(codes))
+		for _, code := range codes {
+			errorCatalog[provider][code.Code] = code
+		}
+	}
+}
+
+// LookupErrorCode returns the catalog entry for a provider error code
+func LookupErrorCode(provider string, code string) (ErrorCodeInfo, bool) {
+	errorCatalogOnce.Do(loadErrorCatalog)
+
+	errorCatalogMu.RLock()
+	defer errorCatalogMu.RUnlock()
+
+	info, ok := errorCatalog[provider][code]
+	return info, ok
+}
+
+// RegisterErrorCodes adds or replaces catalog entries for a provider, letting
+// providers outside this module ship their own tables
+func RegisterErrorCodes(provider string, codes ...ErrorCodeInfo) {
+	errorCatalogOnce.Do(loadErrorCatalog)
+
+	errorCatalogMu.Lock()
+	defer errorCatalogMu.Unlock()
+
+	if errorCatalog[provider] == nil {
+		errorCatalog[provider] = make(map[string]ErrorCodeInfo, len(codes))
+	}
+	for _, code := range codes {
+		errorCatalog[provider][code.Code] = code
+	}
+}
+
+// NewCatalogError builds a normalized error for a provider code, filling in
+// reason and retryability from the catalog; unknown codes keep the message
+// and get ReasonUnknown
+func NewCatalogError(provider string, code string, message string) *PaymentError {
+	paymentError := &PaymentError{
+		Success:      false,
+		ErrorCode:    code,
+		ErrorMessage: message,
+		Reason:       ReasonUnknown,
+	}
+
+	if info, ok := LookupErrorCode(provider, code); ok {
+		paymentError.Reason = info.Reason
+		paymentError.Retryable = info.Retryable
+		if paymentError.ErrorMessage == "" {
+			paymentError.ErrorMessage = info.Description
+		}
+	}
+
+	return paymentError
+}
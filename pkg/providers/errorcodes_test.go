@@ -0,0 +1,40 @@
+package providers
+
+import "testing"
+
+func TestLookupErrorCode(t *testing.T) {
+	info, ok := LookupErrorCode("visa", "EE000051")
+	if !ok {
+		t.Fatal("Expected visa code EE000051 to be in the catalog")
+	}
+
+	if info.Reason != ReasonInsufficientFunds || info.Retryable {
+		t.Errorf("Unexpected catalog entry: %+v", info)
+	}
+
+	if _, ok := LookupErrorCode("visa", "NOPE"); ok {
+		t.Error("Expected unknown code not to be found")
+	}
+
+	if _, ok := LookupErrorCode("unknown_provider", "EE000051"); ok {
+		t.Error("Expected unknown provider not to be found")
+	}
+}
+
+func TestRegisterErrorCodes(t *testing.T) {
+	RegisterErrorCodes("acme", ErrorCodeInfo{Code: "A91", Reason: ReasonIssuerUnavailable, Description: "Issuer down", Retryable: true})
+
+	paymentError := NewCatalogError("acme", "A91", "")
+	if paymentError.Reason != ReasonIssuerUnavailable || !paymentError.Retryable {
+		t.Errorf("Expected catalog reason and retryability, got %+v", paymentError)
+	}
+
+	if paymentError.ErrorMessage != "Issuer down" {
+		t.Errorf("Expected catalog description as message, got %s", paymentError.ErrorMessage)
+	}
+
+	unknown := NewCatalogError("acme", "A00", "something odd")
+	if unknown.Reason != ReasonUnknown || unknown.ErrorMessage != "something odd" {
+		t.Errorf("Expected unknown reason with original message, got %+v", unknown)
+	}
+}
@@ -0,0 +1,81 @@
+package providers
+
+import "strings"
+
+// ErrorCode is a pgas error code that works as a sentinel error. A
+// PaymentError matches the code of its ErrorCode with errors.Is, and the
+// code of its normalized reason, e.g. a decline with reason
+// insufficient_funds matches ErrInsufficientFunds whatever the issuer's
+// own code was.
+type ErrorCode string
+
+func (c ErrorCode) Error() string {
+	return string(c)
+}
+
+// codes of errors raised by the processor itself
+const (
+	ErrInvalidRequest      ErrorCode = "INVALID_REQUEST"
+	ErrInvalidProvider     ErrorCode = "INVALID_PROVIDER"
+	ErrProcessing          ErrorCode = "PROCESSING_ERROR" // also matches reason processing_error
+	ErrParsing             ErrorCode = "PARSING_ERROR"
+	ErrReadOnly            ErrorCode = "READ_ONLY_MODE"
+	ErrProviderDraining    ErrorCode = "PROVIDER_DRAINING"
+	ErrRequestTimeout      ErrorCode = "REQUEST_TIMEOUT"
+	ErrRequestCancelled    ErrorCode = "REQUEST_CANCELLED"
+	ErrIdempotencyKeyInUse ErrorCode = "IDEMPOTENCY_KEY_IN_USE"
+	ErrIdempotencyKeyReuse ErrorCode = "IDEMPOTENCY_KEY_REUSED"
+	ErrAlreadyCaptured     ErrorCode = "ALREADY_CAPTURED"
+)
+
+// codes of the normalized reasons; ErrDeclined matches every decline
+const (
+	ErrDeclined               ErrorCode = "DECLINED"
+	ErrCardDeclined           ErrorCode = "CARD_DECLINED"
+	ErrDoNotHonor             ErrorCode = "DO_NOT_HONOR"
+	ErrInsufficientFunds      ErrorCode = "INSUFFICIENT_FUNDS"
+	ErrExpiredCard            ErrorCode = "EXPIRED_CARD"
+	ErrInvalidCard            ErrorCode = "INVALID_CARD"
+	ErrSuspectedFraud         ErrorCode = "SUSPECTED_FRAUD"
+	ErrIssuerUnavailable      ErrorCode = "ISSUER_UNAVAILABLE"
+	ErrAuthenticationRequired ErrorCode = "AUTHENTICATION_REQUIRED"
+)
+
+func (e *PaymentError) Error() string {
+	if e.ErrorMessage == "" {
+		return e.ErrorCode
+	}
+	return e.ErrorCode + ": " + e.ErrorMessage
+}
+
+// Unwrap returns the error the payment error was raised for, if any
+func (e *PaymentError) Unwrap() error {
+	return e.Err
+}
+
+// Is matches ErrorCode sentinels, see ErrorCode
+func (e *PaymentError) Is(target error) bool {
+	code, ok := target.(ErrorCode)
+	if !ok || e == nil {
+		return false
+	}
+
+	switch {
+	case string(code) == e.ErrorCode:
+		return true
+	case code == ErrDeclined:
+		return e.Declined()
+	default:
+		return e.Reason != "" && strings.ToUpper(e.Reason) == string(code)
+	}
+}
+
+// Declined reports whether the issuer refused the payment, as opposed to
+// a failure to process it
+func (e *PaymentError) Declined() bool {
+	switch e.Reason {
+	case "", ReasonIssuerUnavailable, ReasonProcessingError, ReasonUnknown:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPaymentError_Is(t *testing.T) {
+	decline := NewCatalogError("visa", "EE000051", "Insufficient funds")
+	gatewayFailure := &PaymentError{ErrorCode: "EE000011", Reason: ReasonProcessingError}
+	invalid := &PaymentError{ErrorCode: "INVALID_PROVIDER", ErrorMessage: "invalid provider name provided: 'nope'"}
+
+	testCases := []struct {
+		name   string
+		err    error
+		target error
+		match  bool
+	}{
+		{"reason of a decline", decline, ErrInsufficientFunds, true},
+		{"any decline", decline, ErrDeclined, true},
+		{"other reason", decline, ErrExpiredCard, false},
+		{"processor code", invalid, ErrInvalidProvider, true},
+		{"other processor code", invalid, ErrInvalidRequest, false},
+		{"gateway failure by reason", gatewayFailure, ErrProcessing, true},
+		{"gateway failure is no decline", gatewayFailure, ErrDeclined, false},
+		{"wrapped", fmt.Errorf("charging order 42: %w", decline), ErrInsufficientFunds, true},
+		{"other errors", decline, errors.New("INSUFFICIENT_FUNDS"), false},
+	}
+
+	for _, tc := range testCases {
+		if errors.Is(tc.err, tc.target) != tc.match {
+			t.Errorf("%s: expected errors.Is to be %v", tc.name, tc.match)
+		}
+	}
+}
+
+func TestPaymentError_Unwrap(t *testing.T) {
+	cause := errors.New("amount must be greater than 0")
+	var err error = &PaymentError{ErrorCode: "INVALID_REQUEST", ErrorMessage: cause.Error(), Err: cause}
+
+	if !errors.Is(err, cause) {
+		t.Error("Expected the cause to be unwrapped")
+	}
+
+	var paymentError *PaymentError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &paymentError) || paymentError.ErrorCode != "INVALID_REQUEST" {
+		t.Error("Expected errors.As to find the payment error")
+	}
+
+	if got := err.Error(); got != "INVALID_REQUEST: amount must be greater than 0" {
+		t.Errorf("Unexpected message: %s", got)
+	}
+	if got := (&PaymentError{ErrorCode: "DECLINED"}).Error(); got != "DECLINED" {
+		t.Errorf("Unexpected message without text: %s", got)
+	}
+}
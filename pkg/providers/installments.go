@@ -0,0 +1,23 @@
+package providers
+
+import "context"
+
+// InstallmentPlan is one way a provider can split a payment. Plans are
+// previewed before the charge; the charge names the chosen plan by ID.
+type InstallmentPlan struct {
+	ID        string  `json:"id"` // assigned by the processor when previewed
+	Provider  string  `json:"provider"`
+	Reference string  `json:"reference,omitempty"` // the provider's own plan reference
+	Count     int     `json:"count"`               // number of installments
+	Amount    float64 `json:"amount"`              // per installment
+	Fee       float64 `json:"fee"`                 // total fees and interest over the plan
+	APR       float64 `json:"apr,omitempty"`       // annual percentage rate, 0 when interest free
+	Total     float64 `json:"total"`               // what the cardholder pays in the end
+	Currency  string  `json:"currency"`
+}
+
+// InstallmentPlanner is implemented by providers that can split payments
+// into installments
+type InstallmentPlanner interface {
+	InstallmentPlans(ctx context.Context, request PaymentRequest) ([]InstallmentPlan, error)
+}
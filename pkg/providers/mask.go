@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"fmt"
+
+	"pgas/pkg/redact"
+)
+
+// String formats the request with its card number masked and its CVV and
+// PIN block redacted, so requests can be logged with any fmt verb. JSON keeps the
+// full values, it is how requests reach the gateways and the forward queue.
+func (r PaymentRequest) String() string {
+	return fmt.Sprintf("%+v", r.masked())
+}
+
+// GoString keeps %#v masked too
+func (r PaymentRequest) GoString() string {
+	return fmt.Sprintf("%#v", r.masked())
+}
+
+// masked returns a copy of the request whose card data cannot print
+func (r PaymentRequest) masked() maskedPaymentRequest {
+	masked := maskedPaymentRequest(r)
+	masked.CardNumber = redact.MaskPAN(r.CardNumber)
+	if r.CVV != "" {
+		masked.CVV = redact.Redacted
+	}
+	if r.Cryptogram != "" {
+		masked.Cryptogram = redact.Redacted
+	}
+	return masked
+}
+
+// maskedPaymentRequest has the fields but not the methods of PaymentRequest,
+// printing it does not recurse into String
+type maskedPaymentRequest PaymentRequest
+
+// String formats the credit with its card number masked
+func (r CreditRequest) String() string {
+	return fmt.Sprintf("%+v", r.masked())
+}
+
+// GoString keeps %#v masked too
+func (r CreditRequest) GoString() string {
+	return fmt.Sprintf("%#v", r.masked())
+}
+
+func (r CreditRequest) masked() maskedCreditRequest {
+	masked := maskedCreditRequest(r)
+	masked.CardNumber = redact.MaskPAN(r.CardNumber)
+	if r.Token != "" {
+		masked.Token = redact.Redacted
+	}
+	return masked
+}
+
+type maskedCreditRequest CreditRequest
+
+// String formats the PIN block with its encrypted data redacted, requests
+// printing it nested are covered too
+func (b PINBlock) String() string {
+	return fmt.Sprintf("%+v", b.masked())
+}
+
+// GoString keeps %#v redacted too
+func (b PINBlock) GoString() string {
+	return fmt.Sprintf("%#v", b.masked())
+}
+
+func (b PINBlock) masked() maskedPINBlock {
+	masked := maskedPINBlock(b)
+	if b.Block != "" {
+		masked.Block = redact.Redacted
+	}
+	return masked
+}
+
+type maskedPINBlock PINBlock
@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPaymentRequest_FormatsMasked(t *testing.T) {
+	request := PaymentRequest{Mode: "visa", Amount: 10, Currency: "USD", CardNumber: "4111111111111111", CVV: "123"}
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		output := fmt.Sprintf(verb, request)
+		if strings.Contains(output, "4111111111111111") || strings.Contains(output, "123") {
+			t.Errorf("%s: card data leaked in %s", verb, output)
+		}
+		if !strings.Contains(output, "411111******1111") {
+			t.Errorf("%s: expected masked card number, got %s", verb, output)
+		}
+	}
+
+	if request.CardNumber != "4111111111111111" {
+		t.Error("Expected formatting to leave the request untouched")
+	}
+}
+
+func TestPINBlock_FormatsRedacted(t *testing.T) {
+	pin := &PINBlock{Format: PINFormatISO0, Block: "0412AC89ABCDEF67", KeyID: "tpk-1"}
+	request := PaymentRequest{Mode: "debit", Amount: 10, Currency: "USD", CardNumber: "4111111111111111", PINBlock: pin}
+
+	for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+		for _, value := range []interface{}{request, pin, *pin} {
+			if output := fmt.Sprintf(verb, value); strings.Contains(output, "0412AC89ABCDEF67") {
+				t.Errorf("%s: PIN block leaked in %s", verb, output)
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package mastercard
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"pgas/pkg/providers"
+)
+
+// Credit simulates the mastercard MoneySend payment endpoint
+func (p *MasterCardPaymentProvider) Credit(ctx context.Context, request providers.CreditRequest) (*providers.CreditResponse, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &providers.CreditResponse{
+		Success:   true,
+		CreditID:  fmt.Sprintf("MSND-%08d", rand.IntN(100000000)),
+		Status:    providers.StatusApproved,
+		Amount:    request.Amount,
+		Currency:  request.Currency,
+		Reference: request.Reference,
+	}, nil
+}
@@ -0,0 +1,52 @@
+package mastercard
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"pgas/pkg/providers"
+)
+
+// smallest payment the simulated installment program accepts
+const minInstallmentAmount = 100
+
+// installment programs of the simulated issuer: short plans are interest
+// free, longer ones carry an APR
+var installmentPrograms = []struct {
+	count int
+	apr   float64
+}{
+	{count: 3},
+	{count: 6},
+	{count: 12, apr: 11.9},
+}
+
+// InstallmentPlans simulates the mastercard installments eligibility endpoint
+func (p *MasterCardPaymentProvider) InstallmentPlans(ctx context.Context, request providers.PaymentRequest) ([]providers.InstallmentPlan, error) {
+	if request.Amount < minInstallmentAmount {
+		return nil, errors.New("amount is below the installments minimum of 100")
+	}
+
+	plans := make([]providers.InstallmentPlan, 0, len(installmentPrograms))
+	for _, program := range installmentPrograms {
+		// interest on the average outstanding balance, half the principal
+		fee := round2(request.Amount * program.apr / 100 * float64(program.count) / 12 / 2)
+		total := request.Amount + fee
+		plans = append(plans, providers.InstallmentPlan{
+			Reference: "MCI" + strconv.Itoa(program.count),
+			Count:     program.count,
+			Amount:    round2(total / float64(program.count)),
+			Fee:       fee,
+			APR:       program.apr,
+			Total:     total,
+			Currency:  request.Currency,
+		})
+	}
+	return plans, nil
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
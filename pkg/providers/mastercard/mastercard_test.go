@@ -1,10 +1,22 @@
 package mastercard
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 
+	"pgas/pkg/bin"
+	"pgas/pkg/certification"
+	"pgas/pkg/fixtures"
 	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+	"pgas/pkg/webhooks"
 )
 
 func TestGetNewMasterCardPaymentProvider(t *testing.T) {
@@ -34,11 +46,70 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
+				CVV:         "123",
+			},
+			valid: true,
+		},
+		{
+			name: "authenticated request",
+			request: providers.PaymentRequest{
+				Mode:        "mastercard",
+				Amount:      100.00,
+				Currency:    "USD",
+				CardNumber:  "5555555555554444",
+				ExpiryMonth: "12",
+				ExpiryYear:  "2030",
 				CVV:         "123",
+				ECI:         "02",
+				Cryptogram:  "AAABBEg0VhI0VniQEjRWAAAAAAA=",
 			},
 			valid: true,
 		},
+		{
+			name: "ECI of another scheme",
+			request: providers.PaymentRequest{
+				Mode:        "mastercard",
+				Amount:      100.00,
+				Currency:    "USD",
+				CardNumber:  "5555555555554444",
+				ExpiryMonth: "12",
+				ExpiryYear:  "2030",
+				CVV:         "123",
+				ECI:         "05",
+				Cryptogram:  "AAABBEg0VhI0VniQEjRWAAAAAAA=",
+			},
+			valid: false,
+		},
+		{
+			name: "merchant-initiated request without CVV",
+			request: providers.PaymentRequest{
+				Mode:                  "mastercard",
+				Amount:                100.00,
+				Currency:              "USD",
+				CardNumber:            "5555555555554444",
+				ExpiryMonth:           "12",
+				ExpiryYear:            "2030",
+				InitiatedBy:           providers.InitiatedByMerchant,
+				StoredCredentialUsage: providers.StoredCredentialSubsequent,
+				PriorTransactionID:    "TX1234567890",
+			},
+			valid: true,
+		},
+		{
+			name: "merchant-initiated request without prior transaction",
+			request: providers.PaymentRequest{
+				Mode:                  "mastercard",
+				Amount:                100.00,
+				Currency:              "USD",
+				CardNumber:            "5555555555554444",
+				ExpiryMonth:           "12",
+				ExpiryYear:            "2030",
+				InitiatedBy:           providers.InitiatedByMerchant,
+				StoredCredentialUsage: providers.StoredCredentialSubsequent,
+			},
+			valid: false,
+		},
 		{
 			name: "zero amount",
 			request: providers.PaymentRequest{
@@ -47,7 +118,7 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -60,7 +131,7 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -73,7 +144,7 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -86,7 +157,7 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -99,7 +170,33 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "123",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
+				CVV:         "123",
+			},
+			valid: false,
+		},
+		{
+			name: "card number failing the Luhn check",
+			request: providers.PaymentRequest{
+				Mode:        "mastercard",
+				Amount:      100.00,
+				Currency:    "USD",
+				CardNumber:  "5555555555554445",
+				ExpiryMonth: "12",
+				ExpiryYear:  "2030",
+				CVV:         "123",
+			},
+			valid: false,
+		},
+		{
+			name: "card number with separators",
+			request: providers.PaymentRequest{
+				Mode:        "mastercard",
+				Amount:      100.00,
+				Currency:    "USD",
+				CardNumber:  "5555 5555 5555 4444",
+				ExpiryMonth: "12",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -112,7 +209,7 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -138,7 +235,7 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "",
 			},
 			valid: false,
@@ -151,7 +248,7 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "12",
 			},
 			valid: false,
@@ -180,7 +277,7 @@ func TestMastercardProvider_ValidateRequest(t *testing.T) {
 // 		Currency:    "USD",
 // 		CardNumber:  "5555555555554444",
 // 		ExpiryMonth: "12",
-// 		ExpiryYear:  "2025",
+// 		ExpiryYear:  "2030",
 // 		CVV:         "123",
 // 	}
 
@@ -263,6 +360,48 @@ func TestMastercardProvider_ParseSuccessResponse(t *testing.T) {
 	}
 }
 
+func TestMastercardProvider_ParseSuccessResponse_Malformed(t *testing.T) {
+	provider := GetNewMasterCardPaymentProvider()
+
+	tests := []struct {
+		name     string
+		response interface{}
+		wantErr  string
+	}{
+		{"missing transaction id", map[string]interface{}{"status": "APPROVED", "amount": "1.00"}, "transaction_id"},
+		{"numeric amount", map[string]interface{}{"transaction_id": "TX1", "amount": 1.5}, "field 'amount' must be string"},
+		{"not an object", "APPROVED", "response must be an object"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.ParseSuccessResponse(tt.response)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMastercardProvider_EstimatedAuthorization(t *testing.T) {
+	provider := GetNewMasterCardPaymentProvider()
+
+	response, err := provider.ParseSuccessResponse(map[string]interface{}{
+		"transaction_id":          "TX1234567890",
+		"status":                  "APPROVED",
+		"amount":                  "80.00",
+		"currency":                "USD",
+		"timestamp":               "2024-01-15T10:30:00Z",
+		"authorization_indicator": "PRE_AUTHORIZATION",
+	})
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	if response.AuthorizationType != providers.AuthorizationEstimated {
+		t.Errorf("Expected an estimated authorization, got %q", response.AuthorizationType)
+	}
+}
+
 func TestMastercardProvider_ParseErrorResponse(t *testing.T) {
 	provider := GetNewMasterCardPaymentProvider()
 
@@ -291,6 +430,17 @@ func TestMastercardProvider_ParseErrorResponse(t *testing.T) {
 	if errorResponse.ErrorMessage != "Insufficient funds" {
 		t.Errorf("Expected error message %s, got %s", "Insufficient funds", errorResponse.ErrorMessage)
 	}
+
+	if errorResponse.Advice != "" {
+		t.Errorf("Expected no advice without a merchant advice code, got %s", errorResponse.Advice)
+	}
+
+	mastercardError["error_code"] = "MC0091"
+	mastercardError["merchant_advice_code"] = "03"
+	errorResponse, _ = provider.ParseErrorResponse(mastercardError)
+	if errorResponse.Advice != providers.AdviceDoNotRetry || errorResponse.Retryable {
+		t.Errorf("Expected advice code 03 to forbid retries, got advice '%s' retryable %v", errorResponse.Advice, errorResponse.Retryable)
+	}
 }
 
 func TestMastercardProvider_EdgeCases(t *testing.T) {
@@ -309,7 +459,7 @@ func TestMastercardProvider_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: true,
@@ -322,7 +472,7 @@ func TestMastercardProvider_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: true,
@@ -335,7 +485,7 @@ func TestMastercardProvider_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "5555555555554444",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "1234",
 			},
 			valid: true,
@@ -367,3 +517,89 @@ func TestMastercardProvider_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestMastercardProvider_GoldenFixtures(t *testing.T) {
+	fixtures.Run(t, GetNewMasterCardPaymentProvider(), "testdata/fixtures")
+}
+
+func TestMastercardProvider_Certification(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 10, 0, 0, 0, time.UTC)
+	provider := GetNewMasterCardPaymentProvider()
+	provider.Now = func() time.Time { return now }
+
+	certification.Run(t, provider, bin.Mastercard, certification.Options{Now: now})
+}
+
+func TestMastercardProvider_InstallmentPlans(t *testing.T) {
+	provider := GetNewMasterCardPaymentProvider()
+	request := providers.PaymentRequest{Mode: "mastercard", Amount: 1200, Currency: "EUR"}
+
+	plans, err := provider.InstallmentPlans(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Expected installment plans, got error: %v", err)
+	}
+	if len(plans) != 3 {
+		t.Fatalf("Expected 3 plans, got %d", len(plans))
+	}
+
+	if plans[0].Count != 3 || plans[0].Fee != 0 || plans[0].Amount != 400 {
+		t.Errorf("Expected an interest free 3x400 plan, got %+v", plans[0])
+	}
+	if long := plans[2]; long.APR == 0 || long.Fee <= 0 || long.Total != 1200+long.Fee {
+		t.Errorf("Expected the 12 month plan to carry interest, got %+v", long)
+	}
+
+	request.Amount = 50
+	if _, err := provider.InstallmentPlans(context.Background(), request); err == nil {
+		t.Error("Expected small amounts to be ineligible for installments")
+	}
+}
+
+func TestMastercardProvider_SandboxScenarios(t *testing.T) {
+	provider := GetNewMasterCardPaymentProvider()
+	for _, scenario := range sandbox.ForProvider("mastercard") {
+		if scenario.Operation != sandbox.OperationPayment {
+			continue
+		}
+		request := providers.PaymentRequest{Mode: "mastercard", Amount: 10, Currency: "USD", CardNumber: scenario.CardNumber}
+
+		success, failure := provider.ProcessPayment(context.Background(), request)
+		if scenario.ErrorCode == "" {
+			response, err := provider.ParseSuccessResponse(success)
+			if err != nil || response.Status != scenario.Status {
+				t.Errorf("Card %s: expected status %s, got %+v (%v)", scenario.CardNumber, scenario.Status, response, err)
+			}
+			continue
+		}
+
+		paymentError, err := provider.ParseErrorResponse(failure)
+		if err != nil || paymentError.ErrorCode != scenario.ErrorCode || paymentError.Reason != scenario.Reason || paymentError.Advice != scenario.Advice {
+			t.Errorf("Card %s: expected %s/%s/%s, got %+v (%v)", scenario.CardNumber, scenario.ErrorCode, scenario.Reason, scenario.Advice, paymentError, err)
+		}
+	}
+}
+
+func TestMastercardWebhookParser(t *testing.T) {
+	parser := NewWebhookParser([]byte("whsec"))
+	body := []byte(`{"event_id": "evt-1", "type": "chargeback.created", "transaction_id": "TX1",
+		"amount": "24.44", "currency": "EUR", "reason_code": "4837", "timestamp": "2024-01-15T10:30:00Z"}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec"))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set(SignatureHeader, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	if err := parser.Verify(header, body); err != nil {
+		t.Fatalf("Expected the signature to verify, got %v", err)
+	}
+	if err := parser.Verify(http.Header{}, body); !errors.Is(err, webhooks.ErrInvalidSignature) {
+		t.Errorf("Expected a missing signature to fail, got %v", err)
+	}
+
+	event, err := parser.Parse(body)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if event.Type != webhooks.EventChargeback || event.Reference.GatewayRef != "TX1" || event.Amount != 24.44 || event.Currency != "EUR" || event.Reason != "4837" {
+		t.Errorf("Unexpected event %+v", event)
+	}
+}
@@ -2,27 +2,55 @@ package mastercard
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand/v2"
 	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+	"pgas/pkg/validation"
 	"strconv"
 	"time"
 )
 
+// raw mastercard statuses mapped to normalized statuses
+var statuses = map[string]string{
+	"APPROVED": providers.StatusApproved,
+	"PENDING":  providers.StatusPending,
+	"DECLINED": providers.StatusDeclined,
+}
+
+// mastercard ECI values, authenticated ones need an AAV
+var eciValues = providers.ECIValues{
+	"02": true,  // fully authenticated
+	"01": true,  // authentication attempted
+	"00": false, // not authenticated
+}
+
+// support contacts travel in the DE 43 merchant city field of the descriptor
+var contactRules = providers.ContactRules{PhoneMax: 13, URLMax: 13}
+
 type MasterCardPaymentProvider struct {
+	providers.ResponseVersions
+
 	Name string
+	// Now is the clock card expiry dates are checked against
+	Now      func() time.Time
+	decoding providers.Decoding
 }
 
 func GetNewMasterCardPaymentProvider() *MasterCardPaymentProvider {
-	return &MasterCardPaymentProvider{Name: "mastercard"}
+	return &MasterCardPaymentProvider{Name: "mastercard", Now: time.Now}
 }
 
 func (p *MasterCardPaymentProvider) GetName() string {
 	return p.Name
 }
 
+// SetDecoding configures how unknown response fields are handled
+func (p *MasterCardPaymentProvider) SetDecoding(decoding providers.Decoding) {
+	p.decoding = decoding
+}
+
 func (p *MasterCardPaymentProvider) ValidateRequest(request providers.PaymentRequest) error {
 
 	if request.Amount <= 0 {
@@ -37,32 +65,53 @@ func (p *MasterCardPaymentProvider) ValidateRequest(request providers.PaymentReq
 		return errors.New("currency is required")
 	}
 
-	if request.CardNumber == "" {
-		return errors.New("card number is required")
+	if err := validation.ValidateCardNumber(request.CardNumber); err != nil {
+		return err
 	}
 
-	if len(request.CardNumber) < 13 || len(request.CardNumber) > 19 {
-		return errors.New("card number must be between 13 and 19 digits")
+	if err := validation.ValidateExpiry(request.ExpiryMonth, request.ExpiryYear, p.Now()); err != nil {
+		return err
 	}
 
-	if request.ExpiryMonth == "" || request.ExpiryYear == "" {
-		return errors.New("expiry month and year are required")
-	}
-
-	if request.CVV == "" {
+	// stored credentials are charged without the CVV, it must not be kept
+	storedCredential := request.StoredCredentialUsage == providers.StoredCredentialSubsequent
+	if request.CVV == "" && !storedCredential {
 		return errors.New("CVV is required")
 	}
 
-	if len(request.CVV) < 3 || len(request.CVV) > 4 {
+	if request.CVV != "" && (len(request.CVV) < 3 || len(request.CVV) > 4 || !validation.Digits(request.CVV)) {
 		return errors.New("CVV must be 3 or 4 digits")
 	}
 
-	return nil
+	if err := providers.ValidateStoredCredential(request); err != nil {
+		return err
+	}
+
+	if err := providers.ValidateSupportContact(request.SupportContact, contactRules); err != nil {
+		return err
+	}
+
+	return providers.ValidateAuthenticationData(request, eciValues)
 }
 
 func (p *MasterCardPaymentProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
-	// Simulate a dummy error response sometimes
-	if rand.Float64() < 0.1 {
+	// sandbox test cards trigger their catalogued outcome
+	status := "APPROVED"
+	if scenario, ok := sandbox.Payment(p.Name, request.CardNumber); ok {
+		if scenario.ErrorCode != "" {
+			info, _ := providers.LookupErrorCode(p.Name, scenario.ErrorCode)
+			errorResponse := map[string]interface{}{
+				"error_code": scenario.ErrorCode,
+				"message":    info.Description,
+			}
+			if code := merchantAdviceCode(scenario.Advice); code != "" {
+				errorResponse["merchant_advice_code"] = code
+			}
+			return nil, errorResponse
+		}
+		status = scenario.Status
+	} else if rand.Float64() < 0.1 {
+		// Simulate a dummy error response sometimes
 		errorResponse := map[string]interface{}{
 			"error_code": "MC0001",
 			"message":    "Insufficient funds",
@@ -73,60 +122,71 @@ func (p *MasterCardPaymentProvider) ProcessPayment(ctx context.Context, request
 	// Simulate a dummy successful payment response
 	successResponse := map[string]interface{}{
 		"transaction_id": "TX1234567890",
-		"status":         "APPROVED",
+		"status":         status,
 		"amount":         strconv.FormatFloat(request.Amount, 'f', -1, 64),
 		"currency":       request.Currency,
 		"timestamp":      time.Now(),
 	}
+	if request.ECI != "" {
+		successResponse["eci"] = request.ECI
+		successResponse["liability_shift"] = providers.LiabilityShift(request, eciValues)
+	}
+	if request.AuthorizationType == providers.AuthorizationEstimated {
+		successResponse["authorization_indicator"] = "PRE_AUTHORIZATION"
+	}
 
 	return successResponse, nil
 }
 
 func (p *MasterCardPaymentProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
-	data, ok := response.(map[string]interface{})
-	if !ok {
-		return nil, fmt.Errorf("expected map[string]interface{}, got %T", response)
-	}
+	return p.ParseResponse(response, p.parseResponse)
+}
+
+func (p *MasterCardPaymentProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	return p.ParseError(response, p.parseError)
+}
 
-	amountStr, ok := data["amount"].(string)
-	if !ok {
-		return nil, fmt.Errorf("expected 'amount' field to be a string, got %T", data["amount"])
+// parseResponse parses the mastercard response format, version v1
+func (p *MasterCardPaymentProvider) parseResponse(response interface{}) (*providers.PaymentResponse, error) {
+	var providerResponse PaymentResponse
+	if err := p.decoding.Decode(p.Name, response, &providerResponse); err != nil {
+		return nil, err
 	}
 
-	amount, err := strconv.ParseFloat(amountStr, 64)
+	if providerResponse.TransactionID == "" {
+		return nil, errors.New("mastercard: field 'transaction_id' is required")
+	}
+	amount, err := strconv.ParseFloat(providerResponse.Amount, 64)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert 'amount' to float64: %v", err)
+		return nil, fmt.Errorf("mastercard: field 'amount' must be a decimal string, got '%s'", providerResponse.Amount)
 	}
 
-	dt, _ := data["timestamp"].(time.Time)
+	status := providers.NormalizeStatus(providerResponse.Status, statuses)
 
-	responseObj := &providers.PaymentResponse{
-		Success:       true,
-		TransactionID: data["transaction_id"].(string),
-		Status:        data["status"].(string),
-		Amount:        amount,
-		Currency:      data["currency"].(string),
-		Date:          &dt,
+	paymentResponse := &providers.PaymentResponse{
+		Success:        providers.IsSuccessStatus(status),
+		TransactionID:  providerResponse.TransactionID,
+		Status:         status,
+		RawStatus:      providerResponse.Status,
+		Amount:         amount,
+		Currency:       providerResponse.Currency,
+		Date:           &providerResponse.Timestamp,
+		LiabilityShift: providerResponse.LiabilityShift,
+	}
+	if providerResponse.AuthorizationIndicator == "PRE_AUTHORIZATION" {
+		paymentResponse.AuthorizationType = providers.AuthorizationEstimated
 	}
 
-	return responseObj, nil
+	return paymentResponse, nil
 }
 
-func (p *MasterCardPaymentProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
-	responseJSON, err := json.Marshal(response)
-	if err != nil {
-		return nil, errors.New("error marshalling error response")
-	}
-
+// parseError parses the mastercard error format, version v1
+func (p *MasterCardPaymentProvider) parseError(response interface{}) (*providers.PaymentError, error) {
 	var providerError PaymentError
-	err = json.Unmarshal(responseJSON, &providerError)
-	if err != nil {
-		return nil, errors.New("invalid response error type")
+	if err := p.decoding.Decode(p.Name, response, &providerError); err != nil {
+		return nil, err
 	}
 
-	return &providers.PaymentError{
-		Success:      false,
-		ErrorCode:    providerError.ErrorCode,
-		ErrorMessage: providerError.Message,
-	}, nil
+	return providers.NewCatalogError(p.Name, providerError.ErrorCode, providerError.Message).
+		WithAdvice(merchantAdvice[providerError.MerchantAdviceCode]), nil
 }
@@ -0,0 +1,40 @@
+package mastercard
+
+import (
+	"context"
+	"errors"
+
+	"pgas/pkg/providers"
+)
+
+// mastercard refund reason codes
+var refundReasonCodes = map[providers.RefundReason]string{
+	providers.RefundDuplicate:       "DUPL",
+	providers.RefundFraud:           "FRAD",
+	providers.RefundCustomerRequest: "CUST",
+	providers.RefundProductIssue:    "PROD",
+}
+
+// Refund simulates the mastercard refund endpoint, which accepts reason codes
+func (p *MasterCardPaymentProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	if request.TransactionID == "" {
+		return nil, errors.New("transaction id is required")
+	}
+
+	// the gateway takes its own reason codes, refunds without one are allowed
+	if _, ok := refundReasonCodes[request.Reason]; request.Reason != "" && !ok {
+		return nil, errors.New("unsupported refund reason: '" + string(request.Reason) + "'")
+	}
+
+	response := &providers.RefundResponse{
+		Success:       true,
+		RefundID:      "RF-" + request.TransactionID,
+		TransactionID: request.TransactionID,
+		Status:        providers.StatusApproved,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+		Reason:        request.Reason,
+	}
+
+	return response, nil
+}
@@ -0,0 +1,13 @@
+package mastercard
+
+import (
+	"context"
+
+	"pgas/pkg/providers"
+)
+
+// GetPaymentStatus is not offered by the simulated mastercard gateway, the
+// processor answers from its stored record instead
+func (p *MasterCardPaymentProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return nil, providers.ErrStatusQueryNotSupported
+}
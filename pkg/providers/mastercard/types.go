@@ -1,6 +1,10 @@
 package mastercard
 
-import "time"
+import (
+	"time"
+
+	"pgas/pkg/providers"
+)
 
 // request format for mastercard
 type PaymentRequest struct {
@@ -8,15 +12,50 @@ type PaymentRequest struct {
 
 // success response format for mastercard
 type PaymentResponse struct {
-	TransactionID string    `json:"transaction_id"`
-	Status        string    `json:"status"`
-	Amount        float64   `json:"amount"`
-	Currency      string    `json:"currency"`
-	Timestamp     time.Time `json:"timestamp"` // eg: "2024-01-15T10:30:00Z"
+	TransactionID  string    `json:"transaction_id"`
+	Status         string    `json:"status"`
+	Amount         string    `json:"amount"` // decimal string, eg: "24.44"
+	Currency       string    `json:"currency"`
+	Timestamp      time.Time `json:"timestamp"` // eg: "2024-01-15T10:30:00Z"
+	ECI            string    `json:"eci,omitempty"`
+	LiabilityShift bool      `json:"liability_shift,omitempty"`
+	// AuthorizationIndicator is PRE_AUTHORIZATION for estimated amounts
+	AuthorizationIndicator string `json:"authorization_indicator,omitempty"`
 }
 
 // error response format for mastercard
 type PaymentError struct {
 	ErrorCode string `json:"error_code"`
 	Message   string `json:"message"`
+	// MerchantAdviceCode is the issuer's Merchant Advice Code, e.g. "03"
+	MerchantAdviceCode string `json:"merchant_advice_code,omitempty"`
+}
+
+// Merchant Advice Codes mapped to normalized advice
+var merchantAdvice = map[string]string{
+	"01": providers.AdviceUpdateCard,
+	"02": providers.AdviceRetryLater,
+	"03": providers.AdviceDoNotRetry,
+	"21": providers.AdviceDoNotRetry, // payment cancelled by the cardholder
+}
+
+// merchantAdviceCode is the code the simulator sends for normalized advice
+func merchantAdviceCode(advice string) string {
+	switch advice {
+	case providers.AdviceUpdateCard:
+		return "01"
+	case providers.AdviceRetryLater:
+		return "02"
+	case providers.AdviceDoNotRetry:
+		return "03"
+	}
+	return ""
+}
+
+func (p *MasterCardPaymentProvider) NewWireResponse() interface{} {
+	return &PaymentResponse{}
+}
+
+func (p *MasterCardPaymentProvider) NewWireError() interface{} {
+	return &PaymentError{}
 }
@@ -0,0 +1,100 @@
+package mastercard
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/webhooks"
+)
+
+// SignatureHeader carries the base64 HMAC-SHA256 of a webhook body
+const SignatureHeader = "X-MC-Signature"
+
+// webhook notification format for mastercard
+type WebhookNotification struct {
+	EventID       string    `json:"event_id"`
+	Type          string    `json:"type"`
+	TransactionID string    `json:"transaction_id"`
+	Amount        string    `json:"amount,omitempty"` // decimal string, eg: "24.44"
+	Currency      string    `json:"currency,omitempty"`
+	ErrorCode     string    `json:"error_code,omitempty"`  // authorization.declined
+	ReasonCode    string    `json:"reason_code,omitempty"` // chargeback.created
+	Timestamp     time.Time `json:"timestamp"`             // eg: "2024-01-15T10:30:00Z"
+}
+
+// mastercard notification types mapped to normalized events
+var webhookEvents = map[string]string{
+	"authorization.approved": webhooks.EventApproved,
+	"authorization.declined": webhooks.EventDeclined,
+	"capture.completed":      webhooks.EventCaptured,
+	"refund.completed":       webhooks.EventRefunded,
+	"chargeback.created":     webhooks.EventChargeback,
+}
+
+// WebhookParser verifies and normalizes mastercard webhooks
+type WebhookParser struct {
+	Name   string
+	secret []byte
+}
+
+// NewWebhookParser verifies webhooks with the merchant's webhook secret
+func NewWebhookParser(secret []byte) *WebhookParser {
+	return &WebhookParser{Name: "mastercard", secret: secret}
+}
+
+func (p *WebhookParser) Provider() string {
+	return p.Name
+}
+
+func (p *WebhookParser) Verify(header http.Header, body []byte) error {
+	signature, err := base64.StdEncoding.DecodeString(header.Get(SignatureHeader))
+	if err != nil {
+		return webhooks.ErrInvalidSignature
+	}
+	return webhooks.VerifySHA256(p.secret, body, signature)
+}
+
+func (p *WebhookParser) Parse(body []byte) (webhooks.PaymentEvent, error) {
+	var notification WebhookNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return webhooks.PaymentEvent{}, fmt.Errorf("mastercard: invalid webhook: %v", err)
+	}
+
+	eventType, ok := webhookEvents[notification.Type]
+	if !ok {
+		return webhooks.PaymentEvent{}, fmt.Errorf("mastercard: %w: %s", webhooks.ErrUnsupportedEvent, notification.Type)
+	}
+	if notification.EventID == "" || notification.TransactionID == "" {
+		return webhooks.PaymentEvent{}, errors.New("mastercard: fields 'event_id' and 'transaction_id' are required")
+	}
+
+	event := webhooks.PaymentEvent{
+		ID:         notification.EventID,
+		Provider:   p.Name,
+		Type:       eventType,
+		Reference:  webhooks.Reference{Provider: p.Name, GatewayRef: notification.TransactionID},
+		Currency:   notification.Currency,
+		OccurredAt: notification.Timestamp,
+	}
+	if notification.Amount != "" {
+		amount, err := strconv.ParseFloat(notification.Amount, 64)
+		if err != nil {
+			return webhooks.PaymentEvent{}, fmt.Errorf("mastercard: field 'amount' must be a decimal string, got '%s'", notification.Amount)
+		}
+		event.Amount = amount
+	}
+
+	switch eventType {
+	case webhooks.EventDeclined:
+		event.Reason = providers.NewCatalogError(p.Name, notification.ErrorCode, "").Reason
+	case webhooks.EventChargeback:
+		event.Reason = notification.ReasonCode
+	}
+	return event, nil
+}
@@ -0,0 +1,163 @@
+package providers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// BuiltinVersion is the response version of a provider's own parsers
+const BuiltinVersion = "v1"
+
+// VersionedParsing is implemented by providers that parse several versions
+// of their gateway's responses, so payments keep working while the gateway
+// rolls out a new API version. The provider's own parser is tried first as
+// BuiltinVersion, then the registered ones in registration order.
+// Registering BuiltinVersion replaces the provider's own parser.
+type VersionedParsing interface {
+	RegisterResponseParser(version string, parse func(response interface{}) (*PaymentResponse, error))
+	RegisterErrorParser(version string, parse func(response interface{}) (*PaymentError, error))
+	ResponseVersions() []string
+}
+
+type versionedParser[T any] struct {
+	version string
+	parse   func(response interface{}) (T, error)
+}
+
+// ParserChain tries response parsers in order until one succeeds. Parsing
+// reads the chain without locking, so parsers may be registered while
+// payments are running. The zero value is an empty chain.
+type ParserChain[T any] struct {
+	mu      sync.Mutex // serializes registrations
+	parsers atomic.Pointer[[]versionedParser[T]]
+}
+
+// Register appends a parser of a response version; registering a version
+// again replaces its parser in place
+func (c *ParserChain[T]) Register(version string, parse func(response interface{}) (T, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var next []versionedParser[T]
+	replaced := false
+	for _, parser := range c.list() {
+		if parser.version == version {
+			parser.parse = parse
+			replaced = true
+		}
+		next = append(next, parser)
+	}
+	if !replaced {
+		next = append(next, versionedParser[T]{version: version, parse: parse})
+	}
+	c.parsers.Store(&next)
+}
+
+// Versions lists the registered versions in the order they are tried
+func (c *ParserChain[T]) Versions() []string {
+	var versions []string
+	for _, parser := range c.list() {
+		versions = append(versions, parser.version)
+	}
+	return versions
+}
+
+// Parse returns the result of the first parser that succeeds and its
+// version. When every parser fails, the error of a single parser is returned
+// as is and the errors of several are combined, each named by its version.
+func (c *ParserChain[T]) Parse(response interface{}) (T, string, error) {
+	return parseFirst(c.list(), response)
+}
+
+// parseAfter parses with builtin as BuiltinVersion ahead of the chain,
+// unless the chain replaces it
+func (c *ParserChain[T]) parseAfter(builtin func(response interface{}) (T, error), response interface{}) (T, string, error) {
+	registered := c.list()
+	for _, parser := range registered {
+		if parser.version == BuiltinVersion {
+			return parseFirst(registered, response)
+		}
+	}
+	return parseFirst(append([]versionedParser[T]{{version: BuiltinVersion, parse: builtin}}, registered...), response)
+}
+
+func (c *ParserChain[T]) list() []versionedParser[T] {
+	if parsers := c.parsers.Load(); parsers != nil {
+		return *parsers
+	}
+	return nil
+}
+
+func parseFirst[T any](parsers []versionedParser[T], response interface{}) (T, string, error) {
+	var zero T
+	if len(parsers) == 0 {
+		return zero, "", errors.New("no response parser registered")
+	}
+
+	failures := make([]string, 0, len(parsers))
+	var last error
+	for _, parser := range parsers {
+		parsed, err := parser.parse(response)
+		if err == nil {
+			return parsed, parser.version, nil
+		}
+		last = err
+		failures = append(failures, parser.version+": "+err.Error())
+	}
+
+	if len(parsers) == 1 {
+		return zero, "", last
+	}
+	return zero, "", fmt.Errorf("no response version matched (%s)", strings.Join(failures, "; "))
+}
+
+// ResponseVersions implements VersionedParsing for providers embedding it.
+// Their ParseSuccessResponse and ParseErrorResponse go through
+// ParseResponse and ParseError with their own parser as the built-in one.
+type ResponseVersions struct {
+	responses ParserChain[*PaymentResponse]
+	errors    ParserChain[*PaymentError]
+}
+
+func (v *ResponseVersions) RegisterResponseParser(version string, parse func(response interface{}) (*PaymentResponse, error)) {
+	v.responses.Register(version, parse)
+}
+
+func (v *ResponseVersions) RegisterErrorParser(version string, parse func(response interface{}) (*PaymentError, error)) {
+	v.errors.Register(version, parse)
+}
+
+// ResponseVersions lists the success response versions in the order they
+// are tried
+func (v *ResponseVersions) ResponseVersions() []string {
+	versions := v.responses.Versions()
+	for _, version := range versions {
+		if version == BuiltinVersion {
+			return versions
+		}
+	}
+	return append([]string{BuiltinVersion}, versions...)
+}
+
+// ParseResponse parses a success response, recording the matched version
+func (v *ResponseVersions) ParseResponse(response interface{}, builtin func(response interface{}) (*PaymentResponse, error)) (*PaymentResponse, error) {
+	parsed, version, err := v.responses.parseAfter(builtin, response)
+	if err != nil {
+		return nil, err
+	}
+	parsed.ResponseVersion = version
+	return parsed, nil
+}
+
+// ParseError parses an error response, recording the matched version
+func (v *ResponseVersions) ParseError(response interface{}, builtin func(response interface{}) (*PaymentError, error)) (*PaymentError, error) {
+	parsed, version, err := v.errors.parseAfter(builtin, response)
+	if err != nil {
+		return nil, err
+	}
+	parsed.ResponseVersion = version
+	return parsed, nil
+}
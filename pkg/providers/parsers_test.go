@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// versionParser parses responses carrying the given "version" key
+func versionParser(version string) func(response interface{}) (*PaymentResponse, error) {
+	return func(response interface{}) (*PaymentResponse, error) {
+		fields, _ := response.(map[string]interface{})
+		if fields["version"] != version {
+			return nil, errors.New("not a " + version + " response")
+		}
+		return &PaymentResponse{Success: true, TransactionID: version + "-tx"}, nil
+	}
+}
+
+func TestResponseVersions_TriedInOrder(t *testing.T) {
+	var versions ResponseVersions
+	versions.RegisterResponseParser("v2", versionParser("v2"))
+	versions.RegisterResponseParser("v3", versionParser("v3"))
+
+	if got := versions.ResponseVersions(); !reflect.DeepEqual(got, []string{"v1", "v2", "v3"}) {
+		t.Errorf("Expected the built-in version first, got %v", got)
+	}
+
+	for _, version := range []string{"v1", "v2", "v3"} {
+		response, err := versions.ParseResponse(map[string]interface{}{"version": version}, versionParser("v1"))
+		if err != nil {
+			t.Fatalf("Expected %s to parse, got %v", version, err)
+		}
+		if response.ResponseVersion != version || response.TransactionID != version+"-tx" {
+			t.Errorf("Expected the %s parser to match, got %+v", version, response)
+		}
+	}
+
+	_, err := versions.ParseResponse(map[string]interface{}{"version": "v4"}, versionParser("v1"))
+	if err == nil || !strings.Contains(err.Error(), "v1: not a v1 response") || !strings.Contains(err.Error(), "v3: not a v3 response") {
+		t.Errorf("Expected the failures of every version, got %v", err)
+	}
+}
+
+func TestResponseVersions_SingleVersionError(t *testing.T) {
+	var versions ResponseVersions
+
+	_, err := versions.ParseResponse(map[string]interface{}{}, versionParser("v1"))
+	if err == nil || err.Error() != "not a v1 response" {
+		t.Errorf("Expected the built-in parser's error as is, got %v", err)
+	}
+}
+
+func TestResponseVersions_ReplaceBuiltin(t *testing.T) {
+	var versions ResponseVersions
+	versions.RegisterResponseParser("v2", versionParser("v2"))
+	versions.RegisterResponseParser(BuiltinVersion, versionParser("legacy"))
+
+	if got := versions.ResponseVersions(); !reflect.DeepEqual(got, []string{"v2", "v1"}) {
+		t.Errorf("Expected the registration order, got %v", got)
+	}
+	response, err := versions.ParseResponse(map[string]interface{}{"version": "legacy"}, versionParser("v1"))
+	if err != nil || response.ResponseVersion != BuiltinVersion {
+		t.Errorf("Expected the replacement to parse as v1, got %+v (%v)", response, err)
+	}
+}
+
+func TestResponseVersions_Errors(t *testing.T) {
+	var versions ResponseVersions
+	versions.RegisterErrorParser("v2", func(response interface{}) (*PaymentError, error) {
+		return &PaymentError{ErrorCode: "V2"}, nil
+	})
+
+	paymentError, err := versions.ParseError("payload", func(response interface{}) (*PaymentError, error) {
+		return nil, errors.New("not v1")
+	})
+	if err != nil || paymentError.ErrorCode != "V2" || paymentError.ResponseVersion != "v2" {
+		t.Errorf("Expected the v2 error parser to match, got %+v (%v)", paymentError, err)
+	}
+}
@@ -0,0 +1,80 @@
+package providers
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+)
+
+// ISO 9564 PIN block formats
+const (
+	PINFormatISO0 = "ISO-0" // PIN XOR PAN, TDES
+	PINFormatISO1 = "ISO-1" // PIN with a transaction field, no PAN
+	PINFormatISO3 = "ISO-3" // ISO-0 with random padding
+	PINFormatISO4 = "ISO-4" // AES, 16 byte blocks
+)
+
+// PINBlock is the cardholder's PIN encrypted by the terminal or PIN pad,
+// required by debit networks for PIN-verified payments. The processor only
+// ever sees the encrypted block: it is translated from the terminal's key
+// to the provider's by a PINTranslator, sent to the gateway and dropped.
+// It is never stored, logged or queued.
+type PINBlock struct {
+	Format string `json:"format" validate:"required,oneof=ISO-0 ISO-1 ISO-3 ISO-4"`
+	// Block is the encrypted block in hex
+	Block string `json:"block" validate:"required"`
+	// KeyID names the zone key the block is encrypted under, e.g. the
+	// terminal PIN key or a provider's zone PIN key
+	KeyID string `json:"key_id" validate:"required"`
+	// KSN is the key serial number of blocks encrypted under DUKPT
+	// derived keys
+	KSN string `json:"ksn,omitempty"`
+}
+
+// ValidatePINBlock checks the format of a PIN block and the length of its
+// encrypted data, 8 bytes for the TDES formats and 16 for ISO-4
+func ValidatePINBlock(pin *PINBlock) error {
+	if pin == nil {
+		return nil
+	}
+
+	size := 8
+	switch pin.Format {
+	case PINFormatISO0, PINFormatISO1, PINFormatISO3:
+	case PINFormatISO4:
+		size = 16
+	default:
+		return fmt.Errorf("unknown PIN block format '%s'", pin.Format)
+	}
+
+	block, err := hex.DecodeString(pin.Block)
+	if err != nil || len(block) != size {
+		return fmt.Errorf("%s PIN blocks must be %d bytes of hex", pin.Format, size)
+	}
+	if pin.KeyID == "" {
+		return fmt.Errorf("PIN block key id is required")
+	}
+	if pin.KSN != "" {
+		if ksn, err := hex.DecodeString(pin.KSN); err != nil || len(ksn) != 10 {
+			return fmt.Errorf("KSN must be 10 bytes of hex")
+		}
+	}
+	return nil
+}
+
+// PINAcceptor is implemented by providers of debit networks that take
+// online PINs. PINKey names the zone key the provider's PIN blocks must be
+// encrypted under, PINFormats lists the formats its gateway accepts.
+// Requests carrying a PIN block are only sent to PIN acceptors.
+type PINAcceptor interface {
+	PINKey() string
+	PINFormats() []string
+}
+
+// PINTranslator re-encrypts PIN blocks from one zone key to another, and
+// possibly to another format, without the clear PIN leaving it. The card
+// number is passed for the formats that bind the PIN to it. Production
+// implementations are backed by an HSM's PIN translation command.
+type PINTranslator interface {
+	TranslatePIN(ctx context.Context, pin PINBlock, cardNumber, keyID, format string) (PINBlock, error)
+}
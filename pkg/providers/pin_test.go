@@ -0,0 +1,27 @@
+package providers
+
+import "testing"
+
+func TestValidatePINBlock(t *testing.T) {
+	cases := []struct {
+		name  string
+		pin   *PINBlock
+		valid bool
+	}{
+		{"no PIN", nil, true},
+		{"ISO-0", &PINBlock{Format: PINFormatISO0, Block: "0412AC89ABCDEF67", KeyID: "tpk-1"}, true},
+		{"ISO-4", &PINBlock{Format: PINFormatISO4, Block: "0412AC89ABCDEF670412AC89ABCDEF67", KeyID: "tpk-1"}, true},
+		{"DUKPT", &PINBlock{Format: PINFormatISO0, Block: "0412AC89ABCDEF67", KeyID: "bdk-1", KSN: "FFFF9876543210E00001"}, true},
+		{"unknown format", &PINBlock{Format: "ISO-2", Block: "0412AC89ABCDEF67", KeyID: "tpk-1"}, false},
+		{"short block", &PINBlock{Format: PINFormatISO4, Block: "0412AC89ABCDEF67", KeyID: "tpk-1"}, false},
+		{"not hex", &PINBlock{Format: PINFormatISO0, Block: "0412AC89ABCDEFZZ", KeyID: "tpk-1"}, false},
+		{"no key", &PINBlock{Format: PINFormatISO0, Block: "0412AC89ABCDEF67"}, false},
+		{"bad KSN", &PINBlock{Format: PINFormatISO0, Block: "0412AC89ABCDEF67", KeyID: "bdk-1", KSN: "FFFF"}, false},
+	}
+
+	for _, tc := range cases {
+		if err := ValidatePINBlock(tc.pin); (err == nil) != tc.valid {
+			t.Errorf("%s: expected valid=%v, got %v", tc.name, tc.valid, err)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package providers
+
+import "context"
+
+// Quote is an indicative, non-binding price for processing a payment on a
+// provider. Ineligible quotes carry the reason instead of a price.
+type Quote struct {
+	Provider string  `json:"provider"`
+	Eligible bool    `json:"eligible"`
+	Reason   string  `json:"reason,omitempty"`
+	Fee      float64 `json:"fee"`      // expected total cost in the request currency
+	FXRate   float64 `json:"fx_rate"`  // request currency to settlement currency, 1 without conversion
+	Currency string  `json:"currency"` // settlement currency
+	// SettlementAmount is the request amount after conversion and fees
+	SettlementAmount float64 `json:"settlement_amount"`
+	Source           string  `json:"source"` // "provider" when quoted by the gateway, "estimate" otherwise
+}
+
+// Quoter is implemented by providers that can price a payment before it is
+// submitted, e.g. through an FX or pricing endpoint
+type Quoter interface {
+	Quote(ctx context.Context, request PaymentRequest) (*Quote, error)
+}
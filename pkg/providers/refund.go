@@ -0,0 +1,106 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrRefundNotSupported is returned by providers whose gateway cannot refund
+var ErrRefundNotSupported = errors.New("refunds are not supported")
+
+// RefundReason is the normalized driver of a refund
+type RefundReason string
+
+const (
+	RefundDuplicate       RefundReason = "duplicate"
+	RefundFraud           RefundReason = "fraud"
+	RefundCustomerRequest RefundReason = "customer_request"
+	RefundProductIssue    RefundReason = "product_issue"
+)
+
+// refund reason categories used in finance reporting
+const (
+	RefundCategoryProcessing    = "processing"  // charged in error
+	RefundCategoryRisk          = "risk"        // fraudulent charge
+	RefundCategoryCustomer      = "customer"    // buyer changed their mind
+	RefundCategoryFulfillment   = "fulfillment" // goods or service problem
+	RefundCategoryUncategorized = "uncategorized"
+)
+
+var refundCategories = map[RefundReason]string{
+	RefundDuplicate:       RefundCategoryProcessing,
+	RefundFraud:           RefundCategoryRisk,
+	RefundCustomerRequest: RefundCategoryCustomer,
+	RefundProductIssue:    RefundCategoryFulfillment,
+}
+
+// Valid reports whether the reason is one of the normalized reasons
+func (r RefundReason) Valid() bool {
+	_, ok := refundCategories[r]
+	return ok
+}
+
+// Category groups the reason for reporting; refunds without a reason are
+// uncategorized
+func (r RefundReason) Category() string {
+	if category, ok := refundCategories[r]; ok {
+		return category
+	}
+	return RefundCategoryUncategorized
+}
+
+// RefundRequest returns funds of an earlier payment
+type RefundRequest struct {
+	Mode          string       `json:"mode" validate:"required"` // provider that processed the payment
+	TransactionID string       `json:"transaction_id" validate:"required"`
+	Amount        float64      `json:"amount" validate:"required,gt=0"`
+	Currency      string       `json:"currency" validate:"required,len=3"`
+	Reason        RefundReason `json:"reason,omitempty"`
+	Note          string       `json:"note,omitempty"` // free text for the merchant's records
+}
+
+// Validate checks the fields every refund needs, requireReason makes the
+// reason mandatory
+func (r RefundRequest) Validate(requireReason bool) error {
+	if r.TransactionID == "" {
+		return fmt.Errorf("transaction id is required")
+	}
+	if r.Amount <= 0 {
+		return fmt.Errorf("amount must be greater than 0")
+	}
+	if r.Currency == "" {
+		return fmt.Errorf("currency is required")
+	}
+	if r.Reason == "" {
+		if requireReason {
+			return fmt.Errorf("refund reason is required")
+		}
+		return nil
+	}
+	if !r.Reason.Valid() {
+		return fmt.Errorf("unsupported refund reason: '%s'", r.Reason)
+	}
+	return nil
+}
+
+// normalized refund result
+type RefundResponse struct {
+	Success       bool         `json:"success"`
+	RefundID      string       `json:"refund_id"`
+	TransactionID string       `json:"transaction_id"`
+	Status        string       `json:"status"`
+	Amount        float64      `json:"amount"`
+	Currency      string       `json:"currency"`
+	Reason        RefundReason `json:"reason,omitempty"`
+	// Remaining is what can still be refunded of the payment afterwards,
+	// known for payments in the transaction store
+	Remaining *float64 `json:"remaining,omitempty"`
+}
+
+// Refunder is the refund part of Provider, for code that only refunds.
+// Providers whose gateway accepts reason codes map RefundRequest.Reason
+// onto them.
+type Refunder interface {
+	Refund(ctx context.Context, request RefundRequest) (*RefundResponse, error)
+}
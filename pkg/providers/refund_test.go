@@ -0,0 +1,27 @@
+package providers
+
+import "testing"
+
+func TestRefundReason_Categories(t *testing.T) {
+	cases := map[RefundReason]string{
+		RefundDuplicate:       RefundCategoryProcessing,
+		RefundFraud:           RefundCategoryRisk,
+		RefundCustomerRequest: RefundCategoryCustomer,
+		RefundProductIssue:    RefundCategoryFulfillment,
+		"":                    RefundCategoryUncategorized,
+	}
+
+	for reason, expected := range cases {
+		if category := reason.Category(); category != expected {
+			t.Errorf("Expected category %s for reason '%s', got %s", expected, reason, category)
+		}
+	}
+
+	request := RefundRequest{TransactionID: "TX1", Amount: 10, Currency: "USD"}
+	if err := request.Validate(false); err != nil {
+		t.Errorf("Expected optional reason to be accepted, got %v", err)
+	}
+	if err := request.Validate(true); err == nil {
+		t.Error("Expected required reason to be enforced")
+	}
+}
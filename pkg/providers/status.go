@@ -0,0 +1,44 @@
+package providers
+
+import "errors"
+
+// normalized payment statuses
+const (
+	StatusApproved = "APPROVED"
+	StatusPending  = "PENDING"
+	StatusDeclined = "DECLINED"
+	// StatusUnknown marks a provider status pgas does not recognize; the
+	// payment outcome must be resolved later instead of guessed
+	StatusUnknown = "UNKNOWN"
+	// StatusDeferred marks a payment accepted locally while providers were
+	// unreachable, it is submitted later by the store-and-forward queue
+	StatusDeferred = "DEFERRED"
+	// StatusRequiresAction marks a soft-declined payment waiting for the
+	// cardholder to complete a 3-D Secure challenge
+	StatusRequiresAction = "REQUIRES_ACTION"
+	// StatusExpired marks a PENDING or REQUIRES_ACTION payment that was not
+	// settled within its expiry window
+	StatusExpired = "EXPIRED"
+	// StatusVoided marks a partial approval the processor reversed, see
+	// PartialApprovalVoid
+	StatusVoided = "VOIDED"
+)
+
+// NormalizeStatus maps a raw provider status through the provider's status
+// table, returning StatusUnknown for anything not listed
+func NormalizeStatus(raw string, statuses map[string]string) string {
+	if normalized, ok := statuses[raw]; ok {
+		return normalized
+	}
+	return StatusUnknown
+}
+
+// IsSuccessStatus reports whether the normalized status means the gateway
+// accepted the payment
+func IsSuccessStatus(status string) bool {
+	return status == StatusApproved || status == StatusPending
+}
+
+// ErrStatusQueryNotSupported is returned by providers whose gateway cannot
+// look up earlier payments
+var ErrStatusQueryNotSupported = errors.New("payment status queries are not supported")
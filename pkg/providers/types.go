@@ -3,34 +3,194 @@ package providers
 import (
 	"context"
 	"time"
+
+	"pgas/pkg/redact"
 )
 
-// normalized request format for internal/user purpose
+// normalized request format for internal/user purpose; the validate tags
+// are the format checks of API payloads, see schema.Tags, providers still
+// apply their own rules in ValidateRequest
 type PaymentRequest struct {
-	Mode        string  `json:"mode"`
-	Amount      float64 `json:"amount"`
-	Currency    string  `json:"currency"`
-	CardNumber  string  `json:"card_number"`
-	ExpiryMonth string  `json:"expiry_month"`
-	ExpiryYear  string  `json:"expiry_year"`
-	CVV         string  `json:"cvv"`
+	// Mode names the provider; card payments may leave it empty for the
+	// processor to pick the provider from the card's BIN
+	Mode string `json:"mode"`
+	// IdempotencyKey makes retries of the same payment safe, a repeated key
+	// returns the first outcome instead of charging again
+	IdempotencyKey string  `json:"idempotency_key,omitempty" validate:"max=255"`
+	Amount         float64 `json:"amount" validate:"required,gt=0"`
+	Currency       string  `json:"currency" validate:"required,len=3"`
+	CardNumber     string  `json:"card_number" validate:"digits,min=12,max=19"`
+	ExpiryMonth    string  `json:"expiry_month" validate:"digits,max=2"`
+	ExpiryYear     string  `json:"expiry_year" validate:"digits,min=2,max=4"`
+	CVV            string  `json:"cvv" validate:"digits,min=3,max=4"`
+	// VPA is the UPI virtual payment address, e.g. name@bank, of payments
+	// made without a card
+	VPA string `json:"vpa,omitempty"`
+	// bank account debited by ACH payments, identified by the ABA routing
+	// number of the bank and the account number; AccountType is checking
+	// or savings, empty means checking
+	AccountNumber string `json:"account_number,omitempty" validate:"digits,min=4,max=17"`
+	RoutingNumber string `json:"routing_number,omitempty" validate:"digits,len=9"`
+	AccountType   string `json:"account_type,omitempty" validate:"oneof=checking savings"`
+
+	Method        string     `json:"method,omitempty"`          // payment method, e.g. upi_collect, ach or bnpl; empty means card
+	SubMerchantID string     `json:"sub_merchant_id,omitempty"` // seller of record for platform charges
+	BusinessLine  string     `json:"business_line,omitempty"`   // e.g. retail or travel, selects a merchant account
+	Descriptor    string     `json:"descriptor,omitempty"`      // statement descriptor, may be a template
+	Overrides     *Overrides `json:"overrides,omitempty"`
+	// OrderData fills the placeholders of descriptor templates, e.g.
+	// order_id for "ACME*{order_id}"
+	OrderData map[string]string `json:"order_data,omitempty"`
+	// Metadata and Tags are the merchant's business dimensions, such as
+	// campaign, channel or app_version, kept on the transaction for
+	// reporting
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	// InstallmentPlanID charges with a plan from PreviewInstallments
+	InstallmentPlanID string `json:"installment_plan_id,omitempty"`
+	// Installments is the chosen plan, filled in by the processor for the
+	// provider
+	Installments *InstallmentPlan `json:"installments,omitempty"`
+	// MerchantAccount is the MID and TID the payment is submitted under,
+	// filled in by the processor for providers with merchant accounts
+	MerchantAccount *MerchantAccount `json:"merchant_account,omitempty"`
+	// SupportContact is sent along with the descriptor by providers whose
+	// scheme supports it
+	SupportContact *SupportContact `json:"support_contact,omitempty"`
+	// LatencyBudgetMs bounds the whole payment in milliseconds, 0 uses the
+	// processor timeouts only
+	LatencyBudgetMs int `json:"latency_budget_ms,omitempty" validate:"min=0"`
+	// Authentication is the 3-D Secure result, filled by the processor's
+	// authenticator or supplied by callers running their own MPI
+	Authentication *Authentication `json:"authentication,omitempty"`
+
+	// cardholder authentication data sent with the authorization, taken
+	// from 3-D Secure or from wallet payment tokens
+	ECI             string `json:"eci,omitempty"`
+	Cryptogram      string `json:"cryptogram,omitempty"` // CAVV/AAV or token cryptogram
+	DSTransactionID string `json:"ds_transaction_id,omitempty"`
+	// PINBlock is the encrypted PIN of PIN-verified debit payments, only
+	// accepted by providers implementing PINAcceptor
+	PINBlock *PINBlock `json:"pin_block,omitempty"`
+
+	// stored credential indicators required by the schemes for recurring and
+	// merchant-initiated charges, see ValidateStoredCredential
+	InitiatedBy           string `json:"initiated_by,omitempty"` // empty means customer
+	StoredCredentialUsage string `json:"stored_credential_usage,omitempty"`
+	PriorTransactionID    string `json:"prior_transaction_id,omitempty"` // initial customer-initiated payment
+
+	// ISO 3166-1 alpha-2 countries of the merchant and the card issuer,
+	// they decide where the payment's data may be stored
+	MerchantCountry string `json:"merchant_country,omitempty" validate:"len=2"`
+	IssuerCountry   string `json:"issuer_country,omitempty" validate:"len=2"`
+
+	// AuthorizationType is final or estimated, empty means final. Providers
+	// send the scheme's indicator for estimated authorizations, and the
+	// processor lets captures exceed the estimate within a tolerance.
+	AuthorizationType string `json:"authorization_type,omitempty" validate:"oneof=final estimated"`
+	// PartialApproval is what to do when the issuer approves less than
+	// Amount, accept or void; empty leaves it to the sub-merchant's and then
+	// the processor's configuration
+	PartialApproval string `json:"partial_approval,omitempty" validate:"oneof=accept void"`
+
+	// FallbackProviders are tried in order when Mode's provider fails to
+	// process the payment, replacing the processor's configured fallbacks
+	FallbackProviders []string `json:"fallback_providers,omitempty"`
+}
+
+// per-payment overrides of processor behavior, only honored when the
+// processor's override authorizer accepts the credentials
+type Overrides struct {
+	Actor          string `json:"actor" validate:"required"`
+	Token          string `json:"-"`
+	SkipFraudCheck bool   `json:"skip_fraud_check,omitempty"`
+	ForceProvider  string `json:"force_provider,omitempty"`
+	DisableRetries bool   `json:"disable_retries,omitempty"`
 }
 
 // normalized success response format for internal/user purpose
 type PaymentResponse struct {
 	Success       bool       `json:"success"`
 	TransactionID string     `json:"transaction_id"`
-	Status        string     `json:"status"`
+	Status        string     `json:"status"`               // normalized, see StatusApproved etc.
+	RawStatus     string     `json:"raw_status,omitempty"` // status as returned by the provider, or why an UNKNOWN call went unanswered
 	Amount        float64    `json:"amount,omitempty"`
 	Currency      string     `json:"currency,omitempty"`
 	Date          *time.Time `json:"date,omitempty"`
+	SubMerchantID string     `json:"sub_merchant_id,omitempty"`
+	// MerchantAccount names the provider's merchant account the payment was
+	// submitted under, see MerchantAccount
+	MerchantAccount string `json:"merchant_account,omitempty"`
+	// GatewayReference is the provider's own reference of the payment.
+	// Providers parse it into TransactionID; the processor then gives the
+	// payment a local TransactionID, gateways need not hand out unique ones.
+	GatewayReference string `json:"gateway_reference,omitempty"`
+	// Card is the card charged, always written masked, see
+	// redact.MaskedCard
+	Card redact.MaskedCard `json:"card,omitempty"`
+	// Provider names the provider that handled the payment, a fallback
+	// when the requested one failed
+	Provider string `json:"provider,omitempty"`
+	// SettlesAt is when a PENDING bank transfer is expected to settle; its
+	// status is followed through the provider's status queries
+	SettlesAt *time.Time `json:"settles_at,omitempty"`
+	// ReturnCode is the bank's reason for returning a transfer it first
+	// accepted, e.g. ACH R01, set with StatusDeclined
+	ReturnCode string `json:"return_code,omitempty"`
+	// LiabilityShift reports that the issuer carries fraud liability because
+	// the payment was authenticated
+	LiabilityShift bool `json:"liability_shift,omitempty"`
+	// AuthorizationType is estimated when the gateway acknowledged an
+	// estimated authorization
+	AuthorizationType string `json:"authorization_type,omitempty"`
+	// PartialApproval reports that the issuer approved less than requested,
+	// Amount is then the approved and RequestedAmount the requested amount
+	PartialApproval bool    `json:"partial_approval,omitempty"`
+	RequestedAmount float64 `json:"requested_amount,omitempty"`
+	// Challenge is set with StatusRequiresAction, the front end presents it
+	// to the cardholder
+	Challenge *Challenge `json:"challenge,omitempty"`
+	// Extra holds fields computed by the processor's enrichers, such as
+	// loyalty points or internal ledger ids
+	Extra map[string]string `json:"extra,omitempty"`
+	// Timings breaks down where the processing time went, when enabled
+	Timings *Timings `json:"timings,omitempty"`
+	// IdempotentReplay marks the stored outcome of an earlier payment with
+	// the same idempotency key
+	IdempotentReplay bool `json:"idempotent_replay,omitempty"`
+	// ResponseVersion is the gateway response version the provider parsed,
+	// see VersionedParsing
+	ResponseVersion string `json:"response_version,omitempty"`
+}
+
+// Timings is the processing time of a payment per stage, in milliseconds.
+// Fraud covers the risk checks before the gateway call such as screening
+// and 3-D Secure; gateway covers every attempt including retries.
+type Timings struct {
+	ValidationMs float64 `json:"validation_ms"`
+	FraudMs      float64 `json:"fraud_ms"`
+	GatewayMs    float64 `json:"gateway_ms"`
+	TotalMs      float64 `json:"total_ms"`
 }
 
-// normalized error response format for internal/user purpose
+// normalized error response format for internal/user purpose; it is an
+// error matching the ErrorCode sentinels
 type PaymentError struct {
 	Success      bool   `json:"success"`
 	ErrorCode    string `json:"error_code"`
 	ErrorMessage string `json:"error_message"`
+	Reason       string `json:"reason,omitempty"` // normalized reason, see LookupErrorCode
+	Retryable    bool   `json:"retryable,omitempty"`
+	// Advice is the issuer's advice on retrying, e.g. AdviceDoNotRetry
+	Advice string `json:"advice,omitempty"`
+	// Timings breaks down where the processing time went, when enabled
+	Timings *Timings `json:"timings,omitempty"`
+	// ResponseVersion is the gateway response version the provider parsed,
+	// see VersionedParsing
+	ResponseVersion string `json:"response_version,omitempty"`
+	// Err is the error the payment error was raised for, e.g. a validation
+	// or parse error or the context's error; it is not serialized
+	Err error `json:"-"`
 }
 
 type Provider interface {
@@ -39,4 +199,11 @@ type Provider interface {
 	ProcessPayment(ctx context.Context, request PaymentRequest) (interface{}, interface{})
 	ParseSuccessResponse(response interface{}) (*PaymentResponse, error)
 	ParseErrorResponse(response interface{}) (*PaymentError, error)
+	// Refund returns funds of an earlier payment, in full or in part.
+	// Gateways without refunds return ErrRefundNotSupported.
+	Refund(ctx context.Context, request RefundRequest) (*RefundResponse, error)
+	// GetPaymentStatus looks up the current state of an earlier payment by
+	// its transaction id. Gateways without lookups return
+	// ErrStatusQueryNotSupported.
+	GetPaymentStatus(ctx context.Context, transactionID string) (*PaymentResponse, error)
 }
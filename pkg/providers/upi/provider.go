@@ -0,0 +1,169 @@
+package upi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"regexp"
+	"strconv"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+)
+
+// per-transaction limit of UPI payments in rupees
+const maxAmount = 100000
+
+// a handle of letters, digits, dots, hyphens and underscores, and the
+// payment service provider after the @, e.g. "jane.doe@okbank"
+var vpaPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]{1,255}@[a-zA-Z][a-zA-Z0-9]{1,63}$`)
+
+var ist = time.FixedZone("IST", 5*60*60+30*60)
+
+type UPIPaymentProvider struct {
+	providers.ResponseVersions
+
+	Name     string
+	decoding providers.Decoding
+}
+
+func GetNewUPIPaymentProvider() *UPIPaymentProvider {
+	return &UPIPaymentProvider{Name: "upi"}
+}
+
+func (p *UPIPaymentProvider) GetName() string {
+	return p.Name
+}
+
+// SetDecoding configures how unknown response fields are handled
+func (p *UPIPaymentProvider) SetDecoding(decoding providers.Decoding) {
+	p.decoding = decoding
+}
+
+// ValidateVPA checks the format of a virtual payment address
+func ValidateVPA(vpa string) error {
+	if vpa == "" {
+		return errors.New("VPA is required")
+	}
+	if !vpaPattern.MatchString(vpa) {
+		return fmt.Errorf("VPA '%s' must look like name@bank", vpa)
+	}
+	return nil
+}
+
+// ValidateRequest checks UPI collect payments: an INR amount within the
+// UPI limit and the payer's VPA instead of card data
+func (p *UPIPaymentProvider) ValidateRequest(request providers.PaymentRequest) error {
+
+	if request.Method != "" && request.Method != sandbox.MethodUPICollect {
+		return fmt.Errorf("payment method '%s' is not supported by upi", request.Method)
+	}
+
+	if request.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+
+	if request.Amount > maxAmount {
+		return errors.New("amount exceeds the UPI limit of 100,000")
+	}
+
+	if request.Currency != "INR" {
+		return errors.New("upi payments must be in INR")
+	}
+
+	if request.CardNumber != "" || request.CVV != "" || request.ExpiryMonth != "" || request.ExpiryYear != "" {
+		return errors.New("upi payments must not carry card data")
+	}
+
+	return ValidateVPA(request.VPA)
+}
+
+func (p *UPIPaymentProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	// sandbox VPAs trigger their catalogued outcome
+	status := "SUCCESS"
+	if scenario, ok := sandbox.Payment(p.Name, request.VPA); ok {
+		if scenario.ErrorCode != "" {
+			info, _ := providers.LookupErrorCode(p.Name, scenario.ErrorCode)
+			errorResponse := map[string]interface{}{
+				"response_code": scenario.ErrorCode,
+				"message":       info.Description,
+			}
+			return nil, errorResponse
+		}
+		if scenario.Status == providers.StatusPending {
+			status = "PENDING"
+		}
+	} else if rand.Float64() < 0.1 {
+		// Simulate a dummy error response sometimes
+		errorResponse := map[string]interface{}{
+			"response_code": "U30",
+			"message":       "Debit has failed",
+		}
+		return nil, errorResponse
+	}
+
+	// Simulate a dummy successful payment response
+	successResponse := map[string]interface{}{
+		"txn_id":    fmt.Sprintf("UPI%012d", rand.Int64N(1e12)),
+		"rrn":       fmt.Sprintf("%012d", rand.Int64N(1e12)),
+		"status":    status,
+		"amount":    strconv.FormatFloat(request.Amount, 'f', 2, 64),
+		"currency":  request.Currency,
+		"payer_vpa": request.VPA,
+		"timestamp": time.Now().In(ist).Format(time.RFC3339),
+	}
+
+	return successResponse, nil
+}
+
+func (p *UPIPaymentProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	return p.ParseResponse(response, p.parseResponse)
+}
+
+func (p *UPIPaymentProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	return p.ParseError(response, p.parseError)
+}
+
+// parseResponse parses the upi response format, version v1
+func (p *UPIPaymentProvider) parseResponse(response interface{}) (*providers.PaymentResponse, error) {
+	var providerResponse PaymentResponse
+	if err := p.decoding.Decode(p.Name, response, &providerResponse); err != nil {
+		return nil, err
+	}
+
+	if providerResponse.TxnID == "" {
+		return nil, errors.New("upi: field 'txn_id' is required")
+	}
+	amount, err := strconv.ParseFloat(providerResponse.Amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("upi: field 'amount' must be a decimal string, got '%s'", providerResponse.Amount)
+	}
+	timestamp, err := time.Parse(time.RFC3339, providerResponse.Timestamp)
+	if err != nil {
+		return nil, fmt.Errorf("upi: field 'timestamp' must be an RFC 3339 time, got '%s'", providerResponse.Timestamp)
+	}
+
+	status := providers.NormalizeStatus(providerResponse.Status, statuses)
+
+	return &providers.PaymentResponse{
+		Success:       providers.IsSuccessStatus(status),
+		TransactionID: providerResponse.TxnID,
+		Status:        status,
+		RawStatus:     providerResponse.Status,
+		Amount:        amount,
+		Currency:      providerResponse.Currency,
+		Date:          &timestamp,
+	}, nil
+}
+
+// parseError parses the upi error format, version v1
+func (p *UPIPaymentProvider) parseError(response interface{}) (*providers.PaymentError, error) {
+	var providerError PaymentError
+	if err := p.decoding.Decode(p.Name, response, &providerError); err != nil {
+		return nil, err
+	}
+
+	return providers.NewCatalogError(p.Name, providerError.ResponseCode, providerError.Message), nil
+}
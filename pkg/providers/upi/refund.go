@@ -0,0 +1,25 @@
+package upi
+
+import (
+	"context"
+	"errors"
+
+	"pgas/pkg/providers"
+)
+
+// Refund simulates the upi refund endpoint; refunds are credited back to
+// the payer VPA of the original payment
+func (p *UPIPaymentProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	if request.TransactionID == "" {
+		return nil, errors.New("transaction id is required")
+	}
+
+	return &providers.RefundResponse{
+		Success:       true,
+		RefundID:      "UPIRF" + request.TransactionID,
+		TransactionID: request.TransactionID,
+		Status:        providers.StatusApproved,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
@@ -0,0 +1,13 @@
+package upi
+
+import (
+	"context"
+
+	"pgas/pkg/providers"
+)
+
+// GetPaymentStatus is not offered by the simulated upi gateway, the
+// processor answers from its stored record instead
+func (p *UPIPaymentProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return nil, providers.ErrStatusQueryNotSupported
+}
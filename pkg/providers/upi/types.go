@@ -0,0 +1,35 @@
+package upi
+
+import "pgas/pkg/providers"
+
+// success response format for upi, amounts are rupees with two decimals
+type PaymentResponse struct {
+	TxnID     string `json:"txn_id"`
+	RRN       string `json:"rrn,omitempty"` // retrieval reference number shown to the customer
+	Status    string `json:"status"`
+	Amount    string `json:"amount"`
+	Currency  string `json:"currency"`
+	PayerVPA  string `json:"payer_vpa"`
+	Timestamp string `json:"timestamp"` // RFC 3339 in IST, eg: "2024-01-15T16:00:00+05:30"
+}
+
+// error response format for upi, codes are NPCI response codes
+type PaymentError struct {
+	ResponseCode string `json:"response_code"`
+	Message      string `json:"message"`
+}
+
+// a collect request stays PENDING until the customer approves it in their
+// UPI app
+var statuses = map[string]string{
+	"SUCCESS": providers.StatusApproved,
+	"PENDING": providers.StatusPending,
+}
+
+func (p *UPIPaymentProvider) NewWireResponse() interface{} {
+	return &PaymentResponse{}
+}
+
+func (p *UPIPaymentProvider) NewWireError() interface{} {
+	return &PaymentError{}
+}
@@ -0,0 +1,122 @@
+package upi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"pgas/pkg/fixtures"
+	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+)
+
+func TestGetNewUPIPaymentProvider(t *testing.T) {
+	provider := GetNewUPIPaymentProvider()
+	if provider.GetName() != "upi" {
+		t.Errorf("Expected provider name 'upi', got: %s", provider.GetName())
+	}
+}
+
+func TestUPIProvider_ValidateRequest(t *testing.T) {
+	provider := GetNewUPIPaymentProvider()
+
+	valid := providers.PaymentRequest{
+		Mode:     "upi",
+		Method:   "upi_collect",
+		Amount:   499.00,
+		Currency: "INR",
+		VPA:      "jane.doe@okbank",
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(*providers.PaymentRequest)
+		valid  bool
+	}{
+		{"valid request", func(r *providers.PaymentRequest) {}, true},
+		{"method defaults to collect", func(r *providers.PaymentRequest) { r.Method = "" }, true},
+		{"handle with digits and hyphen", func(r *providers.PaymentRequest) { r.VPA = "98765-43210@ybl" }, true},
+		{"missing VPA", func(r *providers.PaymentRequest) { r.VPA = "" }, false},
+		{"VPA without bank", func(r *providers.PaymentRequest) { r.VPA = "jane.doe" }, false},
+		{"VPA with two handles", func(r *providers.PaymentRequest) { r.VPA = "jane@doe@okbank" }, false},
+		{"VPA with spaces", func(r *providers.PaymentRequest) { r.VPA = "jane doe@okbank" }, false},
+		{"card data", func(r *providers.PaymentRequest) { r.CardNumber = "4111111111111111" }, false},
+		{"CVV", func(r *providers.PaymentRequest) { r.CVV = "123" }, false},
+		{"other currency", func(r *providers.PaymentRequest) { r.Currency = "USD" }, false},
+		{"above the UPI limit", func(r *providers.PaymentRequest) { r.Amount = 100000.01 }, false},
+		{"zero amount", func(r *providers.PaymentRequest) { r.Amount = 0 }, false},
+		{"card method", func(r *providers.PaymentRequest) { r.Method = "bnpl" }, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request := valid
+			tc.modify(&request)
+
+			err := provider.ValidateRequest(request)
+			if tc.valid && err != nil {
+				t.Errorf("Expected valid request, got error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Error("Expected invalid request, got no error")
+			}
+		})
+	}
+}
+
+func TestUPIProvider_ParseSuccessResponse(t *testing.T) {
+	provider := GetNewUPIPaymentProvider()
+
+	upiResponse := map[string]interface{}{
+		"txn_id":    "UPI401512345678",
+		"status":    "PENDING",
+		"amount":    "1250.50",
+		"currency":  "INR",
+		"payer_vpa": "jane.doe@okbank",
+		"timestamp": "2024-01-15T16:00:00+05:30",
+	}
+
+	response, err := provider.ParseSuccessResponse(upiResponse)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	if response.Status != providers.StatusPending || !response.Success {
+		t.Errorf("Expected a pending collect request to be accepted, got %+v", response)
+	}
+	if response.Amount != 1250.50 {
+		t.Errorf("Expected amount 1250.50, got %f", response.Amount)
+	}
+
+	upiResponse["amount"] = 1250.5
+	if _, err := provider.ParseSuccessResponse(upiResponse); err == nil || !strings.Contains(err.Error(), "amount") {
+		t.Errorf("Expected an error for a numeric amount, got: %v", err)
+	}
+}
+
+func TestUPIProvider_GoldenFixtures(t *testing.T) {
+	fixtures.Run(t, GetNewUPIPaymentProvider(), "testdata/fixtures")
+}
+
+func TestUPIProvider_SandboxScenarios(t *testing.T) {
+	provider := GetNewUPIPaymentProvider()
+	for _, scenario := range sandbox.ForProvider("upi") {
+		request := providers.PaymentRequest{Mode: "upi", Method: scenario.Method, Amount: 10, Currency: "INR", VPA: scenario.VPA}
+		if err := provider.ValidateRequest(request); err != nil {
+			t.Errorf("VPA %s: expected a valid request, got: %v", scenario.VPA, err)
+		}
+
+		success, failure := provider.ProcessPayment(context.Background(), request)
+		if scenario.ErrorCode == "" {
+			response, err := provider.ParseSuccessResponse(success)
+			if err != nil || response.Status != scenario.Status {
+				t.Errorf("VPA %s: expected status %s, got %+v (%v)", scenario.VPA, scenario.Status, response, err)
+			}
+			continue
+		}
+
+		paymentError, err := provider.ParseErrorResponse(failure)
+		if err != nil || paymentError.ErrorCode != scenario.ErrorCode || paymentError.Reason != scenario.Reason {
+			t.Errorf("VPA %s: expected %s/%s, got %+v (%v)", scenario.VPA, scenario.ErrorCode, scenario.Reason, paymentError, err)
+		}
+	}
+}
@@ -0,0 +1,27 @@
+package visa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"pgas/pkg/providers"
+)
+
+// Capture simulates the visa capture endpoint, every capture of a payment
+// gets its own reference
+func (p *VisaPaymentProvider) Capture(ctx context.Context, request providers.CaptureRequest) (*providers.CaptureResponse, error) {
+	if request.TransactionID == "" {
+		return nil, errors.New("transaction id is required")
+	}
+
+	return &providers.CaptureResponse{
+		Success:       true,
+		CaptureID:     fmt.Sprintf("CPTR--%s--%06d", request.TransactionID, rand.IntN(1000000)),
+		TransactionID: request.TransactionID,
+		Status:        providers.StatusApproved,
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
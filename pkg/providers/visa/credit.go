@@ -0,0 +1,25 @@
+package visa
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+
+	"pgas/pkg/providers"
+)
+
+// Credit simulates the visa Original Credit Transaction endpoint
+func (p *VisaPaymentProvider) Credit(ctx context.Context, request providers.CreditRequest) (*providers.CreditResponse, error) {
+	if err := request.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &providers.CreditResponse{
+		Success:   true,
+		CreditID:  fmt.Sprintf("OCT--%06d", rand.IntN(1000000)),
+		Status:    providers.StatusApproved,
+		Amount:    request.Amount,
+		Currency:  request.Currency,
+		Reference: request.Reference,
+	}, nil
+}
@@ -2,26 +2,55 @@ package visa
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"math/rand/v2"
 	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+	"pgas/pkg/validation"
 	"strconv"
 	"time"
 )
 
+// raw visa states mapped to normalized statuses
+var statuses = map[string]string{
+	"SUCCESS":  providers.StatusApproved,
+	"PENDING":  providers.StatusPending,
+	"DECLINED": providers.StatusDeclined,
+}
+
+// visa ECI values, authenticated ones need a CAVV
+var eciValues = providers.ECIValues{
+	"05": true,  // fully authenticated
+	"06": true,  // authentication attempted
+	"07": false, // not authenticated
+}
+
+// support contacts travel in the merchant city field of the descriptor
+var contactRules = providers.ContactRules{PhoneMax: 13, URLMax: 13}
+
 type VisaPaymentProvider struct {
+	providers.ResponseVersions
+
 	Name string
+	// Now is the clock card expiry dates are checked against
+	Now      func() time.Time
+	decoding providers.Decoding
 }
 
 func GetNewVisaPaymentProvider() *VisaPaymentProvider {
-	return &VisaPaymentProvider{Name: "visa"}
+	return &VisaPaymentProvider{Name: "visa", Now: time.Now}
 }
 
 func (p *VisaPaymentProvider) GetName() string {
 	return p.Name
 }
 
+// SetDecoding configures how unknown response fields are handled
+func (p *VisaPaymentProvider) SetDecoding(decoding providers.Decoding) {
+	p.decoding = decoding
+}
+
 func (p *VisaPaymentProvider) ValidateRequest(request providers.PaymentRequest) error {
 
 	if request.Amount <= 0 {
@@ -36,33 +65,57 @@ func (p *VisaPaymentProvider) ValidateRequest(request providers.PaymentRequest)
 		return errors.New("currency is required")
 	}
 
-	if request.CardNumber == "" {
-		return errors.New("card number is required")
-	}
-
-	if len(request.CardNumber) < 13 || len(request.CardNumber) > 19 {
-		return errors.New("card number must be between 13 and 19 digits")
+	if err := validation.ValidateCardNumber(request.CardNumber); err != nil {
+		return err
 	}
 
-	if request.ExpiryMonth == "" || request.ExpiryYear == "" {
-		return errors.New("expiry month and year are required")
+	if err := validation.ValidateExpiry(request.ExpiryMonth, request.ExpiryYear, p.Now()); err != nil {
+		return err
 	}
 
-	if request.CVV == "" {
+	// stored credentials are charged without the CVV, it must not be kept
+	storedCredential := request.StoredCredentialUsage == providers.StoredCredentialSubsequent
+	if request.CVV == "" && !storedCredential {
 		return errors.New("CVV is required")
 	}
 
-	if len(request.CVV) < 3 || len(request.CVV) > 4 {
+	if request.CVV != "" && (len(request.CVV) < 3 || len(request.CVV) > 4 || !validation.Digits(request.CVV)) {
 		return errors.New("CVV must be 3 or 4 digits")
 	}
 
-	return nil
+	if err := providers.ValidateStoredCredential(request); err != nil {
+		return err
+	}
+
+	if err := providers.ValidateSupportContact(request.SupportContact, contactRules); err != nil {
+		return err
+	}
+
+	return providers.ValidateAuthenticationData(request, eciValues)
 }
 
 func (p *VisaPaymentProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
-
-	// Simulate a dummy error response sometimes
-	if rand.Float64() < 0.1 {
+	// sandbox test cards trigger their catalogued outcome
+	state := "SUCCESS"
+	if scenario, ok := sandbox.Payment(p.Name, request.CardNumber); ok {
+		if scenario.ErrorCode != "" {
+			info, _ := providers.LookupErrorCode(p.Name, scenario.ErrorCode)
+			details := map[string]interface{}{"code": scenario.ErrorCode}
+			if category := retryCategory(scenario.Advice); category != "" {
+				details["retry_category"] = category
+			}
+			errorResponse := map[string]interface{}{
+				"error_type": "PAYMENT_FAILED",
+				"reason":     info.Description,
+				"details":    details,
+			}
+			return nil, errorResponse
+		}
+		if scenario.Status == providers.StatusPending {
+			state = "PENDING"
+		}
+	} else if rand.Float64() < 0.1 {
+		// Simulate a dummy error response sometimes
 		errorResponse := map[string]interface{}{
 			"error_type": "PAYMENT_FAILED",
 			"reason":     "Card declined",
@@ -76,57 +129,76 @@ func (p *VisaPaymentProvider) ProcessPayment(ctx context.Context, request provid
 	// Simulate a dummy successful payment response
 	successResponse := map[string]interface{}{
 		"payment_id": "PPAAYY--778899--XXYYZZ",
-		"state":      "SUCCESS",
+		"state":      state,
 		"value": map[string]interface{}{
 			"amount":        strconv.FormatFloat(request.Amount, 'f', -1, 64),
 			"currency_code": request.Currency,
 		},
 		"processed_at": 1677587921,
 	}
+	if request.ECI != "" {
+		successResponse["authentication"] = map[string]interface{}{
+			"eci":             request.ECI,
+			"liability_shift": providers.LiabilityShift(request, eciValues),
+		}
+	}
+	if request.AuthorizationType == providers.AuthorizationEstimated {
+		successResponse["authorization_type"] = "ESTIMATED"
+	}
 
 	return successResponse, nil
 }
 
 func (p *VisaPaymentProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
-	responseJSON, err := json.Marshal(response)
-	if err != nil {
-		return nil, errors.New("error marshalling response")
-	}
+	return p.ParseResponse(response, p.parseResponse)
+}
 
+func (p *VisaPaymentProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	return p.ParseError(response, p.parseError)
+}
+
+// parseResponse parses the visa response format, version v1
+func (p *VisaPaymentProvider) parseResponse(response interface{}) (*providers.PaymentResponse, error) {
 	var providerResponse PaymentResponse
-	err = json.Unmarshal(responseJSON, &providerResponse)
-	if err != nil {
-		return nil, errors.New("invalid response type")
+	if err := p.decoding.Decode(p.Name, response, &providerResponse); err != nil {
+		return nil, err
 	}
 
-	parsedAmount, _ := strconv.ParseFloat(providerResponse.Value.Amount, 64)
+	if providerResponse.PaymentID == "" {
+		return nil, errors.New("visa: field 'payment_id' is required")
+	}
+	parsedAmount, err := strconv.ParseFloat(providerResponse.Value.Amount, 64)
+	if err != nil {
+		return nil, fmt.Errorf("visa: field 'value.amount' must be a decimal string, got '%s'", providerResponse.Value.Amount)
+	}
 	parsedTime := time.Unix(providerResponse.ProcessedAt, 0)
+	status := providers.NormalizeStatus(providerResponse.State, statuses)
+
+	paymentResponse := &providers.PaymentResponse{
+		Success:        providers.IsSuccessStatus(status),
+		TransactionID:  providerResponse.PaymentID,
+		Status:         status,
+		RawStatus:      providerResponse.State,
+		Amount:         parsedAmount,
+		Currency:       providerResponse.Value.CurrencyCode,
+		Date:           &parsedTime,
+		LiabilityShift: providerResponse.Authentication != nil && providerResponse.Authentication.LiabilityShift,
+	}
+	if providerResponse.AuthorizationType == "ESTIMATED" {
+		paymentResponse.AuthorizationType = providers.AuthorizationEstimated
+	}
 
-	return &providers.PaymentResponse{
-		Success:       true,
-		TransactionID: providerResponse.PaymentID,
-		Status:        providerResponse.State,
-		Amount:        parsedAmount,
-		Currency:      providerResponse.Value.CurrencyCode,
-		Date:          &parsedTime,
-	}, nil
+	return paymentResponse, nil
 }
 
-func (p *VisaPaymentProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
-	responseJSON, err := json.Marshal(response)
-	if err != nil {
-		return nil, errors.New("error marshalling error response")
-	}
-
+// parseError parses the visa error format, version v1
+func (p *VisaPaymentProvider) parseError(response interface{}) (*providers.PaymentError, error) {
 	var providerError PaymentError
-	err = json.Unmarshal(responseJSON, &providerError)
-	if err != nil {
-		return nil, errors.New("invalid response error type")
+	if err := p.decoding.Decode(p.Name, response, &providerError); err != nil {
+		return nil, err
 	}
 
-	return &providers.PaymentError{
-		Success:      false,
-		ErrorCode:    providerError.Details.Code,
-		ErrorMessage: "ErrorType:" + providerError.ErrorType + " :: ErrorReason: " + providerError.Reason,
-	}, nil
+	return providers.NewCatalogError(p.Name, providerError.Details.Code,
+		"ErrorType:"+providerError.ErrorType+" :: ErrorReason: "+providerError.Reason).
+		WithAdvice(retryAdvice[providerError.Details.RetryCategory]), nil
 }
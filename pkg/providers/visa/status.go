@@ -0,0 +1,13 @@
+package visa
+
+import (
+	"context"
+
+	"pgas/pkg/providers"
+)
+
+// GetPaymentStatus is not offered by the simulated visa gateway, the
+// processor answers from its stored record instead
+func (p *VisaPaymentProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return nil, providers.ErrStatusQueryNotSupported
+}
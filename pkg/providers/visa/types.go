@@ -1,5 +1,7 @@
 package visa
 
+import "pgas/pkg/providers"
+
 type PaymentRequest struct {
 	// request format for visa
 }
@@ -12,7 +14,13 @@ type PaymentResponse struct {
 		Amount       string `json:"amount"`
 		CurrencyCode string `json:"currency_code"`
 	} `json:"value"`
-	ProcessedAt int64 `json:"processed_at"`
+	ProcessedAt    int64 `json:"processed_at"`
+	Authentication *struct {
+		ECI            string `json:"eci"`
+		LiabilityShift bool   `json:"liability_shift"`
+	} `json:"authentication,omitempty"`
+	// AuthorizationType is ESTIMATED for estimated authorizations
+	AuthorizationType string `json:"authorization_type,omitempty"`
 }
 
 // error response format for visa
@@ -21,5 +29,33 @@ type PaymentError struct {
 	Reason    string `json:"reason"`
 	Details   struct {
 		Code string `json:"code"`
+		// RetryCategory is Visa's decline category: "1" never retry,
+		// "2" retry later, "3" retry with corrected data
+		RetryCategory string `json:"retry_category,omitempty"`
 	} `json:"details"`
 }
+
+// decline categories mapped to normalized advice
+var retryAdvice = map[string]string{
+	"1": providers.AdviceDoNotRetry,
+	"2": providers.AdviceRetryLater,
+	"3": providers.AdviceUpdateCard,
+}
+
+// retryCategory is the category the simulator sends for normalized advice
+func retryCategory(advice string) string {
+	for category, value := range retryAdvice {
+		if value == advice {
+			return category
+		}
+	}
+	return ""
+}
+
+func (p *VisaPaymentProvider) NewWireResponse() interface{} {
+	return &PaymentResponse{}
+}
+
+func (p *VisaPaymentProvider) NewWireError() interface{} {
+	return &PaymentError{}
+}
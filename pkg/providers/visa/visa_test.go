@@ -1,9 +1,22 @@
 package visa
 
 import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
 	"testing"
+	"time"
 
+	"pgas/pkg/bin"
+	"pgas/pkg/certification"
+	"pgas/pkg/fixtures"
 	"pgas/pkg/providers"
+	"pgas/pkg/sandbox"
+	"pgas/pkg/webhooks"
 )
 
 func TestGetNewVisaPaymentProvider(t *testing.T) {
@@ -33,11 +46,70 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: true,
 		},
+		{
+			name: "authenticated request",
+			request: providers.PaymentRequest{
+				Mode:        "visa",
+				Amount:      100.00,
+				Currency:    "USD",
+				CardNumber:  "4111111111111111",
+				ExpiryMonth: "12",
+				ExpiryYear:  "2030",
+				CVV:         "123",
+				ECI:         "05",
+				Cryptogram:  "AAABBEg0VhI0VniQEjRWAAAAAAA=",
+			},
+			valid: true,
+		},
+		{
+			name: "ECI of another scheme",
+			request: providers.PaymentRequest{
+				Mode:        "visa",
+				Amount:      100.00,
+				Currency:    "USD",
+				CardNumber:  "4111111111111111",
+				ExpiryMonth: "12",
+				ExpiryYear:  "2030",
+				CVV:         "123",
+				ECI:         "02",
+				Cryptogram:  "AAABBEg0VhI0VniQEjRWAAAAAAA=",
+			},
+			valid: false,
+		},
+		{
+			name: "merchant-initiated request without CVV",
+			request: providers.PaymentRequest{
+				Mode:                  "visa",
+				Amount:                100.00,
+				Currency:              "USD",
+				CardNumber:            "4111111111111111",
+				ExpiryMonth:           "12",
+				ExpiryYear:            "2030",
+				InitiatedBy:           providers.InitiatedByMerchant,
+				StoredCredentialUsage: providers.StoredCredentialSubsequent,
+				PriorTransactionID:    "TX1234567890",
+			},
+			valid: true,
+		},
+		{
+			name: "merchant-initiated request without prior transaction",
+			request: providers.PaymentRequest{
+				Mode:                  "visa",
+				Amount:                100.00,
+				Currency:              "USD",
+				CardNumber:            "4111111111111111",
+				ExpiryMonth:           "12",
+				ExpiryYear:            "2030",
+				InitiatedBy:           providers.InitiatedByMerchant,
+				StoredCredentialUsage: providers.StoredCredentialSubsequent,
+			},
+			valid: false,
+		},
 		{
 			name: "zero amount",
 			request: providers.PaymentRequest{
@@ -46,7 +118,7 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -59,7 +131,7 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -72,7 +144,7 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -85,7 +157,7 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -98,7 +170,33 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "123",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
+				CVV:         "123",
+			},
+			valid: false,
+		},
+		{
+			name: "card number failing the Luhn check",
+			request: providers.PaymentRequest{
+				Mode:        "visa",
+				Amount:      100.00,
+				Currency:    "USD",
+				CardNumber:  "4111111111111112",
+				ExpiryMonth: "12",
+				ExpiryYear:  "2030",
+				CVV:         "123",
+			},
+			valid: false,
+		},
+		{
+			name: "card number with separators",
+			request: providers.PaymentRequest{
+				Mode:        "visa",
+				Amount:      100.00,
+				Currency:    "USD",
+				CardNumber:  "4111-1111-1111-1111",
+				ExpiryMonth: "12",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -111,7 +209,7 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: false,
@@ -137,7 +235,7 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "",
 			},
 			valid: false,
@@ -150,11 +248,39 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "12",
 			},
 			valid: false,
 		},
+		{
+			name: "support contact",
+			request: providers.PaymentRequest{
+				Mode:           "visa",
+				Amount:         100.00,
+				Currency:       "USD",
+				CardNumber:     "4111111111111111",
+				ExpiryMonth:    "12",
+				ExpiryYear:     "2030",
+				CVV:            "123",
+				SupportContact: &providers.SupportContact{Phone: "+1 555 0100", URL: "acme.io/help"},
+			},
+			valid: true,
+		},
+		{
+			name: "support URL exceeding the city field",
+			request: providers.PaymentRequest{
+				Mode:           "visa",
+				Amount:         100.00,
+				Currency:       "USD",
+				CardNumber:     "4111111111111111",
+				ExpiryMonth:    "12",
+				ExpiryYear:     "2030",
+				CVV:            "123",
+				SupportContact: &providers.SupportContact{URL: "help.acme.example"},
+			},
+			valid: false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -179,7 +305,7 @@ func TestVisaProvider_ValidateRequest(t *testing.T) {
 // 		Currency:    "USD",
 // 		CardNumber:  "4111111111111111",
 // 		ExpiryMonth: "12",
-// 		ExpiryYear:  "2025",
+// 		ExpiryYear:  "2030",
 // 		CVV:         "123",
 // 	}
 
@@ -247,8 +373,12 @@ func TestVisaProvider_ParseSuccessResponse(t *testing.T) {
 		t.Errorf("Expected transaction ID %s, got %s", "PPAAYY--778899--XXYYZZ", response.TransactionID)
 	}
 
-	if response.Status != "SUCCESS" {
-		t.Errorf("Expected status %s, got %s", "SUCCESS", response.Status)
+	if response.Status != "APPROVED" {
+		t.Errorf("Expected status %s, got %s", "APPROVED", response.Status)
+	}
+
+	if response.RawStatus != "SUCCESS" {
+		t.Errorf("Expected raw status %s, got %s", "SUCCESS", response.RawStatus)
 	}
 
 	if response.Currency != "USD" {
@@ -264,6 +394,109 @@ func TestVisaProvider_ParseSuccessResponse(t *testing.T) {
 	}
 }
 
+func TestVisaProvider_ParseSuccessResponse_UnknownStatus(t *testing.T) {
+	provider := GetNewVisaPaymentProvider()
+
+	response, err := provider.ParseSuccessResponse(map[string]interface{}{
+		"payment_id": "PPAAYY--778899--XXYYZZ",
+		"state":      "UNDER_REVIEW",
+		"value": map[string]interface{}{
+			"amount":        "10.00",
+			"currency_code": "USD",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+
+	if response.Status != "UNKNOWN" || response.RawStatus != "UNDER_REVIEW" {
+		t.Errorf("Expected UNKNOWN status keeping raw UNDER_REVIEW, got %s / %s", response.Status, response.RawStatus)
+	}
+
+	if response.Success {
+		t.Error("Expected unknown status not to be reported as success")
+	}
+}
+
+func TestVisaProvider_ParseSuccessResponse_LiabilityShift(t *testing.T) {
+	provider := GetNewVisaPaymentProvider()
+
+	visaResponse := map[string]interface{}{
+		"payment_id": "PPAAYY--778899--XXYYZZ",
+		"state":      "SUCCESS",
+		"value": map[string]interface{}{
+			"amount":        "10.00",
+			"currency_code": "USD",
+		},
+		"processed_at": 1677587921,
+		"authentication": map[string]interface{}{
+			"eci":             "05",
+			"liability_shift": true,
+		},
+	}
+
+	response, err := provider.ParseSuccessResponse(visaResponse)
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	if !response.LiabilityShift {
+		t.Error("Expected liability shift for an authenticated payment")
+	}
+}
+
+// a gateway migrating to a v2 format answers in both formats for a while
+func TestVisaProvider_ResponseVersions(t *testing.T) {
+	provider := GetNewVisaPaymentProvider()
+	provider.RegisterResponseParser("v2", func(response interface{}) (*providers.PaymentResponse, error) {
+		fields, _ := response.(map[string]interface{})
+		id, _ := fields["id"].(string)
+		if id == "" {
+			return nil, errors.New("visa: field 'id' is required")
+		}
+		return &providers.PaymentResponse{Success: true, TransactionID: id, Status: providers.StatusApproved}, nil
+	})
+
+	v1, err := provider.ParseSuccessResponse(map[string]interface{}{
+		"payment_id":   "PPAAYY--778899--XXYYZZ",
+		"state":        "SUCCESS",
+		"value":        map[string]interface{}{"amount": "80.00", "currency_code": "USD"},
+		"processed_at": 1677587921,
+	})
+	if err != nil || v1.ResponseVersion != "v1" {
+		t.Fatalf("Expected a v1 response, got %+v (%v)", v1, err)
+	}
+
+	v2, err := provider.ParseSuccessResponse(map[string]interface{}{"id": "pay_2", "outcome": "approved"})
+	if err != nil || v2.ResponseVersion != "v2" || v2.TransactionID != "pay_2" {
+		t.Fatalf("Expected a v2 response, got %+v (%v)", v2, err)
+	}
+
+	if _, err := provider.ParseSuccessResponse(map[string]interface{}{"unknown": true}); err == nil {
+		t.Error("Expected a response of neither version to fail")
+	}
+}
+
+func TestVisaProvider_EstimatedAuthorization(t *testing.T) {
+	provider := GetNewVisaPaymentProvider()
+
+	response, err := provider.ParseSuccessResponse(map[string]interface{}{
+		"payment_id": "PPAAYY--778899--XXYYZZ",
+		"state":      "SUCCESS",
+		"value": map[string]interface{}{
+			"amount":        "80.00",
+			"currency_code": "USD",
+		},
+		"processed_at":       1677587921,
+		"authorization_type": "ESTIMATED",
+	})
+	if err != nil {
+		t.Fatalf("Expected successful parsing, got error: %v", err)
+	}
+	if response.AuthorizationType != providers.AuthorizationEstimated {
+		t.Errorf("Expected an estimated authorization, got %q", response.AuthorizationType)
+	}
+}
+
 func TestVisaProvider_ParseErrorResponse(t *testing.T) {
 	provider := GetNewVisaPaymentProvider()
 
@@ -295,6 +528,12 @@ func TestVisaProvider_ParseErrorResponse(t *testing.T) {
 	if errorResponse.ErrorMessage != "ErrorType:PAYMENT_FAILED :: ErrorReason: Card declined" {
 		t.Errorf("Expected error message %s, got %s", "ErrorType:PAYMENT_FAILED :: ErrorReason: Card declined", errorResponse.ErrorMessage)
 	}
+
+	visaError["details"] = map[string]interface{}{"code": "EE000091", "retry_category": "2"}
+	errorResponse, _ = provider.ParseErrorResponse(visaError)
+	if errorResponse.Advice != providers.AdviceRetryLater || !errorResponse.Retryable {
+		t.Errorf("Expected retry-later advice on a retryable decline, got advice '%s' retryable %v", errorResponse.Advice, errorResponse.Retryable)
+	}
 }
 
 func TestVisaProvider_EdgeCases(t *testing.T) {
@@ -313,7 +552,7 @@ func TestVisaProvider_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: true,
@@ -326,7 +565,7 @@ func TestVisaProvider_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "123",
 			},
 			valid: true,
@@ -339,7 +578,7 @@ func TestVisaProvider_EdgeCases(t *testing.T) {
 				Currency:    "USD",
 				CardNumber:  "4111111111111111",
 				ExpiryMonth: "12",
-				ExpiryYear:  "2025",
+				ExpiryYear:  "2030",
 				CVV:         "1234",
 			},
 			valid: true,
@@ -371,3 +610,100 @@ func TestVisaProvider_EdgeCases(t *testing.T) {
 		})
 	}
 }
+
+func TestVisaProvider_ParseSuccessResponse_StrictDrift(t *testing.T) {
+	provider := GetNewVisaPaymentProvider()
+	provider.SetDecoding(providers.Decoding{Mode: providers.DecodeStrict})
+
+	_, err := provider.ParseSuccessResponse(map[string]interface{}{
+		"payment_id": "PPAAYY--778899--XXYYZZ",
+		"state":      "SUCCESS",
+		"value": map[string]interface{}{
+			"amount":        "10.00",
+			"currency_code": "USD",
+			"fx_rate":       "1.0",
+		},
+	})
+	if err == nil || !strings.Contains(err.Error(), "value.fx_rate") {
+		t.Errorf("Expected strict parsing to reject value.fx_rate, got: %v", err)
+	}
+}
+
+func TestVisaProvider_ParseSuccessResponse_InvalidAmount(t *testing.T) {
+	provider := GetNewVisaPaymentProvider()
+
+	_, err := provider.ParseSuccessResponse(map[string]interface{}{
+		"payment_id": "PPAAYY--778899--XXYYZZ",
+		"state":      "SUCCESS",
+		"value":      map[string]interface{}{"amount": "ten", "currency_code": "USD"},
+	})
+	if err == nil {
+		t.Error("Expected an invalid amount to be rejected instead of parsed as 0")
+	}
+}
+
+func TestVisaProvider_GoldenFixtures(t *testing.T) {
+	fixtures.Run(t, GetNewVisaPaymentProvider(), "testdata/fixtures")
+}
+
+func TestVisaProvider_Certification(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 10, 0, 0, 0, time.UTC)
+	provider := GetNewVisaPaymentProvider()
+	provider.Now = func() time.Time { return now }
+
+	certification.Run(t, provider, bin.Visa, certification.Options{Now: now})
+}
+
+func TestVisaProvider_SandboxScenarios(t *testing.T) {
+	provider := GetNewVisaPaymentProvider()
+	for _, scenario := range sandbox.ForProvider("visa") {
+		if scenario.Operation != sandbox.OperationPayment {
+			continue
+		}
+		request := providers.PaymentRequest{Mode: "visa", Amount: 10, Currency: "USD", CardNumber: scenario.CardNumber}
+
+		success, failure := provider.ProcessPayment(context.Background(), request)
+		if scenario.ErrorCode == "" {
+			response, err := provider.ParseSuccessResponse(success)
+			if err != nil || response.Status != scenario.Status {
+				t.Errorf("Card %s: expected status %s, got %+v (%v)", scenario.CardNumber, scenario.Status, response, err)
+			}
+			continue
+		}
+
+		paymentError, err := provider.ParseErrorResponse(failure)
+		if err != nil || paymentError.ErrorCode != scenario.ErrorCode || paymentError.Reason != scenario.Reason || paymentError.Advice != scenario.Advice {
+			t.Errorf("Card %s: expected %s/%s/%s, got %+v (%v)", scenario.CardNumber, scenario.ErrorCode, scenario.Reason, scenario.Advice, paymentError, err)
+		}
+	}
+}
+
+func TestVisaWebhookParser(t *testing.T) {
+	parser := NewWebhookParser([]byte("whsec"))
+	body := []byte(`{"notification_id": "ntf-1", "event_type": "PAYMENT.DECLINED", "payment_id": "PAY-1",
+		"value": {"amount": "12.50", "currency_code": "USD"}, "decline_code": "EE000051", "created_at": 1700000000}`)
+
+	mac := hmac.New(sha256.New, []byte("whsec"))
+	mac.Write(body)
+	header := http.Header{}
+	header.Set(SignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	if err := parser.Verify(header, body); err != nil {
+		t.Fatalf("Expected the signature to verify, got %v", err)
+	}
+	if err := parser.Verify(header, append(body, ' ')); !errors.Is(err, webhooks.ErrInvalidSignature) {
+		t.Errorf("Expected a tampered body to fail, got %v", err)
+	}
+
+	event, err := parser.Parse(body)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if event.ID != "ntf-1" || event.Type != webhooks.EventDeclined || event.Reference.GatewayRef != "PAY-1" ||
+		event.Amount != 12.5 || event.Reason != providers.ReasonInsufficientFunds || event.OccurredAt.Unix() != 1700000000 {
+		t.Errorf("Unexpected event %+v", event)
+	}
+
+	if _, err := parser.Parse([]byte(`{"notification_id": "ntf-2", "event_type": "PAYOUT.PAID"}`)); !errors.Is(err, webhooks.ErrUnsupportedEvent) {
+		t.Errorf("Expected unsupported events to be reported, got %v", err)
+	}
+}
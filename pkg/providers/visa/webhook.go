@@ -0,0 +1,103 @@
+package visa
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/webhooks"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of a webhook body
+const SignatureHeader = "X-Visa-Signature"
+
+// webhook notification format for visa
+type WebhookNotification struct {
+	NotificationID string `json:"notification_id"`
+	EventType      string `json:"event_type"`
+	PaymentID      string `json:"payment_id"`
+	Value          struct {
+		Amount       string `json:"amount"`
+		CurrencyCode string `json:"currency_code"`
+	} `json:"value"`
+	// DeclineCode is set on PAYMENT.DECLINED, DisputeReason on DISPUTE.CREATED
+	DeclineCode   string `json:"decline_code,omitempty"`
+	DisputeReason string `json:"dispute_reason,omitempty"`
+	CreatedAt     int64  `json:"created_at"`
+}
+
+// visa notification types mapped to normalized events
+var webhookEvents = map[string]string{
+	"PAYMENT.SUCCEEDED": webhooks.EventApproved,
+	"PAYMENT.DECLINED":  webhooks.EventDeclined,
+	"PAYMENT.CAPTURED":  webhooks.EventCaptured,
+	"PAYMENT.REFUNDED":  webhooks.EventRefunded,
+	"DISPUTE.CREATED":   webhooks.EventChargeback,
+}
+
+// WebhookParser verifies and normalizes visa webhooks
+type WebhookParser struct {
+	Name   string
+	secret []byte
+}
+
+// NewWebhookParser verifies webhooks with the merchant's webhook secret
+func NewWebhookParser(secret []byte) *WebhookParser {
+	return &WebhookParser{Name: "visa", secret: secret}
+}
+
+func (p *WebhookParser) Provider() string {
+	return p.Name
+}
+
+func (p *WebhookParser) Verify(header http.Header, body []byte) error {
+	signature, err := hex.DecodeString(header.Get(SignatureHeader))
+	if err != nil {
+		return webhooks.ErrInvalidSignature
+	}
+	return webhooks.VerifySHA256(p.secret, body, signature)
+}
+
+func (p *WebhookParser) Parse(body []byte) (webhooks.PaymentEvent, error) {
+	var notification WebhookNotification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return webhooks.PaymentEvent{}, fmt.Errorf("visa: invalid webhook: %v", err)
+	}
+
+	eventType, ok := webhookEvents[notification.EventType]
+	if !ok {
+		return webhooks.PaymentEvent{}, fmt.Errorf("visa: %w: %s", webhooks.ErrUnsupportedEvent, notification.EventType)
+	}
+	if notification.NotificationID == "" || notification.PaymentID == "" {
+		return webhooks.PaymentEvent{}, errors.New("visa: fields 'notification_id' and 'payment_id' are required")
+	}
+
+	event := webhooks.PaymentEvent{
+		ID:         notification.NotificationID,
+		Provider:   p.Name,
+		Type:       eventType,
+		Reference:  webhooks.Reference{Provider: p.Name, GatewayRef: notification.PaymentID},
+		Currency:   notification.Value.CurrencyCode,
+		OccurredAt: time.Unix(notification.CreatedAt, 0),
+	}
+	if notification.Value.Amount != "" {
+		amount, err := strconv.ParseFloat(notification.Value.Amount, 64)
+		if err != nil {
+			return webhooks.PaymentEvent{}, fmt.Errorf("visa: field 'value.amount' must be a decimal string, got '%s'", notification.Value.Amount)
+		}
+		event.Amount = amount
+	}
+
+	switch eventType {
+	case webhooks.EventDeclined:
+		event.Reason = providers.NewCatalogError(p.Name, notification.DeclineCode, "").Reason
+	case webhooks.EventChargeback:
+		event.Reason = notification.DisputeReason
+	}
+	return event, nil
+}
@@ -0,0 +1,142 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// MaskedCard holds a card number that only ever prints masked, first 6 and
+// last 4 digits, whether it is formatted with any fmt verb, logged or
+// marshalled. Reveal is the one way back to the full number.
+type MaskedCard string
+
+// String returns the masked card number
+func (c MaskedCard) String() string {
+	return MaskPAN(string(c))
+}
+
+// GoString keeps %#v masked too
+func (c MaskedCard) GoString() string {
+	return fmt.Sprintf("redact.MaskedCard(%q)", c.String())
+}
+
+// Format masks the card number for every verb, including %d and %x which
+// would otherwise bypass String
+func (c MaskedCard) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if f.Flag('#') {
+			fmt.Fprint(f, c.GoString())
+			return
+		}
+	case 'q':
+		fmt.Fprintf(f, "%q", c.String())
+		return
+	}
+	fmt.Fprint(f, c.String())
+}
+
+// MarshalJSON writes the masked card number
+func (c MaskedCard) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// MarshalText writes the masked card number, covering map keys and text
+// encoders
+func (c MaskedCard) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// LogValue keeps the card masked in log/slog records whatever the handler
+func (c MaskedCard) LogValue() slog.Value {
+	return slog.StringValue(c.String())
+}
+
+// Reveal returns the full card number, for the gateway call only
+func (c MaskedCard) Reveal() string {
+	return string(c)
+}
+
+// BIN returns the first 6 digits, empty for numbers too short to mask
+func (c MaskedCard) BIN() string {
+	if len(c) < 13 {
+		return ""
+	}
+	return string(c[:6])
+}
+
+// Last4 returns the last 4 digits, empty for numbers too short to mask
+func (c MaskedCard) Last4() string {
+	if len(c) < 13 {
+		return ""
+	}
+	return string(c[len(c)-4:])
+}
+
+// MaskPAN keeps the first 6 and last 4 digits of a card number, shorter
+// values are masked entirely
+func MaskPAN(cardNumber string) string {
+	if cardNumber == "" {
+		return ""
+	}
+	return truncatePAN(cardNumber)
+}
+
+// Scrub masks card numbers in free text such as error messages and gateway
+// statuses: digit runs of card number length that pass the Luhn check, and
+// the payment's own card number whatever its checksum. Digit runs equal to
+// the CVV are redacted. Either may be empty when unknown.
+func Scrub(text, cardNumber, cvv string) string {
+	if text == "" {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); {
+		if !isDigit(text[i]) {
+			b.WriteByte(text[i])
+			i++
+			continue
+		}
+		j := i
+		for j < len(text) && isDigit(text[j]) {
+			j++
+		}
+		run := text[i:j]
+		switch {
+		case cardNumber != "" && run == cardNumber:
+			b.WriteString(MaskPAN(run))
+		case len(run) >= 12 && len(run) <= 19 && luhn(run):
+			b.WriteString(MaskPAN(run))
+		case cvv != "" && run == cvv:
+			b.WriteString(Redacted)
+		default:
+			b.WriteString(run)
+		}
+		i = j
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// luhn is validation.Luhn, kept here so the package stays free of pgas
+// dependencies
+func luhn(number string) bool {
+	sum := 0
+	for i := 0; i < len(number); i++ {
+		digit := int(number[len(number)-1-i] - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,65 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMaskedCard_NeverPrintsTheNumber(t *testing.T) {
+	card := MaskedCard("4111111111111111")
+
+	outputs := map[string]string{
+		"String": card.String(),
+		"%s":     fmt.Sprintf("%s", card),
+		"%v":     fmt.Sprintf("%v", card),
+		"%+v":    fmt.Sprintf("%+v", struct{ Card MaskedCard }{card}),
+		"%#v":    fmt.Sprintf("%#v", card),
+		"%q":     fmt.Sprintf("%q", card),
+		"%d":     fmt.Sprintf("%d", card),
+		"%x":     fmt.Sprintf("%x", card),
+	}
+	data, err := json.Marshal(map[string]MaskedCard{"card": card})
+	if err != nil {
+		t.Fatalf("Expected card to marshal, got: %v", err)
+	}
+	outputs["json"] = string(data)
+	keys, _ := json.Marshal(map[MaskedCard]int{card: 1})
+	outputs["json key"] = string(keys)
+
+	for name, output := range outputs {
+		if strings.Contains(output, "4111111111111111") {
+			t.Errorf("%s: card number leaked in %s", name, output)
+		}
+		if !strings.Contains(output, "411111******1111") {
+			t.Errorf("%s: expected masked card number, got %s", name, output)
+		}
+	}
+
+	if card.Reveal() != "4111111111111111" || card.BIN() != "411111" || card.Last4() != "1111" {
+		t.Errorf("Expected the full number on request, got %s %s %s", card.Reveal(), card.BIN(), card.Last4())
+	}
+	if got := MaskedCard("12345").String(); got != "****" {
+		t.Errorf("Expected short numbers masked entirely, got %s", got)
+	}
+}
+
+func TestScrub(t *testing.T) {
+	cases := map[string]struct {
+		text, pan, cvv, want string
+	}{
+		"known pan":      {"card 4000000000000000 declined", "4000000000000000", "", "card 400000******0000 declined"},
+		"luhn valid pan": {"PAN=5555555555554444;", "", "", "PAN=555555******4444;"},
+		"cvv":            {"cvv 737 mismatch", "", "737", "cvv [REDACTED] mismatch"},
+		"cvv in a run":   {"ref 17370", "", "737", "ref 17370"},
+		"other numbers":  {"order 1234567890123 for 100 USD", "", "", "order 1234567890123 for 100 USD"},
+		"empty":          {"", "4111111111111111", "123", ""},
+	}
+
+	for name, c := range cases {
+		if got := Scrub(c.text, c.pan, c.cvv); got != c.want {
+			t.Errorf("%s: expected %q, got %q", name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+// Package redact decides how sensitive fields appear wherever pgas writes
+// data: logs, events, stored records and captured raw responses. Operators
+// configure a Policy per sink; card numbers, CVVs and PIN blocks are never
+// passed through, whatever the policy says.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type Action string
+
+const (
+	Redact      Action = "redact"      // replaced by Redacted
+	Hash        Action = "hash"        // keyed hash, equal values stay comparable
+	Truncate    Action = "truncate"    // first 6 and last 4 digits of a PAN, last 4 characters otherwise
+	Passthrough Action = "passthrough" // written as is
+)
+
+// Sink is a destination of data leaving the payment path
+type Sink string
+
+const (
+	SinkLogs         Sink = "logs"
+	SinkEvents       Sink = "events"
+	SinkRecords      Sink = "records"
+	SinkRawResponses Sink = "raw_responses"
+)
+
+// fields known to the policy
+const (
+	FieldCardNumber = "card_number"
+	FieldCVV        = "cvv"
+	FieldExpiry     = "expiry"
+	FieldCardholder = "cardholder_name"
+	FieldPINBlock   = "pin_block"
+)
+
+// Redacted replaces values removed by the Redact action
+const Redacted = "[REDACTED]"
+
+// keys that name a policy field in provider payloads and event data
+var aliases = map[string]string{
+	"card_number":     FieldCardNumber,
+	"cardnumber":      FieldCardNumber,
+	"pan":             FieldCardNumber,
+	"account_number":  FieldCardNumber,
+	"cvv":             FieldCVV,
+	"cvv2":            FieldCVV,
+	"cvc":             FieldCVV,
+	"security_code":   FieldCVV,
+	"expiry":          FieldExpiry,
+	"expiry_month":    FieldExpiry,
+	"expiry_year":     FieldExpiry,
+	"exp_month":       FieldExpiry,
+	"exp_year":        FieldExpiry,
+	"cardholder_name": FieldCardholder,
+	"cardholder":      FieldCardholder,
+	"name_on_card":    FieldCardholder,
+	"pin_block":       FieldPINBlock,
+	"pinblock":        FieldPINBlock,
+	"pin":             FieldPINBlock,
+}
+
+// Policy maps fields to actions per sink. Fields without an entry are
+// passed through, except card numbers and CVVs which fall back to the safe
+// defaults.
+type Policy struct {
+	Sinks   map[Sink]map[string]Action `json:"sinks"`
+	HashKey []byte                     `json:"-"`
+}
+
+// DefaultPolicy truncates card numbers, drops CVVs everywhere and keeps
+// expiry dates only in stored records and raw response captures
+func DefaultPolicy() *Policy {
+	policy := &Policy{Sinks: make(map[Sink]map[string]Action)}
+	for _, sink := range []Sink{SinkLogs, SinkEvents, SinkRecords, SinkRawResponses} {
+		policy.Sinks[sink] = map[string]Action{
+			FieldCardNumber: Truncate,
+			FieldCVV:        Redact,
+			FieldExpiry:     Redact,
+			FieldCardholder: Redact,
+		}
+	}
+	policy.Sinks[SinkRecords][FieldExpiry] = Passthrough
+	policy.Sinks[SinkRawResponses][FieldExpiry] = Passthrough
+	return policy
+}
+
+// Parse reads a JSON policy and validates it
+func Parse(data []byte, hashKey []byte) (*Policy, error) {
+	policy := &Policy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+	policy.HashKey = hashKey
+
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Validate rejects unknown sinks and actions and any attempt to pass card
+// numbers or CVVs through. CVVs may only be redacted since a hash of three
+// or four digits is trivially reversed, and PIN blocks are not even hashed.
+func (p *Policy) Validate() error {
+	for sink, fields := range p.Sinks {
+		switch sink {
+		case SinkLogs, SinkEvents, SinkRecords, SinkRawResponses:
+		default:
+			return fmt.Errorf("unknown sink '%s'", sink)
+		}
+
+		for field, action := range fields {
+			switch action {
+			case Redact, Hash, Truncate, Passthrough:
+			default:
+				return fmt.Errorf("%s.%s: unknown action '%s'", sink, field, action)
+			}
+
+			if action == Hash && len(p.HashKey) == 0 {
+				return fmt.Errorf("%s.%s: hashing requires a hash key", sink, field)
+			}
+			if field == FieldCardNumber && action == Passthrough {
+				return fmt.Errorf("%s.%s: card numbers can never be passed through", sink, field)
+			}
+			if field == FieldCVV && action != Redact {
+				return fmt.Errorf("%s.%s: CVVs can only be redacted", sink, field)
+			}
+			if field == FieldPINBlock && action != Redact {
+				return fmt.Errorf("%s.%s: PIN blocks can only be redacted", sink, field)
+			}
+		}
+	}
+	return nil
+}
+
+// Action returns what happens to field in sink. The card field guarantees
+// hold even for policies that were never validated.
+func (p *Policy) Action(sink Sink, field string) Action {
+	if field == FieldCVV || field == FieldPINBlock {
+		return Redact
+	}
+
+	var action Action
+	if p != nil {
+		action = p.Sinks[sink][field]
+	}
+
+	switch {
+	case action == Hash && (p == nil || len(p.HashKey) == 0):
+		return Redact
+	case field == FieldCardNumber && (action == "" || action == Passthrough):
+		return Truncate
+	case action == "":
+		return Passthrough
+	}
+	return action
+}
+
+// Apply returns value as it may be written to sink
+func (p *Policy) Apply(sink Sink, field, value string) string {
+	if value == "" {
+		return value
+	}
+
+	switch p.Action(sink, field) {
+	case Redact:
+		return Redacted
+	case Hash:
+		mac := hmac.New(sha256.New, p.HashKey)
+		mac.Write([]byte(value))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:32]
+	case Truncate:
+		if field == FieldCardNumber {
+			return truncatePAN(value)
+		}
+		if len(value) <= 4 {
+			return strings.Repeat("*", len(value))
+		}
+		return strings.Repeat("*", len(value)-4) + value[len(value)-4:]
+	}
+	return value
+}
+
+// ApplyValue sanitizes an arbitrary payload such as a raw provider response.
+// Values are walked as JSON: map keys naming a known field are rewritten.
+func (p *Policy) ApplyValue(sink Sink, value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	// payloads are normalized through their JSON form so structs nested
+	// anywhere are covered too
+	data, err := json.Marshal(value)
+	if err != nil {
+		return Redacted
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return Redacted
+	}
+
+	return p.walk(sink, generic)
+}
+
+// ApplyMap sanitizes string maps such as event data in place
+func (p *Policy) ApplyMap(sink Sink, values map[string]string) {
+	for key, value := range values {
+		if field, ok := FieldFor(key); ok {
+			values[key] = p.Apply(sink, field, value)
+		}
+	}
+}
+
+// FieldFor maps a payload key to the policy field it holds
+func FieldFor(key string) (string, bool) {
+	field, ok := aliases[strings.ToLower(key)]
+	return field, ok
+}
+
+func (p *Policy) walk(sink Sink, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		sanitized := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			field, ok := FieldFor(key)
+			if !ok {
+				sanitized[key] = p.walk(sink, item)
+				continue
+			}
+			switch scalar := item.(type) {
+			case string:
+				sanitized[key] = p.Apply(sink, field, scalar)
+			case nil:
+				sanitized[key] = nil
+			default:
+				sanitized[key] = p.Apply(sink, field, fmt.Sprint(scalar))
+			}
+		}
+		return sanitized
+	case []interface{}:
+		sanitized := make([]interface{}, len(v))
+		for i, item := range v {
+			sanitized[i] = p.walk(sink, item)
+		}
+		return sanitized
+	}
+	return value
+}
+
+// truncatePAN keeps the first 6 and last 4 digits as allowed by PCI DSS
+func truncatePAN(cardNumber string) string {
+	if len(cardNumber) < 13 {
+		return "****"
+	}
+	masked := []byte(cardNumber)
+	for i := 6; i < len(masked)-4; i++ {
+		masked[i] = '*'
+	}
+	return string(masked)
+}
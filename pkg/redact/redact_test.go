@@ -0,0 +1,113 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	policy := DefaultPolicy()
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("Expected default policy to be valid, got: %v", err)
+	}
+
+	if got := policy.Apply(SinkLogs, FieldCardNumber, "4111111111111111"); got != "411111******1111" {
+		t.Errorf("Expected truncated PAN, got %s", got)
+	}
+
+	if got := policy.Apply(SinkEvents, FieldCVV, "123"); got != Redacted {
+		t.Errorf("Expected redacted CVV, got %s", got)
+	}
+
+	if got := policy.Apply(SinkRecords, FieldExpiry, "12"); got != "12" {
+		t.Errorf("Expected expiry to be kept in records, got %s", got)
+	}
+
+	if got := policy.Apply(SinkLogs, FieldExpiry, "12"); got != Redacted {
+		t.Errorf("Expected expiry to be redacted in logs, got %s", got)
+	}
+}
+
+func TestParse_RejectsUnsafePolicies(t *testing.T) {
+	cases := map[string]string{
+		"pan passthrough":    `{"sinks": {"logs": {"card_number": "passthrough"}}}`,
+		"cvv truncate":       `{"sinks": {"records": {"cvv": "truncate"}}}`,
+		"pin block truncate": `{"sinks": {"events": {"pin_block": "truncate"}}}`,
+		"unknown sink":       `{"sinks": {"stdout": {"card_number": "redact"}}}`,
+		"unknown action":     `{"sinks": {"logs": {"expiry": "encrypt"}}}`,
+		"hash without key":   `{"sinks": {"logs": {"cardholder_name": "hash"}}}`,
+	}
+
+	for name, data := range cases {
+		if _, err := Parse([]byte(data), nil); err == nil {
+			t.Errorf("%s: expected policy to be rejected", name)
+		}
+	}
+
+	policy, err := Parse([]byte(`{"sinks": {"events": {"card_number": "hash", "cardholder_name": "truncate"}}}`), []byte("key"))
+	if err != nil {
+		t.Fatalf("Expected valid policy, got: %v", err)
+	}
+
+	hashed := policy.Apply(SinkEvents, FieldCardNumber, "4111111111111111")
+	if !strings.HasPrefix(hashed, "hmac:") || hashed != policy.Apply(SinkEvents, FieldCardNumber, "4111111111111111") {
+		t.Errorf("Expected stable keyed hash, got %s", hashed)
+	}
+
+	if got := policy.Apply(SinkEvents, FieldCardholder, "JANE DOE"); got != "****DOE" && got != "**** DOE" {
+		t.Errorf("Expected truncated cardholder name, got %s", got)
+	}
+}
+
+func TestPolicy_UnvalidatedCardFieldsStaySafe(t *testing.T) {
+	policy := &Policy{Sinks: map[Sink]map[string]Action{
+		SinkLogs: {FieldCardNumber: Passthrough, FieldCVV: Passthrough, FieldPINBlock: Passthrough},
+	}}
+
+	if got := policy.Apply(SinkLogs, FieldCardNumber, "4111111111111111"); got == "4111111111111111" {
+		t.Error("Expected PAN never to be passed through")
+	}
+
+	if got := policy.Apply(SinkLogs, FieldCVV, "123"); got != Redacted {
+		t.Errorf("Expected CVV to be redacted, got %s", got)
+	}
+
+	if got := policy.Apply(SinkLogs, FieldPINBlock, "0412AC89ABCDEF67"); got != Redacted {
+		t.Errorf("Expected PIN block to be redacted, got %s", got)
+	}
+
+	var nilPolicy *Policy
+	if got := nilPolicy.Apply(SinkLogs, FieldCardNumber, "4111111111111111"); got != "411111******1111" {
+		t.Errorf("Expected nil policy to truncate PANs, got %s", got)
+	}
+}
+
+func TestPolicy_ApplyValue(t *testing.T) {
+	type payload struct {
+		PAN    string `json:"pan"`
+		Status string `json:"status"`
+	}
+
+	sanitized := DefaultPolicy().ApplyValue(SinkRawResponses, map[string]interface{}{
+		"status": "APPROVED",
+		"card": map[string]interface{}{
+			"card_number": "5555555555554444",
+			"CVC":         123,
+		},
+		"items": []interface{}{payload{PAN: "4111111111111111", Status: "ok"}},
+	}).(map[string]interface{})
+
+	card := sanitized["card"].(map[string]interface{})
+	if card["card_number"] != "555555******4444" || card["CVC"] != Redacted {
+		t.Errorf("Expected nested card fields to be sanitized, got %+v", card)
+	}
+
+	item := sanitized["items"].([]interface{})[0].(map[string]interface{})
+	if item["pan"] != "411111******1111" || item["status"] != "ok" {
+		t.Errorf("Expected struct payloads to be sanitized, got %+v", item)
+	}
+
+	if sanitized["status"] != "APPROVED" {
+		t.Errorf("Expected unrelated fields to be kept, got %v", sanitized["status"])
+	}
+}
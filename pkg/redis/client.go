@@ -0,0 +1,210 @@
+// Package redis is a minimal Redis client speaking RESP2 over TCP. It covers
+// what pgas needs from Redis, locks and streams, without a third-party
+// dependency.
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Error is an error reply of the server, the connection stays usable
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// largest bulk reply accepted, guards against reading garbage as a length
+const maxBulkLength = 512 << 20
+
+// largest array reply accepted, each item is allocated before it is read
+const maxArrayLength = 1 << 20
+
+// Client sends commands over a small pool of connections
+type Client struct {
+	Addr     string
+	Password string // sent with AUTH when set
+	DB       int    // selected when not 0
+	// Dial opens connections, defaults to a net.Dialer
+	Dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	// MaxIdle connections are kept for reuse, defaults to 2
+	MaxIdle int
+
+	mu   sync.Mutex
+	idle []*conn
+}
+
+func NewClient(addr string) *Client {
+	return &Client{Addr: addr}
+}
+
+type conn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Do sends one command and returns its reply: a string for simple and bulk
+// strings, int64 for integers, []interface{} for arrays and nil for nil
+// replies. Error replies are returned as Error.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cn.do(ctx, args...)
+	var replyErr Error
+	if err != nil && !errors.As(err, &replyErr) {
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// Close closes the idle connections
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cn := range c.idle {
+		cn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	dial := c.Dial
+	if dial == nil {
+		dial = (&net.Dialer{Timeout: 5 * time.Second}).DialContext
+	}
+	netConn, err := dial(ctx, "tcp", c.Addr)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: netConn, reader: bufio.NewReader(netConn)}
+
+	if c.Password != "" {
+		if _, err := cn.do(ctx, "AUTH", c.Password); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if c.DB != 0 {
+		if _, err := cn.do(ctx, "SELECT", strconv.Itoa(c.DB)); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (c *Client) put(cn *conn) {
+	maxIdle := c.MaxIdle
+	if maxIdle == 0 {
+		maxIdle = 2
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.idle) >= maxIdle {
+		cn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+func (cn *conn) do(ctx context.Context, args ...string) (interface{}, error) {
+	deadline, _ := ctx.Deadline() // zero without a deadline, which clears it
+	if err := cn.SetDeadline(deadline); err != nil {
+		return nil, err
+	}
+
+	if _, err := cn.Write(encode(args)); err != nil {
+		return nil, err
+	}
+	return readReply(cn.reader)
+}
+
+// encode writes a command as an array of bulk strings
+func encode(args []string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, "\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+func readReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		length, err := strconv.Atoi(body)
+		if err != nil || length > maxBulkLength {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if length < 0 {
+			return nil, nil
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:length]), nil
+	case '*':
+		count, err := strconv.Atoi(body)
+		if err != nil || count > maxArrayLength {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(reader); err != nil {
+				var replyErr Error
+				if !errors.As(err, &replyErr) {
+					return nil, err
+				}
+				items[i] = replyErr
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,115 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// serve answers each command on the listener with reply(args)
+func serve(t *testing.T, reply func(args []string) string) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			c, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				reader := bufio.NewReader(c)
+				for {
+					command, err := readReply(reader)
+					if err != nil {
+						return
+					}
+					var args []string
+					for _, arg := range command.([]interface{}) {
+						args = append(args, arg.(string))
+					}
+					c.Write([]byte(reply(args)))
+				}
+			}(c)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestClient_Do(t *testing.T) {
+	var mu sync.Mutex
+	var commands []string
+	addr := serve(t, func(args []string) string {
+		mu.Lock()
+		commands = append(commands, strings.Join(args, " "))
+		mu.Unlock()
+		switch args[0] {
+		case "AUTH", "SELECT":
+			return "+OK\r\n"
+		case "ECHO":
+			return "$" + string(rune('0'+len(args[1]))) + "\r\n" + args[1] + "\r\n"
+		case "INCR":
+			return ":42\r\n"
+		case "GET":
+			return "$-1\r\n"
+		case "MGET":
+			return "*2\r\n$1\r\na\r\n$-1\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	})
+
+	client := &Client{Addr: addr, Password: "secret", DB: 2}
+	defer client.Close()
+	ctx := context.Background()
+
+	if reply, err := client.Do(ctx, "ECHO", "hello"); err != nil || reply != "hello" {
+		t.Errorf("Expected bulk reply 'hello', got %v, %v", reply, err)
+	}
+	if reply, _ := client.Do(ctx, "INCR", "n"); reply != int64(42) {
+		t.Errorf("Expected integer reply, got %v", reply)
+	}
+	if reply, err := client.Do(ctx, "GET", "missing"); err != nil || reply != nil {
+		t.Errorf("Expected nil reply, got %v, %v", reply, err)
+	}
+	if reply, _ := client.Do(ctx, "MGET", "a", "b"); len(reply.([]interface{})) != 2 {
+		t.Errorf("Expected array reply, got %v", reply)
+	}
+	if _, err := client.Do(ctx, "NOPE"); err == nil || err.Error() != "ERR unknown command" {
+		t.Errorf("Expected error reply, got %v", err)
+	}
+
+	// one connection, authenticated and switched to the database once
+	mu.Lock()
+	defer mu.Unlock()
+	if commands[0] != "AUTH secret" || commands[1] != "SELECT 2" || len(commands) != 7 {
+		t.Errorf("Expected AUTH and SELECT once before the commands, got %q", commands)
+	}
+}
+
+func TestReadReply_Lengths(t *testing.T) {
+	replies := map[string]bool{
+		"*2\r\n:1\r\n:2\r\n": true,
+		"*1048577\r\n":       false,
+		"*99999999999\r\n":   false,
+		"$536870913\r\n":     false,
+		"$3\r\nabc\r\n":      true,
+		"*-1\r\n":            true,
+	}
+	for reply, valid := range replies {
+		_, err := readReply(bufio.NewReader(strings.NewReader(reply)))
+		if valid && err != nil {
+			t.Errorf("Expected %q to be read, got %v", reply, err)
+		}
+		if !valid && (err == nil || !strings.Contains(err.Error(), "malformed")) {
+			t.Errorf("Expected %q to be rejected as malformed, got %v", reply, err)
+		}
+	}
+}
@@ -0,0 +1,328 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"pgas/pkg/codec"
+	"pgas/pkg/events"
+)
+
+// StreamPublisher appends every processor event to a Redis stream with
+// XADD. An error means the event may not have been stored and the publish
+// should be retried, so consumers can see an event more than once.
+type StreamPublisher struct {
+	Client *Client
+	Stream string
+	// MaxLen trims the stream to about this many entries, 0 keeps all
+	MaxLen int64
+	// Codec encodes the events, defaults to codec.JSON. Entries name their
+	// codec, consumers decode each with the codec it was written with.
+	Codec codec.Codec
+}
+
+func NewStreamPublisher(client *Client, stream string) *StreamPublisher {
+	return &StreamPublisher{Client: client, Stream: stream}
+}
+
+func (s *StreamPublisher) Publish(ctx context.Context, event events.Event) error {
+	encoder := s.Codec
+	if encoder == nil {
+		encoder = codec.JSON
+	}
+	body, err := encoder.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	args := []string{"XADD", s.Stream}
+	if s.MaxLen > 0 {
+		args = append(args, "MAXLEN", "~", strconv.FormatInt(s.MaxLen, 10))
+	}
+	args = append(args, "*", "type", event.Type, "codec", encoder.Name(), "event", string(body))
+
+	_, err = s.Client.Do(ctx, args...)
+	return err
+}
+
+// StreamConsumer reads processor events as one member of a consumer group.
+// Each entry goes to one member and is acknowledged once the handler
+// succeeded; entries of a failed handler or a crashed member are delivered
+// again, so events arrive at least once.
+type StreamConsumer struct {
+	Client   *Client
+	Stream   string
+	Group    string
+	Consumer string // unique per instance, e.g. the hostname
+	// Count is the number of entries read per poll, defaults to 10
+	Count int
+	// Block is how long a poll waits for new entries, defaults to 5s
+	Block time.Duration
+	// MinIdle makes the consumer claim entries another member read but did
+	// not acknowledge for this long, 0 leaves them pending
+	MinIdle time.Duration
+	// MaxDeliveries is how often an entry is handed to a failing handler
+	// before it is moved to the dead-letter stream, defaults to 5
+	MaxDeliveries int
+	// DeadLetter is the stream entries that cannot be read or handled are
+	// moved to, defaults to the stream's name with ":dead" appended
+	DeadLetter string
+
+	// read this member's pending entries before new ones
+	pending bool
+}
+
+func NewStreamConsumer(client *Client, stream, group, consumer string) *StreamConsumer {
+	return &StreamConsumer{Client: client, Stream: stream, Group: group, Consumer: consumer, pending: true}
+}
+
+// CreateGroup creates the consumer group, starting with new entries, and the
+// stream when it does not exist yet. An existing group is left as is.
+func (s *StreamConsumer) CreateGroup(ctx context.Context) error {
+	_, err := s.Client.Do(ctx, "XGROUP", "CREATE", s.Stream, s.Group, "$", "MKSTREAM")
+	var replyErr Error
+	if errors.As(err, &replyErr) && strings.HasPrefix(string(replyErr), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// Poll hands one batch of entries to handle and returns how many were
+// acknowledged, with the first handler error. It reads stale entries of
+// other members first when MinIdle is set, then this member's
+// unacknowledged entries, then new ones. Entries that cannot be decoded,
+// and entries whose handler failed MaxDeliveries times, are moved to the
+// dead-letter stream so they do not block the consumer.
+func (s *StreamConsumer) Poll(ctx context.Context, handle func(ctx context.Context, event events.Event) error) (int, error) {
+	count := s.Count
+	if count <= 0 {
+		count = 10
+	}
+
+	var entries []streamEntry
+	if s.MinIdle > 0 {
+		reply, err := s.Client.Do(ctx, "XAUTOCLAIM", s.Stream, s.Group, s.Consumer,
+			strconv.FormatInt(s.MinIdle.Milliseconds(), 10), "0", "COUNT", strconv.Itoa(count))
+		if err != nil {
+			return 0, err
+		}
+		if parts, ok := reply.([]interface{}); ok && len(parts) >= 2 {
+			if entries, err = parseEntries(parts[1]); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if len(entries) == 0 && s.pending {
+		var err error
+		if entries, err = s.read(ctx, "0", count); err != nil {
+			return 0, err
+		}
+		s.pending = len(entries) > 0
+	}
+	if len(entries) == 0 {
+		var err error
+		if entries, err = s.read(ctx, ">", count); err != nil {
+			return 0, err
+		}
+	}
+
+	acked := 0
+	var handleErr error
+	for _, entry := range entries {
+		event, err := decodeEntry(entry)
+		if err != nil {
+			// unreadable entries would be delivered forever
+			if err := s.deadLetter(ctx, entry, err); err != nil {
+				return acked, err
+			}
+			continue
+		}
+		if err := handle(ctx, event); err != nil {
+			if handleErr == nil {
+				handleErr = err
+			}
+			exhausted, pendingErr := s.exhausted(ctx, entry)
+			if pendingErr != nil {
+				return acked, pendingErr
+			}
+			if exhausted {
+				if err := s.deadLetter(ctx, entry, err); err != nil {
+					return acked, err
+				}
+				continue
+			}
+			// leave it pending, it is read again with this member's backlog
+			s.pending = true
+			continue
+		}
+		if _, err := s.Client.Do(ctx, "XACK", s.Stream, s.Group, entry.id); err != nil {
+			return acked, err
+		}
+		acked++
+	}
+	return acked, handleErr
+}
+
+// decodeEntry decodes an entry's event with the codec it names, entries
+// without one are JSON
+func decodeEntry(entry streamEntry) (events.Event, error) {
+	var event events.Event
+	name := entry.fields["codec"]
+	if name == "" {
+		name = codec.JSON.Name()
+	}
+	decoder, err := codec.Lookup(name)
+	if err != nil {
+		return event, err
+	}
+	err = decoder.Unmarshal([]byte(entry.fields["event"]), &event)
+	return event, err
+}
+
+// exhausted reports whether the entry was delivered MaxDeliveries times,
+// by the delivery counter Redis keeps for pending entries
+func (s *StreamConsumer) exhausted(ctx context.Context, entry streamEntry) (bool, error) {
+	limit := s.MaxDeliveries
+	if limit <= 0 {
+		limit = 5
+	}
+
+	reply, err := s.Client.Do(ctx, "XPENDING", s.Stream, s.Group, entry.id, entry.id, "1")
+	if err != nil {
+		return false, err
+	}
+	// [[id, consumer, idle ms, deliveries]], empty once acknowledged
+	items, _ := reply.([]interface{})
+	if len(items) == 0 {
+		return false, nil
+	}
+	parts, ok := items[0].([]interface{})
+	if !ok || len(parts) != 4 {
+		return false, errors.New("redis: malformed pending reply")
+	}
+	deliveries, _ := parts[3].(int64)
+	return deliveries >= int64(limit), nil
+}
+
+// deadLetter copies an entry to the dead-letter stream with the reason it
+// was given up on, then acknowledges it
+func (s *StreamConsumer) deadLetter(ctx context.Context, entry streamEntry, reason error) error {
+	stream := s.DeadLetter
+	if stream == "" {
+		stream = s.Stream + ":dead"
+	}
+
+	args := []string{"XADD", stream, "*",
+		"stream", s.Stream, "group", s.Group, "id", entry.id, "error", reason.Error()}
+	for _, field := range []string{"type", "codec", "event"} {
+		if value, ok := entry.fields[field]; ok {
+			args = append(args, field, value)
+		}
+	}
+	if _, err := s.Client.Do(ctx, args...); err != nil {
+		return err
+	}
+	_, err := s.Client.Do(ctx, "XACK", s.Stream, s.Group, entry.id)
+	return err
+}
+
+// read reads this member's pending entries from id "0", or waits for new
+// entries with id ">"
+func (s *StreamConsumer) read(ctx context.Context, id string, count int) ([]streamEntry, error) {
+	args := []string{"XREADGROUP", "GROUP", s.Group, s.Consumer, "COUNT", strconv.Itoa(count)}
+	if id == ">" {
+		block := s.Block
+		if block <= 0 {
+			block = 5 * time.Second
+		}
+		args = append(args, "BLOCK", strconv.FormatInt(block.Milliseconds(), 10))
+	}
+	args = append(args, "STREAMS", s.Stream, id)
+
+	reply, err := s.Client.Do(ctx, args...)
+	if err != nil {
+		return nil, err
+	}
+	return parseStreamReply(reply)
+}
+
+// Consume polls until ctx is done, pausing for a second after errors so a
+// failing handler does not spin on its pending entries
+func (s *StreamConsumer) Consume(ctx context.Context, handle func(ctx context.Context, event events.Event) error) error {
+	for ctx.Err() == nil {
+		if _, err := s.Poll(ctx, handle); err != nil {
+			select {
+			case <-ctx.Done():
+			case <-time.After(time.Second):
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+type streamEntry struct {
+	id     string
+	fields map[string]string
+}
+
+// parseStreamReply reads the XREADGROUP reply [[stream, entries]], nil when
+// the poll timed out
+func parseStreamReply(reply interface{}) ([]streamEntry, error) {
+	if reply == nil {
+		return nil, nil
+	}
+	streams, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected stream reply %T", reply)
+	}
+
+	var entries []streamEntry
+	for _, stream := range streams {
+		parts, ok := stream.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, errors.New("redis: malformed stream reply")
+		}
+		parsed, err := parseEntries(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, parsed...)
+	}
+	return entries, nil
+}
+
+// parseEntries reads [[id, [field, value, ...]], ...]. Entries deleted
+// while pending come back without fields and are skipped.
+func parseEntries(reply interface{}) ([]streamEntry, error) {
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("redis: unexpected entries reply %T", reply)
+	}
+
+	var entries []streamEntry
+	for _, item := range items {
+		parts, ok := item.([]interface{})
+		if !ok || len(parts) != 2 {
+			return nil, errors.New("redis: malformed stream entry")
+		}
+		id, _ := parts[0].(string)
+		values, _ := parts[1].([]interface{})
+		if id == "" || len(values) == 0 {
+			continue
+		}
+
+		entry := streamEntry{id: id, fields: make(map[string]string, len(values)/2)}
+		for i := 0; i+1 < len(values); i += 2 {
+			field, _ := values[i].(string)
+			value, _ := values[i+1].(string)
+			entry.fields[field] = value
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
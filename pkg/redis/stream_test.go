@@ -0,0 +1,259 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"pgas/pkg/codec"
+	"pgas/pkg/events"
+)
+
+// fakeStreams keeps one stream and consumer group in memory, answering the
+// commands the stream publisher and consumer send. Entries added to other
+// streams, such as the dead-letter stream, are only recorded.
+type fakeStreams struct {
+	mu         sync.Mutex
+	stream     string
+	entries    [][]interface{} // [id, [field, value, ...]]
+	group      bool
+	read       int                 // entries delivered to the group so far
+	pending    map[string][]string // consumer -> unacknowledged ids
+	deliveries map[string]int64    // id -> times delivered
+	others     map[string][]map[string]string
+}
+
+func (f *fakeStreams) do(args []string) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.pending == nil {
+		f.pending = make(map[string][]string)
+		f.deliveries = make(map[string]int64)
+		f.others = make(map[string][]map[string]string)
+	}
+
+	switch args[0] {
+	case "XADD":
+		fields := args[len(args)-1:]
+		for i, arg := range args {
+			if arg == "*" {
+				fields = args[i+1:]
+				break
+			}
+		}
+		if args[1] != f.stream {
+			values := make(map[string]string)
+			for i := 0; i+1 < len(fields); i += 2 {
+				values[fields[i]] = fields[i+1]
+			}
+			f.others[args[1]] = append(f.others[args[1]], values)
+			return "1-0", nil
+		}
+
+		id := strconv.Itoa(len(f.entries)+1) + "-0"
+		var values []interface{}
+		for _, arg := range fields {
+			values = append(values, arg)
+		}
+		f.entries = append(f.entries, []interface{}{id, values})
+		return id, nil
+	case "XGROUP":
+		if f.group {
+			return nil, Error("BUSYGROUP Consumer Group name already exists")
+		}
+		f.group = true
+		return "OK", nil
+	case "XREADGROUP":
+		consumer, id := args[3], args[len(args)-1]
+		var delivered []interface{}
+		if id == ">" {
+			for ; f.read < len(f.entries); f.read++ {
+				entry := f.entries[f.read]
+				delivered = append(delivered, entry)
+				f.pending[consumer] = append(f.pending[consumer], entry[0].(string))
+				f.deliveries[entry[0].(string)]++
+			}
+		} else {
+			for _, pendingID := range f.pending[consumer] {
+				delivered = append(delivered, f.entry(pendingID))
+				f.deliveries[pendingID]++
+			}
+		}
+		if len(delivered) == 0 && id == ">" {
+			return nil, nil
+		}
+		return []interface{}{[]interface{}{args[len(args)-2], delivered}}, nil
+	case "XAUTOCLAIM":
+		consumer := args[3]
+		var claimed []interface{}
+		for other, ids := range f.pending {
+			if other == consumer {
+				continue
+			}
+			for _, id := range ids {
+				claimed = append(claimed, f.entry(id))
+				f.deliveries[id]++
+			}
+			f.pending[consumer] = append(f.pending[consumer], ids...)
+			delete(f.pending, other)
+		}
+		return []interface{}{"0-0", claimed, []interface{}{}}, nil
+	case "XPENDING":
+		for consumer, ids := range f.pending {
+			for _, id := range ids {
+				if id == args[3] {
+					return []interface{}{[]interface{}{id, consumer, int64(0), f.deliveries[id]}}, nil
+				}
+			}
+		}
+		return []interface{}{}, nil
+	case "XACK":
+		for consumer, ids := range f.pending {
+			kept := ids[:0]
+			for _, id := range ids {
+				if id != args[3] {
+					kept = append(kept, id)
+				}
+			}
+			f.pending[consumer] = kept
+		}
+		return int64(1), nil
+	}
+	return nil, Error("ERR unknown command")
+}
+
+// reply encodes the outcome of a command as RESP
+func (f *fakeStreams) reply(args []string) string {
+	value, err := f.do(args)
+	if err != nil {
+		return "-" + err.Error() + "\r\n"
+	}
+	return encodeReply(value)
+}
+
+func encodeReply(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "*-1\r\n"
+	case string:
+		return "$" + strconv.Itoa(len(v)) + "\r\n" + v + "\r\n"
+	case int64:
+		return ":" + strconv.FormatInt(v, 10) + "\r\n"
+	case []interface{}:
+		out := "*" + strconv.Itoa(len(v)) + "\r\n"
+		for _, item := range v {
+			out += encodeReply(item)
+		}
+		return out
+	}
+	panic("unexpected reply type")
+}
+
+func (f *fakeStreams) entry(id string) []interface{} {
+	for _, entry := range f.entries {
+		if entry[0] == id {
+			return entry
+		}
+	}
+	return nil
+}
+
+func TestStream_AtLeastOnce(t *testing.T) {
+	ctx := context.Background()
+	streams := &fakeStreams{stream: "pgas:events"}
+	client := NewClient(serve(t, streams.reply))
+	defer client.Close()
+
+	first := NewStreamConsumer(client, "pgas:events", "billing", "instance-1")
+	if err := first.CreateGroup(ctx); err != nil {
+		t.Fatalf("Expected the group to be created, got: %v", err)
+	}
+	if err := first.CreateGroup(ctx); err != nil {
+		t.Errorf("Expected an existing group to be accepted, got: %v", err)
+	}
+
+	publisher := NewStreamPublisher(client, "pgas:events")
+	for _, eventType := range []string{events.TypePaymentDeferred, events.TypePaymentForwarded} {
+		if err := publisher.Publish(ctx, events.Event{Type: eventType, TransactionID: "tx_1"}); err != nil {
+			t.Fatalf("Expected publish to succeed, got: %v", err)
+		}
+	}
+
+	// the first member crashes after reading both entries, handling none
+	failing := errors.New("database down")
+	if acked, err := first.Poll(ctx, func(ctx context.Context, event events.Event) error { return failing }); acked != 0 || !errors.Is(err, failing) {
+		t.Fatalf("Expected no acks and the handler error, got %d, %v", acked, err)
+	}
+
+	// and never comes back, another member claims its entries
+	second := NewStreamConsumer(client, "pgas:events", "billing", "instance-2")
+	second.MinIdle = 30 * time.Second
+	var handled []string
+	acked, err := second.Poll(ctx, func(ctx context.Context, event events.Event) error {
+		handled = append(handled, event.Type)
+		return nil
+	})
+	if err != nil || acked != 2 {
+		t.Fatalf("Expected both entries to be claimed and acknowledged, got %d, %v", acked, err)
+	}
+	if handled[0] != events.TypePaymentDeferred || handled[1] != events.TypePaymentForwarded {
+		t.Errorf("Expected entries in stream order, got %v", handled)
+	}
+	streams.mu.Lock()
+	defer streams.mu.Unlock()
+	for consumer, ids := range streams.pending {
+		if len(ids) != 0 {
+			t.Errorf("Expected nothing pending for %s, got %v", consumer, ids)
+		}
+	}
+}
+
+func TestStream_DeadLetter(t *testing.T) {
+	ctx := context.Background()
+	streams := &fakeStreams{stream: "pgas:events"}
+	client := NewClient(serve(t, streams.reply))
+	defer client.Close()
+
+	consumer := NewStreamConsumer(client, "pgas:events", "billing", "instance-1")
+	consumer.MaxDeliveries = 3
+	consumer.CreateGroup(ctx)
+
+	publisher := NewStreamPublisher(client, "pgas:events")
+	publisher.Codec = codec.Msgpack
+	publisher.Publish(ctx, events.Event{Type: events.TypePaymentDeferred, TransactionID: "tx_1"})
+	publisher.Publish(ctx, events.Event{Type: events.TypePaymentForwarded, TransactionID: "tx_1"})
+
+	// the deferred event always fails, it must not hold up the others
+	poison := errors.New("cannot handle")
+	var handled []string
+	for poll := 0; poll < 5; poll++ {
+		consumer.Poll(ctx, func(ctx context.Context, event events.Event) error {
+			if event.Type == events.TypePaymentDeferred {
+				return poison
+			}
+			handled = append(handled, event.Type)
+			return nil
+		})
+	}
+	if len(handled) != 1 || handled[0] != events.TypePaymentForwarded {
+		t.Errorf("Expected the forwarded event handled once, got %v", handled)
+	}
+
+	streams.mu.Lock()
+	defer streams.mu.Unlock()
+	dead := streams.others["pgas:events:dead"]
+	if len(dead) != 1 || dead[0]["type"] != events.TypePaymentDeferred || dead[0]["error"] != "cannot handle" || dead[0]["codec"] != "msgpack" {
+		t.Fatalf("Expected the deferred event dead-lettered with its error, got %+v", dead)
+	}
+	if streams.deliveries[dead[0]["id"]] != 3 {
+		t.Errorf("Expected three deliveries before giving up, got %d", streams.deliveries[dead[0]["id"]])
+	}
+	for consumer, ids := range streams.pending {
+		if len(ids) != 0 {
+			t.Errorf("Expected nothing pending for %s, got %v", consumer, ids)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+// Package replay protects the server API against replayed requests. Clients
+// send a timestamp, a single-use nonce and an HMAC binding both to the
+// request with every request; requests outside the skew window, reusing a
+// nonce or re-signed without the secret are rejected, so a captured payment
+// submission cannot be sent again even with a leaked API key.
+package replay
+
+import (
+	"bytes"
+	"container/heap"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"pgas/pkg/problem"
+)
+
+// headers carrying the replay protection values
+const (
+	HeaderTimestamp = "X-PGAS-Timestamp" // unix seconds
+	HeaderNonce     = "X-PGAS-Nonce"
+	HeaderSignature = "X-PGAS-Signature" // "v1=" and the hex HMAC-SHA256, see Sign
+)
+
+const signatureVersion = "v1="
+
+// longest accepted nonce, keeps the nonce store bounded per entry
+const maxNonceLength = 128
+
+// largest request body the middleware reads to verify it, larger ones are
+// rejected with 413
+const maxRequestBody = 1 << 20
+
+var (
+	ErrMissing   = errors.New("timestamp, nonce and signature are required")
+	ErrSkewed    = errors.New("timestamp is outside the accepted window")
+	ErrSignature = errors.New("request signature does not match")
+	ErrReplayed  = errors.New("nonce was already used")
+)
+
+// Sign returns the signature header value of a request. The MAC covers
+// method, path with query, timestamp, nonce and body, so none of them can
+// be changed on a captured request.
+func Sign(secret []byte, method, path, timestamp, nonce string, body []byte) string {
+	return signatureVersion + hex.EncodeToString(mac(secret, method, path, timestamp, nonce, body))
+}
+
+// SignRequest stamps a client request with the current time, a random
+// nonce and their signature. body must be the request's body.
+func SignRequest(r *http.Request, secret, body []byte) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	random := make([]byte, 16)
+	rand.Read(random)
+	nonce := hex.EncodeToString(random)
+
+	r.Header.Set(HeaderTimestamp, timestamp)
+	r.Header.Set(HeaderNonce, nonce)
+	r.Header.Set(HeaderSignature, Sign(secret, r.Method, r.URL.RequestURI(), timestamp, nonce, body))
+}
+
+func mac(secret []byte, method, path, timestamp, nonce string, body []byte) []byte {
+	h := hmac.New(sha256.New, secret)
+	for _, part := range []string{method, path, timestamp, nonce} {
+		h.Write([]byte(part))
+		h.Write([]byte("\n"))
+	}
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// NonceStore remembers used nonces until they expire. Add must be atomic
+// across all server instances sharing the store.
+type NonceStore interface {
+	// Add records nonce and reports false when it was already present
+	Add(nonce string, expiresAt time.Time) (bool, error)
+}
+
+// MemoryNonces is a NonceStore for a single server instance
+type MemoryNonces struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time
+	expiry nonceHeap // soonest expiry first, to drop expired nonces cheaply
+	now    func() time.Time
+}
+
+func NewMemoryNonces() *MemoryNonces {
+	return &MemoryNonces{nonces: make(map[string]time.Time), now: time.Now}
+}
+
+func (m *MemoryNonces) Add(nonce string, expiresAt time.Time) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	// expired nonces can no longer pass the timestamp check, drop them
+	for len(m.expiry) > 0 && !now.Before(m.expiry[0].expiresAt) {
+		expired := heap.Pop(&m.expiry).(nonceExpiry)
+		// a nonce added again after it expired has a later entry of its own
+		if m.nonces[expired.nonce].Equal(expired.expiresAt) {
+			delete(m.nonces, expired.nonce)
+		}
+	}
+
+	if existing, ok := m.nonces[nonce]; ok && now.Before(existing) {
+		return false, nil
+	}
+	m.nonces[nonce] = expiresAt
+	heap.Push(&m.expiry, nonceExpiry{nonce: nonce, expiresAt: expiresAt})
+	return true, nil
+}
+
+type nonceExpiry struct {
+	nonce     string
+	expiresAt time.Time
+}
+
+// nonceHeap orders nonces by expiry for container/heap
+type nonceHeap []nonceExpiry
+
+func (h nonceHeap) Len() int            { return len(h) }
+func (h nonceHeap) Less(i, j int) bool  { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h nonceHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nonceHeap) Push(x interface{}) { *h = append(*h, x.(nonceExpiry)) }
+
+func (h *nonceHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
+
+// Guard validates request signatures, timestamps and nonces. Several
+// secrets are accepted so a client's secret can be rotated; a guard without
+// secrets accepts no request.
+type Guard struct {
+	// Skew is how far a timestamp may be from the server clock, either way
+	Skew    time.Duration
+	Nonces  NonceStore
+	Secrets [][]byte
+	Now     func() time.Time
+}
+
+func NewGuard(skew time.Duration, nonces NonceStore, secrets ...[]byte) *Guard {
+	return &Guard{Skew: skew, Nonces: nonces, Secrets: secrets, Now: time.Now}
+}
+
+// Check accepts a signed request once. The signature is verified before
+// the nonce is used up, so forged requests cannot burn the nonces of real
+// ones. Nonces are kept for twice the skew, past that the timestamp check
+// alone rejects the request.
+func (g *Guard) Check(method, path string, header http.Header, body []byte) error {
+	timestamp, nonce, signature := header.Get(HeaderTimestamp), header.Get(HeaderNonce), header.Get(HeaderSignature)
+	if timestamp == "" || nonce == "" || len(nonce) > maxNonceLength || !strings.HasPrefix(signature, signatureVersion) {
+		return ErrMissing
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrMissing
+	}
+
+	sent := time.Unix(seconds, 0)
+	now := g.Now()
+	if sent.Before(now.Add(-g.Skew)) || sent.After(now.Add(g.Skew)) {
+		return ErrSkewed
+	}
+
+	if !g.signed(signature, method, path, timestamp, nonce, body) {
+		return ErrSignature
+	}
+
+	fresh, err := g.Nonces.Add(nonce, sent.Add(2*g.Skew))
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrReplayed
+	}
+	return nil
+}
+
+func (g *Guard) signed(signature, method, path, timestamp, nonce string, body []byte) bool {
+	given, err := hex.DecodeString(strings.TrimPrefix(signature, signatureVersion))
+	if err != nil {
+		return false
+	}
+	for _, secret := range g.Secrets {
+		if hmac.Equal(given, mac(secret, method, path, timestamp, nonce, body)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware rejects requests failing Check before they reach next, which
+// reads the verified body as usual. The signed path is the request URI,
+// query included.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		var err error
+		if r.Body != nil {
+			body, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBody))
+		}
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Write(w, r, problem.New(http.StatusRequestEntityTooLarge, problem.CategoryMalformed, "REQUEST_TOO_LARGE",
+				"request body exceeds "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes"))
+			return
+		}
+		if err == nil {
+			err = g.Check(r.Method, r.URL.RequestURI(), r.Header, body)
+		}
+		if err == nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		p := problem.New(http.StatusUnauthorized, problem.CategoryUnauthorized, "INVALID_REQUEST_TIMESTAMP", err.Error())
+		switch {
+		case errors.Is(err, ErrSignature):
+			p = problem.New(http.StatusUnauthorized, problem.CategoryUnauthorized, "INVALID_SIGNATURE", err.Error())
+		case errors.Is(err, ErrReplayed):
+			p = problem.New(http.StatusConflict, problem.CategoryConflict, "REPLAYED_REQUEST", err.Error())
+		case !errors.Is(err, ErrMissing) && !errors.Is(err, ErrSkewed):
+			// the nonce store is down, the client may retry shortly
+			p = problem.New(http.StatusServiceUnavailable, problem.CategoryUnavailable, "PROCESSING_ERROR", err.Error())
+			p.Retryable = true
+			p.RetryAfter = 1
+		}
+		problem.Write(w, r, p)
+	})
+}
@@ -0,0 +1,208 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/problem"
+)
+
+var secret = []byte("client-secret")
+
+func signedHeader(secret []byte, method, path, timestamp, nonce, body string) http.Header {
+	header := http.Header{}
+	header.Set(HeaderTimestamp, timestamp)
+	header.Set(HeaderNonce, nonce)
+	header.Set(HeaderSignature, Sign(secret, method, path, timestamp, nonce, []byte(body)))
+	return header
+}
+
+func TestGuard_Check(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	nonces := NewMemoryNonces()
+	nonces.now = func() time.Time { return now }
+	guard := NewGuard(5*time.Minute, nonces, []byte("old-secret"), secret)
+	guard.Now = func() time.Time { return now }
+
+	stamp := func(offset time.Duration) string { return strconv.FormatInt(now.Add(offset).Unix(), 10) }
+	check := func(timestamp, nonce string) error {
+		return guard.Check(http.MethodPost, "/v1/payments", signedHeader(secret, http.MethodPost, "/v1/payments", timestamp, nonce, "{}"), []byte("{}"))
+	}
+
+	tests := []struct {
+		name      string
+		timestamp string
+		nonce     string
+		want      error
+	}{
+		{"fresh", stamp(0), "n1", nil},
+		{"replayed", stamp(0), "n1", ErrReplayed},
+		{"slightly behind", stamp(-4 * time.Minute), "n2", nil},
+		{"too old", stamp(-6 * time.Minute), "n3", ErrSkewed},
+		{"from the future", stamp(6 * time.Minute), "n4", ErrSkewed},
+		{"no nonce", stamp(0), "", ErrMissing},
+		{"malformed timestamp", "yesterday", "n5", ErrMissing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := check(tt.timestamp, tt.nonce); !errors.Is(err, tt.want) {
+				t.Errorf("Check() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// once the nonce expired its timestamp is out of the window as well
+	now = now.Add(11 * time.Minute)
+	if err := check(stamp(-11*time.Minute), "n1"); !errors.Is(err, ErrSkewed) {
+		t.Errorf("Expected the old request to stay rejected, got %v", err)
+	}
+	if err := check(stamp(0), "n6"); err != nil {
+		t.Fatalf("Expected a fresh request to pass, got %v", err)
+	}
+	if len(nonces.nonces) != 1 {
+		t.Errorf("Expected expired nonces to be dropped, got %d", len(nonces.nonces))
+	}
+}
+
+func TestGuard_Signature(t *testing.T) {
+	guard := NewGuard(time.Minute, NewMemoryNonces(), secret)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	header := signedHeader(secret, http.MethodPost, "/v1/payments", timestamp, "n1", `{"amount": 10}`)
+
+	// a captured request re-dated with a fresh nonce keeps the old signature
+	redated := header.Clone()
+	redated.Set(HeaderTimestamp, strconv.FormatInt(time.Now().Add(time.Second).Unix(), 10))
+	redated.Set(HeaderNonce, "n2")
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header http.Header
+		body   string
+		want   error
+	}{
+		{"re-dated with a new nonce", http.MethodPost, "/v1/payments", redated, `{"amount": 10}`, ErrSignature},
+		{"other body", http.MethodPost, "/v1/payments", header, `{"amount": 1000}`, ErrSignature},
+		{"other path", http.MethodPost, "/v1/refunds", header, `{"amount": 10}`, ErrSignature},
+		{"other secret", http.MethodPost, "/v1/payments", signedHeader([]byte("guess"), http.MethodPost, "/v1/payments", timestamp, "n3", ""), "", ErrSignature},
+		{"unsigned", http.MethodPost, "/v1/payments", http.Header{HeaderTimestamp: {timestamp}, HeaderNonce: {"n4"}}, "", ErrMissing},
+		// the forgeries above did not use up the nonce of the real request
+		{"signed", http.MethodPost, "/v1/payments", header, `{"amount": 10}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := guard.Check(tt.method, tt.path, tt.header, []byte(tt.body)); !errors.Is(err, tt.want) {
+				t.Errorf("Check() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if err := NewGuard(time.Minute, NewMemoryNonces()).Check(http.MethodPost, "/v1/payments", header, []byte(`{"amount": 10}`)); !errors.Is(err, ErrSignature) {
+		t.Errorf("Expected a guard without secrets to accept nothing, got %v", err)
+	}
+}
+
+func TestGuard_Middleware(t *testing.T) {
+	guard := NewGuard(time.Minute, NewMemoryNonces(), secret)
+	handler := guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if body, _ := io.ReadAll(r.Body); string(body) != `{"amount": 10}` {
+			t.Errorf("Expected the verified body to reach the handler, got %s", body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	body := `{"amount": 10}`
+	header := signedHeader(secret, http.MethodPost, "/v1/payments?dry_run=1", strconv.FormatInt(time.Now().Unix(), 10), "abc", body)
+
+	for _, want := range []int{http.StatusCreated, http.StatusConflict} {
+		request := httptest.NewRequest(http.MethodPost, "/v1/payments?dry_run=1", strings.NewReader(body))
+		request.Header = header.Clone()
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		if recorder.Code != want {
+			t.Errorf("Expected status %d, got %d: %s", want, recorder.Code, recorder.Body)
+		}
+		if want == http.StatusConflict {
+			var problemBody problem.Problem
+			json.Unmarshal(recorder.Body.Bytes(), &problemBody)
+			if recorder.Header().Get("Content-Type") != problem.ContentType || problemBody.Code != "REPLAYED_REQUEST" {
+				t.Errorf("Expected a REPLAYED_REQUEST problem, got %s", recorder.Body)
+			}
+		}
+	}
+
+	tampered := httptest.NewRequest(http.MethodPost, "/v1/payments?dry_run=0", strings.NewReader(body))
+	tampered.Header = header.Clone()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, tampered)
+	var problemBody problem.Problem
+	json.Unmarshal(recorder.Body.Bytes(), &problemBody)
+	if recorder.Code != http.StatusUnauthorized || problemBody.Code != "INVALID_SIGNATURE" {
+		t.Errorf("Expected a tampered query to be rejected, got %d %s", recorder.Code, recorder.Body)
+	}
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/payments", nil))
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unsigned request to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestMemoryNonces_Expiry(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	nonces := NewMemoryNonces()
+	nonces.now = func() time.Time { return now }
+
+	nonces.Add("a", now.Add(time.Minute))
+	nonces.Add("b", now.Add(3*time.Minute))
+
+	now = now.Add(2 * time.Minute)
+	if fresh, _ := nonces.Add("a", now.Add(time.Minute)); !fresh {
+		t.Fatal("Expected an expired nonce to be accepted again")
+	}
+	if fresh, _ := nonces.Add("b", now.Add(time.Minute)); fresh {
+		t.Error("Expected a live nonce to be rejected")
+	}
+
+	// the first expiry of "a" is gone, its second one must keep it
+	if fresh, _ := nonces.Add("c", now.Add(time.Minute)); !fresh || len(nonces.nonces) != 3 {
+		t.Fatalf("Expected a, b and c stored, got %v", nonces.nonces)
+	}
+	if fresh, _ := nonces.Add("a", now.Add(time.Minute)); fresh {
+		t.Error("Expected the re-added nonce to stay used")
+	}
+
+	now = now.Add(2 * time.Minute)
+	nonces.Add("d", now.Add(time.Minute))
+	if len(nonces.nonces) != 1 || len(nonces.expiry) != 1 {
+		t.Errorf("Expected only d left, got %v", nonces.nonces)
+	}
+}
+
+func TestGuard_MiddlewareBodyTooLarge(t *testing.T) {
+	guard := NewGuard(time.Minute, NewMemoryNonces(), secret)
+	handler := guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected an oversized body not to reach the handler")
+	}))
+
+	body := strings.Repeat("x", maxRequestBody+1)
+	request := httptest.NewRequest(http.MethodPost, "/v1/payments", strings.NewReader(body))
+	request.Header = signedHeader(secret, http.MethodPost, "/v1/payments", strconv.FormatInt(time.Now().Unix(), 10), "big", body)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+
+	var problemBody problem.Problem
+	json.Unmarshal(recorder.Body.Bytes(), &problemBody)
+	if recorder.Code != http.StatusRequestEntityTooLarge || problemBody.Code != "REQUEST_TOO_LARGE" {
+		t.Errorf("Expected 413 REQUEST_TOO_LARGE, got %d %s", recorder.Code, recorder.Body)
+	}
+}
@@ -0,0 +1,167 @@
+// Package reporting aggregates processed operations for finance and
+// operations reports.
+package reporting
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// RefundRecord is a completed refund as seen by reporting
+type RefundRecord struct {
+	RefundID      string                 `json:"refund_id"`
+	TransactionID string                 `json:"transaction_id"`
+	Provider      string                 `json:"provider"`
+	Amount        float64                `json:"amount"`
+	Currency      string                 `json:"currency"`
+	Reason        providers.RefundReason `json:"reason,omitempty"`
+	Time          time.Time              `json:"time"`
+	// metadata and tags of the refunded payment
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+}
+
+// ReasonSummary aggregates the refunds sharing a reason
+type ReasonSummary struct {
+	Reason   providers.RefundReason `json:"reason"`
+	Category string                 `json:"category"`
+	Count    int                    `json:"count"`
+	Totals   map[string]float64     `json:"totals"` // refunded amount per currency
+}
+
+// DimensionSummary aggregates the refunds of payments sharing the value of a
+// business dimension
+type DimensionSummary struct {
+	Dimension string             `json:"dimension"`
+	Value     string             `json:"value"`
+	Count     int                `json:"count"`
+	Totals    map[string]float64 `json:"totals"` // refunded amount per currency
+}
+
+// RefundLedger collects refund records, safe for concurrent use
+type RefundLedger struct {
+	mu      sync.Mutex
+	records []RefundRecord
+}
+
+func NewRefundLedger() *RefundLedger {
+	return &RefundLedger{}
+}
+
+func (l *RefundLedger) Record(record RefundRecord) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, record)
+}
+
+// Records returns the refunds recorded at or after since
+func (l *RefundLedger) Records(since time.Time) []RefundRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var records []RefundRecord
+	for _, record := range l.records {
+		if !record.Time.Before(since) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// ForTransaction returns the refunds of a payment
+func (l *RefundLedger) ForTransaction(transactionID string) []RefundRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var records []RefundRecord
+	for _, record := range l.records {
+		if record.TransactionID == transactionID {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// ByReason summarizes refunds since the given time per reason, most frequent first
+func (l *RefundLedger) ByReason(since time.Time) []ReasonSummary {
+	return SummarizeRefunds(l.Records(since))
+}
+
+// SummarizeRefunds groups refund records by reason, most frequent first
+func SummarizeRefunds(records []RefundRecord) []ReasonSummary {
+	byReason := make(map[providers.RefundReason]*ReasonSummary)
+	for _, record := range records {
+		summary, ok := byReason[record.Reason]
+		if !ok {
+			summary = &ReasonSummary{
+				Reason:   record.Reason,
+				Category: record.Reason.Category(),
+				Totals:   make(map[string]float64),
+			}
+			byReason[record.Reason] = summary
+		}
+		summary.Count++
+		summary.Totals[record.Currency] = roundAmount(summary.Totals[record.Currency] + record.Amount)
+	}
+
+	summaries := make([]ReasonSummary, 0, len(byReason))
+	for _, summary := range byReason {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		return summaries[i].Reason < summaries[j].Reason
+	})
+	return summaries
+}
+
+// ByDimension summarizes refunds since the given time per value of a
+// business dimension, most frequent first
+func (l *RefundLedger) ByDimension(dimension string, since time.Time) ([]DimensionSummary, error) {
+	return SummarizeRefundsBy(l.Records(since), dimension)
+}
+
+// SummarizeRefundsBy groups refund records by store.DimensionTag or
+// store.MetadataDimension(key), most frequent first. Refunds of payments
+// without the dimension are grouped under "".
+func SummarizeRefundsBy(records []RefundRecord, dimension string) ([]DimensionSummary, error) {
+	if err := store.ValidateDimension(dimension); err != nil {
+		return nil, err
+	}
+
+	byValue := make(map[string]*DimensionSummary)
+	for _, record := range records {
+		for _, value := range store.DimensionValues(dimension, record.Metadata, record.Tags) {
+			summary, ok := byValue[value]
+			if !ok {
+				summary = &DimensionSummary{Dimension: dimension, Value: value, Totals: make(map[string]float64)}
+				byValue[value] = summary
+			}
+			summary.Count++
+			summary.Totals[record.Currency] = roundAmount(summary.Totals[record.Currency] + record.Amount)
+		}
+	}
+
+	summaries := make([]DimensionSummary, 0, len(byValue))
+	for _, summary := range byValue {
+		summaries = append(summaries, *summary)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Count != summaries[j].Count {
+			return summaries[i].Count > summaries[j].Count
+		}
+		return summaries[i].Value < summaries[j].Value
+	})
+	return summaries, nil
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
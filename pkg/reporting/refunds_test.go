@@ -0,0 +1,62 @@
+package reporting
+
+import (
+	"testing"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+func TestRefundLedger_ByReason(t *testing.T) {
+	ledger := NewRefundLedger()
+	now := time.Now()
+
+	ledger.Record(RefundRecord{RefundID: "old", Amount: 99, Currency: "USD", Reason: providers.RefundFraud, Time: now.Add(-48 * time.Hour)})
+	ledger.Record(RefundRecord{RefundID: "r1", Amount: 10.10, Currency: "USD", Reason: providers.RefundCustomerRequest, Time: now})
+	ledger.Record(RefundRecord{RefundID: "r2", Amount: 5.20, Currency: "USD", Reason: providers.RefundCustomerRequest, Time: now})
+	ledger.Record(RefundRecord{RefundID: "r3", Amount: 7, Currency: "EUR", Reason: providers.RefundCustomerRequest, Time: now})
+	ledger.Record(RefundRecord{RefundID: "r4", Amount: 3, Currency: "USD", Time: now})
+
+	summaries := ledger.ByReason(now.Add(-time.Hour))
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 reasons, got %+v", summaries)
+	}
+
+	top := summaries[0]
+	if top.Reason != providers.RefundCustomerRequest || top.Count != 3 || top.Category != providers.RefundCategoryCustomer {
+		t.Errorf("Expected customer requests first, got %+v", top)
+	}
+
+	if top.Totals["USD"] != 15.30 || top.Totals["EUR"] != 7 {
+		t.Errorf("Expected per-currency totals, got %+v", top.Totals)
+	}
+
+	if summaries[1].Category != providers.RefundCategoryUncategorized {
+		t.Errorf("Expected refunds without reason to be uncategorized, got %+v", summaries[1])
+	}
+}
+
+func TestSummarizeRefundsBy(t *testing.T) {
+	records := []RefundRecord{
+		{Amount: 10, Currency: "USD", Metadata: map[string]string{"channel": "web"}},
+		{Amount: 2.5, Currency: "USD", Metadata: map[string]string{"channel": "web"}},
+		{Amount: 4, Currency: "EUR", Metadata: map[string]string{"channel": "ios"}},
+		{Amount: 1, Currency: "EUR"},
+	}
+
+	summaries, err := SummarizeRefundsBy(records, store.MetadataDimension("channel"))
+	if err != nil {
+		t.Fatalf("Expected summaries, got error: %v", err)
+	}
+	if len(summaries) != 3 || summaries[0].Value != "web" || summaries[0].Count != 2 || summaries[0].Totals["USD"] != 12.5 {
+		t.Errorf("Expected web refunds first, got %+v", summaries)
+	}
+	if summaries[1].Value != "" || summaries[2].Value != "ios" {
+		t.Errorf("Expected ties ordered by value, got %+v", summaries)
+	}
+
+	if _, err := SummarizeRefundsBy(records, "metadata."); err == nil {
+		t.Error("Expected a metadata dimension without key to be rejected")
+	}
+}
@@ -0,0 +1,203 @@
+// Package sandbox catalogs the test card numbers the bundled simulators
+// recognize and the outcome each one triggers, so QA tools and
+// documentation can enumerate scenarios instead of hard-coding them.
+package sandbox
+
+import (
+	"sort"
+
+	"pgas/pkg/providers"
+)
+
+// scenario operations
+const (
+	OperationPayment        = "payment"        // outcome of ProcessPayment
+	OperationAuthentication = "authentication" // outcome of the 3-D Secure simulator
+)
+
+// payment methods of scenarios
+const (
+	MethodCard       = "card"
+	MethodUPICollect = "upi_collect"
+	MethodACH        = "ach"
+)
+
+// Scenario is one test input and the outcome it triggers
+type Scenario struct {
+	Provider   string `json:"provider"`
+	Operation  string `json:"operation"`
+	Method     string `json:"method"`
+	CardNumber string `json:"card_number,omitempty"`
+	VPA        string `json:"vpa,omitempty"` // UPI virtual payment address
+	// AccountNumber is the bank account of ACH scenarios
+	AccountNumber string `json:"account_number,omitempty"`
+	// Status is the normalized payment status, or the 3-D Secure status for
+	// authentication scenarios
+	Status      string `json:"status"`
+	ErrorCode   string `json:"error_code,omitempty"` // provider error code of declines
+	Reason      string `json:"reason,omitempty"`
+	Advice      string `json:"advice,omitempty"` // issuer retry advice of declines
+	Description string `json:"description"`
+}
+
+var catalog = []Scenario{
+	// visa payments
+	{Provider: "visa", Operation: OperationPayment, Method: MethodCard, CardNumber: "4111111111111111",
+		Status: providers.StatusApproved, Description: "Approved"},
+	{Provider: "visa", Operation: OperationPayment, Method: MethodCard, CardNumber: "4000000000000259",
+		Status: providers.StatusPending, Description: "Accepted, outcome pending"},
+	{Provider: "visa", Operation: OperationPayment, Method: MethodCard, CardNumber: "4000000000009995",
+		Status: providers.StatusDeclined, ErrorCode: "EE000051", Reason: providers.ReasonInsufficientFunds,
+		Description: "Declined for insufficient funds"},
+	{Provider: "visa", Operation: OperationPayment, Method: MethodCard, CardNumber: "4000000000000069",
+		Status: providers.StatusDeclined, ErrorCode: "EE000054", Reason: providers.ReasonExpiredCard,
+		Advice: providers.AdviceUpdateCard, Description: "Declined as expired, retry with updated card data"},
+	{Provider: "visa", Operation: OperationPayment, Method: MethodCard, CardNumber: "4000000000000127",
+		Status: providers.StatusDeclined, ErrorCode: "EE000005", Reason: providers.ReasonDoNotHonor,
+		Advice: providers.AdviceDoNotRetry, Description: "Do not honor, the issuer forbids retries"},
+	{Provider: "visa", Operation: OperationPayment, Method: MethodCard, CardNumber: "4000000000000119",
+		Status: providers.StatusDeclined, ErrorCode: "EE000096", Reason: providers.ReasonProcessingError,
+		Description: "Gateway processing error, retryable"},
+
+	// mastercard payments
+	{Provider: "mastercard", Operation: OperationPayment, Method: MethodCard, CardNumber: "5555555555554444",
+		Status: providers.StatusApproved, Description: "Approved"},
+	{Provider: "mastercard", Operation: OperationPayment, Method: MethodCard, CardNumber: "2223003122003222",
+		Status: providers.StatusApproved, Description: "Approved, 2-series BIN"},
+	{Provider: "mastercard", Operation: OperationPayment, Method: MethodCard, CardNumber: "5105105105105100",
+		Status: providers.StatusDeclined, ErrorCode: "MC0001", Reason: providers.ReasonInsufficientFunds,
+		Description: "Declined for insufficient funds"},
+	{Provider: "mastercard", Operation: OperationPayment, Method: MethodCard, CardNumber: "5105105105105019",
+		Status: providers.StatusDeclined, ErrorCode: "MC0002", Reason: providers.ReasonCardDeclined,
+		Advice: providers.AdviceDoNotRetry, Description: "Declined with Merchant Advice Code 03, do not retry"},
+	{Provider: "mastercard", Operation: OperationPayment, Method: MethodCard, CardNumber: "5555555555550913",
+		Status: providers.StatusDeclined, ErrorCode: "MC0091", Reason: providers.ReasonIssuerUnavailable,
+		Advice: providers.AdviceRetryLater, Description: "Issuer unavailable, retry later"},
+
+	// amex payments
+	{Provider: "amex", Operation: OperationPayment, Method: MethodCard, CardNumber: "378282246310005",
+		Status: providers.StatusApproved, Description: "Approved"},
+	{Provider: "amex", Operation: OperationPayment, Method: MethodCard, CardNumber: "371449635398431",
+		Status: providers.StatusApproved, Description: "Approved, 34/37 range"},
+	{Provider: "amex", Operation: OperationPayment, Method: MethodCard, CardNumber: "370000000000259",
+		Status: providers.StatusPending, Description: "Accepted for issuer review, outcome pending"},
+	{Provider: "amex", Operation: OperationPayment, Method: MethodCard, CardNumber: "370000000000119",
+		Status: providers.StatusDeclined, ErrorCode: "116", Reason: providers.ReasonInsufficientFunds,
+		Description: "Declined for insufficient funds"},
+	{Provider: "amex", Operation: OperationPayment, Method: MethodCard, CardNumber: "370000000000127",
+		Status: providers.StatusDeclined, ErrorCode: "122", Reason: providers.ReasonInvalidCard,
+		Description: "Declined for a wrong CID"},
+	{Provider: "amex", Operation: OperationPayment, Method: MethodCard, CardNumber: "370000000000911",
+		Status: providers.StatusDeclined, ErrorCode: "911", Reason: providers.ReasonIssuerUnavailable,
+		Description: "Card issuer timed out, retryable"},
+
+	// upi collect payments
+	{Provider: "upi", Operation: OperationPayment, Method: MethodUPICollect, VPA: "success@upi",
+		Status: providers.StatusApproved, Description: "Approved by the customer"},
+	{Provider: "upi", Operation: OperationPayment, Method: MethodUPICollect, VPA: "pending@upi",
+		Status: providers.StatusPending, Description: "Collect request awaiting the customer"},
+	{Provider: "upi", Operation: OperationPayment, Method: MethodUPICollect, VPA: "insufficient@upi",
+		Status: providers.StatusDeclined, ErrorCode: "Z9", Reason: providers.ReasonInsufficientFunds,
+		Description: "Declined for insufficient funds"},
+	{Provider: "upi", Operation: OperationPayment, Method: MethodUPICollect, VPA: "declined@upi",
+		Status: providers.StatusDeclined, ErrorCode: "ZA", Reason: providers.ReasonCardDeclined,
+		Description: "Collect request declined by the customer"},
+	{Provider: "upi", Operation: OperationPayment, Method: MethodUPICollect, VPA: "expired@upi",
+		Status: providers.StatusDeclined, ErrorCode: "U69", Reason: providers.ReasonCardDeclined,
+		Description: "Collect request expired unanswered"},
+	{Provider: "upi", Operation: OperationPayment, Method: MethodUPICollect, VPA: "bankdown@upi",
+		Status: providers.StatusDeclined, ErrorCode: "BT", Reason: providers.ReasonIssuerUnavailable,
+		Description: "Remitter bank unavailable, retryable"},
+
+	// ach debits are accepted as PENDING, declines arrive as returns once
+	// the settlement delay passed
+	{Provider: "ach", Operation: OperationPayment, Method: MethodACH, AccountNumber: "000123456789",
+		Status: providers.StatusApproved, Description: "Settled"},
+	{Provider: "ach", Operation: OperationPayment, Method: MethodACH, AccountNumber: "000111111113",
+		Status: providers.StatusPending, Description: "Submitted, never settles"},
+	{Provider: "ach", Operation: OperationPayment, Method: MethodACH, AccountNumber: "000111111116",
+		Status: providers.StatusDeclined, ErrorCode: "R01", Reason: providers.ReasonInsufficientFunds,
+		Description: "Returned for insufficient funds"},
+	{Provider: "ach", Operation: OperationPayment, Method: MethodACH, AccountNumber: "000111111123",
+		Status: providers.StatusDeclined, ErrorCode: "R02", Reason: providers.ReasonCardDeclined,
+		Description: "Returned, account closed"},
+	{Provider: "ach", Operation: OperationPayment, Method: MethodACH, AccountNumber: "000111111131",
+		Status: providers.StatusDeclined, ErrorCode: "R03", Reason: providers.ReasonInvalidCard,
+		Description: "Returned, no account or unable to locate account"},
+	{Provider: "ach", Operation: OperationPayment, Method: MethodACH, AccountNumber: "000111111129",
+		Status: providers.StatusDeclined, ErrorCode: "R29", Reason: providers.ReasonSuspectedFraud,
+		Description: "Returned, corporate customer advises not authorized"},
+}
+
+// the 3-D Secure simulator picks its outcome by the last four digits, any
+// card ending in other digits authenticates frictionlessly
+var authentications = []struct {
+	suffix      string
+	status      string
+	description string
+}{
+	{"0001", providers.AuthStatusChallenge, "Challenge required"},
+	{"0002", providers.AuthStatusFailed, "Authentication failed"},
+	{"0003", providers.AuthStatusUnavailable, "Authentication unavailable"},
+	{"0004", providers.AuthStatusAttempted, "Authentication attempted"},
+}
+
+// Luhn-valid cards per provider ending in each simulator suffix
+var authenticationCards = map[string][]string{
+	"visa":       {"4000000000010001", "4000000000000002", "4000000000090003", "4000000000080004"},
+	"mastercard": {"5555555555580001", "5555555555570002", "5555555555560003", "5555555555550004"},
+	"amex":       {"370000001000001", "370000000000002", "370000009000003", "370000008000004"},
+}
+
+// Scenarios lists every scenario, ordered by provider, operation and card
+// number. The returned slice is a copy and may be modified.
+func Scenarios() []Scenario {
+	scenarios := append([]Scenario(nil), catalog...)
+	for provider, cards := range authenticationCards {
+		for i, authentication := range authentications {
+			scenarios = append(scenarios, Scenario{
+				Provider:    provider,
+				Operation:   OperationAuthentication,
+				Method:      MethodCard,
+				CardNumber:  cards[i],
+				Status:      authentication.status,
+				Description: authentication.description,
+			})
+		}
+	}
+
+	sort.SliceStable(scenarios, func(i, j int) bool {
+		a, b := scenarios[i], scenarios[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Operation != b.Operation {
+			return a.Operation > b.Operation // payments first
+		}
+		return a.CardNumber+a.VPA+a.AccountNumber < b.CardNumber+b.VPA+b.AccountNumber
+	})
+	return scenarios
+}
+
+// ForProvider lists the scenarios of one provider
+func ForProvider(provider string) []Scenario {
+	var scenarios []Scenario
+	for _, scenario := range Scenarios() {
+		if scenario.Provider == provider {
+			scenarios = append(scenarios, scenario)
+		}
+	}
+	return scenarios
+}
+
+// Payment returns the payment scenario a simulator should play for a card
+// number, VPA or bank account number, if there is one
+func Payment(provider, value string) (Scenario, bool) {
+	for _, scenario := range catalog {
+		if scenario.Provider == provider && scenario.Operation == OperationPayment &&
+			value != "" && (scenario.CardNumber == value || scenario.VPA == value || scenario.AccountNumber == value) {
+			return scenario, true
+		}
+	}
+	return Scenario{}, false
+}
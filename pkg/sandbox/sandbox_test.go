@@ -0,0 +1,79 @@
+package sandbox
+
+import (
+	"context"
+	"testing"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/threeds"
+	"pgas/pkg/validation"
+)
+
+func TestScenarios_Catalog(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, scenario := range Scenarios() {
+		key := scenario.Provider + "/" + scenario.CardNumber + scenario.VPA + scenario.AccountNumber
+		if seen[key] {
+			t.Errorf("Duplicate scenario %s", key)
+		}
+		seen[key] = true
+
+		if scenario.CardNumber != "" && !validation.Luhn(scenario.CardNumber) {
+			t.Errorf("Card %s fails the Luhn check", scenario.CardNumber)
+		}
+		if scenario.ErrorCode == "" {
+			continue
+		}
+		info, ok := providers.LookupErrorCode(scenario.Provider, scenario.ErrorCode)
+		if !ok || info.Reason != scenario.Reason {
+			t.Errorf("Card %s: expected %s to be catalogued with reason %s, got %+v", scenario.CardNumber, scenario.ErrorCode, scenario.Reason, info)
+		}
+	}
+
+	for _, provider := range []string{"ach", "amex", "mastercard", "upi", "visa"} {
+		if len(ForProvider(provider)) == 0 {
+			t.Errorf("Expected scenarios for %s", provider)
+		}
+	}
+	if first := Scenarios()[0]; first.Provider != "ach" || first.Operation != OperationPayment {
+		t.Errorf("Expected ach payments first, got %+v", first)
+	}
+}
+
+func TestScenarios_Authentication(t *testing.T) {
+	simulator := threeds.NewSimulator()
+	for _, scenario := range Scenarios() {
+		if scenario.Operation != OperationAuthentication {
+			continue
+		}
+		result, err := simulator.Authenticate(context.Background(), threeds.Request{CardNumber: scenario.CardNumber, Currency: "USD"})
+		if err != nil {
+			t.Fatalf("Expected simulated authentication, got error: %v", err)
+		}
+		if result.Authentication.Status != scenario.Status {
+			t.Errorf("Card %s: expected status %s, got %s", scenario.CardNumber, scenario.Status, result.Authentication.Status)
+		}
+	}
+}
+
+func TestPayment(t *testing.T) {
+	scenario, ok := Payment("visa", "4000000000009995")
+	if !ok || scenario.Reason != providers.ReasonInsufficientFunds {
+		t.Errorf("Expected the insufficient funds scenario, got %+v", scenario)
+	}
+	if _, ok := Payment("mastercard", "4000000000009995"); ok {
+		t.Error("Expected scenarios to be per provider")
+	}
+	if _, ok := Payment("visa", "4000000000010001"); ok {
+		t.Error("Expected authentication cards to leave payments to the simulator")
+	}
+	if scenario, ok := Payment("upi", "insufficient@upi"); !ok || scenario.ErrorCode != "Z9" {
+		t.Errorf("Expected a scenario by VPA, got %+v", scenario)
+	}
+	if scenario, ok := Payment("ach", "000111111116"); !ok || scenario.ErrorCode != "R01" {
+		t.Errorf("Expected a scenario by account number, got %+v", scenario)
+	}
+	if _, ok := Payment("visa", ""); ok {
+		t.Error("Expected no scenario for an empty card number")
+	}
+}
@@ -0,0 +1,196 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/webhooks"
+)
+
+// PaymentState is what the processor holds about a payment after a step
+type PaymentState struct {
+	Status   string  `json:"status"`   // stored status
+	Captured float64 `json:"captured"` // approved captures
+	Refunded float64 `json:"refunded"`
+	Disputed bool    `json:"disputed"`
+}
+
+// stepParams are the params of the built-in actions
+type stepParams struct {
+	Amount *float64 `json:"amount,omitempty"` // defaults to the payment's amount
+	Final  bool     `json:"final,omitempty"`
+	Reason string   `json:"reason,omitempty"` // refund reason or dispute reason code
+}
+
+func (r *Run) params(step Step) (stepParams, error) {
+	var params stepParams
+	if step.Params == nil {
+		return params, nil
+	}
+	if err := decode(step.Params, &params); err != nil {
+		return stepParams{}, fmt.Errorf("step '%s': %v", step.Name, err)
+	}
+	return params, nil
+}
+
+// payment returns the payment the step acts on, the response of its ref
+func (r *Run) payment(step Step) (*providers.PaymentResponse, error) {
+	outcome, err := r.Referenced(step)
+	if err != nil {
+		return nil, err
+	}
+	if outcome.Response == nil || outcome.Response.TransactionID == "" {
+		return nil, fmt.Errorf("step '%s' references step '%s' without a payment", step.Name, step.Ref)
+	}
+	return outcome.Response, nil
+}
+
+// state reads the payment's stored record and timeline, nil when the
+// payment was not stored
+func (r *Run) state(transactionID string) *PaymentState {
+	transactions := r.Processor.Transactions()
+	if transactions == nil || transactionID == "" {
+		return nil
+	}
+	tx, err := transactions.Get(transactionID)
+	if err != nil {
+		return nil
+	}
+	timeline, err := r.Processor.GetTimeline(transactionID)
+	if err != nil {
+		return nil
+	}
+
+	state := &PaymentState{Status: tx.Status}
+	for _, entry := range timeline {
+		amount, _ := strconv.ParseFloat(entry.Data["amount"], 64)
+		switch entry.Kind {
+		case processor.TimelineCapture:
+			if entry.Status == providers.StatusApproved {
+				state.Captured += amount
+			}
+		case processor.TimelineRefund:
+			state.Refunded += amount
+		case processor.TimelineDispute:
+			state.Disputed = true
+		}
+	}
+	return state
+}
+
+// followUp describes a capture, refund or dispute as a response of the
+// payment it acted on, so later steps can reference it like the payment
+func followUp(payment *providers.PaymentResponse, success bool, status string, amount float64, currency string) *providers.PaymentResponse {
+	return &providers.PaymentResponse{
+		Success:          success,
+		TransactionID:    payment.TransactionID,
+		GatewayReference: payment.GatewayReference,
+		Provider:         payment.Provider,
+		Status:           status,
+		Amount:           amount,
+		Currency:         currency,
+	}
+}
+
+func captureAction(ctx context.Context, run *Run, step Step) (Outcome, error) {
+	payment, err := run.payment(step)
+	if err != nil {
+		return Outcome{}, err
+	}
+	params, err := run.params(step)
+	if err != nil {
+		return Outcome{}, err
+	}
+
+	request := providers.CaptureRequest{
+		Mode:          payment.Provider,
+		TransactionID: payment.TransactionID,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Final:         params.Final,
+	}
+	if params.Amount != nil {
+		request.Amount = *params.Amount
+	}
+
+	outcome := Outcome{}
+	response, paymentError := run.Processor.CapturePayment(ctx, request)
+	if response != nil {
+		outcome.Response = followUp(payment, response.Success, response.Status, response.Amount, response.Currency)
+	}
+	outcome.Error = paymentError
+	outcome.State = run.state(payment.TransactionID)
+	return outcome, nil
+}
+
+func refundAction(ctx context.Context, run *Run, step Step) (Outcome, error) {
+	payment, err := run.payment(step)
+	if err != nil {
+		return Outcome{}, err
+	}
+	params, err := run.params(step)
+	if err != nil {
+		return Outcome{}, err
+	}
+
+	request := providers.RefundRequest{
+		Mode:          payment.Provider,
+		TransactionID: payment.TransactionID,
+		Amount:        payment.Amount,
+		Currency:      payment.Currency,
+		Reason:        providers.RefundReason(params.Reason),
+	}
+	if params.Amount != nil {
+		request.Amount = *params.Amount
+	}
+
+	outcome := Outcome{}
+	response, paymentError := run.Processor.RefundPayment(ctx, request)
+	if response != nil {
+		outcome.Response = followUp(payment, response.Success, response.Status, response.Amount, response.Currency)
+	}
+	outcome.Error = paymentError
+	outcome.State = run.state(payment.TransactionID)
+	return outcome, nil
+}
+
+// disputeAction applies a chargeback notification for the payment, as its
+// gateway would send one
+func disputeAction(ctx context.Context, run *Run, step Step) (Outcome, error) {
+	payment, err := run.payment(step)
+	if err != nil {
+		return Outcome{}, err
+	}
+	params, err := run.params(step)
+	if err != nil {
+		return Outcome{}, err
+	}
+
+	amount := payment.Amount
+	if params.Amount != nil {
+		amount = *params.Amount
+	}
+
+	result, err := run.Processor.ApplyWebhookEvent(ctx, webhooks.PaymentEvent{
+		ID:         "scenario-" + payment.TransactionID + "-" + step.Name,
+		Provider:   payment.Provider,
+		Type:       webhooks.EventChargeback,
+		Reference:  webhooks.Reference{Provider: payment.Provider, GatewayRef: payment.GatewayReference},
+		Amount:     amount,
+		Currency:   payment.Currency,
+		Reason:     params.Reason,
+		OccurredAt: time.Now(),
+	})
+	if err != nil {
+		return Outcome{}, fmt.Errorf("step '%s': dispute not applied: %v", step.Name, err)
+	}
+
+	return Outcome{
+		Response: followUp(payment, true, result.Status, amount, payment.Currency),
+		State:    run.state(payment.TransactionID),
+	}, nil
+}
@@ -0,0 +1,159 @@
+package scenario
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// decode assigns a parsed YAML tree onto target using the json field tags,
+// converting scalars leniently so unquoted values like `expiry_month: 12`
+// still land in string fields
+func decode(value interface{}, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer")
+	}
+	return assign(value, v.Elem(), "")
+}
+
+func assign(value interface{}, target reflect.Value, path string) error {
+	if value == nil {
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Ptr:
+		if target.IsNil() {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		return assign(value, target.Elem(), path)
+
+	case reflect.Interface:
+		target.Set(reflect.ValueOf(value))
+		return nil
+
+	case reflect.Struct:
+		mapping, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a mapping", displayPath(path))
+		}
+		fields := structFields(target.Type())
+		for key, item := range mapping {
+			index, ok := fields[key]
+			if !ok {
+				return fmt.Errorf("%s: unknown field '%s'", displayPath(path), key)
+			}
+			if err := assign(item, target.Field(index), joinPath(path, key)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		mapping, ok := value.(map[string]interface{})
+		if !ok || target.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("%s: expected a mapping", displayPath(path))
+		}
+		if target.IsNil() {
+			target.Set(reflect.MakeMap(target.Type()))
+		}
+		for key, item := range mapping {
+			element := reflect.New(target.Type().Elem()).Elem()
+			if err := assign(item, element, joinPath(path, key)); err != nil {
+				return err
+			}
+			target.SetMapIndex(reflect.ValueOf(key).Convert(target.Type().Key()), element)
+		}
+		return nil
+
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a list", displayPath(path))
+		}
+		slice := reflect.MakeSlice(target.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := assign(item, slice.Index(i), path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+		return nil
+
+	case reflect.String:
+		switch scalar := value.(type) {
+		case string:
+			target.SetString(scalar)
+		case int64, float64, bool:
+			target.SetString(fmt.Sprint(scalar))
+		default:
+			return fmt.Errorf("%s: expected a string", displayPath(path))
+		}
+		return nil
+
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return fmt.Errorf("%s: expected true or false", displayPath(path))
+		}
+		target.SetBool(b)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := value.(int64)
+		if !ok {
+			return fmt.Errorf("%s: expected an integer", displayPath(path))
+		}
+		target.SetInt(i)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		switch number := value.(type) {
+		case int64:
+			target.SetFloat(float64(number))
+		case float64:
+			target.SetFloat(number)
+		default:
+			return fmt.Errorf("%s: expected a number", displayPath(path))
+		}
+		return nil
+	}
+
+	return fmt.Errorf("%s: unsupported field type %s", displayPath(path), target.Type())
+}
+
+func structFields(t reflect.Type) map[string]int {
+	fields := make(map[string]int, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func displayPath(path string) string {
+	if path == "" {
+		return "scenario"
+	}
+	return path
+}
@@ -0,0 +1,271 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+)
+
+// Scenario is a named end-to-end payment flow described in YAML
+type Scenario struct {
+	Name  string `json:"name"`
+	Steps []Step `json:"steps"`
+}
+
+// Step performs one action and checks its outcome
+type Step struct {
+	Name    string                    `json:"name"`
+	Action  string                    `json:"action"`
+	Ref     string                    `json:"ref,omitempty"` // earlier step whose transaction this step acts on
+	Request *providers.PaymentRequest `json:"request,omitempty"`
+	Params  map[string]interface{}    `json:"params,omitempty"` // free-form input for custom actions
+	Expect  Expectation               `json:"expect"`
+}
+
+// Expectation lists assertions on a step outcome; unset fields are not checked
+type Expectation struct {
+	Success   *bool    `json:"success,omitempty"`
+	Status    string   `json:"status,omitempty"`
+	ErrorCode string   `json:"error_code,omitempty"`
+	Amount    *float64 `json:"amount,omitempty"`
+	Currency  string   `json:"currency,omitempty"`
+
+	// the payment's stored state after the step, see PaymentState
+	State    string   `json:"state,omitempty"`
+	Captured *float64 `json:"captured,omitempty"`
+	Refunded *float64 `json:"refunded,omitempty"`
+	Disputed *bool    `json:"disputed,omitempty"`
+}
+
+// Outcome is the normalized result of a step
+type Outcome struct {
+	Response *providers.PaymentResponse `json:"response,omitempty"`
+	Error    *providers.PaymentError    `json:"error,omitempty"`
+	State    *PaymentState              `json:"state,omitempty"` // nil for payments that were not stored
+}
+
+// Run is the state shared by the steps of a scenario execution
+type Run struct {
+	Processor *processor.PaymentProcessor
+	Outcomes  map[string]Outcome
+}
+
+// Referenced returns the outcome of the step named by step.Ref
+func (r *Run) Referenced(step Step) (Outcome, error) {
+	if step.Ref == "" {
+		return Outcome{}, fmt.Errorf("step '%s' requires a ref", step.Name)
+	}
+	outcome, ok := r.Outcomes[step.Ref]
+	if !ok {
+		return Outcome{}, fmt.Errorf("step '%s' references unknown step '%s'", step.Name, step.Ref)
+	}
+	return outcome, nil
+}
+
+// Action executes a step against the processor
+type Action func(ctx context.Context, run *Run, step Step) (Outcome, error)
+
+// StepResult reports how a step went
+type StepResult struct {
+	Name     string   `json:"name"`
+	Action   string   `json:"action"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+	Outcome  Outcome  `json:"outcome"`
+}
+
+type Report struct {
+	Scenario string       `json:"scenario"`
+	Passed   bool         `json:"passed"`
+	Steps    []StepResult `json:"steps"`
+}
+
+type Runner struct {
+	processor *processor.PaymentProcessor
+	actions   map[string]Action
+}
+
+func NewRunner(paymentProcessor *processor.PaymentProcessor) *Runner {
+	runner := &Runner{
+		processor: paymentProcessor,
+		actions:   make(map[string]Action),
+	}
+
+	runner.Register("pay", payAction)
+	runner.Register("capture", captureAction)
+	runner.Register("refund", refundAction)
+	runner.Register("dispute", disputeAction)
+
+	return runner
+}
+
+// Register adds or replaces an action usable from scenario files
+func (r *Runner) Register(name string, action Action) {
+	r.actions[name] = action
+}
+
+// Actions lists the registered action names
+func (r *Runner) Actions() []string {
+	names := make([]string, 0, len(r.actions))
+	for name := range r.actions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse reads a scenario from YAML
+func Parse(data []byte) (Scenario, error) {
+	tree, err := parseYAML(data)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	var scenario Scenario
+	if err := decode(tree, &scenario); err != nil {
+		return Scenario{}, err
+	}
+
+	if len(scenario.Steps) == 0 {
+		return Scenario{}, fmt.Errorf("scenario '%s' has no steps", scenario.Name)
+	}
+
+	for i := range scenario.Steps {
+		if scenario.Steps[i].Name == "" {
+			scenario.Steps[i].Name = fmt.Sprintf("step-%d", i+1)
+		}
+	}
+
+	return scenario, nil
+}
+
+// Load reads a scenario file
+func Load(path string) (Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Scenario{}, err
+	}
+
+	scenario, err := Parse(data)
+	if err != nil {
+		return Scenario{}, fmt.Errorf("%s: %v", path, err)
+	}
+	return scenario, nil
+}
+
+// Run executes the scenario; steps after the first failing one are skipped
+// since later states depend on earlier ones
+func (r *Runner) Run(ctx context.Context, scenario Scenario) Report {
+	report := Report{Scenario: scenario.Name, Passed: true}
+	run := &Run{Processor: r.processor, Outcomes: make(map[string]Outcome)}
+
+	for _, step := range scenario.Steps {
+		result := StepResult{Name: step.Name, Action: step.Action}
+
+		if !report.Passed {
+			result.Failures = []string{"skipped after earlier failure"}
+			report.Steps = append(report.Steps, result)
+			continue
+		}
+
+		action, ok := r.actions[step.Action]
+		if !ok {
+			result.Failures = []string{fmt.Sprintf("unknown action '%s'", step.Action)}
+		} else if outcome, err := action(ctx, run, step); err != nil {
+			result.Failures = []string{err.Error()}
+		} else {
+			result.Outcome = outcome
+			result.Failures = step.Expect.check(outcome)
+			run.Outcomes[step.Name] = outcome
+		}
+
+		result.Passed = len(result.Failures) == 0
+		report.Passed = report.Passed && result.Passed
+		report.Steps = append(report.Steps, result)
+	}
+
+	return report
+}
+
+func payAction(ctx context.Context, run *Run, step Step) (Outcome, error) {
+	if step.Request == nil {
+		return Outcome{}, fmt.Errorf("step '%s' requires a request", step.Name)
+	}
+
+	response, paymentError := run.Processor.ProcessPayment(ctx, *step.Request)
+	outcome := Outcome{Response: response, Error: paymentError}
+	if response != nil {
+		outcome.State = run.state(response.TransactionID)
+	}
+	return outcome, nil
+}
+
+func (e Expectation) check(outcome Outcome) []string {
+	var failures []string
+
+	success := outcome.Error == nil && outcome.Response != nil && outcome.Response.Success
+	if e.Success != nil && *e.Success != success {
+		failures = append(failures, fmt.Sprintf("expected success %v, got %v", *e.Success, success))
+	}
+
+	if e.ErrorCode != "" {
+		if outcome.Error == nil {
+			failures = append(failures, fmt.Sprintf("expected error code '%s', got no error", e.ErrorCode))
+		} else if outcome.Error.ErrorCode != e.ErrorCode {
+			failures = append(failures, fmt.Sprintf("expected error code '%s', got '%s'", e.ErrorCode, outcome.Error.ErrorCode))
+		}
+	}
+
+	failures = append(failures, e.checkState(outcome.State)...)
+
+	response := outcome.Response
+	if response == nil {
+		if e.Status != "" || e.Amount != nil || e.Currency != "" {
+			failures = append(failures, "expected a response, got none")
+		}
+		return failures
+	}
+
+	if e.Status != "" && response.Status != e.Status {
+		failures = append(failures, fmt.Sprintf("expected status '%s', got '%s'", e.Status, response.Status))
+	}
+
+	if e.Amount != nil && math.Abs(response.Amount-*e.Amount) > 0.001 {
+		failures = append(failures, fmt.Sprintf("expected amount %.2f, got %.2f", *e.Amount, response.Amount))
+	}
+
+	if e.Currency != "" && response.Currency != e.Currency {
+		failures = append(failures, fmt.Sprintf("expected currency '%s', got '%s'", e.Currency, response.Currency))
+	}
+
+	return failures
+}
+
+func (e Expectation) checkState(state *PaymentState) []string {
+	if e.State == "" && e.Captured == nil && e.Refunded == nil && e.Disputed == nil {
+		return nil
+	}
+	if state == nil {
+		return []string{"expected a stored payment, got none"}
+	}
+
+	var failures []string
+	if e.State != "" && state.Status != e.State {
+		failures = append(failures, fmt.Sprintf("expected state '%s', got '%s'", e.State, state.Status))
+	}
+	if e.Captured != nil && math.Abs(state.Captured-*e.Captured) > 0.001 {
+		failures = append(failures, fmt.Sprintf("expected %.2f captured, got %.2f", *e.Captured, state.Captured))
+	}
+	if e.Refunded != nil && math.Abs(state.Refunded-*e.Refunded) > 0.001 {
+		failures = append(failures, fmt.Sprintf("expected %.2f refunded, got %.2f", *e.Refunded, state.Refunded))
+	}
+	if e.Disputed != nil && state.Disputed != *e.Disputed {
+		failures = append(failures, fmt.Sprintf("expected disputed %v, got %v", *e.Disputed, state.Disputed))
+	}
+	return failures
+}
@@ -0,0 +1,242 @@
+package scenario
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+)
+
+// fakeProvider approves every valid request, capture and refund
+type fakeProvider struct{}
+
+func (fakeProvider) GetName() string { return "fake" }
+
+func (fakeProvider) ValidateRequest(request providers.PaymentRequest) error {
+	if request.Amount <= 0 {
+		return errors.New("amount must be greater than 0")
+	}
+	return nil
+}
+
+func (fakeProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	return &providers.PaymentResponse{
+		Success:       true,
+		TransactionID: "FAKE-1",
+		Status:        "APPROVED",
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
+
+func (fakeProvider) ParseSuccessResponse(response interface{}) (*providers.PaymentResponse, error) {
+	return response.(*providers.PaymentResponse), nil
+}
+
+func (fakeProvider) ParseErrorResponse(response interface{}) (*providers.PaymentError, error) {
+	return response.(*providers.PaymentError), nil
+}
+
+func (fakeProvider) Refund(ctx context.Context, request providers.RefundRequest) (*providers.RefundResponse, error) {
+	return &providers.RefundResponse{
+		Success:       true,
+		RefundID:      "REF-" + request.TransactionID,
+		TransactionID: request.TransactionID,
+		Status:        "APPROVED",
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
+
+func (fakeProvider) Capture(ctx context.Context, request providers.CaptureRequest) (*providers.CaptureResponse, error) {
+	return &providers.CaptureResponse{
+		Success:       true,
+		CaptureID:     "CAP-" + request.TransactionID,
+		TransactionID: request.TransactionID,
+		Status:        "APPROVED",
+		Amount:        request.Amount,
+		Currency:      request.Currency,
+	}, nil
+}
+
+func (fakeProvider) GetPaymentStatus(ctx context.Context, transactionID string) (*providers.PaymentResponse, error) {
+	return nil, providers.ErrStatusQueryNotSupported
+}
+
+func newTestRunner() *Runner {
+	return NewRunner(processor.NewPaymentProcessor([]providers.Provider{fakeProvider{}}))
+}
+
+func TestRunner_CheckoutScenario(t *testing.T) {
+	scenario, err := Load("testdata/checkout.yaml")
+	if err != nil {
+		t.Fatalf("Expected scenario to load, got error: %v", err)
+	}
+
+	if scenario.Steps[0].Request.ExpiryMonth != "12" || scenario.Steps[1].Request.ExpiryMonth != "01" {
+		t.Errorf("Expected expiry months to decode as strings, got %q and %q",
+			scenario.Steps[0].Request.ExpiryMonth, scenario.Steps[1].Request.ExpiryMonth)
+	}
+
+	report := newTestRunner().Run(context.Background(), scenario)
+	if !report.Passed {
+		t.Fatalf("Expected scenario to pass, got %+v", report.Steps)
+	}
+
+	if len(report.Steps) != 2 {
+		t.Errorf("Expected 2 step results, got %d", len(report.Steps))
+	}
+}
+
+func TestRunner_FullFlowScenario(t *testing.T) {
+	scenario, err := Load("testdata/full_flow.yaml")
+	if err != nil {
+		t.Fatalf("Expected scenario to load, got error: %v", err)
+	}
+
+	report := newTestRunner().Run(context.Background(), scenario)
+	if !report.Passed {
+		t.Fatalf("Expected scenario to pass, got %+v", report.Steps)
+	}
+
+	last := report.Steps[len(report.Steps)-1].Outcome.State
+	if last == nil || last.Captured != 100 || last.Refunded != 30 || !last.Disputed {
+		t.Errorf("Unexpected final state %+v", last)
+	}
+}
+
+func TestRunner_StateMismatch(t *testing.T) {
+	scenario, _ := Parse([]byte(`
+name: over-refund
+steps:
+  - name: authorize
+    action: pay
+    request:
+      mode: fake
+      amount: 10
+      currency: USD
+  - name: refund
+    action: refund
+    ref: authorize
+    params:
+      amount: 4
+    expect:
+      refunded: 5
+`))
+
+	report := newTestRunner().Run(context.Background(), scenario)
+	if report.Passed || report.Steps[1].Failures[0] != "expected 5.00 refunded, got 4.00" {
+		t.Errorf("Expected the refunded amount to be checked, got %+v", report.Steps)
+	}
+}
+
+func TestRunner_FailedExpectationSkipsRemainingSteps(t *testing.T) {
+	scenario, err := Parse([]byte(`
+name: failing
+steps:
+  - name: authorize
+    action: pay
+    request: {}
+    expect:
+      success: true
+  - name: capture
+    action: capture
+`))
+	if err != nil {
+		t.Fatalf("Expected scenario to parse, got error: %v", err)
+	}
+
+	report := newTestRunner().Run(context.Background(), scenario)
+	if report.Passed {
+		t.Fatal("Expected scenario to fail")
+	}
+
+	if report.Steps[1].Passed || report.Steps[1].Failures[0] != "skipped after earlier failure" {
+		t.Errorf("Expected second step to be skipped, got %+v", report.Steps[1])
+	}
+}
+
+func TestRunner_CustomAction(t *testing.T) {
+	runner := newTestRunner()
+	runner.Register("lookup", func(ctx context.Context, run *Run, step Step) (Outcome, error) {
+		return run.Referenced(step)
+	})
+
+	scenario, _ := Parse([]byte(`
+name: custom
+steps:
+  - name: authorize
+    action: pay
+    request:
+      mode: fake
+      amount: 5
+      currency: EUR
+  - name: check
+    action: lookup
+    ref: authorize
+    expect:
+      currency: EUR
+`))
+
+	if report := runner.Run(context.Background(), scenario); !report.Passed {
+		t.Errorf("Expected custom action scenario to pass, got %+v", report.Steps)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	testCases := map[string]string{
+		"no steps":      "name: empty\n",
+		"unknown field": "name: x\nsteps:\n  - name: a\n    colour: blue\n",
+		"bad indent":    "name: x\n   steps: []\n",
+		"wrong type":    "name: x\nsteps:\n  - name: a\n    request:\n      amount: lots\n",
+	}
+
+	for name, data := range testCases {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(data)); err == nil {
+				t.Error("Expected parse error")
+			}
+		})
+	}
+}
+
+func TestParseYAML_Scalars(t *testing.T) {
+	tree, err := parseYAML([]byte(`
+plain: hello world # trailing comment
+quoted: "a # not a comment"
+single: 'it''s'
+int: 42
+float: 1.5
+zero_padded: 007
+flag: true
+nothing: ~
+list: [1, two, "3"]
+`))
+	if err != nil {
+		t.Fatalf("Expected YAML to parse, got error: %v", err)
+	}
+
+	m := tree.(map[string]interface{})
+	expected := map[string]interface{}{
+		"plain":       "hello world",
+		"quoted":      "a # not a comment",
+		"single":      "it's",
+		"int":         int64(42),
+		"float":       1.5,
+		"zero_padded": "007",
+		"flag":        true,
+		"nothing":     nil,
+	}
+
+	for key, want := range expected {
+		if m[key] != want {
+			t.Errorf("Expected %s to be %#v, got %#v", key, want, m[key])
+		}
+	}
+
+	if list := m["list"].([]interface{}); len(list) != 3 || list[1] != "two" || list[2] != "3" {
+		t.Errorf("Unexpected flow list: %#v", m["list"])
+	}
+}
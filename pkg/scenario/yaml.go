@@ -0,0 +1,296 @@
+package scenario
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A minimal YAML reader covering what scenario files need: block mappings,
+// block sequences, flow lists and mappings of scalars, quoted and plain
+// scalars, and comments. Anchors, multi-line strings and multiple documents
+// are not supported.
+
+type yamlLine struct {
+	number  int
+	indent  int
+	content string
+}
+
+func parseYAML(data []byte) (interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if strings.Contains(raw, "\t") && strings.TrimLeft(raw, " ") != strings.TrimLeft(raw, " \t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+
+		content := strings.TrimRight(stripComment(raw), " ")
+		trimmed := strings.TrimLeft(content, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+
+		lines = append(lines, yamlLine{number: i + 1, indent: len(content) - len(trimmed), content: trimmed})
+	}
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	p := &yamlParser{lines: lines}
+	value, err := p.parseNode(lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].number)
+	}
+
+	return value, nil
+}
+
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+func (p *yamlParser) parseNode(indent int) (interface{}, error) {
+	if isSequenceItem(p.lines[p.pos].content) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent != indent || !isSequenceItem(line.content) {
+			break
+		}
+
+		rest := strings.TrimLeft(strings.TrimPrefix(line.content, "-"), " ")
+		switch {
+		case rest == "":
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			item, err := p.parseNode(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		case isMappingEntry(rest):
+			// "- key: value" starts a mapping indented past the dash
+			itemIndent := indent + (len(line.content) - len(rest))
+			p.lines[p.pos] = yamlLine{number: line.number, indent: itemIndent, content: rest}
+			item, err := p.parseMapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		default:
+			value, err := parseScalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line.number, err)
+			}
+			items = append(items, value)
+			p.pos++
+		}
+	}
+
+	return items, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	mapping := map[string]interface{}{}
+
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.number)
+		}
+		if isSequenceItem(line.content) {
+			break
+		}
+
+		key, rest, ok := splitMappingEntry(line.content)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'key: value'", line.number)
+		}
+		if _, exists := mapping[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key '%s'", line.number, key)
+		}
+		p.pos++
+
+		if rest != "" {
+			value, err := parseScalar(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", line.number, err)
+			}
+			mapping[key] = value
+			continue
+		}
+
+		if p.pos >= len(p.lines) {
+			mapping[key] = nil
+			continue
+		}
+
+		next := p.lines[p.pos]
+		switch {
+		case next.indent > indent:
+			value, err := p.parseNode(next.indent)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = value
+		case next.indent == indent && isSequenceItem(next.content):
+			value, err := p.parseSequence(indent)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = value
+		default:
+			mapping[key] = nil
+		}
+	}
+
+	return mapping, nil
+}
+
+func isSequenceItem(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ")
+}
+
+func isMappingEntry(content string) bool {
+	_, _, ok := splitMappingEntry(content)
+	return ok
+}
+
+func splitMappingEntry(content string) (string, string, bool) {
+	if content == "" || strings.ContainsRune(`"'[{`, rune(content[0])) {
+		return "", "", false
+	}
+
+	for i := 0; i < len(content); i++ {
+		if content[i] != ':' {
+			continue
+		}
+		if i == len(content)-1 || content[i+1] == ' ' {
+			key := strings.TrimSpace(content[:i])
+			if key == "" {
+				return "", "", false
+			}
+			return key, strings.TrimSpace(content[i+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+func parseScalar(value string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted string %s", value)
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return nil, fmt.Errorf("invalid quoted string %s", value)
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'"), nil
+	case strings.HasPrefix(value, "["):
+		if !strings.HasSuffix(value, "]") {
+			return nil, fmt.Errorf("unterminated flow list %s", value)
+		}
+		items := []interface{}{}
+		inner := strings.TrimSpace(value[1 : len(value)-1])
+		if inner == "" {
+			return items, nil
+		}
+		for _, part := range strings.Split(inner, ",") {
+			item, err := parseScalar(strings.TrimSpace(part))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case strings.HasPrefix(value, "{"):
+		if !strings.HasSuffix(value, "}") {
+			return nil, fmt.Errorf("unterminated flow mapping %s", value)
+		}
+		mapping := map[string]interface{}{}
+		inner := strings.TrimSpace(value[1 : len(value)-1])
+		if inner == "" {
+			return mapping, nil
+		}
+		for _, part := range strings.Split(inner, ",") {
+			key, rest, ok := splitMappingEntry(strings.TrimSpace(part))
+			if !ok {
+				return nil, fmt.Errorf("invalid flow mapping entry %s", part)
+			}
+			item, err := parseScalar(rest)
+			if err != nil {
+				return nil, err
+			}
+			mapping[key] = item
+		}
+		return mapping, nil
+	}
+
+	switch value {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil && !hasLeadingZero(value) {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil && !hasLeadingZero(value) {
+		return f, nil
+	}
+
+	return value, nil
+}
+
+// values like "05" or "0123" are kept as strings (expiry months, CVVs)
+func hasLeadingZero(value string) bool {
+	digits := strings.TrimLeft(value, "+-")
+	return len(digits) > 1 && digits[0] == '0' && digits[1] != '.'
+}
+
+func stripComment(line string) string {
+	inSingle, inDouble := false, false
+	for i, r := range line {
+		switch r {
+		case '\'':
+			if !inDouble {
+				inSingle = !inSingle
+			}
+		case '"':
+			if !inSingle {
+				inDouble = !inDouble
+			}
+		case '#':
+			if !inSingle && !inDouble && (i == 0 || line[i-1] == ' ') {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
@@ -0,0 +1,105 @@
+// Package schema validates inbound API payloads and reports every field
+// violation at once as an RFC 7807 problem. The built-in validator reads
+// `validate` struct tags; a JSON Schema engine or hand written checks can be
+// plugged in through the Validator interface.
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"pgas/pkg/problem"
+)
+
+// Validator checks a decoded payload, returning all violations found
+type Validator interface {
+	Validate(v interface{}) []problem.InvalidParam
+}
+
+// ValidatorFunc adapts a function to a Validator
+type ValidatorFunc func(v interface{}) []problem.InvalidParam
+
+func (f ValidatorFunc) Validate(v interface{}) []problem.InvalidParam {
+	return f(v)
+}
+
+// Chain runs validators in order and collects all their violations, e.g.
+// the tag rules followed by cross-field checks
+func Chain(validators ...Validator) Validator {
+	return ValidatorFunc(func(v interface{}) []problem.InvalidParam {
+		var params []problem.InvalidParam
+		for _, validator := range validators {
+			params = append(params, validator.Validate(v)...)
+		}
+		return params
+	})
+}
+
+// maximum accepted body size of Decode
+const maxBodyBytes = 1 << 20
+
+// Decode reads a JSON body into v and validates it. Malformed JSON, type
+// mismatches and unknown fields are reported as a malformed request (400)
+// naming the field, rule violations as a validation problem (422) listing
+// every violation. A nil validator only checks that the body decodes.
+func Decode(r *http.Request, v interface{}, validator Validator) *problem.Problem {
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return problem.Malformed([]problem.InvalidParam{decodeViolation(err)})
+	}
+	if decoder.More() {
+		return problem.Malformed([]problem.InvalidParam{{Rule: "json", Reason: "body must hold a single JSON value"}})
+	}
+
+	if validator == nil {
+		return nil
+	}
+	if params := validator.Validate(v); len(params) > 0 {
+		return problem.Validation(params)
+	}
+	return nil
+}
+
+// decodeViolation turns a JSON decoding error into the violation of the
+// field it happened at, when known
+func decodeViolation(err error) problem.InvalidParam {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return problem.InvalidParam{Rule: "json", Reason: "body is empty"}
+	case errors.As(err, &syntaxErr):
+		return problem.InvalidParam{Rule: "json", Reason: fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset)}
+	case errors.As(err, &typeErr):
+		return problem.InvalidParam{Name: typeErr.Field, Rule: "type", Reason: "must be " + jsonKind(typeErr.Type.Kind())}
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return problem.InvalidParam{Name: name, Rule: "unknown", Reason: "is not a known field"}
+	}
+	return problem.InvalidParam{Rule: "json", Reason: err.Error()}
+}
+
+// jsonKind names a Go kind the way API consumers know it
+func jsonKind(kind reflect.Kind) string {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
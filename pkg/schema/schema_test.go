@@ -0,0 +1,138 @@
+package schema
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"pgas/pkg/problem"
+	"pgas/pkg/providers"
+)
+
+func decode(t *testing.T, body string, v interface{}, validator Validator) *problem.Problem {
+	t.Helper()
+	return Decode(httptest.NewRequest("POST", "/v1/payments", strings.NewReader(body)), v, validator)
+}
+
+// byName indexes violations by field for assertions
+func byName(p *problem.Problem) map[string]problem.InvalidParam {
+	params := make(map[string]problem.InvalidParam)
+	if p != nil {
+		for _, param := range p.InvalidParams {
+			params[param.Name] = param
+		}
+	}
+	return params
+}
+
+func TestDecode_PaymentRequest(t *testing.T) {
+	var request providers.PaymentRequest
+	if p := decode(t, `{"mode":"visa","amount":100,"currency":"USD","card_number":"4111111111111111","cvv":"123"}`, &request, Tags); p != nil {
+		t.Fatalf("Expected a valid payment, got %+v", p)
+	}
+	if request.Amount != 100 || request.CardNumber != "4111111111111111" {
+		t.Errorf("Expected the body decoded, got %+v", request)
+	}
+
+	request = providers.PaymentRequest{}
+	p := decode(t, `{"amount":-5,"currency":"US","card_number":"4111-1111","account_type":"brokerage","latency_budget_ms":-1,"overrides":{}}`, &request, Tags)
+	if p == nil || p.Status != 422 || p.Type != problem.TypeValidation {
+		t.Fatalf("Expected a validation problem, got %+v", p)
+	}
+
+	params := byName(p)
+	expected := map[string]string{
+		"amount":            "gt=0",
+		"currency":          "len=3",
+		"card_number":       "digits",
+		"account_type":      "oneof=checking savings",
+		"latency_budget_ms": "min=0",
+		"overrides.actor":   "required",
+	}
+	if len(params) != len(expected) {
+		t.Errorf("Expected %d violations, got %+v", len(expected), p.InvalidParams)
+	}
+	for name, rule := range expected {
+		if params[name].Rule != rule {
+			t.Errorf("%s: expected rule %s, got %+v", name, rule, params[name])
+		}
+	}
+	if params["currency"].Reason != "must have exactly 3 characters" {
+		t.Errorf("Expected a readable reason, got %q", params["currency"].Reason)
+	}
+}
+
+func TestDecode_MalformedBodies(t *testing.T) {
+	testCases := []struct {
+		body string
+		name string
+		rule string
+	}{
+		{``, "", "json"},
+		{`{"mode":`, "", "json"},
+		{`{"mode":"visa","amount":"100"}`, "amount", "type"},
+		{`{"mode":"visa","amout":100}`, "amout", "unknown"},
+		{`{"mode":"visa"} {}`, "", "json"},
+	}
+
+	for _, tc := range testCases {
+		var request providers.PaymentRequest
+		p := decode(t, tc.body, &request, Tags)
+		if p == nil || len(p.InvalidParams) != 1 {
+			t.Errorf("%q: expected one violation, got %+v", tc.body, p)
+			continue
+		}
+		if param := p.InvalidParams[0]; param.Name != tc.name || param.Rule != tc.rule {
+			t.Errorf("%q: expected %s/%s, got %+v", tc.body, tc.name, tc.rule, param)
+		}
+	}
+}
+
+func TestTags_NestedSlices(t *testing.T) {
+	type item struct {
+		SKU      string `json:"sku" validate:"required"`
+		Quantity int    `json:"quantity" validate:"min=1,max=99"`
+	}
+	type order struct {
+		Items []item   `json:"items" validate:"required,max=2"`
+		Notes []string `json:"notes,omitempty"`
+	}
+
+	params := Tags.Validate(&order{Items: []item{{SKU: "a", Quantity: 1}, {Quantity: 100}}})
+	if len(params) != 2 || params[0].Name != "items[1].sku" || params[1].Name != "items[1].quantity" {
+		t.Errorf("Expected the second item's violations, got %+v", params)
+	}
+
+	params = Tags.Validate(order{Items: make([]item, 3)})
+	if len(params) != 1 || params[0].Rule != "max=2" || params[0].Reason != "must have at most 2 items" {
+		t.Errorf("Expected the slice length checked before its items, got %+v", params)
+	}
+}
+
+func TestChain(t *testing.T) {
+	sameCurrency := ValidatorFunc(func(v interface{}) []problem.InvalidParam {
+		request := v.(*providers.RefundRequest)
+		if request.Currency != "USD" {
+			return []problem.InvalidParam{{Name: "currency", Rule: "settlement_currency", Reason: "must be the settlement currency USD"}}
+		}
+		return nil
+	})
+
+	var request providers.RefundRequest
+	p := decode(t, `{"mode":"visa","amount":5,"currency":"EUR"}`, &request, Chain(Tags, sameCurrency))
+	params := byName(p)
+	if len(params) != 2 || params["transaction_id"].Rule != "required" || params["currency"].Rule != "settlement_currency" {
+		t.Errorf("Expected violations of both validators, got %+v", p)
+	}
+}
+
+func TestTags_UnknownRulePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for an unknown rule")
+		}
+	}()
+	Tags.Validate(struct {
+		Email string `validate:"email"`
+	}{Email: "jane@example.com"})
+}
@@ -0,0 +1,190 @@
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"pgas/pkg/problem"
+)
+
+// Tags validates struct fields by their `validate` tag, a comma separated
+// list of rules:
+//
+//	required      the field must not be empty
+//	gt=N          numbers greater than N
+//	min=N, max=N  bounds of numbers, or of the length of strings and slices
+//	len=N         exact length of strings and slices
+//	oneof=a b c   strings limited to the listed values
+//	digits        strings of ASCII digits only
+//
+// Rules other than required are skipped for empty values, so optional fields
+// are only checked when present. Fields are reported by their JSON name, at
+// most one violation each. Nested structs, pointers to structs and slices of
+// structs are validated too. Unknown rules panic, they are programming errors.
+var Tags Validator = tagValidator{}
+
+type tagValidator struct{}
+
+func (tagValidator) Validate(v interface{}) []problem.InvalidParam {
+	var params []problem.InvalidParam
+	validateValue(reflect.ValueOf(v), "", &params)
+	return params
+}
+
+func validateValue(value reflect.Value, path string, params *[]problem.InvalidParam) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name := jsonName(field)
+			if name == "-" {
+				continue
+			}
+			fieldPath := name
+			if path != "" {
+				fieldPath = path + "." + name
+			}
+
+			if tag := field.Tag.Get("validate"); tag != "" {
+				if param, ok := checkRules(value.Field(i), tag); !ok {
+					param.Name = fieldPath
+					*params = append(*params, param)
+					continue
+				}
+			}
+			validateValue(value.Field(i), fieldPath, params)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			validateValue(value.Index(i), fmt.Sprintf("%s[%d]", path, i), params)
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// checkRules returns the first rule of tag the value violates
+func checkRules(value reflect.Value, tag string) (problem.InvalidParam, bool) {
+	empty := value.IsZero()
+
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if empty {
+				return problem.InvalidParam{Rule: rule, Reason: "is required"}, false
+			}
+			continue
+		}
+		if empty {
+			continue
+		}
+
+		if reason := checkRule(value, name, arg); reason != "" {
+			return problem.InvalidParam{Rule: rule, Reason: reason}, false
+		}
+	}
+	return problem.InvalidParam{}, true
+}
+
+// checkRule returns why value violates the rule, empty when it does not
+func checkRule(value reflect.Value, name, arg string) string {
+	switch name {
+	case "gt", "min", "max", "len":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Sprintf("schema: rule '%s' needs a number, got '%s'", name, arg))
+		}
+		if number, ok := numberOf(value); ok {
+			return compareNumber(name, number, limit, arg)
+		}
+		if length, unit, ok := lengthOf(value); ok {
+			return compareLength(name, length, int(limit), unit)
+		}
+		panic(fmt.Sprintf("schema: rule '%s' does not apply to %s", name, value.Kind()))
+	case "oneof":
+		options := strings.Fields(arg)
+		for _, option := range options {
+			if value.String() == option {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(options, ", ")
+	case "digits":
+		for _, c := range value.String() {
+			if c < '0' || c > '9' {
+				return "must contain digits only"
+			}
+		}
+		return ""
+	}
+	panic(fmt.Sprintf("schema: unknown rule '%s'", name))
+}
+
+func numberOf(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+func lengthOf(value reflect.Value) (int, string, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(value.String()), "characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), "items", true
+	}
+	return 0, "", false
+}
+
+func compareNumber(rule string, number, limit float64, arg string) string {
+	switch {
+	case rule == "gt" && number <= limit:
+		return "must be greater than " + arg
+	case rule == "min" && number < limit:
+		return "must be at least " + arg
+	case rule == "max" && number > limit:
+		return "must be at most " + arg
+	case rule == "len" && number != limit:
+		return "must be " + arg
+	}
+	return ""
+}
+
+func compareLength(rule string, length, limit int, unit string) string {
+	switch {
+	case rule == "gt" && length <= limit:
+		return fmt.Sprintf("must have more than %d %s", limit, unit)
+	case rule == "min" && length < limit:
+		return fmt.Sprintf("must have at least %d %s", limit, unit)
+	case rule == "max" && length > limit:
+		return fmt.Sprintf("must have at most %d %s", limit, unit)
+	case rule == "len" && length != limit:
+		return fmt.Sprintf("must have exactly %d %s", limit, unit)
+	}
+	return ""
+}
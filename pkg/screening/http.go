@@ -0,0 +1,65 @@
+package screening
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HTTPScreener is the reference Screener for external screening services
+// exposing a JSON API: the Request is POSTed to BaseURL + "/screen" and the
+// service answers with a Result.
+type HTTPScreener struct {
+	BaseURL string
+	APIKey  string       // sent as a bearer token when set
+	Client  *http.Client // defaults to http.DefaultClient
+}
+
+func NewHTTPScreener(baseURL, apiKey string) *HTTPScreener {
+	return &HTTPScreener{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: apiKey}
+}
+
+func (s *HTTPScreener) Screen(ctx context.Context, request Request) (*Result, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, s.BaseURL+"/screen", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("screening service answered %s", response.Status)
+	}
+
+	var result Result
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid screening response: %v", err)
+	}
+	switch result.Decision {
+	case Clear, Flag, Block:
+	default:
+		return nil, fmt.Errorf("screening response has unknown decision '%s'", result.Decision)
+	}
+	return &result, nil
+}
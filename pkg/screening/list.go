@@ -0,0 +1,50 @@
+package screening
+
+import (
+	"context"
+	"strings"
+)
+
+// ListScreener is the built-in Screener matching payments against static
+// lists. Countries match the merchant or the issuer country, BINs match by
+// prefix. A payment matching any list gets Decision, Block when unset.
+type ListScreener struct {
+	Countries    []string `json:"countries,omitempty"`
+	SubMerchants []string `json:"sub_merchants,omitempty"`
+	BINs         []string `json:"bins,omitempty"`
+	Decision     Decision `json:"decision,omitempty"`
+}
+
+func (l *ListScreener) Screen(ctx context.Context, request Request) (*Result, error) {
+	var matches []string
+
+	for _, country := range l.Countries {
+		country = strings.ToUpper(country)
+		if country == request.MerchantCountry {
+			matches = append(matches, "merchant_country:"+country)
+		}
+		if country == request.IssuerCountry {
+			matches = append(matches, "issuer_country:"+country)
+		}
+	}
+	for _, subMerchant := range l.SubMerchants {
+		if subMerchant != "" && subMerchant == request.SubMerchantID {
+			matches = append(matches, "sub_merchant:"+subMerchant)
+		}
+	}
+	for _, bin := range l.BINs {
+		if bin != "" && strings.HasPrefix(request.BIN, bin) {
+			matches = append(matches, "bin:"+bin)
+		}
+	}
+
+	if len(matches) == 0 {
+		return &Result{Decision: Clear}, nil
+	}
+
+	decision := l.Decision
+	if decision == "" {
+		decision = Block
+	}
+	return &Result{Decision: decision, Reason: "listed", Matches: matches}, nil
+}
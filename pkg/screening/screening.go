@@ -0,0 +1,61 @@
+// Package screening abstracts AML and sanctions screening so merchants can
+// plug in their compliance provider. The processor screens payments above a
+// configured amount or touching flagged countries before they reach the
+// gateway; blocked payments fail with COMPLIANCE_HOLD.
+package screening
+
+import (
+	"context"
+	"strings"
+
+	"pgas/pkg/providers"
+)
+
+type Decision string
+
+const (
+	Clear Decision = "clear" // nothing found, the payment goes ahead
+	Flag  Decision = "flag"  // the payment goes ahead and is marked for review
+	Block Decision = "block" // the payment is held and never sent to the gateway
+)
+
+// Request is what a screening service gets to see of a payment. Card numbers
+// are not part of it, only the BIN.
+type Request struct {
+	Amount          float64 `json:"amount"`
+	Currency        string  `json:"currency"`
+	BIN             string  `json:"bin,omitempty"`
+	MerchantCountry string  `json:"merchant_country,omitempty"`
+	IssuerCountry   string  `json:"issuer_country,omitempty"`
+	SubMerchantID   string  `json:"sub_merchant_id,omitempty"`
+	Descriptor      string  `json:"descriptor,omitempty"`
+}
+
+// NewRequest builds a screening request from a payment request
+func NewRequest(paymentRequest providers.PaymentRequest) Request {
+	request := Request{
+		Amount:          paymentRequest.Amount,
+		Currency:        paymentRequest.Currency,
+		MerchantCountry: strings.ToUpper(paymentRequest.MerchantCountry),
+		IssuerCountry:   strings.ToUpper(paymentRequest.IssuerCountry),
+		SubMerchantID:   paymentRequest.SubMerchantID,
+		Descriptor:      paymentRequest.Descriptor,
+	}
+	if len(paymentRequest.CardNumber) >= 6 {
+		request.BIN = paymentRequest.CardNumber[:6]
+	}
+	return request
+}
+
+// Result is the screening outcome
+type Result struct {
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason,omitempty"`
+	// Matches names the list entries or watchlist hits behind the decision
+	Matches []string `json:"matches,omitempty"`
+}
+
+// Screener checks a payment against sanctions and AML rules
+type Screener interface {
+	Screen(ctx context.Context, request Request) (*Result, error)
+}
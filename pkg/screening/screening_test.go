@@ -0,0 +1,92 @@
+package screening
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestNewRequest(t *testing.T) {
+	request := NewRequest(providers.PaymentRequest{
+		Amount:          500,
+		Currency:        "EUR",
+		CardNumber:      "4111111111111111",
+		MerchantCountry: "de",
+		IssuerCountry:   "fr",
+	})
+
+	if request.BIN != "411111" || request.MerchantCountry != "DE" || request.IssuerCountry != "FR" {
+		t.Errorf("Unexpected screening request: %+v", request)
+	}
+}
+
+func TestListScreener(t *testing.T) {
+	screener := &ListScreener{Countries: []string{"kp"}, SubMerchants: []string{"shell-co"}, BINs: []string{"4000"}}
+
+	cases := []struct {
+		name     string
+		request  Request
+		decision Decision
+		matches  int
+	}{
+		{"clear", Request{BIN: "411111", MerchantCountry: "US"}, Clear, 0},
+		{"issuer country", Request{IssuerCountry: "KP"}, Block, 1},
+		{"sub-merchant and bin", Request{SubMerchantID: "shell-co", BIN: "400012"}, Block, 2},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := screener.Screen(context.Background(), tc.request)
+			if err != nil {
+				t.Fatalf("Expected screening to succeed, got error: %v", err)
+			}
+			if result.Decision != tc.decision || len(result.Matches) != tc.matches {
+				t.Errorf("Expected %s with %d matches, got %+v", tc.decision, tc.matches, result)
+			}
+		})
+	}
+
+	screener.Decision = Flag
+	result, _ := screener.Screen(context.Background(), Request{MerchantCountry: "KP"})
+	if result.Decision != Flag || result.Matches[0] != "merchant_country:KP" {
+		t.Errorf("Expected the configured decision, got %+v", result)
+	}
+}
+
+func TestHTTPScreener(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/screen" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		var request Request
+		json.NewDecoder(r.Body).Decode(&request)
+
+		if request.Amount > 1000 {
+			json.NewEncoder(w).Encode(Result{Decision: Flag, Reason: "large amount"})
+			return
+		}
+		json.NewEncoder(w).Encode(Result{Decision: "maybe"})
+	}))
+	defer server.Close()
+
+	result, err := NewHTTPScreener(server.URL+"/", "key").Screen(context.Background(), Request{Amount: 5000})
+	if err != nil {
+		t.Fatalf("Expected screening to succeed, got error: %v", err)
+	}
+	if result.Decision != Flag || result.Reason != "large amount" {
+		t.Errorf("Expected service result, got %+v", result)
+	}
+
+	if _, err := NewHTTPScreener(server.URL, "key").Screen(context.Background(), Request{Amount: 10}); err == nil {
+		t.Error("Expected error for an unknown decision")
+	}
+	if _, err := NewHTTPScreener(server.URL, "wrong").Screen(context.Background(), Request{}); err == nil {
+		t.Error("Expected error for rejected request")
+	}
+}
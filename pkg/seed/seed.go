@@ -0,0 +1,298 @@
+// Package seed fills stores with synthetic but realistic payment history so
+// dashboards, reports and reconciliation can be tried without real traffic.
+// Generated records are marked with the "seed_" id prefix.
+package seed
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/reporting"
+	"pgas/pkg/store"
+)
+
+// StatusDisputed marks a dispute raised on a settled payment in a
+// transaction's timeline; the payment itself stays approved
+const StatusDisputed = "DISPUTED"
+
+// IDPrefix starts the id of every generated record
+const IDPrefix = "seed_"
+
+type Options struct {
+	Count       int       // payments to generate, defaults to 1000
+	Start, End  time.Time // defaults to the last 30 days
+	Providers   []string  // defaults to visa and mastercard
+	Currencies  []string  // defaults to USD, EUR, GBP and INR
+	RefundRate  float64   // share of approved payments refunded, defaults to 0.03
+	DisputeRate float64   // share of approved payments disputed, defaults to 0.005
+	// Seed makes the history reproducible
+	Seed uint64
+}
+
+func (o Options) withDefaults() Options {
+	if o.Count <= 0 {
+		o.Count = 1000
+	}
+	if o.End.IsZero() {
+		o.End = time.Now()
+	}
+	if o.Start.IsZero() || !o.Start.Before(o.End) {
+		o.Start = o.End.Add(-30 * 24 * time.Hour)
+	}
+	if len(o.Providers) == 0 {
+		o.Providers = []string{"visa", "mastercard"}
+	}
+	if len(o.Currencies) == 0 {
+		o.Currencies = []string{"USD", "EUR", "GBP", "INR"}
+	}
+	if o.RefundRate == 0 {
+		o.RefundRate = 0.03
+	}
+	if o.DisputeRate == 0 {
+		o.DisputeRate = 0.005
+	}
+	return o
+}
+
+// History is generated payment history
+type History struct {
+	Transactions []store.Transaction      `json:"transactions"`
+	Refunds      []reporting.RefundRecord `json:"refunds"`
+}
+
+// Result counts what Populate wrote
+type Result struct {
+	Transactions int `json:"transactions"`
+	Approved     int `json:"approved"`
+	Declined     int `json:"declined"`
+	Disputes     int `json:"disputes"`
+	Refunds      int `json:"refunds"`
+}
+
+// test card ranges per provider, so BIN breakdowns have a few rows
+var bins = map[string][]string{
+	"visa":       {"411111", "400000", "424242"},
+	"mastercard": {"555555", "510510", "222300"},
+}
+
+// decline mix roughly as seen on card-not-present traffic
+var declines = []struct {
+	reason string
+	weight float64
+}{
+	{providers.ReasonInsufficientFunds, 0.35},
+	{providers.ReasonDoNotHonor, 0.25},
+	{providers.ReasonCardDeclined, 0.15},
+	{providers.ReasonExpiredCard, 0.08},
+	{providers.ReasonSuspectedFraud, 0.07},
+	{providers.ReasonInvalidCard, 0.05},
+	{providers.ReasonIssuerUnavailable, 0.03},
+	{providers.ReasonProcessingError, 0.02},
+}
+
+var refundReasons = []providers.RefundReason{
+	providers.RefundCustomerRequest,
+	providers.RefundCustomerRequest,
+	providers.RefundProductIssue,
+	providers.RefundDuplicate,
+	providers.RefundFraud,
+}
+
+// Generate builds the history, oldest payment first. The same options and
+// seed always give the same history.
+func Generate(opts Options) History {
+	opts = opts.withDefaults()
+	g := newGenerator(opts)
+
+	var history History
+	span := opts.End.Sub(opts.Start)
+	times := make([]time.Time, opts.Count)
+	for i := range times {
+		times[i] = opts.Start.Add(time.Duration(g.rng.Int64N(int64(span))))
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+
+	for i, created := range times {
+		tx := g.transaction(i, created)
+
+		if tx.Status == providers.StatusApproved {
+			if g.rng.Float64() < opts.RefundRate {
+				history.Refunds = append(history.Refunds, g.refund(tx))
+			}
+			if g.rng.Float64() < opts.DisputeRate {
+				g.dispute(&tx)
+			}
+		}
+		history.Transactions = append(history.Transactions, tx)
+	}
+	return history
+}
+
+// Populate writes generated history into the stores; refunds may be nil
+func Populate(transactions store.Transactions, refunds *reporting.RefundLedger, opts Options) (Result, error) {
+	history := Generate(opts)
+
+	var result Result
+	for _, tx := range history.Transactions {
+		if err := transactions.Save(tx); err != nil {
+			return result, err
+		}
+		result.count(tx)
+	}
+
+	if refunds != nil {
+		for _, record := range history.Refunds {
+			refunds.Record(record)
+			result.Refunds++
+		}
+	}
+	return result, nil
+}
+
+// Live keeps adding payments at about tps per second until ctx is done, for
+// demos that need moving numbers
+func Live(ctx context.Context, transactions store.Transactions, tps float64, opts Options) {
+	opts = opts.withDefaults()
+	g := newGenerator(opts)
+	interval := time.Duration(float64(time.Second) / math.Max(tps, 0.1))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for i := 0; ; i++ {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			tx := g.transaction(i, now)
+			tx.ID = fmt.Sprintf("%slive_%06d", IDPrefix, i)
+			transactions.Save(tx)
+		}
+	}
+}
+
+func (r *Result) count(tx store.Transaction) {
+	r.Transactions++
+	switch {
+	case providers.IsSuccessStatus(tx.Status):
+		r.Approved++
+	case tx.Status == providers.StatusDeclined:
+		r.Declined++
+	}
+	for _, change := range tx.Timeline {
+		if change.Status == StatusDisputed {
+			r.Disputes++
+		}
+	}
+}
+
+type generator struct {
+	opts Options
+	rng  *rand.Rand
+	// approval rate per provider, slightly different so comparisons show
+	approval map[string]float64
+}
+
+func newGenerator(opts Options) *generator {
+	g := &generator{
+		opts:     opts,
+		rng:      rand.New(rand.NewPCG(opts.Seed, opts.Seed^0x9e3779b97f4a7c15)),
+		approval: make(map[string]float64),
+	}
+	for _, provider := range opts.Providers {
+		g.approval[provider] = 0.82 + g.rng.Float64()*0.12
+	}
+	return g
+}
+
+func (g *generator) transaction(i int, created time.Time) store.Transaction {
+	provider := g.opts.Providers[g.rng.IntN(len(g.opts.Providers))]
+	providerBINs := bins[provider]
+	if len(providerBINs) == 0 {
+		providerBINs = bins["visa"]
+	}
+
+	// log-normal amounts: many small baskets, a long tail of large ones
+	amount := math.Round(math.Exp(3.5+g.rng.NormFloat64()*1.1)*100) / 100
+	amount = math.Max(amount, 1)
+
+	tx := store.Transaction{
+		ID:          fmt.Sprintf("%s%06d", IDPrefix, i),
+		Provider:    provider,
+		Amount:      amount,
+		Currency:    g.opts.Currencies[g.rng.IntN(len(g.opts.Currencies))],
+		BIN:         providerBINs[g.rng.IntN(len(providerBINs))],
+		Last4:       fmt.Sprintf("%04d", g.rng.IntN(10000)),
+		ExpiryMonth: fmt.Sprintf("%02d", 1+g.rng.IntN(12)),
+		ExpiryYear:  fmt.Sprint(created.Year() + 1 + g.rng.IntN(5)),
+		LatencyMs:   int64(math.Exp(5.3 + g.rng.NormFloat64()*0.45)),
+		CreatedAt:   created,
+		UpdatedAt:   created,
+	}
+
+	roll := g.rng.Float64()
+	switch {
+	case roll < g.approval[provider]:
+		tx.Status = providers.StatusApproved
+	case roll < g.approval[provider]+0.01:
+		tx.Status = providers.StatusPending
+	default:
+		tx.Status = providers.StatusDeclined
+		tx.Reason = g.declineReason()
+		tx.ErrorCode = strings.ToUpper(tx.Reason)
+	}
+
+	tx.Timeline = []store.StatusChange{
+		{Status: "SUBMITTED", Time: created},
+		{Status: tx.Status, Time: created.Add(time.Duration(tx.LatencyMs) * time.Millisecond), Detail: tx.ErrorCode},
+	}
+	return tx
+}
+
+func (g *generator) declineReason() string {
+	roll := g.rng.Float64()
+	for _, decline := range declines {
+		if roll < decline.weight {
+			return decline.reason
+		}
+		roll -= decline.weight
+	}
+	return providers.ReasonCardDeclined
+}
+
+func (g *generator) refund(tx store.Transaction) reporting.RefundRecord {
+	amount := tx.Amount
+	if g.rng.Float64() < 0.3 {
+		amount = math.Round(tx.Amount*(0.2+g.rng.Float64()*0.6)*100) / 100
+	}
+
+	return reporting.RefundRecord{
+		RefundID:      "RF-" + tx.ID,
+		TransactionID: tx.ID,
+		Provider:      tx.Provider,
+		Amount:        amount,
+		Currency:      tx.Currency,
+		Reason:        refundReasons[g.rng.IntN(len(refundReasons))],
+		Time:          g.capped(tx.CreatedAt.Add(time.Duration(1+g.rng.IntN(14*24)) * time.Hour)),
+	}
+}
+
+func (g *generator) dispute(tx *store.Transaction) {
+	opened := g.capped(tx.CreatedAt.Add(time.Duration(3+g.rng.IntN(40)) * 24 * time.Hour))
+	tx.Timeline = append(tx.Timeline, store.StatusChange{Status: StatusDisputed, Time: opened, Detail: "chargeback"})
+	tx.UpdatedAt = opened
+}
+
+// capped keeps follow-up events inside the generated period
+func (g *generator) capped(t time.Time) time.Time {
+	if t.After(g.opts.End) {
+		return g.opts.End
+	}
+	return t
+}
@@ -0,0 +1,77 @@
+package seed
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/reporting"
+	"pgas/pkg/stats"
+	"pgas/pkg/store"
+)
+
+func TestGenerate_Deterministic(t *testing.T) {
+	end := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	opts := Options{Count: 200, End: end, Seed: 7}
+
+	first, second := Generate(opts), Generate(opts)
+	if !reflect.DeepEqual(first, second) {
+		t.Fatal("Expected the same seed to give the same history")
+	}
+
+	opts.Seed = 8
+	if reflect.DeepEqual(first, Generate(opts)) {
+		t.Error("Expected a different seed to give a different history")
+	}
+
+	for i, tx := range first.Transactions {
+		if !strings.HasPrefix(tx.ID, IDPrefix) || tx.CreatedAt.After(end) || tx.CreatedAt.Before(end.Add(-30*24*time.Hour)) {
+			t.Fatalf("Expected seeded transaction inside the period, got %+v", tx)
+		}
+		if i > 0 && tx.CreatedAt.Before(first.Transactions[i-1].CreatedAt) {
+			t.Fatal("Expected history oldest first")
+		}
+	}
+}
+
+func TestPopulate(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	refunds := reporting.NewRefundLedger()
+
+	result, err := Populate(transactions, refunds, Options{Count: 2000, DisputeRate: 0.05, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Transactions != 2000 || result.Approved == 0 || result.Declined == 0 || result.Disputes == 0 || result.Refunds == 0 {
+		t.Errorf("Expected a mixed history, got %+v", result)
+	}
+	if len(refunds.Records(time.Time{})) != result.Refunds {
+		t.Errorf("Expected %d refunds in the ledger", result.Refunds)
+	}
+
+	rate, _ := stats.New(transactions).SuccessRate("", "", 31*24*time.Hour)
+	if rate.Rate < 0.75 || rate.Rate > 0.97 {
+		t.Errorf("Expected a realistic success rate, got %.2f", rate.Rate)
+	}
+
+	rates, _ := stats.New(transactions).SuccessRates(31 * 24 * time.Hour)
+	if len(rates) != 6 {
+		t.Errorf("Expected three BINs for each of two providers, got %d", len(rates))
+	}
+}
+
+func TestLive(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	Live(ctx, transactions, 100, Options{Seed: 1})
+
+	live, _ := transactions.Query(store.TransactionFilter{})
+	if len(live) == 0 {
+		t.Error("Expected live payments to be added")
+	}
+}
@@ -0,0 +1,190 @@
+package settlement
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"pgas/pkg/merchant"
+)
+
+// Entry is a settled amount owed to a payee (merchant or sub-merchant)
+type Entry struct {
+	PayeeID    string    `json:"payee_id"`
+	Currency   string    `json:"currency"`
+	Amount     float64   `json:"amount"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// Balance describes what a payee is owed in one currency at a point in time
+type Balance struct {
+	Currency string  `json:"currency"`
+	Payable  float64 `json:"payable"`  // past the payout delay, net of reserve
+	Pending  float64 `json:"pending"`  // still inside the payout delay
+	Reserved float64 `json:"reserved"` // withheld as rolling reserve
+}
+
+// Instruction is a payout order consumed by the payouts module
+type Instruction struct {
+	PayeeID         string    `json:"payee_id"`
+	Currency        string    `json:"currency"`
+	Amount          float64   `json:"amount"`
+	ReserveWithheld float64   `json:"reserve_withheld"`
+	ReserveReleased float64   `json:"reserve_released"`
+	PayoutDate      time.Time `json:"payout_date"`
+	EntryCount      int       `json:"entry_count"`
+}
+
+type reserve struct {
+	payeeID    string
+	currency   string
+	amount     float64
+	withheldAt time.Time // payout run that withheld it
+	releaseAt  time.Time
+}
+
+// Engine accumulates settled entries and turns them into payout instructions
+// according to each payee's schedule
+type Engine struct {
+	mu       sync.Mutex
+	entries  []Entry
+	reserves []reserve
+}
+
+func NewEngine() *Engine {
+	return &Engine{}
+}
+
+// Record adds a settled entry awaiting payout
+func (e *Engine) Record(entry Entry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.entries = append(e.entries, entry)
+}
+
+// Balances computes the payee's balances per currency as of the given time
+func (e *Engine) Balances(payeeID string, schedule merchant.PayoutSchedule, asOf time.Time) map[string]Balance {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	balances := make(map[string]Balance)
+	for _, entry := range e.entries {
+		if entry.PayeeID != payeeID {
+			continue
+		}
+
+		balance := balances[entry.Currency]
+		balance.Currency = entry.Currency
+		if eligible(entry, schedule, asOf) {
+			withheld := roundAmount(entry.Amount * schedule.ReservePercent / 100)
+			balance.Payable = roundAmount(balance.Payable + entry.Amount - withheld)
+			balance.Reserved = roundAmount(balance.Reserved + withheld)
+		} else {
+			balance.Pending = roundAmount(balance.Pending + entry.Amount)
+		}
+		balances[entry.Currency] = balance
+	}
+
+	for _, r := range e.reserves {
+		if r.payeeID != payeeID {
+			continue
+		}
+
+		balance := balances[r.currency]
+		balance.Currency = r.currency
+		if asOf.Before(r.releaseAt) {
+			balance.Reserved = roundAmount(balance.Reserved + r.amount)
+		} else {
+			balance.Payable = roundAmount(balance.Payable + r.amount)
+		}
+		balances[r.currency] = balance
+	}
+
+	return balances
+}
+
+// GeneratePayouts creates payout instructions for every payee whose schedule
+// has a payout on asOf. Paid entries are consumed and their reserve portion is
+// held until the reserve period ends, then released in a later payout; never
+// in the payout that withheld it, even when the period already ended.
+func (e *Engine) GeneratePayouts(asOf time.Time, schedules map[string]merchant.PayoutSchedule) []Instruction {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	type key struct{ payeeID, currency string }
+	instructions := make(map[key]*Instruction)
+	instructionFor := func(payeeID, currency string) *Instruction {
+		k := key{payeeID, currency}
+		if instructions[k] == nil {
+			instructions[k] = &Instruction{PayeeID: payeeID, Currency: currency, PayoutDate: asOf}
+		}
+		return instructions[k]
+	}
+
+	remaining := e.entries[:0]
+	for _, entry := range e.entries {
+		schedule, ok := schedules[entry.PayeeID]
+		if !ok || !payoutDay(schedule, asOf) || !eligible(entry, schedule, asOf) {
+			remaining = append(remaining, entry)
+			continue
+		}
+
+		withheld := roundAmount(entry.Amount * schedule.ReservePercent / 100)
+		instruction := instructionFor(entry.PayeeID, entry.Currency)
+		instruction.Amount = roundAmount(instruction.Amount + entry.Amount - withheld)
+		instruction.ReserveWithheld = roundAmount(instruction.ReserveWithheld + withheld)
+		instruction.EntryCount++
+
+		if withheld > 0 {
+			e.reserves = append(e.reserves, reserve{
+				payeeID:    entry.PayeeID,
+				currency:   entry.Currency,
+				amount:     withheld,
+				withheldAt: asOf,
+				releaseAt:  entry.CapturedAt.AddDate(0, 0, schedule.ReserveDays),
+			})
+		}
+	}
+	e.entries = remaining
+
+	heldReserves := e.reserves[:0]
+	for _, r := range e.reserves {
+		schedule, ok := schedules[r.payeeID]
+		if !ok || !payoutDay(schedule, asOf) || !r.withheldAt.Before(asOf) || asOf.Before(r.releaseAt) {
+			heldReserves = append(heldReserves, r)
+			continue
+		}
+
+		instruction := instructionFor(r.payeeID, r.currency)
+		instruction.Amount = roundAmount(instruction.Amount + r.amount)
+		instruction.ReserveReleased = roundAmount(instruction.ReserveReleased + r.amount)
+	}
+	e.reserves = heldReserves
+
+	result := make([]Instruction, 0, len(instructions))
+	for _, instruction := range instructions {
+		if instruction.Amount > 0 {
+			result = append(result, *instruction)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].PayeeID != result[j].PayeeID {
+			return result[i].PayeeID < result[j].PayeeID
+		}
+		return result[i].Currency < result[j].Currency
+	})
+
+	return result
+}
+
+func eligible(entry Entry, schedule merchant.PayoutSchedule, asOf time.Time) bool {
+	return !asOf.Before(entry.CapturedAt.AddDate(0, 0, schedule.DelayDays))
+}
+
+func payoutDay(schedule merchant.PayoutSchedule, asOf time.Time) bool {
+	if schedule.Frequency == merchant.PayoutWeekly {
+		return asOf.Weekday() == schedule.WeeklyAnchor
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package settlement
+
+import (
+	"testing"
+	"time"
+
+	"pgas/pkg/merchant"
+)
+
+func TestEngine_RollingReserve(t *testing.T) {
+	engine := NewEngine()
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.UTC) }
+
+	schedule := merchant.PayoutSchedule{
+		Frequency:      merchant.PayoutDaily,
+		DelayDays:      2,
+		ReservePercent: 10,
+		ReserveDays:    5,
+	}
+	schedules := map[string]merchant.PayoutSchedule{"sub_1": schedule}
+
+	engine.Record(Entry{PayeeID: "sub_1", Currency: "USD", Amount: 100, CapturedAt: day(1)})
+	engine.Record(Entry{PayeeID: "sub_1", Currency: "USD", Amount: 50, CapturedAt: day(3)})
+
+	balances := engine.Balances("sub_1", schedule, day(3))
+	if b := balances["USD"]; b.Payable != 90 || b.Reserved != 10 || b.Pending != 50 {
+		t.Errorf("Unexpected balance on day 3: %+v", b)
+	}
+
+	payouts := engine.GeneratePayouts(day(3), schedules)
+	if len(payouts) != 1 || payouts[0].Amount != 90 || payouts[0].ReserveWithheld != 10 || payouts[0].EntryCount != 1 {
+		t.Fatalf("Unexpected payouts on day 3: %+v", payouts)
+	}
+
+	payouts = engine.GeneratePayouts(day(5), schedules)
+	if len(payouts) != 1 || payouts[0].Amount != 45 {
+		t.Fatalf("Unexpected payouts on day 5: %+v", payouts)
+	}
+
+	payouts = engine.GeneratePayouts(day(6), schedules)
+	if len(payouts) != 1 || payouts[0].ReserveReleased != 10 || payouts[0].Amount != 10 {
+		t.Fatalf("Expected first reserve to be released on day 6, got %+v", payouts)
+	}
+}
+
+func TestEngine_ZeroDayReserve(t *testing.T) {
+	engine := NewEngine()
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 12, 0, 0, 0, time.UTC) }
+	schedules := map[string]merchant.PayoutSchedule{
+		"sub_1": {Frequency: merchant.PayoutDaily, ReservePercent: 10},
+	}
+
+	engine.Record(Entry{PayeeID: "sub_1", Currency: "USD", Amount: 100, CapturedAt: day(1)})
+
+	payouts := engine.GeneratePayouts(day(1), schedules)
+	if len(payouts) != 1 || payouts[0].Amount != 90 || payouts[0].ReserveWithheld != 10 || payouts[0].ReserveReleased != 0 {
+		t.Fatalf("Expected the reserve to be held by the payout withholding it, got %+v", payouts)
+	}
+
+	payouts = engine.GeneratePayouts(day(2), schedules)
+	if len(payouts) != 1 || payouts[0].Amount != 10 || payouts[0].ReserveReleased != 10 {
+		t.Fatalf("Expected the reserve to be released in the next payout, got %+v", payouts)
+	}
+}
+
+func TestEngine_WeeklySchedule(t *testing.T) {
+	engine := NewEngine()
+	monday := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	schedules := map[string]merchant.PayoutSchedule{
+		"m_1": {Frequency: merchant.PayoutWeekly, WeeklyAnchor: time.Friday},
+	}
+
+	engine.Record(Entry{PayeeID: "m_1", Currency: "EUR", Amount: 20, CapturedAt: monday})
+
+	if payouts := engine.GeneratePayouts(monday.AddDate(0, 0, 1), schedules); len(payouts) != 0 {
+		t.Errorf("Expected no payout on Tuesday, got %+v", payouts)
+	}
+
+	if payouts := engine.GeneratePayouts(monday.AddDate(0, 0, 4), schedules); len(payouts) != 1 || payouts[0].Amount != 20 {
+		t.Errorf("Expected payout on Friday, got %+v", payouts)
+	}
+}
+
+func TestPayoutSchedule_Validate(t *testing.T) {
+	if err := (merchant.PayoutSchedule{Frequency: "monthly"}).Validate(); err == nil {
+		t.Error("Expected error for unsupported frequency")
+	}
+
+	if err := (merchant.PayoutSchedule{Frequency: merchant.PayoutDaily, ReservePercent: 150}).Validate(); err == nil {
+		t.Error("Expected error for reserve above 100 percent")
+	}
+}
@@ -0,0 +1,21 @@
+package settlement
+
+import (
+	"testing"
+
+	"pgas/pkg/merchant"
+)
+
+func TestSplitCharge(t *testing.T) {
+	subMerchant := merchant.SubMerchant{ID: "sub_1", FeeSplit: merchant.FeeSplit{Percent: 2.5, Fixed: 0.30}}
+
+	split := SplitCharge(100.00, "USD", subMerchant)
+	if split.PlatformFee != 2.80 || split.SubMerchantNet != 97.20 {
+		t.Errorf("Expected 2.80 fee and 97.20 net, got %+v", split)
+	}
+
+	split = SplitCharge(0.10, "USD", subMerchant)
+	if split.PlatformFee != 0.10 || split.SubMerchantNet != 0 {
+		t.Errorf("Expected fee capped at gross amount, got %+v", split)
+	}
+}
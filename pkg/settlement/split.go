@@ -0,0 +1,37 @@
+package settlement
+
+import (
+	"math"
+
+	"pgas/pkg/merchant"
+)
+
+// Split is how a single charge is divided between platform and sub-merchant
+type Split struct {
+	SubMerchantID  string  `json:"sub_merchant_id"`
+	Currency       string  `json:"currency"`
+	Gross          float64 `json:"gross"`
+	PlatformFee    float64 `json:"platform_fee"`
+	SubMerchantNet float64 `json:"sub_merchant_net"`
+}
+
+// SplitCharge applies the sub-merchant's fee split to a charge amount; the
+// platform fee never exceeds the gross amount
+func SplitCharge(amount float64, currency string, subMerchant merchant.SubMerchant) Split {
+	fee := roundAmount(amount*subMerchant.FeeSplit.Percent/100 + subMerchant.FeeSplit.Fixed)
+	if fee > amount {
+		fee = amount
+	}
+
+	return Split{
+		SubMerchantID:  subMerchant.ID,
+		Currency:       currency,
+		Gross:          roundAmount(amount),
+		PlatformFee:    fee,
+		SubMerchantNet: roundAmount(amount - fee),
+	}
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,74 @@
+package stats
+
+import (
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// cache line size on common CPUs, shards are padded to it so cores adding
+// to different shards don't invalidate each other's cache
+const cacheLine = 64
+
+type shard struct {
+	n atomic.Int64
+	_ [cacheLine - 8]byte
+}
+
+// Counter is a concurrency-safe counter for hot paths. Adds are spread over
+// per-core shards so goroutines counting at the same time rarely touch the
+// same memory; Load sums the shards. The zero value is not usable, see
+// NewCounter.
+type Counter struct {
+	shards []shard
+}
+
+func NewCounter() *Counter {
+	return &Counter{shards: make([]shard, runtime.GOMAXPROCS(0))}
+}
+
+func (c *Counter) Add(delta int64) {
+	// the runtime's random source is per thread, picking a shard with it
+	// needs no shared state
+	c.shards[rand.IntN(len(c.shards))].n.Add(delta)
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Load returns the current total. Adds running concurrently may or may not
+// be included.
+func (c *Counter) Load() int64 {
+	var total int64
+	for i := range c.shards {
+		total += c.shards[i].n.Load()
+	}
+	return total
+}
+
+// Counters is a set of named counters created on first use. Looking up an
+// existing counter takes no lock.
+type Counters struct {
+	counters sync.Map // name -> *Counter
+}
+
+// Get returns the counter for name, creating it when missing
+func (c *Counters) Get(name string) *Counter {
+	if counter, ok := c.counters.Load(name); ok {
+		return counter.(*Counter)
+	}
+	counter, _ := c.counters.LoadOrStore(name, NewCounter())
+	return counter.(*Counter)
+}
+
+// Snapshot returns the current value of every counter
+func (c *Counters) Snapshot() map[string]int64 {
+	snapshot := make(map[string]int64)
+	c.counters.Range(func(name, counter any) bool {
+		snapshot[name.(string)] = counter.(*Counter).Load()
+		return true
+	})
+	return snapshot
+}
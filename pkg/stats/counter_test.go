@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCounter_Concurrent(t *testing.T) {
+	counter := NewCounter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				counter.Inc()
+			}
+			counter.Add(-10)
+		}()
+	}
+	wg.Wait()
+
+	if total := counter.Load(); total != 8*990 {
+		t.Errorf("Expected 7920, got %d", total)
+	}
+}
+
+func TestCounters(t *testing.T) {
+	var counters Counters
+
+	counters.Get("visa").Inc()
+	counters.Get("visa").Inc()
+	counters.Get("mastercard").Add(3)
+
+	snapshot := counters.Snapshot()
+	if snapshot["visa"] != 2 || snapshot["mastercard"] != 3 || len(snapshot) != 2 {
+		t.Errorf("Unexpected snapshot: %v", snapshot)
+	}
+}
+
+func BenchmarkCounter_Parallel(b *testing.B) {
+	counter := NewCounter()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			counter.Inc()
+		}
+	})
+}
@@ -0,0 +1,150 @@
+// Package stats computes aggregate payment metrics from stored transactions
+// for routing rules, dashboards and alerts, and provides the sharded
+// counters the processor keeps its live bookkeeping in.
+package stats
+
+import (
+	"sort"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+// Rate is the share of approved payments among decided ones in a window.
+// Payments still DEFERRED or UNKNOWN are not counted yet.
+type Rate struct {
+	Provider string `json:"provider,omitempty"`
+	BIN      string `json:"bin,omitempty"`
+	// Dimension and Value name the business group of SuccessRatesBy
+	Dimension string        `json:"dimension,omitempty"`
+	Value     string        `json:"value,omitempty"`
+	Window    time.Duration `json:"window"`
+	Attempts  int           `json:"attempts"`
+	Approved  int           `json:"approved"`
+	Rate      float64       `json:"rate"` // 0..1, 0 without attempts
+}
+
+type Stats struct {
+	transactions store.Transactions
+	now          func() time.Time
+}
+
+func New(transactions store.Transactions) *Stats {
+	return &Stats{transactions: transactions, now: time.Now}
+}
+
+// SuccessRate computes the success rate over the last window; empty provider
+// or bin match all providers or BINs
+func (s *Stats) SuccessRate(provider, bin string, window time.Duration) (Rate, error) {
+	transactions, err := s.transactions.Query(store.TransactionFilter{
+		Provider: provider,
+		BIN:      bin,
+		Since:    s.now().Add(-window),
+	})
+	if err != nil {
+		return Rate{}, err
+	}
+
+	rate := Rate{Provider: provider, BIN: bin, Window: window}
+	for _, tx := range transactions {
+		rate.add(tx)
+	}
+	return rate.finish(), nil
+}
+
+// SuccessRates breaks the last window down per provider and BIN, worst
+// performing combinations first
+func (s *Stats) SuccessRates(window time.Duration) ([]Rate, error) {
+	transactions, err := s.transactions.Query(store.TransactionFilter{Since: s.now().Add(-window)})
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct{ provider, bin string }
+	byKey := make(map[key]*Rate)
+	for _, tx := range transactions {
+		k := key{tx.Provider, tx.BIN}
+		rate, ok := byKey[k]
+		if !ok {
+			rate = &Rate{Provider: tx.Provider, BIN: tx.BIN, Window: window}
+			byKey[k] = rate
+		}
+		rate.add(tx)
+	}
+
+	rates := make([]Rate, 0, len(byKey))
+	for _, rate := range byKey {
+		if rate.Attempts > 0 {
+			rates = append(rates, rate.finish())
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Rate != rates[j].Rate {
+			return rates[i].Rate < rates[j].Rate
+		}
+		if rates[i].Provider != rates[j].Provider {
+			return rates[i].Provider < rates[j].Provider
+		}
+		return rates[i].BIN < rates[j].BIN
+	})
+	return rates, nil
+}
+
+// SuccessRatesBy breaks the last window down by a business dimension,
+// store.DimensionTag or store.MetadataDimension(key), worst performing
+// groups first. Payments without the dimension are grouped under "".
+func (s *Stats) SuccessRatesBy(dimension string, window time.Duration) ([]Rate, error) {
+	if err := store.ValidateDimension(dimension); err != nil {
+		return nil, err
+	}
+	transactions, err := s.transactions.Query(store.TransactionFilter{Since: s.now().Add(-window)})
+	if err != nil {
+		return nil, err
+	}
+
+	byValue := make(map[string]*Rate)
+	for _, tx := range transactions {
+		for _, value := range store.DimensionValues(dimension, tx.Metadata, tx.Tags) {
+			rate, ok := byValue[value]
+			if !ok {
+				rate = &Rate{Dimension: dimension, Value: value, Window: window}
+				byValue[value] = rate
+			}
+			rate.add(tx)
+		}
+	}
+
+	rates := make([]Rate, 0, len(byValue))
+	for _, rate := range byValue {
+		if rate.Attempts > 0 {
+			rates = append(rates, rate.finish())
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if rates[i].Rate != rates[j].Rate {
+			return rates[i].Rate < rates[j].Rate
+		}
+		return rates[i].Value < rates[j].Value
+	})
+	return rates, nil
+}
+
+func (r *Rate) add(tx store.Transaction) {
+	switch tx.Status {
+	case providers.StatusDeferred, providers.StatusUnknown:
+		return
+	}
+
+	r.Attempts++
+	if providers.IsSuccessStatus(tx.Status) {
+		r.Approved++
+	}
+}
+
+func (r Rate) finish() Rate {
+	if r.Attempts > 0 {
+		r.Rate = float64(r.Approved) / float64(r.Attempts)
+	}
+	return r
+}
@@ -0,0 +1,88 @@
+package stats
+
+import (
+	"testing"
+	"time"
+
+	"pgas/pkg/store"
+)
+
+func TestStats_SuccessRate(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	now := time.Now()
+
+	for i, tx := range []store.Transaction{
+		{Provider: "visa", BIN: "411111", Status: "APPROVED", CreatedAt: now},
+		{Provider: "visa", BIN: "411111", Status: "DECLINED", CreatedAt: now},
+		{Provider: "visa", BIN: "411111", Status: "DECLINED", CreatedAt: now},
+		{Provider: "visa", BIN: "411111", Status: "DEFERRED", CreatedAt: now},
+		{Provider: "visa", BIN: "411111", Status: "APPROVED", CreatedAt: now.Add(-2 * time.Hour)},
+		{Provider: "visa", BIN: "422222", Status: "APPROVED", CreatedAt: now},
+		{Provider: "mastercard", BIN: "555555", Status: "PENDING", CreatedAt: now},
+	} {
+		tx.ID = string(rune('a' + i))
+		transactions.Save(tx)
+	}
+
+	stats := New(transactions)
+	stats.now = func() time.Time { return now.Add(time.Second) }
+
+	rate, err := stats.SuccessRate("visa", "411111", time.Hour)
+	if err != nil {
+		t.Fatalf("Expected success rate, got error: %v", err)
+	}
+
+	if rate.Attempts != 3 || rate.Approved != 1 {
+		t.Errorf("Expected 1 of 3 decided payments approved, got %+v", rate)
+	}
+
+	if rate, _ := stats.SuccessRate("", "", time.Hour); rate.Attempts != 5 || rate.Approved != 3 {
+		t.Errorf("Expected all providers and BINs, got %+v", rate)
+	}
+
+	rates, _ := stats.SuccessRates(time.Hour)
+	if len(rates) != 3 || rates[0].BIN != "411111" {
+		t.Errorf("Expected worst BIN first, got %+v", rates)
+	}
+}
+
+func TestStats_SuccessRatesBy(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	now := time.Now()
+
+	for i, tx := range []store.Transaction{
+		{Status: "APPROVED", Metadata: map[string]string{"campaign": "spring"}, Tags: []string{"app", "promo"}},
+		{Status: "DECLINED", Metadata: map[string]string{"campaign": "spring"}, Tags: []string{"app"}},
+		{Status: "APPROVED", Metadata: map[string]string{"campaign": "summer"}},
+		{Status: "DECLINED"},
+	} {
+		tx.ID = string(rune('a' + i))
+		tx.CreatedAt = now
+		transactions.Save(tx)
+	}
+
+	stats := New(transactions)
+	rates, err := stats.SuccessRatesBy(store.MetadataDimension("campaign"), time.Hour)
+	if err != nil {
+		t.Fatalf("Expected rates, got error: %v", err)
+	}
+	if len(rates) != 3 || rates[0].Value != "" || rates[1].Value != "spring" || rates[1].Rate != 0.5 || rates[2].Value != "summer" {
+		t.Errorf("Expected untagged, spring and summer worst first, got %+v", rates)
+	}
+	if rates[1].Dimension != "metadata.campaign" {
+		t.Errorf("Expected the dimension on each rate, got %+v", rates[1])
+	}
+
+	rates, _ = stats.SuccessRatesBy(store.DimensionTag, time.Hour)
+	byTag := make(map[string]Rate)
+	for _, rate := range rates {
+		byTag[rate.Value] = rate
+	}
+	if byTag["app"].Attempts != 2 || byTag["promo"].Attempts != 1 || byTag[""].Attempts != 2 {
+		t.Errorf("Expected payments counted once per tag, got %+v", rates)
+	}
+
+	if _, err := stats.SuccessRatesBy("campaign", time.Hour); err == nil {
+		t.Error("Expected an unknown dimension to be rejected")
+	}
+}
@@ -0,0 +1,124 @@
+// Package statuspage serves the data behind a customer facing "track your
+// payment" page. Payments are looked up with a signed, expiring token so
+// links can be shared without exposing other payments.
+package statuspage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"pgas/pkg/store"
+)
+
+var (
+	ErrInvalidToken    = errors.New("invalid status page token")
+	ErrTokenExpired    = errors.New("status page token expired")
+	ErrPaymentNotFound = errors.New("payment not found")
+)
+
+// Signer issues and verifies access tokens bound to one payment
+type Signer struct {
+	secret []byte
+	now    func() time.Time
+}
+
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret, now: time.Now}
+}
+
+// Token returns a token granting access to paymentID for ttl
+func (s *Signer) Token(paymentID string, ttl time.Duration) string {
+	expires := strconv.FormatInt(s.now().Add(ttl).Unix(), 10)
+	return expires + "." + s.sign(paymentID, expires)
+}
+
+// Verify checks that token was issued for paymentID and has not expired
+func (s *Signer) Verify(paymentID, token string) error {
+	expires, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidToken
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(s.sign(paymentID, expires))) {
+		return ErrInvalidToken
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidToken
+	}
+	if s.now().After(time.Unix(unix, 0)) {
+		return ErrTokenExpired
+	}
+	return nil
+}
+
+func (s *Signer) sign(paymentID, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(paymentID + "|" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// TimelineEntry is a status change shown to the customer
+type TimelineEntry struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+}
+
+// Page is the sanitized payload for rendering the status page
+type Page struct {
+	PaymentID string          `json:"payment_id"`
+	Status    string          `json:"status"`
+	Amount    float64         `json:"amount"`
+	Currency  string          `json:"currency"`
+	Card      string          `json:"card,omitempty"` // last four digits only
+	Timeline  []TimelineEntry `json:"timeline"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type Service struct {
+	transactions store.Transactions
+	signer       *Signer
+}
+
+func NewService(transactions store.Transactions, signer *Signer) *Service {
+	return &Service{transactions: transactions, signer: signer}
+}
+
+// Lookup verifies the token and returns the status page data. Internal
+// details such as error codes and providers are left out.
+func (s *Service) Lookup(paymentID, token string) (Page, error) {
+	if err := s.signer.Verify(paymentID, token); err != nil {
+		return Page{}, err
+	}
+
+	tx, err := s.transactions.Get(paymentID)
+	if errors.Is(err, store.ErrTransactionNotFound) {
+		return Page{}, ErrPaymentNotFound
+	}
+	if err != nil {
+		return Page{}, err
+	}
+
+	page := Page{
+		PaymentID: tx.ID,
+		Status:    tx.Status,
+		Amount:    tx.Amount,
+		Currency:  tx.Currency,
+		Timeline:  make([]TimelineEntry, 0, len(tx.Timeline)),
+		UpdatedAt: tx.UpdatedAt,
+	}
+	if tx.Last4 != "" {
+		page.Card = "**** " + tx.Last4
+	}
+	for _, change := range tx.Timeline {
+		page.Timeline = append(page.Timeline, TimelineEntry{Status: change.Status, Time: change.Time})
+	}
+
+	return page, nil
+}
@@ -0,0 +1,74 @@
+package statuspage
+
+import (
+	"testing"
+	"time"
+
+	"pgas/pkg/store"
+)
+
+func TestService_Lookup(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	now := time.Now()
+	transactions.Save(store.Transaction{
+		ID:        "TX1",
+		Provider:  "visa",
+		Status:    "DECLINED",
+		ErrorCode: "EE000011",
+		Amount:    42.5,
+		Currency:  "USD",
+		BIN:       "411111",
+		Last4:     "1111",
+		CreatedAt: now,
+		UpdatedAt: now,
+		Timeline: []store.StatusChange{
+			{Status: "SUBMITTED", Time: now},
+			{Status: "DECLINED", Time: now, Detail: "EE000011"},
+		},
+	})
+
+	signer := NewSigner([]byte("secret"))
+	service := NewService(transactions, signer)
+
+	page, err := service.Lookup("TX1", signer.Token("TX1", time.Hour))
+	if err != nil {
+		t.Fatalf("Expected lookup to succeed, got error: %v", err)
+	}
+
+	if page.Card != "**** 1111" || page.Amount != 42.5 || page.Status != "DECLINED" {
+		t.Errorf("Expected sanitized payment data, got %+v", page)
+	}
+
+	if len(page.Timeline) != 2 || page.Timeline[1].Status != "DECLINED" {
+		t.Errorf("Expected status timeline, got %+v", page.Timeline)
+	}
+
+	if _, err := service.Lookup("TX2", signer.Token("TX1", time.Hour)); err != ErrInvalidToken {
+		t.Errorf("Expected token for another payment to be rejected, got %v", err)
+	}
+
+	if _, err := service.Lookup("TX2", signer.Token("TX2", time.Hour)); err != ErrPaymentNotFound {
+		t.Errorf("Expected ErrPaymentNotFound, got %v", err)
+	}
+
+	if _, err := service.Lookup("TX1", "garbage"); err != ErrInvalidToken {
+		t.Errorf("Expected malformed token to be rejected, got %v", err)
+	}
+}
+
+func TestSigner_Expiry(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	now := time.Now()
+	signer.now = func() time.Time { return now }
+
+	token := signer.Token("TX1", time.Minute)
+
+	now = now.Add(2 * time.Minute)
+	if err := signer.Verify("TX1", token); err != ErrTokenExpired {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+
+	if err := NewSigner([]byte("other")).Verify("TX1", token); err != ErrInvalidToken {
+		t.Errorf("Expected token signed with another secret to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	"pgas/pkg/compress"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// Blob is a stored binary record such as a raw provider response or a
+// certification transcript. Encoding names the compression of Data, empty
+// for records stored uncompressed.
+type Blob struct {
+	Key       string    `json:"key"`
+	Encoding  string    `json:"encoding,omitempty"`
+	Size      int       `json:"size"` // uncompressed size in bytes
+	Data      []byte    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Blobs persists blobs as they are, see CompressedBlobs for transparent
+// compression on top
+type Blobs interface {
+	Save(blob Blob) error
+	Get(key string) (Blob, error)
+}
+
+// MemoryBlobs keeps blobs in a bounded in-memory store
+type MemoryBlobs struct {
+	records *Memory[string, Blob]
+}
+
+func NewMemoryBlobs(opts MemoryOptions) *MemoryBlobs {
+	return &MemoryBlobs{records: NewMemory[string, Blob](opts)}
+}
+
+func (m *MemoryBlobs) Save(blob Blob) error {
+	if blob.Key == "" {
+		return errors.New("blob key is required")
+	}
+	m.records.Put(blob.Key, blob)
+	return nil
+}
+
+func (m *MemoryBlobs) Get(key string) (Blob, error) {
+	blob, ok := m.records.Get(key)
+	if !ok {
+		return Blob{}, ErrBlobNotFound
+	}
+	return blob, nil
+}
+
+// CompressedBlobs compresses blobs on write and decompresses them on read
+// using the encoding recorded with each blob, so records written
+// uncompressed or with an earlier codec stay readable
+type CompressedBlobs struct {
+	blobs      Blobs
+	compressor compress.Compressor
+	codecs     compress.Registry
+}
+
+// NewCompressedBlobs writes with compressor and reads gzip, uncompressed and
+// the given additional codecs
+func NewCompressedBlobs(blobs Blobs, compressor compress.Compressor, readers ...compress.Compressor) *CompressedBlobs {
+	if compressor == nil {
+		compressor = compress.None
+	}
+	return &CompressedBlobs{
+		blobs:      blobs,
+		compressor: compressor,
+		codecs:     compress.NewRegistry(append(readers, compressor)...),
+	}
+}
+
+func (c *CompressedBlobs) Put(key string, data []byte) error {
+	compressed, err := c.compressor.Compress(data)
+	if err != nil {
+		return err
+	}
+	return c.blobs.Save(Blob{
+		Key:       key,
+		Encoding:  c.compressor.Encoding(),
+		Size:      len(data),
+		Data:      compressed,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (c *CompressedBlobs) Read(key string) ([]byte, error) {
+	blob, err := c.blobs.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.codecs.Decompress(blob.Encoding, blob.Data)
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"pgas/pkg/compress"
+)
+
+func TestCompressedBlobs(t *testing.T) {
+	backend := NewMemoryBlobs(MemoryOptions{})
+	blobs := NewCompressedBlobs(backend, compress.Gzip{})
+
+	data := bytes.Repeat([]byte(`{"payment_id":"PPAAYY--778899--XXYYZZ","state":"SUCCESS"}`), 20)
+	if err := blobs.Put("tx-1", data); err != nil {
+		t.Fatalf("Expected blob to be stored, got %v", err)
+	}
+
+	stored, _ := backend.Get("tx-1")
+	if stored.Encoding != "gzip" || stored.Size != len(data) || len(stored.Data) >= len(data) {
+		t.Errorf("Expected a compressed record with metadata, got encoding=%s size=%d stored=%d", stored.Encoding, stored.Size, len(stored.Data))
+	}
+
+	read, err := blobs.Read("tx-1")
+	if err != nil || !bytes.Equal(read, data) {
+		t.Errorf("Expected original data back, got %v", err)
+	}
+
+	if _, err := blobs.Read("missing"); err != ErrBlobNotFound {
+		t.Errorf("Expected ErrBlobNotFound, got %v", err)
+	}
+}
+
+func TestCompressedBlobs_LegacyRecords(t *testing.T) {
+	backend := NewMemoryBlobs(MemoryOptions{})
+	// written before compression was enabled
+	backend.Save(Blob{Key: "old", Data: []byte(`{"state":"SUCCESS"}`)})
+
+	blobs := NewCompressedBlobs(backend, compress.Gzip{})
+	read, err := blobs.Read("old")
+	if err != nil || string(read) != `{"state":"SUCCESS"}` {
+		t.Errorf("Expected uncompressed record to stay readable, got %q, %v", read, err)
+	}
+
+	backend.Save(Blob{Key: "odd", Encoding: "lz4", Data: []byte("x")})
+	if _, err := blobs.Read("odd"); err == nil {
+		t.Error("Expected error for a record with an unknown encoding")
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Capture is one capture of an authorized transaction. A transaction captured
+// in several parts has one record per part so settlement lines can be matched
+// against the gateway reference of each capture.
+type Capture struct {
+	ID               string    `json:"id"`
+	TransactionID    string    `json:"transaction_id"`
+	Provider         string    `json:"provider"`
+	GatewayReference string    `json:"gateway_reference,omitempty"` // empty when the gateway rejected the capture
+	Sequence         int       `json:"sequence"`                    // 1 for the first capture of the transaction
+	Final            bool      `json:"final,omitempty"`
+	Status           string    `json:"status"`
+	Amount           float64   `json:"amount"`
+	Currency         string    `json:"currency"`
+	ErrorCode        string    `json:"error_code,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Captures persists capture records per transaction
+type Captures interface {
+	Save(capture Capture) error
+	// ForTransaction returns the captures of a transaction in sequence order
+	ForTransaction(transactionID string) ([]Capture, error)
+}
+
+// MemoryCaptures keeps captures in a bounded in-memory store, bounded by
+// the number of transactions
+type MemoryCaptures struct {
+	mu      sync.Mutex
+	records *Memory[string, []Capture]
+}
+
+func NewMemoryCaptures(opts MemoryOptions) *MemoryCaptures {
+	return &MemoryCaptures{records: NewMemory[string, []Capture](opts)}
+}
+
+// Save adds a capture or replaces the one with the same id
+func (m *MemoryCaptures) Save(capture Capture) error {
+	if capture.ID == "" || capture.TransactionID == "" {
+		return errors.New("capture id and transaction id are required")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	existing, _ := m.records.Get(capture.TransactionID)
+	captures := make([]Capture, 0, len(existing)+1)
+	replaced := false
+	for _, c := range existing {
+		if c.ID == capture.ID {
+			c, replaced = capture, true
+		}
+		captures = append(captures, c)
+	}
+	if !replaced {
+		captures = append(captures, capture)
+	}
+
+	m.records.Put(capture.TransactionID, captures)
+	return nil
+}
+
+func (m *MemoryCaptures) ForTransaction(transactionID string) ([]Capture, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	captures, _ := m.records.Get(transactionID)
+	return append([]Capture(nil), captures...), nil
+}
@@ -0,0 +1,25 @@
+package store
+
+import "testing"
+
+func TestMemoryCaptures(t *testing.T) {
+	captures := NewMemoryCaptures(MemoryOptions{})
+
+	if err := captures.Save(Capture{ID: "cap_1"}); err == nil {
+		t.Error("Expected capture without transaction id to be rejected")
+	}
+
+	captures.Save(Capture{ID: "cap_1", TransactionID: "tx", Sequence: 1, Status: "PENDING"})
+	captures.Save(Capture{ID: "cap_2", TransactionID: "tx", Sequence: 2, Status: "APPROVED"})
+	captures.Save(Capture{ID: "cap_1", TransactionID: "tx", Sequence: 1, Status: "APPROVED"})
+
+	list, _ := captures.ForTransaction("tx")
+	if len(list) != 2 || list[0].ID != "cap_1" || list[0].Status != "APPROVED" || list[1].ID != "cap_2" {
+		t.Errorf("Expected cap_1 updated in place before cap_2, got %+v", list)
+	}
+
+	list[0].Status = "CHANGED"
+	if again, _ := captures.ForTransaction("tx"); again[0].Status != "APPROVED" {
+		t.Error("Expected returned captures to be a copy")
+	}
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DimensionTag groups payments by request tag
+const DimensionTag = "tag"
+
+const metadataPrefix = "metadata."
+
+// MetadataDimension groups payments by the value of a request metadata key
+func MetadataDimension(key string) string {
+	return metadataPrefix + key
+}
+
+// ValidateDimension rejects dimensions other than DimensionTag and
+// MetadataDimension
+func ValidateDimension(dimension string) error {
+	if dimension == DimensionTag || (strings.HasPrefix(dimension, metadataPrefix) && len(dimension) > len(metadataPrefix)) {
+		return nil
+	}
+	return fmt.Errorf("unknown dimension '%s', use '%s' or '%s<key>'", dimension, DimensionTag, metadataPrefix)
+}
+
+// DimensionValues returns the groups a payment falls into. A payment with
+// several tags is in one group per tag; a payment without the metadata key
+// or without tags is in the "" group.
+func DimensionValues(dimension string, metadata map[string]string, tags []string) []string {
+	if dimension == DimensionTag {
+		if len(tags) == 0 {
+			return []string{""}
+		}
+		return tags
+	}
+	return []string{metadata[strings.TrimPrefix(dimension, metadataPrefix)]}
+}
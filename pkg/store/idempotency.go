@@ -0,0 +1,72 @@
+package store
+
+import (
+	"sync"
+	"time"
+
+	"pgas/pkg/providers"
+)
+
+// IdempotencyRecord is what is kept per key. Response and Error are empty
+// while the first payment is still in flight.
+type IdempotencyRecord struct {
+	// RequestHash identifies the payment the key was first used for
+	RequestHash string                     `json:"request_hash"`
+	Done        bool                       `json:"done"`
+	Response    *providers.PaymentResponse `json:"response,omitempty"`
+	Error       *providers.PaymentError    `json:"error,omitempty"`
+}
+
+// Idempotency keeps payment outcomes by idempotency key. Stores shared
+// by several instances must make Reserve atomic.
+type Idempotency interface {
+	// Reserve claims key for a new payment. When the key is already in use
+	// it returns the existing record and false.
+	Reserve(key string, record IdempotencyRecord, ttl time.Duration) (IdempotencyRecord, bool)
+	// Complete stores the outcome of a reserved key
+	Complete(key string, record IdempotencyRecord, ttl time.Duration)
+	// Release forgets a key so the payment may be attempted again
+	Release(key string)
+}
+
+// MemoryIdempotency is an Idempotency store for a single instance
+type MemoryIdempotency struct {
+	mu      sync.Mutex
+	records *Memory[string, idempotencyEntry]
+	now     func() time.Time
+}
+
+type idempotencyEntry struct {
+	record  IdempotencyRecord
+	expires time.Time
+}
+
+// NewMemoryIdempotency keeps records until their TTL passes or opts
+// evicts them; opts.TTL is not used
+func NewMemoryIdempotency(opts MemoryOptions) *MemoryIdempotency {
+	opts.TTL = 0
+	return &MemoryIdempotency{records: NewMemory[string, idempotencyEntry](opts), now: time.Now}
+}
+
+func (m *MemoryIdempotency) Reserve(key string, record IdempotencyRecord, ttl time.Duration) (IdempotencyRecord, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if entry, ok := m.records.Get(key); ok && now.Before(entry.expires) {
+		return entry.record, false
+	}
+	m.records.Put(key, idempotencyEntry{record: record, expires: now.Add(ttl)})
+	return record, true
+}
+
+func (m *MemoryIdempotency) Complete(key string, record IdempotencyRecord, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records.Put(key, idempotencyEntry{record: record, expires: m.now().Add(ttl)})
+}
+
+func (m *MemoryIdempotency) Release(key string) {
+	m.records.Delete(key)
+}
@@ -0,0 +1,31 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryIdempotency_TTL(t *testing.T) {
+	now := time.Now()
+	memory := NewMemoryIdempotency(MemoryOptions{})
+	memory.now = func() time.Time { return now }
+
+	if _, reserved := memory.Reserve("key", IdempotencyRecord{RequestHash: "a"}, time.Minute); !reserved {
+		t.Fatal("Expected a new key to be reserved")
+	}
+	memory.Complete("key", IdempotencyRecord{RequestHash: "a", Done: true}, time.Minute)
+
+	if record, reserved := memory.Reserve("key", IdempotencyRecord{RequestHash: "b"}, time.Minute); reserved || !record.Done {
+		t.Errorf("Expected the completed record, got %+v", record)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, reserved := memory.Reserve("key", IdempotencyRecord{RequestHash: "b"}, time.Minute); !reserved {
+		t.Error("Expected an expired key to be reserved again")
+	}
+
+	memory.Release("key")
+	if _, reserved := memory.Reserve("key", IdempotencyRecord{}, time.Minute); !reserved {
+		t.Error("Expected a released key to be reserved again")
+	}
+}
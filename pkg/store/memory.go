@@ -0,0 +1,205 @@
+package store
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type EvictionReason string
+
+const (
+	EvictedCapacity EvictionReason = "capacity" // dropped as least recently used
+	EvictedExpired  EvictionReason = "expired"  // older than the configured TTL
+)
+
+// limits for an in-memory store; zero values mean unbounded
+type MemoryOptions struct {
+	MaxEntries int
+	TTL        time.Duration
+	OnEvict    func(key interface{}, reason EvictionReason)
+	Now        func() time.Time
+}
+
+// MemoryStats reports store occupancy and eviction counts
+type MemoryStats struct {
+	Entries     int    `json:"entries"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+}
+
+type memoryEntry[K comparable, V any] struct {
+	key       K
+	value     V
+	expiresAt time.Time
+}
+
+type eviction[K comparable] struct {
+	key    K
+	reason EvictionReason
+}
+
+// Memory is a concurrency-safe in-memory store bounded by entry count (LRU)
+// and entry age, used as the default backing for the processor's stores
+type Memory[K comparable, V any] struct {
+	mu      sync.Mutex
+	entries map[K]*list.Element
+	order   *list.List
+	opts    MemoryOptions
+	stats   MemoryStats
+}
+
+func NewMemory[K comparable, V any](opts MemoryOptions) *Memory[K, V] {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+
+	return &Memory[K, V]{
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+		opts:    opts,
+	}
+}
+
+// Get returns the value for key, treating expired entries as missing
+func (m *Memory[K, V]) Get(key K) (V, bool) {
+	var evicted []eviction[K]
+	defer m.notify(&evicted)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var zero V
+	element, ok := m.entries[key]
+	if !ok {
+		return zero, false
+	}
+
+	entry := element.Value.(*memoryEntry[K, V])
+	if m.expired(entry) {
+		evicted = append(evicted, m.remove(element, EvictedExpired))
+		return zero, false
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true
+}
+
+// Put stores the value, evicting the least recently used entry when full
+func (m *Memory[K, V]) Put(key K, value V) {
+	var evicted []eviction[K]
+	defer m.notify(&evicted)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expiresAt time.Time
+	if m.opts.TTL > 0 {
+		expiresAt = m.opts.Now().Add(m.opts.TTL)
+	}
+
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryEntry[K, V])
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(element)
+		return
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry[K, V]{key: key, value: value, expiresAt: expiresAt})
+
+	for m.opts.MaxEntries > 0 && m.order.Len() > m.opts.MaxEntries {
+		evicted = append(evicted, m.remove(m.order.Back(), EvictedCapacity))
+	}
+}
+
+// Delete removes the key if present
+func (m *Memory[K, V]) Delete(key K) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.entries[key]; ok {
+		m.order.Remove(element)
+		delete(m.entries, key)
+	}
+}
+
+// Range calls fn for every live entry until fn returns false
+func (m *Memory[K, V]) Range(fn func(key K, value V) bool) {
+	m.mu.Lock()
+	live := make([]*memoryEntry[K, V], 0, m.order.Len())
+	for element := m.order.Front(); element != nil; element = element.Next() {
+		entry := element.Value.(*memoryEntry[K, V])
+		if !m.expired(entry) {
+			live = append(live, entry)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, entry := range live {
+		if !fn(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+// Sweep removes all expired entries and returns how many were removed
+func (m *Memory[K, V]) Sweep() int {
+	var evicted []eviction[K]
+	defer m.notify(&evicted)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for element := m.order.Back(); element != nil; {
+		previous := element.Prev()
+		if m.expired(element.Value.(*memoryEntry[K, V])) {
+			evicted = append(evicted, m.remove(element, EvictedExpired))
+		}
+		element = previous
+	}
+
+	return len(evicted)
+}
+
+// Len returns the number of stored entries, including not yet swept ones
+func (m *Memory[K, V]) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+func (m *Memory[K, V]) Stats() MemoryStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Entries = m.order.Len()
+	return stats
+}
+
+func (m *Memory[K, V]) expired(entry *memoryEntry[K, V]) bool {
+	return !entry.expiresAt.IsZero() && !m.opts.Now().Before(entry.expiresAt)
+}
+
+// remove drops an evicted entry; callers hold m.mu and pass the eviction to
+// notify once they released it
+func (m *Memory[K, V]) remove(element *list.Element, reason EvictionReason) eviction[K] {
+	entry := m.order.Remove(element).(*memoryEntry[K, V])
+	delete(m.entries, entry.key)
+
+	if reason == EvictedExpired {
+		m.stats.Expirations++
+	} else {
+		m.stats.Evictions++
+	}
+	return eviction[K]{key: entry.key, reason: reason}
+}
+
+// notify calls OnEvict outside the lock, so callbacks may use the store.
+// It is deferred before the lock is taken and so runs after the unlock.
+func (m *Memory[K, V]) notify(evicted *[]eviction[K]) {
+	if m.opts.OnEvict == nil {
+		return
+	}
+	for _, e := range *evicted {
+		m.opts.OnEvict(e.key, e.reason)
+	}
+}
@@ -0,0 +1,107 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemory_CapacityEviction(t *testing.T) {
+	evicted := []interface{}{}
+	m := NewMemory[string, int](MemoryOptions{
+		MaxEntries: 2,
+		OnEvict: func(key interface{}, reason EvictionReason) {
+			if reason != EvictedCapacity {
+				t.Errorf("Expected capacity eviction, got: %s", reason)
+			}
+			evicted = append(evicted, key)
+		},
+	})
+
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Get("a") // "b" is now least recently used
+	m.Put("c", 3)
+
+	if _, ok := m.Get("b"); ok {
+		t.Error("Expected 'b' to be evicted")
+	}
+
+	if v, ok := m.Get("a"); !ok || v != 1 {
+		t.Errorf("Expected 'a' to be kept, got %v %v", v, ok)
+	}
+
+	if len(evicted) != 1 || evicted[0] != "b" {
+		t.Errorf("Expected eviction callback for 'b', got %v", evicted)
+	}
+
+	stats := m.Stats()
+	if stats.Entries != 2 || stats.Evictions != 1 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestMemory_TTLExpiry(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	m := NewMemory[string, int](MemoryOptions{
+		TTL: time.Minute,
+		Now: func() time.Time { return now },
+	})
+
+	m.Put("a", 1)
+	m.Put("b", 2)
+
+	now = now.Add(30 * time.Second)
+	if _, ok := m.Get("a"); !ok {
+		t.Error("Expected 'a' to be live before TTL")
+	}
+
+	now = now.Add(time.Minute)
+	if _, ok := m.Get("a"); ok {
+		t.Error("Expected 'a' to be expired")
+	}
+
+	if removed := m.Sweep(); removed != 1 {
+		t.Errorf("Expected sweep to remove 1 entry, got %d", removed)
+	}
+
+	stats := m.Stats()
+	if stats.Entries != 0 || stats.Expirations != 2 {
+		t.Errorf("Unexpected stats: %+v", stats)
+	}
+}
+
+func TestMemory_RangeAndDelete(t *testing.T) {
+	m := NewMemory[string, int](MemoryOptions{})
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Delete("a")
+
+	seen := map[string]int{}
+	m.Range(func(key string, value int) bool {
+		seen[key] = value
+		return true
+	})
+
+	if len(seen) != 1 || seen["b"] != 2 {
+		t.Errorf("Unexpected range result: %v", seen)
+	}
+}
+
+func TestMemory_OnEvictMayUseStore(t *testing.T) {
+	var m *Memory[string, int]
+	var lengths []int
+	m = NewMemory[string, int](MemoryOptions{
+		MaxEntries: 1,
+		OnEvict: func(key interface{}, reason EvictionReason) {
+			// would deadlock if the callback ran under the store lock
+			lengths = append(lengths, m.Len())
+		},
+	})
+
+	m.Put("a", 1)
+	m.Put("b", 2)
+
+	if len(lengths) != 1 || lengths[0] != 1 {
+		t.Errorf("Expected the callback to see the store after the eviction, got %v", lengths)
+	}
+}
@@ -0,0 +1,193 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ResidencyPolicy pins stored transactions to a regional backend by the
+// merchant's or the card issuer's country, for merchants whose data must not
+// leave a jurisdiction. The merchant country is looked up first.
+type ResidencyPolicy struct {
+	Local     string                  `json:"local"`     // region of this instance
+	Default   string                  `json:"default"`   // region of records no country pins
+	Countries map[string]string       `json:"countries"` // ISO country code to region
+	Backends  map[string]Transactions `json:"-"`         // one store per region
+}
+
+// Validate checks that every region the policy can pick has a backend
+func (p ResidencyPolicy) Validate() error {
+	if p.Backends[p.Local] == nil {
+		return fmt.Errorf("no backend for local region '%s'", p.Local)
+	}
+	if p.Backends[p.Default] == nil {
+		return fmt.Errorf("no backend for default region '%s'", p.Default)
+	}
+	for country, region := range p.Countries {
+		if p.Backends[region] == nil {
+			return fmt.Errorf("country '%s' is pinned to region '%s' which has no backend", country, region)
+		}
+	}
+	return nil
+}
+
+// RegionFor returns the region a transaction must be stored in
+func (p ResidencyPolicy) RegionFor(tx Transaction) string {
+	for _, country := range []string{tx.MerchantCountry, tx.IssuerCountry} {
+		if region, ok := p.Countries[strings.ToUpper(country)]; ok && country != "" {
+			return region
+		}
+	}
+	return p.Default
+}
+
+// RegionalTransactions stores every transaction in the backend of its
+// region. Records of the local region are returned in full; records read
+// from other regions carry metadata only, and filters on card data are
+// never evaluated outside the local region.
+type RegionalTransactions struct {
+	policy ResidencyPolicy
+}
+
+func NewRegionalTransactions(policy ResidencyPolicy) (*RegionalTransactions, error) {
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+
+	countries := make(map[string]string, len(policy.Countries))
+	for country, region := range policy.Countries {
+		countries[strings.ToUpper(country)] = region
+	}
+	policy.Countries = countries
+
+	return &RegionalTransactions{policy: policy}, nil
+}
+
+// Save writes tx to the backend of its region. A metadata-only record read
+// from another region only updates the status fields and annotations of the
+// full record.
+func (r *RegionalTransactions) Save(tx Transaction) error {
+	if !tx.MetadataOnly {
+		tx.Region = r.policy.RegionFor(tx)
+		return r.policy.Backends[tx.Region].Save(tx)
+	}
+
+	backend := r.policy.Backends[tx.Region]
+	if backend == nil {
+		return fmt.Errorf("unknown region '%s'", tx.Region)
+	}
+	full, err := backend.Get(tx.ID)
+	if err != nil {
+		return err
+	}
+	return backend.Save(merged(full, tx))
+}
+
+// CompareAndSave is Save on the condition that the record in tx's region
+// is unchanged since read
+func (r *RegionalTransactions) CompareAndSave(read, tx Transaction) error {
+	if !tx.MetadataOnly {
+		tx.Region = r.policy.RegionFor(tx)
+		return r.policy.Backends[tx.Region].CompareAndSave(read, tx)
+	}
+
+	backend := r.policy.Backends[tx.Region]
+	if backend == nil {
+		return fmt.Errorf("unknown region '%s'", tx.Region)
+	}
+	full, err := backend.Get(tx.ID)
+	if err != nil {
+		return err
+	}
+	if !unchanged(full, read) {
+		return ErrTransactionChanged
+	}
+	return backend.CompareAndSave(full, merged(full, tx))
+}
+
+// merged applies the fields a metadata-only record may update to the full
+// record
+func merged(full, tx Transaction) Transaction {
+	full.Status, full.ErrorCode, full.Reason = tx.Status, tx.ErrorCode, tx.Reason
+	full.UpdatedAt, full.Timeline = tx.UpdatedAt, tx.Timeline
+	full.Annotations = tx.Annotations
+	return full
+}
+
+func (r *RegionalTransactions) Get(id string) (Transaction, error) {
+	for _, region := range r.regions() {
+		tx, err := r.policy.Backends[region].Get(id)
+		if errors.Is(err, ErrTransactionNotFound) {
+			continue
+		}
+		if err != nil {
+			return Transaction{}, err
+		}
+		return r.view(region, tx), nil
+	}
+	return Transaction{}, ErrTransactionNotFound
+}
+
+func (r *RegionalTransactions) Query(filter TransactionFilter) ([]Transaction, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	regions := r.regions()
+	if filter.matchesCardData() {
+		// a match would disclose card data held in the other region
+		regions = regions[:1]
+	}
+
+	var matches []Transaction
+	for _, region := range regions {
+		found, err := r.policy.Backends[region].Query(filter)
+		if err != nil {
+			return nil, err
+		}
+		for _, tx := range found {
+			matches = append(matches, r.view(region, tx))
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].CreatedAt.Before(matches[j].CreatedAt) })
+	return matches, nil
+}
+
+// regions lists the local region first, then the others by name
+func (r *RegionalTransactions) regions() []string {
+	regions := []string{r.policy.Local}
+	var others []string
+	for region := range r.policy.Backends {
+		if region != r.policy.Local {
+			others = append(others, region)
+		}
+	}
+	sort.Strings(others)
+	return append(regions, others...)
+}
+
+func (r *RegionalTransactions) view(region string, tx Transaction) Transaction {
+	tx.Region = region
+	if region == r.policy.Local {
+		return tx
+	}
+	return Metadata(tx)
+}
+
+// Metadata strips a transaction down to what may leave its region: no card
+// data, fingerprints or enrichments
+func Metadata(tx Transaction) Transaction {
+	tx.BIN, tx.Last4 = "", ""
+	tx.ExpiryMonth, tx.ExpiryYear = "", ""
+	tx.Fingerprint = ""
+	tx.Extra = nil
+	tx.MetadataOnly = true
+	return tx
+}
+
+func (f TransactionFilter) matchesCardData() bool {
+	return f.BIN != "" || f.Last4 != "" || f.ExpiryMonth != "" || f.ExpiryYear != "" || len(f.Fingerprints) > 0
+}
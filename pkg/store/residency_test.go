@@ -0,0 +1,101 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func newResidency(t *testing.T) (*RegionalTransactions, *MemoryTransactions, *MemoryTransactions) {
+	eu, us := NewMemoryTransactions(MemoryOptions{}), NewMemoryTransactions(MemoryOptions{})
+	regional, err := NewRegionalTransactions(ResidencyPolicy{
+		Local:     "eu",
+		Default:   "us",
+		Countries: map[string]string{"de": "eu", "FR": "eu"},
+		Backends:  map[string]Transactions{"eu": eu, "us": us},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return regional, eu, us
+}
+
+func TestRegionalTransactions_Pinning(t *testing.T) {
+	regional, eu, us := newResidency(t)
+	now := time.Now()
+
+	regional.Save(Transaction{ID: "merchant-de", MerchantCountry: "DE", IssuerCountry: "US", Last4: "1111", CreatedAt: now})
+	regional.Save(Transaction{ID: "issuer-fr", MerchantCountry: "US", IssuerCountry: "fr", Last4: "1111", CreatedAt: now.Add(time.Second)})
+	regional.Save(Transaction{ID: "unpinned", MerchantCountry: "US", Last4: "1111", BIN: "411111", CreatedAt: now.Add(2 * time.Second)})
+
+	if _, err := eu.Get("merchant-de"); err != nil {
+		t.Error("Expected the merchant country to pin the record to eu")
+	}
+	if _, err := eu.Get("issuer-fr"); err != nil {
+		t.Error("Expected the issuer country to pin the record to eu")
+	}
+	if tx, err := us.Get("unpinned"); err != nil || tx.Region != "us" {
+		t.Errorf("Expected unpinned records in the default region, got %+v (%v)", tx, err)
+	}
+}
+
+func TestRegionalTransactions_CrossRegionMetadataOnly(t *testing.T) {
+	regional, _, us := newResidency(t)
+	now := time.Now()
+	regional.Save(Transaction{ID: "local", MerchantCountry: "DE", Last4: "1111", CreatedAt: now})
+	regional.Save(Transaction{ID: "remote", MerchantCountry: "US", Last4: "1111", BIN: "411111", Fingerprint: "fp", Extra: map[string]string{"k": "v"}, Status: "PENDING", CreatedAt: now.Add(time.Second)})
+
+	local, _ := regional.Get("local")
+	if local.MetadataOnly || local.Last4 != "1111" {
+		t.Errorf("Expected local records in full, got %+v", local)
+	}
+
+	remote, _ := regional.Get("remote")
+	if !remote.MetadataOnly || remote.Last4 != "" || remote.BIN != "" || remote.Fingerprint != "" || remote.Extra != nil {
+		t.Errorf("Expected remote records without card data, got %+v", remote)
+	}
+
+	all, _ := regional.Query(TransactionFilter{})
+	if len(all) != 2 || all[0].ID != "local" || !all[1].MetadataOnly {
+		t.Errorf("Expected both records, the remote one as metadata, got %+v", all)
+	}
+
+	byCard, _ := regional.Query(TransactionFilter{Last4: "1111"})
+	if len(byCard) != 1 || byCard[0].ID != "local" {
+		t.Errorf("Expected card data filters to stay in the local region, got %+v", byCard)
+	}
+
+	// status updates through a metadata view keep the card data in place
+	remote.Status = "APPROVED"
+	if err := regional.Save(remote); err != nil {
+		t.Fatalf("Expected status update of a remote record, got %v", err)
+	}
+	if full, _ := us.Get("remote"); full.Status != "APPROVED" || full.Last4 != "1111" || full.MetadataOnly {
+		t.Errorf("Expected the full remote record updated in place, got %+v", full)
+	}
+
+	// a conditional update from the stale view is refused
+	stale := remote
+	stale.Status = "PENDING"
+	expired := remote
+	expired.Status = "EXPIRED"
+	if err := regional.CompareAndSave(stale, expired); !errors.Is(err, ErrTransactionChanged) {
+		t.Errorf("Expected ErrTransactionChanged, got %v", err)
+	}
+	if err := regional.CompareAndSave(remote, expired); err != nil {
+		t.Fatalf("Expected a conditional update of the unchanged record, got %v", err)
+	}
+	if full, _ := us.Get("remote"); full.Status != "EXPIRED" || full.Last4 != "1111" {
+		t.Errorf("Expected the full remote record updated in place, got %+v", full)
+	}
+}
+
+func TestResidencyPolicy_Validate(t *testing.T) {
+	backends := map[string]Transactions{"eu": NewMemoryTransactions(MemoryOptions{})}
+	if _, err := NewRegionalTransactions(ResidencyPolicy{Local: "eu", Default: "eu", Countries: map[string]string{"US": "us"}, Backends: backends}); err == nil {
+		t.Error("Expected a country pinned to a region without backend to be rejected")
+	}
+	if _, err := NewRegionalTransactions(ResidencyPolicy{Local: "eu", Default: "us", Backends: backends}); err == nil {
+		t.Error("Expected a default region without backend to be rejected")
+	}
+}
@@ -0,0 +1,289 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found")
+	// ErrTransactionChanged is returned by CompareAndSave when the stored
+	// record changed since it was read
+	ErrTransactionChanged = errors.New("transaction changed since it was read")
+)
+
+// Transaction is the stored record of a payment. Card data is kept only in
+// non-sensitive form: BIN, last four digits, expiry and a keyed fingerprint.
+type Transaction struct {
+	ID            string         `json:"id"`
+	Provider      string         `json:"provider"`
+	Status        string         `json:"status"`
+	Amount        float64        `json:"amount"`
+	Currency      string         `json:"currency"`
+	ErrorCode     string         `json:"error_code,omitempty"`
+	Reason        string         `json:"reason,omitempty"`
+	BIN           string         `json:"bin,omitempty"`
+	Last4         string         `json:"last4,omitempty"`
+	ExpiryMonth   string         `json:"expiry_month,omitempty"`
+	ExpiryYear    string         `json:"expiry_year,omitempty"`
+	Fingerprint   string         `json:"fingerprint,omitempty"` // fingerprint.Fingerprint.String()
+	SubMerchantID string         `json:"sub_merchant_id,omitempty"`
+	Descriptor    string         `json:"descriptor,omitempty"` // as rendered for the statement
+	Method        string         `json:"method,omitempty"`
+	LatencyMs     int64          `json:"latency_ms"` // time spent on gateway calls
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	Timeline      []StatusChange `json:"timeline"`
+	// GatewayReference is the provider's reference of the payment, used to
+	// refund, capture and look it up at the gateway
+	GatewayReference string `json:"gateway_reference,omitempty"`
+	// Extra holds the fields enrichers added to the payment response
+	Extra map[string]string `json:"extra,omitempty"`
+	// request metadata and tags, see DimensionValues
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Tags     []string          `json:"tags,omitempty"`
+	// merchant references of the payment, used to correlate gateway
+	// webhooks that do not carry the gateway reference
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	OrderID        string `json:"order_id,omitempty"`
+
+	// stored credential indicators, see providers.ValidateStoredCredential
+	InitiatedBy           string `json:"initiated_by,omitempty"`
+	StoredCredentialUsage string `json:"stored_credential_usage,omitempty"`
+	PriorTransactionID    string `json:"prior_transaction_id,omitempty"`
+	// AuthorizationType is final or estimated, empty means final
+	AuthorizationType string `json:"authorization_type,omitempty"`
+	// RequestedAmount is set on partial approvals, Amount is the approved
+	// amount
+	RequestedAmount float64 `json:"requested_amount,omitempty"`
+	// ResponseVersion is the gateway response version the payment was
+	// parsed as
+	ResponseVersion string `json:"response_version,omitempty"`
+	// merchant account and MID the payment was submitted under;
+	// CrossBorder marks payments without a local account for their
+	// currency, settled by the cross-border account
+	MerchantAccount string `json:"merchant_account,omitempty"`
+	MerchantID      string `json:"merchant_id,omitempty"`
+	CrossBorder     bool   `json:"cross_border,omitempty"`
+
+	// data residency, see ResidencyPolicy
+	MerchantCountry string `json:"merchant_country,omitempty"`
+	IssuerCountry   string `json:"issuer_country,omitempty"`
+	Region          string `json:"region,omitempty"`
+	// MetadataOnly marks records read from another region, card data and
+	// enrichments are left out
+	MetadataOnly bool `json:"metadata_only,omitempty"`
+
+	// Annotations are operator notes added after the fact, oldest first
+	Annotations []Annotation `json:"annotations,omitempty"`
+	// Processing is how the processor got to the payment's outcome:
+	// validation, the provider choice and every gateway attempt
+	Processing []ProcessingStep `json:"processing,omitempty"`
+}
+
+// steps of ProcessingStep
+const (
+	StepValidated = "validated"
+	StepRouted    = "routed"
+	StepAttempt   = "attempt"
+)
+
+// ProcessingStep is one step of processing a payment. Routing steps name
+// the provider and how it was chosen, attempts the provider and the status
+// or error code the gateway call ended with.
+type ProcessingStep struct {
+	Step      string    `json:"step"`
+	Time      time.Time `json:"time"`
+	Provider  string    `json:"provider,omitempty"`
+	Outcome   string    `json:"outcome,omitempty"`
+	LatencyMs int64     `json:"latency_ms,omitempty"`
+}
+
+// Annotation is an operator note on a transaction, such as investigation
+// context recorded by support
+type Annotation struct {
+	Author string    `json:"author"`
+	Note   string    `json:"note,omitempty"`
+	Tags   []string  `json:"tags,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// StatusChange is one step in the life of a transaction
+type StatusChange struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// TransactionFilter selects transactions; zero fields match everything
+type TransactionFilter struct {
+	Provider string
+	Status   string
+	BIN      string
+	Last4    string
+	// card expiry as stored, i.e. after the records redaction policy
+	ExpiryMonth string
+	ExpiryYear  string
+	// Fingerprints matches any of the given card fingerprints, one per key
+	// version so cards stay findable across key rotations
+	Fingerprints []string
+	Since        time.Time // inclusive
+	Until        time.Time // exclusive
+	// Tag matches transactions tagged by the request or by an annotation
+	Tag string
+	// Metadata matches transactions carrying all of the request metadata
+	Metadata map[string]string
+	// Note matches transactions with an annotation containing the text,
+	// ignoring case
+	Note string
+	// merchant references, see Transaction.IdempotencyKey
+	IdempotencyKey string
+	OrderID        string
+	// GatewayReference matches the provider's reference, not unique across
+	// providers nor always within one
+	GatewayReference string
+}
+
+// Validate rejects filters that could only be satisfied by card data the
+// store never holds
+func (f TransactionFilter) Validate() error {
+	if f.Last4 != "" && (len(f.Last4) != 4 || strings.Trim(f.Last4, "0123456789") != "") {
+		return errors.New("last4 must be exactly four digits")
+	}
+	if len(f.BIN) > 8 {
+		return errors.New("bin must be at most eight digits")
+	}
+	return nil
+}
+
+func (f TransactionFilter) matches(tx Transaction) bool {
+	switch {
+	case f.Provider != "" && f.Provider != tx.Provider,
+		f.Status != "" && f.Status != tx.Status,
+		f.BIN != "" && f.BIN != tx.BIN,
+		f.Last4 != "" && f.Last4 != tx.Last4,
+		f.ExpiryMonth != "" && f.ExpiryMonth != tx.ExpiryMonth,
+		f.ExpiryYear != "" && f.ExpiryYear != tx.ExpiryYear,
+		f.IdempotencyKey != "" && f.IdempotencyKey != tx.IdempotencyKey,
+		f.OrderID != "" && f.OrderID != tx.OrderID,
+		f.GatewayReference != "" && f.GatewayReference != tx.GatewayReference,
+		len(f.Fingerprints) > 0 && !contains(f.Fingerprints, tx.Fingerprint),
+		!f.Since.IsZero() && tx.CreatedAt.Before(f.Since),
+		!f.Until.IsZero() && !tx.CreatedAt.Before(f.Until),
+		f.Tag != "" && !contains(tx.Tags, strings.ToLower(f.Tag)) &&
+			!annotated(tx, func(a Annotation) bool { return contains(a.Tags, strings.ToLower(f.Tag)) }),
+		!hasMetadata(tx.Metadata, f.Metadata),
+		f.Note != "" && !annotated(tx, func(a Annotation) bool {
+			return strings.Contains(strings.ToLower(a.Note), strings.ToLower(f.Note))
+		}):
+		return false
+	}
+	return true
+}
+
+func hasMetadata(metadata, wanted map[string]string) bool {
+	for key, value := range wanted {
+		if got, ok := metadata[key]; !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+func annotated(tx Transaction, match func(a Annotation) bool) bool {
+	for _, annotation := range tx.Annotations {
+		if match(annotation) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v != "" && v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Transactions persists transaction records
+type Transactions interface {
+	Save(tx Transaction) error
+	Get(id string) (Transaction, error)
+	// Query returns matching transactions, oldest first
+	Query(filter TransactionFilter) ([]Transaction, error)
+	// CompareAndSave saves tx only while the stored record still has the
+	// status and update time of read, the record tx was derived from, and
+	// returns ErrTransactionChanged otherwise
+	CompareAndSave(read, tx Transaction) error
+}
+
+// unchanged reports whether stored is still the record read
+func unchanged(stored, read Transaction) bool {
+	return stored.Status == read.Status && stored.UpdatedAt.Equal(read.UpdatedAt)
+}
+
+// MemoryTransactions keeps transactions in a bounded in-memory store
+type MemoryTransactions struct {
+	mu      sync.Mutex // serializes writes, see CompareAndSave
+	records *Memory[string, Transaction]
+}
+
+func NewMemoryTransactions(opts MemoryOptions) *MemoryTransactions {
+	return &MemoryTransactions{records: NewMemory[string, Transaction](opts)}
+}
+
+func (m *MemoryTransactions) Save(tx Transaction) error {
+	if tx.ID == "" {
+		return errors.New("transaction id is required")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records.Put(tx.ID, tx)
+	return nil
+}
+
+func (m *MemoryTransactions) CompareAndSave(read, tx Transaction) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.records.Get(tx.ID)
+	if !ok {
+		return ErrTransactionNotFound
+	}
+	if !unchanged(stored, read) {
+		return ErrTransactionChanged
+	}
+	m.records.Put(tx.ID, tx)
+	return nil
+}
+
+func (m *MemoryTransactions) Get(id string) (Transaction, error) {
+	tx, ok := m.records.Get(id)
+	if !ok {
+		return Transaction{}, ErrTransactionNotFound
+	}
+	return tx, nil
+}
+
+func (m *MemoryTransactions) Query(filter TransactionFilter) ([]Transaction, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, err
+	}
+
+	var matches []Transaction
+	m.records.Range(func(id string, tx Transaction) bool {
+		if filter.matches(tx) {
+			matches = append(matches, tx)
+		}
+		return true
+	})
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].CreatedAt.Before(matches[j].CreatedAt) })
+	return matches, nil
+}
@@ -0,0 +1,92 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryTransactions_Query(t *testing.T) {
+	transactions := NewMemoryTransactions(MemoryOptions{})
+	start := time.Now()
+
+	transactions.Save(Transaction{ID: "b", Provider: "visa", Status: "APPROVED", Last4: "1111", CreatedAt: start.Add(time.Minute)})
+	transactions.Save(Transaction{ID: "a", Provider: "visa", Status: "DECLINED", Last4: "1111", CreatedAt: start})
+	transactions.Save(Transaction{ID: "c", Provider: "mastercard", Status: "APPROVED", Last4: "4444", CreatedAt: start.Add(2 * time.Minute)})
+
+	if err := transactions.Save(Transaction{}); err == nil {
+		t.Error("Expected transaction without id to be rejected")
+	}
+
+	matches, _ := transactions.Query(TransactionFilter{Provider: "visa"})
+	if len(matches) != 2 || matches[0].ID != "a" || matches[1].ID != "b" {
+		t.Errorf("Expected visa transactions oldest first, got %+v", matches)
+	}
+
+	matches, _ = transactions.Query(TransactionFilter{Status: "APPROVED", Since: start.Add(time.Minute), Until: start.Add(2 * time.Minute)})
+	if len(matches) != 1 || matches[0].ID != "b" {
+		t.Errorf("Expected time window to select b, got %+v", matches)
+	}
+
+	transactions.Save(Transaction{ID: "d", Last4: "1111", ExpiryMonth: "12", ExpiryYear: "2030", Fingerprint: "hmac-sha256:1:aa", CreatedAt: start})
+	matches, _ = transactions.Query(TransactionFilter{Last4: "1111", ExpiryMonth: "12", ExpiryYear: "2030"})
+	if len(matches) != 1 || matches[0].ID != "d" {
+		t.Errorf("Expected last4 and expiry to select d, got %+v", matches)
+	}
+	matches, _ = transactions.Query(TransactionFilter{Fingerprints: []string{"hmac-sha256:2:bb", "hmac-sha256:1:aa"}})
+	if len(matches) != 1 || matches[0].ID != "d" {
+		t.Errorf("Expected any matching fingerprint to select d, got %+v", matches)
+	}
+	if _, err := transactions.Query(TransactionFilter{Last4: "4111111111111111"}); err == nil {
+		t.Error("Expected a full card number as last4 to be rejected")
+	}
+
+	if _, err := transactions.Get("missing"); err != ErrTransactionNotFound {
+		t.Errorf("Expected ErrTransactionNotFound, got %v", err)
+	}
+}
+
+func TestMemoryTransactions_QueryAnnotations(t *testing.T) {
+	transactions := NewMemoryTransactions(MemoryOptions{})
+	transactions.Save(Transaction{ID: "a", Annotations: []Annotation{{Author: "ops", Note: "Customer says the charge is Unknown", Tags: []string{"dispute"}}}})
+	transactions.Save(Transaction{ID: "b", Annotations: []Annotation{{Author: "ops", Tags: []string{"vip"}}}})
+	transactions.Save(Transaction{ID: "c"})
+
+	matches, _ := transactions.Query(TransactionFilter{Tag: "Dispute"})
+	if len(matches) != 1 || matches[0].ID != "a" {
+		t.Errorf("Expected the tag to select a, got %+v", matches)
+	}
+	matches, _ = transactions.Query(TransactionFilter{Note: "charge is unknown"})
+	if len(matches) != 1 || matches[0].ID != "a" {
+		t.Errorf("Expected the note text to select a, got %+v", matches)
+	}
+	if matches, _ = transactions.Query(TransactionFilter{Tag: "vip", Note: "charge"}); len(matches) != 0 {
+		t.Errorf("Expected tag and note to both apply, got %+v", matches)
+	}
+}
+
+func TestMemoryTransactions_CompareAndSave(t *testing.T) {
+	transactions := NewMemoryTransactions(MemoryOptions{})
+	read := Transaction{ID: "tx", Status: "PENDING", UpdatedAt: time.Now()}
+	transactions.Save(read)
+
+	approved := read
+	approved.Status, approved.UpdatedAt = "APPROVED", read.UpdatedAt.Add(time.Second)
+	transactions.Save(approved)
+
+	expired := read
+	expired.Status = "EXPIRED"
+	if err := transactions.CompareAndSave(read, expired); !errors.Is(err, ErrTransactionChanged) {
+		t.Fatalf("Expected ErrTransactionChanged, got %v", err)
+	}
+	if tx, _ := transactions.Get("tx"); tx.Status != "APPROVED" {
+		t.Errorf("Expected the newer record to stand, got %s", tx.Status)
+	}
+
+	if err := transactions.CompareAndSave(approved, expired); err != nil {
+		t.Fatalf("Expected an unchanged record to be saved, got %v", err)
+	}
+	if err := transactions.CompareAndSave(read, Transaction{ID: "missing"}); !errors.Is(err, ErrTransactionNotFound) {
+		t.Errorf("Expected ErrTransactionNotFound, got %v", err)
+	}
+}
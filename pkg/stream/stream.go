@@ -0,0 +1,150 @@
+// Package stream serves bulk operations over chunked HTTP as NDJSON, one JSON
+// document per line, so clients read per-item results as they complete
+// instead of waiting for the whole batch.
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pgas/pkg/problem"
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/store"
+)
+
+const ContentType = "application/x-ndjson"
+
+// default and maximum number of batch items in flight
+const (
+	defaultConcurrency = 4
+	maxConcurrency     = 64
+)
+
+// Write sends every item as one NDJSON line, flushing after each so the
+// client sees it immediately. It stops early when the client goes away but
+// keeps draining items so their producer can finish.
+func Write[T any](w http.ResponseWriter, items <-chan T) error {
+	w.Header().Set("Content-Type", ContentType)
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+
+	var writeErr error
+	for item := range items {
+		if writeErr != nil {
+			continue
+		}
+		if writeErr = encoder.Encode(item); writeErr == nil && flusher != nil {
+			flusher.Flush()
+		}
+	}
+	return writeErr
+}
+
+// Read decodes an NDJSON stream, calling fn for every line
+func Read[T any](r io.Reader, fn func(item T) error) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var item T
+		if err := decoder.Decode(&item); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+}
+
+// PaymentsHandler accepts a JSON array of payment requests and streams a
+// processor.BatchResult line per payment. The concurrency query parameter
+// bounds payments in flight.
+func PaymentsHandler(p *processor.PaymentProcessor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var requests []providers.PaymentRequest
+		if !decodeBatch(w, r, &requests) {
+			return
+		}
+		Write(w, p.ProcessBatch(r.Context(), requests, concurrency(r)))
+	})
+}
+
+// RefundsHandler accepts a JSON array of refund requests and streams a
+// processor.BatchResult line per refund
+func RefundsHandler(p *processor.PaymentProcessor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var refunds []providers.RefundRequest
+		if !decodeBatch(w, r, &refunds) {
+			return
+		}
+		Write(w, p.RefundBatch(r.Context(), refunds, concurrency(r)))
+	})
+}
+
+// ExportHandler streams stored transactions, oldest first, filtered by the
+// provider, status, since and until (RFC 3339) query parameters
+func ExportHandler(transactions store.Transactions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			problem.Write(w, r, problem.New(http.StatusMethodNotAllowed, problem.CategoryMethodNotAllowed, "", "use GET"))
+			return
+		}
+
+		query := r.URL.Query()
+		filter := store.TransactionFilter{Provider: query.Get("provider"), Status: query.Get("status")}
+		for name, target := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+			if value := query.Get(name); value != "" {
+				parsed, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					problem.Write(w, r, problem.Malformed([]problem.InvalidParam{{Name: name, Rule: "rfc3339", Reason: "must be an RFC 3339 time"}}))
+					return
+				}
+				*target = parsed
+			}
+		}
+
+		matches, err := transactions.Query(filter)
+		if err != nil {
+			problem.Write(w, r, problem.Malformed([]problem.InvalidParam{{Reason: err.Error()}}))
+			return
+		}
+
+		items := make(chan store.Transaction)
+		go func() {
+			defer close(items)
+			for _, tx := range matches {
+				items <- tx
+			}
+		}()
+		Write(w, items)
+	})
+}
+
+func decodeBatch(w http.ResponseWriter, r *http.Request, target interface{}) bool {
+	if r.Method != http.MethodPost {
+		problem.Write(w, r, problem.New(http.StatusMethodNotAllowed, problem.CategoryMethodNotAllowed, "", "use POST"))
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(target); err != nil {
+		problem.Write(w, r, problem.Malformed([]problem.InvalidParam{{Rule: "json", Reason: "request body must be a JSON array: " + err.Error()}}))
+		return false
+	}
+	return true
+}
+
+func concurrency(r *http.Request) int {
+	value, err := strconv.Atoi(r.URL.Query().Get("concurrency"))
+	switch {
+	case err != nil || value < 1:
+		return defaultConcurrency
+	case value > maxConcurrency:
+		return maxConcurrency
+	}
+	return value
+}
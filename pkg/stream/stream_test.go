@@ -0,0 +1,76 @@
+package stream
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"pgas/pkg/processor"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/mastercard"
+	"pgas/pkg/store"
+)
+
+func TestPaymentsHandler(t *testing.T) {
+	p := processor.NewPaymentProcessor([]providers.Provider{mastercard.GetNewMasterCardPaymentProvider()})
+	body := `[
+		{"mode": "mastercard", "amount": 10, "currency": "USD", "card_number": "5555555555554444", "expiry_month": "12", "expiry_year": "2030", "cvv": "123"},
+		{"mode": "unknown", "amount": 10, "currency": "USD"}
+	]`
+
+	recorder := httptest.NewRecorder()
+	PaymentsHandler(p).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/payments/batch?concurrency=2", strings.NewReader(body)))
+
+	if recorder.Header().Get("Content-Type") != ContentType {
+		t.Errorf("Expected NDJSON content type, got %s", recorder.Header().Get("Content-Type"))
+	}
+	if !recorder.Flushed {
+		t.Error("Expected results to be flushed as they complete")
+	}
+
+	seen := make(map[int]processor.BatchResult)
+	err := Read(recorder.Body, func(result processor.BatchResult) error {
+		seen[result.Index] = result
+		return nil
+	})
+	if err != nil || len(seen) != 2 {
+		t.Fatalf("Expected two result lines, got %+v (%v)", seen, err)
+	}
+	if seen[1].Error == nil || seen[1].Error.ErrorCode != "INVALID_PROVIDER" {
+		t.Errorf("Expected the unknown provider to fail on its own line, got %+v", seen[1])
+	}
+
+	recorder = httptest.NewRecorder()
+	PaymentsHandler(p).ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/payments/batch", strings.NewReader("{")))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected malformed batch to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestExportHandler(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	now := time.Now()
+	transactions.Save(store.Transaction{ID: "a", Provider: "visa", CreatedAt: now.Add(-time.Hour)})
+	transactions.Save(store.Transaction{ID: "b", Provider: "visa", CreatedAt: now})
+	transactions.Save(store.Transaction{ID: "c", Provider: "mastercard", CreatedAt: now})
+
+	recorder := httptest.NewRecorder()
+	ExportHandler(transactions).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/transactions/export?provider=visa", nil))
+
+	var ids []string
+	Read(recorder.Body, func(tx store.Transaction) error {
+		ids = append(ids, tx.ID)
+		return nil
+	})
+	if strings.Join(ids, ",") != "a,b" {
+		t.Errorf("Expected visa transactions oldest first, got %v", ids)
+	}
+
+	recorder = httptest.NewRecorder()
+	ExportHandler(transactions).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/v1/transactions/export?since=yesterday", nil))
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected invalid since to be rejected, got %d", recorder.Code)
+	}
+}
@@ -0,0 +1,63 @@
+package threeds
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// HTTPAuthenticator is the reference Authenticator for 3DS servers exposing
+// a JSON API: the Request is POSTed to BaseURL + "/authenticate" and the
+// server answers with a Result.
+type HTTPAuthenticator struct {
+	BaseURL string
+	APIKey  string       // sent as a bearer token when set
+	Client  *http.Client // defaults to http.DefaultClient
+}
+
+func NewHTTPAuthenticator(baseURL, apiKey string) *HTTPAuthenticator {
+	return &HTTPAuthenticator{BaseURL: strings.TrimRight(baseURL, "/"), APIKey: apiKey}
+}
+
+func (a *HTTPAuthenticator) Authenticate(ctx context.Context, request Request) (*Result, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, a.BaseURL+"/authenticate", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	if a.APIKey != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+a.APIKey)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	response, err := client.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("3DS server answered %s", response.Status)
+	}
+
+	var result Result
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid 3DS server response: %v", err)
+	}
+	if result.Authentication.Status == "" {
+		return nil, fmt.Errorf("3DS server response has no transaction status")
+	}
+	return &result, nil
+}
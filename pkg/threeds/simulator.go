@@ -0,0 +1,96 @@
+package threeds
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"pgas/pkg/providers"
+)
+
+// Simulator is an offline Authenticator for tests and sandboxes. The outcome
+// is picked by the last four digits of the card number:
+//
+//	0001  challenge required
+//	0002  authentication failed
+//	0003  authentication unavailable
+//	0004  attempted
+//	other frictionless success
+//
+// Mandated challenges are issued for every card that does not fail. Example
+// cards are listed by sandbox.Scenarios.
+type Simulator struct {
+	Version string // reported protocol version, defaults to 2.2.0
+}
+
+func NewSimulator() *Simulator {
+	return &Simulator{Version: "2.2.0"}
+}
+
+func (s *Simulator) Authenticate(ctx context.Context, request Request) (*Result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	version := s.Version
+	if version == "" {
+		version = "2.2.0"
+	}
+
+	digest := sha256.Sum256([]byte(request.CardNumber + request.Currency))
+	dsTransactionID := hex.EncodeToString(digest[:16])
+	mastercard := strings.HasPrefix(request.CardNumber, "5") || strings.HasPrefix(request.CardNumber, "2")
+
+	result := &Result{Authentication: providers.Authentication{
+		Version:         version,
+		DSTransactionID: dsTransactionID,
+	}}
+	auth := &result.Authentication
+
+	outcome := suffix(request.CardNumber)
+	if request.ChallengeMandated && outcome != "0002" {
+		outcome = "0001"
+	}
+
+	switch outcome {
+	case "0001":
+		auth.Status = providers.AuthStatusChallenge
+		result.Challenge = &Challenge{
+			TransactionID: dsTransactionID,
+			URL:           "https://acs.simulator.invalid/challenge",
+			Payload:       base64.StdEncoding.EncodeToString([]byte(`{"threeDSServerTransID":"` + dsTransactionID + `"}`)),
+		}
+	case "0002":
+		auth.Status = providers.AuthStatusFailed
+		auth.ECI = eci(mastercard, "00", "07")
+	case "0003":
+		auth.Status = providers.AuthStatusUnavailable
+		auth.ECI = eci(mastercard, "00", "07")
+	case "0004":
+		auth.Status = providers.AuthStatusAttempted
+		auth.ECI = eci(mastercard, "01", "06")
+		auth.CAVV = base64.StdEncoding.EncodeToString(digest[:20])
+	default:
+		auth.Status = providers.AuthStatusAuthenticated
+		auth.ECI = eci(mastercard, "02", "05")
+		auth.CAVV = base64.StdEncoding.EncodeToString(digest[:20])
+	}
+
+	return result, nil
+}
+
+func eci(mastercard bool, mastercardValue, visaValue string) string {
+	if mastercard {
+		return mastercardValue
+	}
+	return visaValue
+}
+
+func suffix(cardNumber string) string {
+	if len(cardNumber) < 4 {
+		return cardNumber
+	}
+	return cardNumber[len(cardNumber)-4:]
+}
@@ -0,0 +1,54 @@
+// Package threeds abstracts 3-D Secure cardholder authentication so
+// merchants can plug in their existing 3DS server or MPI. The processor asks
+// the configured Authenticator before charging and passes the resulting
+// ECI/CAVV through to the provider.
+package threeds
+
+import (
+	"context"
+
+	"pgas/pkg/providers"
+)
+
+// Request is what a 3DS server needs to authenticate a payment
+type Request struct {
+	CardNumber  string  `json:"card_number"`
+	ExpiryMonth string  `json:"expiry_month"`
+	ExpiryYear  string  `json:"expiry_year"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	// Channel is "browser" or "app"
+	Channel string `json:"channel,omitempty"`
+	// NotificationURL receives the challenge result in browser flows
+	NotificationURL string `json:"notification_url,omitempty"`
+	// ChallengeMandated asks for a challenge instead of a frictionless flow,
+	// used to step up after an issuer soft decline
+	ChallengeMandated bool `json:"challenge_mandated,omitempty"`
+}
+
+// NewRequest builds an authentication request from a payment request
+func NewRequest(paymentRequest providers.PaymentRequest) Request {
+	return Request{
+		CardNumber:  paymentRequest.CardNumber,
+		ExpiryMonth: paymentRequest.ExpiryMonth,
+		ExpiryYear:  paymentRequest.ExpiryYear,
+		Amount:      paymentRequest.Amount,
+		Currency:    paymentRequest.Currency,
+		Channel:     "browser",
+	}
+}
+
+// Challenge is handed to the front end when the issuer wants to challenge
+// the cardholder
+type Challenge = providers.Challenge
+
+// Result is the authentication outcome
+type Result struct {
+	Authentication providers.Authentication `json:"authentication"`
+	Challenge      *Challenge               `json:"challenge,omitempty"`
+}
+
+// Authenticator runs 3-D Secure authentication for a payment
+type Authenticator interface {
+	Authenticate(ctx context.Context, request Request) (*Result, error)
+}
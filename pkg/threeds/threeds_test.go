@@ -0,0 +1,86 @@
+package threeds
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"pgas/pkg/providers"
+)
+
+func TestSimulator_Outcomes(t *testing.T) {
+	simulator := NewSimulator()
+	cases := map[string]string{
+		"4111111111111111": providers.AuthStatusAuthenticated,
+		"4000000000000001": providers.AuthStatusChallenge,
+		"4000000000000002": providers.AuthStatusFailed,
+		"4000000000000003": providers.AuthStatusUnavailable,
+		"5555555555550004": providers.AuthStatusAttempted,
+	}
+
+	for cardNumber, status := range cases {
+		result, err := simulator.Authenticate(context.Background(), Request{CardNumber: cardNumber, Currency: "USD"})
+		if err != nil {
+			t.Fatalf("Expected simulated authentication, got error: %v", err)
+		}
+		if result.Authentication.Status != status {
+			t.Errorf("Card %s: expected status %s, got %s", cardNumber, status, result.Authentication.Status)
+		}
+		if (status == providers.AuthStatusChallenge) != (result.Challenge != nil) {
+			t.Errorf("Card %s: expected challenge only for status C, got %+v", cardNumber, result.Challenge)
+		}
+	}
+
+	result, _ := simulator.Authenticate(context.Background(), Request{CardNumber: "5555555555554444"})
+	if result.Authentication.ECI != "02" {
+		t.Errorf("Expected mastercard ECI 02, got %s", result.Authentication.ECI)
+	}
+}
+
+func TestSimulator_MandatedChallenge(t *testing.T) {
+	simulator := NewSimulator()
+
+	result, _ := simulator.Authenticate(context.Background(), Request{CardNumber: "4111111111111111", ChallengeMandated: true})
+	if result.Authentication.Status != providers.AuthStatusChallenge || result.Challenge == nil {
+		t.Errorf("Expected a mandated challenge, got %+v", result)
+	}
+
+	result, _ = simulator.Authenticate(context.Background(), Request{CardNumber: "4000000000000002", ChallengeMandated: true})
+	if result.Authentication.Status != providers.AuthStatusFailed {
+		t.Errorf("Expected failing cards to fail without a challenge, got %+v", result)
+	}
+}
+
+func TestHTTPAuthenticator(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/authenticate" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+
+		var request Request
+		json.NewDecoder(r.Body).Decode(&request)
+
+		json.NewEncoder(w).Encode(Result{Authentication: providers.Authentication{
+			Status: providers.AuthStatusAuthenticated,
+			ECI:    "05",
+			CAVV:   "cavv-for-" + request.Currency,
+		}})
+	}))
+	defer server.Close()
+
+	result, err := NewHTTPAuthenticator(server.URL+"/", "key").Authenticate(context.Background(), Request{Currency: "EUR"})
+	if err != nil {
+		t.Fatalf("Expected authentication to succeed, got error: %v", err)
+	}
+
+	if result.Authentication.CAVV != "cavv-for-EUR" {
+		t.Errorf("Expected server result, got %+v", result.Authentication)
+	}
+
+	if _, err := NewHTTPAuthenticator(server.URL, "wrong").Authenticate(context.Background(), Request{}); err == nil {
+		t.Error("Expected error for rejected request")
+	}
+}
@@ -0,0 +1,187 @@
+package transcript
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"pgas/pkg/providers"
+	"pgas/pkg/redact"
+	"pgas/pkg/store"
+)
+
+// sanitized copy of the normalized request as sent to the provider
+type Request struct {
+	Mode        string  `json:"mode"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	CardNumber  string  `json:"card_number"`
+	ExpiryMonth string  `json:"expiry_month"`
+	ExpiryYear  string  `json:"expiry_year"`
+	CVVPresent  bool    `json:"cvv_present"`
+}
+
+// Entry is a single recorded request/response exchange with a provider
+type Entry struct {
+	TestCase   string      `json:"test_case"`
+	Provider   string      `json:"provider"`
+	Operation  string      `json:"operation"`
+	StartedAt  time.Time   `json:"started_at"`
+	DurationMs int64       `json:"duration_ms"`
+	Request    Request     `json:"request"`
+	Response   interface{} `json:"response,omitempty"`
+	Error      interface{} `json:"error,omitempty"`
+}
+
+// Transcript is the file format submitted as certification evidence
+type Transcript struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	Entries     []Entry   `json:"entries"`
+}
+
+// Recorder collects sanitized provider exchanges for a test run
+type Recorder struct {
+	mu       sync.Mutex
+	testCase string
+	entries  []Entry
+	policy   *redact.Policy
+	now      func() time.Time
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{policy: redact.DefaultPolicy(), now: time.Now}
+}
+
+// SetTestCase tags all subsequently recorded exchanges with the test case name
+func (r *Recorder) SetTestCase(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.testCase = name
+}
+
+// SetPolicy changes how recorded requests and responses are sanitized
+func (r *Recorder) SetPolicy(policy *redact.Policy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policy = policy
+}
+
+func (r *Recorder) record(entry Entry, request providers.PaymentRequest) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.TestCase = r.testCase
+	entry.Request = sanitizeRequest(r.policy, request)
+	entry.Response = r.policy.ApplyValue(redact.SinkRawResponses, entry.Response)
+	entry.Error = r.policy.ApplyValue(redact.SinkRawResponses, entry.Error)
+	r.entries = append(r.entries, entry)
+}
+
+// Entries returns a copy of everything recorded so far
+func (r *Recorder) Entries() []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Entry(nil), r.entries...)
+}
+
+// Reset discards recorded entries
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+	r.testCase = ""
+}
+
+// WriteTo writes the transcript as indented JSON
+func (r *Recorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(Transcript{
+		GeneratedAt: r.now().UTC(),
+		Entries:     r.Entries(),
+	}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(append(data, '\n'))
+	return int64(n), err
+}
+
+// Store saves the transcript as a blob, compressed by the blob store
+func (r *Recorder) Store(blobs *store.CompressedBlobs, key string) error {
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		return err
+	}
+	return blobs.Put(key, buf.Bytes())
+}
+
+// Load reads a transcript saved with Store
+func Load(blobs *store.CompressedBlobs, key string) (*Transcript, error) {
+	data, err := blobs.Read(key)
+	if err != nil {
+		return nil, err
+	}
+
+	var transcript Transcript
+	if err := json.Unmarshal(data, &transcript); err != nil {
+		return nil, err
+	}
+	return &transcript, nil
+}
+
+// WriteFile writes the transcript to the given path
+func (r *Recorder) WriteFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Wrap returns a provider that records every exchange of the wrapped provider
+func Wrap(provider providers.Provider, recorder *Recorder) providers.Provider {
+	return &recordingProvider{Provider: provider, recorder: recorder}
+}
+
+type recordingProvider struct {
+	providers.Provider
+	recorder *Recorder
+}
+
+func (p *recordingProvider) ProcessPayment(ctx context.Context, request providers.PaymentRequest) (interface{}, interface{}) {
+	startedAt := p.recorder.now()
+
+	response, providerError := p.Provider.ProcessPayment(ctx, request)
+
+	p.recorder.record(Entry{
+		Provider:   p.GetName(),
+		Operation:  "process_payment",
+		StartedAt:  startedAt.UTC(),
+		DurationMs: p.recorder.now().Sub(startedAt).Milliseconds(),
+		Response:   response,
+		Error:      providerError,
+	}, request)
+
+	return response, providerError
+}
+
+func sanitizeRequest(policy *redact.Policy, request providers.PaymentRequest) Request {
+	return Request{
+		Mode:        request.Mode,
+		Amount:      request.Amount,
+		Currency:    request.Currency,
+		CardNumber:  policy.Apply(redact.SinkRawResponses, redact.FieldCardNumber, request.CardNumber),
+		ExpiryMonth: policy.Apply(redact.SinkRawResponses, redact.FieldExpiry, request.ExpiryMonth),
+		ExpiryYear:  policy.Apply(redact.SinkRawResponses, redact.FieldExpiry, request.ExpiryYear),
+		CVVPresent:  request.CVV != "",
+	}
+}
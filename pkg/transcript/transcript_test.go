@@ -0,0 +1,108 @@
+package transcript
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pgas/pkg/compress"
+	"pgas/pkg/providers"
+	"pgas/pkg/providers/visa"
+	"pgas/pkg/store"
+)
+
+func TestRecorder_RecordsSanitizedExchanges(t *testing.T) {
+	recorder := NewRecorder()
+	provider := Wrap(visa.GetNewVisaPaymentProvider(), recorder)
+
+	if provider.GetName() != "visa" {
+		t.Errorf("Expected wrapped provider name 'visa', got: %s", provider.GetName())
+	}
+
+	recorder.SetTestCase("visa-approval-001")
+
+	request := providers.PaymentRequest{
+		Mode:        "visa",
+		Amount:      100.00,
+		Currency:    "USD",
+		CardNumber:  "4111111111111111",
+		ExpiryMonth: "12",
+		ExpiryYear:  "2030",
+		CVV:         "123",
+	}
+	provider.ProcessPayment(context.Background(), request)
+
+	entries := recorder.Entries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 recorded entry, got %d", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.TestCase != "visa-approval-001" {
+		t.Errorf("Expected test case tag, got: %s", entry.TestCase)
+	}
+
+	if entry.Request.CardNumber != "411111******1111" {
+		t.Errorf("Expected masked card number, got: %s", entry.Request.CardNumber)
+	}
+
+	if entry.Response == nil && entry.Error == nil {
+		t.Error("Expected response or error to be recorded")
+	}
+
+	var buf bytes.Buffer
+	if _, err := recorder.WriteTo(&buf); err != nil {
+		t.Fatalf("Expected transcript to be written, got error: %v", err)
+	}
+
+	if strings.Contains(buf.String(), "4111111111111111") || strings.Contains(buf.String(), `"123"`) {
+		t.Error("Expected transcript to contain no raw card data")
+	}
+
+	var transcript Transcript
+	if err := json.Unmarshal(buf.Bytes(), &transcript); err != nil {
+		t.Fatalf("Expected valid JSON transcript, got error: %v", err)
+	}
+	if len(transcript.Entries) != 1 {
+		t.Errorf("Expected 1 entry in transcript, got %d", len(transcript.Entries))
+	}
+}
+
+func TestRecorder_WriteFile(t *testing.T) {
+	recorder := NewRecorder()
+	path := filepath.Join(t.TempDir(), "transcript.json")
+
+	if err := recorder.WriteFile(path); err != nil {
+		t.Fatalf("Expected transcript file to be written, got error: %v", err)
+	}
+}
+
+func TestRecorder_Store(t *testing.T) {
+	recorder := NewRecorder()
+	provider := Wrap(visa.GetNewVisaPaymentProvider(), recorder)
+	recorder.SetTestCase("visa-approval-001")
+	provider.ProcessPayment(context.Background(), providers.PaymentRequest{
+		Mode: "visa", Amount: 10, Currency: "USD", CardNumber: "4111111111111111", ExpiryMonth: "12", ExpiryYear: "2030", CVV: "123",
+	})
+
+	backend := store.NewMemoryBlobs(store.MemoryOptions{})
+	blobs := store.NewCompressedBlobs(backend, compress.Gzip{})
+	if err := recorder.Store(blobs, "run-1"); err != nil {
+		t.Fatalf("Expected transcript to be stored, got error: %v", err)
+	}
+
+	if blob, _ := backend.Get("run-1"); blob.Encoding != "gzip" {
+		t.Errorf("Expected a gzip record, got encoding '%s'", blob.Encoding)
+	}
+
+	transcript, err := Load(blobs, "run-1")
+	if err != nil {
+		t.Fatalf("Expected transcript to load, got error: %v", err)
+	}
+	if len(transcript.Entries) != 1 || transcript.Entries[0].TestCase != "visa-approval-001" {
+		t.Errorf("Expected the recorded entry back, got %+v", transcript.Entries)
+	}
+}
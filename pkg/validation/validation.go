@@ -0,0 +1,146 @@
+// Package validation holds the card data checks every card provider runs
+// before calling its gateway, so obviously invalid numbers are rejected
+// locally instead of costing a gateway round trip.
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"pgas/pkg/bin"
+)
+
+// lengths of valid card numbers per network; numbers of other networks must
+// have 12 to 19 digits, the ISO/IEC 7812 range
+var networkLengths = map[string][]int{
+	bin.Visa:       {13, 16, 19},
+	bin.Mastercard: {16},
+	bin.Amex:       {15},
+	bin.Discover:   {16, 17, 18, 19},
+	bin.JCB:        {16, 17, 18, 19},
+	bin.UnionPay:   {16, 17, 18, 19},
+	bin.Diners:     {14, 15, 16, 17, 18, 19},
+}
+
+// Digits reports whether value is a non-empty string of ASCII digits
+func Digits(value string) bool {
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// Luhn reports whether a digit string passes the mod 10 checksum of card
+// numbers
+func Luhn(number string) bool {
+	if !Digits(number) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < len(number); i++ {
+		digit := int(number[len(number)-1-i] - '0')
+		if i%2 == 1 {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+	}
+	return sum%10 == 0
+}
+
+// Lengths returns the valid lengths of a network's card numbers
+func Lengths(network string) []int {
+	if lengths, ok := networkLengths[network]; ok {
+		return lengths
+	}
+	return []int{12, 13, 14, 15, 16, 17, 18, 19}
+}
+
+// ValidateCardNumber checks a PAN: digits only, a length valid for the
+// network its BIN belongs to, and the Luhn checksum
+func ValidateCardNumber(number string) error {
+	if number == "" {
+		return errors.New("card number is required")
+	}
+	if !Digits(number) {
+		return errors.New("card number must contain digits only")
+	}
+
+	network, _ := bin.Detect(number)
+	if !validLength(len(number), Lengths(network)) {
+		if network == "" {
+			return fmt.Errorf("card number must be between 12 and 19 digits, got %d", len(number))
+		}
+		return fmt.Errorf("%s card numbers must be %s digits, got %d", network, joinLengths(Lengths(network)), len(number))
+	}
+
+	if !Luhn(number) {
+		return errors.New("card number fails the Luhn check")
+	}
+	return nil
+}
+
+// ParseExpiry reads a card expiry: a month of one or two digits from 1 to
+// 12 and a year of two or four digits, two digit years being in this century
+func ParseExpiry(month, year string) (time.Month, int, error) {
+	if month == "" || year == "" {
+		return 0, 0, errors.New("expiry month and year are required")
+	}
+
+	m, err := strconv.Atoi(month)
+	if err != nil || len(month) > 2 || !Digits(month) || m < 1 || m > 12 {
+		return 0, 0, fmt.Errorf("expiry month '%s' must be 01 to 12", month)
+	}
+
+	y, err := strconv.Atoi(year)
+	if err != nil || (len(year) != 2 && len(year) != 4) || !Digits(year) {
+		return 0, 0, fmt.Errorf("expiry year '%s' must be 2 or 4 digits", year)
+	}
+	if len(year) == 2 {
+		y += 2000
+	}
+	return time.Month(m), y, nil
+}
+
+// ValidateExpiry checks that a card expiry is well formed and not in the
+// past. Cards are valid through the last day of their expiry month, so a
+// card expiring in the month of now is accepted.
+func ValidateExpiry(month, year string, now time.Time) error {
+	m, y, err := ParseExpiry(month, year)
+	if err != nil {
+		return err
+	}
+	if y < now.Year() || (y == now.Year() && m < now.Month()) {
+		return fmt.Errorf("card expired in %02d/%04d", int(m), y)
+	}
+	return nil
+}
+
+func validLength(length int, lengths []int) bool {
+	for _, valid := range lengths {
+		if length == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// joinLengths lists lengths as "16" or "13, 16 or 19"
+func joinLengths(lengths []int) string {
+	text := fmt.Sprint(lengths[0])
+	for i, length := range lengths[1:] {
+		if i == len(lengths)-2 {
+			text += fmt.Sprintf(" or %d", length)
+		} else {
+			text += fmt.Sprintf(", %d", length)
+		}
+	}
+	return text
+}
@@ -0,0 +1,99 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLuhn(t *testing.T) {
+	testCases := []struct {
+		number string
+		valid  bool
+	}{
+		{"4111111111111111", true},
+		{"5555555555554444", true},
+		{"378282246310005", true},
+		{"79927398713", true},
+		{"4111111111111112", false},
+		{"79927398710", false},
+		{"4111 1111 1111 1111", false},
+		{"", false},
+	}
+
+	for _, tc := range testCases {
+		if Luhn(tc.number) != tc.valid {
+			t.Errorf("%q: expected Luhn %v", tc.number, tc.valid)
+		}
+	}
+}
+
+func TestValidateCardNumber(t *testing.T) {
+	testCases := []struct {
+		number string
+		err    string // substring of the expected error, empty for valid numbers
+	}{
+		{"4111111111111111", ""},
+		{"4222222222222", ""},       // 13 digit Visa
+		{"4111111111111111110", ""}, // 19 digit Visa
+		{"5555555555554444", ""},
+		{"2223003122003222", ""},
+		{"378282246310005", ""},
+		{"6011111111111117", ""},
+		{"30569309025904", ""},
+		{"", "required"},
+		{"4111-1111-1111-1111", "digits only"},
+		{"41111111111111111", "visa card numbers must be 13, 16 or 19 digits"},
+		{"555555555555444", "mastercard card numbers must be 16 digits"},
+		{"3782822463100051", "amex card numbers must be 15 digits"},
+		{"99999999999", "between 12 and 19 digits"},
+		{"4111111111111112", "Luhn"},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateCardNumber(tc.number)
+		if tc.err == "" && err != nil {
+			t.Errorf("%q: expected valid, got: %v", tc.number, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%q: expected an error containing %q, got: %v", tc.number, tc.err, err)
+		}
+	}
+}
+
+func TestValidateExpiry(t *testing.T) {
+	now := time.Date(2026, time.June, 30, 23, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		month, year string
+		err         string // substring of the expected error, empty for valid dates
+	}{
+		{"06", "2026", ""}, // valid through the end of the month
+		{"07", "2026", ""},
+		{"01", "2027", ""},
+		{"6", "2026", ""},
+		{"12", "30", ""},
+		{"05", "2026", "expired in 05/2026"},
+		{"12", "2025", "expired in 12/2025"},
+		{"05", "26", "expired in 05/2026"},
+		{"00", "2027", "01 to 12"},
+		{"13", "2027", "01 to 12"},
+		{"99", "2027", "01 to 12"},
+		{"012", "2027", "01 to 12"},
+		{"-1", "2027", "01 to 12"},
+		{"06", "202", "2 or 4 digits"},
+		{"06", "20ab", "2 or 4 digits"},
+		{"", "2027", "required"},
+		{"06", "", "required"},
+	}
+
+	for _, tc := range testCases {
+		err := ValidateExpiry(tc.month, tc.year, now)
+		if tc.err == "" && err != nil {
+			t.Errorf("%s/%s: expected valid, got: %v", tc.month, tc.year, err)
+		}
+		if tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)) {
+			t.Errorf("%s/%s: expected an error containing %q, got: %v", tc.month, tc.year, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package webhooks
+
+import (
+	"errors"
+	"time"
+
+	"pgas/pkg/store"
+)
+
+// correlation keys, in the order they are tried
+const (
+	KeyGatewayRef     = "gateway_ref"
+	KeyIdempotencyKey = "idempotency_key"
+	KeyOrderID        = "order_id"
+	KeyCardAmountTime = "card_amount_time"
+)
+
+// confidence of a match on each key when it finds a single transaction
+var keyConfidence = map[string]float64{
+	KeyGatewayRef:     1.0,
+	KeyIdempotencyKey: 0.95,
+	KeyOrderID:        0.9,
+	KeyCardAmountTime: 0.6,
+}
+
+var ErrNoCorrelation = errors.New("webhook matches no stored transaction")
+
+// Reference is what an inbound event tells about its payment. Gateways
+// differ in what they send back; zero fields are skipped.
+type Reference struct {
+	Provider       string `json:"provider"`
+	GatewayRef     string `json:"gateway_ref,omitempty"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	OrderID        string `json:"order_id,omitempty"`
+	// Amount, Currency and the card fingerprints, one per key version, are
+	// matched against payments made around Time
+	Amount       float64   `json:"amount,omitempty"`
+	Currency     string    `json:"currency,omitempty"`
+	Fingerprints []string  `json:"fingerprints,omitempty"`
+	Time         time.Time `json:"time,omitempty"`
+}
+
+// Correlation is the transaction an event was matched to
+type Correlation struct {
+	Transaction store.Transaction `json:"transaction"`
+	Key         string            `json:"key"` // the correlation key that matched
+	// Confidence is within [0, 1]; it drops when the key matches several
+	// transactions and, for card matches, with the distance in time
+	Confidence float64 `json:"confidence"`
+	Candidates int     `json:"candidates"`
+}
+
+// Correlator matches inbound events to stored transactions by the first
+// key of the event that finds one: gateway reference, idempotency key,
+// order id, then card, amount and time
+type Correlator struct {
+	transactions store.Transactions
+	// Window is how far from the event time card matches are searched
+	Window time.Duration
+}
+
+func NewCorrelator(transactions store.Transactions, window time.Duration) *Correlator {
+	return &Correlator{transactions: transactions, Window: window}
+}
+
+// Correlate returns the best match for ref, or ErrNoCorrelation
+func (c *Correlator) Correlate(ref Reference) (Correlation, error) {
+	keyed := []struct {
+		key    string
+		filter store.TransactionFilter
+	}{
+		{KeyGatewayRef, store.TransactionFilter{Provider: ref.Provider, GatewayReference: ref.GatewayRef}},
+		{KeyIdempotencyKey, store.TransactionFilter{Provider: ref.Provider, IdempotencyKey: ref.IdempotencyKey}},
+		{KeyOrderID, store.TransactionFilter{Provider: ref.Provider, OrderID: ref.OrderID}},
+	}
+	for _, k := range keyed {
+		if k.filter.GatewayReference == "" && k.filter.IdempotencyKey == "" && k.filter.OrderID == "" {
+			continue
+		}
+		matches, err := c.transactions.Query(k.filter)
+		if err != nil {
+			return Correlation{}, err
+		}
+		if len(matches) > 0 {
+			// retries share the merchant references and some gateways
+			// reuse theirs, the latest payment is the one reported on
+			return Correlation{
+				Transaction: matches[len(matches)-1],
+				Key:         k.key,
+				Confidence:  keyConfidence[k.key] / float64(len(matches)),
+				Candidates:  len(matches),
+			}, nil
+		}
+	}
+
+	if len(ref.Fingerprints) > 0 && ref.Amount > 0 && !ref.Time.IsZero() && c.Window > 0 {
+		return c.correlateCard(ref)
+	}
+	return Correlation{}, ErrNoCorrelation
+}
+
+func (c *Correlator) correlateCard(ref Reference) (Correlation, error) {
+	matches, err := c.transactions.Query(store.TransactionFilter{
+		Provider:     ref.Provider,
+		Fingerprints: ref.Fingerprints,
+		Since:        ref.Time.Add(-c.Window),
+		Until:        ref.Time.Add(c.Window),
+	})
+	if err != nil {
+		return Correlation{}, err
+	}
+
+	var best store.Transaction
+	var bestDistance time.Duration
+	candidates := 0
+	for _, tx := range matches {
+		if tx.Amount != ref.Amount || (ref.Currency != "" && tx.Currency != ref.Currency) {
+			continue
+		}
+		distance := ref.Time.Sub(tx.CreatedAt).Abs()
+		if candidates == 0 || distance < bestDistance {
+			best, bestDistance = tx, distance
+		}
+		candidates++
+	}
+	if candidates == 0 {
+		return Correlation{}, ErrNoCorrelation
+	}
+
+	closeness := 1 - float64(bestDistance)/float64(c.Window)
+	return Correlation{
+		Transaction: best,
+		Key:         KeyCardAmountTime,
+		Confidence:  keyConfidence[KeyCardAmountTime] * closeness / float64(candidates),
+		Candidates:  candidates,
+	}, nil
+}
@@ -0,0 +1,82 @@
+package webhooks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"pgas/pkg/store"
+)
+
+func TestCorrelator_Keys(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, tx := range []store.Transaction{
+		{ID: "tx_1", Provider: "visa", Amount: 25, Currency: "USD", IdempotencyKey: "key_1", OrderID: "order_1", Fingerprint: "fp_a", CreatedAt: base},
+		{ID: "tx_2", Provider: "visa", GatewayReference: "gw_2", Amount: 25, Currency: "USD", OrderID: "order_2", Fingerprint: "fp_a", CreatedAt: base.Add(time.Minute)},
+		{ID: "tx_3", Provider: "visa", Amount: 25, Currency: "USD", OrderID: "order_2", Fingerprint: "fp_b", CreatedAt: base.Add(2 * time.Minute)},
+	} {
+		transactions.Save(tx)
+	}
+	correlator := NewCorrelator(transactions, 10*time.Minute)
+
+	tests := []struct {
+		name       string
+		ref        Reference
+		wantID     string
+		wantKey    string
+		confidence float64
+	}{
+		{"gateway reference", Reference{Provider: "visa", GatewayRef: "gw_2", OrderID: "order_1"}, "tx_2", KeyGatewayRef, 1},
+		{"unknown gateway reference falls through", Reference{Provider: "visa", GatewayRef: "gw_9", IdempotencyKey: "key_1"}, "tx_1", KeyIdempotencyKey, 0.95},
+		{"order with retries picks the latest", Reference{Provider: "visa", OrderID: "order_2"}, "tx_3", KeyOrderID, 0.45},
+		{"card, amount and time", Reference{Provider: "visa", Amount: 25, Currency: "USD", Fingerprints: []string{"fp_b"}, Time: base.Add(2 * time.Minute)}, "tx_3", KeyCardAmountTime, 0.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			correlation, err := correlator.Correlate(tt.ref)
+			if err != nil {
+				t.Fatalf("Expected a correlation, got error: %v", err)
+			}
+			if correlation.Transaction.ID != tt.wantID || correlation.Key != tt.wantKey {
+				t.Errorf("Expected %s by %s, got %s by %s", tt.wantID, tt.wantKey, correlation.Transaction.ID, correlation.Key)
+			}
+			if diff := correlation.Confidence - tt.confidence; diff > 1e-9 || diff < -1e-9 {
+				t.Errorf("Expected confidence %.2f, got %.4f", tt.confidence, correlation.Confidence)
+			}
+		})
+	}
+}
+
+func TestCorrelator_CardMatchConfidence(t *testing.T) {
+	transactions := store.NewMemoryTransactions(store.MemoryOptions{})
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	transactions.Save(store.Transaction{ID: "tx_1", Provider: "visa", Amount: 10, Currency: "EUR", Fingerprint: "fp_a", CreatedAt: base})
+	transactions.Save(store.Transaction{ID: "tx_2", Provider: "visa", Amount: 10, Currency: "EUR", Fingerprint: "fp_a", CreatedAt: base.Add(4 * time.Minute)})
+	transactions.Save(store.Transaction{ID: "tx_3", Provider: "visa", Amount: 99, Currency: "EUR", Fingerprint: "fp_a", CreatedAt: base.Add(5 * time.Minute)})
+
+	correlator := NewCorrelator(transactions, 10*time.Minute)
+	ref := Reference{Provider: "visa", Amount: 10, Currency: "EUR", Fingerprints: []string{"fp_old", "fp_a"}, Time: base.Add(5 * time.Minute)}
+
+	correlation, err := correlator.Correlate(ref)
+	if err != nil {
+		t.Fatalf("Expected a correlation, got error: %v", err)
+	}
+	if correlation.Transaction.ID != "tx_2" || correlation.Candidates != 2 {
+		t.Errorf("Expected the closest of 2 candidates, got %s of %d", correlation.Transaction.ID, correlation.Candidates)
+	}
+	// one minute off in a ten minute window, shared by two payments
+	if want := 0.6 * 0.9 / 2; correlation.Confidence < want-1e-9 || correlation.Confidence > want+1e-9 {
+		t.Errorf("Expected confidence %.3f, got %.4f", want, correlation.Confidence)
+	}
+
+	ref.Time = base.Add(time.Hour)
+	if _, err := correlator.Correlate(ref); !errors.Is(err, ErrNoCorrelation) {
+		t.Errorf("Expected no correlation outside the window, got: %v", err)
+	}
+	if _, err := correlator.Correlate(Reference{Provider: "mastercard", GatewayRef: "tx_1"}); !errors.Is(err, ErrNoCorrelation) {
+		t.Errorf("Expected gateway references to be scoped per provider, got: %v", err)
+	}
+}
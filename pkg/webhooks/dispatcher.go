@@ -0,0 +1,132 @@
+// Package webhooks receives asynchronous notifications from payment
+// gateways and hands each logical event to its handler exactly once, even
+// though gateways resend deliveries they consider unacknowledged.
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"pgas/pkg/store"
+)
+
+// Delivery is one webhook request received from a gateway. Resends of the
+// same event carry the same ID.
+type Delivery struct {
+	ID         string    `json:"id"`
+	Provider   string    `json:"provider"`
+	Type       string    `json:"type"`
+	Payload    []byte    `json:"payload,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+// Handler processes a delivery; an error makes the dispatcher forget it so
+// the gateway's resend is processed again
+type Handler func(ctx context.Context, delivery Delivery) error
+
+var ErrMissingID = errors.New("webhook delivery has no id")
+
+// Dedupe remembers delivery keys within a sliding window: every sighting
+// restarts the window of its key, so a gateway resending for longer than
+// the window still cannot get through while it keeps resending
+type Dedupe interface {
+	// Seen records key and reports whether it was already within the window
+	Seen(key string) bool
+	Forget(key string)
+}
+
+// MemoryDedupe is a Dedupe for a single instance
+type MemoryDedupe struct {
+	mu   sync.Mutex
+	keys *store.Memory[string, struct{}]
+}
+
+// NewMemoryDedupe keeps keys for opts.TTL, the dedupe window
+func NewMemoryDedupe(opts store.MemoryOptions) *MemoryDedupe {
+	return &MemoryDedupe{keys: store.NewMemory[string, struct{}](opts)}
+}
+
+func (m *MemoryDedupe) Seen(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, seen := m.keys.Get(key)
+	m.keys.Put(key, struct{}{})
+	return seen
+}
+
+func (m *MemoryDedupe) Forget(key string) {
+	m.keys.Delete(key)
+}
+
+// DispatchStats counts deliveries by outcome
+type DispatchStats struct {
+	Delivered  uint64 `json:"delivered"`
+	Duplicates uint64 `json:"duplicates"`
+	Failed     uint64 `json:"failed"`
+	Ignored    uint64 `json:"ignored"` // no handler for the event type
+}
+
+// Dispatcher routes deliveries to the handler registered for their type
+type Dispatcher struct {
+	dedupe   Dedupe
+	handlers map[string]Handler
+
+	delivered  atomic.Uint64
+	duplicates atomic.Uint64
+	failed     atomic.Uint64
+	ignored    atomic.Uint64
+}
+
+func NewDispatcher(dedupe Dedupe) *Dispatcher {
+	return &Dispatcher{dedupe: dedupe, handlers: make(map[string]Handler)}
+}
+
+// On registers the handler for an event type, replacing any earlier one.
+// Handlers must be registered before deliveries are dispatched.
+func (d *Dispatcher) On(eventType string, handler Handler) {
+	d.handlers[eventType] = handler
+}
+
+// Dispatch runs the handler of a delivery unless the same delivery was
+// already handled within the dedupe window. Duplicates are acknowledged
+// without error so the gateway stops resending.
+func (d *Dispatcher) Dispatch(ctx context.Context, delivery Delivery) (duplicate bool, err error) {
+	if delivery.ID == "" {
+		return false, ErrMissingID
+	}
+
+	// delivery ids are only unique per gateway
+	key := delivery.Provider + ":" + delivery.ID
+	if d.dedupe.Seen(key) {
+		d.duplicates.Add(1)
+		return true, nil
+	}
+
+	handler, ok := d.handlers[delivery.Type]
+	if !ok {
+		d.ignored.Add(1)
+		return false, nil
+	}
+
+	if err := handler(ctx, delivery); err != nil {
+		d.dedupe.Forget(key)
+		d.failed.Add(1)
+		return false, err
+	}
+
+	d.delivered.Add(1)
+	return false, nil
+}
+
+func (d *Dispatcher) Stats() DispatchStats {
+	return DispatchStats{
+		Delivered:  d.delivered.Load(),
+		Duplicates: d.duplicates.Load(),
+		Failed:     d.failed.Load(),
+		Ignored:    d.ignored.Load(),
+	}
+}
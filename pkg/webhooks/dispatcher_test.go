@@ -0,0 +1,98 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"pgas/pkg/store"
+)
+
+func TestDispatcher_Dedupe(t *testing.T) {
+	dispatcher := NewDispatcher(NewMemoryDedupe(store.MemoryOptions{TTL: time.Hour}))
+
+	var mu sync.Mutex
+	handled := 0
+	dispatcher.On("payment.captured", func(ctx context.Context, delivery Delivery) error {
+		mu.Lock()
+		handled++
+		mu.Unlock()
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dispatcher.Dispatch(context.Background(), Delivery{ID: "evt_1", Provider: "visa", Type: "payment.captured"})
+		}()
+	}
+	wg.Wait()
+
+	// the same id from another gateway is another event
+	if duplicate, _ := dispatcher.Dispatch(context.Background(), Delivery{ID: "evt_1", Provider: "mastercard", Type: "payment.captured"}); duplicate {
+		t.Error("Expected delivery ids to be scoped per provider")
+	}
+
+	if handled != 2 {
+		t.Errorf("Expected each event handled once, got %d", handled)
+	}
+	if stats := dispatcher.Stats(); stats.Delivered != 2 || stats.Duplicates != 9 {
+		t.Errorf("Expected 2 delivered and 9 duplicates, got %+v", stats)
+	}
+
+	if _, err := dispatcher.Dispatch(context.Background(), Delivery{Provider: "visa"}); !errors.Is(err, ErrMissingID) {
+		t.Errorf("Expected deliveries without id to be rejected, got %v", err)
+	}
+}
+
+func TestDispatcher_FailedDeliveryIsRetried(t *testing.T) {
+	dispatcher := NewDispatcher(NewMemoryDedupe(store.MemoryOptions{TTL: time.Hour}))
+
+	attempts := 0
+	dispatcher.On("refund.settled", func(ctx context.Context, delivery Delivery) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("ledger unavailable")
+		}
+		return nil
+	})
+
+	delivery := Delivery{ID: "evt_2", Provider: "visa", Type: "refund.settled"}
+	if _, err := dispatcher.Dispatch(context.Background(), delivery); err == nil {
+		t.Fatal("Expected the handler error to be returned")
+	}
+	if duplicate, err := dispatcher.Dispatch(context.Background(), delivery); duplicate || err != nil {
+		t.Errorf("Expected the resend to be handled again, got duplicate=%v err=%v", duplicate, err)
+	}
+
+	dispatcher.Dispatch(context.Background(), Delivery{ID: "evt_3", Provider: "visa", Type: "unknown"})
+	if stats := dispatcher.Stats(); stats.Failed != 1 || stats.Delivered != 1 || stats.Ignored != 1 {
+		t.Errorf("Expected one failed, one delivered and one ignored, got %+v", stats)
+	}
+}
+
+func TestMemoryDedupe_SlidingWindow(t *testing.T) {
+	now := time.Unix(0, 0)
+	dedupe := NewMemoryDedupe(store.MemoryOptions{TTL: time.Minute, Now: func() time.Time { return now }})
+
+	dedupe.Seen("a")
+	now = now.Add(50 * time.Second)
+	if !dedupe.Seen("a") {
+		t.Error("Expected a resend within the window to be a duplicate")
+	}
+
+	// the resend restarted the window
+	now = now.Add(50 * time.Second)
+	if !dedupe.Seen("a") {
+		t.Error("Expected the window to slide with every resend")
+	}
+
+	now = now.Add(2 * time.Minute)
+	if dedupe.Seen("a") {
+		t.Error("Expected the key to expire once resends stop")
+	}
+}
@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// normalized types of payment events gateways notify about
+const (
+	EventApproved   = "approved"   // a pending payment was approved
+	EventDeclined   = "declined"   // a pending payment was declined
+	EventCaptured   = "captured"   // funds of an authorization were captured
+	EventRefunded   = "refunded"   // funds were returned to the cardholder
+	EventChargeback = "chargeback" // the cardholder disputed the payment
+)
+
+var (
+	ErrInvalidSignature = errors.New("webhook signature is invalid")
+	// ErrUnsupportedEvent is returned by parsers for notifications pgas does
+	// not act on; they are acknowledged and ignored
+	ErrUnsupportedEvent = errors.New("webhook event type is not supported")
+)
+
+// PaymentEvent is a gateway notification normalized across providers
+type PaymentEvent struct {
+	// ID is the gateway's notification id, resends carry the same one
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"`
+	Type      string    `json:"type"`      // EventApproved etc.
+	Reference Reference `json:"reference"` // identifies the payment
+	Amount    float64   `json:"amount,omitempty"`
+	Currency  string    `json:"currency,omitempty"`
+	// Reason is the normalized reason of declines and the network's dispute
+	// reason code of chargebacks
+	Reason     string    `json:"reason,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// WebhookParser authenticates and normalizes the webhook requests of one
+// provider
+type WebhookParser interface {
+	// Provider names the provider whose notifications are parsed
+	Provider() string
+	// Verify checks the request's signature over the raw body, failing with
+	// ErrInvalidSignature
+	Verify(header http.Header, body []byte) error
+	// Parse normalizes a verified body into a payment event, failing with
+	// ErrUnsupportedEvent for notifications pgas does not act on
+	Parse(body []byte) (PaymentEvent, error)
+}
+
+// VerifySHA256 checks mac, the decoded signature of a gateway, against the
+// HMAC-SHA256 of body in constant time
+func VerifySHA256(secret, body, mac []byte) error {
+	h := hmac.New(sha256.New, secret)
+	h.Write(body)
+	if len(mac) == 0 || !hmac.Equal(h.Sum(nil), mac) {
+		return ErrInvalidSignature
+	}
+	return nil
+}